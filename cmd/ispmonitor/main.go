@@ -7,14 +7,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/alerting"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/configbackup"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/flow"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/maintenance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/notify"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/radius"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/simulator"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/snmptrap"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/syslog"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -33,42 +45,160 @@ func main() {
 
 	log.Println("Database connection established")
 
+	// Initialize the credential vault used to encrypt router credentials
+	secrets, err := vault.New(cfg.Vault)
+	if err != nil {
+		log.Fatalf("Failed to initialize credential vault: %v", err)
+	}
+	if secrets.Enabled() {
+		log.Printf("Credential vault enabled (active key %s)", secrets.ActiveKeyID())
+	} else {
+		log.Println("WARNING: VAULT_MASTER_KEY is not set; router credentials are stored in plaintext")
+	}
+
+	// Maintenance windows, shared by the poller, trap receiver and API
+	windows := maintenance.NewChecker(db)
+
+	// Alert rules, evaluated against the poller's results and managed by the API
+	rules := alerting.NewEngine(db, windows)
+
+	// Notification dispatcher, delivering alerts and the API's test sends
+	notifier := notify.NewDispatcher(db, cfg.Notify, secrets)
+
+	// MIB modules naming OIDs in vendor profiles, traps and the API
+	mibs, err := mib.Load(cfg.MIB.Dir)
+	if err != nil {
+		log.Fatalf("Failed to load MIBs: %v", err)
+	}
+	for _, warning := range mibs.Warnings() {
+		log.Printf("Warning: MIB %s", warning)
+	}
+	if cfg.MIB.Dir != "" {
+		log.Printf("Loaded %d MIB modules from %s", len(mibs.Modules()), cfg.MIB.Dir)
+	}
+
 	// Initialize router poller service
-	pollerService := poller.NewService(db, cfg.Poller)
+	pollerService, err := poller.NewEnhancedService(db, cfg.Poller, cfg.Sink, secrets, windows, rules, mibs)
+	if err != nil {
+		log.Fatalf("Failed to create poller service: %v", err)
+	}
 
-	// Start poller in background
+	// Background services run until ctx is cancelled on shutdown and are
+	// waited for, so they can store what they still hold
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	var services sync.WaitGroup
+	goService := func(start func()) {
+		services.Add(1)
+		go func() {
+			defer services.Done()
+			start()
+		}()
+	}
 
-	go func() {
+	// Load maintenance windows before polling starts
+	if err := windows.Refresh(ctx); err != nil {
+		log.Printf("Error loading maintenance windows: %v", err)
+	}
+	goService(func() { windows.Start(ctx) })
+
+	// Load alert rules before polling starts
+	if err := rules.Refresh(ctx); err != nil {
+		log.Printf("Error loading alert rules: %v", err)
+	}
+	goService(func() { rules.Start(ctx) })
+
+	// Start notification delivery if enabled
+	if cfg.Notify.Enabled {
+		goService(func() { notifier.Start(ctx) })
+	}
+
+	// Start poller in background
+	goService(func() {
 		log.Println("Starting router poller service...")
 		if err := pollerService.Start(ctx); err != nil {
 			log.Printf("Poller service error: %v", err)
 		}
-	}()
+	})
+
+	// Start configuration backups if enabled
+	if cfg.Backup.Enabled {
+		backupService := configbackup.NewService(db, cfg.Backup, secrets)
+		goService(func() {
+			log.Println("Starting config backup service...")
+			if err := backupService.Start(ctx); err != nil {
+				log.Printf("Config backup service error: %v", err)
+			}
+		})
+	}
+
+	// Start syslog receiver if enabled
+	if cfg.Syslog.Enabled {
+		syslogReceiver := syslog.NewReceiver(db, cfg.Syslog)
+		goService(func() {
+			log.Println("Starting syslog receiver...")
+			if err := syslogReceiver.Start(ctx); err != nil {
+				log.Printf("Syslog receiver error: %v", err)
+			}
+		})
+	}
+
+	// Start SNMP trap receiver if enabled
+	if cfg.SNMPTrap.Enabled {
+		trapReceiver := snmptrap.NewReceiver(db, cfg.SNMPTrap, secrets, windows, mibs)
+		goService(func() {
+			log.Println("Starting SNMP trap receiver...")
+			if err := trapReceiver.Start(ctx); err != nil {
+				log.Printf("SNMP trap receiver error: %v", err)
+			}
+		})
+	}
+
+	// Start flow collector if enabled
+	if cfg.Flow.Enabled {
+		flowCollector := flow.NewCollector(db, cfg.Flow)
+		goService(func() {
+			log.Println("Starting flow collector...")
+			if err := flowCollector.Start(ctx); err != nil {
+				log.Printf("Flow collector error: %v", err)
+			}
+		})
+	}
+
+	// Start RADIUS accounting server if enabled
+	if cfg.RADIUS.Enabled {
+		radiusServer := radius.NewServer(db, cfg.RADIUS, secrets)
+		goService(func() {
+			log.Println("Starting RADIUS accounting server...")
+			if err := radiusServer.Start(ctx); err != nil {
+				log.Printf("RADIUS accounting server error: %v", err)
+			}
+		})
+	}
 
 	// Start simulator if enabled (demo / development mode)
 	deployCfg := config.LoadDeployment()
 	if deployCfg.EnableSimulator {
 		simCfg := simulator.DefaultConfig()
 		svc := simulator.NewService(db.DB, simCfg)
-		go func() {
+		goService(func() {
 			log.Printf("Starting simulator: %s", svc)
 			if err := svc.Start(ctx); err != nil {
 				log.Printf("Simulator error: %v", err)
 			}
-		}()
+		})
 	}
 
 	// Initialize API server
-	apiServer := api.NewServer(db, cfg.API, cfg.Auth)
+	apiServer := api.NewServer(db, cfg.API, cfg.Auth, cfg.Firmware, cfg.Poller, pollerService, secrets, windows, rules, mibs, notifier)
 
-	// Start HTTP server
+	// Start HTTP server; responses to on-demand polls may take up to the
+	// poll timeout
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.API.Port),
 		Handler:      apiServer.Handler(),
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: max(15*time.Second, time.Duration(cfg.Poller.PollNowTimeoutSeconds+5)*time.Second),
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -80,6 +210,29 @@ func main() {
 		}
 	}()
 
+	// Start Prometheus metrics listener: self-metrics, plus polled device
+	// values when the prometheus result sink is configured
+	var metricsSrv *http.Server
+	if cfg.Metrics.Enabled {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		if exporter := pollerService.DeviceExporter(); exporter != nil {
+			mux.Handle("/metrics/devices", exporter.Handler())
+		}
+		metricsSrv = &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Metrics.Port),
+			Handler:      mux,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		}
+		go func() {
+			log.Printf("Starting metrics listener on port %d...", cfg.Metrics.Port)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Metrics listener error: %v", err)
+			}
+		}()
+	}
+
 	// Give the server a moment to start listening
 	time.Sleep(100 * time.Millisecond)
 	log.Printf("API server is ready to accept requests on http://localhost:%d", cfg.API.Port)
@@ -98,6 +251,22 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(shutdownCtx)
+	}
+
+	// Stop the background services and wait for them to flush
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		services.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		log.Println("Background services did not stop in time")
+	}
 
 	log.Println("Server exited")
 }
@@ -0,0 +1,172 @@
+// Command vault manages the master keys router credentials are encrypted
+// with. It never prints credentials, only counts per key.
+//
+// Usage:
+//
+//	go run ./cmd/vault generate-key     print a new random master key
+//	go run ./cmd/vault status           count stored values per key
+//	go run ./cmd/vault rotate [-dry-run] encrypt every value with the master key
+//
+// To rotate, set VAULT_MASTER_KEY to a new key and VAULT_PREVIOUS_KEYS to the
+// old one, restart the services, run rotate, then drop the old key. Running
+// rotate for the first time encrypts credentials stored in plaintext.
+//
+// Environment variables:
+//
+//	DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME, DB_SSLMODE — database connection
+//	VAULT_MASTER_KEY       — base64 AES-256 key values are encrypted with
+//	VAULT_MASTER_KEY_FILE  — file holding the master key
+//	VAULT_PREVIOUS_KEYS    — comma separated keys values may still be encrypted with
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("vault: ")
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "generate-key":
+		key, err := vault.GenerateKey()
+		if err != nil {
+			log.Fatalf("Failed to generate key: %v", err)
+		}
+		fmt.Println(key)
+	case "status":
+		status()
+	case "rotate":
+		flags := flag.NewFlagSet("rotate", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "report what would be rotated without writing")
+		flags.Parse(os.Args[2:])
+		rotate(*dryRun)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: vault generate-key | status | rotate [-dry-run]")
+	os.Exit(2)
+}
+
+func status() {
+	v := openVault()
+	db := connect()
+	defer db.Close()
+
+	statuses, err := vault.Status(context.Background(), db.DB)
+	if err != nil {
+		log.Fatalf("Failed to read credentials: %v", err)
+	}
+
+	if v.Enabled() {
+		fmt.Printf("Active key: %s\n", v.ActiveKeyID())
+	} else {
+		fmt.Println("Active key: none, new credentials are stored in plaintext")
+	}
+	for _, s := range statuses {
+		ids := make([]string, 0, len(s.Values))
+		for id := range s.Values {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		fmt.Printf("%s:\n", s.Table)
+		if len(ids) == 0 {
+			fmt.Println("  no credentials")
+		}
+		for _, id := range ids {
+			fmt.Printf("  %-12s %d\n", keyName(id), s.Values[id])
+		}
+	}
+}
+
+func rotate(dryRun bool) {
+	v := openVault()
+	if !v.Enabled() {
+		log.Fatal("VAULT_MASTER_KEY or VAULT_MASTER_KEY_FILE must be set to rotate")
+	}
+	db := connect()
+	defer db.Close()
+
+	results, err := vault.Rotate(context.Background(), db.DB, v, dryRun)
+	for _, r := range results {
+		verb := "re-encrypted"
+		if dryRun {
+			verb = "would re-encrypt"
+		}
+		fmt.Printf("%s: %s %d values in %d rows with key %s\n", r.Table, verb, r.Values, r.Rows, v.ActiveKeyID())
+	}
+	if err != nil {
+		log.Fatalf("Rotation failed: %v", err)
+	}
+}
+
+func openVault() *vault.Vault {
+	v, err := vault.New(config.VaultConfig{
+		MasterKey:     os.Getenv("VAULT_MASTER_KEY"),
+		MasterKeyFile: os.Getenv("VAULT_MASTER_KEY_FILE"),
+		PreviousKeys:  os.Getenv("VAULT_PREVIOUS_KEYS"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize vault: %v", err)
+	}
+	return v
+}
+
+func connect() *database.DB {
+	db, err := database.NewConnection(config.DatabaseConfig{
+		Host:     envStr("DB_HOST", "localhost"),
+		Port:     envInt("DB_PORT", 5432),
+		User:     envStr("DB_USER", "ispmonitor"),
+		Password: envStr("DB_PASSWORD", "ispmonitor"),
+		DBName:   envStr("DB_NAME", "ispmonitor"),
+		SSLMode:  envStr("DB_SSLMODE", "disable"),
+		MaxConns: 2,
+		MinConns: 1,
+	})
+	if err != nil {
+		log.Fatalf("Database connection failed: %v", err)
+	}
+	return db
+}
+
+// keyName names plaintext values in output
+func keyName(id string) string {
+	if id == "" {
+		return "plaintext"
+	}
+	return id
+}
+
+func envStr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
+}
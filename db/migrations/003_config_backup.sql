@@ -0,0 +1,59 @@
+-- ISP Visual Monitor - Configuration Backup Migration
+-- This migration adds support for:
+-- 1. Versioned device configuration backups with content hashes
+-- 2. Device events (config changes and other discrete device-side events)
+
+-- ============================================================================
+-- CONFIGURATION VERSIONS
+-- ============================================================================
+
+-- Config Versions - one row per distinct configuration seen on a router
+CREATE TABLE config_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL, -- Monotonic per router, starting at 1
+    content TEXT NOT NULL, -- Configuration text with secrets masked
+    content_hash VARCHAR(64) NOT NULL, -- SHA-256 of the normalized, masked content
+    size_bytes INTEGER NOT NULL,
+    collection_method VARCHAR(50) NOT NULL, -- ssh, mikrotik_api
+    lines_added INTEGER DEFAULT 0, -- Compared to the previous version
+    lines_removed INTEGER DEFAULT 0,
+    collected_at TIMESTAMP NOT NULL,
+    last_verified_at TIMESTAMP NOT NULL, -- Last time a backup run saw this exact content
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_router_config_version UNIQUE(router_id, version)
+);
+
+CREATE INDEX idx_config_versions_tenant ON config_versions(tenant_id);
+CREATE INDEX idx_config_versions_router ON config_versions(router_id, version DESC);
+CREATE INDEX idx_config_versions_hash ON config_versions(router_id, content_hash);
+
+-- ============================================================================
+-- DEVICE EVENTS
+-- ============================================================================
+
+-- Device Events - discrete things that happened on a device (config change, trap, etc.)
+CREATE TABLE device_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID REFERENCES routers(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL, -- config_change, ...
+    severity VARCHAR(50) DEFAULT 'info', -- critical, warning, info
+    source VARCHAR(50) NOT NULL, -- config_backup, ...
+    message TEXT NOT NULL,
+    metadata JSONB,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_device_events_tenant_time ON device_events(tenant_id, occurred_at DESC);
+CREATE INDEX idx_device_events_router_time ON device_events(router_id, occurred_at DESC);
+CREATE INDEX idx_device_events_type ON device_events(event_type);
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE config_versions IS 'Versioned, secret-masked configuration backups per router';
+COMMENT ON TABLE device_events IS 'Discrete device events such as configuration changes';
@@ -0,0 +1,53 @@
+-- ISP Visual Monitor - Configuration Compliance Migration
+-- This migration adds support for:
+-- 1. Tenant-defined golden-template compliance policies
+-- 2. Per-router, per-policy evaluation results against the latest stored config
+
+-- ============================================================================
+-- COMPLIANCE POLICIES
+-- ============================================================================
+
+-- Compliance Policies - required lines, forbidden patterns and per-role sections
+CREATE TABLE compliance_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    rules JSONB NOT NULL DEFAULT '[]', -- required_line / forbidden_pattern rules
+    role_sections JSONB NOT NULL DEFAULT '{}', -- Templated sections keyed by router_roles.code
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_tenant_compliance_policy UNIQUE(tenant_id, name)
+);
+
+CREATE INDEX idx_compliance_policies_tenant ON compliance_policies(tenant_id);
+
+-- ============================================================================
+-- COMPLIANCE RESULTS
+-- ============================================================================
+
+-- Compliance Results - latest evaluation of one policy against one router
+CREATE TABLE compliance_results (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL REFERENCES compliance_policies(id) ON DELETE CASCADE,
+    config_version INTEGER NOT NULL, -- config_versions.version that was evaluated
+    score DECIMAL(5,2) NOT NULL, -- 0-100, weighted by violation severity
+    passed_checks INTEGER NOT NULL,
+    total_checks INTEGER NOT NULL,
+    violations JSONB NOT NULL DEFAULT '[]',
+    evaluated_at TIMESTAMP NOT NULL,
+    CONSTRAINT unique_router_compliance_policy UNIQUE(router_id, policy_id)
+);
+
+CREATE INDEX idx_compliance_results_tenant ON compliance_results(tenant_id);
+CREATE INDEX idx_compliance_results_policy ON compliance_results(policy_id, score);
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE compliance_policies IS 'Tenant golden-template policies evaluated against stored configurations';
+COMMENT ON TABLE compliance_results IS 'Latest compliance score and violations per router and policy';
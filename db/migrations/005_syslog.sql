@@ -0,0 +1,38 @@
+-- ISP Visual Monitor - Syslog Migration
+-- This migration adds support for:
+-- 1. Storing syslog messages received from routers with syslog enabled
+-- 2. Searching them by router, severity, time range and text
+
+-- ============================================================================
+-- SYSLOG MESSAGES
+-- ============================================================================
+
+-- Syslog Messages - one row per accepted message; old rows are pruned by the
+-- receiver according to SYSLOG_RETENTION_DAYS
+CREATE TABLE syslog_messages (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    source_ip INET NOT NULL,
+    facility SMALLINT NOT NULL, -- 0-23 (RFC 5424 numeric facility)
+    severity SMALLINT NOT NULL, -- 0 emergency .. 7 debug
+    hostname VARCHAR(255),
+    app_name VARCHAR(255), -- RFC 5424 APP-NAME or RFC 3164 TAG
+    proc_id VARCHAR(128),
+    msg_id VARCHAR(64),
+    structured_data TEXT, -- RFC 5424 STRUCTURED-DATA, verbatim
+    message TEXT NOT NULL,
+    device_time TIMESTAMP, -- Timestamp carried in the message, if any
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_syslog_messages_tenant_time ON syslog_messages(tenant_id, received_at DESC);
+CREATE INDEX idx_syslog_messages_router_time ON syslog_messages(router_id, received_at DESC);
+CREATE INDEX idx_syslog_messages_severity ON syslog_messages(tenant_id, severity, received_at DESC);
+CREATE INDEX idx_syslog_messages_search ON syslog_messages USING gin(message gin_trgm_ops);
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE syslog_messages IS 'Syslog messages received from routers, kept for the configured retention period';
@@ -0,0 +1,19 @@
+-- ISP Visual Monitor - SNMP Trap Migration
+-- This migration adds support for:
+-- 1. Raising and clearing alerts from device-side events (traps) by key
+--
+-- Decoded traps are stored in device_events (see 003_config_backup.sql).
+
+-- ============================================================================
+-- ALERT DEDUPLICATION
+-- ============================================================================
+
+-- Identifies the condition an open alert stands for, e.g.
+-- 'snmp_trap:<router_id>:link:3'. At most one unresolved alert may exist per
+-- key, so repeated traps do not pile up and a clearing trap finds its alert.
+ALTER TABLE alerts ADD COLUMN dedup_key VARCHAR(255);
+
+CREATE UNIQUE INDEX idx_alerts_open_dedup_key ON alerts(tenant_id, dedup_key)
+    WHERE dedup_key IS NOT NULL AND status IN ('active', 'acknowledged');
+
+COMMENT ON COLUMN alerts.dedup_key IS 'Condition key; unique among unresolved alerts of a tenant';
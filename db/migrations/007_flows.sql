@@ -0,0 +1,41 @@
+-- ISP Visual Monitor - Flow Collection Migration
+-- This migration adds support for:
+-- 1. Per-minute traffic rollups built from NetFlow exports
+-- 2. Traffic matrix and top-talker queries by prefix, AS, protocol and interface
+
+-- ============================================================================
+-- FLOW ROLLUPS
+-- ============================================================================
+
+-- Flow Rollups - traffic aggregated per router and minute. Addresses are
+-- truncated to prefixes (FLOW_IPV4_PREFIX / FLOW_IPV6_PREFIX); when the
+-- collector runs out of aggregation slots, prefixes collapse to 0.0.0.0/0 or
+-- ::/0 and AS numbers to 0 so totals stay correct. Byte and packet counts are
+-- already scaled by the exporter's sampling rate.
+CREATE TABLE flow_rollups (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL, -- Start of the minute
+    input_if_index INTEGER NOT NULL DEFAULT 0,
+    output_if_index INTEGER NOT NULL DEFAULT 0,
+    protocol SMALLINT NOT NULL, -- IANA protocol number
+    src_prefix CIDR NOT NULL,
+    dst_prefix CIDR NOT NULL,
+    src_as BIGINT NOT NULL DEFAULT 0,
+    dst_as BIGINT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    packets BIGINT NOT NULL DEFAULT 0,
+    flows BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT unique_flow_rollup UNIQUE(router_id, bucket, input_if_index, output_if_index,
+        protocol, src_prefix, dst_prefix, src_as, dst_as)
+);
+
+CREATE INDEX idx_flow_rollups_tenant_bucket ON flow_rollups(tenant_id, bucket DESC);
+CREATE INDEX idx_flow_rollups_router_bucket ON flow_rollups(router_id, bucket DESC);
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE flow_rollups IS 'Per-minute traffic rollups from flow exports, kept for FLOW_RETENTION_DAYS';
@@ -0,0 +1,39 @@
+-- ISP Visual Monitor - NAT Event Migration
+-- This migration adds support for:
+-- 1. Keeping CGNAT translation events exported over IPFIX (RFC 8158)
+-- 2. Answering "which subscriber used this public address and port" queries
+
+-- ============================================================================
+-- NAT EVENTS
+-- ============================================================================
+
+-- NAT Events - one row per translation event or translated flow record. Rows
+-- are kept for FLOW_NAT_RETENTION_DAYS, which should match the retention
+-- period required of the operator.
+CREATE TABLE nat_events (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    event_time TIMESTAMP NOT NULL,
+    nat_event SMALLINT NOT NULL DEFAULT 0, -- IANA natEvent; 0 for translated flow records
+    protocol SMALLINT NOT NULL,
+    src_address INET,
+    src_port INTEGER,
+    dst_address INET,
+    dst_port INTEGER,
+    post_nat_src_address INET,
+    post_nat_src_port INTEGER,
+    post_nat_dst_address INET,
+    post_nat_dst_port INTEGER,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_nat_events_tenant_time ON nat_events(tenant_id, event_time DESC);
+CREATE INDEX idx_nat_events_post_nat ON nat_events(tenant_id, post_nat_src_address, event_time DESC);
+CREATE INDEX idx_nat_events_src ON nat_events(tenant_id, src_address, event_time DESC);
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE nat_events IS 'NAT translation events from IPFIX exporters, kept for compliance lookups';
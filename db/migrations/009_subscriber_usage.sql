@@ -0,0 +1,59 @@
+-- ISP Visual Monitor - Subscriber Traffic Accounting Migration
+-- This migration adds support for:
+-- 1. Per-minute traffic per subscriber, from flows matched to PPPoE sessions and DHCP leases
+-- 2. Hourly per-application traffic per subscriber, by protocol and service port
+
+-- ============================================================================
+-- SUBSCRIBER USAGE
+-- ============================================================================
+
+-- Subscriber Usage - flow traffic attributed to the subscriber holding the
+-- flow's source or destination address at the flow's time. The subscriber is
+-- the PPPoE username, or the MAC address for DHCP leases. Bytes in are
+-- downloaded by the subscriber, bytes out uploaded. Counts are already scaled
+-- by the exporter's sampling rate.
+CREATE TABLE subscriber_usage (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL, -- Start of the minute
+    subscriber VARCHAR(255) NOT NULL,
+    subscriber_type VARCHAR(20) NOT NULL, -- pppoe, dhcp
+    bytes_in BIGINT NOT NULL DEFAULT 0,
+    bytes_out BIGINT NOT NULL DEFAULT 0,
+    packets_in BIGINT NOT NULL DEFAULT 0,
+    packets_out BIGINT NOT NULL DEFAULT 0,
+    flows BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT unique_subscriber_usage UNIQUE(router_id, bucket, subscriber_type, subscriber)
+);
+
+CREATE INDEX idx_subscriber_usage_tenant_bucket ON subscriber_usage(tenant_id, bucket DESC);
+CREATE INDEX idx_subscriber_usage_subscriber ON subscriber_usage(tenant_id, subscriber, bucket DESC);
+
+-- Subscriber Application Usage - the same traffic per hour by protocol and
+-- the port on the remote side of the flow. Ephemeral ports (49152 and up) are
+-- stored as port 0.
+CREATE TABLE subscriber_app_usage (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL, -- Start of the hour
+    subscriber VARCHAR(255) NOT NULL,
+    subscriber_type VARCHAR(20) NOT NULL,
+    protocol SMALLINT NOT NULL,
+    port INTEGER NOT NULL,
+    bytes_in BIGINT NOT NULL DEFAULT 0,
+    bytes_out BIGINT NOT NULL DEFAULT 0,
+    flows BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT unique_subscriber_app_usage UNIQUE(router_id, bucket, subscriber_type, subscriber, protocol, port)
+);
+
+CREATE INDEX idx_subscriber_app_usage_tenant_bucket ON subscriber_app_usage(tenant_id, bucket DESC);
+CREATE INDEX idx_subscriber_app_usage_subscriber ON subscriber_app_usage(tenant_id, subscriber, bucket DESC);
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE subscriber_usage IS 'Per-minute traffic per PPPoE or DHCP subscriber from flow exports, kept for FLOW_RETENTION_DAYS';
+COMMENT ON TABLE subscriber_app_usage IS 'Hourly traffic per subscriber, protocol and service port, kept for FLOW_RETENTION_DAYS';
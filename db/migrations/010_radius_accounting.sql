@@ -0,0 +1,33 @@
+-- ISP Visual Monitor - RADIUS Accounting Migration
+-- This migration adds support for:
+-- 1. Routers sending RADIUS accounting (Acct-Start/Interim-Update/Stop) as a NAS
+-- 2. Upserting PPPoE sessions by the NAS's Acct-Session-Id
+
+-- ============================================================================
+-- RADIUS ACCOUNTING CAPABILITY
+-- ============================================================================
+
+-- A router is accepted as a NAS when RADIUS accounting is enabled. Requests
+-- are matched by NAS-Identifier, then NAS-IP-Address, then source address,
+-- and authenticated with the router's shared secret (RADIUS_ACCT_SECRET when
+-- unset).
+ALTER TABLE router_capabilities
+    ADD COLUMN radius_acct_enabled BOOLEAN DEFAULT false,
+    ADD COLUMN radius_secret VARCHAR(255), -- Shared secret (sensitive)
+    ADD COLUMN radius_nas_identifier VARCHAR(255);
+
+-- ============================================================================
+-- PPPOE SESSIONS
+-- ============================================================================
+
+-- Accounting records of one session carry the same Acct-Session-Id, stored
+-- as session_id
+CREATE UNIQUE INDEX unique_pppoe_session ON pppoe_sessions(router_id, session_id)
+    WHERE session_id IS NOT NULL;
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON COLUMN router_capabilities.radius_nas_identifier IS 'NAS-Identifier the router sends in RADIUS accounting requests';
+COMMENT ON COLUMN pppoe_sessions.session_id IS 'Router session ID; the Acct-Session-Id for sessions from RADIUS accounting';
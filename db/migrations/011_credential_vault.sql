@@ -0,0 +1,38 @@
+-- ISP Visual Monitor - Credential Vault Migration
+-- This migration adds support for:
+-- 1. Router credentials encrypted at rest with the credential vault
+-- 2. Encrypted values longer than the plaintext column limits
+
+-- ============================================================================
+-- CREDENTIAL COLUMNS
+-- ============================================================================
+
+-- Encrypted values carry the master key ID, a sealed data key and the sealed
+-- credential ("vault:v1:<key id>:<data key>:<value>"), well beyond 255
+-- characters for longer secrets. Existing plaintext values stay readable
+-- until they are encrypted with the vault rotate command.
+ALTER TABLE router_capabilities
+    ALTER COLUMN snmp_community TYPE TEXT,
+    ALTER COLUMN snmp_v3_auth_password TYPE TEXT,
+    ALTER COLUMN snmp_v3_priv_password TYPE TEXT,
+    ALTER COLUMN api_password TYPE TEXT,
+    ALTER COLUMN ssh_password TYPE TEXT,
+    ALTER COLUMN netconf_password TYPE TEXT,
+    ALTER COLUMN radius_secret TYPE TEXT;
+
+ALTER TABLE routers
+    ALTER COLUMN snmp_community TYPE TEXT;
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON COLUMN router_capabilities.snmp_community IS 'SNMP v1/v2c community, encrypted by the credential vault';
+COMMENT ON COLUMN router_capabilities.snmp_v3_auth_password IS 'SNMPv3 authentication password, encrypted by the credential vault';
+COMMENT ON COLUMN router_capabilities.snmp_v3_priv_password IS 'SNMPv3 privacy password, encrypted by the credential vault';
+COMMENT ON COLUMN router_capabilities.api_password IS 'Vendor API password, encrypted by the credential vault';
+COMMENT ON COLUMN router_capabilities.ssh_password IS 'SSH password, encrypted by the credential vault';
+COMMENT ON COLUMN router_capabilities.ssh_private_key IS 'SSH private key, encrypted by the credential vault';
+COMMENT ON COLUMN router_capabilities.netconf_password IS 'NETCONF password, encrypted by the credential vault';
+COMMENT ON COLUMN router_capabilities.radius_secret IS 'RADIUS accounting shared secret, encrypted by the credential vault';
+COMMENT ON COLUMN routers.snmp_community IS 'Legacy SNMP community, encrypted by the credential vault';
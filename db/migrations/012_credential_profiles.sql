@@ -0,0 +1,72 @@
+-- ISP Visual Monitor - Credential Profiles Migration
+-- This migration adds support for:
+-- 1. Named SNMP, API and SSH credential profiles per tenant
+-- 2. Routers referencing a profile per method, with per-router overrides
+
+-- ============================================================================
+-- CREDENTIAL PROFILES
+-- ============================================================================
+
+-- A profile holds the credentials of one method. Secrets are encrypted by
+-- the credential vault like those in router_capabilities. username is the
+-- SNMPv3 user for SNMP profiles.
+CREATE TABLE credential_profiles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('snmp', 'api', 'ssh')),
+    description TEXT,
+
+    -- SNMP
+    snmp_version VARCHAR(10), -- v1, v2c, v3
+    snmp_community TEXT, -- (encrypted)
+    snmp_v3_auth_protocol VARCHAR(50),
+    snmp_v3_auth_password TEXT, -- (encrypted)
+    snmp_v3_priv_protocol VARCHAR(50),
+    snmp_v3_priv_password TEXT, -- (encrypted)
+
+    -- API, SSH and SNMPv3
+    username VARCHAR(255),
+    password TEXT, -- (encrypted)
+    private_key TEXT, -- SSH only (encrypted)
+
+    rotated_at TIMESTAMP WITH TIME ZONE, -- Last secret change
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_credential_profile_name UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_credential_profiles_tenant ON credential_profiles(tenant_id, method);
+
+-- ============================================================================
+-- PROFILE REFERENCES
+-- ============================================================================
+
+-- Credentials set on router_capabilities override the referenced profile's
+-- value by value; a profile in use cannot be deleted.
+ALTER TABLE router_capabilities
+    ADD COLUMN snmp_profile_id UUID REFERENCES credential_profiles(id) ON DELETE RESTRICT,
+    ADD COLUMN api_profile_id UUID REFERENCES credential_profiles(id) ON DELETE RESTRICT,
+    ADD COLUMN ssh_profile_id UUID REFERENCES credential_profiles(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_router_capabilities_snmp_profile ON router_capabilities(snmp_profile_id) WHERE snmp_profile_id IS NOT NULL;
+CREATE INDEX idx_router_capabilities_api_profile ON router_capabilities(api_profile_id) WHERE api_profile_id IS NOT NULL;
+CREATE INDEX idx_router_capabilities_ssh_profile ON router_capabilities(ssh_profile_id) WHERE ssh_profile_id IS NOT NULL;
+
+-- ============================================================================
+-- TRIGGERS
+-- ============================================================================
+
+CREATE TRIGGER update_credential_profiles_updated_at BEFORE UPDATE ON credential_profiles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE credential_profiles IS 'Named credentials of one method shared by many routers';
+COMMENT ON COLUMN credential_profiles.username IS 'API or SSH user, or SNMPv3 user for SNMP profiles';
+COMMENT ON COLUMN router_capabilities.snmp_profile_id IS 'SNMP credential profile; SNMP credentials set on the router override it';
+COMMENT ON COLUMN router_capabilities.api_profile_id IS 'API credential profile; api_username and api_password override it';
+COMMENT ON COLUMN router_capabilities.ssh_profile_id IS 'SSH credential profile; SSH credentials set on the router override it';
@@ -0,0 +1,85 @@
+-- ISP Visual Monitor - Maintenance Windows Migration
+-- This migration adds support for:
+-- 1. One-off and recurring maintenance windows scoped to routers, POPs, regions or roles
+-- 2. Marking routers unreachable after consecutive failed polls
+-- 3. Alerts raised suppressed during maintenance
+
+-- ============================================================================
+-- MAINTENANCE WINDOWS
+-- ============================================================================
+
+-- A one-off window runs from starts_at to ends_at. A recurring window runs
+-- for duration_minutes from each start of recurrence (a cron expression or
+-- RRULE evaluated in timezone) from starts_at until ends_at, if set. Empty
+-- scope arrays on all four columns cover every router of the tenant.
+CREATE TABLE maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    recurrence VARCHAR(255), -- cron (e.g. '0 2 * * 0') or RRULE (e.g. 'FREQ=WEEKLY;BYDAY=SU;BYHOUR=2')
+    duration_minutes INTEGER CHECK (duration_minutes > 0),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+
+    -- Scope
+    router_ids UUID[] NOT NULL DEFAULT '{}',
+    pop_ids UUID[] NOT NULL DEFAULT '{}',
+    region_ids UUID[] NOT NULL DEFAULT '{}', -- Sub-regions included
+    role_codes TEXT[] NOT NULL DEFAULT '{}',
+
+    pause_polling BOOLEAN NOT NULL DEFAULT false,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT maintenance_window_schedule CHECK (
+        (recurrence IS NULL AND ends_at IS NOT NULL AND duration_minutes IS NULL)
+        OR (recurrence IS NOT NULL AND duration_minutes IS NOT NULL)
+    )
+);
+
+CREATE INDEX idx_maintenance_windows_tenant ON maintenance_windows(tenant_id, starts_at DESC);
+CREATE INDEX idx_maintenance_windows_enabled ON maintenance_windows(ends_at) WHERE enabled = true;
+
+-- ============================================================================
+-- ROUTER REACHABILITY
+-- ============================================================================
+
+-- Failed polls in a row; a router is unreachable from the configured
+-- threshold until its next successful poll. Failures during maintenance are
+-- not counted.
+ALTER TABLE routers
+    ADD COLUMN poll_failures INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN unreachable_since TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_routers_unreachable ON routers(tenant_id) WHERE unreachable_since IS NOT NULL;
+
+-- ============================================================================
+-- ALERT SUPPRESSION
+-- ============================================================================
+
+ALTER TABLE alerts
+    ADD COLUMN suppressed BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN maintenance_window_id UUID REFERENCES maintenance_windows(id) ON DELETE SET NULL;
+
+-- ============================================================================
+-- TRIGGERS
+-- ============================================================================
+
+CREATE TRIGGER update_maintenance_windows_updated_at BEFORE UPDATE ON maintenance_windows
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE maintenance_windows IS 'Planned maintenance that suppresses alerts and unreachable state, and optionally pauses polling';
+COMMENT ON COLUMN maintenance_windows.recurrence IS 'Five-field cron expression or RRULE; NULL for a one-off window';
+COMMENT ON COLUMN maintenance_windows.ends_at IS 'End of a one-off window, or of the recurrence of a recurring one';
+COMMENT ON COLUMN routers.poll_failures IS 'Consecutive failed polls outside maintenance';
+COMMENT ON COLUMN routers.unreachable_since IS 'Set once poll_failures reaches the unreachable threshold; cleared by a successful poll';
+COMMENT ON COLUMN alerts.suppressed IS 'Raised during a maintenance window; not to be notified';
+COMMENT ON COLUMN alerts.maintenance_window_id IS 'Maintenance window the alert was raised in';
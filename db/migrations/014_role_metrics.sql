@@ -0,0 +1,23 @@
+-- ISP Visual Monitor - Role Metrics Migration
+-- This migration adds support for:
+-- 1. Querying role-specific metric series per router and role
+
+-- ============================================================================
+-- ROLE-SPECIFIC METRICS
+-- ============================================================================
+
+-- The poller writes one row per successful poll and router role with the
+-- numeric metrics of that role; series are read per role and time range,
+-- for one router or all routers of a tenant.
+CREATE INDEX idx_role_metrics_router_role_time ON role_specific_metrics(router_id, role_code, timestamp DESC);
+CREATE INDEX idx_role_metrics_tenant_role_time ON role_specific_metrics(tenant_id, role_code, timestamp DESC);
+
+-- Superseded by the indexes above
+DROP INDEX IF EXISTS idx_role_metrics_role;
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON COLUMN role_specific_metrics.role_code IS 'Router role the metrics belong to, e.g. pppoe_server or nat_gateway';
+COMMENT ON COLUMN role_specific_metrics.metrics IS 'Metric values of the role by metric name, as registered in the poller''s metric registry';
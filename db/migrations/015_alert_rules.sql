@@ -0,0 +1,46 @@
+-- ISP Visual Monitor - Alert Rules Migration
+-- This migration adds support for:
+-- 1. Evaluating alert rules against polled metrics
+-- 2. Finding the unresolved alerts raised by a rule
+
+-- ============================================================================
+-- ALERT RULES
+-- ============================================================================
+
+-- The poller loads the enabled rules of every tenant periodically
+CREATE INDEX idx_alert_rules_enabled_tenant ON alert_rules(tenant_id) WHERE enabled = true;
+
+-- Superseded by the partial index above
+DROP INDEX IF EXISTS idx_alert_rules_enabled;
+
+UPDATE alert_rules SET enabled = true WHERE enabled IS NULL;
+UPDATE alert_rules SET severity = 'warning' WHERE severity IS NULL;
+ALTER TABLE alert_rules ALTER COLUMN enabled SET NOT NULL;
+ALTER TABLE alert_rules ALTER COLUMN severity SET NOT NULL;
+
+-- ============================================================================
+-- RULE ALERTS
+-- ============================================================================
+
+-- Alerts raised by rules carry the rule and a dedup_key of the form
+-- alert_rule:<rule_id>:<router_id>[:<interface>]; the evaluator resolves
+-- them when the condition clears and when the rule is disabled or deleted
+CREATE INDEX idx_alerts_rule_unresolved ON alerts(rule_id)
+    WHERE status IN ('active', 'acknowledged');
+
+-- ============================================================================
+-- TRIGGERS
+-- ============================================================================
+
+CREATE TRIGGER update_alert_rules_updated_at BEFORE UPDATE ON alert_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON COLUMN alert_rules.rule_type IS 'threshold, rate_of_change or absence';
+COMMENT ON COLUMN alert_rules.target_type IS 'router, interface, role or pop; NULL for every router of the tenant';
+COMMENT ON COLUMN alert_rules.target_id IS 'Router, interface, router_roles or pops row the rule applies to';
+COMMENT ON COLUMN alert_rules.condition IS 'metric, operator, for, window, percent, clear_threshold and clear_for as applicable to rule_type';
+COMMENT ON COLUMN alert_rules.threshold_value IS 'Value, or change over the window, beyond which threshold and rate_of_change rules fire';
@@ -0,0 +1,18 @@
+-- ISP Visual Monitor - Alert Rule API Migration
+-- This migration adds support for:
+-- 1. Thresholds beyond DECIMAL(10,2), e.g. octet counter changes
+-- 2. Previewing absence rules against the stored polls of a router
+
+-- ============================================================================
+-- ALERT RULES
+-- ============================================================================
+
+ALTER TABLE alert_rules ALTER COLUMN threshold_value TYPE DOUBLE PRECISION;
+
+-- ============================================================================
+-- POLLING HISTORY
+-- ============================================================================
+
+-- Rule previews read the successful polls of routers over a time range
+CREATE INDEX idx_polling_history_router_success ON polling_history(router_id, poll_completed_at)
+    WHERE success = true;
@@ -0,0 +1,123 @@
+-- ISP Visual Monitor - Notifications Migration
+-- This migration adds support for:
+-- 1. Per-tenant notification channels: SMTP email, signed JSON webhooks,
+--    Slack/Mattermost incoming webhooks and Telegram bots
+-- 2. Routing rules sending alerts to channels by severity, router role and POP
+-- 3. A delivery log that doubles as the retry queue
+
+-- ============================================================================
+-- NOTIFICATION CHANNELS
+-- ============================================================================
+
+-- config holds the non-secret settings of the channel type; secret holds
+-- the SMTP password, webhook HMAC key, incoming webhook URL or bot token,
+-- encrypted by the credential vault. NULL templates use the defaults.
+CREATE TABLE notification_channels (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    channel_type VARCHAR(20) NOT NULL CHECK (channel_type IN ('email', 'webhook', 'slack', 'telegram')),
+    config JSONB NOT NULL DEFAULT '{}',
+    secret TEXT, -- (sensitive)
+    title_template TEXT,
+    body_template TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (tenant_id, name)
+);
+
+-- ============================================================================
+-- NOTIFICATION ROUTES
+-- ============================================================================
+
+-- A route sends the alerts matching all of its filters to its channel; an
+-- empty filter array matches every alert. Role and POP filters match the
+-- router an alert targets, directly or through one of its interfaces.
+CREATE TABLE notification_routes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    severities TEXT[] NOT NULL DEFAULT '{}',
+    role_codes TEXT[] NOT NULL DEFAULT '{}',
+    pop_ids UUID[] NOT NULL DEFAULT '{}',
+    notify_resolved BOOLEAN NOT NULL DEFAULT true,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_routes_tenant ON notification_routes(tenant_id) WHERE enabled = true;
+CREATE INDEX idx_notification_routes_channel ON notification_routes(channel_id);
+
+-- ============================================================================
+-- NOTIFICATION DELIVERIES
+-- ============================================================================
+
+-- One row per alert event and channel, or per test send without an alert.
+-- Pending rows are sent from next_attempt_at; a claimed row's
+-- next_attempt_at is pushed out so that it is not sent twice.
+CREATE TABLE notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    alert_id UUID REFERENCES alerts(id) ON DELETE CASCADE,
+    event VARCHAR(20) NOT NULL CHECK (event IN ('firing', 'resolved', 'test')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (channel_id, alert_id, event)
+);
+
+CREATE INDEX idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_notification_deliveries_tenant ON notification_deliveries(tenant_id, created_at DESC);
+CREATE INDEX idx_notification_deliveries_alert ON notification_deliveries(alert_id);
+
+-- ============================================================================
+-- ALERT NOTIFICATION STATE
+-- ============================================================================
+
+-- Set once an alert's firing or resolution has been routed to channels
+ALTER TABLE alerts
+    ADD COLUMN notified_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN resolved_notified_at TIMESTAMP WITH TIME ZONE;
+
+-- Alerts raised before notifications existed are not sent
+UPDATE alerts
+SET notified_at = CURRENT_TIMESTAMP,
+    resolved_notified_at = CASE WHEN status = 'resolved' THEN CURRENT_TIMESTAMP END;
+
+CREATE INDEX idx_alerts_notify_firing ON alerts(triggered_at) WHERE notified_at IS NULL;
+CREATE INDEX idx_alerts_notify_resolved ON alerts(resolved_at)
+    WHERE status = 'resolved' AND notified_at IS NOT NULL AND resolved_notified_at IS NULL;
+
+-- ============================================================================
+-- TRIGGERS
+-- ============================================================================
+
+CREATE TRIGGER update_notification_channels_updated_at BEFORE UPDATE ON notification_channels
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_notification_routes_updated_at BEFORE UPDATE ON notification_routes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_notification_deliveries_updated_at BEFORE UPDATE ON notification_deliveries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE notification_channels IS 'Destinations alert notifications are sent to';
+COMMENT ON COLUMN notification_channels.secret IS 'SMTP password, webhook HMAC key, incoming webhook URL or Telegram bot token, encrypted by the credential vault';
+COMMENT ON TABLE notification_routes IS 'Rules sending alerts to a channel by severity, router role and POP';
+COMMENT ON TABLE notification_deliveries IS 'Notification delivery log and retry queue';
+COMMENT ON COLUMN alerts.notified_at IS 'When the alert was routed to notification channels';
@@ -0,0 +1,150 @@
+-- ISP Visual Monitor - Escalation Migration
+-- This migration adds support for:
+-- 1. On-call schedules rotating users with weekly coverage hours, in a time zone
+-- 2. Overrides handing a schedule to another user for a while
+-- 3. Escalation policies paging tier after tier until an alert is acknowledged
+
+-- ============================================================================
+-- ON-CALL SCHEDULES
+-- ============================================================================
+
+-- participants take turns in order, each for rotation_days, handing over at
+-- the wall clock time of rotation_start in timezone. coverage lists the
+-- weekly hours the schedule is on call, e.g.
+-- [{"days": ["mon", "tue"], "start": "09:00", "end": "17:00"}]; an empty
+-- list covers every hour.
+CREATE TABLE oncall_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    participants UUID[] NOT NULL DEFAULT '{}',
+    rotation_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    rotation_days INTEGER NOT NULL DEFAULT 7 CHECK (rotation_days > 0),
+    coverage JSONB NOT NULL DEFAULT '[]',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (tenant_id, name)
+);
+
+-- An override puts user_id on call from starts_at to ends_at, in and out of
+-- coverage hours; the latest created wins where overrides overlap
+CREATE TABLE oncall_overrides (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    schedule_id UUID NOT NULL REFERENCES oncall_schedules(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    note TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_oncall_overrides_schedule ON oncall_overrides(schedule_id, ends_at);
+
+-- ============================================================================
+-- ESCALATION POLICIES
+-- ============================================================================
+
+-- A policy pages the alerts matching all of its filters, like a
+-- notification route. tiers is an ordered JSON list of
+-- {"escalate_after_minutes": n, "targets": [...]}; each target names a
+-- channel_id and optionally the schedule_id or user_id paged through it.
+-- After the last tier the policy starts over repeat_count times.
+CREATE TABLE escalation_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    severities TEXT[] NOT NULL DEFAULT '{}',
+    role_codes TEXT[] NOT NULL DEFAULT '{}',
+    pop_ids UUID[] NOT NULL DEFAULT '{}',
+    tiers JSONB NOT NULL,
+    repeat_count INTEGER NOT NULL DEFAULT 0 CHECK (repeat_count BETWEEN 0 AND 10),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_escalation_policies_tenant ON escalation_policies(tenant_id) WHERE enabled = true;
+
+-- ============================================================================
+-- ALERT ESCALATIONS
+-- ============================================================================
+
+-- One row per alert and matching policy. tier is the index of the next tier
+-- to page at next_escalation_at; repeats counts the passes started over.
+-- A claimed row's next_escalation_at is pushed out so that it is not
+-- paged twice.
+CREATE TABLE alert_escalations (
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL REFERENCES escalation_policies(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    tier INTEGER NOT NULL DEFAULT 0,
+    repeats INTEGER NOT NULL DEFAULT 0,
+    next_escalation_at TIMESTAMP WITH TIME ZONE,
+    stopped_at TIMESTAMP WITH TIME ZONE,
+    stop_reason VARCHAR(20) CHECK (stop_reason IN ('acknowledged', 'resolved', 'exhausted', 'disabled')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (alert_id, policy_id)
+);
+
+CREATE INDEX idx_alert_escalations_due ON alert_escalations(next_escalation_at) WHERE stopped_at IS NULL;
+
+-- ============================================================================
+-- NOTIFICATION DELIVERIES
+-- ============================================================================
+
+-- Escalation deliveries record the tier (from 1) and the user paged; an
+-- alert may page the same channel at several tiers, so only firing and
+-- resolved deliveries stay unique per channel. Pending escalation
+-- deliveries are cancelled when the alert is acknowledged.
+ALTER TABLE notification_deliveries
+    ADD COLUMN tier INTEGER,
+    ADD COLUMN recipient_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    DROP CONSTRAINT notification_deliveries_channel_id_alert_id_event_key,
+    DROP CONSTRAINT notification_deliveries_event_check,
+    ADD CONSTRAINT notification_deliveries_event_check
+        CHECK (event IN ('firing', 'resolved', 'test', 'escalation')),
+    DROP CONSTRAINT notification_deliveries_status_check,
+    ADD CONSTRAINT notification_deliveries_status_check
+        CHECK (status IN ('pending', 'sent', 'failed', 'cancelled'));
+
+CREATE UNIQUE INDEX idx_notification_deliveries_event ON notification_deliveries(channel_id, alert_id, event)
+    WHERE event IN ('firing', 'resolved');
+
+-- ============================================================================
+-- TRIGGERS
+-- ============================================================================
+
+CREATE TRIGGER update_oncall_schedules_updated_at BEFORE UPDATE ON oncall_schedules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_escalation_policies_updated_at BEFORE UPDATE ON escalation_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_alert_escalations_updated_at BEFORE UPDATE ON alert_escalations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================================
+-- COMMENTS FOR DOCUMENTATION
+-- ============================================================================
+
+COMMENT ON TABLE oncall_schedules IS 'Rotations of users on call, with weekly coverage hours in a time zone';
+COMMENT ON TABLE oncall_overrides IS 'Periods a user covers a schedule in place of its rotation';
+COMMENT ON TABLE escalation_policies IS 'Tiers of channels, schedules and users paged until an alert is acknowledged';
+COMMENT ON TABLE alert_escalations IS 'Escalation progress of each alert under each matching policy';
+COMMENT ON COLUMN alert_escalations.tier IS 'Index of the next tier to page';
+COMMENT ON COLUMN notification_deliveries.tier IS 'Escalation tier paged, from 1; NULL for other events';
+COMMENT ON COLUMN notification_deliveries.recipient_id IS 'User paged through the channel by an escalation';
@@ -5,6 +5,7 @@ go 1.24.11
 require (
 	github.com/go-playground/validator/v10 v10.30.1
 	github.com/golang-jwt/jwt/v5 v5.3.0
+	github.com/golang/snappy v0.0.4
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
 	github.com/gosnmp/gosnmp v1.43.2
@@ -12,7 +13,9 @@ require (
 	github.com/prometheus/client_golang v1.23.2
 	go.uber.org/zap v1.27.1
 	golang.org/x/crypto v0.47.0
+	google.golang.org/protobuf v1.36.8
 	gopkg.in/routeros.v2 v2.0.0-20190905230420-1bbf141cdd91
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -30,5 +33,4 @@ require (
 	go.yaml.in/yaml/v2 v2.4.2 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
-	google.golang.org/protobuf v1.36.8 // indirect
 )
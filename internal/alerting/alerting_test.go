@@ -0,0 +1,260 @@
+package alerting
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+func testRule(t *testing.T, ruleType, condition string, threshold *float64) *Rule {
+	t.Helper()
+	r, err := Compile(&models.AlertRule{ID: uuid.New(), RuleType: ruleType, Condition: condition, ThresholdValue: threshold})
+	if err != nil {
+		t.Fatalf("Compile(%s): %v", condition, err)
+	}
+	return r
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCompile(t *testing.T) {
+	routerID := uuid.New()
+
+	tests := []struct {
+		name       string
+		rule       models.AlertRule
+		wantErr    string
+		wantIfRule bool
+	}{
+		{
+			name: "threshold",
+			rule: models.AlertRule{RuleType: "threshold", Condition: `{"metric": "cpu_percent", "operator": ">", "for": "5m", "clear_threshold": 80}`, ThresholdValue: ptr(90.0)},
+		},
+		{
+			name:       "interface rate",
+			rule:       models.AlertRule{RuleType: "rate_of_change", Condition: `{"metric": "if_in_errors", "operator": ">", "window": "10m"}`, ThresholdValue: ptr(100.0), TargetType: ptr("interface"), TargetID: &routerID},
+			wantIfRule: true,
+		},
+		{
+			name: "absence of polls",
+			rule: models.AlertRule{RuleType: "absence", Condition: `{"window": "15m"}`, TargetType: ptr("pop"), TargetID: &routerID},
+		},
+		{
+			name:    "unknown type",
+			rule:    models.AlertRule{RuleType: "anomaly", Condition: `{"metric": "cpu_percent"}`},
+			wantErr: "unknown rule_type",
+		},
+		{
+			name:    "unknown metric",
+			rule:    models.AlertRule{RuleType: "threshold", Condition: `{"metric": "cpu", "operator": ">"}`, ThresholdValue: ptr(1.0)},
+			wantErr: `unknown metric "cpu"`,
+		},
+		{
+			name:    "text metric",
+			rule:    models.AlertRule{RuleType: "threshold", Condition: `{"metric": "os_version", "operator": ">"}`, ThresholdValue: ptr(1.0)},
+			wantErr: "not numeric",
+		},
+		{
+			name:    "missing threshold",
+			rule:    models.AlertRule{RuleType: "threshold", Condition: `{"metric": "cpu_percent", "operator": ">"}`},
+			wantErr: "threshold_value",
+		},
+		{
+			name:    "clear threshold beyond threshold",
+			rule:    models.AlertRule{RuleType: "threshold", Condition: `{"metric": "cpu_percent", "operator": ">", "clear_threshold": 95}`, ThresholdValue: ptr(90.0)},
+			wantErr: "clear_threshold",
+		},
+		{
+			name:    "rate without window",
+			rule:    models.AlertRule{RuleType: "rate_of_change", Condition: `{"metric": "cpu_percent", "operator": ">"}`, ThresholdValue: ptr(10.0)},
+			wantErr: "window",
+		},
+		{
+			name:    "bad duration",
+			rule:    models.AlertRule{RuleType: "absence", Condition: `{"window": 900}`},
+			wantErr: "duration",
+		},
+		{
+			name:    "unknown field",
+			rule:    models.AlertRule{RuleType: "absence", Condition: `{"window": "5m", "windwo": "5m"}`},
+			wantErr: "unknown field",
+		},
+		{
+			name:    "interface target with router metric",
+			rule:    models.AlertRule{RuleType: "threshold", Condition: `{"metric": "cpu_percent", "operator": ">"}`, ThresholdValue: ptr(1.0), TargetType: ptr("interface"), TargetID: &routerID},
+			wantErr: "interface metric",
+		},
+		{
+			name:    "target without id",
+			rule:    models.AlertRule{RuleType: "absence", Condition: `{"window": "5m"}`, TargetType: ptr("role")},
+			wantErr: "target_id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Compile(&tt.rule)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Compile error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if r.Interface != tt.wantIfRule {
+				t.Errorf("Interface = %v, want %v", r.Interface, tt.wantIfRule)
+			}
+			if r.Severity != models.SeverityWarning {
+				t.Errorf("Severity = %q, want the default", r.Severity)
+			}
+		})
+	}
+}
+
+// feed observes values one minute apart from t0 and returns the transitions
+func feed(s *Series, t0 time.Time, values ...float64) []Transition {
+	var got []Transition
+	for i, v := range values {
+		got = append(got, s.Observe(Point{Time: t0.Add(time.Duration(i) * time.Minute), Value: v}))
+	}
+	return got
+}
+
+func TestThresholdForAndHysteresis(t *testing.T) {
+	r := testRule(t, "threshold", `{"metric": "cpu_percent", "operator": ">", "for": "2m", "clear_threshold": 80, "clear_for": "1m"}`, ptr(90.0))
+	s := NewSeries(r, time.Time{})
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got := feed(s, t0,
+		95, 95, 70, // Breach interrupted before 2m
+		95, 95, 95, // Fires after holding for 2m
+		85, 95, // Between the thresholds: still firing
+		75, 75, // Resolves after clearing for 1m
+	)
+	want := []Transition{
+		Unchanged, Unchanged, Unchanged,
+		Unchanged, Unchanged, Fired,
+		Unchanged, Unchanged,
+		Unchanged, Resolved,
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", got, want)
+		}
+	}
+	if s.Value() != 75 {
+		t.Errorf("Value = %g, want the clearing value", s.Value())
+	}
+}
+
+func TestRateOfChange(t *testing.T) {
+	r := testRule(t, "rate_of_change", `{"metric": "if_in_errors", "operator": ">", "window": "2m"}`, ptr(50.0))
+	s := NewSeries(r, time.Time{})
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got := feed(s, t0, 100, 110, 120, 200, 10, 20, 30)
+	want := []Transition{
+		Unchanged, Unchanged, // Less than a window of history
+		Unchanged, // +20 over 2m
+		Fired,     // +90 over 2m
+		Unchanged, // Counter reset drops the history
+		Unchanged,
+		Resolved, // +20 over 2m
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", got, want)
+		}
+	}
+
+	pct := testRule(t, "rate_of_change", `{"metric": "cpu_percent", "operator": "<=", "window": "1m", "percent": true}`, ptr(-50.0))
+	s = NewSeries(pct, time.Time{})
+	if got := feed(s, t0, 0, 40, 10); got[1] != Unchanged || got[2] != Fired {
+		t.Fatalf("percent transitions = %v, want no change from 0 and a firing -75%%", got)
+	}
+	if s.Value() != -75 {
+		t.Errorf("Value = %g, want -75", s.Value())
+	}
+}
+
+func TestAbsence(t *testing.T) {
+	r := testRule(t, "absence", `{"window": "10m"}`, nil)
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSeries(r, t0)
+
+	if tr := s.Check(t0.Add(9 * time.Minute)); tr != Unchanged {
+		t.Fatalf("Check before the window = %v", tr)
+	}
+	s.Observe(Point{Time: t0.Add(5 * time.Minute), Value: 1})
+	if tr := s.Check(t0.Add(14 * time.Minute)); tr != Unchanged {
+		t.Fatalf("Check after data = %v", tr)
+	}
+	if tr := s.Check(t0.Add(15 * time.Minute)); tr != Fired || s.Value() != 600 {
+		t.Fatalf("Check = %v, %g, want firing after 600s", tr, s.Value())
+	}
+	if tr := s.Check(t0.Add(20 * time.Minute)); tr != Unchanged {
+		t.Fatalf("Check while firing = %v", tr)
+	}
+	if tr := s.Observe(Point{Time: t0.Add(21 * time.Minute), Value: 1}); tr != Resolved {
+		t.Fatalf("Observe while firing = %v, want resolved", tr)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	r := testRule(t, "threshold", `{"metric": "cpu_percent", "operator": ">"}`, ptr(90.0))
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := func(values ...float64) []Point {
+		var p []Point
+		for i, v := range values {
+			p = append(p, Point{Time: t0.Add(time.Duration(i) * time.Minute), Value: v})
+		}
+		return p
+	}
+
+	b := &binding{rule: r, series: make(map[string]*Series)}
+	if _, ok := b.replay(points(95, 50)); ok {
+		t.Error("a condition that cleared in the stored series should not be reported")
+	}
+
+	b = &binding{rule: r, series: make(map[string]*Series)}
+	if ev, ok := b.replay(points(50, 95, 97)); !ok || ev.transition != Fired || ev.value != 95 || !ev.at.Equal(t0.Add(time.Minute)) {
+		t.Errorf("replay = %+v, %v, want firing at the first breach", ev, ok)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := func(values ...float64) []Point {
+		var p []Point
+		for i, v := range values {
+			p = append(p, Point{Time: t0.Add(time.Duration(i) * time.Minute), Value: v})
+		}
+		return p
+	}
+
+	r := testRule(t, "threshold", `{"metric": "cpu_percent", "operator": ">=", "for": "1m"}`, ptr(90.0))
+	got := Preview(r, points(50, 90, 95, 50, 99, 99), t0, t0.Add(time.Hour))
+	if len(got) != 2 {
+		t.Fatalf("firings = %+v, want 2", got)
+	}
+	if !got[0].Start.Equal(t0.Add(2*time.Minute)) || got[0].End == nil || !got[0].End.Equal(t0.Add(3*time.Minute)) || got[0].Value != 95 {
+		t.Errorf("first firing = %+v", got[0])
+	}
+	if !got[1].Start.Equal(t0.Add(5*time.Minute)) || got[1].End != nil {
+		t.Errorf("second firing = %+v, want one still firing", got[1])
+	}
+
+	absence := testRule(t, "absence", `{"window": "10m"}`, nil)
+	polls := []Point{{Time: t0.Add(5 * time.Minute), Value: 1}, {Time: t0.Add(30 * time.Minute), Value: 1}}
+	got = Preview(absence, polls, t0, t0.Add(50*time.Minute))
+	if len(got) != 2 || !got[0].Start.Equal(t0.Add(15*time.Minute)) || !got[0].End.Equal(t0.Add(30*time.Minute)) ||
+		!got[1].Start.Equal(t0.Add(40*time.Minute)) || got[1].End != nil {
+		t.Errorf("absence firings = %+v", got)
+	}
+}
@@ -0,0 +1,515 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/maintenance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// refreshInterval is how often the engine reloads rules; changes made
+// through the API are applied at once with Refresh
+const refreshInterval = 30 * time.Second
+
+// checkInterval is how often absence rules are checked
+const checkInterval = 15 * time.Second
+
+// binding is a rule applied to one of its routers, with the rule's series
+// for the router by interface name ("" for router metrics)
+type binding struct {
+	rule   *Rule
+	target *repository.AlertRuleTarget
+	series map[string]*Series
+}
+
+// event is a transition of a series, raised or resolved once the engine's
+// lock is released
+type event struct {
+	binding    *binding
+	iface      string
+	transition Transition
+	value      float64
+	at         time.Time
+}
+
+// Engine evaluates the enabled alert rules of every tenant against poll
+// results. Firing rules raise alerts with the rule's ID, which are resolved
+// once the rule clears, is disabled or no longer applies to the router.
+// A nil Engine evaluates nothing.
+type Engine struct {
+	rules   repository.AlertRuleRepository
+	alerts  repository.AlertRepository
+	windows *maintenance.Checker
+	started time.Time
+
+	refreshMu sync.Mutex
+	mu        sync.Mutex
+	bindings  map[uuid.UUID][]*binding // By router
+}
+
+// NewEngine creates a new alert rule engine. Alerts about routers in
+// maintenance per windows are raised suppressed.
+func NewEngine(db *database.DB, windows *maintenance.Checker) *Engine {
+	return &Engine{
+		rules:    postgres.NewAlertRuleRepo(db.DB),
+		alerts:   postgres.NewAlertRepo(db.DB),
+		windows:  windows,
+		started:  time.Now(),
+		bindings: make(map[uuid.UUID][]*binding),
+	}
+}
+
+// Start reloads rules and checks absence rules periodically until ctx is
+// cancelled. Call Refresh first so that rules apply to the first polls.
+func (e *Engine) Start(ctx context.Context) {
+	log.Printf("Alert rule engine started (refresh every %s)", refreshInterval)
+
+	refresh := time.NewTicker(refreshInterval)
+	defer refresh.Stop()
+	check := time.NewTicker(checkInterval)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Alert rule engine stopped")
+			return
+		case <-refresh.C:
+			if err := e.Refresh(ctx); err != nil {
+				log.Printf("Error loading alert rules: %v", err)
+			}
+		case now := <-check.C:
+			e.Check(ctx, now)
+		}
+	}
+}
+
+// Refresh reloads the enabled rules and the routers they apply to. Series
+// of unchanged rules keep their state; new ones start firing when their rule
+// has an unresolved alert and are fed the router's stored series, so that
+// rules apply to past polls too. Alerts of rules that no longer apply are
+// resolved.
+func (e *Engine) Refresh(ctx context.Context) error {
+	if e == nil {
+		return nil
+	}
+	e.refreshMu.Lock()
+	defer e.refreshMu.Unlock()
+
+	now := time.Now()
+	stored, err := e.rules.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	unresolved, err := e.alerts.ListUnresolvedBySource(ctx, models.EventSourceAlertRule)
+	if err != nil {
+		return err
+	}
+	firing := make(map[string]bool, len(unresolved))
+	for _, a := range unresolved {
+		if a.DedupKey != nil {
+			firing[*a.DedupKey] = true
+		}
+	}
+
+	e.mu.Lock()
+	previous := e.bindings
+	e.mu.Unlock()
+
+	type loaded struct {
+		rule    *Rule
+		targets []*repository.AlertRuleTarget
+		points  map[uuid.UUID][]Point // Stored series of the new bindings
+	}
+	var rules []loaded
+	for _, m := range stored {
+		rule, err := Compile(m)
+		if err != nil {
+			log.Printf("Skipping alert rule %s (%s): %v", m.ID, m.Name, err)
+			continue
+		}
+		targets, err := e.rules.ListTargets(ctx, m, rule.Interface)
+		if err != nil {
+			return err
+		}
+		l := loaded{rule: rule, targets: targets}
+
+		var routerIDs []uuid.UUID
+		for _, t := range targets {
+			if findBinding(previous[t.RouterID], rule) == nil {
+				routerIDs = append(routerIDs, t.RouterID)
+			}
+		}
+		if rule.stored() && len(routerIDs) > 0 {
+			points, err := e.rules.ListPoints(ctx, rule.Metric, routerIDs, now.Add(-rule.lookback()), now)
+			if err != nil {
+				return err
+			}
+			l.points = make(map[uuid.UUID][]Point)
+			for _, p := range points {
+				l.points[p.RouterID] = append(l.points[p.RouterID], Point{Time: p.Timestamp, Value: p.Value})
+			}
+		}
+		rules = append(rules, l)
+	}
+
+	var events []event
+	e.mu.Lock()
+	bindings := make(map[uuid.UUID][]*binding)
+	for _, l := range rules {
+		for _, t := range l.targets {
+			b := findBinding(e.bindings[t.RouterID], l.rule)
+			if b != nil {
+				b.rule, b.target = l.rule, t
+				b.sync(firing)
+			} else {
+				b = e.newBinding(l.rule, t)
+				b.sync(firing)
+				if ev, ok := b.replay(l.points[t.RouterID]); ok {
+					events = append(events, ev)
+				}
+			}
+			bindings[t.RouterID] = append(bindings[t.RouterID], b)
+		}
+	}
+	e.bindings = bindings
+	e.mu.Unlock()
+
+	e.apply(ctx, events)
+
+	// Resolve the alerts of rules no longer applying to their router
+	for _, a := range unresolved {
+		if a.DedupKey == nil || e.applies(*a.DedupKey) {
+			continue
+		}
+		if _, err := e.alerts.ResolveByDedupKey(ctx, a.TenantID, *a.DedupKey, now); err != nil {
+			log.Printf("Error resolving alert %s of a removed rule: %v", a.ID, err)
+		}
+	}
+	return nil
+}
+
+// Observe evaluates the rules applying to a router against its poll result
+func (e *Engine) Observe(ctx context.Context, result *adapter.PollResult) {
+	if e == nil || !result.Success {
+		return
+	}
+
+	var events []event
+	e.mu.Lock()
+	for _, b := range e.bindings[result.RouterID] {
+		events = append(events, b.observe(result)...)
+	}
+	e.mu.Unlock()
+
+	e.apply(ctx, events)
+}
+
+// Check fires the absence rules of series without data for their window.
+// Rules fire a window after the engine started at the earliest, so that
+// a poller restart does not fire them.
+func (e *Engine) Check(ctx context.Context, now time.Time) {
+	if e == nil {
+		return
+	}
+
+	var events []event
+	e.mu.Lock()
+	for _, bindings := range e.bindings {
+		for _, b := range bindings {
+			if b.rule.Type != models.AlertRuleAbsence {
+				continue
+			}
+			for name, s := range b.series {
+				if tr := s.Check(now); tr != Unchanged {
+					events = append(events, event{binding: b, iface: name, transition: tr, value: s.Value(), at: now})
+				}
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	e.apply(ctx, events)
+}
+
+// applies reports whether an alert's dedup key belongs to a current binding
+func (e *Engine) applies(dedupKey string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	parts := strings.SplitN(dedupKey, ":", 4)
+	if len(parts) < 3 {
+		return false
+	}
+	routerID, err := uuid.Parse(parts[2])
+	if err != nil {
+		return false
+	}
+	for _, b := range e.bindings[routerID] {
+		if _, ok := b.owns(dedupKey); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newBinding applies a rule to a router. Absence rules start counting from
+// the router's last poll, or from the engine's start when that is later.
+func (e *Engine) newBinding(rule *Rule, target *repository.AlertRuleTarget) *binding {
+	b := &binding{rule: rule, target: target, series: make(map[string]*Series)}
+	if rule.Type != models.AlertRuleAbsence {
+		return b
+	}
+
+	lastSeen := e.started
+	if target.LastPolledAt != nil && target.LastPolledAt.After(lastSeen) {
+		lastSeen = *target.LastPolledAt
+	}
+	if !rule.Interface {
+		b.series[""] = NewSeries(rule, lastSeen)
+	}
+	for name := range target.Interfaces {
+		b.series[name] = NewSeries(rule, lastSeen)
+	}
+	return b
+}
+
+// apply raises and resolves the alerts of events
+func (e *Engine) apply(ctx context.Context, events []event) {
+	for _, ev := range events {
+		b := ev.binding
+		dedupKey := b.dedupKey(ev.iface)
+
+		if ev.transition == Resolved {
+			if _, err := e.alerts.ResolveByDedupKey(ctx, b.rule.TenantID, dedupKey, ev.at); err != nil {
+				log.Printf("Error resolving alert of rule %q for router %s: %v", b.rule.Name, b.target.RouterName, err)
+			}
+			continue
+		}
+
+		alert := b.alert(ev, dedupKey)
+		e.windows.Suppress(alert, b.target.RouterID)
+		if _, err := e.alerts.Raise(ctx, alert); err != nil {
+			log.Printf("Error raising alert of rule %q for router %s: %v", b.rule.Name, b.target.RouterName, err)
+		}
+	}
+}
+
+// findBinding returns the binding of an unchanged rule among a router's
+func findBinding(bindings []*binding, rule *Rule) *binding {
+	for _, b := range bindings {
+		if b.rule.ID == rule.ID && b.rule.Source.UpdatedAt.Equal(rule.Source.UpdatedAt) {
+			return b
+		}
+	}
+	return nil
+}
+
+// get returns the series of an interface, "" for router metrics
+func (b *binding) get(name string) *Series {
+	s := b.series[name]
+	if s == nil {
+		s = NewSeries(b.rule, time.Now())
+		b.series[name] = s
+	}
+	return s
+}
+
+// observe feeds a poll result to the binding's series
+func (b *binding) observe(result *adapter.PollResult) []event {
+	var events []event
+	feed := func(name string, v float64) {
+		s := b.get(name)
+		if tr := s.Observe(Point{Time: result.Timestamp, Value: v}); tr != Unchanged {
+			events = append(events, event{binding: b, iface: name, transition: tr, value: s.Value(), at: result.Timestamp})
+		}
+	}
+
+	switch {
+	case b.rule.Interface:
+		for i := range result.Interfaces {
+			iface := &result.Interfaces[i]
+			if b.rule.TargetType == models.AlertTargetInterface {
+				if _, ok := b.target.Interfaces[iface.Name]; !ok {
+					continue
+				}
+			}
+			if v, ok := interfaceValue(b.rule.Metric, iface); ok {
+				feed(iface.Name, v)
+			}
+		}
+	case b.rule.Metric == "":
+		feed("", 1)
+	default:
+		if v, ok := result.Metrics.Float(b.rule.Metric); ok {
+			feed("", v)
+		}
+	}
+	return events
+}
+
+// sync sets the firing state of the binding's series to that of their
+// alerts, firing per the dedup keys of the unresolved alerts
+func (b *binding) sync(firing map[string]bool) {
+	for name, s := range b.series {
+		if want := firing[b.dedupKey(name)]; s.Firing() != want {
+			s.SetFiring(want)
+		}
+	}
+
+	prefix := b.dedupKey("")
+	for key := range firing {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if name, ok := b.owns(key); ok && !b.get(name).Firing() {
+			b.series[name].SetFiring(true)
+		}
+	}
+}
+
+// replay feeds stored points to the router series of a new binding. Only a
+// change of its firing state is reported, so that alerts are not raised for
+// conditions that already cleared.
+func (b *binding) replay(points []Point) (event, bool) {
+	if len(points) == 0 {
+		return event{}, false
+	}
+
+	s := b.get("")
+	firing := s.Firing()
+	var last event
+	for _, p := range points {
+		if tr := s.Observe(p); tr != Unchanged {
+			last = event{binding: b, transition: tr, value: s.Value(), at: p.Time}
+		}
+	}
+	return last, s.Firing() != firing
+}
+
+// dedupKey identifies the alert of a series
+func (b *binding) dedupKey(name string) string {
+	key := fmt.Sprintf("%s:%s:%s", models.EventSourceAlertRule, b.rule.ID, b.target.RouterID)
+	if name != "" {
+		key += ":" + name
+	}
+	return key
+}
+
+// owns reports whether a dedup key is one of the binding's, and of which
+// interface
+func (b *binding) owns(dedupKey string) (string, bool) {
+	prefix := b.dedupKey("")
+	if dedupKey == prefix {
+		return "", !b.rule.Interface
+	}
+	name, ok := strings.CutPrefix(dedupKey, prefix+":")
+	if !ok || !b.rule.Interface {
+		return "", false
+	}
+	if b.rule.TargetType == models.AlertTargetInterface {
+		_, ok = b.target.Interfaces[name]
+	}
+	return name, ok
+}
+
+// alert builds the alert raised by an event
+func (b *binding) alert(ev event, dedupKey string) *models.Alert {
+	r := b.rule
+	ruleID := r.ID
+
+	targetType := models.AlertTargetRouter
+	targetID := b.target.RouterID
+	if id, ok := b.target.Interfaces[ev.iface]; ok && ev.iface != "" {
+		targetType, targetID = models.AlertTargetInterface, id
+	}
+
+	subject := b.target.RouterName
+	if ev.iface != "" {
+		subject += " " + ev.iface
+	}
+	var description string
+	switch r.Type {
+	case models.AlertRuleAbsence:
+		what := "successful poll"
+		if r.Metric != "" {
+			what = r.Metric
+		}
+		description = fmt.Sprintf("No %s from %s for %s", what, subject, r.Window)
+	case models.AlertRuleRateOfChange:
+		unit := ""
+		if r.Percent {
+			unit = "%"
+		}
+		description = fmt.Sprintf("%s of %s changed by %g%s over %s (%s %g%s)",
+			r.Metric, subject, ev.value, unit, r.Window, r.Operator, r.Threshold, unit)
+	default:
+		description = fmt.Sprintf("%s of %s is %g (%s %g", r.Metric, subject, ev.value, r.Operator, r.Threshold)
+		if r.For > 0 {
+			description += " for " + r.For.String()
+		}
+		description += ")"
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"source":    models.EventSourceAlertRule,
+		"rule_id":   r.ID,
+		"rule_type": r.Type,
+		"router_id": b.target.RouterID,
+		"interface": ev.iface,
+		"metric":    r.Metric,
+		"value":     ev.value,
+		"threshold": r.Threshold,
+	})
+	metadataStr := string(metadata)
+
+	return &models.Alert{
+		TenantID:    r.TenantID,
+		RuleID:      &ruleID,
+		Name:        r.Name,
+		Description: &description,
+		Severity:    r.Severity,
+		TargetType:  &targetType,
+		TargetID:    &targetID,
+		TriggeredAt: ev.at,
+		Metadata:    &metadataStr,
+		DedupKey:    &dedupKey,
+	}
+}
+
+// interfaceValue reads an interface metric
+func interfaceValue(name string, iface *adapter.InterfaceStatus) (float64, bool) {
+	switch name {
+	case MetricIfUtilization:
+		return iface.UtilizationPercent, true
+	case MetricIfUp:
+		if iface.Status == "up" {
+			return 1, true
+		}
+		return 0, true
+	case MetricIfInOctets:
+		return float64(iface.InOctets), true
+	case MetricIfOutOctets:
+		return float64(iface.OutOctets), true
+	case MetricIfInErrors:
+		return float64(iface.InErrors), true
+	case MetricIfOutErrors:
+		return float64(iface.OutErrors), true
+	case MetricIfInDiscards:
+		return float64(iface.InDiscards), true
+	case MetricIfOutDiscards:
+		return float64(iface.OutDiscards), true
+	}
+	return 0, false
+}
@@ -0,0 +1,164 @@
+package alerting
+
+import (
+	"math"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// Point is a value of a series at a time
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Transition is the change of a series' state caused by a point or check
+type Transition int
+
+const (
+	Unchanged Transition = iota
+	Fired
+	Resolved
+)
+
+// Series is the evaluation state of a rule for one router or interface.
+// Points must be fed in time order. A Series is not safe for concurrent use.
+type Series struct {
+	rule *Rule
+
+	firing       bool
+	pendingSince time.Time // Since when the condition holds, while not firing
+	clearSince   time.Time // Since when the value is cleared, while firing
+	lastSeen     time.Time // Last point, for absence rules
+	history      []Point   // Points within the window, for rate rules
+	value        float64
+}
+
+// NewSeries creates the state of a rule for one router or interface.
+// Absence rules count the time without data from lastSeen.
+func NewSeries(rule *Rule, lastSeen time.Time) *Series {
+	return &Series{rule: rule, lastSeen: lastSeen}
+}
+
+// Firing reports whether the rule fires for the series
+func (s *Series) Firing() bool {
+	return s.firing
+}
+
+// SetFiring restores the firing state, e.g. from an unresolved alert
+func (s *Series) SetFiring(firing bool) {
+	s.firing = firing
+	s.pendingSince, s.clearSince = time.Time{}, time.Time{}
+}
+
+// Value returns the value compared at the last transition: the metric for
+// threshold rules, its change for rate-of-change rules and the seconds
+// without data for absence rules
+func (s *Series) Value() float64 {
+	return s.value
+}
+
+// Observe feeds a point of the series
+func (s *Series) Observe(p Point) Transition {
+	switch s.rule.Type {
+	case models.AlertRuleAbsence:
+		s.lastSeen = p.Time
+		if s.firing {
+			s.SetFiring(false)
+			s.value = 0
+			return Resolved
+		}
+		return Unchanged
+	case models.AlertRuleRateOfChange:
+		change, ok := s.change(p)
+		if !ok {
+			return Unchanged
+		}
+		return s.compare(p.Time, change)
+	default:
+		return s.compare(p.Time, p.Value)
+	}
+}
+
+// Check fires an absence rule when no point was observed for its window
+func (s *Series) Check(now time.Time) Transition {
+	if s.rule.Type != models.AlertRuleAbsence || s.firing {
+		return Unchanged
+	}
+	if gap := now.Sub(s.lastSeen); gap >= s.rule.Window {
+		s.firing = true
+		s.value = math.Round(gap.Seconds())
+		return Fired
+	}
+	return Unchanged
+}
+
+// compare applies the threshold with its for duration and hysteresis
+func (s *Series) compare(at time.Time, v float64) Transition {
+	r := s.rule
+
+	if !s.firing {
+		if !r.breached(v) {
+			s.pendingSince = time.Time{}
+			return Unchanged
+		}
+		if s.pendingSince.IsZero() {
+			s.pendingSince = at
+		}
+		if at.Sub(s.pendingSince) < r.For {
+			return Unchanged
+		}
+		s.SetFiring(true)
+		s.value = v
+		return Fired
+	}
+
+	if !r.cleared(v) {
+		s.clearSince = time.Time{}
+		return Unchanged
+	}
+	if s.clearSince.IsZero() {
+		s.clearSince = at
+	}
+	if at.Sub(s.clearSince) < r.ClearFor {
+		return Unchanged
+	}
+	s.SetFiring(false)
+	s.value = v
+	return Resolved
+}
+
+// change returns the change of the series over the rule's window, from the
+// latest point at least a window older than p. It reports false until the
+// series is a window long and after a counter reset.
+func (s *Series) change(p Point) (float64, bool) {
+	r := s.rule
+
+	if n := len(s.history); n > 0 && r.Counter && p.Value < s.history[n-1].Value {
+		s.history = s.history[:0]
+	}
+	s.history = append(s.history, p)
+
+	base := -1
+	for i, h := range s.history {
+		if p.Time.Sub(h.Time) < r.Window {
+			break
+		}
+		base = i
+	}
+	if base < 0 {
+		return 0, false
+	}
+	s.history = s.history[base:]
+
+	from := s.history[0].Value
+	delta := p.Value - from
+	if !r.Percent {
+		return delta, true
+	}
+	if from == 0 {
+		return 0, false
+	}
+	return delta / math.Abs(from) * 100, true
+}
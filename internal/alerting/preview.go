@@ -0,0 +1,59 @@
+package alerting
+
+import (
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// Firing is a period during which a rule fired for a series; End is nil
+// when the rule was still firing at the end of the previewed range
+type Firing struct {
+	Start time.Time
+	End   *time.Time
+	Value float64 // Value compared when the rule fired
+}
+
+// Preview replays a series' points in [from, to) through a rule and returns
+// the periods during which the rule would have fired. Absence rules fire a
+// window after the previous point, or after from for the first one.
+func Preview(rule *Rule, points []Point, from, to time.Time) []Firing {
+	firings := make([]Firing, 0)
+	s := NewSeries(rule, from)
+
+	fire := func(at time.Time) {
+		firings = append(firings, Firing{Start: at, Value: s.Value()})
+	}
+	resolve := func(at time.Time) {
+		end := at
+		firings[len(firings)-1].End = &end
+	}
+	// An absence rule fires a window after the last point, when the gap up
+	// to at is at least a window long
+	checkAbsence := func(at time.Time) {
+		start := s.lastSeen.Add(rule.Window)
+		if s.Check(at) == Fired {
+			s.value = rule.Window.Seconds()
+			fire(start)
+		}
+	}
+
+	for _, p := range points {
+		if p.Time.Before(from) || !p.Time.Before(to) {
+			continue
+		}
+		if rule.Type == models.AlertRuleAbsence {
+			checkAbsence(p.Time)
+		}
+		switch s.Observe(p) {
+		case Fired:
+			fire(p.Time)
+		case Resolved:
+			resolve(p.Time)
+		}
+	}
+	if rule.Type == models.AlertRuleAbsence {
+		checkAbsence(to)
+	}
+	return firings
+}
@@ -0,0 +1,304 @@
+// Package alerting evaluates the alert rules of the alert_rules table
+// against poll results. Threshold rules fire once a metric stays beyond
+// their threshold for a duration, rate-of-change rules when a metric changes
+// by more than their threshold over a window, and absence rules when a
+// router sends no data for a window. Firing rules raise alerts carrying the
+// rule; they are resolved once the metric is back past the rule's clear
+// threshold, so that values hovering around the threshold do not flap.
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Comparison operators of threshold and rate-of-change rules
+const (
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+)
+
+// Interface metrics, read from each interface of a poll result. Counters are
+// the totals reported by the router; compare them with rate-of-change rules.
+const (
+	MetricIfUtilization = "if_utilization_percent"
+	MetricIfUp          = "if_up" // 1 when operationally up, else 0
+	MetricIfInOctets    = "if_in_octets"
+	MetricIfOutOctets   = "if_out_octets"
+	MetricIfInErrors    = "if_in_errors"
+	MetricIfOutErrors   = "if_out_errors"
+	MetricIfInDiscards  = "if_in_discards"
+	MetricIfOutDiscards = "if_out_discards"
+)
+
+// interfaceMetrics lists the interface metrics; true marks counters
+var interfaceMetrics = map[string]bool{
+	MetricIfUtilization: false,
+	MetricIfUp:          false,
+	MetricIfInOctets:    true,
+	MetricIfOutOctets:   true,
+	MetricIfInErrors:    true,
+	MetricIfOutErrors:   true,
+	MetricIfInDiscards:  true,
+	MetricIfOutDiscards: true,
+}
+
+// Duration is a time.Duration written in JSON as a Go duration string, e.g. "5m"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5m\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON writes a duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Condition is the JSON condition of an alert rule. The threshold itself is
+// the rule's threshold_value.
+type Condition struct {
+	// Router metric of metric.Default or interface metric (if_*). Absence
+	// rules without a metric fire when a router is not polled successfully.
+	Metric string `json:"metric"`
+
+	// How the value, or change for rate-of-change rules, compares with the
+	// threshold when the rule fires
+	Operator string `json:"operator,omitempty"`
+
+	// How long the comparison must hold before the rule fires
+	For Duration `json:"for,omitempty"`
+
+	// Hysteresis: a firing rule resolves once the value is back past
+	// ClearThreshold (the threshold when unset) for ClearFor
+	ClearThreshold *float64 `json:"clear_threshold,omitempty"`
+	ClearFor       Duration `json:"clear_for,omitempty"`
+
+	// Rate-of-change rules compare the change over Window, in percent of the
+	// earlier value with Percent; absence rules fire after Window without data
+	Window  Duration `json:"window,omitempty"`
+	Percent bool     `json:"percent,omitempty"`
+}
+
+// Rule is a validated alert rule
+type Rule struct {
+	ID         uuid.UUID
+	TenantID   uuid.UUID
+	Name       string
+	Type       string
+	Severity   string
+	TargetType string // Empty for every router of the tenant
+	TargetID   *uuid.UUID
+
+	Metric    string
+	Interface bool // Metric is read per interface
+	Counter   bool // Metric is an interface counter
+	Operator  string
+	Threshold float64
+	Clear     float64
+	For       time.Duration
+	ClearFor  time.Duration
+	Window    time.Duration
+	Percent   bool
+
+	Source *models.AlertRule
+}
+
+// Compile validates a stored rule and its condition
+func Compile(rule *models.AlertRule) (*Rule, error) {
+	var cond Condition
+	dec := json.NewDecoder(strings.NewReader(rule.Condition))
+	dec.DisallowUnknownFields()
+	if rule.Condition != "" {
+		if err := dec.Decode(&cond); err != nil {
+			return nil, fmt.Errorf("condition: %w", err)
+		}
+		if dec.More() {
+			return nil, fmt.Errorf("condition: unexpected data after the object")
+		}
+	}
+
+	r := &Rule{
+		ID:       rule.ID,
+		TenantID: rule.TenantID,
+		Name:     rule.Name,
+		Type:     rule.RuleType,
+		Severity: rule.Severity,
+		TargetID: rule.TargetID,
+		Metric:   cond.Metric,
+		Operator: cond.Operator,
+		For:      time.Duration(cond.For),
+		ClearFor: time.Duration(cond.ClearFor),
+		Window:   time.Duration(cond.Window),
+		Percent:  cond.Percent,
+		Source:   rule,
+	}
+	if rule.TargetType != nil {
+		r.TargetType = *rule.TargetType
+	}
+	if r.Severity == "" {
+		r.Severity = models.SeverityWarning
+	}
+
+	if err := r.validateTarget(); err != nil {
+		return nil, err
+	}
+	if err := r.validateMetric(); err != nil {
+		return nil, err
+	}
+
+	switch r.Type {
+	case models.AlertRuleThreshold, models.AlertRuleRateOfChange:
+		if rule.ThresholdValue == nil {
+			return nil, fmt.Errorf("%s rules need threshold_value", r.Type)
+		}
+		r.Threshold = *rule.ThresholdValue
+		if err := r.validateComparison(cond.ClearThreshold); err != nil {
+			return nil, err
+		}
+		if r.Type == models.AlertRuleRateOfChange && r.Window <= 0 {
+			return nil, fmt.Errorf("rate_of_change rules need a window")
+		}
+		if r.Type == models.AlertRuleThreshold && (r.Window != 0 || r.Percent) {
+			return nil, fmt.Errorf("window and percent apply to rate_of_change rules only")
+		}
+	case models.AlertRuleAbsence:
+		if r.Window <= 0 {
+			return nil, fmt.Errorf("absence rules need a window")
+		}
+		if r.Operator != "" || r.For != 0 || r.ClearFor != 0 || cond.ClearThreshold != nil || r.Percent {
+			return nil, fmt.Errorf("absence rules take a metric and window only")
+		}
+	default:
+		return nil, fmt.Errorf("unknown rule_type %q, expected threshold, rate_of_change or absence", r.Type)
+	}
+
+	if r.For < 0 || r.ClearFor < 0 || r.Window < 0 {
+		return nil, fmt.Errorf("durations must not be negative")
+	}
+	return r, nil
+}
+
+func (r *Rule) validateTarget() error {
+	switch r.TargetType {
+	case "":
+		if r.TargetID != nil {
+			return fmt.Errorf("target_id needs a target_type")
+		}
+		return nil
+	case models.AlertTargetRouter, models.AlertTargetInterface, models.AlertTargetRole, models.AlertTargetPOP:
+	default:
+		return fmt.Errorf("unknown target_type %q, expected router, interface, role or pop", r.TargetType)
+	}
+	if r.TargetID == nil {
+		return fmt.Errorf("target_type %s needs a target_id", r.TargetType)
+	}
+	return nil
+}
+
+func (r *Rule) validateMetric() error {
+	if counter, ok := interfaceMetrics[r.Metric]; ok {
+		r.Interface, r.Counter = true, counter
+		return nil
+	}
+	if r.TargetType == models.AlertTargetInterface {
+		return fmt.Errorf("rules targeting an interface need an interface metric (if_*)")
+	}
+	if r.Metric == "" {
+		if r.Type == models.AlertRuleAbsence {
+			return nil // Absence of successful polls
+		}
+		return fmt.Errorf("metric is required")
+	}
+
+	def, ok := metric.Default.Lookup(r.Metric)
+	if !ok {
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	if def.Type == metric.TypeString {
+		return fmt.Errorf("metric %q is not numeric", r.Metric)
+	}
+	return nil
+}
+
+// validateComparison checks the operator and sets the clear threshold,
+// which must not be beyond the threshold
+func (r *Rule) validateComparison(clear *float64) error {
+	r.Clear = r.Threshold
+	if clear != nil {
+		r.Clear = *clear
+	}
+
+	switch r.Operator {
+	case OpGreater, OpGreaterEqual:
+		if r.Clear > r.Threshold {
+			return fmt.Errorf("clear_threshold must not be above the threshold of a %s rule", r.Operator)
+		}
+	case OpLess, OpLessEqual:
+		if r.Clear < r.Threshold {
+			return fmt.Errorf("clear_threshold must not be below the threshold of a %s rule", r.Operator)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q, expected >, >=, < or <=", r.Operator)
+	}
+	return nil
+}
+
+// stored reports whether the rule's metric is stored with poll results,
+// so that its series can be replayed
+func (r *Rule) stored() bool {
+	return r.Type != models.AlertRuleAbsence && r.Metric != "" && !r.Interface
+}
+
+// lookback is how far back stored points are replayed: the rule's longest
+// duration, with a margin for the interval between polls
+func (r *Rule) lookback() time.Duration {
+	return r.Window + max(r.For, r.ClearFor) + 10*time.Minute
+}
+
+// breached reports whether a value fires the rule
+func (r *Rule) breached(v float64) bool {
+	switch r.Operator {
+	case OpGreater:
+		return v > r.Threshold
+	case OpGreaterEqual:
+		return v >= r.Threshold
+	case OpLess:
+		return v < r.Threshold
+	case OpLessEqual:
+		return v <= r.Threshold
+	}
+	return false
+}
+
+// cleared reports whether a value is back past the clear threshold
+func (r *Rule) cleared(v float64) bool {
+	if r.Clear == r.Threshold {
+		return !r.breached(v)
+	}
+	switch r.Operator {
+	case OpGreater, OpGreaterEqual:
+		return v <= r.Clear
+	case OpLess, OpLessEqual:
+		return v >= r.Clear
+	}
+	return false
+}
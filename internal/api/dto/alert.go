@@ -10,6 +10,7 @@ import (
 type AlertDTO struct {
 	ID             uuid.UUID  `json:"id"`
 	TenantID       uuid.UUID  `json:"tenant_id"`
+	RuleID         *uuid.UUID `json:"rule_id,omitempty"`
 	Name           string     `json:"name"`
 	Description    *string    `json:"description,omitempty"`
 	Severity       string     `json:"severity"`
@@ -20,6 +21,10 @@ type AlertDTO struct {
 	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
 	AcknowledgedBy *uuid.UUID `json:"acknowledged_by,omitempty"`
 	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+
+	// Raised during a maintenance window
+	Suppressed          bool       `json:"suppressed"`
+	MaintenanceWindowID *uuid.UUID `json:"maintenance_window_id,omitempty"`
 }
 
 // AcknowledgeAlertRequest represents the request to acknowledge an alert
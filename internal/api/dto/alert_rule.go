@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AlertRuleDTO represents an alert rule in API responses
+type AlertRuleDTO struct {
+	ID             uuid.UUID       `json:"id"`
+	TenantID       uuid.UUID       `json:"tenant_id"`
+	Name           string          `json:"name"`
+	Description    *string         `json:"description,omitempty"`
+	RuleType       string          `json:"rule_type"`
+	TargetType     *string         `json:"target_type,omitempty"`
+	TargetID       *uuid.UUID      `json:"target_id,omitempty"`
+	Condition      json.RawMessage `json:"condition"`
+	ThresholdValue *float64        `json:"threshold_value,omitempty"`
+	Severity       string          `json:"severity"`
+	Enabled        bool            `json:"enabled"`
+	CreatedBy      *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// CreateAlertRuleRequest represents the request to create an alert rule.
+// The fields of condition depend on rule_type; without a target the rule
+// applies to every router of the tenant.
+type CreateAlertRuleRequest struct {
+	Name           string          `json:"name" validate:"required,max=255"`
+	Description    *string         `json:"description,omitempty"`
+	RuleType       string          `json:"rule_type" validate:"required,oneof=threshold rate_of_change absence"`
+	TargetType     *string         `json:"target_type,omitempty" validate:"omitempty,oneof=router interface role pop"`
+	TargetID       *uuid.UUID      `json:"target_id,omitempty"`
+	Condition      json.RawMessage `json:"condition" validate:"required"`
+	ThresholdValue *float64        `json:"threshold_value,omitempty"`
+	Severity       string          `json:"severity,omitempty" validate:"omitempty,oneof=critical warning info"`
+	Enabled        *bool           `json:"enabled,omitempty"`
+}
+
+// UpdateAlertRuleRequest represents the request to update an alert rule;
+// an empty target_type removes the target
+type UpdateAlertRuleRequest struct {
+	Name           *string         `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description    *string         `json:"description,omitempty"`
+	RuleType       *string         `json:"rule_type,omitempty" validate:"omitempty,oneof=threshold rate_of_change absence"`
+	TargetType     *string         `json:"target_type,omitempty" validate:"omitempty,oneof=router interface role pop"`
+	TargetID       *uuid.UUID      `json:"target_id,omitempty"`
+	Condition      json.RawMessage `json:"condition,omitempty"`
+	ThresholdValue *float64        `json:"threshold_value,omitempty"`
+	Severity       *string         `json:"severity,omitempty" validate:"omitempty,oneof=critical warning info"`
+	Enabled        *bool           `json:"enabled,omitempty"`
+}
+
+// AlertRulePreviewDTO lists when a draft rule would have fired over the
+// stored series of the routers it applies to
+type AlertRulePreviewDTO struct {
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	Routers int                  `json:"routers"` // Routers the rule applies to
+	Points  int                  `json:"points"`  // Stored points evaluated
+	Firings []AlertRuleFiringDTO `json:"firings"`
+}
+
+// AlertRuleFiringDTO is a period during which a previewed rule fired for a
+// router; resolved_at is absent when it still fired at the end of the range
+type AlertRuleFiringDTO struct {
+	RouterID   uuid.UUID  `json:"router_id"`
+	RouterName string     `json:"router_name"`
+	FiredAt    time.Time  `json:"fired_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Value      float64    `json:"value"`
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ComplianceRuleDTO represents a required-line or forbidden-pattern check
+type ComplianceRuleDTO struct {
+	Type        string `json:"type" validate:"required,oneof=required_line forbidden_pattern"`
+	Pattern     string `json:"pattern" validate:"required"`
+	Regex       bool   `json:"regex,omitempty"`
+	Description string `json:"description,omitempty"`
+	Severity    string `json:"severity,omitempty" validate:"omitempty,oneof=critical warning info"`
+}
+
+// ComplianceSectionDTO represents a templated section required for a role
+type ComplianceSectionDTO struct {
+	Name     string `json:"name" validate:"required"`
+	Template string `json:"template" validate:"required"`
+	Severity string `json:"severity,omitempty" validate:"omitempty,oneof=critical warning info"`
+}
+
+// CompliancePolicyDTO represents a compliance policy in API responses
+type CompliancePolicyDTO struct {
+	ID           uuid.UUID                         `json:"id"`
+	TenantID     uuid.UUID                         `json:"tenant_id"`
+	Name         string                            `json:"name"`
+	Description  *string                           `json:"description,omitempty"`
+	Rules        []ComplianceRuleDTO               `json:"rules"`
+	RoleSections map[string][]ComplianceSectionDTO `json:"role_sections"`
+	IsActive     bool                              `json:"is_active"`
+	CreatedAt    time.Time                         `json:"created_at"`
+	UpdatedAt    time.Time                         `json:"updated_at"`
+}
+
+// CreateCompliancePolicyRequest represents the request to create a compliance policy
+type CreateCompliancePolicyRequest struct {
+	Name         string                            `json:"name" validate:"required,max=255"`
+	Description  *string                           `json:"description,omitempty"`
+	Rules        []ComplianceRuleDTO               `json:"rules" validate:"dive"`
+	RoleSections map[string][]ComplianceSectionDTO `json:"role_sections" validate:"dive,dive"`
+	IsActive     *bool                             `json:"is_active,omitempty"`
+}
+
+// UpdateCompliancePolicyRequest represents the request to update a compliance policy
+type UpdateCompliancePolicyRequest struct {
+	Name         *string                           `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description  *string                           `json:"description,omitempty"`
+	Rules        []ComplianceRuleDTO               `json:"rules,omitempty" validate:"omitempty,dive"`
+	RoleSections map[string][]ComplianceSectionDTO `json:"role_sections,omitempty" validate:"omitempty,dive,dive"`
+	IsActive     *bool                             `json:"is_active,omitempty"`
+}
+
+// ComplianceViolationDTO represents one failed compliance check
+type ComplianceViolationDTO struct {
+	PolicyID     uuid.UUID `json:"policy_id"`
+	PolicyName   string    `json:"policy_name"`
+	Type         string    `json:"type"`
+	Check        string    `json:"check"`
+	Severity     string    `json:"severity"`
+	Message      string    `json:"message"`
+	Line         int       `json:"line,omitempty"`
+	Content      string    `json:"content,omitempty"`
+	MissingLines []string  `json:"missing_lines,omitempty"`
+}
+
+// RouterComplianceDTO represents a router's compliance across the evaluated policies
+type RouterComplianceDTO struct {
+	RouterID      uuid.UUID                `json:"router_id"`
+	RouterName    string                   `json:"router_name"`
+	RoleCodes     []string                 `json:"role_codes"`
+	ConfigVersion int                      `json:"config_version"`
+	Score         float64                  `json:"score"`
+	PassedChecks  int                      `json:"passed_checks"`
+	TotalChecks   int                      `json:"total_checks"`
+	Compliant     bool                     `json:"compliant"`
+	Violations    []ComplianceViolationDTO `json:"violations"`
+	EvaluatedAt   time.Time                `json:"evaluated_at"`
+}
+
+// ComplianceReportDTO represents a fleet-wide compliance report
+type ComplianceReportDTO struct {
+	GeneratedAt         time.Time             `json:"generated_at"`
+	RoutersEvaluated    int                   `json:"routers_evaluated"`
+	CompliantRouters    int                   `json:"compliant_routers"`
+	NonCompliantRouters int                   `json:"non_compliant_routers"`
+	AverageScore        float64               `json:"average_score"`
+	Routers             []RouterComplianceDTO `json:"routers"`
+}
+
+// ComplianceEvaluationDTO represents the outcome of an on-demand evaluation
+type ComplianceEvaluationDTO struct {
+	RoutersEvaluated int `json:"routers_evaluated"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConfigVersionDTO represents a stored configuration version in API responses
+type ConfigVersionDTO struct {
+	ID               uuid.UUID `json:"id"`
+	RouterID         uuid.UUID `json:"router_id"`
+	Version          int       `json:"version"`
+	ContentHash      string    `json:"content_hash"`
+	SizeBytes        int       `json:"size_bytes"`
+	CollectionMethod string    `json:"collection_method"`
+	LinesAdded       int       `json:"lines_added"`
+	LinesRemoved     int       `json:"lines_removed"`
+	CollectedAt      time.Time `json:"collected_at"`
+	LastVerifiedAt   time.Time `json:"last_verified_at"`
+	Content          string    `json:"content,omitempty"`
+}
+
+// ConfigDiffDTO represents a unified diff between two configuration versions
+type ConfigDiffDTO struct {
+	RouterID     uuid.UUID `json:"router_id"`
+	FromVersion  int       `json:"from_version"`
+	ToVersion    int       `json:"to_version"`
+	LinesAdded   int       `json:"lines_added"`
+	LinesRemoved int       `json:"lines_removed"`
+	Identical    bool      `json:"identical"`
+	Diff         string    `json:"diff"`
+}
@@ -0,0 +1,105 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CredentialProfileDTO represents a credential profile in API responses.
+// Secrets are never returned; SecretsSet names the ones holding a value.
+type CredentialProfileDTO struct {
+	ID                 uuid.UUID  `json:"id"`
+	TenantID           uuid.UUID  `json:"tenant_id"`
+	Name               string     `json:"name"`
+	Method             string     `json:"method"`
+	Description        *string    `json:"description,omitempty"`
+	SNMPVersion        *string    `json:"snmp_version,omitempty"`
+	SNMPV3AuthProtocol *string    `json:"snmp_v3_auth_protocol,omitempty"`
+	SNMPV3PrivProtocol *string    `json:"snmp_v3_priv_protocol,omitempty"`
+	Username           *string    `json:"username,omitempty"`
+	SecretsSet         []string   `json:"secrets_set"`
+	RouterCount        int        `json:"router_count"`
+	RotatedAt          *time.Time `json:"rotated_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// CredentialSecrets holds the secrets of a profile in requests. An empty
+// string clears a secret; an absent one keeps it.
+type CredentialSecrets struct {
+	SNMPCommunity      *string `json:"snmp_community,omitempty"`
+	SNMPV3AuthPassword *string `json:"snmp_v3_auth_password,omitempty"`
+	SNMPV3PrivPassword *string `json:"snmp_v3_priv_password,omitempty"`
+	Password           *string `json:"password,omitempty"`
+	PrivateKey         *string `json:"private_key,omitempty"`
+}
+
+// CreateCredentialProfileRequest represents the request to create a credential profile
+type CreateCredentialProfileRequest struct {
+	Name               string  `json:"name" validate:"required,max=255"`
+	Method             string  `json:"method" validate:"required,oneof=snmp api ssh"`
+	Description        *string `json:"description,omitempty"`
+	SNMPVersion        *string `json:"snmp_version,omitempty" validate:"omitempty,oneof=v1 v2c v3"`
+	SNMPV3AuthProtocol *string `json:"snmp_v3_auth_protocol,omitempty" validate:"omitempty,max=50"`
+	SNMPV3PrivProtocol *string `json:"snmp_v3_priv_protocol,omitempty" validate:"omitempty,max=50"`
+	Username           *string `json:"username,omitempty" validate:"omitempty,max=255"`
+	CredentialSecrets
+}
+
+// UpdateCredentialProfileRequest represents the request to update a
+// credential profile; its method cannot change
+type UpdateCredentialProfileRequest struct {
+	Name               *string `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description        *string `json:"description,omitempty"`
+	SNMPVersion        *string `json:"snmp_version,omitempty" validate:"omitempty,oneof=v1 v2c v3"`
+	SNMPV3AuthProtocol *string `json:"snmp_v3_auth_protocol,omitempty" validate:"omitempty,max=50"`
+	SNMPV3PrivProtocol *string `json:"snmp_v3_priv_protocol,omitempty" validate:"omitempty,max=50"`
+	Username           *string `json:"username,omitempty" validate:"omitempty,max=255"`
+	CredentialSecrets
+}
+
+// RotateCredentialProfileRequest represents new credentials for a profile
+// to be verified against every router using it
+type RotateCredentialProfileRequest struct {
+	Username *string `json:"username,omitempty" validate:"omitempty,max=255"`
+	CredentialSecrets
+
+	// Restore the previous credentials when any router fails the check
+	RollbackOnFailure bool `json:"rollback_on_failure"`
+}
+
+// AssignCredentialProfileRequest represents routers to reference a profile
+type AssignCredentialProfileRequest struct {
+	RouterIDs []uuid.UUID `json:"router_ids" validate:"required,min=1"`
+
+	// Keep the routers' own credentials as overrides of the profile
+	KeepOverrides bool `json:"keep_overrides"`
+}
+
+// CredentialProfileRoutersDTO represents the routers referencing a profile
+type CredentialProfileRoutersDTO struct {
+	ProfileID uuid.UUID   `json:"profile_id"`
+	RouterIDs []uuid.UUID `json:"router_ids"`
+}
+
+// CredentialCheckDTO represents the check of a profile's credentials on one router
+type CredentialCheckDTO struct {
+	RouterID       uuid.UUID `json:"router_id"`
+	Adapter        string    `json:"adapter,omitempty"`
+	Status         string    `json:"status"` // passed, failed, skipped
+	ErrorMessage   string    `json:"error_message,omitempty"`
+	ResponseTimeMs int       `json:"response_time_ms"`
+}
+
+// CredentialCheckReportDTO represents the checks of a profile against the
+// routers using it, after a rotation or on its own
+type CredentialCheckReportDTO struct {
+	Profile    CredentialProfileDTO `json:"profile"`
+	Routers    int                  `json:"routers"`
+	Passed     int                  `json:"passed"`
+	Failed     int                  `json:"failed"`
+	Skipped    int                  `json:"skipped"`
+	RolledBack bool                 `json:"rolled_back"`
+	Results    []CredentialCheckDTO `json:"results"`
+}
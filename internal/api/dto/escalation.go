@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EscalationPolicyDTO represents an escalation policy in API responses
+type EscalationPolicyDTO struct {
+	ID          uuid.UUID       `json:"id"`
+	TenantID    uuid.UUID       `json:"tenant_id"`
+	Name        string          `json:"name"`
+	Description *string         `json:"description,omitempty"`
+	Severities  []string        `json:"severities"`
+	RoleCodes   []string        `json:"role_codes"`
+	POPIDs      []uuid.UUID     `json:"pop_ids"`
+	Tiers       json.RawMessage `json:"tiers"`
+	RepeatCount int             `json:"repeat_count"`
+	Enabled     bool            `json:"enabled"`
+	CreatedBy   *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// CreateEscalationPolicyRequest represents the request to create an
+// escalation policy; empty filters match every alert. Each tier pages its
+// targets, then escalates to the next after escalate_after_minutes unless
+// the alert is acknowledged or resolved.
+type CreateEscalationPolicyRequest struct {
+	Name        string          `json:"name" validate:"required,max=255"`
+	Description *string         `json:"description,omitempty"`
+	Severities  []string        `json:"severities,omitempty" validate:"dive,oneof=critical warning info"`
+	RoleCodes   []string        `json:"role_codes,omitempty" validate:"dive,max=50"`
+	POPIDs      []uuid.UUID     `json:"pop_ids,omitempty"`
+	Tiers       json.RawMessage `json:"tiers" validate:"required"`
+	RepeatCount int             `json:"repeat_count,omitempty" validate:"min=0,max=10"`
+	Enabled     *bool           `json:"enabled,omitempty"`
+}
+
+// UpdateEscalationPolicyRequest represents the request to update an
+// escalation policy; filter lists and tiers given replace the stored ones.
+// Running escalations continue with the new tiers.
+type UpdateEscalationPolicyRequest struct {
+	Name        *string         `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description *string         `json:"description,omitempty"`
+	Severities  []string        `json:"severities,omitempty" validate:"dive,oneof=critical warning info"`
+	RoleCodes   []string        `json:"role_codes,omitempty" validate:"dive,max=50"`
+	POPIDs      []uuid.UUID     `json:"pop_ids,omitempty"`
+	Tiers       json.RawMessage `json:"tiers,omitempty"`
+	RepeatCount *int            `json:"repeat_count,omitempty" validate:"omitempty,min=0,max=10"`
+	Enabled     *bool           `json:"enabled,omitempty"`
+}
+
+// AlertEscalationDTO represents the progress of an alert through an
+// escalation policy. NextTier is the 1-based tier paged at
+// next_escalation_at while the escalation runs.
+type AlertEscalationDTO struct {
+	AlertID          uuid.UUID  `json:"alert_id"`
+	PolicyID         uuid.UUID  `json:"policy_id"`
+	PolicyName       string     `json:"policy_name,omitempty"`
+	NextTier         int        `json:"next_tier"`
+	Repeats          int        `json:"repeats"`
+	NextEscalationAt *time.Time `json:"next_escalation_at,omitempty"`
+	StoppedAt        *time.Time `json:"stopped_at,omitempty"`
+	StopReason       *string    `json:"stop_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FirmwareInventoryDTO represents the fleet grouped by vendor, model and OS version
+type FirmwareInventoryDTO struct {
+	GeneratedAt           time.Time          `json:"generated_at"`
+	DatasetLoaded         bool               `json:"dataset_loaded"`
+	DatasetUpdated        string             `json:"dataset_updated,omitempty"`
+	TotalRouters          int                `json:"total_routers"`
+	VulnerableRouters     int                `json:"vulnerable_routers"`
+	UnsupportedRouters    int                `json:"unsupported_routers"`
+	UnknownVersionRouters int                `json:"unknown_version_routers"`
+	Groups                []FirmwareGroupDTO `json:"groups"`
+}
+
+// FirmwareGroupDTO represents all routers sharing a vendor, model and OS version
+type FirmwareGroupDTO struct {
+	Vendor      string                `json:"vendor"`
+	Model       string                `json:"model"`
+	OSVersion   string                `json:"os_version"`
+	Status      string                `json:"status"` // ok, vulnerable, unsupported, unknown
+	Vulnerable  bool                  `json:"vulnerable"`
+	Unsupported bool                  `json:"unsupported"`
+	RouterCount int                   `json:"router_count"`
+	Routers     []FirmwareRouterDTO   `json:"routers"`
+	Advisories  []FirmwareAdvisoryDTO `json:"advisories,omitempty"`
+	Lifecycle   *FirmwareLifecycleDTO `json:"lifecycle,omitempty"`
+}
+
+// FirmwareRouterDTO identifies a router within an inventory group
+type FirmwareRouterDTO struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// FirmwareAdvisoryDTO represents a vulnerability advisory matching a group
+type FirmwareAdvisoryDTO struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+	URL      string `json:"url,omitempty"`
+}
+
+// FirmwareLifecycleDTO represents the support status of a group's release
+type FirmwareLifecycleDTO struct {
+	Release      string `json:"release"`
+	EndOfSale    string `json:"end_of_sale,omitempty"`
+	EndOfSupport string `json:"end_of_support,omitempty"`
+	Note         string `json:"note,omitempty"`
+}
@@ -0,0 +1,116 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FlowTotalDTO represents traffic for one key of a top-N flow query
+type FlowTotalDTO struct {
+	Key           string `json:"key"`
+	Label         string `json:"label,omitempty"` // Protocol name for protocol grouping
+	Bytes         int64  `json:"bytes"`
+	Packets       int64  `json:"packets"`
+	Flows         int64  `json:"flows"`
+	BitsPerSecond int64  `json:"bits_per_second"` // Average over the queried range
+}
+
+// FlowTopResponse represents a top-N flow query result
+type FlowTopResponse struct {
+	GroupBy string         `json:"group_by"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Items   []FlowTotalDTO `json:"items"`
+}
+
+// FlowMatrixCellDTO represents traffic between one source and destination
+type FlowMatrixCellDTO struct {
+	Source        string `json:"source"`
+	Destination   string `json:"destination"`
+	Bytes         int64  `json:"bytes"`
+	Packets       int64  `json:"packets"`
+	Flows         int64  `json:"flows"`
+	BitsPerSecond int64  `json:"bits_per_second"`
+}
+
+// FlowMatrixResponse represents a traffic matrix query result
+type FlowMatrixResponse struct {
+	By    string              `json:"by"` // prefix or as
+	From  time.Time           `json:"from"`
+	To    time.Time           `json:"to"`
+	Cells []FlowMatrixCellDTO `json:"cells"`
+}
+
+// NATEventDTO represents a stored NAT translation event
+type NATEventDTO struct {
+	ID                int64     `json:"id"`
+	RouterID          uuid.UUID `json:"router_id"`
+	RouterName        string    `json:"router_name"`
+	EventTime         time.Time `json:"event_time"`
+	Event             string    `json:"event"`
+	Protocol          int       `json:"protocol"`
+	SrcAddress        *string   `json:"src_address,omitempty"`
+	SrcPort           *int      `json:"src_port,omitempty"`
+	DstAddress        *string   `json:"dst_address,omitempty"`
+	DstPort           *int      `json:"dst_port,omitempty"`
+	PostNATSrcAddress *string   `json:"post_nat_src_address,omitempty"`
+	PostNATSrcPort    *int      `json:"post_nat_src_port,omitempty"`
+	PostNATDstAddress *string   `json:"post_nat_dst_address,omitempty"`
+	PostNATDstPort    *int      `json:"post_nat_dst_port,omitempty"`
+}
+
+// SubscriberTotalDTO represents one subscriber's traffic over a time range
+type SubscriberTotalDTO struct {
+	Subscriber       string `json:"subscriber"`
+	SubscriberType   string `json:"subscriber_type"` // pppoe or dhcp
+	BytesIn          int64  `json:"bytes_in"`
+	BytesOut         int64  `json:"bytes_out"`
+	PacketsIn        int64  `json:"packets_in"`
+	PacketsOut       int64  `json:"packets_out"`
+	Flows            int64  `json:"flows"`
+	BitsPerSecondIn  int64  `json:"bits_per_second_in"` // Averages over the queried range
+	BitsPerSecondOut int64  `json:"bits_per_second_out"`
+}
+
+// SubscriberTopResponse represents a top subscriber query result
+type SubscriberTopResponse struct {
+	OrderBy string               `json:"order_by"`
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	Items   []SubscriberTotalDTO `json:"items"`
+}
+
+// SubscriberUsagePointDTO represents a subscriber's traffic in one step
+type SubscriberUsagePointDTO struct {
+	Bucket   time.Time `json:"bucket"`
+	BytesIn  int64     `json:"bytes_in"`
+	BytesOut int64     `json:"bytes_out"`
+}
+
+// SubscriberHistoryResponse represents a subscriber's traffic over time
+type SubscriberHistoryResponse struct {
+	Subscriber string                    `json:"subscriber"`
+	Step       string                    `json:"step"`
+	From       time.Time                 `json:"from"`
+	To         time.Time                 `json:"to"`
+	Points     []SubscriberUsagePointDTO `json:"points"`
+}
+
+// ApplicationTotalDTO represents subscriber traffic to one protocol and port
+type ApplicationTotalDTO struct {
+	Application string `json:"application"`
+	Protocol    int    `json:"protocol"`
+	Port        int    `json:"port"`
+	BytesIn     int64  `json:"bytes_in"`
+	BytesOut    int64  `json:"bytes_out"`
+	Flows       int64  `json:"flows"`
+}
+
+// SubscriberApplicationsResponse represents a per-application breakdown
+type SubscriberApplicationsResponse struct {
+	Subscriber string                `json:"subscriber,omitempty"`
+	From       time.Time             `json:"from"`
+	To         time.Time             `json:"to"`
+	Items      []ApplicationTotalDTO `json:"items"`
+}
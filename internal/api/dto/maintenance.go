@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceWindowDTO represents a maintenance window in API responses
+type MaintenanceWindowDTO struct {
+	ID              uuid.UUID                 `json:"id"`
+	TenantID        uuid.UUID                 `json:"tenant_id"`
+	Name            string                    `json:"name"`
+	Description     *string                   `json:"description,omitempty"`
+	StartsAt        time.Time                 `json:"starts_at"`
+	EndsAt          *time.Time                `json:"ends_at,omitempty"`
+	Recurrence      *string                   `json:"recurrence,omitempty"`
+	DurationMinutes *int                      `json:"duration_minutes,omitempty"`
+	Timezone        string                    `json:"timezone"`
+	RouterIDs       []uuid.UUID               `json:"router_ids"`
+	POPIDs          []uuid.UUID               `json:"pop_ids"`
+	RegionIDs       []uuid.UUID               `json:"region_ids"`
+	RoleCodes       []string                  `json:"role_codes"`
+	PausePolling    bool                      `json:"pause_polling"`
+	Enabled         bool                      `json:"enabled"`
+	Active          bool                      `json:"active"`
+	NextOccurrence  *MaintenanceOccurrenceDTO `json:"next_occurrence,omitempty"`
+	CreatedBy       *uuid.UUID                `json:"created_by,omitempty"`
+	CreatedAt       time.Time                 `json:"created_at"`
+	UpdatedAt       time.Time                 `json:"updated_at"`
+}
+
+// MaintenanceOccurrenceDTO represents one period of a maintenance window
+type MaintenanceOccurrenceDTO struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// CreateMaintenanceWindowRequest represents the request to create a
+// maintenance window. A one-off window needs ends_at; a recurring one
+// needs recurrence and duration_minutes.
+type CreateMaintenanceWindowRequest struct {
+	Name            string      `json:"name" validate:"required,max=255"`
+	Description     *string     `json:"description,omitempty"`
+	StartsAt        time.Time   `json:"starts_at" validate:"required"`
+	EndsAt          *time.Time  `json:"ends_at,omitempty"`
+	Recurrence      *string     `json:"recurrence,omitempty" validate:"omitempty,max=255"`
+	DurationMinutes *int        `json:"duration_minutes,omitempty" validate:"omitempty,min=1"`
+	Timezone        string      `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	RouterIDs       []uuid.UUID `json:"router_ids,omitempty"`
+	POPIDs          []uuid.UUID `json:"pop_ids,omitempty"`
+	RegionIDs       []uuid.UUID `json:"region_ids,omitempty"`
+	RoleCodes       []string    `json:"role_codes,omitempty" validate:"dive,max=50"`
+	PausePolling    bool        `json:"pause_polling"`
+	Enabled         *bool       `json:"enabled,omitempty"`
+}
+
+// UpdateMaintenanceWindowRequest represents the request to update a
+// maintenance window; scope lists given replace the stored ones
+type UpdateMaintenanceWindowRequest struct {
+	Name            *string     `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description     *string     `json:"description,omitempty"`
+	StartsAt        *time.Time  `json:"starts_at,omitempty"`
+	EndsAt          *time.Time  `json:"ends_at,omitempty"`
+	Recurrence      *string     `json:"recurrence,omitempty" validate:"omitempty,max=255"`
+	DurationMinutes *int        `json:"duration_minutes,omitempty" validate:"omitempty,min=1"`
+	Timezone        *string     `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	RouterIDs       []uuid.UUID `json:"router_ids,omitempty"`
+	POPIDs          []uuid.UUID `json:"pop_ids,omitempty"`
+	RegionIDs       []uuid.UUID `json:"region_ids,omitempty"`
+	RoleCodes       []string    `json:"role_codes,omitempty" validate:"dive,max=50"`
+	PausePolling    *bool       `json:"pause_polling,omitempty"`
+	Enabled         *bool       `json:"enabled,omitempty"`
+}
+
+// MaintenanceWindowRoutersDTO represents the routers a window covers
+type MaintenanceWindowRoutersDTO struct {
+	WindowID  uuid.UUID   `json:"window_id"`
+	RouterIDs []uuid.UUID `json:"router_ids"`
+}
+
+// AnnotationDTO marks a period on metric graphs
+type AnnotationDTO struct {
+	WindowID uuid.UUID `json:"window_id"`
+	Title    string    `json:"title"`
+	Text     *string   `json:"text,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Tags     []string  `json:"tags"`
+}
@@ -21,6 +21,7 @@ type InterfaceMetrics struct {
 	InErrors      []MetricDataPoint `json:"in_errors,omitempty"`
 	OutErrors     []MetricDataPoint `json:"out_errors,omitempty"`
 	Utilization   []MetricDataPoint `json:"utilization,omitempty"`
+	Annotations   []AnnotationDTO   `json:"annotations"` // Maintenance windows of the interface's router
 }
 
 // RouterMetrics represents metrics for a router
@@ -32,4 +33,31 @@ type RouterMetrics struct {
 	TotalInBps       []MetricDataPoint `json:"total_in_bps,omitempty"`
 	TotalOutBps      []MetricDataPoint `json:"total_out_bps,omitempty"`
 	ActiveInterfaces []MetricDataPoint `json:"active_interfaces,omitempty"`
+	Annotations      []AnnotationDTO   `json:"annotations"` // Maintenance windows of the router
+}
+
+// RoleMetricSeries represents one metric of a router over time
+type RoleMetricSeries struct {
+	Metric string            `json:"metric"`
+	Unit   string            `json:"unit,omitempty"`
+	Kind   string            `json:"kind"`
+	Points []MetricDataPoint `json:"points"`
+}
+
+// RouterRoleMetrics represents the role-specific metric series of a router
+type RouterRoleMetrics struct {
+	RouterID   string             `json:"router_id"`
+	RouterName string             `json:"router_name"`
+	Series     []RoleMetricSeries `json:"series"`
+}
+
+// RoleMetrics represents role-specific metrics of one or more routers
+type RoleMetrics struct {
+	Role            string              `json:"role"`
+	Aggregation     string              `json:"aggregation"`
+	IntervalSeconds int64               `json:"interval_seconds"`
+	From            time.Time           `json:"from"`
+	To              time.Time           `json:"to"`
+	Routers         []RouterRoleMetrics `json:"routers"`
+	Annotations     []AnnotationDTO     `json:"annotations"` // Maintenance windows of the routers
 }
@@ -0,0 +1,38 @@
+package dto
+
+// MIBNodeDTO represents a MIB object resolved from a name or OID
+type MIBNodeDTO struct {
+	Name        string       `json:"name"`   // MODULE::object
+	Object      string       `json:"object"` // Object name without module
+	Module      string       `json:"module"`
+	OID         string       `json:"oid"`
+	Instance    string       `json:"instance,omitempty"` // Suffix of the queried OID below the object
+	Kind        string       `json:"kind"`
+	Syntax      string       `json:"syntax,omitempty"`
+	Access      string       `json:"access,omitempty"`
+	Units       string       `json:"units,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Enums       []MIBEnumDTO `json:"enums,omitempty"`
+	Index       []string     `json:"index,omitempty"`
+	Augments    string       `json:"augments,omitempty"`
+}
+
+// MIBEnumDTO represents a labelled integer value
+type MIBEnumDTO struct {
+	Value int64  `json:"value"`
+	Name  string `json:"name"`
+}
+
+// MIBModuleDTO represents a loaded MIB module
+type MIBModuleDTO struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Objects int    `json:"objects"`
+}
+
+// MIBModuleListResponse lists the loaded modules and load problems
+type MIBModuleListResponse struct {
+	Modules  []MIBModuleDTO `json:"modules"`
+	Warnings []string       `json:"warnings"`
+}
@@ -0,0 +1,106 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationChannelDTO represents a notification channel in API
+// responses. The secret is never returned; SecretSet tells whether one is
+// stored.
+type NotificationChannelDTO struct {
+	ID            uuid.UUID       `json:"id"`
+	TenantID      uuid.UUID       `json:"tenant_id"`
+	Name          string          `json:"name"`
+	ChannelType   string          `json:"channel_type"`
+	Config        json.RawMessage `json:"config"`
+	SecretSet     bool            `json:"secret_set"`
+	TitleTemplate *string         `json:"title_template,omitempty"`
+	BodyTemplate  *string         `json:"body_template,omitempty"`
+	Enabled       bool            `json:"enabled"`
+	CreatedBy     *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// CreateNotificationChannelRequest represents the request to create a
+// notification channel. The fields of config depend on channel_type; secret
+// is the SMTP password, webhook HMAC key, incoming webhook URL or bot token.
+type CreateNotificationChannelRequest struct {
+	Name          string          `json:"name" validate:"required,max=255"`
+	ChannelType   string          `json:"channel_type" validate:"required,oneof=email webhook slack telegram"`
+	Config        json.RawMessage `json:"config,omitempty"`
+	Secret        *string         `json:"secret,omitempty"`
+	TitleTemplate *string         `json:"title_template,omitempty"`
+	BodyTemplate  *string         `json:"body_template,omitempty"`
+	Enabled       *bool           `json:"enabled,omitempty"`
+}
+
+// UpdateNotificationChannelRequest represents the request to update a
+// notification channel; an empty secret or template clears it
+type UpdateNotificationChannelRequest struct {
+	Name          *string         `json:"name,omitempty" validate:"omitempty,max=255"`
+	ChannelType   *string         `json:"channel_type,omitempty" validate:"omitempty,oneof=email webhook slack telegram"`
+	Config        json.RawMessage `json:"config,omitempty"`
+	Secret        *string         `json:"secret,omitempty"`
+	TitleTemplate *string         `json:"title_template,omitempty"`
+	BodyTemplate  *string         `json:"body_template,omitempty"`
+	Enabled       *bool           `json:"enabled,omitempty"`
+}
+
+// NotificationRouteDTO represents a notification route in API responses
+type NotificationRouteDTO struct {
+	ID             uuid.UUID   `json:"id"`
+	TenantID       uuid.UUID   `json:"tenant_id"`
+	ChannelID      uuid.UUID   `json:"channel_id"`
+	Name           string      `json:"name"`
+	Severities     []string    `json:"severities"`
+	RoleCodes      []string    `json:"role_codes"`
+	POPIDs         []uuid.UUID `json:"pop_ids"`
+	NotifyResolved bool        `json:"notify_resolved"`
+	Enabled        bool        `json:"enabled"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// CreateNotificationRouteRequest represents the request to create a
+// notification route; empty filters match every alert
+type CreateNotificationRouteRequest struct {
+	ChannelID      uuid.UUID   `json:"channel_id" validate:"required"`
+	Name           string      `json:"name" validate:"required,max=255"`
+	Severities     []string    `json:"severities,omitempty" validate:"dive,oneof=critical warning info"`
+	RoleCodes      []string    `json:"role_codes,omitempty" validate:"dive,max=50"`
+	POPIDs         []uuid.UUID `json:"pop_ids,omitempty"`
+	NotifyResolved *bool       `json:"notify_resolved,omitempty"`
+	Enabled        *bool       `json:"enabled,omitempty"`
+}
+
+// UpdateNotificationRouteRequest represents the request to update a
+// notification route; filter lists given replace the stored ones
+type UpdateNotificationRouteRequest struct {
+	ChannelID      *uuid.UUID  `json:"channel_id,omitempty"`
+	Name           *string     `json:"name,omitempty" validate:"omitempty,max=255"`
+	Severities     []string    `json:"severities,omitempty" validate:"dive,oneof=critical warning info"`
+	RoleCodes      []string    `json:"role_codes,omitempty" validate:"dive,max=50"`
+	POPIDs         []uuid.UUID `json:"pop_ids,omitempty"`
+	NotifyResolved *bool       `json:"notify_resolved,omitempty"`
+	Enabled        *bool       `json:"enabled,omitempty"`
+}
+
+// NotificationDeliveryDTO represents a delivery log entry in API responses
+type NotificationDeliveryDTO struct {
+	ID            int64      `json:"id"`
+	ChannelID     uuid.UUID  `json:"channel_id"`
+	AlertID       *uuid.UUID `json:"alert_id,omitempty"`
+	Event         string     `json:"event"`
+	Tier          *int       `json:"tier,omitempty"`
+	RecipientID   *uuid.UUID `json:"recipient_id,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
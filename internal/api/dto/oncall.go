@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OnCallScheduleDTO represents an on-call schedule in API responses
+type OnCallScheduleDTO struct {
+	ID            uuid.UUID       `json:"id"`
+	TenantID      uuid.UUID       `json:"tenant_id"`
+	Name          string          `json:"name"`
+	Description   *string         `json:"description,omitempty"`
+	Timezone      string          `json:"timezone"`
+	Participants  []uuid.UUID     `json:"participants"`
+	RotationStart time.Time       `json:"rotation_start"`
+	RotationDays  int             `json:"rotation_days"`
+	Coverage      json.RawMessage `json:"coverage"`
+	OnCall        *uuid.UUID      `json:"on_call,omitempty"`
+	CreatedBy     *uuid.UUID      `json:"created_by,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// CreateOnCallScheduleRequest represents the request to create an on-call
+// schedule. Participants take turns in order for rotation_days each,
+// handing over at the wall clock time of rotation_start in timezone;
+// coverage lists the weekly hours the schedule is on call, every hour when
+// empty.
+type CreateOnCallScheduleRequest struct {
+	Name          string          `json:"name" validate:"required,max=255"`
+	Description   *string         `json:"description,omitempty"`
+	Timezone      string          `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	Participants  []uuid.UUID     `json:"participants" validate:"required,min=1,max=100"`
+	RotationStart time.Time       `json:"rotation_start" validate:"required"`
+	RotationDays  int             `json:"rotation_days,omitempty" validate:"omitempty,min=1,max=365"`
+	Coverage      json.RawMessage `json:"coverage,omitempty"`
+}
+
+// UpdateOnCallScheduleRequest represents the request to update an on-call
+// schedule; a participant list given replaces the stored one
+type UpdateOnCallScheduleRequest struct {
+	Name          *string         `json:"name,omitempty" validate:"omitempty,max=255"`
+	Description   *string         `json:"description,omitempty"`
+	Timezone      *string         `json:"timezone,omitempty" validate:"omitempty,max=64"`
+	Participants  []uuid.UUID     `json:"participants,omitempty" validate:"omitempty,max=100"`
+	RotationStart *time.Time      `json:"rotation_start,omitempty"`
+	RotationDays  *int            `json:"rotation_days,omitempty" validate:"omitempty,min=1,max=365"`
+	Coverage      json.RawMessage `json:"coverage,omitempty"`
+}
+
+// OnCallOverrideDTO represents an on-call override in API responses
+type OnCallOverrideDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	ScheduleID uuid.UUID  `json:"schedule_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     time.Time  `json:"ends_at"`
+	Note       *string    `json:"note,omitempty"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateOnCallOverrideRequest represents the request to put a user on
+// call for a schedule from starts_at to ends_at
+type CreateOnCallOverrideRequest struct {
+	UserID   uuid.UUID `json:"user_id" validate:"required"`
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+	EndsAt   time.Time `json:"ends_at" validate:"required"`
+	Note     *string   `json:"note,omitempty" validate:"omitempty,max=500"`
+}
+
+// OnCallShiftDTO represents a period one user is on call
+type OnCallShiftDTO struct {
+	UserID   uuid.UUID `json:"user_id"`
+	UserName string    `json:"user_name,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Override bool      `json:"override"`
+}
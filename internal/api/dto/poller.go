@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PollResultDTO represents the result of an on-demand poll
+type PollResultDTO struct {
+	RouterID       uuid.UUID              `json:"router_id"`
+	Success        bool                   `json:"success"`
+	AdapterUsed    string                 `json:"adapter_used"`
+	Timestamp      time.Time              `json:"timestamp"`
+	ResponseTimeMs int                    `json:"response_time_ms"`
+	ErrorMessage   string                 `json:"error_message,omitempty"`
+	Metrics        map[string]interface{} `json:"metrics"`
+	MetricErrors   []string               `json:"metric_errors,omitempty"` // Values the adapter reported that were rejected
+	Interfaces     []PolledInterfaceDTO   `json:"interfaces"`
+	PPPoESessions  int                    `json:"pppoe_sessions"`
+	NATSessions    int                    `json:"nat_sessions"`
+	DHCPLeases     int                    `json:"dhcp_leases"`
+}
+
+// PolledInterfaceDTO represents an interface's state as reported by a poll
+type PolledInterfaceDTO struct {
+	Name        string `json:"name"`
+	IfIndex     int    `json:"if_index,omitempty"`
+	Status      string `json:"status"`
+	AdminStatus string `json:"admin_status"`
+	SpeedMbps   int64  `json:"speed_mbps,omitempty"`
+	InOctets    int64  `json:"in_octets"`
+	OutOctets   int64  `json:"out_octets"`
+	InErrors    int64  `json:"in_errors"`
+	OutErrors   int64  `json:"out_errors"`
+}
+
+// RouterHealthDTO represents the result of a router health check
+type RouterHealthDTO struct {
+	RouterID       uuid.UUID `json:"router_id"`
+	Adapter        string    `json:"adapter"`
+	Healthy        bool      `json:"healthy"`
+	ErrorMessage   string    `json:"error_message,omitempty"`
+	ResponseTimeMs int       `json:"response_time_ms"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyslogMessageDTO represents a stored syslog message
+type SyslogMessageDTO struct {
+	ID             int64      `json:"id"`
+	RouterID       uuid.UUID  `json:"router_id"`
+	RouterName     string     `json:"router_name"`
+	SourceIP       string     `json:"source_ip"`
+	Facility       string     `json:"facility"`
+	Severity       string     `json:"severity"`
+	SeverityLevel  int        `json:"severity_level"` // 0 emergency .. 7 debug
+	Hostname       string     `json:"hostname,omitempty"`
+	AppName        string     `json:"app_name,omitempty"`
+	ProcID         string     `json:"proc_id,omitempty"`
+	MsgID          string     `json:"msg_id,omitempty"`
+	StructuredData string     `json:"structured_data,omitempty"`
+	Message        string     `json:"message"`
+	DeviceTime     *time.Time `json:"device_time,omitempty"`
+	ReceivedAt     time.Time  `json:"received_at"`
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// defaultPreviewHours is the range an alert rule preview replays when the
+// request does not give one
+const defaultPreviewHours = 24
+
+// AlertRuleHandler handles alert rule requests
+type AlertRuleHandler struct {
+	ruleService *service.AlertRuleService
+	validator   *validator.Validate
+}
+
+// NewAlertRuleHandler creates a new alert rule handler
+func NewAlertRuleHandler(ruleService *service.AlertRuleService, validator *validator.Validate) *AlertRuleHandler {
+	return &AlertRuleHandler{
+		ruleService: ruleService,
+		validator:   validator,
+	}
+}
+
+// HandleListRules lists alert rules
+func (h *AlertRuleHandler) HandleListRules(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	rules, total, err := h.ruleService.ListRules(r.Context(), tenantID, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, rules, page, pageSize, total)
+}
+
+// HandleCreateRule creates an alert rule
+func (h *AlertRuleHandler) HandleCreateRule(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	var req dto.CreateAlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	rule, err := h.ruleService.CreateRule(r.Context(), tenantID, createdBy, &req)
+	if err != nil {
+		respondAlertRuleError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, rule)
+}
+
+// HandleGetRule retrieves an alert rule
+func (h *AlertRuleHandler) HandleGetRule(w http.ResponseWriter, r *http.Request) {
+	tenantID, ruleID, ok := ruleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.ruleService.GetRule(r.Context(), tenantID, ruleID)
+	if err != nil {
+		respondAlertRuleError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, rule)
+}
+
+// HandleUpdateRule updates an alert rule
+func (h *AlertRuleHandler) HandleUpdateRule(w http.ResponseWriter, r *http.Request) {
+	tenantID, ruleID, ok := ruleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateAlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	rule, err := h.ruleService.UpdateRule(r.Context(), tenantID, ruleID, &req)
+	if err != nil {
+		respondAlertRuleError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, rule)
+}
+
+// HandleDeleteRule deletes an alert rule
+func (h *AlertRuleHandler) HandleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	tenantID, ruleID, ok := ruleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ruleService.DeleteRule(r.Context(), tenantID, ruleID); err != nil {
+		respondAlertRuleError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandlePreviewRule evaluates a draft rule, given like one to create,
+// against the stored series of the last hours given by the hours query
+// parameter (24 by default)
+func (h *AlertRuleHandler) HandlePreviewRule(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	hours := defaultPreviewHours
+	if v := r.URL.Query().Get("hours"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid hours"))
+			return
+		}
+		hours = n
+	}
+
+	var req dto.CreateAlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	preview, err := h.ruleService.PreviewRule(r.Context(), tenantID, &req, time.Duration(hours)*time.Hour)
+	if err != nil {
+		respondAlertRuleError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, preview)
+}
+
+// ruleRequestIDs extracts the tenant and alert rule IDs of a request,
+// responding with an error when either is missing
+func ruleRequestIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	ruleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid alert rule ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantID, ruleID, true
+}
+
+// respondAlertRuleError maps alert rule service errors to HTTP responses;
+// a missing target is a bad request, a missing rule is not found
+func respondAlertRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid "):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case err.Error() == "alert rule not found":
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+	case strings.HasSuffix(err.Error(), " not found"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
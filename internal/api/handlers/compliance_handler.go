@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type ComplianceHandler struct {
+	complianceService *service.ComplianceService
+	validator         *validator.Validate
+}
+
+func NewComplianceHandler(complianceService *service.ComplianceService, validator *validator.Validate) *ComplianceHandler {
+	return &ComplianceHandler{
+		complianceService: complianceService,
+		validator:         validator,
+	}
+}
+
+func (h *ComplianceHandler) HandleListPolicies(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	policies, total, err := h.complianceService.ListPolicies(r.Context(), tenantID, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, policies, page, pageSize, total)
+}
+
+func (h *ComplianceHandler) HandleCreatePolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var req dto.CreateCompliancePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	policy, err := h.complianceService.CreatePolicy(r.Context(), tenantID, &req)
+	if err != nil {
+		respondComplianceError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, policy)
+}
+
+func (h *ComplianceHandler) HandleGetPolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	policyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid policy ID"))
+		return
+	}
+
+	policy, err := h.complianceService.GetPolicy(r.Context(), tenantID, policyID)
+	if err != nil {
+		respondComplianceError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, policy)
+}
+
+func (h *ComplianceHandler) HandleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	policyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid policy ID"))
+		return
+	}
+
+	var req dto.UpdateCompliancePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	policy, err := h.complianceService.UpdatePolicy(r.Context(), tenantID, policyID, &req)
+	if err != nil {
+		respondComplianceError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, policy)
+}
+
+func (h *ComplianceHandler) HandleDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	policyID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid policy ID"))
+		return
+	}
+
+	if err := h.complianceService.DeletePolicy(r.Context(), tenantID, policyID); err != nil {
+		respondComplianceError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+func (h *ComplianceHandler) HandleEvaluate(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	result, err := h.complianceService.EvaluateTenant(r.Context(), tenantID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+func (h *ComplianceHandler) HandleGetReport(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.ComplianceResultFilter{
+		RoleCode: query.Get("role"),
+	}
+	if p := query.Get("policy_id"); p != "" {
+		policyID, err := uuid.Parse(p)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid policy ID"))
+			return
+		}
+		filter.PolicyID = &policyID
+	}
+	nonCompliantOnly := query.Get("non_compliant") == "true"
+
+	report, err := h.complianceService.GetReport(r.Context(), tenantID, filter, nonCompliantOnly)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+func (h *ComplianceHandler) HandleGetRouterCompliance(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	routerID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	result, err := h.complianceService.GetRouterCompliance(r.Context(), tenantID, routerID)
+	if err != nil {
+		respondComplianceError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// respondComplianceError maps compliance service errors to HTTP responses
+func respondComplianceError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+	case strings.Contains(err.Error(), "already exists"):
+		utils.RespondError(w, http.StatusConflict, utils.ErrConflict.WithDetails("Compliance policy already exists"))
+	case strings.HasPrefix(err.Error(), "invalid policy"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
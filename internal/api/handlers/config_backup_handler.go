@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/configbackup"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type ConfigBackupHandler struct {
+	configBackupService *service.ConfigBackupService
+	validator           *validator.Validate
+}
+
+func NewConfigBackupHandler(configBackupService *service.ConfigBackupService, validator *validator.Validate) *ConfigBackupHandler {
+	return &ConfigBackupHandler{
+		configBackupService: configBackupService,
+		validator:           validator,
+	}
+}
+
+func (h *ConfigBackupHandler) HandleListConfigVersions(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	vars := mux.Vars(r)
+	routerID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	versions, total, err := h.configBackupService.ListVersions(r.Context(), tenantID, routerID, opts)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound)
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, versions, page, pageSize, total)
+}
+
+func (h *ConfigBackupHandler) HandleGetConfigVersion(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	vars := mux.Vars(r)
+	routerID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil || version < 1 {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid config version"))
+		return
+	}
+
+	configVersion, err := h.configBackupService.GetVersion(r.Context(), tenantID, routerID, version)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound)
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, configVersion)
+}
+
+func (h *ConfigBackupHandler) HandleDiffConfigVersions(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	vars := mux.Vars(r)
+	routerID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	query := r.URL.Query()
+	fromVersion, err := strconv.Atoi(query.Get("from"))
+	if err != nil || fromVersion < 1 {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Query parameter 'from' must be a version number"))
+		return
+	}
+	toVersion, err := strconv.Atoi(query.Get("to"))
+	if err != nil || toVersion < 1 {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Query parameter 'to' must be a version number"))
+		return
+	}
+
+	contextLines := configbackup.DefaultContextLines
+	if c := query.Get("context"); c != "" {
+		if val, err := strconv.Atoi(c); err == nil && val >= 0 && val <= 100 {
+			contextLines = val
+		}
+	}
+
+	diff, err := h.configBackupService.DiffVersions(r.Context(), tenantID, routerID, fromVersion, toVersion, contextLines)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, diff)
+}
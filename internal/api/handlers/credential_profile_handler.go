@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CredentialProfileHandler handles credential profile requests
+type CredentialProfileHandler struct {
+	profileService *service.CredentialProfileService
+	validator      *validator.Validate
+}
+
+// NewCredentialProfileHandler creates a new credential profile handler
+func NewCredentialProfileHandler(profileService *service.CredentialProfileService, validator *validator.Validate) *CredentialProfileHandler {
+	return &CredentialProfileHandler{
+		profileService: profileService,
+		validator:      validator,
+	}
+}
+
+// HandleListProfiles lists credential profiles, of the method given by the
+// method query parameter when present
+func (h *CredentialProfileHandler) HandleListProfiles(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	profiles, total, err := h.profileService.ListProfiles(r.Context(), tenantID, r.URL.Query().Get("method"), opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, profiles, page, pageSize, total)
+}
+
+// HandleCreateProfile creates a credential profile
+func (h *CredentialProfileHandler) HandleCreateProfile(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var req dto.CreateCredentialProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	profile, err := h.profileService.CreateProfile(r.Context(), tenantID, &req)
+	if err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, profile)
+}
+
+// HandleGetProfile retrieves a credential profile
+func (h *CredentialProfileHandler) HandleGetProfile(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := h.profileService.GetProfile(r.Context(), tenantID, profileID)
+	if err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, profile)
+}
+
+// HandleUpdateProfile updates a credential profile
+func (h *CredentialProfileHandler) HandleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateCredentialProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	profile, err := h.profileService.UpdateProfile(r.Context(), tenantID, profileID, &req)
+	if err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, profile)
+}
+
+// HandleDeleteProfile deletes a credential profile no router references
+func (h *CredentialProfileHandler) HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.profileService.DeleteProfile(r.Context(), tenantID, profileID); err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandleListRouters lists the routers referencing a credential profile
+func (h *CredentialProfileHandler) HandleListRouters(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	routers, err := h.profileService.ListRouters(r.Context(), tenantID, profileID)
+	if err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, routers)
+}
+
+// HandleAssignRouters makes routers reference a credential profile
+func (h *CredentialProfileHandler) HandleAssignRouters(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.AssignCredentialProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	routers, err := h.profileService.AssignRouters(r.Context(), tenantID, profileID, &req)
+	if err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, routers)
+}
+
+// HandleUnassignRouter removes a router's reference to a credential profile
+func (h *CredentialProfileHandler) HandleUnassignRouter(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	routerID, err := uuid.Parse(mux.Vars(r)["routerId"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	if err := h.profileService.UnassignRouter(r.Context(), tenantID, profileID, routerID); err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandleTestProfile checks a credential profile against its routers
+func (h *CredentialProfileHandler) HandleTestProfile(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.profileService.TestProfile(r.Context(), tenantID, profileID)
+	if err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+// HandleRotateProfile replaces the credentials of a profile and checks them
+// against its routers
+func (h *CredentialProfileHandler) HandleRotateProfile(w http.ResponseWriter, r *http.Request) {
+	tenantID, profileID, ok := profileRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.RotateCredentialProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	report, err := h.profileService.RotateProfile(r.Context(), tenantID, profileID, &req)
+	if err != nil {
+		respondCredentialProfileError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+// profileRequestIDs reads the tenant and profile IDs of a request,
+// responding with an error when either is missing
+func profileRequestIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	profileID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid credential profile ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantID, profileID, true
+}
+
+// respondCredentialProfileError maps credential profile service errors to
+// HTTP responses
+func respondCredentialProfileError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+	case strings.Contains(err.Error(), "already exists"):
+		utils.RespondError(w, http.StatusConflict, utils.ErrConflict.WithDetails("Credential profile already exists"))
+	case strings.Contains(err.Error(), "in use"):
+		utils.RespondError(w, http.StatusConflict, utils.ErrConflict.WithDetails("Credential profile is assigned to routers"))
+	case strings.HasPrefix(err.Error(), "invalid credential profile"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// EscalationHandler handles escalation policy and alert escalation requests
+type EscalationHandler struct {
+	escalationService *service.EscalationService
+	validator         *validator.Validate
+}
+
+// NewEscalationHandler creates a new escalation handler
+func NewEscalationHandler(escalationService *service.EscalationService, validator *validator.Validate) *EscalationHandler {
+	return &EscalationHandler{
+		escalationService: escalationService,
+		validator:         validator,
+	}
+}
+
+// HandleListPolicies lists escalation policies
+func (h *EscalationHandler) HandleListPolicies(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	policies, total, err := h.escalationService.ListPolicies(r.Context(), tenantID, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, policies, page, pageSize, total)
+}
+
+// HandleCreatePolicy creates an escalation policy
+func (h *EscalationHandler) HandleCreatePolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	var req dto.CreateEscalationPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	policy, err := h.escalationService.CreatePolicy(r.Context(), tenantID, createdBy, &req)
+	if err != nil {
+		respondEscalationError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, policy)
+}
+
+// HandleGetPolicy retrieves an escalation policy
+func (h *EscalationHandler) HandleGetPolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, policyID, ok := escalationRequestIDs(w, r, "escalation policy")
+	if !ok {
+		return
+	}
+
+	policy, err := h.escalationService.GetPolicy(r.Context(), tenantID, policyID)
+	if err != nil {
+		respondEscalationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, policy)
+}
+
+// HandleUpdatePolicy updates an escalation policy
+func (h *EscalationHandler) HandleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, policyID, ok := escalationRequestIDs(w, r, "escalation policy")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateEscalationPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	policy, err := h.escalationService.UpdatePolicy(r.Context(), tenantID, policyID, &req)
+	if err != nil {
+		respondEscalationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, policy)
+}
+
+// HandleDeletePolicy deletes an escalation policy
+func (h *EscalationHandler) HandleDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	tenantID, policyID, ok := escalationRequestIDs(w, r, "escalation policy")
+	if !ok {
+		return
+	}
+
+	if err := h.escalationService.DeletePolicy(r.Context(), tenantID, policyID); err != nil {
+		respondEscalationError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandleListAlertEscalations lists the escalations of an alert
+func (h *EscalationHandler) HandleListAlertEscalations(w http.ResponseWriter, r *http.Request) {
+	tenantID, alertID, ok := escalationRequestIDs(w, r, "alert")
+	if !ok {
+		return
+	}
+
+	escalations, err := h.escalationService.ListAlertEscalations(r.Context(), tenantID, alertID)
+	if err != nil {
+		respondEscalationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, escalations)
+}
+
+// escalationRequestIDs extracts the tenant ID and the ID of the policy or
+// alert a request is for, responding with an error when either is missing
+func escalationRequestIDs(w http.ResponseWriter, r *http.Request, what string) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid "+what+" ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantID, id, true
+}
+
+// respondEscalationError maps escalation service errors to HTTP responses;
+// a missing channel, schedule or user referenced by a tier is a bad request
+func respondEscalationError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid "):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case err.Error() == "escalation policy not found", err.Error() == "alert not found":
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+	case strings.HasSuffix(err.Error(), " not found"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case strings.HasSuffix(err.Error(), " already exists"):
+		utils.RespondError(w, http.StatusConflict, utils.ErrConflict.WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
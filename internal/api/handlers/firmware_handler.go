@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type FirmwareHandler struct {
+	firmwareService *service.FirmwareService
+	validator       *validator.Validate
+}
+
+func NewFirmwareHandler(firmwareService *service.FirmwareService, validator *validator.Validate) *FirmwareHandler {
+	return &FirmwareHandler{
+		firmwareService: firmwareService,
+		validator:       validator,
+	}
+}
+
+func (h *FirmwareHandler) HandleGetInventory(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	flaggedOnly := r.URL.Query().Get("flagged") == "true"
+
+	inventory, err := h.firmwareService.GetInventory(r.Context(), tenantID, flaggedOnly)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, inventory)
+}
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/flow"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// defaultFlowRange is the time range queried when from is not given
+const defaultFlowRange = time.Hour
+
+// maxFlowRange bounds a single flow query
+const maxFlowRange = 31 * 24 * time.Hour
+
+type FlowHandler struct {
+	flowService *service.FlowService
+	validator   *validator.Validate
+}
+
+func NewFlowHandler(flowService *service.FlowService, validator *validator.Validate) *FlowHandler {
+	return &FlowHandler{
+		flowService: flowService,
+		validator:   validator,
+	}
+}
+
+func (h *FlowHandler) HandleTop(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = repository.FlowGroupDstPrefix
+	case repository.FlowGroupInterface, repository.FlowGroupProtocol,
+		repository.FlowGroupSrcPrefix, repository.FlowGroupDstPrefix,
+		repository.FlowGroupSrcAS, repository.FlowGroupDstAS:
+	default:
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid group_by"))
+		return
+	}
+
+	filter, limit, ok := parseFlowQuery(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.flowService.Top(r.Context(), tenantID, filter, groupBy, limit)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+func (h *FlowHandler) HandleMatrix(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var byAS bool
+	switch r.URL.Query().Get("by") {
+	case "", "prefix":
+	case "as":
+		byAS = true
+	default:
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid by, expected prefix or as"))
+		return
+	}
+
+	filter, limit, ok := parseFlowQuery(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.flowService.Matrix(r.Context(), tenantID, filter, byAS, limit)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+func (h *FlowHandler) HandleNATEvents(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.NATEventFilter{}
+
+	if v := query.Get("router_id"); v != "" {
+		routerID, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+			return
+		}
+		filter.RouterID = &routerID
+	}
+	if v := query.Get("address"); v != "" {
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid address"))
+			return
+		}
+		filter.Address = addr.String()
+	}
+	if v := query.Get("post_nat_address"); v != "" {
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid post_nat_address"))
+			return
+		}
+		filter.PostNATAddress = addr.String()
+	}
+	if v := query.Get("post_nat_port"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port < 0 || port > 65535 {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid post_nat_port"))
+			return
+		}
+		filter.PostNATPort = &port
+	}
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid from time, expected RFC 3339"))
+			return
+		}
+		filter.From = &from
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid to time, expected RFC 3339"))
+			return
+		}
+		filter.To = &to
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	events, total, err := h.flowService.SearchNATEvents(r.Context(), tenantID, filter, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, events, page, pageSize, total)
+}
+
+func (h *FlowHandler) HandleTopSubscribers(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	orderBy := r.URL.Query().Get("order_by")
+	switch orderBy {
+	case "":
+		orderBy = repository.SubscriberOrderTotal
+	case repository.SubscriberOrderTotal, repository.SubscriberOrderIn, repository.SubscriberOrderOut:
+	default:
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid order_by, expected total, in or out"))
+		return
+	}
+
+	filter, ok := parseSubscriberQuery(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseFlowLimit(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.flowService.TopSubscribers(r.Context(), tenantID, filter, orderBy, limit)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+func (h *FlowHandler) HandleSubscriberHistory(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	filter, ok := parseSubscriberQuery(w, r)
+	if !ok {
+		return
+	}
+	if filter.Subscriber == "" {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("subscriber is required"))
+		return
+	}
+
+	step := r.URL.Query().Get("step")
+	switch step {
+	case "":
+		step = repository.SubscriberStepMinute
+		if filter.To.Sub(filter.From) > 24*time.Hour {
+			step = repository.SubscriberStepHour
+		}
+	case repository.SubscriberStepMinute, repository.SubscriberStepHour, repository.SubscriberStepDay:
+	default:
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid step, expected minute, hour or day"))
+		return
+	}
+
+	result, err := h.flowService.SubscriberHistory(r.Context(), tenantID, filter, step)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+func (h *FlowHandler) HandleSubscriberApplications(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	filter, ok := parseSubscriberQuery(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseFlowLimit(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.flowService.SubscriberApplications(r.Context(), tenantID, filter, limit)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// parseFlowQuery reads the filter and limit shared by the flow endpoints. It
+// writes the error response and returns false on invalid input.
+func parseFlowQuery(w http.ResponseWriter, r *http.Request) (repository.FlowFilter, int, bool) {
+	query := r.URL.Query()
+	filter := repository.FlowFilter{}
+
+	from, to, ok := parseFlowRange(w, r)
+	if !ok {
+		return filter, 0, false
+	}
+	filter.From, filter.To = from, to
+
+	if v := query.Get("router_id"); v != "" {
+		routerID, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+			return filter, 0, false
+		}
+		filter.RouterID = &routerID
+	}
+	if v := query.Get("protocol"); v != "" {
+		protocol, ok := flow.ParseProtocol(v)
+		if !ok {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid protocol"))
+			return filter, 0, false
+		}
+		filter.Protocol = &protocol
+	}
+	if v := query.Get("if_index"); v != "" {
+		ifIndex, err := strconv.Atoi(v)
+		if err != nil || ifIndex < 0 {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid if_index"))
+			return filter, 0, false
+		}
+		filter.IfIndex = &ifIndex
+	}
+
+	limit, ok := parseFlowLimit(w, r)
+	return filter, limit, ok
+}
+
+// parseSubscriberQuery reads the filter shared by the subscriber usage
+// endpoints. It writes the error response and returns false on invalid input.
+func parseSubscriberQuery(w http.ResponseWriter, r *http.Request) (repository.SubscriberUsageFilter, bool) {
+	query := r.URL.Query()
+	filter := repository.SubscriberUsageFilter{Subscriber: query.Get("subscriber")}
+
+	from, to, ok := parseFlowRange(w, r)
+	if !ok {
+		return filter, false
+	}
+	filter.From, filter.To = from, to
+
+	if v := query.Get("router_id"); v != "" {
+		routerID, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+			return filter, false
+		}
+		filter.RouterID = &routerID
+	}
+	if v := query.Get("pop_id"); v != "" {
+		popID, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid POP ID"))
+			return filter, false
+		}
+		filter.POPID = &popID
+	}
+
+	return filter, true
+}
+
+// parseFlowRange reads the from/to range, defaulting to the last hour
+func parseFlowRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	to := time.Now()
+
+	if v := query.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid to time, expected RFC 3339"))
+			return time.Time{}, time.Time{}, false
+		}
+		to = t
+	}
+	from := to.Add(-defaultFlowRange)
+	if v := query.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid from time, expected RFC 3339"))
+			return time.Time{}, time.Time{}, false
+		}
+		from = t
+	}
+	if !from.Before(to) || to.Sub(from) > maxFlowRange {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Time range must be positive and at most 31 days"))
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+// parseFlowLimit reads the limit of a top-N query, 1-100 and 10 by default
+func parseFlowLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		val, err := strconv.Atoi(v)
+		if err != nil || val < 1 || val > 100 {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid limit, expected 1-100"))
+			return 0, false
+		}
+		limit = val
+	}
+	return limit, true
+}
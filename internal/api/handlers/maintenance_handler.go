@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// MaintenanceHandler handles maintenance window requests
+type MaintenanceHandler struct {
+	maintenanceService *service.MaintenanceService
+	validator          *validator.Validate
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(maintenanceService *service.MaintenanceService, validator *validator.Validate) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+		validator:          validator,
+	}
+}
+
+// HandleListWindows lists maintenance windows
+func (h *MaintenanceHandler) HandleListWindows(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	windows, total, err := h.maintenanceService.ListWindows(r.Context(), tenantID, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, windows, page, pageSize, total)
+}
+
+// HandleCreateWindow creates a maintenance window
+func (h *MaintenanceHandler) HandleCreateWindow(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	var req dto.CreateMaintenanceWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	window, err := h.maintenanceService.CreateWindow(r.Context(), tenantID, createdBy, &req)
+	if err != nil {
+		respondMaintenanceError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, window)
+}
+
+// HandleGetWindow retrieves a maintenance window
+func (h *MaintenanceHandler) HandleGetWindow(w http.ResponseWriter, r *http.Request) {
+	tenantID, windowID, ok := windowRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	window, err := h.maintenanceService.GetWindow(r.Context(), tenantID, windowID)
+	if err != nil {
+		respondMaintenanceError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, window)
+}
+
+// HandleUpdateWindow updates a maintenance window
+func (h *MaintenanceHandler) HandleUpdateWindow(w http.ResponseWriter, r *http.Request) {
+	tenantID, windowID, ok := windowRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateMaintenanceWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	window, err := h.maintenanceService.UpdateWindow(r.Context(), tenantID, windowID, &req)
+	if err != nil {
+		respondMaintenanceError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, window)
+}
+
+// HandleDeleteWindow deletes a maintenance window
+func (h *MaintenanceHandler) HandleDeleteWindow(w http.ResponseWriter, r *http.Request) {
+	tenantID, windowID, ok := windowRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.maintenanceService.DeleteWindow(r.Context(), tenantID, windowID); err != nil {
+		respondMaintenanceError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandleListRouters lists the routers a maintenance window covers
+func (h *MaintenanceHandler) HandleListRouters(w http.ResponseWriter, r *http.Request) {
+	tenantID, windowID, ok := windowRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	routers, err := h.maintenanceService.ListRouters(r.Context(), tenantID, windowID)
+	if err != nil {
+		respondMaintenanceError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, routers)
+}
+
+// HandleListAnnotations lists maintenance occurrences between the from and
+// to query parameters as graph annotations, only those covering the router
+// given by router_id when present
+func (h *MaintenanceHandler) HandleListAnnotations(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var routerID *uuid.UUID
+	if v := r.URL.Query().Get("router_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+			return
+		}
+		routerID = &id
+	}
+
+	from, to := parseTimeRange(r)
+
+	annotations, err := h.maintenanceService.Annotations(r.Context(), tenantID, routerID, from, to)
+	if err != nil {
+		respondMaintenanceError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, annotations)
+}
+
+// windowRequestIDs extracts the tenant and maintenance window IDs of a
+// request, responding with an error when either is missing
+func windowRequestIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	windowID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid maintenance window ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantID, windowID, true
+}
+
+// respondMaintenanceError maps maintenance service errors to HTTP responses
+func respondMaintenanceError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid maintenance window"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case strings.HasPrefix(err.Error(), "router "):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case strings.Contains(err.Error(), "not found"):
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
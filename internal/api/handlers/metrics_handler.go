@@ -7,6 +7,7 @@ import (
 
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -102,3 +103,106 @@ func parseTimeRange(r *http.Request) (time.Time, time.Time) {
 
 	return from, to
 }
+
+func (h *MetricsHandler) HandleGetRoleMetrics(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	filter, interval, aggregation, ok := parseRoleMetricsQuery(w, r)
+	if !ok {
+		return
+	}
+	if v := r.URL.Query().Get("router_id"); v != "" {
+		routerID, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+			return
+		}
+		filter.RouterID = &routerID
+	}
+
+	metrics, err := h.metricsService.GetRoleMetrics(r.Context(), tenantID, filter, interval, aggregation)
+	if err != nil {
+		respondRoleMetricsError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, metrics)
+}
+
+func (h *MetricsHandler) HandleGetRouterRoleMetrics(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	routerID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	filter, interval, aggregation, ok := parseRoleMetricsQuery(w, r)
+	if !ok {
+		return
+	}
+	filter.RouterID = &routerID
+
+	metrics, err := h.metricsService.GetRoleMetrics(r.Context(), tenantID, filter, interval, aggregation)
+	if err != nil {
+		respondRoleMetricsError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, metrics)
+}
+
+// parseRoleMetricsQuery reads the role from the path and the metrics,
+// interval, aggregation and time range from the query
+func parseRoleMetricsQuery(w http.ResponseWriter, r *http.Request) (repository.RoleMetricFilter, time.Duration, string, bool) {
+	query := r.URL.Query()
+	filter := repository.RoleMetricFilter{RoleCode: mux.Vars(r)["role"]}
+	filter.From, filter.To = parseTimeRange(r)
+
+	for _, name := range strings.Split(query.Get("metrics"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			filter.Metrics = append(filter.Metrics, name)
+		}
+	}
+
+	var interval time.Duration
+	if v := query.Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid interval, expected a duration such as 5m"))
+			return filter, 0, "", false
+		}
+		interval = d
+	}
+
+	aggregation := query.Get("agg")
+	switch aggregation {
+	case "", repository.RoleMetricAggAvg, repository.RoleMetricAggMin, repository.RoleMetricAggMax,
+		repository.RoleMetricAggSum, repository.RoleMetricAggLast:
+	default:
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid agg, expected avg, min, max, sum or last"))
+		return filter, 0, "", false
+	}
+
+	return filter, interval, aggregation, true
+}
+
+func respondRoleMetricsError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case strings.Contains(err.Error(), "not found"):
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound)
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+type MIBHandler struct {
+	mibService *service.MIBService
+	validator  *validator.Validate
+}
+
+func NewMIBHandler(mibService *service.MIBService, validator *validator.Validate) *MIBHandler {
+	return &MIBHandler{
+		mibService: mibService,
+		validator:  validator,
+	}
+}
+
+// HandleLookup resolves ?q= given as an object name or a numeric OID
+func (h *MIBHandler) HandleLookup(w http.ResponseWriter, r *http.Request) {
+	node, err := h.mibService.Lookup(r.URL.Query().Get("q"))
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+		case strings.Contains(err.Error(), "not found"):
+			utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, node)
+}
+
+// HandleListModules lists the loaded MIB modules
+func (h *MIBHandler) HandleListModules(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, h.mibService.ListModules())
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// NotificationHandler handles notification channel, route and delivery
+// log requests
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	validator           *validator.Validate
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *service.NotificationService, validator *validator.Validate) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		validator:           validator,
+	}
+}
+
+// HandleListChannels lists notification channels
+func (h *NotificationHandler) HandleListChannels(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	channels, total, err := h.notificationService.ListChannels(r.Context(), tenantID, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, channels, page, pageSize, total)
+}
+
+// HandleCreateChannel creates a notification channel
+func (h *NotificationHandler) HandleCreateChannel(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	var req dto.CreateNotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	channel, err := h.notificationService.CreateChannel(r.Context(), tenantID, createdBy, &req)
+	if err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, channel)
+}
+
+// HandleGetChannel retrieves a notification channel
+func (h *NotificationHandler) HandleGetChannel(w http.ResponseWriter, r *http.Request) {
+	tenantID, channelID, ok := notificationRequestIDs(w, r, "notification channel")
+	if !ok {
+		return
+	}
+
+	channel, err := h.notificationService.GetChannel(r.Context(), tenantID, channelID)
+	if err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, channel)
+}
+
+// HandleUpdateChannel updates a notification channel
+func (h *NotificationHandler) HandleUpdateChannel(w http.ResponseWriter, r *http.Request) {
+	tenantID, channelID, ok := notificationRequestIDs(w, r, "notification channel")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateNotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	channel, err := h.notificationService.UpdateChannel(r.Context(), tenantID, channelID, &req)
+	if err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, channel)
+}
+
+// HandleDeleteChannel deletes a notification channel
+func (h *NotificationHandler) HandleDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	tenantID, channelID, ok := notificationRequestIDs(w, r, "notification channel")
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.DeleteChannel(r.Context(), tenantID, channelID); err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandleTestChannel sends a test notification to a channel and returns its
+// delivery log entry, failed or not
+func (h *NotificationHandler) HandleTestChannel(w http.ResponseWriter, r *http.Request) {
+	tenantID, channelID, ok := notificationRequestIDs(w, r, "notification channel")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.notificationService.TestChannel(r.Context(), tenantID, channelID)
+	if err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, delivery)
+}
+
+// HandleListRoutes lists notification routes
+func (h *NotificationHandler) HandleListRoutes(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	routes, total, err := h.notificationService.ListRoutes(r.Context(), tenantID, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, routes, page, pageSize, total)
+}
+
+// HandleCreateRoute creates a notification route
+func (h *NotificationHandler) HandleCreateRoute(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var req dto.CreateNotificationRouteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	route, err := h.notificationService.CreateRoute(r.Context(), tenantID, &req)
+	if err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, route)
+}
+
+// HandleGetRoute retrieves a notification route
+func (h *NotificationHandler) HandleGetRoute(w http.ResponseWriter, r *http.Request) {
+	tenantID, routeID, ok := notificationRequestIDs(w, r, "notification route")
+	if !ok {
+		return
+	}
+
+	route, err := h.notificationService.GetRoute(r.Context(), tenantID, routeID)
+	if err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, route)
+}
+
+// HandleUpdateRoute updates a notification route
+func (h *NotificationHandler) HandleUpdateRoute(w http.ResponseWriter, r *http.Request) {
+	tenantID, routeID, ok := notificationRequestIDs(w, r, "notification route")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateNotificationRouteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	route, err := h.notificationService.UpdateRoute(r.Context(), tenantID, routeID, &req)
+	if err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, route)
+}
+
+// HandleDeleteRoute deletes a notification route
+func (h *NotificationHandler) HandleDeleteRoute(w http.ResponseWriter, r *http.Request) {
+	tenantID, routeID, ok := notificationRequestIDs(w, r, "notification route")
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.DeleteRoute(r.Context(), tenantID, routeID); err != nil {
+		respondNotificationError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandleListDeliveries lists the notification delivery log, latest first,
+// optionally filtered by the channel_id, alert_id and status query
+// parameters
+func (h *NotificationHandler) HandleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	query := r.URL.Query()
+	var filter repository.NotificationDeliveryFilter
+	if v := query.Get("channel_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid channel_id"))
+			return
+		}
+		filter.ChannelID = &id
+	}
+	if v := query.Get("alert_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid alert_id"))
+			return
+		}
+		filter.AlertID = &id
+	}
+	switch status := query.Get("status"); status {
+	case "", models.DeliveryPending, models.DeliverySent, models.DeliveryFailed, models.DeliveryCancelled:
+		filter.Status = status
+	default:
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid status"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	deliveries, total, err := h.notificationService.ListDeliveries(r.Context(), tenantID, filter, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, deliveries, page, pageSize, total)
+}
+
+// notificationRequestIDs extracts the tenant ID and the ID of the channel
+// or route a request is for, responding with an error when either is
+// missing
+func notificationRequestIDs(w http.ResponseWriter, r *http.Request, what string) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid "+what+" ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantID, id, true
+}
+
+// respondNotificationError maps notification service errors to HTTP
+// responses; a missing channel referenced by a route is a bad request
+func respondNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid "):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case err.Error() == "notification channel not found", err.Error() == "notification route not found":
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+	case strings.HasSuffix(err.Error(), " not found"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case strings.HasSuffix(err.Error(), " already exists"):
+		utils.RespondError(w, http.StatusConflict, utils.ErrConflict.WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
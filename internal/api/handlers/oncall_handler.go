@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// OnCallHandler handles on-call schedule, override and shift requests
+type OnCallHandler struct {
+	oncallService *service.OnCallService
+	validator     *validator.Validate
+}
+
+// NewOnCallHandler creates a new on-call handler
+func NewOnCallHandler(oncallService *service.OnCallService, validator *validator.Validate) *OnCallHandler {
+	return &OnCallHandler{
+		oncallService: oncallService,
+		validator:     validator,
+	}
+}
+
+// HandleListSchedules lists on-call schedules
+func (h *OnCallHandler) HandleListSchedules(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	schedules, total, err := h.oncallService.ListSchedules(r.Context(), tenantID, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, schedules, page, pageSize, total)
+}
+
+// HandleCreateSchedule creates an on-call schedule
+func (h *OnCallHandler) HandleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	var req dto.CreateOnCallScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	schedule, err := h.oncallService.CreateSchedule(r.Context(), tenantID, createdBy, &req)
+	if err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, schedule)
+}
+
+// HandleGetSchedule retrieves an on-call schedule with the user on call now
+func (h *OnCallHandler) HandleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	tenantID, scheduleID, ok := scheduleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.oncallService.GetSchedule(r.Context(), tenantID, scheduleID)
+	if err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, schedule)
+}
+
+// HandleUpdateSchedule updates an on-call schedule
+func (h *OnCallHandler) HandleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	tenantID, scheduleID, ok := scheduleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateOnCallScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	schedule, err := h.oncallService.UpdateSchedule(r.Context(), tenantID, scheduleID, &req)
+	if err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, schedule)
+}
+
+// HandleDeleteSchedule deletes an on-call schedule with its overrides
+func (h *OnCallHandler) HandleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	tenantID, scheduleID, ok := scheduleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.oncallService.DeleteSchedule(r.Context(), tenantID, scheduleID); err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// HandleListShifts lists who is on call for a schedule between the from
+// and to query parameters, the next two weeks by default
+func (h *OnCallHandler) HandleListShifts(w http.ResponseWriter, r *http.Request) {
+	tenantID, scheduleID, ok := scheduleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseScheduleRange(w, r)
+	if !ok {
+		return
+	}
+
+	shifts, err := h.oncallService.ListShifts(r.Context(), tenantID, scheduleID, from, to)
+	if err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, shifts)
+}
+
+// HandleListOverrides lists the overrides of a schedule between the from
+// and to query parameters, the next two weeks by default
+func (h *OnCallHandler) HandleListOverrides(w http.ResponseWriter, r *http.Request) {
+	tenantID, scheduleID, ok := scheduleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseScheduleRange(w, r)
+	if !ok {
+		return
+	}
+
+	overrides, err := h.oncallService.ListOverrides(r.Context(), tenantID, scheduleID, from, to)
+	if err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, overrides)
+}
+
+// HandleCreateOverride puts a user on call for a schedule over a period
+func (h *OnCallHandler) HandleCreateOverride(w http.ResponseWriter, r *http.Request) {
+	tenantID, scheduleID, ok := scheduleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(uuid.UUID); ok {
+		createdBy = &userID
+	}
+
+	var req dto.CreateOnCallOverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.RespondValidationError(w, err)
+		return
+	}
+
+	override, err := h.oncallService.CreateOverride(r.Context(), tenantID, scheduleID, createdBy, &req)
+	if err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondCreated(w, override)
+}
+
+// HandleDeleteOverride deletes an override of an on-call schedule
+func (h *OnCallHandler) HandleDeleteOverride(w http.ResponseWriter, r *http.Request) {
+	tenantID, scheduleID, ok := scheduleRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	overrideID, err := uuid.Parse(mux.Vars(r)["override_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid on-call override ID"))
+		return
+	}
+
+	if err := h.oncallService.DeleteOverride(r.Context(), tenantID, scheduleID, overrideID); err != nil {
+		respondOnCallError(w, err)
+		return
+	}
+
+	utils.RespondNoContent(w)
+}
+
+// scheduleRequestIDs extracts the tenant and on-call schedule IDs of a
+// request, responding with an error when either is missing
+func scheduleRequestIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	scheduleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid on-call schedule ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantID, scheduleID, true
+}
+
+// parseScheduleRange parses the optional RFC 3339 from and to query
+// parameters, responding with an error when either is malformed
+func parseScheduleRange(w http.ResponseWriter, r *http.Request) (*time.Time, *time.Time, bool) {
+	var bounds [2]*time.Time
+	for i, name := range []string{"from", "to"} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid "+name+" time"))
+			return nil, nil, false
+		}
+		bounds[i] = &t
+	}
+	return bounds[0], bounds[1], true
+}
+
+// respondOnCallError maps on-call service errors to HTTP responses; a
+// missing user referenced by a schedule or override is a bad request
+func respondOnCallError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid "):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case err.Error() == "on-call schedule not found", err.Error() == "on-call override not found":
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails(err.Error()))
+	case strings.HasSuffix(err.Error(), " not found"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case strings.HasSuffix(err.Error(), " already exists"):
+		utils.RespondError(w, http.StatusConflict, utils.ErrConflict.WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// PollerHandler handles on-demand polling and health check requests
+type PollerHandler struct {
+	pollerService *service.PollerService
+	validator     *validator.Validate
+}
+
+// NewPollerHandler creates a new poller handler
+func NewPollerHandler(pollerService *service.PollerService, validator *validator.Validate) *PollerHandler {
+	return &PollerHandler{
+		pollerService: pollerService,
+		validator:     validator,
+	}
+}
+
+// HandlePollNow polls a router immediately and returns the result
+func (h *PollerHandler) HandlePollNow(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	routerID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	result, err := h.pollerService.PollNow(r.Context(), tenantID, routerID)
+	if err != nil {
+		respondPollerError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// HandleHealthCheck tests connectivity to a router, through the adapter
+// named by the adapter query parameter when given
+func (h *PollerHandler) HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	routerID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+		return
+	}
+
+	result, err := h.pollerService.HealthCheck(r.Context(), tenantID, routerID, r.URL.Query().Get("adapter"))
+	if err != nil {
+		respondPollerError(w, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// respondPollerError maps poller service errors to HTTP responses
+func respondPollerError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		utils.RespondError(w, http.StatusNotFound, utils.ErrNotFound.WithDetails("Router not found"))
+	case strings.HasPrefix(err.Error(), "invalid adapter"):
+		utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails(err.Error()))
+	case strings.Contains(err.Error(), "queue full"):
+		utils.RespondError(w, http.StatusServiceUnavailable, utils.NewAPIError("POLLER_BUSY", "Poll queue is full").WithDetails(err.Error()))
+	case strings.Contains(err.Error(), "timed out"):
+		utils.RespondError(w, http.StatusGatewayTimeout, utils.NewAPIError("POLL_TIMEOUT", "Poll did not complete in time").WithDetails(err.Error()))
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/syslog"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type SyslogHandler struct {
+	syslogService *service.SyslogService
+	validator     *validator.Validate
+}
+
+func NewSyslogHandler(syslogService *service.SyslogService, validator *validator.Validate) *SyslogHandler {
+	return &SyslogHandler{
+		syslogService: syslogService,
+		validator:     validator,
+	}
+}
+
+func (h *SyslogHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := r.Context().Value(middleware.TenantIDKey).(uuid.UUID)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal.WithDetails("Tenant context not found"))
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.SyslogFilter{
+		Text: query.Get("q"),
+	}
+
+	if v := query.Get("router_id"); v != "" {
+		routerID, err := uuid.Parse(v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid router ID"))
+			return
+		}
+		filter.RouterID = &routerID
+	}
+	if v := query.Get("severity"); v != "" {
+		severity, ok := syslog.ParseSeverity(v)
+		if !ok {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid severity"))
+			return
+		}
+		filter.MaxSeverity = &severity
+	}
+	if v := query.Get("facility"); v != "" {
+		facility, ok := syslog.ParseFacility(v)
+		if !ok {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid facility"))
+			return
+		}
+		filter.Facility = &facility
+	}
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid from time, expected RFC 3339"))
+			return
+		}
+		filter.From = &from
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, utils.ErrBadRequest.WithDetails("Invalid to time, expected RFC 3339"))
+			return
+		}
+		filter.To = &to
+	}
+
+	page, pageSize := parsePagination(r)
+	opts := repository.ListOptions{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	messages, total, err := h.syslogService.Search(r.Context(), tenantID, filter, opts)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, utils.ErrInternal)
+		return
+	}
+
+	utils.RespondPaginated(w, messages, page, pageSize, total)
+}
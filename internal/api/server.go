@@ -3,17 +3,26 @@ package api
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/alerting"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/handlers"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/utils"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/auth"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/compliance"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/firmware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/maintenance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/middleware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/notify"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/service"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
 )
 
@@ -27,18 +36,32 @@ type Server struct {
 	logger       *zap.Logger
 
 	// Handlers
-	authHandler      *handlers.AuthHandler
-	routerHandler    *handlers.RouterHandler
-	interfaceHandler *handlers.InterfaceHandler
-	topologyHandler  *handlers.TopologyHandler
-	metricsHandler   *handlers.MetricsHandler
-	alertHandler     *handlers.AlertHandler
-	userHandler      *handlers.UserHandler
-	tenantHandler    *handlers.TenantHandler
+	authHandler       *handlers.AuthHandler
+	routerHandler     *handlers.RouterHandler
+	interfaceHandler  *handlers.InterfaceHandler
+	topologyHandler   *handlers.TopologyHandler
+	metricsHandler    *handlers.MetricsHandler
+	alertHandler      *handlers.AlertHandler
+	userHandler       *handlers.UserHandler
+	tenantHandler     *handlers.TenantHandler
+	configHandler     *handlers.ConfigBackupHandler
+	complianceHandler *handlers.ComplianceHandler
+	firmwareHandler   *handlers.FirmwareHandler
+	syslogHandler     *handlers.SyslogHandler
+	flowHandler       *handlers.FlowHandler
+	pollerHandler     *handlers.PollerHandler
+
+	credentialProfileHandler *handlers.CredentialProfileHandler
+	maintenanceHandler       *handlers.MaintenanceHandler
+	alertRuleHandler         *handlers.AlertRuleHandler
+	mibHandler               *handlers.MIBHandler
+	notificationHandler      *handlers.NotificationHandler
+	oncallHandler            *handlers.OnCallHandler
+	escalationHandler        *handlers.EscalationHandler
 }
 
 // NewServer creates a new API server instance
-func NewServer(db *database.DB, apiCfg config.APIConfig, authCfg config.AuthConfig) *Server {
+func NewServer(db *database.DB, apiCfg config.APIConfig, authCfg config.AuthConfig, firmwareCfg config.FirmwareConfig, pollerCfg config.PollerConfig, pollerService *poller.EnhancedService, secrets *vault.Vault, windows *maintenance.Checker, rules *alerting.Engine, mibs *mib.Tree, notifier *notify.Dispatcher) *Server {
 	// Create logger
 	logger, err := zap.NewProduction()
 	if err != nil {
@@ -58,16 +81,43 @@ func NewServer(db *database.DB, apiCfg config.APIConfig, authCfg config.AuthConf
 	interfaceRepo := postgres.NewInterfaceRepo(db.DB)
 	linkRepo := postgres.NewLinkRepo(db.DB)
 	alertRepo := postgres.NewAlertRepo(db.DB)
+	configBackupRepo := postgres.NewConfigBackupRepo(db.DB)
+	complianceRepo := postgres.NewComplianceRepo(db.DB)
+	syslogRepo := postgres.NewSyslogRepo(db.DB)
+	flowRepo := postgres.NewFlowRepo(db.DB)
+	natEventRepo := postgres.NewNATEventRepo(db.DB)
+	subscriberUsageRepo := postgres.NewSubscriberUsageRepo(db.DB)
+	credentialProfileRepo := postgres.NewCredentialProfileRepo(db.DB)
+	maintenanceWindowRepo := postgres.NewMaintenanceWindowRepo(db.DB)
+	roleMetricRepo := postgres.NewRoleMetricRepo(db.DB)
+	alertRuleRepo := postgres.NewAlertRuleRepo(db.DB)
+	notificationRepo := postgres.NewNotificationRepo(db.DB)
+	oncallRepo := postgres.NewOnCallRepo(db.DB)
+	escalationRepo := postgres.NewEscalationRepo(db.DB)
 
 	// Create services
 	authService := service.NewAuthService(userRepo, tenantRepo, authProvider, logger)
-	routerService := service.NewRouterService(routerRepo, logger)
+	routerService := service.NewRouterService(routerRepo, secrets, logger)
 	interfaceService := service.NewInterfaceService(interfaceRepo, routerRepo, logger)
 	topologyService := service.NewTopologyService(routerRepo, interfaceRepo, linkRepo, logger)
-	metricsService := service.NewMetricsService(interfaceRepo, routerRepo, logger)
-	alertService := service.NewAlertService(alertRepo, logger)
+	metricsService := service.NewMetricsService(interfaceRepo, routerRepo, maintenanceWindowRepo, roleMetricRepo, logger)
+	alertService := service.NewAlertService(alertRepo, escalationRepo, logger)
 	userService := service.NewUserService(userRepo, logger)
 	tenantService := service.NewTenantService(tenantRepo, logger)
+	configBackupService := service.NewConfigBackupService(configBackupRepo, routerRepo, logger)
+	complianceEngine := compliance.NewEngine(complianceRepo, configBackupRepo, routerRepo)
+	complianceService := service.NewComplianceService(complianceRepo, complianceEngine, logger)
+	firmwareService := service.NewFirmwareService(routerRepo, firmware.NewStore(firmwareCfg.DatasetPath), logger)
+	syslogService := service.NewSyslogService(syslogRepo, logger)
+	flowService := service.NewFlowService(flowRepo, natEventRepo, subscriberUsageRepo, logger)
+	pollerSvc := service.NewPollerService(routerRepo, pollerService, time.Duration(pollerCfg.PollNowTimeoutSeconds)*time.Second, logger)
+	credentialProfileService := service.NewCredentialProfileService(credentialProfileRepo, routerRepo, pollerService, secrets, time.Duration(pollerCfg.PollNowTimeoutSeconds)*time.Second, logger)
+	maintenanceService := service.NewMaintenanceService(maintenanceWindowRepo, routerRepo, windows, logger)
+	alertRuleService := service.NewAlertRuleService(alertRuleRepo, rules, logger)
+	mibService := service.NewMIBService(mibs, logger)
+	notificationService := service.NewNotificationService(notificationRepo, notifier, secrets, logger)
+	oncallService := service.NewOnCallService(oncallRepo, userRepo, logger)
+	escalationService := service.NewEscalationService(escalationRepo, alertRepo, notificationRepo, oncallRepo, userRepo, logger)
 
 	// Create validator
 	validatorInstance := utils.NewValidator()
@@ -81,22 +131,49 @@ func NewServer(db *database.DB, apiCfg config.APIConfig, authCfg config.AuthConf
 	alertHandler := handlers.NewAlertHandler(alertService, validatorInstance.Validator())
 	userHandler := handlers.NewUserHandler(userService, validatorInstance.Validator())
 	tenantHandler := handlers.NewTenantHandler(tenantService, validatorInstance.Validator())
+	configHandler := handlers.NewConfigBackupHandler(configBackupService, validatorInstance.Validator())
+	complianceHandler := handlers.NewComplianceHandler(complianceService, validatorInstance.Validator())
+	firmwareHandler := handlers.NewFirmwareHandler(firmwareService, validatorInstance.Validator())
+	syslogHandler := handlers.NewSyslogHandler(syslogService, validatorInstance.Validator())
+	flowHandler := handlers.NewFlowHandler(flowService, validatorInstance.Validator())
+	pollerHandler := handlers.NewPollerHandler(pollerSvc, validatorInstance.Validator())
+	credentialProfileHandler := handlers.NewCredentialProfileHandler(credentialProfileService, validatorInstance.Validator())
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService, validatorInstance.Validator())
+	alertRuleHandler := handlers.NewAlertRuleHandler(alertRuleService, validatorInstance.Validator())
+	mibHandler := handlers.NewMIBHandler(mibService, validatorInstance.Validator())
+	notificationHandler := handlers.NewNotificationHandler(notificationService, validatorInstance.Validator())
+	oncallHandler := handlers.NewOnCallHandler(oncallService, validatorInstance.Validator())
+	escalationHandler := handlers.NewEscalationHandler(escalationService, validatorInstance.Validator())
 
 	s := &Server{
-		db:               db,
-		config:           apiCfg,
-		authConfig:       authCfg,
-		router:           mux.NewRouter(),
-		authProvider:     authProvider,
-		logger:           logger,
-		authHandler:      authHandler,
-		routerHandler:    routerHandler,
-		interfaceHandler: interfaceHandler,
-		topologyHandler:  topologyHandler,
-		metricsHandler:   metricsHandler,
-		alertHandler:     alertHandler,
-		userHandler:      userHandler,
-		tenantHandler:    tenantHandler,
+		db:                db,
+		config:            apiCfg,
+		authConfig:        authCfg,
+		router:            mux.NewRouter(),
+		authProvider:      authProvider,
+		logger:            logger,
+		authHandler:       authHandler,
+		routerHandler:     routerHandler,
+		interfaceHandler:  interfaceHandler,
+		topologyHandler:   topologyHandler,
+		metricsHandler:    metricsHandler,
+		alertHandler:      alertHandler,
+		userHandler:       userHandler,
+		tenantHandler:     tenantHandler,
+		configHandler:     configHandler,
+		complianceHandler: complianceHandler,
+		firmwareHandler:   firmwareHandler,
+		syslogHandler:     syslogHandler,
+		flowHandler:       flowHandler,
+		pollerHandler:     pollerHandler,
+
+		credentialProfileHandler: credentialProfileHandler,
+		maintenanceHandler:       maintenanceHandler,
+		alertRuleHandler:         alertRuleHandler,
+		mibHandler:               mibHandler,
+		notificationHandler:      notificationHandler,
+		oncallHandler:            oncallHandler,
+		escalationHandler:        escalationHandler,
 	}
 
 	s.setupRoutes()
@@ -144,6 +221,102 @@ func (s *Server) setupRoutes() {
 	protected.HandleFunc("/routers/{id}", s.routerHandler.HandleGetRouter).Methods("GET")
 	protected.HandleFunc("/routers/{id}", s.routerHandler.HandleUpdateRouter).Methods("PUT")
 	protected.HandleFunc("/routers/{id}", s.routerHandler.HandleDeleteRouter).Methods("DELETE")
+	protected.HandleFunc("/routers/{id}/poll", s.pollerHandler.HandlePollNow).Methods("POST")
+	protected.HandleFunc("/routers/{id}/health-check", s.pollerHandler.HandleHealthCheck).Methods("POST")
+
+	// Router configuration backup routes
+	protected.HandleFunc("/routers/{id}/configs", s.configHandler.HandleListConfigVersions).Methods("GET")
+	protected.HandleFunc("/routers/{id}/configs/diff", s.configHandler.HandleDiffConfigVersions).Methods("GET")
+	protected.HandleFunc("/routers/{id}/configs/{version:[0-9]+}", s.configHandler.HandleGetConfigVersion).Methods("GET")
+	protected.HandleFunc("/routers/{id}/compliance", s.complianceHandler.HandleGetRouterCompliance).Methods("GET")
+
+	// Configuration compliance routes
+	protected.HandleFunc("/compliance/policies", s.complianceHandler.HandleListPolicies).Methods("GET")
+	protected.HandleFunc("/compliance/policies", s.complianceHandler.HandleCreatePolicy).Methods("POST")
+	protected.HandleFunc("/compliance/policies/{id}", s.complianceHandler.HandleGetPolicy).Methods("GET")
+	protected.HandleFunc("/compliance/policies/{id}", s.complianceHandler.HandleUpdatePolicy).Methods("PUT")
+	protected.HandleFunc("/compliance/policies/{id}", s.complianceHandler.HandleDeletePolicy).Methods("DELETE")
+	protected.HandleFunc("/compliance/evaluate", s.complianceHandler.HandleEvaluate).Methods("POST")
+	protected.HandleFunc("/compliance/report", s.complianceHandler.HandleGetReport).Methods("GET")
+
+	// Credential profile routes
+	protected.HandleFunc("/credential-profiles", s.credentialProfileHandler.HandleListProfiles).Methods("GET")
+	protected.HandleFunc("/credential-profiles", s.credentialProfileHandler.HandleCreateProfile).Methods("POST")
+	protected.HandleFunc("/credential-profiles/{id}", s.credentialProfileHandler.HandleGetProfile).Methods("GET")
+	protected.HandleFunc("/credential-profiles/{id}", s.credentialProfileHandler.HandleUpdateProfile).Methods("PUT")
+	protected.HandleFunc("/credential-profiles/{id}", s.credentialProfileHandler.HandleDeleteProfile).Methods("DELETE")
+	protected.HandleFunc("/credential-profiles/{id}/routers", s.credentialProfileHandler.HandleListRouters).Methods("GET")
+	protected.HandleFunc("/credential-profiles/{id}/routers", s.credentialProfileHandler.HandleAssignRouters).Methods("POST")
+	protected.HandleFunc("/credential-profiles/{id}/routers/{routerId}", s.credentialProfileHandler.HandleUnassignRouter).Methods("DELETE")
+	protected.HandleFunc("/credential-profiles/{id}/test", s.credentialProfileHandler.HandleTestProfile).Methods("POST")
+	protected.HandleFunc("/credential-profiles/{id}/rotate", s.credentialProfileHandler.HandleRotateProfile).Methods("POST")
+
+	// Maintenance window routes
+	protected.HandleFunc("/maintenance-windows", s.maintenanceHandler.HandleListWindows).Methods("GET")
+	protected.HandleFunc("/maintenance-windows", s.maintenanceHandler.HandleCreateWindow).Methods("POST")
+	protected.HandleFunc("/maintenance-windows/annotations", s.maintenanceHandler.HandleListAnnotations).Methods("GET")
+	protected.HandleFunc("/maintenance-windows/{id}", s.maintenanceHandler.HandleGetWindow).Methods("GET")
+	protected.HandleFunc("/maintenance-windows/{id}", s.maintenanceHandler.HandleUpdateWindow).Methods("PUT")
+	protected.HandleFunc("/maintenance-windows/{id}", s.maintenanceHandler.HandleDeleteWindow).Methods("DELETE")
+	protected.HandleFunc("/maintenance-windows/{id}/routers", s.maintenanceHandler.HandleListRouters).Methods("GET")
+
+	// Alert rule routes
+	protected.HandleFunc("/alert-rules", s.alertRuleHandler.HandleListRules).Methods("GET")
+	protected.HandleFunc("/alert-rules", s.alertRuleHandler.HandleCreateRule).Methods("POST")
+	protected.HandleFunc("/alert-rules/preview", s.alertRuleHandler.HandlePreviewRule).Methods("POST")
+	protected.HandleFunc("/alert-rules/{id}", s.alertRuleHandler.HandleGetRule).Methods("GET")
+	protected.HandleFunc("/alert-rules/{id}", s.alertRuleHandler.HandleUpdateRule).Methods("PUT")
+	protected.HandleFunc("/alert-rules/{id}", s.alertRuleHandler.HandleDeleteRule).Methods("DELETE")
+
+	// Notification routes
+	protected.HandleFunc("/notification-channels", s.notificationHandler.HandleListChannels).Methods("GET")
+	protected.HandleFunc("/notification-channels", s.notificationHandler.HandleCreateChannel).Methods("POST")
+	protected.HandleFunc("/notification-channels/{id}", s.notificationHandler.HandleGetChannel).Methods("GET")
+	protected.HandleFunc("/notification-channels/{id}", s.notificationHandler.HandleUpdateChannel).Methods("PUT")
+	protected.HandleFunc("/notification-channels/{id}", s.notificationHandler.HandleDeleteChannel).Methods("DELETE")
+	protected.HandleFunc("/notification-channels/{id}/test", s.notificationHandler.HandleTestChannel).Methods("POST")
+	protected.HandleFunc("/notification-routes", s.notificationHandler.HandleListRoutes).Methods("GET")
+	protected.HandleFunc("/notification-routes", s.notificationHandler.HandleCreateRoute).Methods("POST")
+	protected.HandleFunc("/notification-routes/{id}", s.notificationHandler.HandleGetRoute).Methods("GET")
+	protected.HandleFunc("/notification-routes/{id}", s.notificationHandler.HandleUpdateRoute).Methods("PUT")
+	protected.HandleFunc("/notification-routes/{id}", s.notificationHandler.HandleDeleteRoute).Methods("DELETE")
+	protected.HandleFunc("/notification-deliveries", s.notificationHandler.HandleListDeliveries).Methods("GET")
+
+	// On-call schedule routes
+	protected.HandleFunc("/oncall-schedules", s.oncallHandler.HandleListSchedules).Methods("GET")
+	protected.HandleFunc("/oncall-schedules", s.oncallHandler.HandleCreateSchedule).Methods("POST")
+	protected.HandleFunc("/oncall-schedules/{id}", s.oncallHandler.HandleGetSchedule).Methods("GET")
+	protected.HandleFunc("/oncall-schedules/{id}", s.oncallHandler.HandleUpdateSchedule).Methods("PUT")
+	protected.HandleFunc("/oncall-schedules/{id}", s.oncallHandler.HandleDeleteSchedule).Methods("DELETE")
+	protected.HandleFunc("/oncall-schedules/{id}/shifts", s.oncallHandler.HandleListShifts).Methods("GET")
+	protected.HandleFunc("/oncall-schedules/{id}/overrides", s.oncallHandler.HandleListOverrides).Methods("GET")
+	protected.HandleFunc("/oncall-schedules/{id}/overrides", s.oncallHandler.HandleCreateOverride).Methods("POST")
+	protected.HandleFunc("/oncall-schedules/{id}/overrides/{override_id}", s.oncallHandler.HandleDeleteOverride).Methods("DELETE")
+
+	// Escalation policy routes
+	protected.HandleFunc("/escalation-policies", s.escalationHandler.HandleListPolicies).Methods("GET")
+	protected.HandleFunc("/escalation-policies", s.escalationHandler.HandleCreatePolicy).Methods("POST")
+	protected.HandleFunc("/escalation-policies/{id}", s.escalationHandler.HandleGetPolicy).Methods("GET")
+	protected.HandleFunc("/escalation-policies/{id}", s.escalationHandler.HandleUpdatePolicy).Methods("PUT")
+	protected.HandleFunc("/escalation-policies/{id}", s.escalationHandler.HandleDeletePolicy).Methods("DELETE")
+
+	// MIB routes
+	protected.HandleFunc("/mibs/lookup", s.mibHandler.HandleLookup).Methods("GET")
+	protected.HandleFunc("/mibs/modules", s.mibHandler.HandleListModules).Methods("GET")
+
+	// Firmware inventory routes
+	protected.HandleFunc("/firmware/inventory", s.firmwareHandler.HandleGetInventory).Methods("GET")
+
+	// Syslog routes
+	protected.HandleFunc("/syslog", s.syslogHandler.HandleSearch).Methods("GET")
+
+	// Flow routes
+	protected.HandleFunc("/flows/top", s.flowHandler.HandleTop).Methods("GET")
+	protected.HandleFunc("/flows/matrix", s.flowHandler.HandleMatrix).Methods("GET")
+	protected.HandleFunc("/flows/nat-events", s.flowHandler.HandleNATEvents).Methods("GET")
+	protected.HandleFunc("/flows/subscribers/top", s.flowHandler.HandleTopSubscribers).Methods("GET")
+	protected.HandleFunc("/flows/subscribers/history", s.flowHandler.HandleSubscriberHistory).Methods("GET")
+	protected.HandleFunc("/flows/subscribers/applications", s.flowHandler.HandleSubscriberApplications).Methods("GET")
 
 	// Interface endpoints
 	protected.HandleFunc("/interfaces", s.interfaceHandler.HandleListInterfaces).Methods("GET")
@@ -156,10 +329,13 @@ func (s *Server) setupRoutes() {
 	// Metrics endpoints
 	protected.HandleFunc("/metrics/interfaces/{id}", s.metricsHandler.HandleGetInterfaceMetrics).Methods("GET")
 	protected.HandleFunc("/metrics/routers/{id}", s.metricsHandler.HandleGetRouterMetrics).Methods("GET")
+	protected.HandleFunc("/metrics/routers/{id}/roles/{role}", s.metricsHandler.HandleGetRouterRoleMetrics).Methods("GET")
+	protected.HandleFunc("/metrics/roles/{role}", s.metricsHandler.HandleGetRoleMetrics).Methods("GET")
 
 	// Alert endpoints
 	protected.HandleFunc("/alerts", s.alertHandler.HandleListAlerts).Methods("GET")
 	protected.HandleFunc("/alerts/{id}/acknowledge", s.alertHandler.HandleAcknowledgeAlert).Methods("POST")
+	protected.HandleFunc("/alerts/{id}/escalations", s.escalationHandler.HandleListAlertEscalations).Methods("GET")
 
 	// User endpoints
 	protected.HandleFunc("/users", s.userHandler.HandleListUsers).Methods("GET")
@@ -202,19 +378,118 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 				"POST /api/v1/auth/logout":   "User logout (auth required)",
 			},
 			"routers": map[string]string{
-				"GET /api/v1/routers":         "List all routers (auth required)",
-				"POST /api/v1/routers":        "Create new router (auth required)",
-				"GET /api/v1/routers/{id}":    "Get router details (auth required)",
-				"PUT /api/v1/routers/{id}":    "Update router (auth required)",
-				"DELETE /api/v1/routers/{id}": "Delete router (auth required)",
+				"GET /api/v1/routers":                                   "List all routers (auth required)",
+				"POST /api/v1/routers":                                  "Create new router (auth required)",
+				"GET /api/v1/routers/{id}":                              "Get router details (auth required)",
+				"PUT /api/v1/routers/{id}":                              "Update router (auth required)",
+				"DELETE /api/v1/routers/{id}":                           "Delete router (auth required)",
+				"POST /api/v1/routers/{id}/poll":                        "Poll router now and return the result (auth required)",
+				"POST /api/v1/routers/{id}/health-check?adapter={name}": "Test connectivity through an adapter (auth required)",
+			},
+			"configs": map[string]string{
+				"GET /api/v1/routers/{id}/configs":                      "List stored configuration versions (auth required)",
+				"GET /api/v1/routers/{id}/configs/{version}":            "Get a configuration version (auth required)",
+				"GET /api/v1/routers/{id}/configs/diff?from={v}&to={v}": "Unified diff between two versions (auth required)",
+			},
+			"compliance": map[string]string{
+				"GET /api/v1/compliance/policies":         "List compliance policies (auth required)",
+				"POST /api/v1/compliance/policies":        "Create compliance policy (auth required)",
+				"GET /api/v1/compliance/policies/{id}":    "Get compliance policy (auth required)",
+				"PUT /api/v1/compliance/policies/{id}":    "Update compliance policy (auth required)",
+				"DELETE /api/v1/compliance/policies/{id}": "Delete compliance policy (auth required)",
+				"POST /api/v1/compliance/evaluate":        "Evaluate all routers against active policies (auth required)",
+				"GET /api/v1/compliance/report":           "Fleet compliance report (auth required)",
+				"GET /api/v1/routers/{id}/compliance":     "Evaluate one router's compliance (auth required)",
+			},
+			"credential_profiles": map[string]string{
+				"GET /api/v1/credential-profiles?method={method}":             "List credential profiles (auth required)",
+				"POST /api/v1/credential-profiles":                            "Create credential profile (auth required)",
+				"GET /api/v1/credential-profiles/{id}":                        "Get credential profile (auth required)",
+				"PUT /api/v1/credential-profiles/{id}":                        "Update credential profile (auth required)",
+				"DELETE /api/v1/credential-profiles/{id}":                     "Delete unused credential profile (auth required)",
+				"GET /api/v1/credential-profiles/{id}/routers":                "List routers using the profile (auth required)",
+				"POST /api/v1/credential-profiles/{id}/routers":               "Assign the profile to routers (auth required)",
+				"DELETE /api/v1/credential-profiles/{id}/routers/{router_id}": "Unassign the profile from a router (auth required)",
+				"POST /api/v1/credential-profiles/{id}/test":                  "Check the profile against every router using it (auth required)",
+				"POST /api/v1/credential-profiles/{id}/rotate":                "Replace the profile's secrets and check every router using it (auth required)",
+			},
+			"maintenance_windows": map[string]string{
+				"GET /api/v1/maintenance-windows":                                    "List maintenance windows (auth required)",
+				"POST /api/v1/maintenance-windows":                                   "Create one-off or recurring maintenance window (auth required)",
+				"GET /api/v1/maintenance-windows/{id}":                               "Get maintenance window (auth required)",
+				"PUT /api/v1/maintenance-windows/{id}":                               "Update maintenance window (auth required)",
+				"DELETE /api/v1/maintenance-windows/{id}":                            "Delete maintenance window (auth required)",
+				"GET /api/v1/maintenance-windows/{id}/routers":                       "List routers the window covers (auth required)",
+				"GET /api/v1/maintenance-windows/annotations?from&to&router_id={id}": "Maintenance periods as graph annotations (auth required)",
+			},
+			"alert_rules": map[string]string{
+				"GET /api/v1/alert-rules":                    "List alert rules (auth required)",
+				"POST /api/v1/alert-rules":                   "Create threshold, rate-of-change or absence rule (auth required)",
+				"GET /api/v1/alert-rules/{id}":               "Get alert rule (auth required)",
+				"PUT /api/v1/alert-rules/{id}":               "Update alert rule (auth required)",
+				"DELETE /api/v1/alert-rules/{id}":            "Delete alert rule and resolve its alerts (auth required)",
+				"POST /api/v1/alert-rules/preview?hours={n}": "When a draft rule would have fired over stored data (auth required)",
+			},
+			"notifications": map[string]string{
+				"GET /api/v1/notification-channels":                              "List notification channels (auth required)",
+				"POST /api/v1/notification-channels":                             "Create email, webhook, Slack or Telegram channel (auth required)",
+				"GET /api/v1/notification-channels/{id}":                         "Get notification channel (auth required)",
+				"PUT /api/v1/notification-channels/{id}":                         "Update notification channel (auth required)",
+				"DELETE /api/v1/notification-channels/{id}":                      "Delete notification channel and its routes (auth required)",
+				"POST /api/v1/notification-channels/{id}/test":                   "Send a test notification through a channel (auth required)",
+				"GET /api/v1/notification-routes":                                "List notification routes (auth required)",
+				"POST /api/v1/notification-routes":                               "Route alerts by severity, role or POP to a channel (auth required)",
+				"GET /api/v1/notification-routes/{id}":                           "Get notification route (auth required)",
+				"PUT /api/v1/notification-routes/{id}":                           "Update notification route (auth required)",
+				"DELETE /api/v1/notification-routes/{id}":                        "Delete notification route (auth required)",
+				"GET /api/v1/notification-deliveries?channel_id&alert_id&status": "Notification delivery log (auth required)",
+			},
+			"oncall": map[string]string{
+				"GET /api/v1/oncall-schedules":                                 "List on-call schedules (auth required)",
+				"POST /api/v1/oncall-schedules":                                "Create weekly rotation with coverage hours and time zone (auth required)",
+				"GET /api/v1/oncall-schedules/{id}":                            "Get on-call schedule and who is on call now (auth required)",
+				"PUT /api/v1/oncall-schedules/{id}":                            "Update on-call schedule (auth required)",
+				"DELETE /api/v1/oncall-schedules/{id}":                         "Delete on-call schedule and its overrides (auth required)",
+				"GET /api/v1/oncall-schedules/{id}/shifts?from&to":             "Who is on call over a range, overrides applied (auth required)",
+				"GET /api/v1/oncall-schedules/{id}/overrides?from&to":          "List on-call overrides (auth required)",
+				"POST /api/v1/oncall-schedules/{id}/overrides":                 "Put a user on call over a period (auth required)",
+				"DELETE /api/v1/oncall-schedules/{id}/overrides/{override_id}": "Delete on-call override (auth required)",
+			},
+			"escalations": map[string]string{
+				"GET /api/v1/escalation-policies":         "List escalation policies (auth required)",
+				"POST /api/v1/escalation-policies":        "Page tiers of channels, users and schedules until acknowledged (auth required)",
+				"GET /api/v1/escalation-policies/{id}":    "Get escalation policy (auth required)",
+				"PUT /api/v1/escalation-policies/{id}":    "Update escalation policy (auth required)",
+				"DELETE /api/v1/escalation-policies/{id}": "Delete escalation policy (auth required)",
+				"GET /api/v1/alerts/{id}/escalations":     "Escalation progress of an alert (auth required)",
+			},
+			"mibs": map[string]string{
+				"GET /api/v1/mibs/lookup?q={name|oid}": "Resolve a MIB object name to its OID or an OID to its object (auth required)",
+				"GET /api/v1/mibs/modules":             "List loaded MIB modules and load warnings (auth required)",
+			},
+			"firmware": map[string]string{
+				"GET /api/v1/firmware/inventory": "Fleet grouped by vendor/model/OS version with advisory and EOL flags (auth required)",
+			},
+			"syslog": map[string]string{
+				"GET /api/v1/syslog": "Search received syslog messages by router, severity, time and text (auth required)",
+			},
+			"flows": map[string]string{
+				"GET /api/v1/flows/top":                      "Top traffic by interface, protocol, prefix or AS from flow rollups (auth required)",
+				"GET /api/v1/flows/matrix":                   "Source/destination traffic matrix by prefix or AS (auth required)",
+				"GET /api/v1/flows/nat-events":               "Search NAT translation events by inside or public address and port (auth required)",
+				"GET /api/v1/flows/subscribers/top":          "Top PPPoE and DHCP subscribers by traffic, per router or POP (auth required)",
+				"GET /api/v1/flows/subscribers/history":      "One subscriber's traffic over time (auth required)",
+				"GET /api/v1/flows/subscribers/applications": "Subscriber traffic by application port (auth required)",
 			},
 			"topology": map[string]string{
 				"GET /api/v1/topology":         "Get network topology (auth required)",
 				"GET /api/v1/topology/geojson": "Get topology as GeoJSON (auth required)",
 			},
 			"metrics": map[string]string{
-				"GET /api/v1/metrics/interfaces/{id}": "Get interface metrics (auth required)",
-				"GET /api/v1/metrics/routers/{id}":    "Get router metrics (auth required)",
+				"GET /api/v1/metrics/interfaces/{id}":           "Get interface metrics (auth required)",
+				"GET /api/v1/metrics/routers/{id}":              "Get router metrics (auth required)",
+				"GET /api/v1/metrics/routers/{id}/roles/{role}": "Get a router's role-specific metric series (auth required)",
+				"GET /api/v1/metrics/roles/{role}":              "Get role-specific metric series of all routers with a role (auth required)",
 			},
 		},
 		"note": "Most endpoints require JWT authentication. Use /api/v1/auth/login to get a token.",
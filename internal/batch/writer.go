@@ -0,0 +1,206 @@
+// Package batch provides a write-behind pipeline that buffers rows per table
+// and stores them with COPY.
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/metrics"
+	"github.com/lib/pq"
+)
+
+// flushTimeout bounds one table's COPY
+const flushTimeout = 30 * time.Second
+
+// ErrUnknownTable is returned when writing to a table that was not registered
+var ErrUnknownTable = errors.New("table not registered with batch writer")
+
+// CopyFunc stores rows of a table; NewWriter's uses COPY
+type CopyFunc func(ctx context.Context, table string, columns []string, rows [][]interface{}) error
+
+// row is a queued row of a table
+type row struct {
+	table  string
+	values []interface{}
+}
+
+// buffer holds the rows of one table waiting to be flushed
+type buffer struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// Writer buffers rows per table and flushes a table once it holds batchSize
+// rows, and all tables every flushInterval. Write blocks while the queue is
+// full, so a database that cannot keep up slows down the producers instead of
+// rows being dropped. Rows of a batch that fails to copy are dropped.
+type Writer struct {
+	batchSize     int
+	flushInterval time.Duration
+	copy          CopyFunc
+
+	// tables is only modified by Register before Run
+	tables map[string]*buffer
+	queue  chan row
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewWriter creates a writer that copies into db. Register the tables before
+// calling Run.
+func NewWriter(db *sql.DB, batchSize int, flushInterval time.Duration, queueSize int) *Writer {
+	return NewWriterFunc(func(ctx context.Context, table string, columns []string, rows [][]interface{}) error {
+		return copyIn(ctx, db, table, columns, rows)
+	}, batchSize, flushInterval, queueSize)
+}
+
+// NewWriterFunc creates a writer that stores batches with copy
+func NewWriterFunc(copy CopyFunc, batchSize int, flushInterval time.Duration, queueSize int) *Writer {
+	w := newWriter(batchSize, flushInterval, queueSize)
+	w.copy = copy
+	return w
+}
+
+func newWriter(batchSize int, flushInterval time.Duration, queueSize int) *Writer {
+	return &Writer{
+		batchSize:     max(batchSize, 1),
+		flushInterval: flushInterval,
+		tables:        make(map[string]*buffer),
+		queue:         make(chan row, queueSize),
+		done:          make(chan struct{}),
+	}
+}
+
+// Register declares a table and the columns rows are written with
+func (w *Writer) Register(table string, columns ...string) {
+	w.tables[table] = &buffer{
+		columns: columns,
+		rows:    make([][]interface{}, 0, w.batchSize),
+	}
+}
+
+// Write queues a row for table, with values in the registered column order.
+// It blocks while the queue is full and must not be called after Close.
+func (w *Writer) Write(table string, values ...interface{}) error {
+	b, ok := w.tables[table]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTable, table)
+	}
+	if len(values) != len(b.columns) {
+		return fmt.Errorf("%s row has %d values, want %d", table, len(values), len(b.columns))
+	}
+
+	item := row{table: table, values: values}
+	select {
+	case w.queue <- item:
+	default:
+		// Record that the producer had to wait for the database
+		metrics.BatchWriterBlocked.Inc()
+		w.queue <- item
+	}
+	metrics.BatchWriterQueueDepth.Set(float64(len(w.queue)))
+	return nil
+}
+
+// Run flushes queued rows until Close is called, then flushes what is left
+func (w *Writer) Run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				w.flushAll()
+				return
+			}
+			b := w.tables[item.table]
+			b.rows = append(b.rows, item.values)
+			if len(b.rows) >= w.batchSize {
+				w.flush(item.table, b)
+			}
+		case <-ticker.C:
+			w.flushAll()
+			metrics.BatchWriterQueueDepth.Set(float64(len(w.queue)))
+		}
+	}
+}
+
+// Close stops accepting rows and waits until the queued ones are flushed.
+// The caller must make sure no Write is in progress or follows.
+func (w *Writer) Close() {
+	w.closeOnce.Do(func() {
+		close(w.queue)
+	})
+	<-w.done
+}
+
+// flushAll flushes every table holding rows
+func (w *Writer) flushAll() {
+	for table, b := range w.tables {
+		w.flush(table, b)
+	}
+}
+
+// flush copies a table's buffered rows and empties its buffer
+func (w *Writer) flush(table string, b *buffer) {
+	if len(b.rows) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := w.copy(ctx, table, b.columns, b.rows)
+	metrics.BatchWriterFlushDuration.WithLabelValues(table).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		metrics.BatchWriterRowsDropped.WithLabelValues(table).Add(float64(len(b.rows)))
+		log.Printf("Error copying %d rows into %s: %v", len(b.rows), table, err)
+	} else {
+		metrics.BatchWriterRowsWritten.WithLabelValues(table).Add(float64(len(b.rows)))
+	}
+
+	// copy does not keep the rows, so the buffer is reused
+	clear(b.rows)
+	b.rows = b.rows[:0]
+}
+
+// copyIn stores rows in one transaction with COPY FROM STDIN
+func copyIn(ctx context.Context, db *sql.DB, table string, columns []string, rows [][]interface{}) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	for _, values := range rows {
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	// An Exec without arguments ends the COPY and reports its errors
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
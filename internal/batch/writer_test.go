@@ -0,0 +1,156 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCopy records the batches the writer flushes
+type fakeCopy struct {
+	mu      sync.Mutex
+	batches map[string][]int
+	rows    map[string][][]interface{}
+	fail    bool
+	block   chan struct{}
+}
+
+func newFakeCopy() *fakeCopy {
+	return &fakeCopy{batches: make(map[string][]int), rows: make(map[string][][]interface{})}
+}
+
+func (f *fakeCopy) copy(ctx context.Context, table string, columns []string, rows [][]interface{}) error {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("copy failed")
+	}
+	f.batches[table] = append(f.batches[table], len(rows))
+	for _, r := range rows {
+		f.rows[table] = append(f.rows[table], append([]interface{}(nil), r...))
+	}
+	return nil
+}
+
+func (f *fakeCopy) batchSizes(table string) []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.batches[table]...)
+}
+
+func testWriter(f *fakeCopy, batchSize int, interval time.Duration, queueSize int) *Writer {
+	w := newWriter(batchSize, interval, queueSize)
+	w.copy = f.copy
+	w.Register("router_metrics", "router_id", "cpu_percent")
+	w.Register("polling_history", "router_id", "success")
+	return w
+}
+
+func TestWriterFlushesBySize(t *testing.T) {
+	f := newFakeCopy()
+	w := testWriter(f, 3, time.Hour, 100)
+	go w.Run()
+
+	for i := 0; i < 7; i++ {
+		if err := w.Write("router_metrics", i, float64(i)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Write("polling_history", 1, true); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Close()
+
+	// Two full batches, then what was left at shutdown
+	if got := f.batchSizes("router_metrics"); len(got) != 3 || got[0] != 3 || got[1] != 3 || got[2] != 1 {
+		t.Errorf("router_metrics batches = %v, want [3 3 1]", got)
+	}
+	if got := f.batchSizes("polling_history"); len(got) != 1 || got[0] != 1 {
+		t.Errorf("polling_history batches = %v, want [1]", got)
+	}
+	for i, r := range f.rows["router_metrics"] {
+		if r[0] != i {
+			t.Fatalf("row %d = %v, rows reordered or reused", i, r)
+		}
+	}
+}
+
+func TestWriterFlushesByTime(t *testing.T) {
+	f := newFakeCopy()
+	w := testWriter(f, 1000, 10*time.Millisecond, 100)
+	go w.Run()
+	defer w.Close()
+
+	if err := w.Write("router_metrics", 1, 12.5); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(f.batchSizes("router_metrics")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("row not flushed by the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWriterBackpressure(t *testing.T) {
+	f := newFakeCopy()
+	f.block = make(chan struct{})
+	w := testWriter(f, 1, time.Hour, 1)
+	go w.Run()
+
+	// The first row is being copied and the second fills the queue, so the
+	// third has to wait for the copy
+	written := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			w.Write("router_metrics", i, 0.0)
+		}
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("Write did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(f.block)
+	<-written
+	w.Close()
+	if got := f.batchSizes("router_metrics"); len(got) != 3 {
+		t.Errorf("batches = %v, want 3 single rows", got)
+	}
+}
+
+func TestWriterRejectsInvalidRows(t *testing.T) {
+	w := testWriter(newFakeCopy(), 10, time.Hour, 10)
+	if err := w.Write("alerts", 1); !errors.Is(err, ErrUnknownTable) {
+		t.Errorf("unknown table: err = %v, want ErrUnknownTable", err)
+	}
+	if err := w.Write("router_metrics", 1); err == nil {
+		t.Error("row with missing values accepted")
+	}
+}
+
+func TestWriterDropsFailedBatch(t *testing.T) {
+	f := newFakeCopy()
+	f.fail = true
+	w := testWriter(f, 2, time.Hour, 10)
+	go w.Run()
+
+	w.Write("router_metrics", 1, 1.0)
+	w.Write("router_metrics", 2, 2.0)
+	w.Write("router_metrics", 3, 3.0)
+	w.Close()
+
+	if len(w.tables["router_metrics"].rows) != 0 {
+		t.Errorf("failed rows kept in buffer: %v", w.tables["router_metrics"].rows)
+	}
+}
@@ -0,0 +1,144 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// routerPageSize is the page size used when walking all routers of a tenant
+const routerPageSize = 100
+
+// Engine evaluates a tenant's active policies against the latest stored
+// configuration of its routers and persists the results
+type Engine struct {
+	compliance repository.ComplianceRepository
+	configs    repository.ConfigBackupRepository
+	routers    repository.RouterRepository
+}
+
+// NewEngine creates a new compliance engine
+func NewEngine(
+	complianceRepo repository.ComplianceRepository,
+	configRepo repository.ConfigBackupRepository,
+	routerRepo repository.RouterRepository,
+) *Engine {
+	return &Engine{
+		compliance: complianceRepo,
+		configs:    configRepo,
+		routers:    routerRepo,
+	}
+}
+
+// EvaluateRouter checks every active policy against the router's latest
+// configuration. Routers without a stored configuration are skipped and
+// return no results.
+func (e *Engine) EvaluateRouter(ctx context.Context, tenantID, routerID uuid.UUID) ([]*models.ComplianceResult, error) {
+	policies, err := e.compliance.ListActivePolicies(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compliance policies: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	router, err := e.routers.GetByID(ctx, tenantID, routerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.evaluate(ctx, router, policies)
+}
+
+// EvaluateTenant checks every active policy against every router of the tenant
+// and returns the number of routers evaluated
+func (e *Engine) EvaluateTenant(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	policies, err := e.compliance.ListActivePolicies(ctx, tenantID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load compliance policies: %w", err)
+	}
+	if len(policies) == 0 {
+		return 0, nil
+	}
+
+	evaluated := 0
+	for page := 1; ; page++ {
+		routers, total, err := e.routers.List(ctx, tenantID, repository.ListOptions{Page: page, PageSize: routerPageSize})
+		if err != nil {
+			return evaluated, fmt.Errorf("failed to list routers: %w", err)
+		}
+
+		for _, router := range routers {
+			results, err := e.evaluate(ctx, router, policies)
+			if err != nil {
+				return evaluated, err
+			}
+			if len(results) > 0 {
+				evaluated++
+			}
+		}
+
+		if int64(page*routerPageSize) >= total || len(routers) == 0 {
+			return evaluated, nil
+		}
+	}
+}
+
+func (e *Engine) evaluate(ctx context.Context, router *models.Router, policies []*models.CompliancePolicy) ([]*models.ComplianceResult, error) {
+	config, err := e.configs.GetLatest(ctx, router.TenantID, router.ID)
+	if errors.Is(err, repository.ErrConfigVersionNotFound) {
+		// Nothing backed up yet
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load latest config of router %s: %w", router.Name, err)
+	}
+
+	roleCodes, err := e.routers.GetRoleCodes(ctx, router.TenantID, router.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load roles for router %s: %w", router.Name, err)
+	}
+
+	data := TemplateData{
+		Name:         router.Name,
+		ManagementIP: router.ManagementIP,
+	}
+	if router.Hostname != nil {
+		data.Hostname = *router.Hostname
+	}
+	if router.Vendor != nil {
+		data.Vendor = *router.Vendor
+	}
+
+	now := time.Now()
+	results := make([]*models.ComplianceResult, 0, len(policies))
+	for _, policy := range policies {
+		eval := Evaluate(policy, config.Content, data, roleCodes)
+
+		result := &models.ComplianceResult{
+			TenantID:      router.TenantID,
+			RouterID:      router.ID,
+			PolicyID:      policy.ID,
+			ConfigVersion: config.Version,
+			Score:         eval.Score,
+			PassedChecks:  eval.PassedChecks,
+			TotalChecks:   eval.TotalChecks,
+			Violations:    eval.Violations,
+			EvaluatedAt:   now,
+			RouterName:    router.Name,
+			PolicyName:    policy.Name,
+			RoleCodes:     roleCodes,
+		}
+		if err := e.compliance.SaveResult(ctx, result); err != nil {
+			return nil, fmt.Errorf("failed to store compliance result for router %s: %w", router.Name, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,304 @@
+// Package compliance checks stored router configurations against
+// tenant-defined golden-template policies.
+package compliance
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// maxForbiddenMatches caps how many offending lines one rule reports
+const maxForbiddenMatches = 10
+
+// TemplateData is the data available to role section templates,
+// e.g. "set name={{.Name}}" or "ip address {{.ManagementIP}} 255.255.255.255"
+type TemplateData struct {
+	Name         string
+	Hostname     string
+	ManagementIP string
+	Vendor       string
+	RoleCode     string
+}
+
+// Evaluation is the outcome of checking one policy against one configuration
+type Evaluation struct {
+	Score        float64
+	PassedChecks int
+	TotalChecks  int
+	Violations   []models.ComplianceViolation
+}
+
+// ValidatePolicy checks that all patterns compile and all templates parse
+func ValidatePolicy(policy *models.CompliancePolicy) error {
+	for i, rule := range policy.Rules {
+		if strings.TrimSpace(rule.Pattern) == "" {
+			return fmt.Errorf("rule %d: pattern is required", i+1)
+		}
+		switch rule.Type {
+		case models.ComplianceRequiredLine:
+			if rule.Regex {
+				if _, err := regexp.Compile(rule.Pattern); err != nil {
+					return fmt.Errorf("rule %d: invalid regex: %w", i+1, err)
+				}
+			}
+		case models.ComplianceForbiddenPattern:
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return fmt.Errorf("rule %d: invalid regex: %w", i+1, err)
+			}
+		default:
+			return fmt.Errorf("rule %d: unknown rule type %q", i+1, rule.Type)
+		}
+	}
+
+	for roleCode, sections := range policy.RoleSections {
+		for _, section := range sections {
+			if _, err := parseTemplate(section); err != nil {
+				return fmt.Errorf("role %s section %q: %w", roleCode, section.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Evaluate checks a configuration against a policy. Role sections are only
+// checked for the role codes the router holds.
+func Evaluate(policy *models.CompliancePolicy, config string, data TemplateData, roleCodes []string) Evaluation {
+	lines, numbers := configLines(config)
+	eval := Evaluation{}
+	var passedWeight, totalWeight int
+
+	record := func(severity string, violation *models.ComplianceViolation) {
+		weight := severityWeight(severity)
+		totalWeight += weight
+		eval.TotalChecks++
+		if violation == nil {
+			passedWeight += weight
+			eval.PassedChecks++
+			return
+		}
+		violation.PolicyID = policy.ID
+		violation.PolicyName = policy.Name
+		violation.Severity = normalizeSeverity(severity)
+		eval.Violations = append(eval.Violations, *violation)
+	}
+
+	for _, rule := range policy.Rules {
+		switch rule.Type {
+		case models.ComplianceRequiredLine:
+			record(rule.Severity, checkRequiredLine(rule, lines))
+		case models.ComplianceForbiddenPattern:
+			record(rule.Severity, checkForbiddenPattern(rule, lines, numbers))
+		}
+	}
+
+	for _, roleCode := range roleCodes {
+		data.RoleCode = roleCode
+		for _, section := range policy.RoleSections[roleCode] {
+			record(section.Severity, checkSection(section, lines, data))
+		}
+	}
+
+	eval.Score = 100
+	if totalWeight > 0 {
+		eval.Score = math.Round(float64(passedWeight)/float64(totalWeight)*1000) / 10
+	}
+
+	return eval
+}
+
+// checkRequiredLine returns a violation if no configuration line matches the rule
+func checkRequiredLine(rule models.ComplianceRule, lines []string) *models.ComplianceViolation {
+	var re *regexp.Regexp
+	if rule.Regex {
+		var err error
+		if re, err = regexp.Compile(rule.Pattern); err != nil {
+			return &models.ComplianceViolation{
+				Type:    rule.Type,
+				Check:   rule.Pattern,
+				Message: fmt.Sprintf("invalid regex: %v", err),
+			}
+		}
+	}
+
+	want := collapseSpaces(rule.Pattern)
+	for _, line := range lines {
+		if (re != nil && re.MatchString(line)) || (re == nil && line == want) {
+			return nil
+		}
+	}
+
+	return &models.ComplianceViolation{
+		Type:    rule.Type,
+		Check:   rule.Pattern,
+		Message: describe(rule, "required line not found"),
+	}
+}
+
+// checkForbiddenPattern returns a violation listing the first offending
+// lines; numbers holds the configuration line each of lines starts on
+func checkForbiddenPattern(rule models.ComplianceRule, lines []string, numbers []int) *models.ComplianceViolation {
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return &models.ComplianceViolation{
+			Type:    rule.Type,
+			Check:   rule.Pattern,
+			Message: fmt.Sprintf("invalid regex: %v", err),
+		}
+	}
+
+	var matches []string
+	firstLine := 0
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		if firstLine == 0 {
+			firstLine = numbers[i]
+		}
+		matches = append(matches, line)
+		if len(matches) == maxForbiddenMatches {
+			break
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	return &models.ComplianceViolation{
+		Type:    rule.Type,
+		Check:   rule.Pattern,
+		Message: describe(rule, fmt.Sprintf("forbidden pattern found on %d line(s)", len(matches))),
+		Line:    firstLine,
+		Content: strings.Join(matches, "\n"),
+	}
+}
+
+// checkSection renders the section template and returns a violation listing
+// the template lines that do not appear, in order, in the configuration
+func checkSection(section models.ComplianceSection, lines []string, data TemplateData) *models.ComplianceViolation {
+	tmpl, err := parseTemplate(section)
+	if err == nil {
+		var buf bytes.Buffer
+		if err = tmpl.Execute(&buf, data); err == nil {
+			want, _ := configLines(buf.String())
+			missing := missingInOrder(want, lines)
+			if len(missing) == 0 {
+				return nil
+			}
+			return &models.ComplianceViolation{
+				Type:         models.ComplianceRequiredSection,
+				Check:        section.Name,
+				Message:      fmt.Sprintf("section %q (%s) is missing %d line(s)", section.Name, data.RoleCode, len(missing)),
+				MissingLines: missing,
+			}
+		}
+	}
+
+	return &models.ComplianceViolation{
+		Type:    models.ComplianceRequiredSection,
+		Check:   section.Name,
+		Message: fmt.Sprintf("section %q template error: %v", section.Name, err),
+	}
+}
+
+// missingInOrder greedily matches want as an ordered subsequence of have and
+// returns the wanted lines that could not be placed
+func missingInOrder(want, have []string) []string {
+	var missing []string
+	pos := 0
+	for _, w := range want {
+		found := false
+		for i := pos; i < len(have); i++ {
+			if have[i] == w {
+				pos = i + 1
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
+
+func parseTemplate(section models.ComplianceSection) (*template.Template, error) {
+	return template.New(section.Name).Option("missingkey=error").Parse(section.Template)
+}
+
+// configLines splits a configuration into trimmed, non-empty lines with
+// runs of whitespace collapsed, and returns the 1-based number of the
+// configuration line each starts on. RouterOS wraps long export lines with a
+// trailing backslash; those continuations are joined back together.
+func configLines(config string) ([]string, []int) {
+	raw := strings.Split(strings.ReplaceAll(config, "\r\n", "\n"), "\n")
+
+	lines := make([]string, 0, len(raw))
+	numbers := make([]int, 0, len(raw))
+	var pending strings.Builder
+	start := 0
+	for i, line := range raw {
+		line = strings.TrimSpace(line)
+		if pending.Len() == 0 {
+			start = i + 1
+		}
+		if strings.HasSuffix(line, "\\") {
+			pending.WriteString(strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		if pending.Len() > 0 {
+			pending.WriteString(line)
+			line = pending.String()
+			pending.Reset()
+		}
+		if line = collapseSpaces(line); line != "" {
+			lines = append(lines, line)
+			numbers = append(numbers, start)
+		}
+	}
+	if pending.Len() > 0 {
+		lines = append(lines, collapseSpaces(pending.String()))
+		numbers = append(numbers, start)
+	}
+
+	return lines, numbers
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func describe(rule models.ComplianceRule, fallback string) string {
+	if rule.Description != "" {
+		return rule.Description
+	}
+	return fallback
+}
+
+func normalizeSeverity(severity string) string {
+	switch severity {
+	case models.SeverityCritical, models.SeverityInfo:
+		return severity
+	default:
+		return models.SeverityWarning
+	}
+}
+
+// severityWeight makes critical failures pull the score down harder
+func severityWeight(severity string) int {
+	switch normalizeSeverity(severity) {
+	case models.SeverityCritical:
+		return 3
+	case models.SeverityWarning:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,150 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+const pppoeExport = `/ip firewall filter
+add action=accept chain=input connection-state=established,related
+add action=drop chain=input connection-state=invalid \
+    comment="drop invalid"
+/ip service
+set telnet disabled=yes
+set ftp disabled=no
+/system identity
+set name=pppoe-01
+`
+
+func testPolicy() *models.CompliancePolicy {
+	return &models.CompliancePolicy{
+		Name: "baseline",
+		Rules: []models.ComplianceRule{
+			{Type: models.ComplianceRequiredLine, Pattern: "set telnet   disabled=yes"},
+			{Type: models.ComplianceForbiddenPattern, Pattern: `^set (ftp|www) disabled=no`, Severity: models.SeverityCritical},
+		},
+		RoleSections: map[string][]models.ComplianceSection{
+			models.RoleCodePPPoEServer: {{
+				Name: "standard firewall",
+				Template: `/ip firewall filter
+add action=accept chain=input connection-state=established,related
+add action=drop chain=input connection-state=invalid comment="drop invalid"
+add action=drop chain=input in-interface-list=WAN`,
+			}},
+			models.RoleCodeCoreRouter: {{
+				Name:     "identity",
+				Template: "set name={{.Name}}",
+			}},
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy := testPolicy()
+	if err := ValidatePolicy(policy); err != nil {
+		t.Fatalf("ValidatePolicy() unexpected error: %v", err)
+	}
+
+	eval := Evaluate(policy, pppoeExport, TemplateData{Name: "pppoe-01"}, []string{models.RoleCodePPPoEServer})
+
+	if eval.TotalChecks != 3 || eval.PassedChecks != 1 {
+		t.Fatalf("expected 1/3 checks passed, got %d/%d", eval.PassedChecks, eval.TotalChecks)
+	}
+	if len(eval.Violations) != 2 {
+		t.Fatalf("expected 2 violations, got %d: %+v", len(eval.Violations), eval.Violations)
+	}
+
+	forbidden := eval.Violations[0]
+	if forbidden.Type != models.ComplianceForbiddenPattern || forbidden.Content != "set ftp disabled=no" || forbidden.Line != 7 {
+		t.Errorf("unexpected forbidden violation: %+v", forbidden)
+	}
+
+	section := eval.Violations[1]
+	if len(section.MissingLines) != 1 || section.MissingLines[0] != "add action=drop chain=input in-interface-list=WAN" {
+		t.Errorf("expected only the WAN drop rule to be missing, got %v", section.MissingLines)
+	}
+
+	// weights: required warning 2 (pass), forbidden critical 3, section warning 2
+	if eval.Score != 28.6 {
+		t.Errorf("expected score 28.6, got %v", eval.Score)
+	}
+}
+
+func TestForbiddenPatternLine(t *testing.T) {
+	config := `/ip service
+
+set telnet disabled=yes \
+    port=23
+
+/ip firewall filter
+add action=accept chain=input \
+    protocol=tcp dst-port=21
+`
+	policy := &models.CompliancePolicy{Rules: []models.ComplianceRule{
+		{Type: models.ComplianceForbiddenPattern, Pattern: `dst-port=21`},
+	}}
+
+	eval := Evaluate(policy, config, TemplateData{}, nil)
+	if len(eval.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got %+v", eval.Violations)
+	}
+	// The match is on the continuation of the rule starting on line 7
+	if v := eval.Violations[0]; v.Line != 7 || v.Content != "add action=accept chain=input protocol=tcp dst-port=21" {
+		t.Errorf("violation on line %d: %q, want line 7", v.Line, v.Content)
+	}
+}
+
+func TestEvaluateRoleTemplate(t *testing.T) {
+	policy := &models.CompliancePolicy{RoleSections: testPolicy().RoleSections}
+
+	eval := Evaluate(policy, pppoeExport, TemplateData{Name: "pppoe-01"}, []string{models.RoleCodeCoreRouter})
+	if eval.Score != 100 || len(eval.Violations) != 0 {
+		t.Errorf("expected templated identity section to pass, got %+v", eval)
+	}
+
+	eval = Evaluate(policy, pppoeExport, TemplateData{Name: "core-01"}, []string{models.RoleCodeCoreRouter})
+	if len(eval.Violations) != 1 || eval.Violations[0].MissingLines[0] != "set name=core-01" {
+		t.Errorf("expected identity section to fail for another router name, got %+v", eval.Violations)
+	}
+
+	// No role sections apply and there are no rules
+	eval = Evaluate(policy, pppoeExport, TemplateData{}, nil)
+	if eval.Score != 100 || eval.TotalChecks != 0 {
+		t.Errorf("expected empty evaluation to score 100, got %+v", eval)
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *models.CompliancePolicy
+	}{
+		{
+			name: "invalid regex",
+			policy: &models.CompliancePolicy{Rules: []models.ComplianceRule{
+				{Type: models.ComplianceForbiddenPattern, Pattern: "("},
+			}},
+		},
+		{
+			name: "unknown rule type",
+			policy: &models.CompliancePolicy{Rules: []models.ComplianceRule{
+				{Type: "sometimes_line", Pattern: "x"},
+			}},
+		},
+		{
+			name: "broken template",
+			policy: &models.CompliancePolicy{RoleSections: map[string][]models.ComplianceSection{
+				models.RoleCodeFirewall: {{Name: "bad", Template: "{{.Name"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePolicy(tt.policy); err == nil {
+				t.Error("expected validation error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package configbackup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/sshclient"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"gopkg.in/routeros.v2"
+)
+
+// Collector retrieves the running configuration of a router
+type Collector interface {
+	// Method returns the collection method name stored with each version
+	Method() string
+
+	// CanHandle determines if this collector can fetch the router's configuration
+	CanHandle(router *models.EnhancedRouter) bool
+
+	// Fetch returns the raw configuration text
+	Fetch(ctx context.Context, router *models.EnhancedRouter) (string, error)
+}
+
+// isMikroTik reports whether the router runs RouterOS
+func isMikroTik(router *models.EnhancedRouter) bool {
+	if router.Capabilities != nil && router.Capabilities.API != nil &&
+		router.Capabilities.API.Enabled && router.Capabilities.API.Type == "mikrotik" {
+		return true
+	}
+	return router.Vendor != nil && strings.EqualFold(*router.Vendor, "mikrotik")
+}
+
+// ConfigCommand returns the CLI command that prints the full configuration
+// for the router's vendor
+func ConfigCommand(router *models.EnhancedRouter) string {
+	if isMikroTik(router) {
+		return "/export"
+	}
+	if router.Vendor != nil {
+		switch strings.ToLower(*router.Vendor) {
+		case "juniper":
+			return "show configuration | display set | no-more"
+		case "huawei":
+			return "display current-configuration"
+		}
+	}
+	return "show running-config"
+}
+
+// SSHCollector fetches configurations by running the vendor export command over SSH
+type SSHCollector struct {
+	timeout time.Duration
+}
+
+// NewSSHCollector creates a new SSH collector
+func NewSSHCollector(timeout time.Duration) *SSHCollector {
+	return &SSHCollector{timeout: timeout}
+}
+
+// Method returns the collection method name
+func (c *SSHCollector) Method() string {
+	return models.ConfigCollectionSSH
+}
+
+// CanHandle checks if SSH credentials are configured for the router
+func (c *SSHCollector) CanHandle(router *models.EnhancedRouter) bool {
+	if router.Capabilities == nil || router.Capabilities.SSH == nil {
+		return false
+	}
+	sshCfg := router.Capabilities.SSH
+	return sshCfg.Enabled && sshCfg.Username != "" &&
+		(sshCfg.Password != nil || sshCfg.PrivateKey != nil)
+}
+
+// Fetch runs the export command and returns its output
+func (c *SSHCollector) Fetch(ctx context.Context, router *models.EnhancedRouter) (string, error) {
+	client, err := sshclient.Dial(ctx, router, c.timeout)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	command := ConfigCommand(router)
+	if err := session.Run(command); err != nil {
+		return "", fmt.Errorf("command %q failed: %w (%s)", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if stdout.Len() == 0 {
+		return "", fmt.Errorf("command %q returned no output", command)
+	}
+
+	return stdout.String(), nil
+}
+
+// MikroTikAPICollector fetches RouterOS exports through the RouterOS API
+type MikroTikAPICollector struct {
+	timeout time.Duration
+}
+
+// NewMikroTikAPICollector creates a new RouterOS API collector
+func NewMikroTikAPICollector(timeout time.Duration) *MikroTikAPICollector {
+	return &MikroTikAPICollector{timeout: timeout}
+}
+
+// Method returns the collection method name
+func (c *MikroTikAPICollector) Method() string {
+	return models.ConfigCollectionMikroTikAPI
+}
+
+// CanHandle checks if the RouterOS API is configured for the router
+func (c *MikroTikAPICollector) CanHandle(router *models.EnhancedRouter) bool {
+	if router.Capabilities == nil || router.Capabilities.API == nil {
+		return false
+	}
+	api := router.Capabilities.API
+	return api.Enabled && api.Type == "mikrotik"
+}
+
+// Fetch runs /export over the API. RouterOS streams the export as a series
+// of !re sentences carrying a "ret" attribute, one chunk each.
+func (c *MikroTikAPICollector) Fetch(ctx context.Context, router *models.EnhancedRouter) (string, error) {
+	apiCfg := router.Capabilities.API
+
+	address := router.ManagementIP
+	if apiCfg.Port != nil && *apiCfg.Port != 0 {
+		address = net.JoinHostPort(address, strconv.Itoa(*apiCfg.Port))
+	} else {
+		address = net.JoinHostPort(address, "8728")
+	}
+
+	timeout := c.timeout
+	if apiCfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(apiCfg.TimeoutSeconds) * time.Second
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect: %w", err)
+	}
+	// The API client has no context; the session is bounded by the deadline
+	// of ctx and cut off when ctx is cancelled
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := routeros.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("failed to connect: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Login(apiCfg.Username, apiCfg.Password); err != nil {
+		return "", fmt.Errorf("failed to connect: %w", fetchErr(ctx, err))
+	}
+
+	reply, err := client.Run("/export")
+	if err != nil {
+		return "", fmt.Errorf("/export failed: %w", fetchErr(ctx, err))
+	}
+
+	var sb strings.Builder
+	for _, re := range reply.Re {
+		if chunk, ok := re.Map["ret"]; ok {
+			sb.WriteString(chunk)
+		}
+	}
+	if reply.Done != nil {
+		if chunk, ok := reply.Done.Map["ret"]; ok {
+			sb.WriteString(chunk)
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("/export returned no output")
+	}
+
+	return sb.String(), nil
+}
+
+// fetchErr returns the error of ctx when it ended the API session, since
+// the read error of the closed connection does not tell why it was closed
+func fetchErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
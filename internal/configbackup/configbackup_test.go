@@ -0,0 +1,170 @@
+package configbackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "routeros ppp secret",
+			input:    `/ppp secret add name=user1 password=hunter2 profile=default`,
+			expected: `/ppp secret add name=user1 password=******** profile=default`,
+		},
+		{
+			name:     "routeros quoted wireless key",
+			input:    `set wpa2-pre-shared-key="my key \"x\"" mode=dynamic-keys`,
+			expected: `set wpa2-pre-shared-key=******** mode=dynamic-keys`,
+		},
+		{
+			name:     "ios enable secret",
+			input:    "enable secret 5 $1$abcd$efgh",
+			expected: "enable secret 5 ********",
+		},
+		{
+			name:     "ios snmp community",
+			input:    "snmp-server community public RO",
+			expected: "snmp-server community ******** RO",
+		},
+		{
+			name:     "routeros secret menu header",
+			input:    "/ppp secret\nadd name=user1 password=hunter2",
+			expected: "/ppp secret\nadd name=user1 password=********",
+		},
+		{
+			name:     "key chain name is not a secret",
+			input:    "key chain OSPF-KEYS",
+			expected: "key chain OSPF-KEYS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskSecrets(tt.input); got != tt.expected {
+				t.Errorf("MaskSecrets() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrepareIgnoresExportTimestamp(t *testing.T) {
+	first := "# jan/02/2024 10:00:00 by RouterOS 7.12\r\n/system identity\r\nset name=core1\r\n"
+	second := "# jan/03/2024 11:30:00 by RouterOS 7.12\n/system identity\nset name=core1   \n"
+
+	_, hash1 := Prepare(first)
+	_, hash2 := Prepare(second)
+	if hash1 != hash2 {
+		t.Errorf("expected identical hashes for exports differing only in timestamp, got %s and %s", hash1, hash2)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	to := "a\nb\nc\nD\ne\nf\ng\nh\ni\n"
+
+	if diff := UnifiedDiff("v1", "v2", from, from, DefaultContextLines); diff != "" {
+		t.Errorf("expected empty diff for identical content, got %q", diff)
+	}
+
+	diff := UnifiedDiff("v1", "v2", from, to, 1)
+	expected := strings.Join([]string{
+		"--- v1",
+		"+++ v2",
+		"@@ -3,3 +3,3 @@",
+		" c",
+		"-d",
+		"+D",
+		" e",
+		"@@ -8 +8,2 @@",
+		" h",
+		"+i",
+		"",
+	}, "\n")
+	if diff != expected {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", diff, expected)
+	}
+
+	stats := Stats(from, to)
+	if stats.Added != 2 || stats.Removed != 1 {
+		t.Errorf("Stats() = +%d/-%d, want +2/-1", stats.Added, stats.Removed)
+	}
+}
+
+func TestStatsLargeConfig(t *testing.T) {
+	// Thousands of changed /ppp secret lines between otherwise equal exports
+	var from, to strings.Builder
+	from.WriteString("/ppp secret\n")
+	to.WriteString("/ppp secret\n")
+	for i := 0; i < 8000; i++ {
+		line := fmt.Sprintf("add name=user%d profile=default service=pppoe\n", i)
+		from.WriteString(line)
+		switch {
+		case i%4 == 0:
+			to.WriteString(fmt.Sprintf("add name=user%d profile=premium service=pppoe\n", i))
+		case i%4 == 1:
+		default:
+			to.WriteString(line)
+		}
+	}
+
+	stats := Stats(from.String(), to.String())
+	if stats.Added != 2000 || stats.Removed != 4000 {
+		t.Errorf("Stats() = +%d/-%d, want +2000/-4000", stats.Added, stats.Removed)
+	}
+}
+
+func TestMikroTikAPIFetchCancelled(t *testing.T) {
+	// The router accepts the connection and never answers
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	port := ln.Addr().(*net.TCPAddr).Port
+	router := &models.EnhancedRouter{
+		Router: models.Router{ManagementIP: "127.0.0.1"},
+		Capabilities: &models.RouterCapabilities{
+			API: &models.APICapability{Enabled: true, Type: "mikrotik", Port: &port, Username: "backup"},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := NewMikroTikAPICollector(time.Minute).Fetch(ctx, router)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Fetch did not return after its context ended")
+	}
+}
@@ -0,0 +1,309 @@
+package configbackup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContextLines is the number of unchanged lines shown around a change
+const DefaultContextLines = 3
+
+// opKind identifies a single line operation in an edit script
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// lineOp is one step of an edit script turning a into b
+type lineOp struct {
+	kind opKind
+	aIdx int // index into a (equal, delete)
+	bIdx int // index into b (equal, insert)
+}
+
+// hunkSpan is a [start, end) range of ops rendered as one hunk
+type hunkSpan struct {
+	start int
+	end   int
+}
+
+// DiffStats counts the lines added and removed between two configurations
+type DiffStats struct {
+	Added   int `json:"lines_added"`
+	Removed int `json:"lines_removed"`
+}
+
+// splitLines splits text into lines without a trailing empty element
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	text = strings.TrimSuffix(text, "\n")
+	return strings.Split(text, "\n")
+}
+
+// editScript computes a minimal line edit script with Myers' O(ND)
+// algorithm. The linear space variant is used, so that exports with
+// thousands of changed lines do not need a table of every line pair.
+func editScript(a, b []string) []lineOp {
+	d := &differ{a: a, b: b, ops: make([]lineOp, 0, len(a)+len(b))}
+	d.diff(0, len(a), 0, len(b))
+	return d.ops
+}
+
+// differ builds the edit script turning a into b
+type differ struct {
+	a, b []string
+	ops  []lineOp
+}
+
+// diff appends the ops turning a[aLo:aHi] into b[bLo:bHi]
+func (d *differ) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, lineOp{kind: opEqual, aIdx: aLo, bIdx: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-1-suffix] == d.b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	if aLo < aHi && bLo < bHi {
+		x, y := d.split(aLo, aHi, bLo, bHi)
+		d.diff(aLo, x, bLo, y)
+		d.diff(x, aHi, y, bHi)
+	} else {
+		for i := aLo; i < aHi; i++ {
+			d.ops = append(d.ops, lineOp{kind: opDelete, aIdx: i})
+		}
+		for j := bLo; j < bHi; j++ {
+			d.ops = append(d.ops, lineOp{kind: opInsert, bIdx: j})
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		d.ops = append(d.ops, lineOp{kind: opEqual, aIdx: aHi + k, bIdx: bHi + k})
+	}
+}
+
+// split finds where the forward and reverse searches for the shortest edit
+// script of a[aLo:aHi] and b[bLo:bHi] meet, and returns the point dividing
+// it into two halves. Both ranges are non-empty and differ in their first
+// and last lines.
+func (d *differ) split(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[offset+k] is the furthest x reached from the start on
+	// diagonal k = x-y, reverse[offset+k] the furthest from the end
+	forward := make([]int, 2*offset+1)
+	reverse := make([]int, 2*offset+1)
+	for i := range forward {
+		forward[i] = -1
+		reverse[i] = -1
+	}
+	forward[offset+1] = 0
+	reverse[offset+1] = 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals running off the grid are skipped
+	fStart, fEnd, rStart, rEnd := 0, 0, 0, 0
+	for D := 0; D <= maxD; D++ {
+		for k := -D + fStart; k <= D-fEnd; k += 2 {
+			var x int
+			if k == -D || (k != D && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				rk := offset + delta - k
+				if rk >= 0 && rk < len(reverse) && reverse[rk] != -1 && x >= n-reverse[rk] {
+					return aLo + x, bLo + y
+				}
+			}
+		}
+
+		for k := -D + rStart; k <= D-rEnd; k += 2 {
+			var x int
+			if k == -D || (k != D && reverse[offset+k-1] < reverse[offset+k+1]) {
+				x = reverse[offset+k+1]
+			} else {
+				x = reverse[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			reverse[offset+k] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				fk := offset + delta - k
+				if fk >= 0 && fk < len(forward) && forward[fk] != -1 {
+					fx := forward[fk]
+					fy := fx - (fk - offset)
+					if fx >= n-x {
+						return aLo + fx, bLo + fy
+					}
+				}
+			}
+		}
+	}
+
+	// Not reached: the searches meet after at most maxD steps each
+	return aLo + n, bLo
+}
+
+// Stats returns the number of added and removed lines between two configurations
+func Stats(from, to string) DiffStats {
+	stats := DiffStats{}
+	for _, op := range editScript(splitLines(from), splitLines(to)) {
+		switch op.kind {
+		case opInsert:
+			stats.Added++
+		case opDelete:
+			stats.Removed++
+		}
+	}
+	return stats
+}
+
+// UnifiedDiff renders a unified diff between two configurations. An empty
+// string is returned when they are identical.
+func UnifiedDiff(fromName, toName, from, to string, contextLines int) string {
+	a := splitLines(from)
+	b := splitLines(to)
+	ops := editScript(a, b)
+
+	// Group changed ops, with their surrounding context, into hunks
+	hunks := []hunkSpan{}
+	for idx := 0; idx < len(ops); idx++ {
+		if ops[idx].kind == opEqual {
+			continue
+		}
+		start := idx - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := idx + 1
+		// Extend while the next change is within 2*context lines
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual {
+				next++
+			}
+			if next < len(ops) && next-end <= 2*contextLines {
+				end = next
+				continue
+			}
+			break
+		}
+		end += contextLines
+		if end > len(ops) {
+			end = len(ops)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunkSpan{start, end})
+		}
+		idx = end - 1
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for _, h := range hunks {
+		aStart, bStart := -1, -1
+		aCount, bCount := 0, 0
+		var body strings.Builder
+
+		for _, op := range ops[h.start:h.end] {
+			switch op.kind {
+			case opEqual:
+				if aStart < 0 {
+					aStart = op.aIdx
+				}
+				if bStart < 0 {
+					bStart = op.bIdx
+				}
+				aCount++
+				bCount++
+				body.WriteString(" " + a[op.aIdx] + "\n")
+			case opDelete:
+				if aStart < 0 {
+					aStart = op.aIdx
+				}
+				aCount++
+				body.WriteString("-" + a[op.aIdx] + "\n")
+			case opInsert:
+				if bStart < 0 {
+					bStart = op.bIdx
+				}
+				bCount++
+				body.WriteString("+" + b[op.bIdx] + "\n")
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(aStart, aCount, h, ops, true),
+			hunkRange(bStart, bCount, h, ops, false))
+		sb.WriteString(body.String())
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the "start,count" part of a hunk header. When one side
+// has no lines in the hunk, the start is the line before the insertion
+// point, as in GNU diff.
+func hunkRange(start, count int, h hunkSpan, ops []lineOp, sideA bool) string {
+	if count == 0 {
+		// Locate the position on this side just before the hunk
+		pos := 0
+		for _, op := range ops[:h.start] {
+			if sideA && op.kind != opInsert {
+				pos++
+			}
+			if !sideA && op.kind != opDelete {
+				pos++
+			}
+		}
+		return fmt.Sprintf("%d,0", pos)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,74 @@
+package configbackup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// MaskedValue replaces every secret found in a configuration
+const MaskedValue = "********"
+
+// secretPatterns match secrets in RouterOS exports and IOS-style running
+// configs. Group 1 is kept, group 2 (the secret itself) is replaced.
+var secretPatterns = []*regexp.Regexp{
+	// RouterOS key=value pairs, quoted or bare
+	regexp.MustCompile(`(?i)(\b(?:password|secret|passphrase|pre-shared-key|wpa-pre-shared-key|wpa2-pre-shared-key|authentication-key|auth-key|private-key|community|authentication-password|encryption-password)=)("(?:[^"\\]|\\.)*"|\S+)`),
+	// IOS / generic: "enable secret 5 xyz", "username x password 7 xyz".
+	// RouterOS menu paths ("/ppp secret add ...") start with a slash and are skipped.
+	regexp.MustCompile(`(?im)^([^/\n][^\n]*?\b(?:secret|password)[ \t]+(?:[0-9][ \t]+)?)(\S+)`),
+	// IOS key chains and TACACS/RADIUS keys: "key-string 7 xyz", "key 7 xyz"
+	regexp.MustCompile(`(?i)(\bkey-string\s+(?:[0-9]\s+)?)(\S+)`),
+	regexp.MustCompile(`(?i)(\bkey\s+[0-9]\s+)(\S+)`),
+	// SNMP communities: "snmp-server community public RO"
+	regexp.MustCompile(`(?i)(\bsnmp-server\s+community\s+)(\S+)`),
+	// Junos-style "encrypted-password "$1$..."" and pre-shared keys
+	regexp.MustCompile(`(?i)(\b(?:encrypted-password|pre-shared-key\s+ascii-text)\s+)("(?:[^"\\]|\\.)*"|\S+)`),
+}
+
+// routerOSHeader matches the timestamp comment RouterOS prepends to every
+// export (e.g. "# jan/02/2024 10:00:00 by RouterOS 7.12"); it changes on
+// every run and must not count as a configuration change.
+var routerOSHeader = regexp.MustCompile(`^#\s+\S+\s+\d{2}:\d{2}:\d{2}\s+by RouterOS`)
+
+// MaskSecrets replaces passwords, keys and communities with MaskedValue
+func MaskSecrets(config string) string {
+	masked := config
+	for _, pattern := range secretPatterns {
+		masked = pattern.ReplaceAllString(masked, "${1}"+MaskedValue)
+	}
+	return masked
+}
+
+// Normalize strips volatile lines and trailing whitespace so that two
+// exports of an unchanged configuration hash identically
+func Normalize(config string) string {
+	config = strings.ReplaceAll(config, "\r\n", "\n")
+	lines := strings.Split(config, "\n")
+
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if routerOSHeader.MatchString(line) {
+			continue
+		}
+		// IOS prints the current time at the top of "show running-config"
+		if strings.HasPrefix(line, "! Last configuration change at") ||
+			strings.HasPrefix(line, "! NVRAM config last updated at") ||
+			strings.HasPrefix(line, "Building configuration") ||
+			strings.HasPrefix(line, "Current configuration :") {
+			continue
+		}
+		out = append(out, strings.TrimRight(line, " \t"))
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n")) + "\n"
+}
+
+// Prepare normalizes and masks a raw configuration and returns the stored
+// content together with its SHA-256 hash
+func Prepare(raw string) (string, string) {
+	content := MaskSecrets(Normalize(raw))
+	sum := sha256.Sum256([]byte(content))
+	return content, hex.EncodeToString(sum[:])
+}
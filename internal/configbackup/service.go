@@ -0,0 +1,317 @@
+// Package configbackup collects, versions and diffs router configurations.
+// Each backup run exports the running configuration of every router that has
+// SSH or RouterOS API access, masks secrets, and stores a new version only
+// when the content hash changes. New versions raise a config_change event
+// and are re-checked against the tenant's compliance policies.
+package configbackup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/compliance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// Service runs scheduled configuration backups
+type Service struct {
+	db         *database.DB
+	config     config.ConfigBackupConfig
+	collectors []Collector
+	versions   repository.ConfigBackupRepository
+	events     repository.DeviceEventRepository
+	compliance *compliance.Engine
+	vault      *vault.Vault
+}
+
+// NewService creates a new configuration backup service; router
+// credentials are decrypted with secrets
+func NewService(db *database.DB, cfg config.ConfigBackupConfig, secrets *vault.Vault) *Service {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	versions := postgres.NewConfigBackupRepo(db.DB)
+
+	return &Service{
+		db:     db,
+		config: cfg,
+		// Prefer SSH: /export over the API is not available on older RouterOS
+		collectors: []Collector{
+			NewSSHCollector(timeout),
+			NewMikroTikAPICollector(timeout),
+		},
+		versions: versions,
+		events:   postgres.NewDeviceEventRepo(db.DB),
+		compliance: compliance.NewEngine(
+			postgres.NewComplianceRepo(db.DB), versions, postgres.NewRouterRepo(db.DB),
+		),
+		vault: secrets,
+	}
+}
+
+// Start runs a backup of every eligible router immediately and then on each interval
+func (s *Service) Start(ctx context.Context) error {
+	interval := time.Duration(s.config.IntervalMinutes) * time.Minute
+	log.Printf("Starting config backup service (interval %s, %d workers)", interval, s.config.Workers)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.runBackups(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Config backup service stopped")
+			return nil
+		case <-ticker.C:
+			s.runBackups(ctx)
+		}
+	}
+}
+
+// runBackups fans out one backup job per eligible router to a bounded worker
+// pool and waits for the run to finish, so runs never overlap
+func (s *Service) runBackups(ctx context.Context) {
+	routers, err := s.fetchRouters(ctx)
+	if err != nil {
+		log.Printf("Error querying routers for config backup: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	jobs := make(chan *models.EnhancedRouter)
+	for i := 0; i < s.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for router := range jobs {
+				if _, err := s.BackupRouter(ctx, router); err != nil {
+					log.Printf("Config backup failed for router %s: %v", router.Name, err)
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, router := range routers {
+		select {
+		case jobs <- router:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// fetchRouters loads all active routers with the credentials needed to
+// export their configuration, their own or from their credential profiles
+func (s *Service) fetchRouters(ctx context.Context) ([]*models.EnhancedRouter, error) {
+	query := `
+		SELECT r.id, r.tenant_id, r.name, r.management_ip, r.vendor,
+			rc.api_enabled, rc.api_type, rc.api_port,
+			COALESCE(rc.api_username, ap.username), COALESCE(rc.api_password, ap.password),
+			rc.api_timeout_seconds,
+			rc.ssh_enabled, rc.ssh_host, rc.ssh_port,
+			COALESCE(rc.ssh_username, hp.username), COALESCE(rc.ssh_password, hp.password),
+			COALESCE(rc.ssh_private_key, hp.private_key), rc.ssh_timeout_seconds
+		FROM routers r
+		JOIN router_capabilities rc ON r.id = rc.router_id
+		LEFT JOIN credential_profiles ap ON ap.id = rc.api_profile_id
+		LEFT JOIN credential_profiles hp ON hp.id = rc.ssh_profile_id
+		WHERE r.status = 'active'
+		  AND (rc.ssh_enabled = true OR (rc.api_enabled = true AND rc.api_type = 'mikrotik'))
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routers := []*models.EnhancedRouter{}
+	for rows.Next() {
+		router := &models.EnhancedRouter{}
+		router.Capabilities = &models.RouterCapabilities{
+			API: &models.APICapability{},
+			SSH: &models.SSHCapability{},
+		}
+
+		var apiEnabled, sshEnabled *bool
+		var apiType, apiUsername, apiPassword, sshHost, sshUsername *string
+		var apiTimeout, sshPort, sshTimeout *int
+
+		err := rows.Scan(
+			&router.ID, &router.TenantID, &router.Name, &router.ManagementIP, &router.Vendor,
+			&apiEnabled, &apiType, &router.Capabilities.API.Port, &apiUsername, &apiPassword,
+			&apiTimeout,
+			&sshEnabled, &sshHost, &sshPort, &sshUsername, &router.Capabilities.SSH.Password,
+			&router.Capabilities.SSH.PrivateKey, &sshTimeout,
+		)
+		if err != nil {
+			log.Printf("Error scanning router for config backup: %v", err)
+			continue
+		}
+
+		api := router.Capabilities.API
+		api.Enabled = apiEnabled != nil && *apiEnabled
+		api.Type = derefString(apiType)
+		api.Username = derefString(apiUsername)
+		api.Password = derefString(apiPassword)
+		api.TimeoutSeconds = derefInt(apiTimeout)
+
+		sshCfg := router.Capabilities.SSH
+		sshCfg.Enabled = sshEnabled != nil && *sshEnabled
+		sshCfg.Host = derefString(sshHost)
+		sshCfg.Port = derefInt(sshPort)
+		sshCfg.Username = derefString(sshUsername)
+		sshCfg.TimeoutSeconds = derefInt(sshTimeout)
+
+		err = s.vault.DecryptFields(&api.Password, sshCfg.Password, sshCfg.PrivateKey)
+		if err != nil {
+			log.Printf("Error decrypting credentials of router %s for config backup: %v", router.Name, err)
+			continue
+		}
+
+		routers = append(routers, router)
+	}
+
+	return routers, rows.Err()
+}
+
+// BackupRouter fetches the router's configuration and stores it if it changed.
+// It returns the new version, or nil when the configuration is unchanged.
+func (s *Service) BackupRouter(ctx context.Context, router *models.EnhancedRouter) (*models.ConfigVersion, error) {
+	raw, method, err := s.fetch(ctx, router)
+	if err != nil {
+		return nil, err
+	}
+
+	content, hash := Prepare(raw)
+	now := time.Now()
+
+	previous, err := s.versions.GetLatest(ctx, router.TenantID, router.ID)
+	if errors.Is(err, repository.ErrConfigVersionNotFound) {
+		// No earlier version: this backup becomes the baseline
+		previous = nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get latest config version: %w", err)
+	}
+	if previous != nil && previous.ContentHash == hash {
+		if err := s.versions.MarkVerified(ctx, previous.ID, now); err != nil {
+			log.Printf("Error updating config verification time for router %s: %v", router.Name, err)
+		}
+		return nil, nil
+	}
+
+	version := &models.ConfigVersion{
+		TenantID:         router.TenantID,
+		RouterID:         router.ID,
+		Content:          content,
+		ContentHash:      hash,
+		CollectionMethod: method,
+		CollectedAt:      now,
+		LastVerifiedAt:   now,
+	}
+	if previous != nil {
+		stats := Stats(previous.Content, content)
+		version.LinesAdded = stats.Added
+		version.LinesRemoved = stats.Removed
+	}
+
+	if err := s.versions.Create(ctx, version); err != nil {
+		return nil, fmt.Errorf("failed to store config version: %w", err)
+	}
+
+	log.Printf("Stored config version %d for router %s (+%d/-%d lines)",
+		version.Version, router.Name, version.LinesAdded, version.LinesRemoved)
+
+	// The first backup is a baseline, not a change
+	if previous != nil {
+		s.raiseChangeEvent(ctx, router, previous, version)
+	}
+
+	if _, err := s.compliance.EvaluateRouter(ctx, router.TenantID, router.ID); err != nil {
+		log.Printf("Compliance evaluation failed for router %s: %v", router.Name, err)
+	}
+
+	return version, nil
+}
+
+// fetch tries each collector that can handle the router until one succeeds
+func (s *Service) fetch(ctx context.Context, router *models.EnhancedRouter) (string, string, error) {
+	var lastErr error
+
+	for _, collector := range s.collectors {
+		if !collector.CanHandle(router) {
+			continue
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, time.Duration(s.config.TimeoutSeconds)*time.Second)
+		raw, err := collector.Fetch(fetchCtx, router)
+		cancel()
+
+		if err == nil {
+			return raw, collector.Method(), nil
+		}
+
+		log.Printf("Config collection via %s failed for router %s: %v", collector.Method(), router.Name, err)
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return "", "", fmt.Errorf("no config collector available for router %s", router.Name)
+	}
+	return "", "", fmt.Errorf("all config collectors failed, last error: %w", lastErr)
+}
+
+// raiseChangeEvent records a config_change device event for a new version
+func (s *Service) raiseChangeEvent(ctx context.Context, router *models.EnhancedRouter, previous, current *models.ConfigVersion) {
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"previous_version": previous.Version,
+		"version":          current.Version,
+		"lines_added":      current.LinesAdded,
+		"lines_removed":    current.LinesRemoved,
+		"content_hash":     current.ContentHash,
+	})
+	metadataStr := string(metadata)
+
+	routerID := router.ID
+	event := &models.DeviceEvent{
+		TenantID:   router.TenantID,
+		RouterID:   &routerID,
+		EventType:  models.EventTypeConfigChange,
+		Severity:   models.SeverityInfo,
+		Source:     models.EventSourceConfigBackup,
+		Message:    fmt.Sprintf("Configuration of %s changed (version %d -> %d)", router.Name, previous.Version, current.Version),
+		Metadata:   &metadataStr,
+		OccurredAt: current.CollectedAt,
+	}
+
+	if err := s.events.Create(ctx, event); err != nil {
+		log.Printf("Error recording config change event for router %s: %v", router.Name, err)
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -0,0 +1,198 @@
+package firmware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dataset is the locally imported advisory and lifecycle data. It is read
+// from a JSON file; see configs/firmware_dataset.example.json.
+type Dataset struct {
+	Updated    string           `json:"updated,omitempty"`
+	Advisories []Advisory       `json:"advisories"`
+	Lifecycle  []LifecycleEntry `json:"lifecycle"`
+}
+
+// VersionRange matches versions v with Introduced <= v < Fixed. An empty
+// bound is open.
+type VersionRange struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+// Advisory is a published vulnerability affecting a range of releases
+type Advisory struct {
+	ID       string         `json:"id"` // CVE or vendor advisory ID
+	Title    string         `json:"title"`
+	Severity string         `json:"severity"` // critical, high, medium, low
+	Vendor   string         `json:"vendor"`
+	Models   []string       `json:"models,omitempty"` // Empty = all models; "CCR*" matches by prefix
+	Affected []VersionRange `json:"affected"`
+	URL      string         `json:"url,omitempty"`
+}
+
+// LifecycleEntry describes the support status of a release train
+type LifecycleEntry struct {
+	Vendor       string         `json:"vendor"`
+	Models       []string       `json:"models,omitempty"`
+	Versions     []VersionRange `json:"versions"`
+	Release      string         `json:"release"` // Human-readable train, e.g. "RouterOS v6"
+	EndOfSale    string         `json:"end_of_sale,omitempty"`
+	EndOfSupport string         `json:"end_of_support,omitempty"` // YYYY-MM-DD
+	Note         string         `json:"note,omitempty"`
+}
+
+// Assessment is the outcome of matching one vendor/model/version
+type Assessment struct {
+	Advisories  []Advisory
+	Lifecycle   *LifecycleEntry
+	Unsupported bool
+}
+
+// dateLayout is the format used for lifecycle dates in the dataset
+const dateLayout = "2006-01-02"
+
+// LoadDataset reads and validates a dataset file
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &Dataset{}
+	if err := json.Unmarshal(data, dataset); err != nil {
+		return nil, fmt.Errorf("invalid firmware dataset %s: %w", path, err)
+	}
+
+	for _, entry := range dataset.Lifecycle {
+		for _, date := range []string{entry.EndOfSale, entry.EndOfSupport} {
+			if date == "" {
+				continue
+			}
+			if _, err := time.Parse(dateLayout, date); err != nil {
+				return nil, fmt.Errorf("invalid date %q for %s %s: %w", date, entry.Vendor, entry.Release, err)
+			}
+		}
+	}
+
+	return dataset, nil
+}
+
+// Assess matches a router's vendor, model and version against the dataset
+func (d *Dataset) Assess(vendor, model, version string, now time.Time) Assessment {
+	assessment := Assessment{}
+	if d == nil || version == "" {
+		return assessment
+	}
+
+	for _, advisory := range d.Advisories {
+		if matchesVendor(advisory.Vendor, vendor) && matchesModel(advisory.Models, model) &&
+			inAnyRange(version, advisory.Affected) {
+			assessment.Advisories = append(assessment.Advisories, advisory)
+		}
+	}
+
+	for i := range d.Lifecycle {
+		entry := &d.Lifecycle[i]
+		if !matchesVendor(entry.Vendor, vendor) || !matchesModel(entry.Models, model) ||
+			!inAnyRange(version, entry.Versions) {
+			continue
+		}
+		assessment.Lifecycle = entry
+		if entry.EndOfSupport != "" {
+			eos, _ := time.Parse(dateLayout, entry.EndOfSupport)
+			assessment.Unsupported = !now.Before(eos)
+		}
+		break
+	}
+
+	return assessment
+}
+
+// matchesVendor compares case-insensitively and tolerates decorated vendor
+// names such as "Cisco Systems" or "MikroTik RouterBOARD"
+func matchesVendor(datasetVendor, routerVendor string) bool {
+	dv := strings.ToLower(strings.TrimSpace(datasetVendor))
+	rv := strings.ToLower(strings.TrimSpace(routerVendor))
+	return dv != "" && rv != "" && strings.Contains(rv, dv)
+}
+
+func matchesModel(models []string, model string) bool {
+	if len(models) == 0 {
+		return true
+	}
+	m := strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range models {
+		p := strings.ToLower(strings.TrimSpace(pattern))
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(m, prefix) {
+				return true
+			}
+		} else if m == p {
+			return true
+		}
+	}
+	return false
+}
+
+func inAnyRange(version string, ranges []VersionRange) bool {
+	for _, r := range ranges {
+		if r.Introduced != "" && CompareVersions(version, r.Introduced) < 0 {
+			continue
+		}
+		if r.Fixed != "" && CompareVersions(version, r.Fixed) >= 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Store serves the dataset from a file and reloads it when the file changes,
+// so a new import only requires replacing the file
+type Store struct {
+	path string
+
+	mu      sync.Mutex
+	dataset *Dataset
+	modTime time.Time
+}
+
+// NewStore creates a dataset store for the given file. An empty path
+// disables advisory and lifecycle matching.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Dataset returns the current dataset, reloading it if the file was modified.
+// It returns nil when no dataset is configured.
+func (s *Store) Dataset() (*Dataset, error) {
+	if s.path == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("firmware dataset unavailable: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dataset != nil && info.ModTime().Equal(s.modTime) {
+		return s.dataset, nil
+	}
+
+	dataset, err := LoadDataset(s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.dataset = dataset
+	s.modTime = info.ModTime()
+
+	return dataset, nil
+}
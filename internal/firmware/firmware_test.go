@@ -0,0 +1,96 @@
+package firmware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExtractVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"RouterOS RB4011iGS+", ""},
+		{"RouterOS 6.48.6", "6.48.6"},
+		{"RouterOS CCR1036-8G-2S+ 7.12.1 (stable)", "7.12.1"},
+		{"7.13rc2 (testing)", "7.13rc2"},
+		{"Cisco IOS Software, ISR4300 Software (X86_64_LINUX_IOSD-UNIVERSALK9-M), Version 16.9.4, RELEASE SOFTWARE (fc2)", "16.9.4"},
+		{"Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.2(4)M7, RELEASE SOFTWARE (fc2)", "15.2(4)M7"},
+		{"Juniper Networks, Inc. mx480 internet router, kernel JUNOS 20.4R3.8, Build date: 2021-09-09", "20.4R3.8"},
+		{"Huawei Versatile Routing Platform Software VRP (R) software, Version 8.180 (CE6800 V200R005C10SPC607B607)", "V200R005C10SPC607"},
+		{"Linux gateway 5.10.0", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ExtractVersion(tt.input); got != tt.expected {
+				t.Errorf("ExtractVersion() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"6.49.10", "6.49.7", 1},
+		{"6.49", "6.49.0", -1},
+		{"7.12", "7.12", 0},
+		{"7.12rc1", "7.12", -1},
+		{"7.12rc1", "7.12.1", -1},
+		{"15.2(4)M10", "15.2(4)M7", 1},
+		{"20.4R3.8", "21.1R1", -1},
+		{"V200R005C10", "V200R019C00", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			if got := CompareVersions(tt.a, tt.b); got != tt.expected {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+			}
+			if got := CompareVersions(tt.b, tt.a); got != -tt.expected {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.expected)
+			}
+		})
+	}
+}
+
+func TestAssess(t *testing.T) {
+	dataset := &Dataset{
+		Advisories: []Advisory{
+			{ID: "CVE-A", Vendor: "mikrotik", Affected: []VersionRange{{Introduced: "6.29", Fixed: "6.42.1"}}},
+			{ID: "CVE-B", Vendor: "mikrotik", Models: []string{"CCR*"}, Affected: []VersionRange{{Fixed: "6.49.7"}}},
+		},
+		Lifecycle: []LifecycleEntry{
+			{Vendor: "mikrotik", Release: "v6", Versions: []VersionRange{{Introduced: "6.0", Fixed: "7.0"}}, EndOfSupport: "2025-01-01"},
+		},
+	}
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	a := dataset.Assess("MikroTik", "CCR1036-8G-2S+", "6.40.5", now)
+	if len(a.Advisories) != 2 || !a.Unsupported || a.Lifecycle == nil {
+		t.Errorf("expected both advisories and unsupported v6, got %+v", a)
+	}
+
+	a = dataset.Assess("MikroTik", "RB4011", "6.42.1", now)
+	if len(a.Advisories) != 0 {
+		t.Errorf("expected fixed release and non-matching model to have no advisories, got %+v", a.Advisories)
+	}
+
+	a = dataset.Assess("mikrotik", "RB4011", "7.12.1", now)
+	if len(a.Advisories) != 0 || a.Unsupported || a.Lifecycle != nil {
+		t.Errorf("expected v7 to be clean, got %+v", a)
+	}
+
+	a = dataset.Assess("Cisco", "ISR4331", "6.40", now)
+	if len(a.Advisories) != 0 {
+		t.Errorf("expected other vendors not to match, got %+v", a.Advisories)
+	}
+
+	var none *Dataset
+	if a := none.Assess("mikrotik", "", "6.40", now); len(a.Advisories) != 0 || a.Unsupported {
+		t.Errorf("expected nil dataset to flag nothing, got %+v", a)
+	}
+}
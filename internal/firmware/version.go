@@ -0,0 +1,108 @@
+// Package firmware groups the router fleet by vendor, model and OS version
+// and matches releases against a locally imported advisory and
+// end-of-life dataset.
+package firmware
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionPatterns extract an OS version from sysDescr strings or
+// vendor-reported version fields, most specific first
+var versionPatterns = []*regexp.Regexp{
+	// "RouterOS 6.48.6", "RouterOS RB4011iGS+ 7.12.1 (stable)"
+	regexp.MustCompile(`(?i)RouterOS(?:\s+\S+)*?\s+v?(\d+\.\d+(?:\.\d+)*(?:(?:beta|rc)\d+)?)`),
+	// "JUNOS 20.4R3.8", "Junos: 21.2R3-S2.9"
+	regexp.MustCompile(`(?i)JUNOS:?\s+(\d+\.\d+[A-Z]\d+(?:[.-][A-Z]?\d+)*(?:\.\d+)?)`),
+	// Huawei VRP: "VRP (R) software, Version 8.180 (CE6800 V200R005C10SPC607B607)"
+	regexp.MustCompile(`\b(V\d{3}R\d{3}(?:C\d+)?(?:SPC\d+)?)`),
+	// Cisco and generic: "Version 15.2(4)M7," / "Version 17.3.4a"
+	regexp.MustCompile(`(?i)\bVersion\s+(\d+[\w.()-]*\w\)?)`),
+	// Bare version as reported by vendor APIs: "7.12.1 (stable)"
+	regexp.MustCompile(`^\s*v?(\d+\.\d+(?:\.\d+)*(?:(?:beta|rc)\d+)?)`),
+}
+
+// ExtractVersion returns the OS version contained in a sysDescr string or a
+// vendor-reported version, or "" if none is recognised
+func ExtractVersion(description string) string {
+	for _, pattern := range versionPatterns {
+		if m := pattern.FindStringSubmatch(description); m != nil {
+			return strings.TrimRight(m[1], ",.")
+		}
+	}
+	return ""
+}
+
+// versionToken splits versions into alternating numeric and alphabetic runs
+var versionToken = regexp.MustCompile(`\d+|[A-Za-z]+`)
+
+// CompareVersions compares two version strings segment by segment, treating
+// numeric runs numerically and letter runs case-insensitively, so that
+// "6.49.10" > "6.49.7", "15.2(4)M10" > "15.2(4)M7" and "20.4R3" < "21.1R1".
+// Pre-release suffixes such as "7.12rc1" sort before the release they
+// precede. It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	ta := versionToken.FindAllString(a, -1)
+	tb := versionToken.FindAllString(b, -1)
+
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		if i >= len(ta) {
+			return -compareMissing(tb[i])
+		}
+		if i >= len(tb) {
+			return compareMissing(ta[i])
+		}
+
+		na, errA := strconv.Atoi(ta[i])
+		nb, errB := strconv.Atoi(tb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return sign(na - nb)
+			}
+		case errA == nil:
+			// "7.12.1" vs "7.12rc1": the numeric segment is a later release
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(ta[i]), strings.ToLower(tb[i])); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return 0
+}
+
+// compareMissing orders a version that has an extra trailing segment against
+// one that ends: "6.49.1" > "6.49", but "7.12rc1" < "7.12"
+func compareMissing(extra string) int {
+	if _, err := strconv.Atoi(extra); err == nil {
+		return 1
+	}
+	if isPreRelease(extra) {
+		return -1
+	}
+	return 1
+}
+
+func isPreRelease(token string) bool {
+	switch strings.ToLower(token) {
+	case "alpha", "beta", "rc":
+		return true
+	}
+	return false
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
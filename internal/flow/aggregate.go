@@ -0,0 +1,204 @@
+package flow
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// maxClockSkew is how far a flow's end time may be from the time it was
+// received before the receive time is used instead; it protects the rollups
+// from exporters with a wrong clock
+const maxClockSkew = time.Hour
+
+var (
+	otherIPv4 = netip.PrefixFrom(netip.IPv4Unspecified(), 0)
+	otherIPv6 = netip.PrefixFrom(netip.IPv6Unspecified(), 0)
+)
+
+type rollupKey struct {
+	RouterID  uuid.UUID
+	Minute    int64 // Unix minute
+	InputIf   uint32
+	OutputIf  uint32
+	Protocol  uint8
+	SrcPrefix netip.Prefix
+	DstPrefix netip.Prefix
+	SrcAS     uint32
+	DstAS     uint32
+}
+
+type rollupValue struct {
+	TenantID uuid.UUID
+	Bytes    uint64
+	Packets  uint64
+	Flows    uint64
+}
+
+// Aggregator sums flows into per-minute rollups by interface, protocol,
+// source and destination prefix and AS. Memory is bounded by maxKeys: once
+// it is reached, flows for new keys are folded into a catch-all key per
+// interface and protocol with the prefixes and AS numbers zeroed, and past
+// twice maxKeys they are dropped.
+type Aggregator struct {
+	ipv4Bits int
+	ipv6Bits int
+	maxKeys  int
+
+	mu       sync.Mutex
+	rollups  map[rollupKey]*rollupValue
+	folded   uint64
+	overflow uint64
+}
+
+// NewAggregator creates an aggregator truncating addresses to the given
+// prefix lengths
+func NewAggregator(ipv4Bits, ipv6Bits, maxKeys int) *Aggregator {
+	return &Aggregator{
+		ipv4Bits: ipv4Bits,
+		ipv6Bits: ipv6Bits,
+		maxKeys:  maxKeys,
+		rollups:  make(map[rollupKey]*rollupValue),
+	}
+}
+
+// Add aggregates records exported by e and received at received
+func (a *Aggregator) Add(e *Exporter, records []Record, received time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := range records {
+		rec := &records[i]
+
+		end := flowTime(rec, received)
+
+		key := rollupKey{
+			RouterID:  e.RouterID,
+			Minute:    end.Unix() / 60,
+			InputIf:   rec.InputIf,
+			OutputIf:  rec.OutputIf,
+			Protocol:  rec.Protocol,
+			SrcPrefix: a.prefix(rec.SrcAddr),
+			DstPrefix: a.prefix(rec.DstAddr),
+			SrcAS:     rec.SrcAS,
+			DstAS:     rec.DstAS,
+		}
+
+		value, ok := a.rollups[key]
+		if !ok && len(a.rollups) >= a.maxKeys {
+			key.SrcPrefix, key.DstPrefix = otherPrefix(key.SrcPrefix), otherPrefix(key.DstPrefix)
+			key.SrcAS, key.DstAS = 0, 0
+			value, ok = a.rollups[key]
+			a.folded++
+		}
+		if !ok {
+			if len(a.rollups) >= 2*a.maxKeys {
+				a.overflow++
+				continue
+			}
+			value = &rollupValue{TenantID: e.TenantID}
+			a.rollups[key] = value
+		}
+
+		bytes, packets := sampledCounts(rec)
+		value.Bytes += bytes
+		value.Packets += packets
+		value.Flows++
+	}
+}
+
+// Drain removes and returns the rollups for minutes that ended before cutoff
+func (a *Aggregator) Drain(cutoff time.Time) []*models.FlowRollup {
+	limit := cutoff.Unix() / 60
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rollups := make([]*models.FlowRollup, 0)
+	for key, value := range a.rollups {
+		if key.Minute >= limit {
+			continue
+		}
+		rollups = append(rollups, &models.FlowRollup{
+			TenantID:      value.TenantID,
+			RouterID:      key.RouterID,
+			Bucket:        time.Unix(key.Minute*60, 0).UTC(),
+			InputIfIndex:  int(key.InputIf),
+			OutputIfIndex: int(key.OutputIf),
+			Protocol:      int(key.Protocol),
+			SrcPrefix:     key.SrcPrefix.String(),
+			DstPrefix:     key.DstPrefix.String(),
+			SrcAS:         int64(key.SrcAS),
+			DstAS:         int64(key.DstAS),
+			Bytes:         int64(value.Bytes),
+			Packets:       int64(value.Packets),
+			Flows:         int64(value.Flows),
+		})
+		delete(a.rollups, key)
+	}
+	return rollups
+}
+
+// Stats returns and resets the number of flows folded into catch-all keys and
+// dropped because the aggregator was full
+func (a *Aggregator) Stats() (folded, overflow uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	folded, overflow = a.folded, a.overflow
+	a.folded, a.overflow = 0, 0
+	return folded, overflow
+}
+
+// Len returns the number of rollups held in memory
+func (a *Aggregator) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.rollups)
+}
+
+// flowTime returns the time a flow is accounted at: its end time, or the
+// time it was received when the exporter did not send a plausible one
+func flowTime(rec *Record, received time.Time) time.Time {
+	if rec.End.IsZero() || rec.End.Sub(received).Abs() > maxClockSkew {
+		return received
+	}
+	return rec.End
+}
+
+// sampledCounts returns a flow's byte and packet counts scaled by its
+// sampling rate
+func sampledCounts(rec *Record) (bytes, packets uint64) {
+	scale := uint64(1)
+	if rec.SamplingRate > 1 {
+		scale = uint64(rec.SamplingRate)
+	}
+	return rec.Bytes * scale, rec.Packets * scale
+}
+
+// prefix truncates addr to the configured prefix length
+func (a *Aggregator) prefix(addr netip.Addr) netip.Prefix {
+	if !addr.IsValid() {
+		return otherIPv4
+	}
+	addr = addr.Unmap()
+	bits := a.ipv4Bits
+	if addr.Is6() {
+		bits = a.ipv6Bits
+	}
+	p, err := addr.Prefix(bits)
+	if err != nil {
+		return otherPrefix(netip.PrefixFrom(addr, 0))
+	}
+	return p
+}
+
+// otherPrefix returns the catch-all prefix of p's address family
+func otherPrefix(p netip.Prefix) netip.Prefix {
+	if p.Addr().Is6() {
+		return otherIPv6
+	}
+	return otherIPv4
+}
@@ -0,0 +1,721 @@
+package flow
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// maxPacketSize is the largest UDP datagram and IPFIX message
+const maxPacketSize = 65535
+
+// flushInterval is how often completed minutes are written
+const flushInterval = 10 * time.Second
+
+// flushGrace is how long after a minute ends its rollups are kept in memory
+// for late exports before being written
+const flushGrace = 30 * time.Second
+
+// retentionBatch is the number of rows removed per retention delete
+const retentionBatch = 10000
+
+// Interface counter samples from sFlow are few compared to flows, so their
+// queue is not configurable
+const (
+	metricQueueSize = 10000
+	metricBatchSize = 500
+)
+
+// sFlow counter sample modes
+const (
+	SFlowCountersFallback = "fallback"
+	SFlowCountersAlways   = "always"
+	SFlowCountersOff      = "off"
+)
+
+// Collector receives flow exports, stores per-minute rollups and keeps the
+// NAT translation events reported over IPFIX. sFlow counter samples are
+// stored as interface metrics for routers that are not polled over SNMP.
+type Collector struct {
+	config      config.FlowConfig
+	repo        repository.FlowRepository
+	natRepo     repository.NATEventRepository
+	metricRepo  repository.InterfaceMetricRepository
+	usageRepo   repository.SubscriberUsageRepository
+	exporters   *ExporterMap
+	subscribers *SubscriberMap
+	templates   *TemplateCache
+	aggregator  *Aggregator
+	usage       *SubscriberAggregator
+	counters    *CounterTracker
+	enterprise  EnterpriseMap
+	natQueue    chan *models.NATEvent
+	metricQueue chan *models.InterfaceMetric
+
+	mu           sync.Mutex
+	udpListeners map[int]net.PacketConn
+	tcpListeners map[int]net.Listener
+	wg           sync.WaitGroup
+
+	// Counters reported with each exporter refresh
+	packets       atomic.Uint64
+	flows         atomic.Uint64
+	translations  atomic.Uint64
+	unknown       atomic.Uint64
+	ambiguous     atomic.Uint64
+	malformed     atomic.Uint64
+	mismatch      atomic.Uint64
+	missing       atomic.Uint64
+	natDropped    atomic.Uint64
+	samples       atomic.Uint64
+	metrics       atomic.Uint64
+	metricDropped atomic.Uint64
+}
+
+// NewCollector creates a new flow collector
+func NewCollector(db *database.DB, cfg config.FlowConfig) *Collector {
+	subscribers := NewSubscriberMap(db.DB)
+	return &Collector{
+		config:       cfg,
+		repo:         postgres.NewFlowRepo(db.DB),
+		natRepo:      postgres.NewNATEventRepo(db.DB),
+		metricRepo:   postgres.NewInterfaceMetricRepo(db.DB),
+		usageRepo:    postgres.NewSubscriberUsageRepo(db.DB),
+		exporters:    NewExporterMap(db.DB),
+		subscribers:  subscribers,
+		templates:    NewTemplateCache(),
+		aggregator:   NewAggregator(cfg.IPv4PrefixLen, cfg.IPv6PrefixLen, cfg.MaxRollups),
+		usage:        NewSubscriberAggregator(subscribers, cfg.MaxRollups),
+		counters:     NewCounterTracker(time.Duration(cfg.SFlowCounterInterval) * time.Second),
+		natQueue:     make(chan *models.NATEvent, cfg.NATQueueSize),
+		metricQueue:  make(chan *models.InterfaceMetric, metricQueueSize),
+		udpListeners: make(map[int]net.PacketConn),
+		tcpListeners: make(map[int]net.Listener),
+	}
+}
+
+// Start opens the NetFlow, IPFIX and sFlow listeners and runs until ctx is
+// cancelled
+func (c *Collector) Start(ctx context.Context) error {
+	enterprise, err := ParseEnterpriseMap(c.config.EnterpriseElements)
+	if err != nil {
+		return err
+	}
+	c.enterprise = enterprise
+
+	switch c.config.SFlowCounters {
+	case SFlowCountersFallback, SFlowCountersAlways, SFlowCountersOff:
+	default:
+		return fmt.Errorf("invalid sFlow counter mode %q, expected fallback, always or off", c.config.SFlowCounters)
+	}
+
+	if err := c.exporters.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to load flow exporters: %w", err)
+	}
+	c.refreshSubscribers(ctx)
+
+	netflowHost, netflowPort, err := splitAddr(c.config.NetFlowAddr)
+	if err != nil {
+		return err
+	}
+	if err := c.listenUDP(netflowHost, netflowPort); err != nil {
+		return err
+	}
+
+	ipfixHost := ""
+	if c.config.IPFIXAddr != "" {
+		var ipfixPort int
+		ipfixHost, ipfixPort, err = splitAddr(c.config.IPFIXAddr)
+		if err != nil {
+			return err
+		}
+		if err := c.listenUDP(ipfixHost, ipfixPort); err != nil {
+			return err
+		}
+		if err := c.listenTCP(ctx, ipfixHost, ipfixPort); err != nil {
+			return err
+		}
+	}
+	if c.config.SFlowAddr != "" {
+		sflowHost, sflowPort, err := splitAddr(c.config.SFlowAddr)
+		if err != nil {
+			return err
+		}
+		if err := c.listenUDP(sflowHost, sflowPort); err != nil {
+			return err
+		}
+	}
+	c.listenRouterPorts(ctx, netflowHost, ipfixHost)
+
+	var writers sync.WaitGroup
+	writers.Add(2)
+	go func() {
+		defer writers.Done()
+		runBatchWriter(ctx, c.natQueue, c.config.NATBatchSize, "NAT events", c.natRepo.CreateBatch)
+	}()
+	go func() {
+		defer writers.Done()
+		runBatchWriter(ctx, c.metricQueue, metricBatchSize, "sFlow interface metrics", c.metricRepo.CreateBatch)
+	}()
+
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	refresh := time.NewTicker(time.Duration(c.config.RefreshSeconds) * time.Second)
+	defer refresh.Stop()
+	retention := time.NewTicker(time.Hour)
+	defer retention.Stop()
+
+	c.applyRetention(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			for _, conn := range c.udpListeners {
+				conn.Close()
+			}
+			for _, listener := range c.tcpListeners {
+				listener.Close()
+			}
+			c.mu.Unlock()
+			c.wg.Wait()
+			writers.Wait()
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			c.flush(shutdownCtx, time.Now().Add(time.Hour))
+			cancel()
+			log.Println("Flow collector stopped")
+			return nil
+		case <-flush.C:
+			c.flush(ctx, time.Now().Add(-flushGrace))
+		case <-refresh.C:
+			if err := c.exporters.Refresh(ctx); err != nil {
+				log.Printf("Error refreshing flow exporters: %v", err)
+			} else {
+				c.listenRouterPorts(ctx, netflowHost, ipfixHost)
+			}
+			c.refreshSubscribers(ctx)
+			c.logStats()
+		case <-retention.C:
+			c.applyRetention(ctx)
+		}
+	}
+}
+
+// splitAddr parses a listener address into host and port
+func splitAddr(addr string) (string, int, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid flow listener address %q: %w", addr, err)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid flow listener port %q", port)
+	}
+	return host, n, nil
+}
+
+// listenRouterPorts opens listeners for NetFlow and IPFIX ports configured on
+// routers that are not open yet. A port that cannot be opened is logged and
+// retried on the next refresh. IPFIX ports are only opened when IPFIX is
+// enabled.
+func (c *Collector) listenRouterPorts(ctx context.Context, netflowHost, ipfixHost string) {
+	for _, port := range c.exporters.NetFlowPorts() {
+		if err := c.listenUDP(netflowHost, port); err != nil {
+			log.Printf("Error opening NetFlow port configured on routers: %v", err)
+		}
+	}
+	if c.config.IPFIXAddr == "" {
+		return
+	}
+	for _, port := range c.exporters.IPFIXPorts() {
+		if err := c.listenUDP(ipfixHost, port); err != nil {
+			log.Printf("Error opening IPFIX port configured on routers: %v", err)
+		}
+		if err := c.listenTCP(ctx, ipfixHost, port); err != nil {
+			log.Printf("Error opening IPFIX port configured on routers: %v", err)
+		}
+	}
+}
+
+// listenUDP opens a UDP listener on port unless one is already open. UDP
+// listeners accept every export version and sFlow.
+func (c *Collector) listenUDP(host string, port int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, open := c.udpListeners[port]; open {
+		return nil
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on udp %s: %w", addr, err)
+	}
+	c.udpListeners[port] = conn
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.serveUDP(conn)
+	}()
+	log.Printf("Flow collector listening on udp %s", addr)
+	return nil
+}
+
+// listenTCP opens an IPFIX TCP listener on port unless one is already open
+func (c *Collector) listenTCP(ctx context.Context, host string, port int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, open := c.tcpListeners[port]; open {
+		return nil
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on tcp %s: %w", addr, err)
+	}
+	c.tcpListeners[port] = listener
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.serveStream(ctx, listener)
+	}()
+	log.Printf("Flow collector listening for IPFIX on tcp %s", addr)
+	return nil
+}
+
+// serveUDP reads one export packet per datagram until the connection is closed
+func (c *Collector) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("Flow UDP read error: %v", err)
+			continue
+		}
+		if udpAddr, ok := addr.(*net.UDPAddr); ok {
+			c.handle(buf[:n], udpAddr.AddrPort().Addr(), time.Now())
+		}
+	}
+}
+
+// serveStream accepts IPFIX TCP connections until the listener is closed
+func (c *Collector) serveStream(ctx context.Context, listener net.Listener) {
+	var conns sync.WaitGroup
+	defer conns.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("Flow accept error: %v", err)
+			continue
+		}
+
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			c.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn reads IPFIX messages from one TCP connection; each message is
+// framed by the length in its header
+func (c *Collector) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return
+	}
+	ip := tcpAddr.AddrPort().Addr()
+
+	reader := bufio.NewReader(conn)
+	buf := make([]byte, maxPacketSize)
+	for {
+		if _, err := io.ReadFull(reader, buf[:ipfixHeaderLen]); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("IPFIX stream from %s closed: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		length, err := IPFIXMessageLength(buf[:ipfixHeaderLen])
+		if err != nil {
+			log.Printf("IPFIX stream from %s closed: %v", conn.RemoteAddr(), err)
+			return
+		}
+		if _, err := io.ReadFull(reader, buf[ipfixHeaderLen:length]); err != nil {
+			return
+		}
+		c.handle(buf[:length], ip, time.Now())
+	}
+}
+
+// handle attributes, decodes and aggregates one export packet
+func (c *Collector) handle(data []byte, from netip.Addr, received time.Time) {
+	c.packets.Add(1)
+
+	ip := from.Unmap()
+	if IsSFlow(data) {
+		c.handleSFlow(data, ip, received)
+		return
+	}
+
+	exporter, ambiguous := c.exporters.Lookup(ip)
+	if exporter == nil {
+		if ambiguous {
+			c.ambiguous.Add(1)
+		} else {
+			c.unknown.Add(1)
+		}
+		return
+	}
+
+	version, err := PacketVersion(data)
+	if err != nil {
+		c.malformed.Add(1)
+		return
+	}
+	if !exporter.AcceptsVersion(int(version)) {
+		c.mismatch.Add(1)
+		return
+	}
+
+	var records []Record
+	switch version {
+	case 5:
+		records, err = DecodeNetFlowV5(data)
+	case 9:
+		var missing int
+		records, missing, err = DecodeNetFlowV9(data, ip, c.templates)
+		c.missing.Add(uint64(missing))
+	case ipfixVersion:
+		var missing int
+		records, missing, err = DecodeIPFIX(data, ip, c.templates, c.enterprise)
+		c.missing.Add(uint64(missing))
+	default:
+		err = fmt.Errorf("unsupported version %d", version)
+	}
+	if err != nil {
+		c.malformed.Add(1)
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	c.flows.Add(uint64(len(records)))
+
+	// NAT session events carry no traffic and are only kept as events
+	traffic := records[:0]
+	for i := range records {
+		if records[i].HasTranslation() {
+			c.queueTranslation(exporter, &records[i], received)
+		}
+		if records[i].NATEvent == 0 || records[i].Bytes > 0 || records[i].Packets > 0 {
+			traffic = append(traffic, records[i])
+		}
+	}
+	c.aggregate(exporter, traffic, received)
+}
+
+// aggregate adds traffic records to the rollups and subscriber usage
+func (c *Collector) aggregate(e *Exporter, records []Record, received time.Time) {
+	c.aggregator.Add(e, records, received)
+	if c.config.SubscriberAccounting {
+		c.usage.Add(e, records, received)
+	}
+}
+
+// refreshSubscribers reloads the subscriber sessions and leases flows can be
+// attributed to. Flows up to maxClockSkew old are accepted, so sessions that
+// ended within twice that are kept.
+func (c *Collector) refreshSubscribers(ctx context.Context) {
+	if !c.config.SubscriberAccounting {
+		return
+	}
+	if err := c.subscribers.Refresh(ctx, time.Now().Add(-2*maxClockSkew)); err != nil {
+		log.Printf("Error refreshing flow subscribers: %v", err)
+	}
+}
+
+// handleSFlow attributes, decodes and aggregates one sFlow datagram. The
+// agent address in the datagram identifies the router, since agents often
+// send from a different address; the UDP source is the fallback.
+func (c *Collector) handleSFlow(data []byte, from netip.Addr, received time.Time) {
+	datagram, err := DecodeSFlow(data)
+	if err != nil {
+		c.malformed.Add(1)
+	}
+	if datagram == nil {
+		return
+	}
+
+	exporter, ambiguous := c.exporters.Lookup(datagram.Agent)
+	if exporter == nil {
+		exporter, ambiguous = c.exporters.Lookup(from)
+	}
+	if exporter == nil {
+		if ambiguous {
+			c.ambiguous.Add(1)
+		} else {
+			c.unknown.Add(1)
+		}
+		return
+	}
+	if !exporter.AcceptsSFlow() {
+		c.mismatch.Add(1)
+		return
+	}
+
+	if len(datagram.Flows) > 0 {
+		c.flows.Add(uint64(len(datagram.Flows)))
+		c.aggregate(exporter, datagram.Flows, received)
+	}
+	c.storeCounters(exporter, datagram.Counters, received)
+}
+
+// storeCounters queues interface metrics from counter samples, by default
+// only for routers that are not polled over SNMP
+func (c *Collector) storeCounters(e *Exporter, counters []InterfaceCounters, received time.Time) {
+	switch {
+	case len(counters) == 0, c.config.SFlowCounters == SFlowCountersOff:
+		return
+	case c.config.SFlowCounters == SFlowCountersFallback && e.SNMP:
+		return
+	}
+
+	c.samples.Add(uint64(len(counters)))
+	for _, counter := range counters {
+		iface, ok := c.exporters.Interface(e.RouterID, counter.IfIndex)
+		if !ok {
+			continue
+		}
+		metric := c.counters.Observe(e, iface, counter, received)
+		if metric == nil {
+			continue
+		}
+
+		select {
+		case c.metricQueue <- metric:
+			c.metrics.Add(1)
+		default:
+			c.metricDropped.Add(1)
+		}
+	}
+}
+
+// queueTranslation queues a NAT translation for storage, dropping it when the
+// writer has fallen behind
+func (c *Collector) queueTranslation(e *Exporter, rec *Record, received time.Time) {
+	eventTime := rec.End
+	if eventTime.IsZero() {
+		eventTime = rec.Start
+	}
+	if eventTime.IsZero() || eventTime.Sub(received).Abs() > maxClockSkew {
+		eventTime = received
+	}
+
+	event := &models.NATEvent{
+		TenantID:          e.TenantID,
+		RouterID:          e.RouterID,
+		EventTime:         eventTime,
+		NATEvent:          int(rec.NATEvent),
+		Protocol:          int(rec.Protocol),
+		SrcAddress:        addrString(rec.SrcAddr),
+		SrcPort:           portPtr(rec.SrcPort, rec.SrcAddr),
+		DstAddress:        addrString(rec.DstAddr),
+		DstPort:           portPtr(rec.DstPort, rec.DstAddr),
+		PostNATSrcAddress: addrString(rec.PostNATSrcAddr),
+		PostNATSrcPort:    portPtr(rec.PostNATSrcPort, rec.PostNATSrcAddr),
+		PostNATDstAddress: addrString(rec.PostNATDstAddr),
+		PostNATDstPort:    portPtr(rec.PostNATDstPort, rec.PostNATDstAddr),
+		ReceivedAt:        received,
+	}
+
+	select {
+	case c.natQueue <- event:
+		c.translations.Add(1)
+	default:
+		c.natDropped.Add(1)
+	}
+}
+
+// runBatchWriter drains queue in batches until ctx is cancelled, then
+// writes whatever is still queued
+func runBatchWriter[T any](ctx context.Context, queue <-chan T, batchSize int, what string, store func(context.Context, []T) error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := store(ctx, batch); err != nil {
+			log.Printf("Error storing %d %s: %v", len(batch), what, err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case item := <-queue:
+			batch = append(batch, item)
+			if len(batch) >= batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			for {
+				select {
+				case item := <-queue:
+					batch = append(batch, item)
+					if len(batch) >= batchSize {
+						flush(shutdownCtx)
+					}
+				default:
+					flush(shutdownCtx)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes the rollups and subscriber usage of minutes that ended before
+// cutoff
+func (c *Collector) flush(ctx context.Context, cutoff time.Time) {
+	if rollups := c.aggregator.Drain(cutoff); len(rollups) > 0 {
+		if err := c.repo.UpsertRollups(ctx, rollups); err != nil {
+			log.Printf("Error storing %d flow rollups: %v", len(rollups), err)
+		}
+	}
+
+	usage, applications := c.usage.Drain(cutoff)
+	if len(usage) > 0 {
+		if err := c.usageRepo.UpsertUsage(ctx, usage); err != nil {
+			log.Printf("Error storing %d subscriber usage rows: %v", len(usage), err)
+		}
+	}
+	if len(applications) > 0 {
+		if err := c.usageRepo.UpsertAppUsage(ctx, applications); err != nil {
+			log.Printf("Error storing %d subscriber application usage rows: %v", len(applications), err)
+		}
+	}
+}
+
+// applyRetention deletes rollups, subscriber usage and NAT events older than
+// their retention periods
+func (c *Collector) applyRetention(ctx context.Context) {
+	prune(ctx, "flow rollups", c.config.RetentionDays, c.repo.DeleteBefore)
+	prune(ctx, "subscriber usage rows", c.config.RetentionDays, c.usageRepo.DeleteUsageBefore)
+	prune(ctx, "subscriber application usage rows", c.config.RetentionDays, c.usageRepo.DeleteAppUsageBefore)
+	prune(ctx, "NAT events", c.config.NATRetentionDays, c.natRepo.DeleteBefore)
+}
+
+// prune deletes rows older than days in batches
+func prune(ctx context.Context, what string, days int, deleteBefore func(context.Context, time.Time, int) (int64, error)) {
+	if days <= 0 {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+	var total int64
+	for {
+		deleted, err := deleteBefore(ctx, cutoff, retentionBatch)
+		if err != nil {
+			log.Printf("Error applying retention to %s: %v", what, err)
+			return
+		}
+		total += deleted
+		if deleted < retentionBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Flow retention removed %d %s older than %d days", total, what, days)
+	}
+}
+
+// logStats reports and resets the receive counters
+func (c *Collector) logStats() {
+	if c.packets.Load() == 0 {
+		// Keep the aggregator's counts for the next report
+		return
+	}
+	folded, overflow := c.aggregator.Stats()
+	ingest.LogStats("Flow", &c.packets, "packets", []ingest.Count{
+		{Name: "flows", Value: &c.flows},
+		{Name: "NAT events", Value: &c.translations},
+		{Name: "sFlow interface counters", Value: &c.samples},
+		{Name: "unknown exporter", Value: &c.unknown},
+		{Name: "ambiguous exporter", Value: &c.ambiguous},
+		{Name: "malformed", Value: &c.malformed},
+		{Name: "version mismatch", Value: &c.mismatch},
+		{Name: "sets without template", Value: &c.missing},
+		{Name: "NAT events dropped", Value: &c.natDropped},
+		{Name: "interface metrics queued", Value: &c.metrics},
+		{Name: "interface metrics dropped", Value: &c.metricDropped},
+	},
+		fmt.Sprintf("%d known exporters", c.exporters.Len()),
+		fmt.Sprintf("%d templates cached", c.templates.Len()),
+		fmt.Sprintf("%d rollups in memory", c.aggregator.Len()),
+		fmt.Sprintf("%d flows folded", folded),
+		fmt.Sprintf("%d flows dropped", overflow))
+
+	if c.config.SubscriberAccounting {
+		matched, dropped := c.usage.Stats()
+		log.Printf("Flow subscribers: %d flow directions attributed to %d subscriber addresses, %d dropped",
+			matched, c.subscribers.Len(), dropped)
+	}
+}
+
+// addrString formats a decoded address for storage, nil when absent
+func addrString(addr netip.Addr) *string {
+	if !addr.IsValid() {
+		return nil
+	}
+	s := addr.Unmap().String()
+	return &s
+}
+
+// portPtr returns the port of a present address for storage
+func portPtr(port uint16, addr netip.Addr) *int {
+	if !addr.IsValid() {
+		return nil
+	}
+	p := int(port)
+	return &p
+}
@@ -0,0 +1,91 @@
+package flow
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+type counterState struct {
+	counters InterfaceCounters
+	at       time.Time
+}
+
+// CounterTracker turns cumulative sFlow interface counters into interval
+// samples. Agents send counters every few seconds; a sample is produced at
+// most once per interval per interface, holding the deltas since the
+// previous one. A counter going backwards (agent restart or a 32 bit packet
+// counter wrapping) starts a new interval without a sample.
+type CounterTracker struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[interfaceKey]*counterState
+}
+
+// NewCounterTracker creates a tracker producing samples every interval
+func NewCounterTracker(interval time.Duration) *CounterTracker {
+	return &CounterTracker{
+		interval: interval,
+		last:     make(map[interfaceKey]*counterState),
+	}
+}
+
+// Observe records counters of iface received from e and returns a sample
+// once the interval has passed, or nil
+func (t *CounterTracker) Observe(e *Exporter, iface ExporterInterface, c InterfaceCounters, received time.Time) *models.InterfaceMetric {
+	key := interfaceKey{RouterID: e.RouterID, IfIndex: c.IfIndex}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, ok := t.last[key]
+	if ok && received.Sub(prev.at) < t.interval {
+		return nil
+	}
+	t.last[key] = &counterState{counters: c, at: received}
+	if !ok {
+		return nil
+	}
+
+	p := prev.counters
+	deltas := [8]uint64{}
+	for i, pair := range [8][2]uint64{
+		{c.InOctets, p.InOctets}, {c.OutOctets, p.OutOctets},
+		{c.InPackets, p.InPackets}, {c.OutPackets, p.OutPackets},
+		{c.InErrors, p.InErrors}, {c.OutErrors, p.OutErrors},
+		{c.InDiscards, p.InDiscards}, {c.OutDiscards, p.OutDiscards},
+	} {
+		if pair[0] < pair[1] {
+			return nil
+		}
+		deltas[i] = pair[0] - pair[1]
+	}
+
+	metric := &models.InterfaceMetric{
+		TenantID:    e.TenantID,
+		InterfaceID: iface.ID,
+		Timestamp:   received,
+		InOctets:    int64(deltas[0]),
+		OutOctets:   int64(deltas[1]),
+		InPackets:   int64(deltas[2]),
+		OutPackets:  int64(deltas[3]),
+		InErrors:    int64(deltas[4]),
+		OutErrors:   int64(deltas[5]),
+		InDiscards:  int64(deltas[6]),
+		OutDiscards: int64(deltas[7]),
+	}
+
+	speed := float64(c.Speed)
+	if speed == 0 {
+		speed = float64(iface.SpeedMbps) * 1e6
+	}
+	if seconds := received.Sub(prev.at).Seconds(); speed > 0 && seconds > 0 {
+		busiest := max(deltas[0], deltas[1])
+		utilization := min(float64(busiest)*8/seconds/speed*100, 100)
+		metric.UtilizationPercent = &utilization
+	}
+
+	return metric
+}
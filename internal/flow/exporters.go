@@ -0,0 +1,207 @@
+package flow
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/google/uuid"
+)
+
+// Exporter is a router sending flow exports
+type Exporter struct {
+	TenantID uuid.UUID
+	RouterID uuid.UUID
+	Name     string
+
+	// NetFlow settings from the router's NetFlowCapability
+	NetFlow        bool
+	NetFlowVersion int // 5, 9 or 10 (IPFIX); 0 accepts any
+	NetFlowPort    int
+
+	// IPFIX settings from the router's IPFIXCapability
+	IPFIX     bool
+	IPFIXPort int
+
+	// SNMP is true when the router's interfaces are polled over SNMP
+	SNMP bool
+}
+
+// AcceptsSFlow reports whether the exporter may send sFlow, which is
+// configured with the NetFlow/sFlow capability
+func (e *Exporter) AcceptsSFlow() bool {
+	return e.NetFlow
+}
+
+// ExporterInterface is an interface of an exporter, by ifIndex
+type ExporterInterface struct {
+	ID        uuid.UUID
+	SpeedMbps int64
+}
+
+type interfaceKey struct {
+	RouterID uuid.UUID
+	IfIndex  uint32
+}
+
+// AcceptsVersion reports whether the exporter is configured to send the
+// given NetFlow or IPFIX (10) version
+func (e *Exporter) AcceptsVersion(version int) bool {
+	if version == ipfixVersion && e.IPFIX {
+		return true
+	}
+	return e.NetFlow && (e.NetFlowVersion == 0 || e.NetFlowVersion == version)
+}
+
+// ExporterMap attributes flow export source addresses to routers with flow
+// export enabled, see ingest.Sources, and holds their interfaces and ports
+type ExporterMap struct {
+	db *sql.DB
+	*ingest.Sources[*Exporter]
+
+	mu           sync.RWMutex
+	interfaces   map[interfaceKey]ExporterInterface
+	netflowPorts []int
+	ipfixPorts   []int
+}
+
+// NewExporterMap creates an empty exporter map; call Refresh to load it
+func NewExporterMap(db *sql.DB) *ExporterMap {
+	return &ExporterMap{
+		db:         db,
+		Sources:    ingest.NewSources[*Exporter](),
+		interfaces: make(map[interfaceKey]ExporterInterface),
+	}
+}
+
+// Refresh reloads the address to router mapping and the exporters'
+// interfaces
+func (m *ExporterMap) Refresh(ctx context.Context) error {
+	query := ingest.SourceQuery(`
+		SELECT r.id, r.tenant_id, r.name,
+			COALESCE(rc.netflow_enabled, false) AS netflow_enabled, rc.netflow_version, rc.netflow_port,
+			COALESCE(rc.ipfix_enabled, false) AS ipfix_enabled, rc.ipfix_port,
+			COALESCE(rc.snmp_enabled, false) AS snmp_enabled
+		FROM routers r
+		JOIN router_capabilities rc ON r.id = rc.router_id
+		WHERE rc.netflow_enabled = true OR rc.ipfix_enabled = true
+	`)
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	set := ingest.NewSourceSet[*Exporter]()
+	seen := make(map[uuid.UUID]*Exporter)
+	netflowPorts := make(map[int]bool)
+	ipfixPorts := make(map[int]bool)
+	for rows.Next() {
+		var host string
+		var priority int
+		var version, netflowPort, ipfixPort *int
+		e := &Exporter{}
+		err := rows.Scan(&host, &priority, &e.RouterID, &e.TenantID, &e.Name,
+			&e.NetFlow, &version, &netflowPort, &e.IPFIX, &ipfixPort, &e.SNMP)
+		if err != nil {
+			return err
+		}
+
+		if existing, ok := seen[e.RouterID]; ok {
+			e = existing
+		} else {
+			if version != nil {
+				e.NetFlowVersion = *version
+			}
+			if netflowPort != nil && e.NetFlow {
+				e.NetFlowPort = *netflowPort
+				netflowPorts[*netflowPort] = true
+			}
+			if ipfixPort != nil && e.IPFIX {
+				e.IPFIXPort = *ipfixPort
+				ipfixPorts[*ipfixPort] = true
+			}
+			seen[e.RouterID] = e
+		}
+		set.Add(host, priority, e)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	interfaces, err := m.loadInterfaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.Set(set)
+	m.mu.Lock()
+	m.interfaces = interfaces
+	m.netflowPorts = sortedPorts(netflowPorts)
+	m.ipfixPorts = sortedPorts(ipfixPorts)
+	m.mu.Unlock()
+
+	return nil
+}
+
+// loadInterfaces reads the indexed interfaces of routers exporting NetFlow or
+// sFlow
+func (m *ExporterMap) loadInterfaces(ctx context.Context) (map[interfaceKey]ExporterInterface, error) {
+	query := `
+		SELECT i.router_id, i.if_index, i.id, COALESCE(i.speed_mbps, 0)
+		FROM interfaces i
+		JOIN router_capabilities rc ON i.router_id = rc.router_id
+		WHERE rc.netflow_enabled = true AND i.if_index IS NOT NULL
+	`
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	interfaces := make(map[interfaceKey]ExporterInterface)
+	for rows.Next() {
+		var key interfaceKey
+		var iface ExporterInterface
+		if err := rows.Scan(&key.RouterID, &key.IfIndex, &iface.ID, &iface.SpeedMbps); err != nil {
+			return nil, err
+		}
+		interfaces[key] = iface
+	}
+	return interfaces, rows.Err()
+}
+
+// Interface returns the interface of a router by ifIndex
+func (m *ExporterMap) Interface(routerID uuid.UUID, ifIndex uint32) (ExporterInterface, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	iface, ok := m.interfaces[interfaceKey{RouterID: routerID, IfIndex: ifIndex}]
+	return iface, ok
+}
+
+// NetFlowPorts returns the distinct NetFlow ports configured on routers
+func (m *ExporterMap) NetFlowPorts() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.netflowPorts
+}
+
+// IPFIXPorts returns the distinct IPFIX ports configured on routers
+func (m *ExporterMap) IPFIXPorts() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ipfixPorts
+}
+
+func sortedPorts(set map[int]bool) []int {
+	ports := make([]int, 0, len(set))
+	for port := range set {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
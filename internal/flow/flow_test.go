@@ -0,0 +1,439 @@
+package flow
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// packet builds big-endian test packets
+type packet []byte
+
+func (p packet) u8(v uint8) packet   { return append(p, v) }
+func (p packet) u16(v uint16) packet { return binary.BigEndian.AppendUint16(p, v) }
+func (p packet) u32(v uint32) packet { return binary.BigEndian.AppendUint32(p, v) }
+func (p packet) ip(s string) packet {
+	return append(p, netip.MustParseAddr(s).AsSlice()...)
+}
+
+func TestDecodeNetFlowV5(t *testing.T) {
+	export := time.Unix(1700000000, 0)
+	p := packet{}.u16(5).u16(1).u32(100000).u32(uint32(export.Unix())).u32(0).u32(1).u8(0).u8(0).u16(0x4000 | 100)
+	p = p.ip("10.0.0.1").ip("192.0.2.5").ip("0.0.0.0").u16(2).u16(3).
+		u32(10).u32(1500).u32(40000).u32(99000).
+		u16(51000).u16(443).u8(0).u8(0x18).u8(6).u8(0).
+		u16(64500).u16(15169).u8(24).u8(24).u16(0)
+
+	records, err := DecodeNetFlowV5(p)
+	if err != nil {
+		t.Fatalf("DecodeNetFlowV5() error = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	r := records[0]
+	if r.SrcAddr.String() != "10.0.0.1" || r.DstAddr.String() != "192.0.2.5" || r.DstPort != 443 || r.Protocol != 6 {
+		t.Errorf("record = %+v", r)
+	}
+	if r.InputIf != 2 || r.OutputIf != 3 || r.Bytes != 1500 || r.Packets != 10 || r.SrcAS != 64500 || r.DstAS != 15169 {
+		t.Errorf("record = %+v", r)
+	}
+	if r.SamplingRate != 100 {
+		t.Errorf("sampling = %d, want 100", r.SamplingRate)
+	}
+	if want := export.Add(-time.Second); !r.End.Equal(want) {
+		t.Errorf("end = %v, want %v", r.End, want)
+	}
+
+	if _, err := DecodeNetFlowV5(p[:40]); err != ErrTruncated {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+}
+
+func TestDecodeNetFlowV9(t *testing.T) {
+	exporter := netip.MustParseAddr("198.51.100.1")
+	cache := NewTemplateCache()
+	header := func(count uint16) packet {
+		return packet{}.u16(9).u16(count).u32(50000).u32(1700000000).u32(1).u32(7)
+	}
+	dataSet := packet{}.u16(256).u16(4 + 13 + 3).
+		ip("10.1.2.3").ip("203.0.113.9").u8(17).u32(900).u8(0).u8(0).u8(0)
+
+	// Data before its template is counted as missing
+	records, missing, err := DecodeNetFlowV9(append(header(1), dataSet...), exporter, cache)
+	if err != nil || len(records) != 0 || missing != 1 {
+		t.Fatalf("got %d records, %d missing, err %v", len(records), missing, err)
+	}
+
+	templateSet := packet{}.u16(0).u16(4 + 4 + 4*4).u16(256).u16(4).
+		u16(fieldIPv4SrcAddr).u16(4).u16(fieldIPv4DstAddr).u16(4).
+		u16(fieldProtocol).u16(1).u16(fieldInBytes).u16(4)
+	optionsTemplateSet := packet{}.u16(1).u16(4 + 6 + 4 + 4 + 2).u16(257).u16(4).u16(4).
+		u16(1).u16(4).u16(fieldSamplingInterval).u16(4).u16(0)
+	optionsData := packet{}.u16(257).u16(4 + 8).u32(7).u32(512)
+
+	p := header(4)
+	p = append(p, templateSet...)
+	p = append(p, optionsTemplateSet...)
+	p = append(p, optionsData...)
+	p = append(p, dataSet...)
+
+	records, missing, err = DecodeNetFlowV9(p, exporter, cache)
+	if err != nil || missing != 0 {
+		t.Fatalf("missing %d, err %v", missing, err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record (padding ignored), got %d", len(records))
+	}
+	r := records[0]
+	if r.SrcAddr.String() != "10.1.2.3" || r.DstAddr.String() != "203.0.113.9" || r.Protocol != 17 || r.Bytes != 900 {
+		t.Errorf("record = %+v", r)
+	}
+	if r.SamplingRate != 512 {
+		t.Errorf("sampling = %d, want 512 from options data", r.SamplingRate)
+	}
+
+	// Templates are scoped to the source ID
+	other := packet{}.u16(9).u16(1).u32(50000).u32(1700000000).u32(2).u32(8)
+	if _, missing, _ := DecodeNetFlowV9(append(other, dataSet...), exporter, cache); missing != 1 {
+		t.Errorf("expected template of source 7 not to apply to source 8")
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	e := &Exporter{TenantID: uuid.New(), RouterID: uuid.New()}
+	now := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+	rec := func(src, dst string, bytes uint64) Record {
+		return Record{
+			SrcAddr:  netip.MustParseAddr(src),
+			DstAddr:  netip.MustParseAddr(dst),
+			Protocol: 6,
+			Bytes:    bytes,
+			Packets:  1,
+			InputIf:  1,
+			End:      now.Add(-10 * time.Second),
+		}
+	}
+
+	a := NewAggregator(24, 48, 2)
+	sampled := rec("10.0.0.1", "2001:db8:1:2::1", 100)
+	sampled.SamplingRate = 10
+	a.Add(e, []Record{
+		rec("10.0.0.1", "192.0.2.1", 100),
+		rec("10.0.0.200", "192.0.2.77", 50),
+		sampled,
+		rec("172.16.0.1", "192.0.2.1", 5),
+	}, now)
+
+	if len(a.Drain(now)) != 0 {
+		t.Fatal("expected the current minute to stay in memory")
+	}
+
+	rollups := a.Drain(now.Add(time.Minute))
+	if len(rollups) != 3 {
+		t.Fatalf("expected 3 rollups (2 keys + 1 folded), got %d", len(rollups))
+	}
+
+	byKey := make(map[string]int64)
+	for _, r := range rollups {
+		byKey[r.SrcPrefix+" "+r.DstPrefix] = r.Bytes
+		if !r.Bucket.Equal(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)) {
+			t.Errorf("bucket = %v", r.Bucket)
+		}
+	}
+	if byKey["10.0.0.0/24 192.0.2.0/24"] != 150 {
+		t.Errorf("expected same-prefix flows to merge, got %v", byKey)
+	}
+	if byKey["10.0.0.0/24 2001:db8:1::/48"] != 1000 {
+		t.Errorf("expected sampled flow scaled to 1000 bytes, got %v", byKey)
+	}
+	if byKey["0.0.0.0/0 0.0.0.0/0"] != 5 {
+		t.Errorf("expected overflow flow folded into catch-all, got %v", byKey)
+	}
+	if folded, _ := a.Stats(); folded != 1 {
+		t.Errorf("folded = %d, want 1", folded)
+	}
+	if a.Len() != 0 {
+		t.Errorf("expected drained aggregator to be empty, got %d", a.Len())
+	}
+}
+
+func TestDecodeIPFIX(t *testing.T) {
+	exporter := netip.MustParseAddr("198.51.100.2")
+	cache := NewTemplateCache()
+	enterprise := EnterpriseMap{{Enterprise: 32473, ID: 7}: fieldPostNAPTSrcPort}
+	message := func(sets ...packet) packet {
+		length := ipfixHeaderLen
+		for _, s := range sets {
+			length += len(s)
+		}
+		p := packet{}.u16(10).u16(uint16(length)).u32(1700000000).u32(1).u32(42)
+		for _, s := range sets {
+			p = append(p, s...)
+		}
+		return p
+	}
+
+	// Template 300: source, destination, protocol, a variable-length
+	// enterprise field that is skipped, a mapped enterprise field, NAT fields
+	template := packet{}.u16(2).u16(4 + 4 + 7*4 + 2*4).u16(300).u16(7).
+		u16(fieldIPv4SrcAddr).u16(4).u16(fieldIPv4DstAddr).u16(4).u16(fieldProtocol).u16(1).
+		u16(enterpriseBit | 1).u16(variableLength).u32(32473).
+		u16(enterpriseBit | 7).u16(2).u32(32473).
+		u16(fieldPostNATSrcIPv4Addr).u16(4).u16(fieldNATEvent).u16(1)
+	options := packet{}.u16(3).u16(4 + 6 + 3*4).u16(301).u16(3).u16(1).
+		u16(149).u16(4).u16(fieldSamplingInterval).u16(4).u16(fieldSystemInitTimeMs).u16(8)
+	optionsData := packet{}.u16(301).u16(4 + 16).u32(42).u32(64).u32(0).u32(0)
+	data := packet{}.u16(300).u16(4 + 9 + 4 + 2 + 5 + 3).
+		ip("100.64.0.10").ip("203.0.113.80").u8(6).
+		u8(3).u8('a').u8('b').u8('c').
+		u16(40001).
+		ip("192.0.2.200").u8(1).
+		u8(0).u8(0).u8(0)
+
+	records, missing, err := DecodeIPFIX(message(template, options, optionsData, data), exporter, cache, enterprise)
+	if err != nil || missing != 0 {
+		t.Fatalf("missing %d, err %v", missing, err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if r.SrcAddr.String() != "100.64.0.10" || r.DstAddr.String() != "203.0.113.80" || r.Protocol != 6 {
+		t.Errorf("record = %+v", r)
+	}
+	if r.PostNATSrcAddr.String() != "192.0.2.200" || r.PostNATSrcPort != 40001 || r.NATEvent != 1 || !r.HasTranslation() {
+		t.Errorf("expected NAT translation with mapped enterprise port, got %+v", r)
+	}
+	if r.SamplingRate != 64 {
+		t.Errorf("sampling = %d, want 64", r.SamplingRate)
+	}
+
+	// Withdrawing the template stops decoding its data sets
+	withdraw := packet{}.u16(2).u16(8).u16(300).u16(0)
+	records, missing, _ = DecodeIPFIX(message(withdraw, data), exporter, cache, enterprise)
+	if len(records) != 0 || missing != 1 {
+		t.Errorf("expected withdrawn template to be missing, got %d records, %d missing", len(records), missing)
+	}
+
+	if _, _, err := DecodeIPFIX(message(data)[:20], exporter, cache, enterprise); err != ErrTruncated {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+}
+
+func TestParseEnterpriseMap(t *testing.T) {
+	m, err := ParseEnterpriseMap(" 32473:1=225, 32473:2 = 227 ")
+	if err != nil {
+		t.Fatalf("ParseEnterpriseMap() error = %v", err)
+	}
+	if m[EnterpriseElement{32473, 1}] != 225 || m[EnterpriseElement{32473, 2}] != 227 {
+		t.Errorf("map = %v", m)
+	}
+
+	for _, invalid := range []string{"32473=225", "0:1=2", "x:1=2", "1:40000=2"} {
+		if _, err := ParseEnterpriseMap(invalid); err == nil {
+			t.Errorf("ParseEnterpriseMap(%q) expected error", invalid)
+		}
+	}
+}
+
+func TestDecodeSFlow(t *testing.T) {
+	// Ethernet frame with an 802.1Q tag carrying IPv4 TCP 10.2.0.5:50000 -> 198.51.100.7:443
+	frame := packet{}.u32(0).u32(0).u32(0).u16(0x8100).u16(100).u16(0x0800).
+		u8(0x45).u8(0).u16(1400).u32(0).u8(64).u8(6).u16(0).ip("10.2.0.5").ip("198.51.100.7").
+		u16(50000).u16(443).u16(0) // two bytes of padding to a word
+	rawHeader := packet{}.u32(1).u32(1400).u32(4).u32(uint32(len(frame)) - 2)
+	rawHeader = append(rawHeader, frame...)
+	gateway := packet{}.u32(1).ip("192.0.2.1").u32(64500).u32(64501).u32(0).
+		u32(1).u32(2).u32(2).u32(3356).u32(15169).u32(0).u32(0)
+
+	flowSample := packet{}.u32(1).u32(3).u32(2048).u32(100000).u32(0).u32(7).u32(0x80000002).u32(3).
+		u32(sflowRawPacketHeader).u32(uint32(len(rawHeader)))
+	flowSample = append(flowSample, rawHeader...)
+	flowSample = flowSample.u32(sflowExtendedGateway).u32(uint32(len(gateway)))
+	flowSample = append(flowSample, gateway...)
+	flowSample = flowSample.u32(0x00009000 | 5).u32(4).u32(0) // vendor record, skipped
+
+	generic := packet{}.u32(7).u32(6).u32(0).u32(1000000000).u32(1).u32(3).
+		u32(0).u32(5000).u32(10).u32(2).u32(1).u32(3).u32(4).u32(0).
+		u32(0).u32(9000).u32(20).u32(0).u32(0).u32(5).u32(6).u32(0)
+	counterSample := packet{}.u32(9).u32(0).u32(7).u32(1).
+		u32(sflowGenericInterface).u32(uint32(len(generic)))
+	counterSample = append(counterSample, generic...)
+
+	p := packet{}.u32(5).u32(1).ip("203.0.113.50").u32(0).u32(44).u32(120000).u32(2).
+		u32(sflowFlowSample).u32(uint32(len(flowSample)))
+	p = append(p, flowSample...)
+	p = p.u32(sflowExpandedCounterSample).u32(uint32(len(counterSample)))
+	p = append(p, counterSample...)
+
+	if !IsSFlow(p) {
+		t.Fatal("expected datagram to be recognised as sFlow")
+	}
+	if IsSFlow(packet{}.u16(5).u16(1)) {
+		t.Error("expected NetFlow v5 not to be recognised as sFlow")
+	}
+
+	d, err := DecodeSFlow(p)
+	if err != nil {
+		t.Fatalf("DecodeSFlow() error = %v", err)
+	}
+	if d.Agent.String() != "203.0.113.50" || d.Sequence != 44 {
+		t.Errorf("header = %+v", d)
+	}
+	if len(d.Flows) != 1 {
+		t.Fatalf("expected 1 flow, got %d", len(d.Flows))
+	}
+	r := d.Flows[0]
+	if r.SrcAddr.String() != "10.2.0.5" || r.DstAddr.String() != "198.51.100.7" || r.Protocol != 6 || r.SrcPort != 50000 || r.DstPort != 443 {
+		t.Errorf("record = %+v", r)
+	}
+	if r.Bytes != 1400 || r.Packets != 1 || r.SamplingRate != 2048 {
+		t.Errorf("expected 1 packet of 1400 bytes sampled 1 in 2048, got %+v", r)
+	}
+	if r.InputIf != 7 || r.OutputIf != 0 {
+		t.Errorf("expected input 7 and no output for multiple interfaces, got %d/%d", r.InputIf, r.OutputIf)
+	}
+	if r.SrcAS != 64501 || r.DstAS != 15169 {
+		t.Errorf("expected AS 64501 -> 15169, got %d -> %d", r.SrcAS, r.DstAS)
+	}
+
+	if len(d.Counters) != 1 {
+		t.Fatalf("expected 1 counter record, got %d", len(d.Counters))
+	}
+	c := d.Counters[0]
+	if c.IfIndex != 7 || c.Speed != 1000000000 || !c.AdminUp || !c.OperUp {
+		t.Errorf("counters = %+v", c)
+	}
+	if c.InOctets != 5000 || c.InPackets != 13 || c.InDiscards != 3 || c.InErrors != 4 || c.OutOctets != 9000 || c.OutPackets != 20 || c.OutErrors != 6 {
+		t.Errorf("counters = %+v", c)
+	}
+
+	if d, err := DecodeSFlow(p[:len(p)-8]); err != ErrTruncated || len(d.Flows) != 1 {
+		t.Errorf("expected ErrTruncated with the complete samples kept, got %v", err)
+	}
+}
+
+func TestCounterTracker(t *testing.T) {
+	e := &Exporter{TenantID: uuid.New(), RouterID: uuid.New()}
+	iface := ExporterInterface{ID: uuid.New(), SpeedMbps: 100}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewCounterTracker(time.Minute)
+
+	if m := tracker.Observe(e, iface, InterfaceCounters{IfIndex: 3, InOctets: 1000}, start); m != nil {
+		t.Fatal("expected the first sample to only set the baseline")
+	}
+	if m := tracker.Observe(e, iface, InterfaceCounters{IfIndex: 3, InOctets: 2000}, start.Add(20*time.Second)); m != nil {
+		t.Fatal("expected no sample before the interval has passed")
+	}
+
+	// 75 MB in a minute on a 100 Mbps interface is 10% utilization
+	m := tracker.Observe(e, iface, InterfaceCounters{IfIndex: 3, InOctets: 75001000, OutOctets: 10}, start.Add(time.Minute))
+	if m == nil {
+		t.Fatal("expected a sample after the interval")
+	}
+	if m.InterfaceID != iface.ID || m.InOctets != 75000000 || m.OutOctets != 10 {
+		t.Errorf("metric = %+v", m)
+	}
+	if m.UtilizationPercent == nil || *m.UtilizationPercent != 10 {
+		t.Errorf("utilization = %v, want 10", m.UtilizationPercent)
+	}
+
+	if m := tracker.Observe(e, iface, InterfaceCounters{IfIndex: 3, InOctets: 5}, start.Add(2*time.Minute)); m != nil {
+		t.Error("expected a counter reset to produce no sample")
+	}
+}
+
+func TestSubscriberAggregator(t *testing.T) {
+	e := &Exporter{TenantID: uuid.New(), RouterID: uuid.New()}
+	now := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+
+	subscribers := NewSubscriberMap(nil)
+	alice := subscriberAddr{TenantID: e.TenantID, Addr: netip.MustParseAddr("100.64.1.10")}
+	subscribers.leases[alice] = []subscriberLease{
+		// The address moved from bob to alice at 12:00
+		{Subscriber: "alice", Type: "pppoe", Start: now.Add(-30 * time.Minute)},
+		{Subscriber: "bob", Type: "pppoe", Start: now.Add(-2 * time.Hour), End: now.Add(-30 * time.Minute)},
+	}
+	lan := subscriberAddr{TenantID: e.TenantID, Addr: netip.MustParseAddr("10.9.0.2")}
+	subscribers.leases[lan] = []subscriberLease{
+		{Subscriber: "aa:bb:cc:dd:ee:ff", Type: "dhcp", Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+	}
+
+	rec := func(src, dst string, srcPort, dstPort uint16, end time.Time) Record {
+		return Record{
+			SrcAddr: netip.MustParseAddr(src), DstAddr: netip.MustParseAddr(dst),
+			SrcPort: srcPort, DstPort: dstPort, Protocol: 6,
+			Bytes: 1000, Packets: 2, End: end, SamplingRate: 10,
+		}
+	}
+
+	a := NewSubscriberAggregator(subscribers, 100)
+	a.Add(e, []Record{
+		rec("100.64.1.10", "203.0.113.5", 50000, 443, now),                      // alice upload
+		rec("203.0.113.5", "100.64.1.10", 443, 50000, now),                      // alice download
+		rec("203.0.113.5", "100.64.1.10", 443, 50000, now.Add(-40*time.Minute)), // bob, before the move
+		rec("10.9.0.2", "100.64.1.10", 51000, 60000, now),                       // both subscribers
+		rec("198.51.100.1", "203.0.113.5", 1, 2, now),                           // no subscriber
+	}, now)
+
+	usage, applications := a.Drain(now.Add(time.Minute))
+	byName := make(map[string]*models.SubscriberUsage)
+	for _, u := range usage {
+		byName[u.Subscriber] = u
+	}
+	if len(usage) != 3 {
+		t.Fatalf("expected usage for alice, bob and the DHCP client, got %d rows", len(usage))
+	}
+	if u := byName["alice"]; u.BytesOut != 10000 || u.BytesIn != 20000 || u.PacketsIn != 40 || u.SubscriberType != "pppoe" {
+		t.Errorf("alice = %+v", u)
+	}
+	if u := byName["bob"]; u.BytesIn != 10000 || !u.Bucket.Equal(time.Date(2024, 5, 1, 11, 50, 0, 0, time.UTC)) {
+		t.Errorf("bob = %+v", u)
+	}
+	if u := byName["aa:bb:cc:dd:ee:ff"]; u.BytesOut != 10000 || u.SubscriberType != "dhcp" {
+		t.Errorf("dhcp client = %+v", u)
+	}
+
+	var https, other *models.SubscriberAppUsage
+	for _, app := range applications {
+		if app.Subscriber != "alice" {
+			continue
+		}
+		switch app.Port {
+		case 443:
+			https = app
+		case 0:
+			other = app
+		}
+	}
+	if https == nil || https.BytesIn != 10000 || https.BytesOut != 10000 || !https.Bucket.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected alice's https traffic in the 12:00 hour, got %+v", https)
+	}
+	if other == nil || other.BytesIn != 10000 {
+		t.Errorf("expected ephemeral remote port accounted as port 0, got %+v", other)
+	}
+	if matched, overflow := a.Stats(); matched != 5 || overflow != 0 {
+		t.Errorf("stats = %d matched, %d overflow", matched, overflow)
+	}
+}
+
+func TestApplicationName(t *testing.T) {
+	cases := map[[2]int]string{
+		{6, 443}:  "https",
+		{17, 443}: "quic",
+		{17, 53}:  "dns",
+		{6, 8291}: "tcp/8291",
+		{6, 0}:    "other",
+	}
+	for in, want := range cases {
+		if got := ApplicationName(in[0], in[1]); got != want {
+			t.Errorf("ApplicationName(%d, %d) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
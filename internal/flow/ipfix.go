@@ -0,0 +1,185 @@
+package flow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ipfixVersion   = 10
+	ipfixHeaderLen = 16
+
+	// IPFIX set IDs below 256 are reserved for templates
+	ipfixTemplateSet        = 2
+	ipfixOptionsTemplateSet = 3
+
+	// enterpriseBit marks an enterprise-specific information element
+	enterpriseBit = 0x8000
+
+	// variableLength marks a variable-length field in a template
+	variableLength = 0xffff
+)
+
+// EnterpriseElement identifies an enterprise-specific information element
+type EnterpriseElement struct {
+	Enterprise uint32 // IANA private enterprise number
+	ID         uint16
+}
+
+// EnterpriseMap maps enterprise-specific information elements to the IANA
+// element with the same meaning, so vendor fields decode like standard ones
+type EnterpriseMap map[EnterpriseElement]uint16
+
+// ParseEnterpriseMap parses a comma-separated list of PEN:ELEMENT=IANA
+// mappings, e.g. "32473:1=225" to decode element 1 of enterprise 32473 as
+// postNATSourceIPv4Address
+func ParseEnterpriseMap(s string) (EnterpriseMap, error) {
+	m := make(EnterpriseMap)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		source, target, ok := strings.Cut(entry, "=")
+		pen, id, ok2 := strings.Cut(source, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid enterprise element mapping %q", entry)
+		}
+		enterprise, err1 := strconv.ParseUint(strings.TrimSpace(pen), 10, 32)
+		element, err2 := strconv.ParseUint(strings.TrimSpace(id), 10, 15)
+		iana, err3 := strconv.ParseUint(strings.TrimSpace(target), 10, 15)
+		if err1 != nil || err2 != nil || err3 != nil || enterprise == 0 {
+			return nil, fmt.Errorf("invalid enterprise element mapping %q", entry)
+		}
+		m[EnterpriseElement{uint32(enterprise), uint16(element)}] = uint16(iana)
+	}
+	return m, nil
+}
+
+// IPFIXMessageLength returns the length of the IPFIX message starting with
+// header, used to frame messages on a TCP stream
+func IPFIXMessageLength(header []byte) (int, error) {
+	if len(header) < ipfixHeaderLen {
+		return 0, ErrTruncated
+	}
+	if version := binary.BigEndian.Uint16(header); version != ipfixVersion {
+		return 0, fmt.Errorf("unexpected IPFIX version %d", version)
+	}
+	length := int(binary.BigEndian.Uint16(header[2:]))
+	if length < ipfixHeaderLen {
+		return 0, fmt.Errorf("invalid IPFIX message length %d", length)
+	}
+	return length, nil
+}
+
+// DecodeIPFIX decodes one IPFIX message (RFC 7011). Templates are cached per
+// exporter and observation domain; enterprise elements listed in enterprise
+// are decoded as their IANA equivalents and other enterprise elements are
+// skipped. Data sets whose template has not been seen yet are counted in
+// missing.
+func DecodeIPFIX(data []byte, exporter netip.Addr, cache *TemplateCache, enterprise EnterpriseMap) (records []Record, missing int, err error) {
+	length, err := IPFIXMessageLength(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if length > len(data) {
+		return nil, 0, ErrTruncated
+	}
+
+	exportTime := time.Unix(int64(binary.BigEndian.Uint32(data[4:])), 0)
+	domainID := binary.BigEndian.Uint32(data[12:])
+
+	rest := data[ipfixHeaderLen:length]
+	for len(rest) >= 4 {
+		setID := binary.BigEndian.Uint16(rest)
+		setLen := int(binary.BigEndian.Uint16(rest[2:]))
+		if setLen < 4 || setLen > len(rest) {
+			return records, missing, ErrTruncated
+		}
+		body := rest[4:setLen]
+		rest = rest[setLen:]
+
+		switch {
+		case setID == ipfixTemplateSet || setID == ipfixOptionsTemplateSet:
+			if err := parseIPFIXTemplates(body, setID == ipfixOptionsTemplateSet, exporter, domainID, cache, enterprise); err != nil {
+				return records, missing, err
+			}
+		case setID >= minDataSetID:
+			template, ok := cache.Get(exporter, domainID, setID)
+			if !ok {
+				missing++
+				continue
+			}
+			base := timeBase{exportTime: exportTime, initTime: cache.InitTime(exporter, domainID)}
+			records = decodeDataSet(body, template, exporter, domainID, cache, base, records)
+		}
+	}
+
+	sampling := cache.Sampling(exporter, domainID)
+	for i := range records {
+		if records[i].SamplingRate == 0 {
+			records[i].SamplingRate = sampling
+		}
+	}
+	return records, missing, nil
+}
+
+// parseIPFIXTemplates reads the template records of a template or options
+// template set, including withdrawals (a record with no fields)
+func parseIPFIXTemplates(body []byte, options bool, exporter netip.Addr, domainID uint32, cache *TemplateCache, enterprise EnterpriseMap) error {
+	for len(body) >= 4 {
+		id := binary.BigEndian.Uint16(body)
+		count := int(binary.BigEndian.Uint16(body[2:]))
+		body = body[4:]
+
+		if count == 0 {
+			if id == ipfixTemplateSet || id == ipfixOptionsTemplateSet {
+				cache.WithdrawAll(exporter, domainID)
+			} else {
+				cache.Withdraw(exporter, domainID, id)
+			}
+			continue
+		}
+		if id < minDataSetID {
+			return fmt.Errorf("invalid template ID %d", id)
+		}
+
+		t := &Template{ID: id, Options: options, Fields: make([]TemplateField, 0, count)}
+		if options {
+			if len(body) < 2 {
+				return ErrTruncated
+			}
+			t.ScopeFields = int(binary.BigEndian.Uint16(body))
+			body = body[2:]
+		}
+
+		for i := 0; i < count; i++ {
+			if len(body) < 4 {
+				return ErrTruncated
+			}
+			f := TemplateField{
+				Type:   binary.BigEndian.Uint16(body),
+				Length: binary.BigEndian.Uint16(body[2:]),
+			}
+			body = body[4:]
+			if f.Type&enterpriseBit != 0 {
+				if len(body) < 4 {
+					return ErrTruncated
+				}
+				f.Type &^= enterpriseBit
+				f.Enterprise = binary.BigEndian.Uint32(body)
+				body = body[4:]
+				if iana, ok := enterprise[EnterpriseElement{f.Enterprise, f.Type}]; ok {
+					f.Type, f.Enterprise = iana, 0
+				}
+			}
+			t.Fields = append(t.Fields, f)
+		}
+		cache.Put(exporter, domainID, t)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package flow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+const (
+	netflowV5HeaderLen = 24
+	netflowV5RecordLen = 48
+	netflowV9HeaderLen = 20
+
+	// NetFlow v9 flowset IDs below 256 are reserved for templates
+	netflowV9TemplateSet        = 0
+	netflowV9OptionsTemplateSet = 1
+	minDataSetID                = 256
+)
+
+// ErrTruncated is returned for packets shorter than their headers claim
+var ErrTruncated = errors.New("truncated packet")
+
+// PacketVersion returns the version field shared by NetFlow and IPFIX headers
+func PacketVersion(data []byte) (uint16, error) {
+	if len(data) < 2 {
+		return 0, ErrTruncated
+	}
+	return binary.BigEndian.Uint16(data), nil
+}
+
+// DecodeNetFlowV5 decodes a NetFlow v5 export packet
+func DecodeNetFlowV5(data []byte) ([]Record, error) {
+	if len(data) < netflowV5HeaderLen {
+		return nil, ErrTruncated
+	}
+	if version := binary.BigEndian.Uint16(data); version != 5 {
+		return nil, fmt.Errorf("unexpected NetFlow version %d", version)
+	}
+
+	count := int(binary.BigEndian.Uint16(data[2:]))
+	if len(data) < netflowV5HeaderLen+count*netflowV5RecordLen {
+		return nil, ErrTruncated
+	}
+
+	base := timeBase{
+		exportTime: time.Unix(int64(binary.BigEndian.Uint32(data[8:])), int64(binary.BigEndian.Uint32(data[12:]))),
+		uptimeMs:   binary.BigEndian.Uint32(data[4:]),
+		hasUptime:  true,
+	}
+	// The top two bits carry the sampling mode, the rest the interval
+	sampling := uint32(binary.BigEndian.Uint16(data[22:]) & 0x3fff)
+
+	records := make([]Record, 0, count)
+	for i := 0; i < count; i++ {
+		r := data[netflowV5HeaderLen+i*netflowV5RecordLen:]
+		records = append(records, Record{
+			SrcAddr:      netip.AddrFrom4([4]byte(r[0:4])),
+			DstAddr:      netip.AddrFrom4([4]byte(r[4:8])),
+			InputIf:      uint32(binary.BigEndian.Uint16(r[12:])),
+			OutputIf:     uint32(binary.BigEndian.Uint16(r[14:])),
+			Packets:      uint64(binary.BigEndian.Uint32(r[16:])),
+			Bytes:        uint64(binary.BigEndian.Uint32(r[20:])),
+			Start:        base.at(binary.BigEndian.Uint32(r[24:])),
+			End:          base.at(binary.BigEndian.Uint32(r[28:])),
+			SrcPort:      binary.BigEndian.Uint16(r[32:]),
+			DstPort:      binary.BigEndian.Uint16(r[34:]),
+			Protocol:     r[38],
+			SrcAS:        uint32(binary.BigEndian.Uint16(r[40:])),
+			DstAS:        uint32(binary.BigEndian.Uint16(r[42:])),
+			SamplingRate: sampling,
+		})
+	}
+	return records, nil
+}
+
+// DecodeNetFlowV9 decodes a NetFlow v9 export packet. Templates are cached per
+// exporter and source ID; data flowsets whose template has not been seen yet
+// are skipped and counted in missing.
+func DecodeNetFlowV9(data []byte, exporter netip.Addr, cache *TemplateCache) (records []Record, missing int, err error) {
+	if len(data) < netflowV9HeaderLen {
+		return nil, 0, ErrTruncated
+	}
+	if version := binary.BigEndian.Uint16(data); version != 9 {
+		return nil, 0, fmt.Errorf("unexpected NetFlow version %d", version)
+	}
+
+	base := timeBase{
+		exportTime: time.Unix(int64(binary.BigEndian.Uint32(data[8:])), 0),
+		uptimeMs:   binary.BigEndian.Uint32(data[4:]),
+		hasUptime:  true,
+	}
+	sourceID := binary.BigEndian.Uint32(data[16:])
+
+	rest := data[netflowV9HeaderLen:]
+	for len(rest) >= 4 {
+		setID := binary.BigEndian.Uint16(rest)
+		length := int(binary.BigEndian.Uint16(rest[2:]))
+		if length < 4 || length > len(rest) {
+			return records, missing, ErrTruncated
+		}
+		body := rest[4:length]
+		rest = rest[length:]
+
+		switch {
+		case setID == netflowV9TemplateSet:
+			if err := parseV9Templates(body, exporter, sourceID, cache); err != nil {
+				return records, missing, err
+			}
+		case setID == netflowV9OptionsTemplateSet:
+			if err := parseV9OptionsTemplates(body, exporter, sourceID, cache); err != nil {
+				return records, missing, err
+			}
+		case setID >= minDataSetID:
+			template, ok := cache.Get(exporter, sourceID, setID)
+			if !ok {
+				missing++
+				continue
+			}
+			records = decodeDataSet(body, template, exporter, sourceID, cache, base, records)
+		}
+	}
+
+	sampling := cache.Sampling(exporter, sourceID)
+	for i := range records {
+		if records[i].SamplingRate == 0 {
+			records[i].SamplingRate = sampling
+		}
+	}
+	return records, missing, nil
+}
+
+// parseV9Templates reads the templates of a template flowset
+func parseV9Templates(body []byte, exporter netip.Addr, sourceID uint32, cache *TemplateCache) error {
+	for len(body) >= 4 {
+		id := binary.BigEndian.Uint16(body)
+		count := int(binary.BigEndian.Uint16(body[2:]))
+		body = body[4:]
+		if len(body) < count*4 {
+			return ErrTruncated
+		}
+
+		t := &Template{ID: id, Fields: make([]TemplateField, count)}
+		for i := range t.Fields {
+			t.Fields[i] = TemplateField{
+				Type:   binary.BigEndian.Uint16(body[i*4:]),
+				Length: binary.BigEndian.Uint16(body[i*4+2:]),
+			}
+		}
+		body = body[count*4:]
+		cache.Put(exporter, sourceID, t)
+	}
+	return nil
+}
+
+// parseV9OptionsTemplates reads the templates of an options template flowset
+func parseV9OptionsTemplates(body []byte, exporter netip.Addr, sourceID uint32, cache *TemplateCache) error {
+	for len(body) >= 6 {
+		id := binary.BigEndian.Uint16(body)
+		scopeLen := int(binary.BigEndian.Uint16(body[2:]))
+		optionLen := int(binary.BigEndian.Uint16(body[4:]))
+		if id < minDataSetID {
+			// Padding at the end of the flowset
+			return nil
+		}
+		body = body[6:]
+		if len(body) < scopeLen+optionLen {
+			return ErrTruncated
+		}
+
+		t := &Template{ID: id, Options: true, ScopeFields: scopeLen / 4}
+		specs := body[:scopeLen+optionLen]
+		for len(specs) >= 4 {
+			t.Fields = append(t.Fields, TemplateField{
+				Type:   binary.BigEndian.Uint16(specs),
+				Length: binary.BigEndian.Uint16(specs[2:]),
+			})
+			specs = specs[4:]
+		}
+		body = body[scopeLen+optionLen:]
+		cache.Put(exporter, sourceID, t)
+	}
+	return nil
+}
+
+// decodeDataSet appends the flow records of a data set to records. Options
+// records update the cached sampling rate and boot time instead of producing
+// flows. Enterprise fields without an IANA equivalent are skipped.
+func decodeDataSet(body []byte, t *Template, exporter netip.Addr, sourceID uint32, cache *TemplateCache, base timeBase, records []Record) []Record {
+	for {
+		var rec Record
+		var initTime time.Time
+		consumed, ok := walkRecord(body, t, func(i int, f TemplateField, value []byte) {
+			if f.Enterprise != 0 || (t.Options && i < t.ScopeFields) {
+				return
+			}
+			if t.Options && f.Type == fieldSystemInitTimeMs {
+				if ms := readUint(value); ms > 0 {
+					initTime = time.UnixMilli(int64(ms))
+				}
+				return
+			}
+			setField(&rec, f.Type, value, base)
+		})
+		if !ok {
+			return records
+		}
+		body = body[consumed:]
+
+		if t.Options {
+			if rec.SamplingRate > 0 {
+				cache.SetSampling(exporter, sourceID, rec.SamplingRate)
+			}
+			if !initTime.IsZero() {
+				cache.SetInitTime(exporter, sourceID, initTime)
+			}
+			continue
+		}
+		if rec.SrcAddr.IsValid() || rec.DstAddr.IsValid() || rec.NATEvent != 0 {
+			records = append(records, rec)
+		}
+	}
+}
+
+// walkRecord calls fn for each field of the record at the start of body and
+// returns the record length. It reports false when body holds no complete
+// record, which is also how trailing set padding ends the walk.
+func walkRecord(body []byte, t *Template, fn func(i int, f TemplateField, value []byte)) (int, bool) {
+	offset := 0
+	for i, f := range t.Fields {
+		length := int(f.Length)
+		if f.Length == variableLength {
+			// RFC 7011 section 7: one length octet, or 255 and two more
+			if offset >= len(body) {
+				return 0, false
+			}
+			length = int(body[offset])
+			offset++
+			if length == 255 {
+				if offset+2 > len(body) {
+					return 0, false
+				}
+				length = int(binary.BigEndian.Uint16(body[offset:]))
+				offset += 2
+			}
+		}
+		if offset+length > len(body) {
+			return 0, false
+		}
+		fn(i, f, body[offset:offset+length])
+		offset += length
+	}
+	return offset, offset > 0
+}
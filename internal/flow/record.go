@@ -0,0 +1,300 @@
+package flow
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is a single decoded flow, normalised across export formats
+type Record struct {
+	SrcAddr  netip.Addr
+	DstAddr  netip.Addr
+	SrcPort  uint16
+	DstPort  uint16
+	Protocol uint8
+	Bytes    uint64
+	Packets  uint64
+	InputIf  uint32
+	OutputIf uint32
+	SrcAS    uint32
+	DstAS    uint32
+	Start    time.Time
+	End      time.Time
+
+	// SamplingRate is the exporter's 1-in-N packet sampling; 0 or 1 means
+	// unsampled. Byte and packet counts are scaled by it when aggregated.
+	SamplingRate uint32
+
+	// NAT translation, set by IPFIX exporters doing NAT (RFC 8158). NATEvent
+	// is the IANA natEvent code; zero for ordinary flow records.
+	NATEvent       uint8
+	PostNATSrcAddr netip.Addr
+	PostNATDstAddr netip.Addr
+	PostNATSrcPort uint16
+	PostNATDstPort uint16
+}
+
+// HasTranslation reports whether the record carries a NAT translation
+func (r *Record) HasTranslation() bool {
+	return r.NATEvent != 0 || (r.PostNATSrcAddr.IsValid() && r.PostNATSrcAddr != r.SrcAddr)
+}
+
+// Field type numbers shared by NetFlow v9 and IPFIX (RFC 3954, IANA IPFIX
+// information elements)
+const (
+	fieldInBytes          = 1
+	fieldInPkts           = 2
+	fieldProtocol         = 4
+	fieldL4SrcPort        = 7
+	fieldIPv4SrcAddr      = 8
+	fieldInputSNMP        = 10
+	fieldL4DstPort        = 11
+	fieldIPv4DstAddr      = 12
+	fieldOutputSNMP       = 14
+	fieldSrcAS            = 16
+	fieldDstAS            = 17
+	fieldLastSwitched     = 21
+	fieldFirstSwitched    = 22
+	fieldOutBytes         = 23
+	fieldOutPkts          = 24
+	fieldIPv6SrcAddr      = 27
+	fieldIPv6DstAddr      = 28
+	fieldSamplingInterval = 34
+	fieldSamplerInterval  = 50
+
+	// IPFIX-only information elements
+	fieldFlowStartSeconds      = 150
+	fieldFlowEndSeconds        = 151
+	fieldFlowStartMilliseconds = 152
+	fieldFlowEndMilliseconds   = 153
+	fieldSystemInitTimeMs      = 160
+	fieldPostNATSrcIPv4Addr    = 225
+	fieldPostNATDstIPv4Addr    = 226
+	fieldPostNAPTSrcPort       = 227
+	fieldPostNAPTDstPort       = 228
+	fieldNATEvent              = 230
+	fieldPostNATSrcIPv6Addr    = 281
+	fieldPostNATDstIPv6Addr    = 282
+	fieldObservationTimeMs     = 323
+)
+
+// timeBase converts exporter uptime offsets to wall-clock time. NetFlow
+// headers carry the exporter's uptime; IPFIX exporters instead announce their
+// boot time in options data, and until they have, uptime offsets are ignored.
+type timeBase struct {
+	exportTime time.Time
+	uptimeMs   uint32
+	hasUptime  bool
+	initTime   time.Time
+}
+
+// at returns the wall-clock time of an uptime offset in milliseconds, or the
+// zero time when the offset cannot be converted
+func (b timeBase) at(uptimeMs uint32) time.Time {
+	if !b.initTime.IsZero() {
+		return b.initTime.Add(time.Duration(uptimeMs) * time.Millisecond)
+	}
+	if !b.hasUptime {
+		return time.Time{}
+	}
+	// Unsigned subtraction handles uptime wrapping after ~49.7 days
+	return b.exportTime.Add(-time.Duration(b.uptimeMs-uptimeMs) * time.Millisecond)
+}
+
+// setField decodes one template field into rec and reports whether the field
+// type is one the collector understands
+func setField(rec *Record, fieldType uint16, value []byte, base timeBase) bool {
+	switch fieldType {
+	case fieldInBytes, fieldOutBytes:
+		if rec.Bytes == 0 {
+			rec.Bytes = readUint(value)
+		}
+	case fieldInPkts, fieldOutPkts:
+		if rec.Packets == 0 {
+			rec.Packets = readUint(value)
+		}
+	case fieldProtocol:
+		rec.Protocol = uint8(readUint(value))
+	case fieldL4SrcPort:
+		rec.SrcPort = uint16(readUint(value))
+	case fieldL4DstPort:
+		rec.DstPort = uint16(readUint(value))
+	case fieldIPv4SrcAddr, fieldIPv6SrcAddr:
+		if addr, ok := netip.AddrFromSlice(value); ok {
+			rec.SrcAddr = addr
+		}
+	case fieldIPv4DstAddr, fieldIPv6DstAddr:
+		if addr, ok := netip.AddrFromSlice(value); ok {
+			rec.DstAddr = addr
+		}
+	case fieldInputSNMP:
+		rec.InputIf = uint32(readUint(value))
+	case fieldOutputSNMP:
+		rec.OutputIf = uint32(readUint(value))
+	case fieldSrcAS:
+		rec.SrcAS = uint32(readUint(value))
+	case fieldDstAS:
+		rec.DstAS = uint32(readUint(value))
+	case fieldFirstSwitched:
+		rec.Start = base.at(uint32(readUint(value)))
+	case fieldLastSwitched:
+		rec.End = base.at(uint32(readUint(value)))
+	case fieldSamplingInterval, fieldSamplerInterval:
+		rec.SamplingRate = uint32(readUint(value))
+	case fieldFlowStartSeconds:
+		rec.Start = time.Unix(int64(readUint(value)), 0)
+	case fieldFlowEndSeconds:
+		rec.End = time.Unix(int64(readUint(value)), 0)
+	case fieldFlowStartMilliseconds:
+		rec.Start = time.UnixMilli(int64(readUint(value)))
+	case fieldFlowEndMilliseconds, fieldObservationTimeMs:
+		rec.End = time.UnixMilli(int64(readUint(value)))
+	case fieldNATEvent:
+		rec.NATEvent = uint8(readUint(value))
+	case fieldPostNATSrcIPv4Addr, fieldPostNATSrcIPv6Addr:
+		if addr, ok := netip.AddrFromSlice(value); ok {
+			rec.PostNATSrcAddr = addr
+		}
+	case fieldPostNATDstIPv4Addr, fieldPostNATDstIPv6Addr:
+		if addr, ok := netip.AddrFromSlice(value); ok {
+			rec.PostNATDstAddr = addr
+		}
+	case fieldPostNAPTSrcPort:
+		rec.PostNATSrcPort = uint16(readUint(value))
+	case fieldPostNAPTDstPort:
+		rec.PostNATDstPort = uint16(readUint(value))
+	default:
+		return false
+	}
+	return true
+}
+
+// readUint decodes a big-endian unsigned integer of up to eight bytes; longer
+// values keep their low-order bytes
+func readUint(b []byte) uint64 {
+	if len(b) > 8 {
+		b = b[len(b)-8:]
+	}
+	var buf [8]byte
+	copy(buf[8-len(b):], b)
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+// protocolNames covers the IANA protocol numbers commonly seen in ISP traffic
+var protocolNames = map[int]string{
+	1:   "icmp",
+	2:   "igmp",
+	6:   "tcp",
+	17:  "udp",
+	41:  "ipv6",
+	47:  "gre",
+	50:  "esp",
+	51:  "ah",
+	58:  "ipv6-icmp",
+	89:  "ospf",
+	103: "pim",
+	112: "vrrp",
+	132: "sctp",
+}
+
+// ProtocolName returns the name of an IANA protocol number, or "" if unknown
+func ProtocolName(protocol int) string {
+	return protocolNames[protocol]
+}
+
+// ParseProtocol accepts a protocol name or number
+func ParseProtocol(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return n, true
+	}
+	for n, name := range protocolNames {
+		if strings.EqualFold(name, s) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// applicationNames names well-known service ports
+var applicationNames = map[int]string{
+	20:   "ftp-data",
+	21:   "ftp",
+	22:   "ssh",
+	25:   "smtp",
+	53:   "dns",
+	80:   "http",
+	110:  "pop3",
+	123:  "ntp",
+	143:  "imap",
+	443:  "https",
+	465:  "smtps",
+	587:  "submission",
+	853:  "dns-over-tls",
+	993:  "imaps",
+	995:  "pop3s",
+	1194: "openvpn",
+	1935: "rtmp",
+	3074: "xbox-live",
+	3478: "stun",
+	5222: "xmpp",
+	5228: "google-play",
+	8080: "http-alt",
+	8443: "https-alt",
+}
+
+// ApplicationName returns a label for traffic to a protocol and port: the
+// service name of a well-known port, otherwise protocol/port. Port 0 stands
+// for traffic without a service port.
+func ApplicationName(protocol, port int) string {
+	if port == 0 {
+		return "other"
+	}
+	if protocol == 17 && port == 443 {
+		return "quic"
+	}
+	if name, ok := applicationNames[port]; ok {
+		return name
+	}
+	name := ProtocolName(protocol)
+	if name == "" {
+		name = strconv.Itoa(protocol)
+	}
+	return name + "/" + strconv.Itoa(port)
+}
+
+// natEventNames are the IANA natEvent values (RFC 8158)
+var natEventNames = map[int]string{
+	1:  "nat44_session_create",
+	2:  "nat44_session_delete",
+	3:  "nat_translation_create",
+	4:  "nat64_session_create",
+	5:  "nat64_session_delete",
+	6:  "nat44_bib_create",
+	7:  "nat44_bib_delete",
+	8:  "nat64_bib_create",
+	9:  "nat64_bib_delete",
+	10: "addresses_exhausted",
+	11: "nat44_session_limit",
+	12: "nat64_session_limit",
+	13: "quota_exceeded",
+	14: "address_binding_create",
+	15: "address_binding_delete",
+	16: "port_block_allocation",
+	17: "port_block_deallocation",
+	18: "threshold_reached",
+}
+
+// NATEventName returns the name of an IANA natEvent value. Zero marks a
+// translated flow record rather than a session event.
+func NATEventName(event int) string {
+	if event == 0 {
+		return "translated_flow"
+	}
+	if name, ok := natEventNames[event]; ok {
+		return name
+	}
+	return "unknown"
+}
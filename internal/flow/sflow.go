@@ -0,0 +1,388 @@
+package flow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+)
+
+// sFlow v5 (sflow.org/sflow_version_5.txt) is XDR encoded: every field is a
+// big-endian 32 or 64 bit word and opaque data is padded to 4 bytes
+const (
+	sflowVersion = 5
+
+	sflowAddressIPv4 = 1
+	sflowAddressIPv6 = 2
+
+	// Sample formats, enterprise 0
+	sflowFlowSample            = 1
+	sflowCounterSample         = 2
+	sflowExpandedFlowSample    = 3
+	sflowExpandedCounterSample = 4
+
+	// Flow record formats, enterprise 0
+	sflowRawPacketHeader  = 1
+	sflowSampledIPv4      = 3
+	sflowSampledIPv6      = 4
+	sflowExtendedGateway  = 1003
+	sflowGenericInterface = 1 // counter record
+
+	// Raw packet header protocols
+	sflowHeaderEthernet = 1
+	sflowHeaderIPv4     = 11
+	sflowHeaderIPv6     = 12
+
+	// Interface values are 30 bits; the top two bits give their format
+	sflowInterfaceMask    = 0x3fffffff
+	sflowInterfaceUnknown = 0x3fffffff
+
+	sflowGenericInterfaceLen = 88
+)
+
+// SFlowDatagram is a decoded sFlow v5 datagram
+type SFlowDatagram struct {
+	Agent      netip.Addr // Agent address from the header, not the UDP source
+	SubAgentID uint32
+	Sequence   uint32
+	UptimeMs   uint32
+
+	// Flows has one record per flow sample; each stands for SamplingRate
+	// packets
+	Flows []Record
+
+	// Counters has the generic interface counters of counter samples
+	Counters []InterfaceCounters
+}
+
+// InterfaceCounters is an sFlow generic interface counter record
+type InterfaceCounters struct {
+	IfIndex     uint32
+	Speed       uint64 // bits per second
+	AdminUp     bool
+	OperUp      bool
+	InOctets    uint64
+	InPackets   uint64 // unicast, multicast and broadcast
+	InDiscards  uint64
+	InErrors    uint64
+	OutOctets   uint64
+	OutPackets  uint64
+	OutDiscards uint64
+	OutErrors   uint64
+}
+
+// IsSFlow reports whether data starts with an sFlow v5 header. NetFlow v5
+// also starts with version 5, but as a 16 bit word followed by a non-zero
+// record count, so the two never collide.
+func IsSFlow(data []byte) bool {
+	return len(data) >= 4 && binary.BigEndian.Uint32(data) == sflowVersion
+}
+
+// sflowReader reads XDR words, remembering whether it ran past the end
+type sflowReader struct {
+	data []byte
+	err  bool
+}
+
+func (r *sflowReader) u32() uint32 {
+	if len(r.data) < 4 {
+		r.err = true
+		r.data = nil
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *sflowReader) u64() uint64 {
+	if len(r.data) < 8 {
+		r.err = true
+		r.data = nil
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return v
+}
+
+// opaque returns n bytes and skips their padding
+func (r *sflowReader) opaque(n uint32) []byte {
+	padded := (uint64(n) + 3) &^ 3
+	if uint64(len(r.data)) < padded {
+		r.err = true
+		r.data = nil
+		return nil
+	}
+	v := r.data[:n]
+	r.data = r.data[padded:]
+	return v
+}
+
+// address reads an address_type followed by the address
+func (r *sflowReader) address() netip.Addr {
+	return r.addressOf(r.u32())
+}
+
+// addressOf reads an address of the given address_type
+func (r *sflowReader) addressOf(addressType uint32) netip.Addr {
+	switch addressType {
+	case sflowAddressIPv4:
+		if b := r.opaque(4); b != nil {
+			return netip.AddrFrom4([4]byte(b))
+		}
+	case sflowAddressIPv6:
+		if b := r.opaque(16); b != nil {
+			return netip.AddrFrom16([16]byte(b))
+		}
+	default:
+		// The length of an unknown address type is unknown too
+		r.err = true
+		r.data = nil
+	}
+	return netip.Addr{}
+}
+
+// DecodeSFlow decodes an sFlow v5 datagram. Samples and records of unknown
+// formats, including vendor (non-zero enterprise) formats, are skipped.
+func DecodeSFlow(data []byte) (*SFlowDatagram, error) {
+	r := &sflowReader{data: data}
+	if version := r.u32(); r.err {
+		return nil, ErrTruncated
+	} else if version != sflowVersion {
+		return nil, fmt.Errorf("unexpected sFlow version %d", version)
+	}
+
+	d := &SFlowDatagram{}
+	addressType := r.u32()
+	if !r.err && addressType != sflowAddressIPv4 && addressType != sflowAddressIPv6 {
+		return nil, fmt.Errorf("unsupported sFlow agent address type %d", addressType)
+	}
+	d.Agent = r.addressOf(addressType)
+	d.SubAgentID = r.u32()
+	d.Sequence = r.u32()
+	d.UptimeMs = r.u32()
+	count := r.u32()
+	if r.err {
+		return nil, ErrTruncated
+	}
+
+	for i := uint32(0); i < count; i++ {
+		format := r.u32()
+		sample := &sflowReader{data: r.opaque(r.u32())}
+		if r.err {
+			return d, ErrTruncated
+		}
+
+		switch format {
+		case sflowFlowSample, sflowExpandedFlowSample:
+			if rec, ok := decodeFlowSample(sample, format == sflowExpandedFlowSample); ok {
+				d.Flows = append(d.Flows, rec)
+			}
+		case sflowCounterSample, sflowExpandedCounterSample:
+			d.Counters = append(d.Counters, decodeCounterSample(sample, format == sflowExpandedCounterSample)...)
+		}
+		if sample.err {
+			return d, ErrTruncated
+		}
+	}
+
+	return d, nil
+}
+
+// decodeFlowSample decodes a flow sample into one record. It reports false
+// when none of the sample's records carried addresses.
+func decodeFlowSample(s *sflowReader, expanded bool) (Record, bool) {
+	var rec Record
+
+	s.u32() // sequence number
+	if expanded {
+		s.u32() // source id type
+		s.u32() // source id index
+	} else {
+		s.u32() // source id
+	}
+	rec.SamplingRate = s.u32()
+	s.u32() // sample pool
+	s.u32() // drops
+
+	if expanded {
+		rec.InputIf = sflowInterface(s.u32(), s.u32())
+		rec.OutputIf = sflowInterface(s.u32(), s.u32())
+	} else {
+		input, output := s.u32(), s.u32()
+		rec.InputIf = sflowInterface(input>>30, input&sflowInterfaceMask)
+		rec.OutputIf = sflowInterface(output>>30, output&sflowInterfaceMask)
+	}
+
+	count := s.u32()
+	for i := uint32(0); i < count && !s.err; i++ {
+		format := s.u32()
+		data := s.opaque(s.u32())
+		if s.err {
+			break
+		}
+		record := &sflowReader{data: data}
+
+		switch format {
+		case sflowRawPacketHeader:
+			protocol := record.u32()
+			frameLength := record.u32()
+			record.u32() // stripped
+			header := record.opaque(record.u32())
+			if record.err {
+				continue
+			}
+			if parsePacketHeader(&rec, protocol, header) {
+				rec.Bytes = uint64(frameLength)
+			}
+		case sflowSampledIPv4, sflowSampledIPv6:
+			length := record.u32()
+			protocol := record.u32()
+			size := uint32(4)
+			if format == sflowSampledIPv6 {
+				size = 16
+			}
+			src, _ := netip.AddrFromSlice(record.opaque(size))
+			dst, _ := netip.AddrFromSlice(record.opaque(size))
+			srcPort, dstPort := record.u32(), record.u32()
+			if record.err || rec.SrcAddr.IsValid() {
+				continue
+			}
+			rec.SrcAddr, rec.DstAddr = src, dst
+			rec.Protocol = uint8(protocol)
+			rec.SrcPort, rec.DstPort = uint16(srcPort), uint16(dstPort)
+			rec.Bytes = uint64(length)
+		case sflowExtendedGateway:
+			record.address() // next hop
+			record.u32()     // router AS
+			srcAS := record.u32()
+			record.u32() // source peer AS
+			var dstAS uint32
+			segments := record.u32()
+			for j := uint32(0); j < segments && !record.err; j++ {
+				record.u32() // segment type
+				for n := record.u32(); n > 0 && !record.err; n-- {
+					dstAS = record.u32()
+				}
+			}
+			if !record.err {
+				rec.SrcAS, rec.DstAS = srcAS, dstAS
+			}
+		}
+	}
+
+	if !rec.SrcAddr.IsValid() {
+		return rec, false
+	}
+	rec.Packets = 1
+	return rec, true
+}
+
+// sflowInterface returns the ifIndex of a single-interface value. Discarded
+// packets and packets sent to multiple interfaces have no output ifIndex.
+func sflowInterface(format, value uint32) uint32 {
+	if format != 0 || value == sflowInterfaceUnknown {
+		return 0
+	}
+	return value
+}
+
+// parsePacketHeader fills addresses, protocol and ports from the sampled
+// packet header. VLAN tags are skipped; other encapsulations are not decoded.
+func parsePacketHeader(rec *Record, protocol uint32, header []byte) bool {
+	var etherType uint16
+	switch protocol {
+	case sflowHeaderEthernet:
+		if len(header) < 14 {
+			return false
+		}
+		etherType = binary.BigEndian.Uint16(header[12:])
+		header = header[14:]
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(header) >= 4 {
+			etherType = binary.BigEndian.Uint16(header[2:])
+			header = header[4:]
+		}
+	case sflowHeaderIPv4:
+		etherType = 0x0800
+	case sflowHeaderIPv6:
+		etherType = 0x86dd
+	default:
+		return false
+	}
+
+	var transport []byte
+	switch etherType {
+	case 0x0800:
+		if len(header) < 20 || header[0]>>4 != 4 {
+			return false
+		}
+		headerLen := int(header[0]&0x0f) * 4
+		rec.Protocol = header[9]
+		rec.SrcAddr = netip.AddrFrom4([4]byte(header[12:16]))
+		rec.DstAddr = netip.AddrFrom4([4]byte(header[16:20]))
+		// Only the first fragment carries the transport header
+		if binary.BigEndian.Uint16(header[6:])&0x1fff == 0 && headerLen >= 20 && len(header) >= headerLen {
+			transport = header[headerLen:]
+		}
+	case 0x86dd:
+		if len(header) < 40 || header[0]>>4 != 6 {
+			return false
+		}
+		rec.Protocol = header[6]
+		rec.SrcAddr = netip.AddrFrom16([16]byte(header[8:24]))
+		rec.DstAddr = netip.AddrFrom16([16]byte(header[24:40]))
+		transport = header[40:]
+	default:
+		return false
+	}
+
+	switch rec.Protocol {
+	case 6, 17, 132: // TCP, UDP, SCTP
+		if len(transport) >= 4 {
+			rec.SrcPort = binary.BigEndian.Uint16(transport)
+			rec.DstPort = binary.BigEndian.Uint16(transport[2:])
+		}
+	}
+	return true
+}
+
+// decodeCounterSample returns the generic interface counters of a counter
+// sample; other counter records are skipped
+func decodeCounterSample(s *sflowReader, expanded bool) []InterfaceCounters {
+	s.u32() // sequence number
+	if expanded {
+		s.u32() // source id type
+		s.u32() // source id index
+	} else {
+		s.u32() // source id
+	}
+
+	var counters []InterfaceCounters
+	count := s.u32()
+	for i := uint32(0); i < count && !s.err; i++ {
+		format := s.u32()
+		data := s.opaque(s.u32())
+		if s.err || format != sflowGenericInterface || len(data) < sflowGenericInterfaceLen {
+			continue
+		}
+
+		r := &sflowReader{data: data}
+		c := InterfaceCounters{IfIndex: r.u32()}
+		r.u32() // ifType
+		c.Speed = r.u64()
+		r.u32() // ifDirection
+		status := r.u32()
+		c.AdminUp, c.OperUp = status&1 != 0, status&2 != 0
+		c.InOctets = r.u64()
+		c.InPackets = uint64(r.u32()) + uint64(r.u32()) + uint64(r.u32())
+		c.InDiscards = uint64(r.u32())
+		c.InErrors = uint64(r.u32())
+		r.u32() // ifInUnknownProtos
+		c.OutOctets = r.u64()
+		c.OutPackets = uint64(r.u32()) + uint64(r.u32()) + uint64(r.u32())
+		c.OutDiscards = uint64(r.u32())
+		c.OutErrors = uint64(r.u32())
+		counters = append(counters, c)
+	}
+	return counters
+}
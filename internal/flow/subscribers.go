@@ -0,0 +1,333 @@
+package flow
+
+import (
+	"context"
+	"database/sql"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// minEphemeralPort is the start of the IANA dynamic port range. Remote ports
+// in it identify no application and are accounted as port 0.
+const minEphemeralPort = 49152
+
+type subscriberAddr struct {
+	TenantID uuid.UUID
+	Addr     netip.Addr
+}
+
+// subscriberLease is an address held by a subscriber from Start until End;
+// a zero Start or End leaves that side open
+type subscriberLease struct {
+	Subscriber string
+	Type       string
+	Start      time.Time
+	End        time.Time
+}
+
+func (l *subscriberLease) validAt(t time.Time) bool {
+	return (l.Start.IsZero() || !t.Before(l.Start)) && (l.End.IsZero() || t.Before(l.End))
+}
+
+// SubscriberMap resolves addresses to the subscriber holding them at a given
+// time, from PPPoE sessions (framed IP address) and DHCP leases. PPPoE
+// sessions take precedence when both match.
+type SubscriberMap struct {
+	db *sql.DB
+
+	mu     sync.RWMutex
+	leases map[subscriberAddr][]subscriberLease
+}
+
+// NewSubscriberMap creates an empty subscriber map; call Refresh to load it
+func NewSubscriberMap(db *sql.DB) *SubscriberMap {
+	return &SubscriberMap{db: db, leases: make(map[subscriberAddr][]subscriberLease)}
+}
+
+// Refresh reloads the sessions and leases that are open or ended after since
+func (m *SubscriberMap) Refresh(ctx context.Context, since time.Time) error {
+	// PPPoE sessions sort first so they take precedence in Lookup. A session
+	// marked disconnected without a disconnect time cannot be placed in time
+	// and is skipped.
+	query := `
+		SELECT tenant_id, host(framed_ip_address), username, 'pppoe', connect_time, disconnect_time
+		FROM pppoe_sessions
+		WHERE framed_ip_address IS NOT NULL
+			AND (disconnect_time > $1 OR (disconnect_time IS NULL AND status <> 'disconnected'))
+		UNION ALL
+		SELECT tenant_id, host(ip_address), mac_address::text, 'dhcp', lease_start, lease_end
+		FROM dhcp_leases
+		WHERE lease_end > $1 AND COALESCE(lease_state, 'active') <> 'offered'
+		ORDER BY 4 DESC
+	`
+
+	rows, err := m.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	leases := make(map[subscriberAddr][]subscriberLease)
+	for rows.Next() {
+		var tenantID uuid.UUID
+		var host string
+		var lease subscriberLease
+		var start, end sql.NullTime
+		if err := rows.Scan(&tenantID, &host, &lease.Subscriber, &lease.Type, &start, &end); err != nil {
+			return err
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			continue
+		}
+		lease.Start, lease.End = start.Time, end.Time
+
+		key := subscriberAddr{TenantID: tenantID, Addr: addr.Unmap()}
+		leases[key] = append(leases[key], lease)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.leases = leases
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Lookup returns the subscriber holding addr in the tenant at time at
+func (m *SubscriberMap) Lookup(tenantID uuid.UUID, addr netip.Addr, at time.Time) (subscriber, subscriberType string, ok bool) {
+	if !addr.IsValid() {
+		return "", "", false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, lease := range m.leases[subscriberAddr{TenantID: tenantID, Addr: addr.Unmap()}] {
+		if lease.validAt(at) {
+			return lease.Subscriber, lease.Type, true
+		}
+	}
+	return "", "", false
+}
+
+// Len returns the number of addresses with sessions or leases
+func (m *SubscriberMap) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.leases)
+}
+
+type subscriberKey struct {
+	RouterID   uuid.UUID
+	Minute     int64 // Unix minute
+	Subscriber string
+	Type       string
+}
+
+type subscriberValue struct {
+	TenantID   uuid.UUID
+	BytesIn    uint64
+	BytesOut   uint64
+	PacketsIn  uint64
+	PacketsOut uint64
+	Flows      uint64
+}
+
+type applicationKey struct {
+	subscriberKey
+	Protocol uint8
+	Port     uint16
+}
+
+// SubscriberAggregator sums flows into per-minute traffic per subscriber and
+// per subscriber and application. A flow between two subscribers counts for
+// both. Each of the two maps holds at most maxKeys keys; flows for new keys
+// past that are dropped.
+type SubscriberAggregator struct {
+	subscribers *SubscriberMap
+	maxKeys     int
+
+	mu           sync.Mutex
+	usage        map[subscriberKey]*subscriberValue
+	applications map[applicationKey]*subscriberValue
+	matched      uint64
+	overflow     uint64
+}
+
+// NewSubscriberAggregator creates an aggregator resolving flow addresses with
+// subscribers
+func NewSubscriberAggregator(subscribers *SubscriberMap, maxKeys int) *SubscriberAggregator {
+	return &SubscriberAggregator{
+		subscribers:  subscribers,
+		maxKeys:      maxKeys,
+		usage:        make(map[subscriberKey]*subscriberValue),
+		applications: make(map[applicationKey]*subscriberValue),
+	}
+}
+
+// Add aggregates the records exported by e whose source or destination
+// address belonged to a subscriber at the flow's time
+func (a *SubscriberAggregator) Add(e *Exporter, records []Record, received time.Time) {
+	for i := range records {
+		rec := &records[i]
+		at := flowTime(rec, received)
+
+		// Traffic from a subscriber is upload, to a subscriber download; the
+		// application is identified by the port on the other side
+		if subscriber, subscriberType, ok := a.subscribers.Lookup(e.TenantID, rec.SrcAddr, at); ok {
+			a.add(e, rec, at, subscriber, subscriberType, false, rec.DstPort)
+		}
+		if subscriber, subscriberType, ok := a.subscribers.Lookup(e.TenantID, rec.DstAddr, at); ok {
+			a.add(e, rec, at, subscriber, subscriberType, true, rec.SrcPort)
+		}
+	}
+}
+
+func (a *SubscriberAggregator) add(e *Exporter, rec *Record, at time.Time, subscriber, subscriberType string, download bool, remotePort uint16) {
+	key := subscriberKey{
+		RouterID:   e.RouterID,
+		Minute:     at.Unix() / 60,
+		Subscriber: subscriber,
+		Type:       subscriberType,
+	}
+	appKey := applicationKey{
+		subscriberKey: key,
+		Protocol:      rec.Protocol,
+		Port:          applicationPort(rec.Protocol, remotePort),
+	}
+	bytes, packets := sampledCounts(rec)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.matched++
+	for _, value := range []*subscriberValue{a.usageValue(e, key), a.applicationValue(e, appKey)} {
+		if value == nil {
+			a.overflow++
+			continue
+		}
+		if download {
+			value.BytesIn += bytes
+			value.PacketsIn += packets
+		} else {
+			value.BytesOut += bytes
+			value.PacketsOut += packets
+		}
+		value.Flows++
+	}
+}
+
+func (a *SubscriberAggregator) usageValue(e *Exporter, key subscriberKey) *subscriberValue {
+	value, ok := a.usage[key]
+	if !ok {
+		if len(a.usage) >= a.maxKeys {
+			return nil
+		}
+		value = &subscriberValue{TenantID: e.TenantID}
+		a.usage[key] = value
+	}
+	return value
+}
+
+func (a *SubscriberAggregator) applicationValue(e *Exporter, key applicationKey) *subscriberValue {
+	value, ok := a.applications[key]
+	if !ok {
+		if len(a.applications) >= a.maxKeys {
+			return nil
+		}
+		value = &subscriberValue{TenantID: e.TenantID}
+		a.applications[key] = value
+	}
+	return value
+}
+
+// Drain removes and returns the traffic for minutes that ended before cutoff.
+// Application traffic is returned per hour, merging the drained minutes.
+func (a *SubscriberAggregator) Drain(cutoff time.Time) ([]*models.SubscriberUsage, []*models.SubscriberAppUsage) {
+	limit := cutoff.Unix() / 60
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage := make([]*models.SubscriberUsage, 0)
+	for key, value := range a.usage {
+		if key.Minute >= limit {
+			continue
+		}
+		usage = append(usage, &models.SubscriberUsage{
+			TenantID:       value.TenantID,
+			RouterID:       key.RouterID,
+			Bucket:         time.Unix(key.Minute*60, 0).UTC(),
+			Subscriber:     key.Subscriber,
+			SubscriberType: key.Type,
+			BytesIn:        int64(value.BytesIn),
+			BytesOut:       int64(value.BytesOut),
+			PacketsIn:      int64(value.PacketsIn),
+			PacketsOut:     int64(value.PacketsOut),
+			Flows:          int64(value.Flows),
+		})
+		delete(a.usage, key)
+	}
+
+	hourly := make(map[applicationKey]*models.SubscriberAppUsage)
+	for key, value := range a.applications {
+		if key.Minute >= limit {
+			continue
+		}
+		delete(a.applications, key)
+
+		hourKey := key
+		hourKey.Minute -= hourKey.Minute % 60
+		app, ok := hourly[hourKey]
+		if !ok {
+			app = &models.SubscriberAppUsage{
+				TenantID:       value.TenantID,
+				RouterID:       key.RouterID,
+				Bucket:         time.Unix(hourKey.Minute*60, 0).UTC(),
+				Subscriber:     key.Subscriber,
+				SubscriberType: key.Type,
+				Protocol:       int(key.Protocol),
+				Port:           int(key.Port),
+			}
+			hourly[hourKey] = app
+		}
+		app.BytesIn += int64(value.BytesIn)
+		app.BytesOut += int64(value.BytesOut)
+		app.Flows += int64(value.Flows)
+	}
+
+	applications := make([]*models.SubscriberAppUsage, 0, len(hourly))
+	for _, app := range hourly {
+		applications = append(applications, app)
+	}
+	return usage, applications
+}
+
+// Stats returns and resets the number of flow directions matched to a
+// subscriber and of those dropped because the aggregator was full
+func (a *SubscriberAggregator) Stats() (matched, overflow uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	matched, overflow = a.matched, a.overflow
+	a.matched, a.overflow = 0, 0
+	return matched, overflow
+}
+
+// applicationPort returns the port identifying a flow's application: the
+// remote port for TCP, UDP and SCTP unless it is ephemeral, otherwise 0
+func applicationPort(protocol uint8, port uint16) uint16 {
+	switch protocol {
+	case 6, 17, 132:
+		if port < minEphemeralPort {
+			return port
+		}
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package flow
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// maxTemplates bounds the template cache across all exporters
+const maxTemplates = 8192
+
+// TemplateField describes one field of a template
+type TemplateField struct {
+	Type       uint16
+	Length     uint16
+	Enterprise uint32 // IPFIX private enterprise number; 0 for IANA fields
+}
+
+// Template describes the layout of data records in a NetFlow v9 or IPFIX set
+type Template struct {
+	ID     uint16
+	Fields []TemplateField
+
+	// Options templates describe exporter metadata such as the sampling
+	// rate; their first ScopeFields fields identify what the data applies to
+	Options     bool
+	ScopeFields int
+}
+
+// domain identifies one exporter observation domain: a NetFlow v9 source ID
+// or an IPFIX observation domain ID at a given exporter address
+type domain struct {
+	Exporter netip.Addr
+	SourceID uint32
+}
+
+type templateKey struct {
+	domain
+	ID uint16
+}
+
+// domainState is metadata an exporter domain announces in options data
+type domainState struct {
+	Sampling uint32
+	InitTime time.Time // IPFIX systemInitTimeMilliseconds
+}
+
+// TemplateCache stores templates per exporter, source ID and template ID, and
+// the options data announced by each exporter domain
+type TemplateCache struct {
+	mu        sync.RWMutex
+	templates map[templateKey]*Template
+	domains   map[domain]domainState
+}
+
+// NewTemplateCache creates an empty template cache
+func NewTemplateCache() *TemplateCache {
+	return &TemplateCache{
+		templates: make(map[templateKey]*Template),
+		domains:   make(map[domain]domainState),
+	}
+}
+
+// Put stores or replaces a template. It reports false when the cache is full
+// and the template is new.
+func (c *TemplateCache) Put(exporter netip.Addr, sourceID uint32, t *Template) bool {
+	key := templateKey{domain{exporter, sourceID}, t.ID}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.templates[key]; !exists && len(c.templates) >= maxTemplates {
+		return false
+	}
+	c.templates[key] = t
+	return true
+}
+
+// Get returns a stored template
+func (c *TemplateCache) Get(exporter netip.Addr, sourceID uint32, id uint16) (*Template, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	t, ok := c.templates[templateKey{domain{exporter, sourceID}, id}]
+	return t, ok
+}
+
+// Withdraw removes a template; IPFIX exporters withdraw templates over TCP
+func (c *TemplateCache) Withdraw(exporter netip.Addr, sourceID uint32, id uint16) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.templates, templateKey{domain{exporter, sourceID}, id})
+}
+
+// WithdrawAll removes every template of an exporter domain
+func (c *TemplateCache) WithdrawAll(exporter netip.Addr, sourceID uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.templates {
+		if key.domain == (domain{exporter, sourceID}) {
+			delete(c.templates, key)
+		}
+	}
+}
+
+// SetSampling records the sampling rate announced by an exporter domain
+func (c *TemplateCache) SetSampling(exporter netip.Addr, sourceID uint32, rate uint32) {
+	c.updateDomain(domain{exporter, sourceID}, func(state *domainState) { state.Sampling = rate })
+}
+
+// Sampling returns the sampling rate announced by an exporter domain, or 0
+func (c *TemplateCache) Sampling(exporter netip.Addr, sourceID uint32) uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.domains[domain{exporter, sourceID}].Sampling
+}
+
+// SetInitTime records the boot time announced by an IPFIX exporter domain
+func (c *TemplateCache) SetInitTime(exporter netip.Addr, sourceID uint32, initTime time.Time) {
+	c.updateDomain(domain{exporter, sourceID}, func(state *domainState) { state.InitTime = initTime })
+}
+
+// InitTime returns the boot time announced by an exporter domain, or the zero
+// time
+func (c *TemplateCache) InitTime(exporter netip.Addr, sourceID uint32) time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.domains[domain{exporter, sourceID}].InitTime
+}
+
+func (c *TemplateCache) updateDomain(d domain, update func(*domainState)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state, exists := c.domains[d]
+	if !exists && len(c.domains) >= maxTemplates {
+		return
+	}
+	update(&state)
+	c.domains[d] = state
+}
+
+// Len returns the number of cached templates
+func (c *TemplateCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.templates)
+}
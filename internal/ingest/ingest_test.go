@@ -0,0 +1,95 @@
+package ingest
+
+import (
+	"bytes"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+type router struct{ name string }
+
+func TestSources(t *testing.T) {
+	core, edge, other := &router{"core"}, &router{"edge"}, &router{"other"}
+
+	set := NewSourceSet[*router]()
+	set.Add("10.0.0.1", ManagementAddress, core)
+	set.Add("10.0.0.2", ManagementAddress, edge)
+	set.Add("10.0.0.3", ManagementAddress, core)
+	set.Add("10.0.0.3", ManagementAddress, other) // Claimed twice: ambiguous
+	set.Add("10.0.0.1", InterfaceAddress, edge)   // Management address wins
+	set.Add("10.0.0.9", InterfaceAddress, edge)
+	set.Add("10.0.0.9", InterfaceAddress, edge) // Same router twice
+	set.Add("not an address", ManagementAddress, other)
+
+	m := NewSources[*router]()
+	m.Set(set)
+
+	tests := []struct {
+		ip        string
+		want      *router
+		ambiguous bool
+	}{
+		{"10.0.0.1", core, false},
+		{"10.0.0.2", edge, false},
+		{"10.0.0.3", nil, true},
+		{"10.0.0.9", edge, false},
+		{"10.0.0.4", nil, false},
+	}
+	for _, tt := range tests {
+		got, ambiguous := m.Lookup(netip.MustParseAddr(tt.ip))
+		if got != tt.want || ambiguous != tt.ambiguous {
+			t.Errorf("Lookup(%s) = %v, %v; want %v, %v", tt.ip, got, ambiguous, tt.want, tt.ambiguous)
+		}
+	}
+
+	// IPv4 in its 16-byte and IPv4-mapped forms
+	if got, _ := m.LookupIP(net.ParseIP("10.0.0.2")); got != edge {
+		t.Errorf("LookupIP(10.0.0.2) = %v, want edge", got)
+	}
+	if got, _ := m.Lookup(netip.MustParseAddr("::ffff:10.0.0.2")); got != edge {
+		t.Errorf("Lookup(::ffff:10.0.0.2) = %v, want edge", got)
+	}
+	if got, ambiguous := m.LookupIP(nil); got != nil || ambiguous {
+		t.Errorf("LookupIP(nil) = %v, %v; want nil, false", got, ambiguous)
+	}
+	if m.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", m.Len())
+	}
+}
+
+func TestLogStats(t *testing.T) {
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+
+	var received, unknown, dropped atomic.Uint64
+	counts := []Count{{Name: "unknown source", Value: &unknown}, {Name: "dropped", Value: &dropped}}
+
+	unknown.Add(2)
+	LogStats("Syslog", &received, "received", counts)
+	if buf.Len() != 0 {
+		t.Fatalf("logged %q with nothing received", buf.String())
+	}
+	if unknown.Load() != 2 {
+		t.Fatalf("counter reset with nothing received")
+	}
+
+	received.Add(5)
+	LogStats("Syslog", &received, "received", counts, "3 known sources")
+	want := "Syslog: 5 received (2 unknown source, 0 dropped); 3 known sources\n"
+	if got := buf.String(); got != want {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+	if received.Load() != 0 || unknown.Load() != 0 {
+		t.Errorf("counters not reset")
+	}
+}
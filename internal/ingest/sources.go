@@ -0,0 +1,123 @@
+// Package ingest holds what the syslog, SNMP trap, flow and RADIUS
+// listeners share: attributing datagrams to routers by source address and
+// reporting receive counters.
+package ingest
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+)
+
+// Address priorities of SourceQuery rows; the lower wins
+const (
+	ManagementAddress = 0
+	InterfaceAddress  = 1
+)
+
+// SourceQuery returns a query of the addresses of the routers selected by
+// routers, a query whose first column is the router ID. Each row holds a
+// host address, its priority and the columns of routers, management
+// addresses first.
+func SourceQuery(routers string) string {
+	return `
+		WITH sources AS (` + routers + `)
+		SELECT host(r.management_ip), 0, s.*
+		FROM sources s JOIN routers r ON r.id = s.id
+		UNION ALL
+		SELECT host(i.ip_address), 1, s.*
+		FROM sources s JOIN interfaces i ON i.router_id = s.id
+		WHERE i.ip_address IS NOT NULL
+		ORDER BY 2
+	`
+}
+
+// Sources attributes source addresses to routers. Management addresses take
+// precedence over interface addresses. Management IPs are only unique per
+// tenant, so an address claimed by several routers is ambiguous and not
+// attributed rather than guessed.
+type Sources[T comparable] struct {
+	mu   sync.RWMutex
+	byIP map[netip.Addr][]T
+}
+
+// NewSources creates an empty source map; Set loads it
+func NewSources[T comparable]() *Sources[T] {
+	return &Sources[T]{byIP: make(map[netip.Addr][]T)}
+}
+
+// Set replaces the address to router mapping
+func (m *Sources[T]) Set(set *SourceSet[T]) {
+	m.mu.Lock()
+	m.byIP = set.byIP
+	m.mu.Unlock()
+}
+
+// Lookup returns the router sending from ip, the zero value when none does.
+// ambiguous is true when several routers claim the address.
+func (m *Sources[T]) Lookup(ip netip.Addr) (src T, ambiguous bool) {
+	m.mu.RLock()
+	candidates := m.byIP[ip.Unmap()]
+	m.mu.RUnlock()
+
+	switch len(candidates) {
+	case 0:
+		return src, false
+	case 1:
+		return candidates[0], false
+	default:
+		return src, true
+	}
+}
+
+// LookupIP is Lookup for a net.IP
+func (m *Sources[T]) LookupIP(ip net.IP) (src T, ambiguous bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return src, false
+	}
+	return m.Lookup(addr)
+}
+
+// Len returns the number of mapped addresses
+func (m *Sources[T]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.byIP)
+}
+
+// SourceSet collects the addresses of routers while a Sources is reloaded
+type SourceSet[T comparable] struct {
+	byIP       map[netip.Addr][]T
+	priorities map[netip.Addr]int
+}
+
+// NewSourceSet creates an empty source set
+func NewSourceSet[T comparable]() *SourceSet[T] {
+	return &SourceSet[T]{
+		byIP:       make(map[netip.Addr][]T),
+		priorities: make(map[netip.Addr]int),
+	}
+}
+
+// Add maps a host address to a router unless the address already belongs
+// to a router by a lower priority. Rows must come in priority order, as
+// SourceQuery returns them; a router is added once per address.
+func (s *SourceSet[T]) Add(host string, priority int, src T) {
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return
+	}
+	ip = ip.Unmap()
+
+	if best, ok := s.priorities[ip]; ok && priority > best {
+		return
+	}
+	s.priorities[ip] = priority
+	for _, existing := range s.byIP[ip] {
+		if existing == src {
+			return
+		}
+	}
+	s.byIP[ip] = append(s.byIP[ip], src)
+}
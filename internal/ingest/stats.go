@@ -0,0 +1,35 @@
+package ingest
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Count is a named receive counter of a listener
+type Count struct {
+	Name  string
+	Value *atomic.Uint64
+}
+
+// LogStats reports and resets a listener's counters as
+// "what: N unit (n name, ...); notes". Nothing is logged, and the counters
+// keep counting, while total is zero.
+func LogStats(what string, total *atomic.Uint64, unit string, counts []Count, notes ...string) {
+	n := total.Swap(0)
+	if n == 0 {
+		return
+	}
+
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%d %s", c.Value.Swap(0), c.Name)
+	}
+
+	msg := fmt.Sprintf("%s: %d %s (%s)", what, n, unit, strings.Join(parts, ", "))
+	if len(notes) > 0 {
+		msg += "; " + strings.Join(notes, ", ")
+	}
+	log.Print(msg)
+}
@@ -0,0 +1,164 @@
+package maintenance
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// refreshInterval is how often the checker reloads windows; changes made
+// through the API are applied at once with Refresh
+const refreshInterval = 30 * time.Second
+
+// scheduled is a window with its occurrences around the last refresh
+type scheduled struct {
+	window      *models.MaintenanceWindow
+	occurrences []Occurrence
+}
+
+// Checker tells which routers are in maintenance. It keeps the windows
+// running or starting before the next refresh, resolved to the routers
+// they cover. A nil Checker reports no maintenance.
+type Checker struct {
+	repo repository.MaintenanceWindowRepository
+
+	mu      sync.RWMutex
+	routers map[uuid.UUID][]scheduled
+}
+
+// NewChecker creates a new maintenance checker
+func NewChecker(db *database.DB) *Checker {
+	return &Checker{
+		repo:    postgres.NewMaintenanceWindowRepo(db.DB),
+		routers: make(map[uuid.UUID][]scheduled),
+	}
+}
+
+// Start refreshes the windows periodically until ctx is cancelled. Call
+// Refresh first so that windows are known before services using the
+// checker start.
+func (c *Checker) Start(ctx context.Context) {
+	log.Printf("Maintenance checker started (refresh every %s)", refreshInterval)
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Maintenance checker stopped")
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				log.Printf("Error loading maintenance windows: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh reloads the enabled windows and the routers they cover
+func (c *Checker) Refresh(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+
+	now := time.Now()
+	windows, err := c.repo.ListEnabled(ctx, repository.MaintenanceWindowFilter{Since: now.Add(-refreshInterval)})
+	if err != nil {
+		return err
+	}
+
+	routers := make(map[uuid.UUID][]scheduled)
+	for _, w := range windows {
+		occurrences, err := Occurrences(w, now.Add(-refreshInterval), now.Add(2*refreshInterval))
+		if err != nil {
+			log.Printf("Skipping maintenance window %s: %v", w.ID, err)
+			continue
+		}
+		if len(occurrences) == 0 {
+			continue
+		}
+
+		routerIDs, err := c.repo.ListRouterIDs(ctx, w)
+		if err != nil {
+			return err
+		}
+		for _, id := range routerIDs {
+			routers[id] = append(routers[id], scheduled{window: w, occurrences: occurrences})
+		}
+	}
+
+	c.mu.Lock()
+	c.routers = routers
+	c.mu.Unlock()
+	return nil
+}
+
+// Active returns a window covering the router that is active at t, or nil.
+// Times around the last refresh only are known.
+func (c *Checker) Active(routerID uuid.UUID, t time.Time) *models.MaintenanceWindow {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return activeWindow(c.routers[routerID], t, false)
+}
+
+// PausedRouters returns the routers in a window pausing polling at t
+func (c *Checker) PausedRouters(t time.Time) []uuid.UUID {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var paused []uuid.UUID
+	for routerID, windows := range c.routers {
+		if activeWindow(windows, t, true) != nil {
+			paused = append(paused, routerID)
+		}
+	}
+	return paused
+}
+
+// Suppress marks an alert about a router as suppressed when the router is
+// in maintenance at the alert's trigger time
+func (c *Checker) Suppress(alert *models.Alert, routerID uuid.UUID) {
+	at := alert.TriggeredAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	if w := c.Active(routerID, at); w != nil {
+		windowID := w.ID
+		alert.Suppressed = true
+		alert.MaintenanceWindowID = &windowID
+	}
+}
+
+// activeWindow returns the first of windows active at t, considering only
+// those pausing polling when pausing is set
+func activeWindow(windows []scheduled, t time.Time, pausing bool) *models.MaintenanceWindow {
+	for _, s := range windows {
+		if pausing && !s.window.PausePolling {
+			continue
+		}
+		for _, o := range s.occurrences {
+			if o.Contains(t) {
+				return s.window
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,592 @@
+package maintenance
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// maxOccurrences bounds the occurrences returned for one window
+const maxOccurrences = 1000
+
+// Occurrence is one period during which a maintenance window is active
+type Occurrence struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Contains reports whether t falls in the occurrence
+func (o Occurrence) Contains(t time.Time) bool {
+	return !t.Before(o.Start) && t.Before(o.End)
+}
+
+// schedule yields the start times of a recurring window
+type schedule interface {
+	// starts returns the start times in [from, to), in order, at most max
+	starts(from, to time.Time, max int) []time.Time
+}
+
+// Validate checks that a window's times, timezone and recurrence are usable
+func Validate(w *models.MaintenanceWindow) error {
+	if w.StartsAt.IsZero() {
+		return fmt.Errorf("starts_at is required")
+	}
+	if _, err := location(w.Timezone); err != nil {
+		return err
+	}
+	if w.EndsAt != nil && !w.EndsAt.After(w.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+
+	if !w.IsRecurring() {
+		if w.EndsAt == nil {
+			return fmt.Errorf("ends_at is required for a one-off window")
+		}
+		if w.DurationMinutes != nil {
+			return fmt.Errorf("duration_minutes applies to recurring windows only")
+		}
+		return nil
+	}
+
+	if w.DurationMinutes == nil || *w.DurationMinutes <= 0 {
+		return fmt.Errorf("duration_minutes is required for a recurring window")
+	}
+	_, err := parseWindowSchedule(w)
+	return err
+}
+
+// Occurrences returns the occurrences of a window overlapping [from, to),
+// in order. Occurrences that started before from but are still running are
+// included.
+func Occurrences(w *models.MaintenanceWindow, from, to time.Time) ([]Occurrence, error) {
+	if !w.IsRecurring() {
+		if w.EndsAt == nil || !w.StartsAt.Before(to) || !w.EndsAt.After(from) {
+			return nil, nil
+		}
+		return []Occurrence{{Start: w.StartsAt, End: *w.EndsAt}}, nil
+	}
+
+	if w.DurationMinutes == nil || *w.DurationMinutes <= 0 {
+		return nil, fmt.Errorf("duration_minutes is required for a recurring window")
+	}
+	sched, err := parseWindowSchedule(w)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Duration(*w.DurationMinutes) * time.Minute
+	if w.EndsAt != nil && w.EndsAt.Before(to) {
+		to = *w.EndsAt
+	}
+	first := from.Add(-duration)
+	if first.Before(w.StartsAt) {
+		first = w.StartsAt
+	}
+
+	var occurrences []Occurrence
+	for _, start := range sched.starts(first, to, maxOccurrences) {
+		end := start.Add(duration)
+		if end.After(from) {
+			occurrences = append(occurrences, Occurrence{Start: start, End: end})
+		}
+	}
+	return occurrences, nil
+}
+
+// parseWindowSchedule parses a window's recurrence: an RRULE, optionally
+// prefixed with "RRULE:", or a five-field cron expression
+func parseWindowSchedule(w *models.MaintenanceWindow) (schedule, error) {
+	loc, err := location(w.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	expr := strings.TrimSpace(*w.Recurrence)
+	if strings.Contains(strings.ToUpper(expr), "FREQ=") {
+		return parseRRule(expr, w.StartsAt.In(loc))
+	}
+	return parseCron(expr, loc)
+}
+
+// location loads a window's timezone, UTC when empty
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// ============================================================================
+// CRON
+// ============================================================================
+
+// cronSchedule is a five-field cron expression: minute, hour, day of
+// month, month and day of week (0 or 7 is Sunday). Fields take *, numbers,
+// ranges, lists and steps.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+	loc                           *time.Location
+}
+
+func parseCron(expr string, loc *time.Location) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	c := &cronSchedule{loc: loc}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron day of week: %w", err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField parses one cron field into a bit set of allowed values
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		stepped := false
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step, stepped, part = n, true, part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = n, n
+			if stepped {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// matchesDay applies cron's rule that a day matches either restricted
+// day field when both are restricted
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (c *cronSchedule) starts(from, to time.Time, max int) []time.Time {
+	var starts []time.Time
+
+	t := from.In(c.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, c.loc)
+	if t.Before(from) {
+		t = t.Add(time.Minute)
+	}
+
+	for t.Before(to) && len(starts) < max {
+		var next time.Time
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+		case !c.matchesDay(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+		default:
+			if c.minute&(1<<uint(t.Minute())) != 0 {
+				starts = append(starts, t)
+			}
+			next = t.Add(time.Minute)
+		}
+
+		// Daylight saving changes can map a wall clock time back onto t
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return starts
+}
+
+// ============================================================================
+// RRULE
+// ============================================================================
+
+// rruleSchedule is the RFC 5545 recurrence subset used for maintenance:
+// FREQ (HOURLY, DAILY, WEEKLY, MONTHLY), INTERVAL, COUNT, UNTIL, BYDAY,
+// BYMONTHDAY, BYHOUR, BYMINUTE and WKST=MO. Periods are counted from
+// dtstart, whose day, hour and minute fill in missing BY parts.
+type rruleSchedule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+	byHour     []int
+	byMinute   []int
+	dtstart    time.Time
+	loc        *time.Location
+}
+
+// weekdayNum is a BYDAY entry; n selects the nth (or from the end when
+// negative) weekday of the month, 0 every one
+type weekdayNum struct {
+	day time.Weekday
+	n   int
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRRule(expr string, dtstart time.Time) (*rruleSchedule, error) {
+	r := &rruleSchedule{interval: 1, dtstart: dtstart, loc: dtstart.Location()}
+
+	expr = strings.TrimPrefix(strings.ToUpper(expr), "RRULE:")
+	for _, part := range strings.Split(expr, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case "HOURLY", "DAILY", "WEEKLY", "MONTHLY":
+				r.freq = value
+			default:
+				return nil, fmt.Errorf("unsupported RRULE frequency %q", value)
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err == nil && r.interval < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err == nil && r.count < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "UNTIL":
+			r.until, err = parseRRuleTime(value, r.loc)
+		case "BYDAY":
+			r.byDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseIntList(value, -31, 31)
+			for _, d := range r.byMonthDay {
+				if d == 0 {
+					err = fmt.Errorf("0 is not a day of the month")
+				}
+			}
+		case "BYHOUR":
+			r.byHour, err = parseIntList(value, 0, 23)
+		case "BYMINUTE":
+			r.byMinute, err = parseIntList(value, 0, 59)
+		case "WKST":
+			if value != "MO" {
+				err = fmt.Errorf("only MO is supported")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("RRULE %s: %w", key, err)
+		}
+	}
+
+	if r.freq == "" {
+		return nil, fmt.Errorf("RRULE FREQ is required")
+	}
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, fmt.Errorf("RRULE COUNT and UNTIL cannot both be set")
+	}
+	if r.freq != "MONTHLY" {
+		for _, d := range r.byDay {
+			if d.n != 0 {
+				return nil, fmt.Errorf("RRULE BYDAY ordinals apply to MONTHLY only")
+			}
+		}
+	}
+	return r, nil
+}
+
+// parseRRuleTime parses an UNTIL value, in UTC with a Z suffix and in loc
+// otherwise
+func parseRRuleTime(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if layout == "20060102T150405Z" {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+func parseByDay(value string) ([]weekdayNum, error) {
+	var days []weekdayNum
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid day %q", item)
+		}
+		day, ok := rruleWeekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q", item)
+		}
+		wd := weekdayNum{day: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid day %q", item)
+			}
+			wd.n = n
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
+
+func parseIntList(value string, min, max int) ([]int, error) {
+	var values []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < min || n > max {
+			return nil, fmt.Errorf("%q is outside %d-%d", item, min, max)
+		}
+		values = append(values, n)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+func (r *rruleSchedule) starts(from, to time.Time, max int) []time.Time {
+	var starts []time.Time
+
+	// With COUNT every start from dtstart must be counted; otherwise days
+	// before from cannot contribute
+	day := DateOf(r.dtstart)
+	if r.count == 0 && from.After(r.dtstart) {
+		day = DateOf(from.In(r.loc))
+	}
+	last := DateOf(to.In(r.loc))
+	if !r.until.IsZero() && r.until.Before(to) {
+		last = DateOf(r.until.In(r.loc))
+	}
+
+	seen := 0
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !r.matchesDay(day) {
+			continue
+		}
+		for _, t := range r.times(day) {
+			if t.Before(r.dtstart) {
+				continue
+			}
+			if !r.until.IsZero() && t.After(r.until) {
+				return starts
+			}
+			seen++
+			if r.count > 0 && seen > r.count {
+				return starts
+			}
+			if !t.Before(to) || len(starts) >= max {
+				return starts
+			}
+			if !t.Before(from) {
+				starts = append(starts, t)
+			}
+		}
+	}
+	return starts
+}
+
+// matchesDay reports whether a day, at midnight in r.loc, is in a period
+// selected by INTERVAL and matches the BY day parts
+func (r *rruleSchedule) matchesDay(day time.Time) bool {
+	start := DateOf(r.dtstart)
+
+	switch r.freq {
+	case "DAILY":
+		if DaysBetween(start, day)%r.interval != 0 {
+			return false
+		}
+	case "WEEKLY":
+		if DaysBetween(weekStart(start), weekStart(day))/7%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+	case "MONTHLY":
+		months := (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+		if months%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 && len(r.byMonthDay) == 0 {
+			return day.Day() == start.Day()
+		}
+	}
+
+	if len(r.byMonthDay) > 0 && !matchesMonthDay(day, r.byMonthDay) {
+		return false
+	}
+	if len(r.byDay) > 0 && !matchesWeekday(day, r.byDay) {
+		return false
+	}
+	return true
+}
+
+// times returns the start times of a matching day, in order
+func (r *rruleSchedule) times(day time.Time) []time.Time {
+	hours := r.byHour
+	if len(hours) == 0 {
+		if r.freq == "HOURLY" {
+			for h := 0; h < 24; h++ {
+				hours = append(hours, h)
+			}
+		} else {
+			hours = []int{r.dtstart.Hour()}
+		}
+	}
+	minutes := r.byMinute
+	if len(minutes) == 0 {
+		minutes = []int{r.dtstart.Minute()}
+	}
+
+	var times []time.Time
+	for _, h := range hours {
+		for _, m := range minutes {
+			t := time.Date(day.Year(), day.Month(), day.Day(), h, m, r.dtstart.Second(), 0, r.loc)
+			if r.freq == "HOURLY" {
+				hoursSince := int(t.Sub(r.dtstart.Truncate(time.Hour)) / time.Hour)
+				if hoursSince%r.interval != 0 {
+					continue
+				}
+			}
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+func matchesMonthDay(day time.Time, monthDays []int) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, d := range monthDays {
+		if d == day.Day() || (d < 0 && daysInMonth+d+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesWeekday(day time.Time, weekdays []weekdayNum) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, wd := range weekdays {
+		if wd.day != day.Weekday() {
+			continue
+		}
+		switch {
+		case wd.n == 0:
+			return true
+		case wd.n > 0 && (day.Day()-1)/7+1 == wd.n:
+			return true
+		case wd.n < 0 && (daysInMonth-day.Day())/7+1 == -wd.n:
+			return true
+		}
+	}
+	return false
+}
+
+// DateOf returns midnight of t's day in t's location
+func DateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// weekStart returns the Monday of a day's week
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// DaysBetween counts calendar days from a to b, ignoring daylight saving
+func DaysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
+
+// nextHorizon is how far ahead Next looks for an occurrence
+const nextHorizon = 366 * 24 * time.Hour
+
+// Next returns the occurrence of a window running at t, or else the first
+// starting after t within a year; nil when there is none
+func Next(w *models.MaintenanceWindow, t time.Time) (*Occurrence, error) {
+	occurrences, err := Occurrences(w, t, t.Add(nextHorizon))
+	if err != nil || len(occurrences) == 0 {
+		return nil, err
+	}
+	return &occurrences[0], nil
+}
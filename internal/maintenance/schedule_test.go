@@ -0,0 +1,237 @@
+package maintenance
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+func recurring(expr string, minutes int, startsAt time.Time) *models.MaintenanceWindow {
+	return &models.MaintenanceWindow{
+		StartsAt:        startsAt,
+		Recurrence:      &expr,
+		DurationMinutes: &minutes,
+		Timezone:        "UTC",
+	}
+}
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func occurrenceStarts(t *testing.T, w *models.MaintenanceWindow, from, to time.Time) []time.Time {
+	t.Helper()
+	occurrences, err := Occurrences(w, from, to)
+	if err != nil {
+		t.Fatalf("Occurrences: %v", err)
+	}
+	starts := make([]time.Time, len(occurrences))
+	for i, o := range occurrences {
+		starts[i] = o.Start.UTC()
+	}
+	return starts
+}
+
+func assertStarts(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d starts %v, want %d %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("start %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOneOffWindow(t *testing.T) {
+	end := utc(2026, 10, 4, 4, 0)
+	w := &models.MaintenanceWindow{StartsAt: utc(2026, 10, 4, 2, 0), EndsAt: &end}
+
+	occurrences, err := Occurrences(w, utc(2026, 10, 4, 3, 0), utc(2026, 10, 5, 0, 0))
+	if err != nil {
+		t.Fatalf("Occurrences: %v", err)
+	}
+	if len(occurrences) != 1 || !occurrences[0].Start.Equal(w.StartsAt) || !occurrences[0].End.Equal(end) {
+		t.Fatalf("occurrences = %v, want the window itself", occurrences)
+	}
+	if !occurrences[0].Contains(utc(2026, 10, 4, 3, 59)) || occurrences[0].Contains(end) {
+		t.Error("occurrence should contain its start but not its end")
+	}
+
+	if occurrences, _ := Occurrences(w, end, end.Add(time.Hour)); len(occurrences) != 0 {
+		t.Errorf("window ended, got %v", occurrences)
+	}
+}
+
+func TestCronWeekly(t *testing.T) {
+	w := recurring("0 2 * * 0", 120, utc(2026, 1, 1, 0, 0))
+
+	got := occurrenceStarts(t, w, utc(2026, 10, 1, 0, 0), utc(2026, 11, 1, 0, 0))
+	assertStarts(t, got, []time.Time{
+		utc(2026, 10, 4, 2, 0),
+		utc(2026, 10, 11, 2, 0),
+		utc(2026, 10, 18, 2, 0),
+		utc(2026, 10, 25, 2, 0),
+	})
+}
+
+func TestCronIncludesRunningOccurrence(t *testing.T) {
+	w := recurring("0 2 * * 0", 120, utc(2026, 1, 1, 0, 0))
+
+	got := occurrenceStarts(t, w, utc(2026, 10, 4, 3, 0), utc(2026, 10, 4, 5, 0))
+	assertStarts(t, got, []time.Time{utc(2026, 10, 4, 2, 0)})
+}
+
+func TestCronStepsAndRanges(t *testing.T) {
+	w := recurring("*/30 8-9 1,15 * *", 10, utc(2026, 1, 1, 0, 0))
+
+	got := occurrenceStarts(t, w, utc(2026, 10, 1, 0, 0), utc(2026, 10, 16, 0, 0))
+	assertStarts(t, got, []time.Time{
+		utc(2026, 10, 1, 8, 0), utc(2026, 10, 1, 8, 30), utc(2026, 10, 1, 9, 0), utc(2026, 10, 1, 9, 30),
+		utc(2026, 10, 15, 8, 0), utc(2026, 10, 15, 8, 30), utc(2026, 10, 15, 9, 0), utc(2026, 10, 15, 9, 30),
+	})
+}
+
+func TestCronTimezone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	w := recurring("30 1 * * *", 60, utc(2026, 1, 1, 0, 0))
+	w.Timezone = "Europe/Berlin"
+
+	got := occurrenceStarts(t, w, utc(2026, 10, 24, 12, 0), utc(2026, 10, 26, 12, 0))
+	assertStarts(t, got, []time.Time{
+		time.Date(2026, 10, 25, 1, 30, 0, 0, berlin).UTC(),
+		time.Date(2026, 10, 26, 1, 30, 0, 0, berlin).UTC(),
+	})
+	if got[1].Sub(got[0]) != 25*time.Hour {
+		t.Errorf("starts across the DST change %s apart, want 25h", got[1].Sub(got[0]))
+	}
+}
+
+func TestRRuleWeeklyByDay(t *testing.T) {
+	w := recurring("FREQ=WEEKLY;BYDAY=TU,TH;BYHOUR=3;BYMINUTE=0", 60, utc(2026, 1, 1, 0, 0))
+
+	got := occurrenceStarts(t, w, utc(2026, 10, 1, 0, 0), utc(2026, 10, 14, 0, 0))
+	assertStarts(t, got, []time.Time{
+		utc(2026, 10, 1, 3, 0),
+		utc(2026, 10, 6, 3, 0),
+		utc(2026, 10, 8, 3, 0),
+		utc(2026, 10, 13, 3, 0),
+	})
+}
+
+func TestRRuleMonthlyLastFriday(t *testing.T) {
+	w := recurring("RRULE:FREQ=MONTHLY;BYDAY=-1FR;BYHOUR=22;BYMINUTE=0", 240, utc(2026, 1, 1, 0, 0))
+
+	got := occurrenceStarts(t, w, utc(2026, 10, 1, 0, 0), utc(2027, 1, 1, 0, 0))
+	assertStarts(t, got, []time.Time{
+		utc(2026, 10, 30, 22, 0),
+		utc(2026, 11, 27, 22, 0),
+		utc(2026, 12, 25, 22, 0),
+	})
+}
+
+func TestRRuleCountAndUntil(t *testing.T) {
+	w := recurring("FREQ=DAILY;COUNT=3", 30, utc(2026, 10, 1, 4, 0))
+	got := occurrenceStarts(t, w, utc(2026, 10, 2, 0, 0), utc(2026, 11, 1, 0, 0))
+	assertStarts(t, got, []time.Time{utc(2026, 10, 2, 4, 0), utc(2026, 10, 3, 4, 0)})
+
+	w = recurring("FREQ=DAILY;UNTIL=20261003T040000Z", 30, utc(2026, 10, 1, 4, 0))
+	got = occurrenceStarts(t, w, utc(2026, 10, 1, 0, 0), utc(2026, 11, 1, 0, 0))
+	assertStarts(t, got, []time.Time{utc(2026, 10, 1, 4, 0), utc(2026, 10, 2, 4, 0), utc(2026, 10, 3, 4, 0)})
+}
+
+func TestRRuleHourlyInterval(t *testing.T) {
+	w := recurring("FREQ=HOURLY;INTERVAL=6", 15, utc(2026, 10, 1, 1, 0))
+
+	got := occurrenceStarts(t, w, utc(2026, 10, 1, 0, 0), utc(2026, 10, 2, 0, 0))
+	assertStarts(t, got, []time.Time{
+		utc(2026, 10, 1, 1, 0),
+		utc(2026, 10, 1, 7, 0),
+		utc(2026, 10, 1, 13, 0),
+		utc(2026, 10, 1, 19, 0),
+	})
+}
+
+func TestRecurrenceStopsAtEndsAt(t *testing.T) {
+	w := recurring("0 2 * * *", 60, utc(2026, 10, 1, 0, 0))
+	end := utc(2026, 10, 3, 12, 0)
+	w.EndsAt = &end
+
+	got := occurrenceStarts(t, w, utc(2026, 9, 1, 0, 0), utc(2026, 11, 1, 0, 0))
+	assertStarts(t, got, []time.Time{utc(2026, 10, 1, 2, 0), utc(2026, 10, 2, 2, 0), utc(2026, 10, 3, 2, 0)})
+}
+
+func TestNext(t *testing.T) {
+	w := recurring("0 2 * * 0", 120, utc(2026, 1, 1, 0, 0))
+
+	next, err := Next(w, utc(2026, 10, 4, 3, 0))
+	if err != nil || next == nil {
+		t.Fatalf("Next = %v, %v", next, err)
+	}
+	if !next.Start.Equal(utc(2026, 10, 4, 2, 0)) {
+		t.Errorf("running occurrence starts %s", next.Start)
+	}
+
+	next, _ = Next(w, utc(2026, 10, 4, 4, 0))
+	if next == nil || !next.Start.Equal(utc(2026, 10, 11, 2, 0)) {
+		t.Errorf("next occurrence = %v, want 2026-10-11 02:00", next)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	start := utc(2026, 10, 4, 2, 0)
+	before := start.Add(-time.Hour)
+	after := start.Add(time.Hour)
+	minutes := 60
+	zero := 0
+	bad := "FREQ=YEARLY"
+	cron := "0 2 * * 0"
+
+	tests := []struct {
+		name   string
+		window models.MaintenanceWindow
+		err    string
+	}{
+		{"one-off", models.MaintenanceWindow{StartsAt: start, EndsAt: &after}, ""},
+		{"recurring", models.MaintenanceWindow{StartsAt: start, Recurrence: &cron, DurationMinutes: &minutes}, ""},
+		{"missing start", models.MaintenanceWindow{EndsAt: &after}, "starts_at"},
+		{"end before start", models.MaintenanceWindow{StartsAt: start, EndsAt: &before}, "ends_at must be after"},
+		{"one-off without end", models.MaintenanceWindow{StartsAt: start}, "ends_at is required"},
+		{"one-off with duration", models.MaintenanceWindow{StartsAt: start, EndsAt: &after, DurationMinutes: &minutes}, "duration_minutes"},
+		{"recurring without duration", models.MaintenanceWindow{StartsAt: start, Recurrence: &cron, DurationMinutes: &zero}, "duration_minutes"},
+		{"unsupported frequency", models.MaintenanceWindow{StartsAt: start, Recurrence: &bad, DurationMinutes: &minutes}, "frequency"},
+		{"bad timezone", models.MaintenanceWindow{StartsAt: start, EndsAt: &after, Timezone: "Mars/Olympus"}, "Mars/Olympus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.window)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Validate error = %v, want one mentioning %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateCronExpressions(t *testing.T) {
+	minutes := 60
+	for _, expr := range []string{"0 2 * *", "60 * * * *", "0 2 * * 8", "a b c d e", "*/0 * * * *"} {
+		expr := expr
+		w := &models.MaintenanceWindow{StartsAt: utc(2026, 1, 1, 0, 0), Recurrence: &expr, DurationMinutes: &minutes}
+		if err := Validate(w); err == nil {
+			t.Errorf("Validate(%q) succeeded, want an error", expr)
+		}
+	}
+}
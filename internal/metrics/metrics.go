@@ -90,6 +90,82 @@ var (
 	)
 )
 
+// Batch writer metrics
+var (
+	// BatchWriterQueueDepth tracks the rows waiting to be buffered by the
+	// batch writer
+	BatchWriterQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "ispmonitor",
+			Name:      "batch_writer_queue_depth",
+			Help:      "Number of rows waiting in the batch writer queue",
+		},
+	)
+
+	// BatchWriterBlocked counts writes that waited for a full queue
+	BatchWriterBlocked = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "ispmonitor",
+			Name:      "batch_writer_blocked_total",
+			Help:      "Total number of writes that waited because the batch writer queue was full",
+		},
+	)
+
+	// BatchWriterFlushDuration tracks how long COPY flushes take
+	BatchWriterFlushDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "ispmonitor",
+			Name:      "batch_writer_flush_duration_seconds",
+			Help:      "Duration of batch writer flushes in seconds",
+			Buckets:   []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+		},
+		[]string{"table"},
+	)
+
+	// BatchWriterRowsWritten counts rows stored by the batch writer
+	BatchWriterRowsWritten = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ispmonitor",
+			Name:      "batch_writer_rows_written_total",
+			Help:      "Total number of rows stored by the batch writer",
+		},
+		[]string{"table"},
+	)
+
+	// BatchWriterRowsDropped counts rows of failed flushes
+	BatchWriterRowsDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ispmonitor",
+			Name:      "batch_writer_rows_dropped_total",
+			Help:      "Total number of rows dropped because their flush failed",
+		},
+		[]string{"table"},
+	)
+)
+
+// Result sink metrics
+var (
+	// SinkSamplesWritten counts samples sent to remote result sinks
+	SinkSamplesWritten = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ispmonitor",
+			Name:      "sink_samples_written_total",
+			Help:      "Total number of samples sent to result sinks",
+		},
+		[]string{"sink"},
+	)
+
+	// SinkSamplesDropped counts samples dropped on full queues or failed sends
+	SinkSamplesDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ispmonitor",
+			Name:      "sink_samples_dropped_total",
+			Help:      "Total number of samples dropped by result sinks",
+		},
+		[]string{"sink"},
+	)
+)
+
 // Router metrics
 var (
 	// RouterCount tracks the total number of routers
@@ -0,0 +1,116 @@
+package mib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// token is a lexical unit of a MIB module
+type token struct {
+	text   string
+	line   int
+	quoted bool // A string literal, text holds its contents
+}
+
+// tokenize splits ASN.1 source into identifiers, numbers, string literals
+// and punctuation, dropping comments. A comment runs from "--" to the next
+// "--" or the end of the line.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			i += 2
+			for i < len(src) && src[i] != '\n' {
+				if strings.HasPrefix(src[i:], "--") {
+					i += 2
+					break
+				}
+				i++
+			}
+		case c == '"':
+			start, startLine := i+1, line
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(src) {
+					return nil, fmt.Errorf("line %d: unterminated string", startLine)
+				}
+				if src[i] == '"' {
+					if i+1 < len(src) && src[i+1] == '"' {
+						b.WriteString(src[start : i+1])
+						i++
+						start = i + 1
+						continue
+					}
+					break
+				}
+				if src[i] == '\n' {
+					line++
+				}
+			}
+			b.WriteString(src[start:i])
+			tokens = append(tokens, token{text: b.String(), line: startLine, quoted: true})
+			i++
+		case c == '\'':
+			// Binary or hex string, e.g. '0A'H
+			end := strings.IndexByte(src[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated binary string", line)
+			}
+			end += i + 2
+			if end < len(src) && (src[end] == 'H' || src[end] == 'h' || src[end] == 'B' || src[end] == 'b') {
+				end++
+			}
+			tokens = append(tokens, token{text: src[i:end], line: line})
+			i = end
+		case strings.HasPrefix(src[i:], "::="):
+			tokens = append(tokens, token{text: "::=", line: line})
+			i += 3
+		case strings.HasPrefix(src[i:], ".."):
+			tokens = append(tokens, token{text: "..", line: line})
+			i += 2
+		case isWordByte(c):
+			start := i
+			for i < len(src) && isWordByte(src[i]) && !strings.HasPrefix(src[i:], "--") {
+				i++
+			}
+			tokens = append(tokens, token{text: src[start:i], line: line})
+		default:
+			tokens = append(tokens, token{text: string(c), line: line})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isUpper(s string) bool {
+	return s != "" && s[0] >= 'A' && s[0] <= 'Z'
+}
+
+func isLower(s string) bool {
+	return s != "" && s[0] >= 'a' && s[0] <= 'z'
+}
@@ -0,0 +1,253 @@
+package mib
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testTC = `
+ACME-TC DEFINITIONS ::= BEGIN
+IMPORTS
+    TEXTUAL-CONVENTION FROM SNMPv2-TC;
+
+AcmeState ::= TEXTUAL-CONVENTION
+    STATUS      current
+    DESCRIPTION "Operational state; SYNTAX in a description is not a clause."
+    SYNTAX      INTEGER { ok(1), degraded(2), failed(3) }
+
+AcmeAlias ::= AcmeState
+END
+`
+
+const testMIB = `
+-- A vendor MIB in the usual layout
+ACME-MIB DEFINITIONS ::= BEGIN
+
+IMPORTS
+    MODULE-IDENTITY, OBJECT-TYPE, NOTIFICATION-TYPE,
+    Integer32, enterprises          FROM SNMPv2-SMI
+    DisplayString                   FROM SNMPv2-TC
+    AcmeAlias                       FROM ACME-TC;
+
+acme MODULE-IDENTITY
+    LAST-UPDATED "202601010000Z"
+    ORGANIZATION "Acme"
+    CONTACT-INFO "noc@example.com"
+    DESCRIPTION  "The Acme
+                  enterprise MIB."
+    REVISION     "202601010000Z"
+    DESCRIPTION  "Initial revision."
+    ::= { enterprises 99999 }
+
+acmeObjects OBJECT IDENTIFIER ::= { acme 1 }
+acmeTraps   OBJECT IDENTIFIER ::= { acme 0 }
+
+acmePsuTable OBJECT-TYPE
+    SYNTAX      SEQUENCE OF AcmePsuEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Power supplies."
+    ::= { acmeObjects 2 }
+
+acmePsuEntry OBJECT-TYPE
+    SYNTAX      AcmePsuEntry
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "A power supply."
+    INDEX       { acmePsuIndex }
+    ::= { acmePsuTable 1 }
+
+AcmePsuEntry ::= SEQUENCE {
+    acmePsuIndex  Integer32,
+    acmePsuState  AcmeAlias,
+    acmePsuDescr  DisplayString
+}
+
+acmePsuIndex OBJECT-TYPE
+    SYNTAX      Integer32 (1..64)
+    MAX-ACCESS  not-accessible
+    STATUS      current
+    DESCRIPTION "Index."
+    ::= { acmePsuEntry 1 }
+
+acmePsuState OBJECT-TYPE
+    SYNTAX      AcmeAlias
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "State."
+    ::= { acmePsuEntry 2 }
+
+acmePsuTemp OBJECT-TYPE
+    SYNTAX      Integer32
+    UNITS       "degrees Celsius"
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Temperature."
+    DEFVAL      { 0 }
+    ::= { acmePsuEntry 3 }
+
+acmePsuFailed NOTIFICATION-TYPE
+    OBJECTS     { acmePsuState }
+    STATUS      current
+    DESCRIPTION "A power supply failed."
+    ::= { acmeTraps 1 }
+
+acmeOrphan OBJECT IDENTIFIER ::= { acmeMissing 1 }
+
+END
+`
+
+const testV1MIB = `
+ACME-V1-MIB DEFINITIONS ::= BEGIN
+IMPORTS enterprises FROM RFC1155-SMI
+        TRAP-TYPE FROM RFC-1215;
+
+acmeV1 OBJECT IDENTIFIER ::= { enterprises 99998 }
+
+acmeFanFailure TRAP-TYPE
+    ENTERPRISE  acmeV1
+    VARIABLES   { acmeFanIndex }
+    DESCRIPTION "A fan failed."
+    ::= 3
+
+acmeFanIndex OBJECT-TYPE
+    SYNTAX  INTEGER
+    ACCESS  read-only
+    STATUS  mandatory
+    ::= { acmeV1 1 1 }
+
+acmeState OBJECT-TYPE
+    SYNTAX  INTEGER { on(1), off(2) }
+    ACCESS  read-only
+    STATUS  mandatory
+    ::= { iso org(3) dod(6) 1 4 1 99998 2 }
+END
+`
+
+func loadTest(t *testing.T) *Tree {
+	t.Helper()
+	tree, err := LoadFS(fstest.MapFS{
+		"ACME-TC.txt":     {Data: []byte(testTC)},
+		"ACME-MIB.my":     {Data: []byte(testMIB)},
+		"ACME-V1-MIB":     {Data: []byte(testV1MIB)},
+		"BROKEN-MIB.txt":  {Data: []byte("BROKEN-MIB DEFINITIONS ::= BEGIN\nx OBJECT IDENTIFIER ::= { enterprises 1 }\n")},
+		".hidden-MIB.txt": {Data: []byte("not a MIB")},
+	})
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	return tree
+}
+
+func TestLoad(t *testing.T) {
+	tree := loadTest(t)
+
+	var names []string
+	for _, m := range tree.Modules() {
+		names = append(names, m.Name)
+	}
+	if got := strings.Join(names, ","); got != "ACME-MIB,ACME-TC,ACME-V1-MIB" {
+		t.Errorf("modules = %s", got)
+	}
+
+	warnings := strings.Join(tree.Warnings(), "\n")
+	for _, want := range []string{"BROKEN-MIB.txt", "ACME-MIB::acmeOrphan: unknown parent acmeMissing"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings %q do not mention %q", warnings, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tree := loadTest(t)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"acme", "1.3.6.1.4.1.99999"},
+		{"ACME-MIB::acmePsuState", "1.3.6.1.4.1.99999.1.2.1.2"},
+		{"acmePsuTemp.4", "1.3.6.1.4.1.99999.1.2.1.3.4"},
+		{"acmePsuFailed", "1.3.6.1.4.1.99999.0.1"},
+		{"acmeFanFailure", "1.3.6.1.4.1.99998.0.3"},
+		{"acmeState", "1.3.6.1.4.1.99998.2"},
+		{"ifDescr", ""},
+		{"enterprises", "1.3.6.1.4.1"},
+		{".1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.1.5.0"},
+		{"ACME-V1-MIB::acmePsuState", ""},
+		{"acmePsuTemp.x", ""},
+	}
+	for _, tt := range tests {
+		got, err := tree.Resolve(tt.name)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("Resolve(%s) = %s, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%s) = %s, %v, want %s", tt.name, got, err, tt.want)
+		}
+	}
+
+	var nilTree *Tree
+	if oid, err := nilTree.Resolve("1.3.6.1"); err != nil || oid != "1.3.6.1" {
+		t.Errorf("nil tree should pass numeric OIDs through, got %s, %v", oid, err)
+	}
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	tree, _ := LoadFS(fstest.MapFS{
+		"A": {Data: []byte("A-MIB DEFINITIONS ::= BEGIN\nstate OBJECT IDENTIFIER ::= { enterprises 1 }\nEND\n")},
+		"B": {Data: []byte("B-MIB DEFINITIONS ::= BEGIN\nstate OBJECT IDENTIFIER ::= { enterprises 2 }\nEND\n")},
+	})
+	if _, err := tree.Resolve("state"); err == nil || !strings.Contains(err.Error(), "A-MIB::state, B-MIB::state") {
+		t.Errorf("Resolve error = %v, want the qualified names", err)
+	}
+	if oid, err := tree.Resolve("B-MIB::state"); err != nil || oid != "1.3.6.1.4.1.2" {
+		t.Errorf("Resolve(B-MIB::state) = %s, %v", oid, err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tree := loadTest(t)
+
+	n, suffix := tree.Lookup(".1.3.6.1.4.1.99999.1.2.1.2.7")
+	if n == nil || n.Name != "acmePsuState" || suffix != "7" {
+		t.Fatalf("Lookup = %+v, %q", n, suffix)
+	}
+	if n.Syntax != "AcmeAlias" || n.Access != "read-only" || n.Status != "current" {
+		t.Errorf("clauses = %q %q %q", n.Syntax, n.Access, n.Status)
+	}
+	if label, ok := n.EnumName(3); !ok || label != "failed" {
+		t.Errorf("EnumName(3) = %q, want the textual convention's label", label)
+	}
+
+	entry, _ := tree.Lookup("1.3.6.1.4.1.99999.1.2.1")
+	if strings.Join(entry.Index, ",") != "acmePsuIndex" {
+		t.Errorf("index = %v", entry.Index)
+	}
+	table, _ := tree.Lookup("1.3.6.1.4.1.99999.1.2")
+	if table.Syntax != "SEQUENCE OF AcmePsuEntry" {
+		t.Errorf("table syntax = %q", table.Syntax)
+	}
+	temp, _ := tree.Lookup("1.3.6.1.4.1.99999.1.2.1.3")
+	if temp.Units != "degrees Celsius" || temp.Syntax != "Integer32" {
+		t.Errorf("temperature = %+v", temp)
+	}
+	module, _ := tree.Lookup("1.3.6.1.4.1.99999")
+	if module.Kind != "MODULE-IDENTITY" || module.Description != "The Acme enterprise MIB." {
+		t.Errorf("module identity = %+v", module)
+	}
+
+	if got := tree.Format("1.3.6.1.4.1.99998.0.3"); got != "ACME-V1-MIB::acmeFanFailure" {
+		t.Errorf("Format = %s", got)
+	}
+	if got := tree.Format("1.3.6.1.4.1.12345.1"); got != "SNMPv2-SMI::enterprises.12345.1" {
+		t.Errorf("Format = %s", got)
+	}
+	if got := tree.Format("2.999"); got != "SNMPv2-SMI::joint-iso-ccitt.999" {
+		t.Errorf("Format = %s", got)
+	}
+}
@@ -0,0 +1,412 @@
+package mib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// module is a parsed MIB module with its definitions not yet resolved
+type module struct {
+	name    string
+	file    string
+	imports map[string]string // Imported name to the module it is imported from
+	nodes   []*Node
+	types   map[string]*typeDef
+}
+
+// typeDef is a type assignment, e.g. a textual convention
+type typeDef struct {
+	syntax string
+	enums  []Enum
+}
+
+// macros are the SMI macros whose invocations assign an OID
+var macros = map[string]bool{
+	"MODULE-IDENTITY":    true,
+	"OBJECT-IDENTITY":    true,
+	"OBJECT-TYPE":        true,
+	"NOTIFICATION-TYPE":  true,
+	"TRAP-TYPE":          true, // SMIv1 (RFC 1215)
+	"OBJECT-GROUP":       true,
+	"NOTIFICATION-GROUP": true,
+	"MODULE-COMPLIANCE":  true,
+	"AGENT-CAPABILITIES": true,
+}
+
+// clauses are the keywords that start a clause of a macro invocation
+var clauses = map[string]bool{
+	"SYNTAX": true, "UNITS": true, "MAX-ACCESS": true, "ACCESS": true, "MIN-ACCESS": true,
+	"STATUS": true, "DESCRIPTION": true, "REFERENCE": true, "INDEX": true, "AUGMENTS": true,
+	"DEFVAL": true, "DISPLAY-HINT": true, "OBJECTS": true, "ENTERPRISE": true, "VARIABLES": true,
+	"LAST-UPDATED": true, "ORGANIZATION": true, "CONTACT-INFO": true, "REVISION": true,
+	"MODULE": true, "MANDATORY-GROUPS": true, "GROUP": true, "OBJECT": true, "WRITE-SYNTAX": true,
+	"NOTIFICATIONS": true, "PRODUCT-RELEASE": true, "SUPPORTS": true, "INCLUDES": true,
+	"VARIATION": true, "CREATION-REQUIRES": true,
+}
+
+// parser walks the tokens of one file
+type parser struct {
+	tokens []token
+	pos    int
+	file   string
+}
+
+// parse reads the modules of a MIB file. Only what is needed to name OIDs
+// and describe objects is kept; other assignments are skipped.
+func parse(file, src string) ([]*module, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, file: file}
+
+	var modules []*module
+	for p.pos < len(p.tokens) {
+		m, err := p.module()
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			modules = append(modules, m)
+		}
+	}
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no module definition found")
+	}
+	return modules, nil
+}
+
+func (p *parser) peek(offset int) string {
+	if p.pos+offset < len(p.tokens) {
+		t := p.tokens[p.pos+offset]
+		if t.quoted {
+			return "\"" // Never matches a keyword or name
+		}
+		return t.text
+	}
+	return ""
+}
+
+func (p *parser) next() token {
+	if p.pos < len(p.tokens) {
+		t := p.tokens[p.pos]
+		p.pos++
+		return t
+	}
+	return token{}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// module reads "Name DEFINITIONS ::= BEGIN ... END"
+func (p *parser) module() (*module, error) {
+	// Find the next module header
+	for p.pos < len(p.tokens) && p.peek(1) != "DEFINITIONS" {
+		p.pos++
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, nil
+	}
+
+	m := &module{
+		name:    p.next().text,
+		file:    p.file,
+		imports: make(map[string]string),
+		types:   make(map[string]*typeDef),
+	}
+	for p.pos < len(p.tokens) && p.peek(0) != "BEGIN" {
+		p.pos++
+	}
+	p.pos++
+
+	for {
+		if p.pos >= len(p.tokens) {
+			return nil, p.errorf("module %s has no END", m.name)
+		}
+		t := p.peek(0)
+
+		switch {
+		case t == "END":
+			p.pos++
+			return m, nil
+		case t == "IMPORTS":
+			p.pos++
+			p.imports(m)
+		case t == "EXPORTS":
+			p.skipTo(";")
+		case p.peek(1) == "MACRO":
+			p.skipTo("END")
+		case t == "{" || t == "(":
+			p.skipBlock()
+		case isLower(t) && p.peek(1) == "OBJECT" && p.peek(2) == "IDENTIFIER" && p.peek(3) == "::=":
+			node := &Node{Name: t, Module: m.name, Kind: KindObjectIdentifier}
+			p.pos += 4
+			if err := p.oidValue(node); err != nil {
+				return nil, err
+			}
+			m.nodes = append(m.nodes, node)
+		case isLower(t) && macros[p.peek(1)]:
+			node := &Node{Name: t, Module: m.name, Kind: p.peek(1)}
+			p.pos += 2
+			if err := p.macro(node); err != nil {
+				return nil, err
+			}
+			m.nodes = append(m.nodes, node)
+		case isUpper(t) && p.peek(1) == "::=":
+			p.pos += 2
+			m.types[t] = p.typeAssignment()
+		default:
+			p.pos++
+		}
+	}
+}
+
+// imports reads "a, b FROM M1 c FROM M2 ;"
+func (p *parser) imports(m *module) {
+	var names []string
+	for p.pos < len(p.tokens) {
+		t := p.next().text
+		switch t {
+		case ";":
+			return
+		case ",":
+		case "FROM":
+			from := p.next().text
+			for _, name := range names {
+				m.imports[name] = from
+			}
+			names = names[:0]
+		default:
+			names = append(names, t)
+		}
+	}
+}
+
+// macro reads the clauses of a macro invocation and its value
+func (p *parser) macro(node *Node) error {
+	var enterprise string
+	for p.pos < len(p.tokens) && p.peek(0) != "::=" {
+		switch p.peek(0) {
+		case "SYNTAX":
+			p.pos++
+			syntax, enums := p.syntax()
+			if node.Kind == "OBJECT-TYPE" {
+				// Compliance statements refine the syntax of other objects
+				node.Syntax, node.Enums = syntax, enums
+			}
+		case "MAX-ACCESS", "ACCESS":
+			p.pos++
+			node.Access = p.next().text
+		case "STATUS":
+			p.pos++
+			node.Status = p.next().text
+		case "UNITS":
+			p.pos++
+			node.Units = p.next().text
+		case "DESCRIPTION":
+			p.pos++
+			// Later descriptions are those of revisions or compliance groups
+			if text := p.next().text; node.Description == "" {
+				node.Description = normalizeText(text)
+			}
+		case "ENTERPRISE":
+			p.pos++
+			enterprise = p.next().text
+		case "INDEX":
+			p.pos++
+			node.Index = p.nameList()
+		case "AUGMENTS":
+			p.pos++
+			if augments := p.nameList(); len(augments) > 0 {
+				node.Augments = augments[0]
+			}
+		case "{", "(":
+			p.skipBlock()
+		default:
+			p.pos++
+		}
+	}
+	p.pos++ // ::=
+
+	if node.Kind == "TRAP-TYPE" {
+		// RFC 3584 section 3.1: enterprise.0.specific-trap
+		number := p.next().text
+		if enterprise == "" || !isNumber(number) {
+			return p.errorf("trap %s needs an ENTERPRISE and a number", node.Name)
+		}
+		node.parent = enterprise
+		node.subIDs = []uint32{0, parseSubID(number)}
+		return nil
+	}
+	return p.oidValue(node)
+}
+
+// oidValue reads "{ parent 1 2 }", "{ iso org(3) 6 }" or "{ 1 3 6 }"
+func (p *parser) oidValue(node *Node) error {
+	if p.peek(0) != "{" {
+		return p.errorf("expected { in the value of %s", node.Name)
+	}
+	p.pos++
+
+	first := true
+	for p.pos < len(p.tokens) && p.peek(0) != "}" {
+		t := p.next().text
+		switch {
+		case isNumber(t):
+			node.subIDs = append(node.subIDs, parseSubID(t))
+		case p.peek(0) == "(" && isNumber(p.peek(1)) && p.peek(2) == ")":
+			// name(number): the number counts, the name is documentation
+			node.subIDs = append(node.subIDs, parseSubID(p.peek(1)))
+			p.pos += 3
+		case first:
+			node.parent = t
+		default:
+			return p.errorf("unexpected %q in the value of %s", t, node.Name)
+		}
+		first = false
+	}
+	p.pos++ // }
+
+	if node.parent == "" && len(node.subIDs) == 0 {
+		return p.errorf("empty value of %s", node.Name)
+	}
+	return nil
+}
+
+// typeAssignment reads the right side of "Name ::= ..."
+func (p *parser) typeAssignment() *typeDef {
+	if p.peek(0) == "TEXTUAL-CONVENTION" {
+		p.pos++
+		for p.pos < len(p.tokens) && p.peek(0) != "SYNTAX" {
+			if p.peek(0) == "{" || p.peek(0) == "(" {
+				p.skipBlock()
+				continue
+			}
+			p.pos++
+		}
+		p.pos++
+	}
+	syntax, enums := p.syntax()
+	return &typeDef{syntax: syntax, enums: enums}
+}
+
+// syntax reads a type such as "INTEGER { up(1), down(2) }",
+// "OCTET STRING (SIZE (0..255))" or "SEQUENCE OF IfEntry". Constraints are
+// dropped. A type is at most three words and ends with its braces or
+// parentheses, so the name of the next assignment is not consumed.
+func (p *parser) syntax() (string, []Enum) {
+	if p.peek(0) == "[" {
+		p.skipTo("]")
+	}
+	if p.peek(0) == "IMPLICIT" {
+		p.pos++
+	}
+	if p.peek(0) == "OBJECT" && p.peek(1) == "IDENTIFIER" {
+		p.pos += 2
+		return "OBJECT IDENTIFIER", nil
+	}
+
+	var words []string
+	var enums []Enum
+	for len(words) < 3 && p.pos < len(p.tokens) {
+		t := p.peek(0)
+		if !isUpper(t) || t == "END" || clauses[t] || macros[t] || p.peek(1) == "::=" || p.peek(1) == "MACRO" {
+			break
+		}
+		words = append(words, t)
+		p.pos++
+
+		switch p.peek(0) {
+		case "{":
+			if t == "INTEGER" || t == "BITS" {
+				enums = p.enums()
+			} else {
+				p.skipBlock()
+			}
+			return strings.Join(words, " "), enums
+		case "(":
+			p.skipBlock()
+			return strings.Join(words, " "), nil
+		}
+	}
+	return strings.Join(words, " "), nil
+}
+
+// enums reads "{ up(1), down(2) }"
+func (p *parser) enums() []Enum {
+	p.pos++ // {
+	var enums []Enum
+	for p.pos < len(p.tokens) && p.peek(0) != "}" {
+		if isLower(p.peek(0)) && p.peek(1) == "(" && p.peek(3) == ")" {
+			if v, err := strconv.ParseInt(p.peek(2), 10, 64); err == nil {
+				enums = append(enums, Enum{Value: v, Name: p.peek(0)})
+			}
+			p.pos += 4
+			continue
+		}
+		p.pos++
+	}
+	p.pos++ // }
+	return enums
+}
+
+// nameList reads "{ a, IMPLIED b }"
+func (p *parser) nameList() []string {
+	if p.peek(0) != "{" {
+		return nil
+	}
+	p.pos++
+	var names []string
+	for p.pos < len(p.tokens) && p.peek(0) != "}" {
+		t := p.next().text
+		if t != "," && t != "IMPLIED" {
+			names = append(names, t)
+		}
+	}
+	p.pos++
+	return names
+}
+
+// skipBlock skips a balanced {...} or (...) group
+func (p *parser) skipBlock() {
+	depth := 0
+	for p.pos < len(p.tokens) {
+		switch p.next().text {
+		case "{", "(":
+			depth++
+		case "}", ")":
+			depth--
+		}
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+// skipTo skips past the next token equal to text
+func (p *parser) skipTo(text string) {
+	for p.pos < len(p.tokens) {
+		if t := p.next(); !t.quoted && t.text == text {
+			return
+		}
+	}
+}
+
+func parseSubID(s string) uint32 {
+	v, _ := strconv.ParseUint(s, 10, 32)
+	return uint32(v)
+}
+
+// normalizeText collapses the indentation of a description
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,414 @@
+// Package mib loads SMIv1/SMIv2 MIB modules and resolves their object names
+// to OIDs and back. Only what is needed to name and describe objects is
+// read: OID assignments, object syntax and enumerations, access, units,
+// status, description and table indexes. Modules whose parents cannot be
+// resolved are kept as far as they can be and the rest is reported as
+// warnings, so one broken file does not hide the others.
+package mib
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KindObjectIdentifier is the kind of a plain "OBJECT IDENTIFIER" assignment;
+// other nodes have the name of the macro that defines them, e.g. OBJECT-TYPE
+const KindObjectIdentifier = "OBJECT IDENTIFIER"
+
+// Node is a named OID
+type Node struct {
+	Name        string
+	Module      string
+	OID         string
+	Kind        string
+	Syntax      string // Textual convention or base type, e.g. "InterfaceIndex" or "INTEGER"
+	Access      string
+	Units       string
+	Status      string
+	Description string
+	Enums       []Enum   // Labels of INTEGER or BITS values, including those of the textual convention
+	Index       []string // Index objects of a table entry
+	Augments    string   // Entry whose index a table entry shares
+
+	parent string   // Parent name as written in the module
+	subIDs []uint32 // Sub-identifiers below parent
+}
+
+// Enum labels an integer value
+type Enum struct {
+	Value int64
+	Name  string
+}
+
+// Module summarizes a loaded module
+type Module struct {
+	Name    string
+	File    string
+	Objects int
+}
+
+// wellKnown are the nodes of the ASN.1 root and SNMPv2-SMI, which most
+// modules import without the SMI files being installed
+var wellKnown = []struct{ name, oid string }{
+	{"ccitt", "0"},
+	{"iso", "1"},
+	{"joint-iso-ccitt", "2"},
+	{"zeroDotZero", "0.0"},
+	{"org", "1.3"},
+	{"dod", "1.3.6"},
+	{"internet", "1.3.6.1"},
+	{"directory", "1.3.6.1.1"},
+	{"mgmt", "1.3.6.1.2"},
+	{"mib-2", "1.3.6.1.2.1"},
+	{"transmission", "1.3.6.1.2.1.10"},
+	{"experimental", "1.3.6.1.3"},
+	{"private", "1.3.6.1.4"},
+	{"enterprises", "1.3.6.1.4.1"},
+	{"security", "1.3.6.1.5"},
+	{"snmpV2", "1.3.6.1.6"},
+	{"snmpDomains", "1.3.6.1.6.1"},
+	{"snmpProxys", "1.3.6.1.6.2"},
+	{"snmpModules", "1.3.6.1.6.3"},
+}
+
+const smiModule = "SNMPv2-SMI"
+
+// Tree holds the resolved nodes of the loaded modules. A nil Tree resolves
+// numeric OIDs only.
+type Tree struct {
+	byOID    map[string]*Node
+	byName   map[string][]*Node
+	modules  map[string]*module
+	warnings []string
+}
+
+// Load reads every regular, non-hidden file of dir as MIB source. An empty
+// dir yields a tree of the well-known SMI nodes only. Files that fail to
+// parse and objects that cannot be placed are reported by Warnings.
+func Load(dir string) (*Tree, error) {
+	if dir == "" {
+		return build(nil, nil), nil
+	}
+	return LoadFS(os.DirFS(dir))
+}
+
+// LoadFS reads the MIB files at the root of fsys
+func LoadFS(fsys fs.FS) (*Tree, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MIB files: %w", err)
+	}
+
+	var modules []*module
+	var warnings []string
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(".", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MIB file %s: %w", entry.Name(), err)
+		}
+		parsed, err := parse(entry.Name(), string(data))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", entry.Name(), err))
+			continue
+		}
+		for _, m := range parsed {
+			if file, ok := seen[m.name]; ok {
+				warnings = append(warnings, fmt.Sprintf("%s: module %s already loaded from %s", entry.Name(), m.name, file))
+				continue
+			}
+			seen[m.name] = entry.Name()
+			modules = append(modules, m)
+		}
+	}
+
+	return build(modules, warnings), nil
+}
+
+// build resolves the OIDs of the modules' nodes
+func build(modules []*module, warnings []string) *Tree {
+	t := &Tree{
+		byOID:    make(map[string]*Node),
+		byName:   make(map[string][]*Node),
+		modules:  make(map[string]*module, len(modules)),
+		warnings: warnings,
+	}
+	for _, m := range modules {
+		t.modules[m.name] = m
+	}
+
+	// Without SNMPv2-SMI installed its nodes are provided
+	builtin := make(map[string]string, len(wellKnown))
+	for _, wk := range wellKnown {
+		builtin[wk.name] = wk.oid
+		if _, ok := t.modules[smiModule]; !ok {
+			t.add(&Node{Name: wk.name, Module: smiModule, OID: wk.oid, Kind: KindObjectIdentifier})
+		}
+	}
+
+	r := &resolver{tree: t, builtin: builtin, defs: make(map[string]map[string]*Node), byName: make(map[string][]*Node)}
+	for _, m := range modules {
+		defs := make(map[string]*Node, len(m.nodes))
+		for _, n := range m.nodes {
+			defs[n.Name] = n
+			r.byName[n.Name] = append(r.byName[n.Name], n)
+		}
+		r.defs[m.name] = defs
+	}
+
+	names := make([]string, 0, len(modules))
+	for _, m := range modules {
+		names = append(names, m.name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := t.modules[name]
+		for _, n := range m.nodes {
+			if err := r.resolve(n, 0); err != nil {
+				t.warnings = append(t.warnings, fmt.Sprintf("%s::%s: %v", m.name, n.Name, err))
+				continue
+			}
+			if len(n.Enums) == 0 {
+				n.Enums = t.typeEnums(m, n.Syntax)
+			}
+			t.add(n)
+		}
+	}
+	return t
+}
+
+// add indexes a node. An OID defined by several modules, as objects
+// redefined by SMIv2 versions of SMIv1 modules are, keeps its first
+// definition for Lookup; all of them are resolvable by name.
+func (t *Tree) add(n *Node) {
+	if _, ok := t.byOID[n.OID]; !ok {
+		t.byOID[n.OID] = n
+	}
+	t.byName[n.Name] = append(t.byName[n.Name], n)
+}
+
+// typeEnums returns the enumeration of a textual convention, following
+// conventions defined in terms of others
+func (t *Tree) typeEnums(m *module, syntax string) []Enum {
+	for depth := 0; depth < 8 && m != nil && isUpper(syntax); depth++ {
+		def, ok := m.types[syntax]
+		if !ok {
+			from, imported := m.imports[syntax]
+			if !imported {
+				return nil
+			}
+			m = t.modules[from]
+			continue
+		}
+		if len(def.enums) > 0 {
+			return def.enums
+		}
+		syntax = def.syntax
+	}
+	return nil
+}
+
+// resolver places nodes under their parents
+type resolver struct {
+	tree    *Tree
+	builtin map[string]string
+	defs    map[string]map[string]*Node // Module to name to node
+	byName  map[string][]*Node
+}
+
+func (r *resolver) resolve(n *Node, depth int) error {
+	if n.OID != "" {
+		return nil
+	}
+	if depth > 128 {
+		return fmt.Errorf("parent chain too deep or circular")
+	}
+
+	prefix := ""
+	if n.parent != "" {
+		oid, err := r.parentOID(n, depth)
+		if err != nil {
+			return err
+		}
+		prefix = oid
+	}
+
+	parts := make([]string, 0, len(n.subIDs)+1)
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	for _, id := range n.subIDs {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	n.OID = strings.Join(parts, ".")
+	return nil
+}
+
+// parentOID finds the parent in the node's module, then in the module it is
+// imported from, then among the SMI nodes and last by a name unique across
+// the loaded modules
+func (r *resolver) parentOID(n *Node, depth int) (string, error) {
+	name := n.parent
+	candidates := []*Node{r.defs[n.Module][name]}
+	if m := r.tree.modules[n.Module]; m != nil {
+		if from, ok := m.imports[name]; ok {
+			candidates = append(candidates, r.defs[from][name])
+		}
+	}
+	for _, parent := range candidates {
+		if parent != nil {
+			if err := r.resolve(parent, depth+1); err != nil {
+				return "", err
+			}
+			return parent.OID, nil
+		}
+	}
+
+	if oid, ok := r.builtin[name]; ok {
+		return oid, nil
+	}
+	if nodes := r.byName[name]; len(nodes) == 1 {
+		if err := r.resolve(nodes[0], depth+1); err != nil {
+			return "", err
+		}
+		return nodes[0].OID, nil
+	}
+	return "", fmt.Errorf("unknown parent %s", name)
+}
+
+// Resolve returns the OID of a name. Names may be qualified by module
+// ("IF-MIB::ifDescr") and followed by an instance suffix ("ifDescr.3").
+// Numeric OIDs are returned as is, without a leading dot.
+func (t *Tree) Resolve(name string) (string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), ".")
+	if name == "" {
+		return "", fmt.Errorf("empty OID")
+	}
+	if isNumericOID(name) {
+		return name, nil
+	}
+
+	moduleName, object, qualified := strings.Cut(name, "::")
+	if !qualified {
+		object, moduleName = moduleName, ""
+	}
+	object, suffix, _ := strings.Cut(object, ".")
+	if suffix != "" && !isNumericOID(suffix) {
+		return "", fmt.Errorf("invalid instance suffix %q in %s", suffix, name)
+	}
+
+	var matches []*Node
+	if t != nil {
+		for _, n := range t.byName[object] {
+			if moduleName == "" || n.Module == moduleName {
+				matches = append(matches, n)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("unknown MIB object %s", name)
+	}
+	oid := matches[0].OID
+	for _, n := range matches[1:] {
+		if n.OID != oid {
+			return "", fmt.Errorf("MIB object %s is ambiguous, qualify it as one of %s", object, qualify(matches))
+		}
+	}
+
+	if suffix != "" {
+		oid += "." + suffix
+	}
+	return oid, nil
+}
+
+// Lookup returns the node with the longest OID prefixing oid and the
+// remaining instance suffix, or nil
+func (t *Tree) Lookup(oid string) (*Node, string) {
+	if t == nil {
+		return nil, ""
+	}
+	oid = strings.TrimPrefix(strings.TrimSpace(oid), ".")
+	for prefix := oid; prefix != ""; {
+		if n, ok := t.byOID[prefix]; ok {
+			return n, strings.TrimPrefix(strings.TrimPrefix(oid, prefix), ".")
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return nil, ""
+}
+
+// Format renders an OID as MODULE::name.suffix, or returns it unchanged
+// when no loaded node prefixes it
+func (t *Tree) Format(oid string) string {
+	n, suffix := t.Lookup(oid)
+	if n == nil {
+		return strings.TrimPrefix(oid, ".")
+	}
+	name := n.Module + "::" + n.Name
+	if suffix != "" {
+		name += "." + suffix
+	}
+	return name
+}
+
+// Modules returns the loaded modules, sorted by name
+func (t *Tree) Modules() []Module {
+	if t == nil {
+		return nil
+	}
+	modules := make([]Module, 0, len(t.modules))
+	for _, m := range t.modules {
+		modules = append(modules, Module{Name: m.name, File: m.file, Objects: len(m.nodes)})
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+	return modules
+}
+
+// Warnings returns the problems found while loading
+func (t *Tree) Warnings() []string {
+	if t == nil {
+		return nil
+	}
+	return t.warnings
+}
+
+// EnumName returns the label of an enumerated value
+func (n *Node) EnumName(value int64) (string, bool) {
+	for _, e := range n.Enums {
+		if e.Value == value {
+			return e.Name, true
+		}
+	}
+	return "", false
+}
+
+func isNumericOID(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if !isNumber(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func qualify(nodes []*Node) string {
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		names = append(names, n.Module+"::"+n.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
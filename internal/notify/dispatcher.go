@@ -0,0 +1,272 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+const (
+	// batchSize is how many alerts are routed, and deliveries claimed, at
+	// a time
+	batchSize = 100
+
+	// workers is how many deliveries are sent concurrently
+	workers = 8
+
+	// claimLease is how long a claimed delivery is held before another
+	// dispatcher may send it, e.g. after a crash mid-send
+	claimLease = 5 * time.Minute
+
+	// retentionInterval is how often old deliveries are removed
+	retentionInterval = time.Hour
+	retentionBatch    = 5000
+)
+
+// Dispatcher routes new and resolved alerts to the channels of matching
+// routes, pages the tiers of escalation policies until an alert is
+// acknowledged or resolved, and sends the queued deliveries, retrying
+// failures with exponential backoff. Dispatchers of several instances may
+// run side by side; each alert is routed, each tier paged and each
+// delivery sent once.
+type Dispatcher struct {
+	repo        repository.NotificationRepository
+	escalations repository.EscalationRepository
+	oncall      repository.OnCallRepository
+	users       repository.UserRepository
+	secrets     *vault.Vault
+	config      config.NotifyConfig
+	client      *http.Client
+}
+
+// NewDispatcher creates a new notification dispatcher
+func NewDispatcher(db *database.DB, cfg config.NotifyConfig, secrets *vault.Vault) *Dispatcher {
+	return &Dispatcher{
+		repo:        postgres.NewNotificationRepo(db.DB),
+		escalations: postgres.NewEscalationRepo(db.DB),
+		oncall:      postgres.NewOnCallRepo(db.DB),
+		users:       postgres.NewUserRepo(db.DB),
+		secrets:     secrets,
+		config:      cfg,
+		client:      &http.Client{},
+	}
+}
+
+// Start routes and sends notifications periodically until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	interval := time.Duration(d.config.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	log.Printf("Notification dispatcher started (every %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	retention := time.NewTicker(retentionInterval)
+	defer retention.Stop()
+
+	d.applyRetention(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Notification dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.Run(ctx)
+		case <-retention.C:
+			d.applyRetention(ctx)
+		}
+	}
+}
+
+// Run routes the alerts waiting to be routed, pages the escalation tiers
+// that are due, then sends the deliveries that are due
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		queued, err := d.repo.QueueDeliveries(ctx, batchSize)
+		if err != nil {
+			log.Printf("Error routing alert notifications: %v", err)
+			return
+		}
+		if queued < batchSize {
+			break
+		}
+	}
+
+	d.escalate(ctx)
+
+	for ctx.Err() == nil {
+		jobs, err := d.repo.ClaimDeliveries(ctx, batchSize, claimLease)
+		if err != nil {
+			log.Printf("Error claiming notification deliveries: %v", err)
+			return
+		}
+
+		queue := make(chan *repository.NotificationJob)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for job := range queue {
+					d.deliver(ctx, job)
+				}
+			}()
+		}
+		for _, job := range jobs {
+			queue <- job
+		}
+		close(queue)
+		wg.Wait()
+
+		if len(jobs) < batchSize {
+			return
+		}
+	}
+}
+
+// Send renders an event with a channel's templates and sends it, returning
+// the send error
+func (d *Dispatcher) Send(ctx context.Context, channel *models.NotificationChannel, ev Event, deliveryID int64) error {
+	secret := ""
+	if channel.Secret != nil {
+		plaintext, err := d.secrets.Decrypt(*channel.Secret)
+		if err != nil {
+			return err
+		}
+		secret = plaintext
+	}
+
+	sender, err := New(channel, secret, d.client)
+	if err != nil {
+		return err
+	}
+	templates, err := ParseTemplates(channel.TitleTemplate, channel.BodyTemplate)
+	if err != nil {
+		return err
+	}
+	msg, err := templates.Render(ev)
+	if err != nil {
+		return err
+	}
+	msg.DeliveryID = deliveryID
+
+	timeout := time.Duration(d.config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sender.Send(sendCtx, msg)
+}
+
+// deliver makes one send attempt of a claimed delivery and records its
+// outcome, scheduling a retry or giving up after the last attempt
+func (d *Dispatcher) deliver(ctx context.Context, job *repository.NotificationJob) {
+	delivery := job.Delivery
+
+	if delivery.Event == models.NotificationEscalation && job.Alert != nil {
+		// Paging stops once the alert is handled, even mid-retry
+		reason := ""
+		switch {
+		case job.Alert.AcknowledgedAt != nil:
+			reason = "alert acknowledged"
+		case job.Alert.Status == "resolved":
+			reason = "alert resolved"
+		}
+		if reason != "" {
+			delivery.Status = models.DeliveryCancelled
+			delivery.NextAttemptAt = nil
+			delivery.LastError = &reason
+			if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+				log.Printf("Error recording notification delivery %d: %v", delivery.ID, err)
+			}
+			return
+		}
+	}
+
+	var err error
+	if !job.Channel.Enabled {
+		err = errChannelDisabled
+	} else {
+		err = d.Send(ctx, job.Channel, NewEvent(job), delivery.ID)
+	}
+	if ctx.Err() != nil {
+		// Shutting down: the lease expires and another attempt is made
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	if err == nil {
+		delivery.Status = models.DeliverySent
+		delivery.SentAt = &now
+		delivery.NextAttemptAt = nil
+		delivery.LastError = nil
+	} else {
+		msg := err.Error()
+		delivery.LastError = &msg
+		if err == errChannelDisabled || delivery.Attempts >= d.config.MaxAttempts {
+			delivery.Status = models.DeliveryFailed
+			delivery.NextAttemptAt = nil
+			log.Printf("Notification %d to channel %s failed after %d attempts: %v",
+				delivery.ID, job.Channel.Name, delivery.Attempts, err)
+		} else {
+			next := now.Add(Backoff(delivery.Attempts,
+				time.Duration(d.config.RetrySeconds)*time.Second,
+				time.Duration(d.config.MaxRetrySeconds)*time.Second))
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("Error recording notification delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// Backoff returns the delay before the retry following a failed attempt:
+// base after the first, doubling per attempt up to max
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// applyRetention deletes finished deliveries older than the retention period
+func (d *Dispatcher) applyRetention(ctx context.Context) {
+	if d.config.RetentionDays <= 0 {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -d.config.RetentionDays)
+	var total int64
+	for {
+		deleted, err := d.repo.DeleteDeliveriesBefore(ctx, cutoff, retentionBatch)
+		if err != nil {
+			log.Printf("Error applying notification retention: %v", err)
+			return
+		}
+		total += deleted
+		if deleted < retentionBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Notification retention removed %d deliveries older than %d days", total, d.config.RetentionDays)
+	}
+}
@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP transport security modes
+const (
+	TLSAuto     = ""         // STARTTLS when the server offers it
+	TLSStartTLS = "starttls" // STARTTLS required
+	TLSImplicit = "tls"      // TLS from connect, e.g. port 465
+	TLSNone     = "none"
+)
+
+// EmailConfig is the config of an email channel; the secret is the SMTP
+// password, used with Username
+type EmailConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"` // 587 by default, 465 with implicit TLS
+	TLS      string   `json:"tls"`
+	Username string   `json:"username"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+type emailSender struct {
+	config   EmailConfig
+	password string
+}
+
+func newEmailSender(config, secret string) (*emailSender, error) {
+	s := &emailSender{password: secret}
+	if err := decodeConfig(config, &s.config); err != nil {
+		return nil, err
+	}
+
+	c := &s.config
+	if c.Host == "" {
+		return nil, fmt.Errorf("config: host is required")
+	}
+	switch c.TLS {
+	case TLSAuto, TLSStartTLS, TLSNone:
+		if c.Port == 0 {
+			c.Port = 587
+		}
+	case TLSImplicit:
+		if c.Port == 0 {
+			c.Port = 465
+		}
+	default:
+		return nil, fmt.Errorf("config: unknown tls mode %q", c.TLS)
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return nil, fmt.Errorf("config: invalid port %d", c.Port)
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return nil, fmt.Errorf("config: invalid from address %q", c.From)
+	}
+	if len(c.To) == 0 {
+		return nil, fmt.Errorf("config: at least one to address is required")
+	}
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("config: invalid to address %q", to)
+		}
+	}
+	if c.Username != "" && secret == "" {
+		return nil, fmt.Errorf("a password is required with username")
+	}
+	return s, nil
+}
+
+// Send delivers the message over SMTP. Credentials are only sent over TLS
+// or to a local server.
+func (s *emailSender) Send(ctx context.Context, msg *Message) error {
+	c := s.config
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if c.TLS == TLSImplicit {
+		conn = tls.Client(conn, &tls.Config{ServerName: c.Host})
+	}
+
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if c.TLS == TLSAuto || c.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: c.Host}); err != nil {
+				return err
+			}
+		} else if c.TLS == TLSStartTLS {
+			return fmt.Errorf("server does not offer STARTTLS")
+		}
+	}
+
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, s.password, c.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(c.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range recipients(c.To, msg) {
+		rcpt, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.compose(msg, time.Now())); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// recipients returns the addresses a message is sent to: the user an
+// escalation pages, or else the channel's
+func recipients(to []string, msg *Message) []string {
+	if r := msg.Event.Recipient; r != nil && r.Email != "" {
+		if _, err := mail.ParseAddress(r.Email); err == nil {
+			return []string{r.Email}
+		}
+	}
+	return to
+}
+
+// compose builds a plain text RFC 5322 message
+func (s *emailSender) compose(msg *Message, now time.Time) []byte {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+
+	header("From", s.config.From)
+	header("To", strings.Join(recipients(s.config.To, msg), ", "))
+	// Line breaks in a rendered title would end the header
+	subject := strings.Join(strings.Fields(msg.Title), " ")
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "quoted-printable")
+	header("X-ISPMonitor-Event", msg.Event.Kind)
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	_, _ = qp.Write([]byte(msg.Body))
+	_ = qp.Close()
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
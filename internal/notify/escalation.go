@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/oncall"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// escalate pages the due tiers of running escalations, queueing a delivery
+// per target, and schedules their next tier
+func (d *Dispatcher) escalate(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := d.escalations.ClaimEscalations(ctx, batchSize, claimLease)
+		if err != nil {
+			log.Printf("Error claiming alert escalations: %v", err)
+			return
+		}
+
+		p := &pager{dispatcher: d, now: time.Now()}
+		for _, job := range jobs {
+			d.page(ctx, p, job)
+		}
+
+		if len(jobs) < batchSize {
+			return
+		}
+	}
+}
+
+// page pages the next tier of one escalation, or stops it when the alert
+// no longer needs paging
+func (d *Dispatcher) page(ctx context.Context, p *pager, job *repository.EscalationJob) {
+	e, alert, policy := job.Escalation, job.Alert, job.Policy
+
+	reason := ""
+	switch {
+	case alert.AcknowledgedAt != nil:
+		reason = models.EscalationAcknowledged
+	case alert.Status == "resolved":
+		reason = models.EscalationResolved
+	case !policy.Enabled:
+		reason = models.EscalationDisabled
+	}
+
+	tiers, err := oncall.ParseTiers(policy.Tiers, policy.RepeatCount)
+	if reason == "" && err != nil {
+		log.Printf("Escalation policy %s is invalid: %v", policy.Name, err)
+		reason = models.EscalationExhausted
+	}
+	if reason == "" && e.Tier >= len(tiers) {
+		// The policy lost tiers since the escalation reached this one
+		reason = models.EscalationExhausted
+	}
+	if reason != "" {
+		if err := d.escalations.StopEscalation(ctx, e.AlertID, e.PolicyID, reason); err != nil {
+			log.Printf("Error stopping escalation of alert %s: %v", e.AlertID, err)
+		}
+		return
+	}
+
+	tier := e.Tier + 1
+	var deliveries []*models.NotificationDelivery
+	for _, target := range tiers[e.Tier].Targets {
+		recipientID, ok := p.resolve(ctx, e.TenantID, target)
+		if !ok {
+			continue
+		}
+		deliveries = append(deliveries, &models.NotificationDelivery{
+			TenantID:      e.TenantID,
+			ChannelID:     target.ChannelID,
+			AlertID:       &alert.ID,
+			Event:         models.NotificationEscalation,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &p.now,
+			Tier:          &tier,
+			RecipientID:   recipientID,
+		})
+	}
+
+	next := *e
+	step := oncall.Next(tiers, policy.RepeatCount, e.Tier, e.Repeats, p.now)
+	if step.Done {
+		stopReason := models.EscalationExhausted
+		next.StoppedAt = &p.now
+		next.StopReason = &stopReason
+		next.NextEscalationAt = nil
+	} else {
+		next.Tier = step.Tier
+		next.Repeats = step.Repeats
+		next.NextEscalationAt = &step.At
+	}
+
+	advanced, err := d.escalations.AdvanceEscalation(ctx, &next, e.Tier, e.Repeats, deliveries)
+	if err != nil {
+		log.Printf("Error escalating alert %s under policy %s: %v", alert.ID, policy.Name, err)
+		return
+	}
+	if advanced && len(deliveries) == 0 {
+		log.Printf("Escalation tier %d of policy %s paged nobody for alert %s", tier, policy.Name, alert.ID)
+	}
+}
+
+// pager resolves escalation targets during one escalation pass, looking
+// each channel, user and schedule up once
+type pager struct {
+	dispatcher *Dispatcher
+	now        time.Time
+	channels   map[uuid.UUID]bool
+	users      map[uuid.UUID]bool
+	onCall     map[uuid.UUID]*uuid.UUID
+}
+
+// resolve returns the user a target pages, nil for the channel itself;
+// false when the target cannot be paged: its channel or user is gone or
+// nobody is on call for its schedule
+func (p *pager) resolve(ctx context.Context, tenantID uuid.UUID, target oncall.Target) (*uuid.UUID, bool) {
+	if !p.channelExists(ctx, tenantID, target.ChannelID) {
+		return nil, false
+	}
+
+	switch {
+	case target.UserID != nil:
+		if !p.userExists(ctx, tenantID, *target.UserID) {
+			return nil, false
+		}
+		return target.UserID, true
+	case target.ScheduleID != nil:
+		userID := p.userOnCall(ctx, tenantID, *target.ScheduleID)
+		if userID == nil || !p.userExists(ctx, tenantID, *userID) {
+			return nil, false
+		}
+		return userID, true
+	default:
+		return nil, true
+	}
+}
+
+func (p *pager) channelExists(ctx context.Context, tenantID, channelID uuid.UUID) bool {
+	if p.channels == nil {
+		p.channels = make(map[uuid.UUID]bool)
+	}
+	exists, ok := p.channels[channelID]
+	if !ok {
+		_, err := p.dispatcher.repo.GetChannel(ctx, tenantID, channelID)
+		exists = err == nil
+		if !exists {
+			log.Printf("Escalation channel %s unavailable: %v", channelID, err)
+		}
+		p.channels[channelID] = exists
+	}
+	return exists
+}
+
+func (p *pager) userExists(ctx context.Context, tenantID, userID uuid.UUID) bool {
+	if p.users == nil {
+		p.users = make(map[uuid.UUID]bool)
+	}
+	exists, ok := p.users[userID]
+	if !ok {
+		user, err := p.dispatcher.users.GetByID(ctx, userID)
+		exists = err == nil && user.TenantID == tenantID
+		if !exists {
+			log.Printf("Escalation user %s unavailable", userID)
+		}
+		p.users[userID] = exists
+	}
+	return exists
+}
+
+func (p *pager) userOnCall(ctx context.Context, tenantID, scheduleID uuid.UUID) *uuid.UUID {
+	if p.onCall == nil {
+		p.onCall = make(map[uuid.UUID]*uuid.UUID)
+	}
+	if userID, ok := p.onCall[scheduleID]; ok {
+		return userID
+	}
+
+	userID, err := OnCall(ctx, p.dispatcher.oncall, tenantID, scheduleID, p.now)
+	if err != nil {
+		log.Printf("Error resolving on-call schedule %s: %v", scheduleID, err)
+	} else if userID == nil {
+		log.Printf("Nobody is on call for schedule %s", scheduleID)
+	}
+	p.onCall[scheduleID] = userID
+	return userID
+}
+
+// OnCall returns the user on call for a schedule at t; nil when nobody is
+func OnCall(ctx context.Context, repo repository.OnCallRepository, tenantID, scheduleID uuid.UUID, t time.Time) (*uuid.UUID, error) {
+	schedule, err := repo.GetSchedule(ctx, tenantID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := repo.ListOverrides(ctx, tenantID, scheduleID, t, t.Add(time.Second))
+	if err != nil {
+		return nil, err
+	}
+	sched, err := oncall.New(schedule, overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, ok := sched.OnCall(t)
+	if !ok {
+		return nil, nil
+	}
+	return &userID, nil
+}
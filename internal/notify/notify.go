@@ -0,0 +1,172 @@
+// Package notify sends alert notifications to per-tenant channels: SMTP
+// email, signed JSON webhooks, Slack/Mattermost incoming webhooks and
+// Telegram bots. The Dispatcher routes alerts to channels, pages the tiers
+// of escalation policies and sends them from the delivery log, retrying
+// failed sends with backoff.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Event is an alert notification, the data message templates are executed
+// with
+type Event struct {
+	Kind        string // firing, resolved, test or escalation
+	AlertID     uuid.UUID
+	TenantID    uuid.UUID
+	Name        string
+	Description string
+	Severity    string
+	Status      string
+	TargetType  string
+	TargetID    *uuid.UUID
+	RouterID    *uuid.UUID // Router the alert targets, directly or through an interface
+	RouterName  string
+	TriggeredAt time.Time
+	ResolvedAt  *time.Time
+	Metadata    map[string]interface{}
+	Tier        int        // Escalation tier paged, from 1; zero for other events
+	Recipient   *Recipient // User paged by an escalation, if any
+}
+
+// Recipient is a user an escalation pages through a channel. Email
+// channels send to the user's address instead of their own.
+type Recipient struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+// Message is an event rendered for a channel
+type Message struct {
+	Event      Event
+	Title      string
+	Body       string
+	DeliveryID int64 // Zero for test sends
+}
+
+// Sender sends messages to one channel
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewEvent returns the event of a claimed delivery
+func NewEvent(job *repository.NotificationJob) Event {
+	a := job.Alert
+	ev := Event{
+		Kind:        job.Delivery.Event,
+		AlertID:     a.ID,
+		TenantID:    a.TenantID,
+		Name:        a.Name,
+		Severity:    a.Severity,
+		Status:      a.Status,
+		TargetID:    a.TargetID,
+		RouterID:    job.RouterID,
+		RouterName:  job.RouterName,
+		TriggeredAt: a.TriggeredAt,
+		ResolvedAt:  a.ResolvedAt,
+	}
+	if a.Description != nil {
+		ev.Description = *a.Description
+	}
+	if a.TargetType != nil {
+		ev.TargetType = *a.TargetType
+	}
+	if a.Metadata != nil {
+		_ = json.Unmarshal([]byte(*a.Metadata), &ev.Metadata)
+	}
+	if job.Delivery.Tier != nil {
+		ev.Tier = *job.Delivery.Tier
+	}
+	if u := job.Recipient; u != nil {
+		ev.Recipient = &Recipient{ID: u.ID, Name: UserName(u), Email: u.Email}
+	}
+	return ev
+}
+
+// UserName returns the full name of a user, or the email address of a user
+// without one
+func UserName(u *models.User) string {
+	var parts []string
+	for _, part := range []*string{u.FirstName, u.LastName} {
+		if part != nil && strings.TrimSpace(*part) != "" {
+			parts = append(parts, strings.TrimSpace(*part))
+		}
+	}
+	if len(parts) == 0 {
+		return u.Email
+	}
+	return strings.Join(parts, " ")
+}
+
+// TestEvent returns the event of a test send to a channel
+func TestEvent(channel *models.NotificationChannel, at time.Time) Event {
+	return Event{
+		Kind:        models.NotificationTest,
+		TenantID:    channel.TenantID,
+		Name:        "Test notification",
+		Description: fmt.Sprintf("Test notification sent to channel %s", channel.Name),
+		Severity:    models.SeverityInfo,
+		Status:      "active",
+		TriggeredAt: at,
+	}
+}
+
+// New returns the sender of a channel given its decrypted secret. Requests
+// are made with client.
+func New(channel *models.NotificationChannel, secret string, client *http.Client) (Sender, error) {
+	config := channel.Config
+	if config == "" {
+		config = "{}"
+	}
+
+	switch channel.ChannelType {
+	case models.ChannelEmail:
+		return newEmailSender(config, secret)
+	case models.ChannelWebhook:
+		return newWebhookSender(config, secret, client)
+	case models.ChannelSlack:
+		return newSlackSender(config, secret, client)
+	case models.ChannelTelegram:
+		return newTelegramSender(config, secret, client)
+	default:
+		return nil, fmt.Errorf("unknown channel_type %q", channel.ChannelType)
+	}
+}
+
+// Validate checks a channel's config, secret and templates
+func Validate(channel *models.NotificationChannel, secret string) error {
+	if _, err := New(channel, secret, http.DefaultClient); err != nil {
+		return err
+	}
+	_, err := ParseTemplates(channel.TitleTemplate, channel.BodyTemplate)
+	return err
+}
+
+// decodeConfig decodes a channel config, rejecting unknown fields
+func decodeConfig(config string, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(config))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("config: trailing data")
+	}
+	return nil
+}
+
+// errChannelDisabled fails the deliveries queued for a channel that has
+// since been disabled
+var errChannelDisabled = errors.New("channel is disabled")
@@ -0,0 +1,338 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+func testEvent() Event {
+	routerID := uuid.New()
+	return Event{
+		Kind:        models.NotificationFiring,
+		AlertID:     uuid.New(),
+		Name:        "CPU high",
+		Description: "cpu_percent 97 > 90",
+		Severity:    models.SeverityCritical,
+		Status:      "active",
+		RouterID:    &routerID,
+		RouterName:  "core-1",
+		TriggeredAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func render(t *testing.T, title, body *string, ev Event) *Message {
+	t.Helper()
+	templates, err := ParseTemplates(title, body)
+	if err != nil {
+		t.Fatalf("ParseTemplates: %v", err)
+	}
+	msg, err := templates.Render(ev)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return msg
+}
+
+func channel(channelType, config string) *models.NotificationChannel {
+	return &models.NotificationChannel{ID: uuid.New(), Name: "ops", ChannelType: channelType, Config: config, Enabled: true}
+}
+
+func newSender(t *testing.T, c *models.NotificationChannel, secret string) Sender {
+	t.Helper()
+	s, err := New(c, secret, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+func TestTemplates(t *testing.T) {
+	ev := testEvent()
+	msg := render(t, nil, nil, ev)
+	if msg.Title != "[CRITICAL] CPU high on core-1" {
+		t.Errorf("default title = %q", msg.Title)
+	}
+	if !strings.Contains(msg.Body, "cpu_percent 97 > 90") || !strings.Contains(msg.Body, "Router: core-1") ||
+		!strings.Contains(msg.Body, ev.AlertID.String()) {
+		t.Errorf("default body = %q", msg.Body)
+	}
+
+	ev.Kind = models.NotificationResolved
+	resolvedAt := ev.TriggeredAt.Add(time.Hour)
+	ev.ResolvedAt = &resolvedAt
+	msg = render(t, nil, nil, ev)
+	if msg.Title != "[RESOLVED] CPU high on core-1" || !strings.Contains(msg.Body, "Resolved: 2026-01-01 13:00:00 UTC") {
+		t.Errorf("resolved message = %q / %q", msg.Title, msg.Body)
+	}
+
+	title, body := `{{lower .Severity}}: {{.Name}}`, `{{.RouterName}} since {{.TriggeredAt.Format "15:04"}}`
+	msg = render(t, &title, &body, testEvent())
+	if msg.Title != "critical: CPU high" || msg.Body != "core-1 since 12:00" {
+		t.Errorf("custom message = %q / %q", msg.Title, msg.Body)
+	}
+
+	bad := `{{.Nmae}}`
+	templates, err := ParseTemplates(&bad, nil)
+	if err != nil {
+		t.Fatalf("ParseTemplates: %v", err)
+	}
+	if _, err := templates.Render(testEvent()); err == nil {
+		t.Error("Render with an unknown field should fail")
+	}
+	unclosed := `{{if .Name}}`
+	if _, err := ParseTemplates(nil, &unclosed); err == nil || !strings.Contains(err.Error(), "body_template") {
+		t.Errorf("ParseTemplates error = %v, want a body_template error", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel *models.NotificationChannel
+		secret  string
+		wantErr string
+	}{
+		{"email", channel(models.ChannelEmail, `{"host": "smtp.example.com", "from": "noc@example.com", "to": ["ops@example.com"]}`), "", ""},
+		{"email without to", channel(models.ChannelEmail, `{"host": "smtp.example.com", "from": "noc@example.com"}`), "", "to address"},
+		{"email username without password", channel(models.ChannelEmail, `{"host": "smtp.example.com", "username": "noc", "from": "noc@example.com", "to": ["ops@example.com"]}`), "", "password"},
+		{"email tls mode", channel(models.ChannelEmail, `{"host": "smtp.example.com", "tls": "ssl", "from": "noc@example.com", "to": ["ops@example.com"]}`), "", "tls mode"},
+		{"webhook", channel(models.ChannelWebhook, `{"url": "https://example.com/hook"}`), "key", ""},
+		{"webhook url", channel(models.ChannelWebhook, `{"url": "example.com/hook"}`), "", "http or https"},
+		{"webhook signature header", channel(models.ChannelWebhook, `{"url": "https://example.com/hook", "headers": {"x-ispmonitor-signature": "x"}}`), "", "set by the webhook"},
+		{"unknown field", channel(models.ChannelWebhook, `{"url": "https://example.com/hook", "secret": "x"}`), "", "unknown field"},
+		{"slack", channel(models.ChannelSlack, `{"username": "monitor"}`), "https://hooks.slack.com/services/T/B/x", ""},
+		{"slack without url", channel(models.ChannelSlack, ``), "", "incoming webhook URL"},
+		{"telegram", channel(models.ChannelTelegram, `{"chat_id": "-100123"}`), "123:abc", ""},
+		{"telegram without chat", channel(models.ChannelTelegram, `{}`), "123:abc", "chat_id"},
+		{"telegram without token", channel(models.ChannelTelegram, `{"chat_id": "1"}`), "", "bot token"},
+		{"unknown type", channel("pager", `{}`), "", "unknown channel_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.channel, tt.secret)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	c := channel(models.ChannelWebhook, `{"url": "`+srv.URL+`/hook", "headers": {"X-Team": "noc"}}`)
+	ev := testEvent()
+	msg := render(t, nil, nil, ev)
+	msg.DeliveryID = 42
+	if err := newSender(t, c, "s3cret").Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	timestamp := got.Header.Get(HeaderTimestamp)
+	if want := "sha256=" + Sign("s3cret", timestamp, body); got.Header.Get(HeaderSignature) != want {
+		t.Errorf("signature = %q, want %q", got.Header.Get(HeaderSignature), want)
+	}
+	if got.Header.Get(HeaderDelivery) != "42" || got.Header.Get(HeaderEvent) != "firing" || got.Header.Get("X-Team") != "noc" {
+		t.Errorf("headers = %v", got.Header)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.Event != "firing" || payload.Title != msg.Title || payload.Alert.ID == nil || *payload.Alert.ID != ev.AlertID ||
+		payload.Alert.RouterName != "core-1" {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	// Unsigned without a key; errors carry the response
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	})
+	err := newSender(t, c, "").Send(context.Background(), msg)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401: bad token") {
+		t.Errorf("Send error = %v, want the 401 response", err)
+	}
+	if got.Header.Get(HeaderSignature) != "" {
+		t.Error("request without a key should not be signed")
+	}
+}
+
+func TestSlack(t *testing.T) {
+	var payload slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+
+	ev := testEvent()
+	ev.Kind = models.NotificationResolved
+	msg := render(t, nil, nil, ev)
+	c := channel(models.ChannelSlack, `{"channel": "#noc"}`)
+	if err := newSender(t, c, srv.URL+"/hooks/abc").Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if payload.Channel != "#noc" || payload.Text != msg.Title || len(payload.Attachments) != 1 ||
+		payload.Attachments[0].Color != slackColors["resolved"] || payload.Attachments[0].Text != msg.Body {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	var path string
+	var payload telegramMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		if payload.ChatID == "0" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ok": false, "description": "Bad Request: chat not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	msg := render(t, nil, nil, testEvent())
+	c := channel(models.ChannelTelegram, `{"chat_id": "-100123", "api_url": "`+srv.URL+`"}`)
+	if err := newSender(t, c, "123:abc").Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if path != "/bot123:abc/sendMessage" || payload.ChatID != "-100123" || payload.Text != msg.Title+"\n\n"+msg.Body {
+		t.Errorf("request = %s %+v", path, payload)
+	}
+
+	c = channel(models.ChannelTelegram, `{"chat_id": "0", "api_url": "`+srv.URL+`"}`)
+	err := newSender(t, c, "123:abc").Send(context.Background(), msg)
+	if err == nil || err.Error() != "telegram: Bad Request: chat not found" {
+		t.Errorf("Send error = %v, want the API description", err)
+	}
+}
+
+// smtpStandIn accepts one SMTP session and returns what it received
+func smtpStandIn(t *testing.T) (addr string, session <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	done := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				done <- lines
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				reply("235 2.7.0 Authentication successful")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				for {
+					data, err := r.ReadString('\n')
+					if err != nil {
+						done <- lines
+						return
+					}
+					data = strings.TrimRight(data, "\r\n")
+					if data == "." {
+						break
+					}
+					lines = append(lines, data)
+				}
+				reply("250 2.0.0 Ok: queued")
+			case "QUIT":
+				reply("221 2.0.0 Bye")
+				done <- lines
+				return
+			default:
+				reply("250 2.1.0 Ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), done
+}
+
+func TestEmail(t *testing.T) {
+	addr, session := smtpStandIn(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	c := channel(models.ChannelEmail, `{"host": "`+host+`", "port": `+port+`, "username": "noc",
+		"from": "Monitor <noc@example.com>", "to": ["ops@example.com", "oncall@example.com"]}`)
+	msg := render(t, nil, nil, testEvent())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := newSender(t, c, "pw").Send(ctx, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	lines := <-session
+	text := strings.Join(lines, "\n")
+	auth := base64.StdEncoding.EncodeToString([]byte("\x00noc\x00pw"))
+	for _, want := range []string{
+		"AUTH PLAIN " + auth,
+		"MAIL FROM:<noc@example.com>",
+		"RCPT TO:<ops@example.com>",
+		"RCPT TO:<oncall@example.com>",
+		"To: ops@example.com, oncall@example.com",
+		"Subject: [CRITICAL] CPU high on core-1",
+		"Router: core-1",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("session lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 30*time.Second, 5*time.Minute
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := Backoff(i+1, base, max); got != w {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
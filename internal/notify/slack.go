@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// Attachment colors of Slack messages by severity, and for resolutions
+var slackColors = map[string]string{
+	models.SeverityCritical: "#d32f2f",
+	models.SeverityWarning:  "#f9a825",
+	models.SeverityInfo:     "#1976d2",
+	"resolved":              "#2e7d32",
+}
+
+// SlackConfig is the config of a Slack or Mattermost incoming webhook
+// channel; the secret is the webhook URL. Empty fields keep the webhook's
+// own settings.
+type SlackConfig struct {
+	Channel   string `json:"channel"`
+	Username  string `json:"username"`
+	IconEmoji string `json:"icon_emoji"`
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Fallback string `json:"fallback"`
+	Color    string `json:"color,omitempty"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+}
+
+type slackSender struct {
+	config SlackConfig
+	url    string
+	client *http.Client
+}
+
+func newSlackSender(config, secret string, client *http.Client) (*slackSender, error) {
+	s := &slackSender{url: secret, client: client}
+	if err := decodeConfig(config, &s.config); err != nil {
+		return nil, err
+	}
+	if err := checkURL(secret); err != nil {
+		return nil, fmt.Errorf("the secret must be the incoming webhook URL: %v", err)
+	}
+	return s, nil
+}
+
+// Send posts the message as a colored attachment, which both Slack and
+// Mattermost render
+func (s *slackSender) Send(ctx context.Context, msg *Message) error {
+	color := slackColors[msg.Event.Severity]
+	if msg.Event.Kind == models.NotificationResolved {
+		color = slackColors["resolved"]
+	}
+
+	body, err := json.Marshal(slackMessage{
+		Text:      msg.Title,
+		Channel:   s.config.Channel,
+		Username:  s.config.Username,
+		IconEmoji: s.config.IconEmoji,
+		Attachments: []slackAttachment{{
+			Fallback: msg.Title,
+			Color:    color,
+			Title:    msg.Title,
+			Text:     msg.Body,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = postJSON(ctx, s.client, s.url, nil, body)
+	return err
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultTelegramAPI is the Bot API base URL used without api_url
+	DefaultTelegramAPI = "https://api.telegram.org"
+
+	// maxTelegramText is the longest text of a Telegram message
+	maxTelegramText = 4096
+)
+
+// TelegramConfig is the config of a Telegram bot channel; the secret is
+// the bot token
+type TelegramConfig struct {
+	ChatID    string `json:"chat_id"`    // Numeric ID or @channelusername
+	ParseMode string `json:"parse_mode"` // Empty for plain text, HTML or MarkdownV2
+	APIURL    string `json:"api_url"`    // Bot API server, e.g. a local one
+}
+
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+type telegramSender struct {
+	config TelegramConfig
+	token  string
+	client *http.Client
+}
+
+func newTelegramSender(config, secret string, client *http.Client) (*telegramSender, error) {
+	s := &telegramSender{token: secret, client: client}
+	if err := decodeConfig(config, &s.config); err != nil {
+		return nil, err
+	}
+	if s.config.ChatID == "" {
+		return nil, fmt.Errorf("config: chat_id is required")
+	}
+	switch s.config.ParseMode {
+	case "", "HTML", "MarkdownV2":
+	default:
+		return nil, fmt.Errorf("config: unknown parse_mode %q", s.config.ParseMode)
+	}
+	if s.config.APIURL == "" {
+		s.config.APIURL = DefaultTelegramAPI
+	}
+	if err := checkURL(s.config.APIURL); err != nil {
+		return nil, fmt.Errorf("config: api_url: %v", err)
+	}
+	if secret == "" || strings.ContainsAny(secret, "/?# ") {
+		return nil, fmt.Errorf("the secret must be the bot token")
+	}
+	return s, nil
+}
+
+// Send sends the title and body as one message with sendMessage
+func (s *telegramSender) Send(ctx context.Context, msg *Message) error {
+	text := msg.Title
+	if msg.Body != "" {
+		text += "\n\n" + msg.Body
+	}
+	if runes := []rune(text); len(runes) > maxTelegramText {
+		text = string(runes[:maxTelegramText-1]) + "…"
+	}
+
+	body, err := json.Marshal(telegramMessage{
+		ChatID:                s.config.ChatID,
+		Text:                  text,
+		ParseMode:             s.config.ParseMode,
+		DisableWebPagePreview: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimRight(s.config.APIURL, "/") + "/bot" + s.token + "/sendMessage"
+	respBody, err := postJSON(ctx, s.client, endpoint, nil, body)
+
+	// The Bot API explains failures in the description of its response
+	var resp telegramResponse
+	if jsonErr := json.Unmarshal(respBody, &resp); jsonErr == nil && !resp.OK && resp.Description != "" {
+		return fmt.Errorf("telegram: %s", resp.Description)
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Default message templates, used when a channel has none
+const (
+	DefaultTitleTemplate = `{{if eq .Kind "resolved"}}[RESOLVED]{{else}}[{{upper .Severity}}]{{end}} {{.Name}}{{with .RouterName}} on {{.}}{{end}}`
+
+	DefaultBodyTemplate = `{{with .Description}}{{.}}
+{{end}}Severity: {{.Severity}}
+{{- with .RouterName}}
+Router: {{.}}{{end}}
+Triggered: {{.TriggeredAt.Format "2006-01-02 15:04:05 MST"}}
+{{- with .ResolvedAt}}
+Resolved: {{.Format "2006-01-02 15:04:05 MST"}}{{end}}
+{{- if .Tier}}
+Escalation tier: {{.Tier}}{{end}}
+{{- with .Recipient}}
+On call: {{.Name}}{{end}}
+{{- if ne .Kind "test"}}
+Alert: {{.AlertID}}{{end}}`
+)
+
+// templateFuncs are the functions available to message templates
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Templates render the title and body of a channel's messages
+type Templates struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// ParseTemplates parses a channel's title and body templates; nil or empty
+// ones use the defaults
+func ParseTemplates(title, body *string) (*Templates, error) {
+	t := &Templates{}
+	var err error
+	if t.title, err = parseTemplate("title", title, DefaultTitleTemplate); err != nil {
+		return nil, err
+	}
+	if t.body, err = parseTemplate("body", body, DefaultBodyTemplate); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render executes the templates with an event
+func (t *Templates) Render(ev Event) (*Message, error) {
+	var title, body strings.Builder
+	if err := t.title.Execute(&title, ev); err != nil {
+		return nil, fmt.Errorf("title template: %v", err)
+	}
+	if err := t.body.Execute(&body, ev); err != nil {
+		return nil, fmt.Errorf("body template: %v", err)
+	}
+	return &Message{
+		Event: ev,
+		Title: strings.TrimSpace(title.String()),
+		Body:  strings.TrimSpace(body.String()),
+	}, nil
+}
+
+func parseTemplate(name string, text *string, fallback string) (*template.Template, error) {
+	source := fallback
+	if text != nil && *text != "" {
+		source = *text
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s_template: %v", name, err)
+	}
+	return tmpl, nil
+}
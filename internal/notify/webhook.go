@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook request headers. The signature is the hex HMAC-SHA256, keyed
+// with the channel secret, of the timestamp header, a dot and the body.
+const (
+	HeaderEvent     = "X-ISPMonitor-Event"
+	HeaderDelivery  = "X-ISPMonitor-Delivery"
+	HeaderTimestamp = "X-ISPMonitor-Timestamp"
+	HeaderSignature = "X-ISPMonitor-Signature"
+)
+
+// maxErrorBody is how much of an error response is kept for the delivery log
+const maxErrorBody = 512
+
+// WebhookConfig is the config of a generic webhook channel; the secret is
+// the HMAC key requests are signed with, if any
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"` // Added to every request
+}
+
+// WebhookPayload is the JSON body of a webhook request
+type WebhookPayload struct {
+	Event     string       `json:"event"` // firing, resolved, test or escalation
+	Title     string       `json:"title"`
+	Message   string       `json:"message"`
+	Alert     WebhookAlert `json:"alert"`
+	Tier      int          `json:"tier,omitempty"`      // Escalation tier, from 1
+	Recipient *Recipient   `json:"recipient,omitempty"` // User paged by an escalation
+	SentAt    time.Time    `json:"sent_at"`
+}
+
+// WebhookAlert is the alert of a webhook payload
+type WebhookAlert struct {
+	ID          *uuid.UUID             `json:"id,omitempty"` // Absent for test sends
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Severity    string                 `json:"severity"`
+	Status      string                 `json:"status"`
+	TargetType  string                 `json:"target_type,omitempty"`
+	TargetID    *uuid.UUID             `json:"target_id,omitempty"`
+	RouterID    *uuid.UUID             `json:"router_id,omitempty"`
+	RouterName  string                 `json:"router_name,omitempty"`
+	TriggeredAt time.Time              `json:"triggered_at"`
+	ResolvedAt  *time.Time             `json:"resolved_at,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type webhookSender struct {
+	config WebhookConfig
+	key    string
+	client *http.Client
+}
+
+func newWebhookSender(config, secret string, client *http.Client) (*webhookSender, error) {
+	s := &webhookSender{key: secret, client: client}
+	if err := decodeConfig(config, &s.config); err != nil {
+		return nil, err
+	}
+	if err := checkURL(s.config.URL); err != nil {
+		return nil, fmt.Errorf("config: %v", err)
+	}
+	for name := range s.config.Headers {
+		if strings.EqualFold(name, HeaderSignature) || strings.EqualFold(name, HeaderTimestamp) {
+			return nil, fmt.Errorf("config: header %s is set by the webhook", name)
+		}
+	}
+	return s, nil
+}
+
+// Send posts the message as a WebhookPayload
+func (s *webhookSender) Send(ctx context.Context, msg *Message) error {
+	ev := msg.Event
+	alert := WebhookAlert{
+		Name:        ev.Name,
+		Description: ev.Description,
+		Severity:    ev.Severity,
+		Status:      ev.Status,
+		TargetType:  ev.TargetType,
+		TargetID:    ev.TargetID,
+		RouterID:    ev.RouterID,
+		RouterName:  ev.RouterName,
+		TriggeredAt: ev.TriggeredAt,
+		ResolvedAt:  ev.ResolvedAt,
+		Metadata:    ev.Metadata,
+	}
+	if ev.AlertID != uuid.Nil {
+		id := ev.AlertID
+		alert.ID = &id
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(WebhookPayload{
+		Event:     ev.Kind,
+		Title:     msg.Title,
+		Message:   msg.Body,
+		Alert:     alert,
+		Tier:      ev.Tier,
+		Recipient: ev.Recipient,
+		SentAt:    now.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	headers := make(http.Header)
+	for name, value := range s.config.Headers {
+		headers.Set(name, value)
+	}
+	headers.Set(HeaderEvent, ev.Kind)
+	if msg.DeliveryID != 0 {
+		headers.Set(HeaderDelivery, strconv.FormatInt(msg.DeliveryID, 10))
+	}
+	if s.key != "" {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		headers.Set(HeaderTimestamp, timestamp)
+		headers.Set(HeaderSignature, "sha256="+Sign(s.key, timestamp, body))
+	}
+
+	_, err = postJSON(ctx, s.client, s.config.URL, headers, body)
+	return err
+}
+
+// Sign returns the hex HMAC-SHA256 signature of a webhook request, for
+// receivers to compare with the signature header
+func Sign(key, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON posts a JSON body and returns the response body; responses
+// other than 2xx are errors
+func postJSON(ctx context.Context, client *http.Client, target string, headers http.Header, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ISPVisualMonitor")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may hold a secret, e.g. an incoming webhook or bot token
+		if uerr, ok := err.(*url.Error); ok {
+			return nil, uerr.Err
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(respBody) > maxErrorBody {
+			respBody = respBody[:maxErrorBody]
+		}
+		return respBody, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return respBody, nil
+}
+
+// checkURL checks that a URL is absolute http or https
+func checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package oncall
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxTiers         = 10
+	maxTargets       = 20
+	maxRepeatCount   = 10
+	maxEscalateAfter = 7 * 24 * 60 // A week, in minutes
+)
+
+// Tier is one tier of an escalation policy. Its targets are paged at once;
+// unless the alert is acknowledged or resolved, the next tier is paged
+// EscalateAfterMinutes later.
+type Tier struct {
+	EscalateAfterMinutes int      `json:"escalate_after_minutes"`
+	Targets              []Target `json:"targets"`
+}
+
+// Target is paged through the notification channel ChannelID: the channel
+// as configured, or addressed to the user on call for ScheduleID or to
+// UserID
+type Target struct {
+	ChannelID  uuid.UUID  `json:"channel_id"`
+	ScheduleID *uuid.UUID `json:"schedule_id,omitempty"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+}
+
+// Step is where an alert escalation goes after paging a tier
+type Step struct {
+	Tier    int       // Index of the next tier to page
+	Repeats int       // Passes started over
+	At      time.Time // When to page it
+	Done    bool      // Every tier and repeat paged
+}
+
+// ParseTiers parses and checks a policy's tiers JSON. Every tier that is
+// followed by another, or by a repeat, needs escalate_after_minutes.
+func ParseTiers(raw string, repeatCount int) ([]Tier, error) {
+	if repeatCount < 0 || repeatCount > maxRepeatCount {
+		return nil, fmt.Errorf("repeat_count must be between 0 and %d", maxRepeatCount)
+	}
+
+	var tiers []Tier
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&tiers); err != nil {
+		return nil, fmt.Errorf("tiers: %v", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("tiers: trailing data")
+	}
+
+	if len(tiers) == 0 || len(tiers) > maxTiers {
+		return nil, fmt.Errorf("tiers: between 1 and %d tiers are required", maxTiers)
+	}
+	for i, tier := range tiers {
+		if len(tier.Targets) == 0 || len(tier.Targets) > maxTargets {
+			return nil, fmt.Errorf("tier %d: between 1 and %d targets are required", i+1, maxTargets)
+		}
+		for j, target := range tier.Targets {
+			if target.ChannelID == uuid.Nil {
+				return nil, fmt.Errorf("tier %d target %d: channel_id is required", i+1, j+1)
+			}
+			if target.ScheduleID != nil && target.UserID != nil {
+				return nil, fmt.Errorf("tier %d target %d: schedule_id and user_id cannot both be set", i+1, j+1)
+			}
+		}
+
+		if tier.EscalateAfterMinutes < 0 || tier.EscalateAfterMinutes > maxEscalateAfter {
+			return nil, fmt.Errorf("tier %d: escalate_after_minutes must be between 0 and %d", i+1, maxEscalateAfter)
+		}
+		last := i == len(tiers)-1
+		if (!last || repeatCount > 0) && tier.EscalateAfterMinutes == 0 {
+			return nil, fmt.Errorf("tier %d: escalate_after_minutes is required", i+1)
+		}
+	}
+	return tiers, nil
+}
+
+// Next returns the step after paging tiers[tier] at t during pass repeats
+func Next(tiers []Tier, repeatCount, tier, repeats int, t time.Time) Step {
+	if tier < 0 || tier >= len(tiers) {
+		return Step{Tier: tier, Repeats: repeats, Done: true}
+	}
+
+	at := t.Add(time.Duration(tiers[tier].EscalateAfterMinutes) * time.Minute)
+	switch {
+	case tier+1 < len(tiers):
+		return Step{Tier: tier + 1, Repeats: repeats, At: at}
+	case repeats < repeatCount:
+		return Step{Tier: 0, Repeats: repeats + 1, At: at}
+	default:
+		return Step{Tier: tier, Repeats: repeats, Done: true}
+	}
+}
@@ -0,0 +1,253 @@
+package oncall
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+var (
+	alice = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	bob   = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	carol = uuid.MustParse("00000000-0000-0000-0000-00000000000c")
+	dave  = uuid.MustParse("00000000-0000-0000-0000-00000000000d")
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func mustSchedule(t *testing.T, s *models.OnCallSchedule, overrides ...*models.OnCallOverride) *Schedule {
+	t.Helper()
+	sched, err := New(s, overrides)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return sched
+}
+
+func assertOnCall(t *testing.T, sched *Schedule, at time.Time, want uuid.UUID) {
+	t.Helper()
+	got, ok := sched.OnCall(at)
+	if want == uuid.Nil {
+		if ok {
+			t.Errorf("at %s: %s on call, want nobody", at, got)
+		}
+		return
+	}
+	if !ok || got != want {
+		t.Errorf("at %s: on call = %s (%v), want %s", at, got, ok, want)
+	}
+}
+
+func TestWeeklyRotation(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	sched := mustSchedule(t, &models.OnCallSchedule{
+		Timezone:      "Europe/Berlin",
+		Participants:  []uuid.UUID{alice, bob, carol},
+		RotationStart: time.Date(2026, 10, 5, 9, 0, 0, 0, berlin), // Monday
+		RotationDays:  7,
+	})
+
+	assertOnCall(t, sched, time.Date(2026, 10, 5, 8, 59, 0, 0, berlin), uuid.Nil)
+	assertOnCall(t, sched, time.Date(2026, 10, 5, 9, 0, 0, 0, berlin), alice)
+	assertOnCall(t, sched, time.Date(2026, 10, 12, 8, 59, 0, 0, berlin), alice)
+	assertOnCall(t, sched, time.Date(2026, 10, 12, 9, 0, 0, 0, berlin), bob)
+	assertOnCall(t, sched, time.Date(2026, 10, 19, 9, 0, 0, 0, berlin), carol)
+
+	// Daylight saving ends on 25 October; the handoff stays at 09:00 local
+	assertOnCall(t, sched, time.Date(2026, 10, 26, 8, 59, 0, 0, berlin), carol)
+	assertOnCall(t, sched, time.Date(2026, 10, 26, 9, 0, 0, 0, berlin), alice)
+}
+
+func TestCoverage(t *testing.T) {
+	sched := mustSchedule(t, &models.OnCallSchedule{
+		Participants:  []uuid.UUID{alice},
+		RotationStart: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		RotationDays:  7,
+		Coverage: `[{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "09:00", "end": "17:00"},
+			{"days": ["sat"], "start": "22:00", "end": "06:00"}]`,
+	})
+
+	// Friday 16 October 2026
+	assertOnCall(t, sched, time.Date(2026, 10, 16, 8, 59, 0, 0, time.UTC), uuid.Nil)
+	assertOnCall(t, sched, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), alice)
+	assertOnCall(t, sched, time.Date(2026, 10, 16, 17, 0, 0, 0, time.UTC), uuid.Nil)
+	// Saturday night runs into Sunday morning, but not Friday night
+	assertOnCall(t, sched, time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC), uuid.Nil)
+	assertOnCall(t, sched, time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC), alice)
+	assertOnCall(t, sched, time.Date(2026, 10, 18, 5, 59, 0, 0, time.UTC), alice)
+	assertOnCall(t, sched, time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC), uuid.Nil)
+}
+
+func TestOverrides(t *testing.T) {
+	start := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	older := &models.OnCallOverride{
+		UserID:    carol,
+		StartsAt:  start.Add(10 * time.Hour),
+		EndsAt:    start.Add(20 * time.Hour),
+		CreatedAt: start,
+	}
+	newer := &models.OnCallOverride{
+		UserID:    dave,
+		StartsAt:  start.Add(12 * time.Hour),
+		EndsAt:    start.Add(14 * time.Hour),
+		CreatedAt: start.Add(time.Hour),
+	}
+	sched := mustSchedule(t, &models.OnCallSchedule{
+		Participants:  []uuid.UUID{alice, bob},
+		RotationStart: start,
+		RotationDays:  1,
+		Coverage:      `[{"start": "08:00", "end": "18:00"}]`,
+	}, older, newer)
+
+	assertOnCall(t, sched, start.Add(9*time.Hour), alice)
+	assertOnCall(t, sched, start.Add(11*time.Hour), carol)
+	assertOnCall(t, sched, start.Add(13*time.Hour), dave)
+	// Overrides apply outside coverage hours too
+	assertOnCall(t, sched, start.Add(19*time.Hour), carol)
+	assertOnCall(t, sched, start.Add(20*time.Hour), uuid.Nil)
+
+	shifts := sched.Shifts(start, start.Add(48*time.Hour))
+	want := []Shift{
+		{UserID: alice, Start: start.Add(8 * time.Hour), End: start.Add(10 * time.Hour)},
+		{UserID: carol, Start: start.Add(10 * time.Hour), End: start.Add(12 * time.Hour), Override: true},
+		{UserID: dave, Start: start.Add(12 * time.Hour), End: start.Add(14 * time.Hour), Override: true},
+		{UserID: carol, Start: start.Add(14 * time.Hour), End: start.Add(20 * time.Hour), Override: true},
+		{UserID: bob, Start: start.Add(32 * time.Hour), End: start.Add(42 * time.Hour)},
+	}
+	if len(shifts) != len(want) {
+		t.Fatalf("got %d shifts %+v, want %d", len(shifts), shifts, len(want))
+	}
+	for i := range want {
+		got := shifts[i]
+		if got.UserID != want[i].UserID || !got.Start.Equal(want[i].Start) ||
+			!got.End.Equal(want[i].End) || got.Override != want[i].Override {
+			t.Errorf("shift %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestShiftsMergeTurns(t *testing.T) {
+	start := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	sched := mustSchedule(t, &models.OnCallSchedule{
+		Participants:  []uuid.UUID{alice},
+		RotationStart: start,
+		RotationDays:  1,
+	})
+
+	// A single participant is on call throughout, from the rotation start
+	shifts := sched.Shifts(start.Add(-time.Hour), start.Add(72*time.Hour))
+	if len(shifts) != 1 || !shifts[0].Start.Equal(start) || !shifts[0].End.Equal(start.Add(72*time.Hour)) {
+		t.Errorf("shifts = %+v, want one from the rotation start", shifts)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	base := func() *models.OnCallSchedule {
+		return &models.OnCallSchedule{
+			Timezone:      "UTC",
+			RotationStart: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			RotationDays:  7,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *models.OnCallSchedule)
+		want   string
+	}{
+		{"valid", func(s *models.OnCallSchedule) {}, ""},
+		{"midnight end", func(s *models.OnCallSchedule) { s.Coverage = `[{"start": "18:00", "end": "24:00"}]` }, ""},
+		{"timezone", func(s *models.OnCallSchedule) { s.Timezone = "Mars/Olympus" }, "unknown timezone"},
+		{"no start", func(s *models.OnCallSchedule) { s.RotationStart = time.Time{} }, "rotation_start"},
+		{"rotation days", func(s *models.OnCallSchedule) { s.RotationDays = 0 }, "rotation_days"},
+		{"day", func(s *models.OnCallSchedule) { s.Coverage = `[{"days": ["moon"], "start": "09:00", "end": "17:00"}]` }, "unknown day"},
+		{"clock", func(s *models.OnCallSchedule) { s.Coverage = `[{"start": "9am", "end": "17:00"}]` }, "invalid time"},
+		{"empty period", func(s *models.OnCallSchedule) { s.Coverage = `[{"start": "09:00", "end": "09:00"}]` }, "must differ"},
+		{"unknown field", func(s *models.OnCallSchedule) { s.Coverage = `[{"from": "09:00"}]` }, "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := base()
+			tt.modify(s)
+			err := Validate(s)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTiers(t *testing.T) {
+	channel := `"channel_id": "00000000-0000-0000-0000-000000000001"`
+	tests := []struct {
+		name    string
+		tiers   string
+		repeats int
+		want    string
+	}{
+		{"valid", `[{"escalate_after_minutes": 15, "targets": [{` + channel + `, "schedule_id": "00000000-0000-0000-0000-000000000002"}]},
+			{"targets": [{` + channel + `}]}]`, 0, ""},
+		{"no tiers", `[]`, 0, "between 1 and"},
+		{"no targets", `[{"escalate_after_minutes": 5, "targets": []}]`, 0, "targets are required"},
+		{"no channel", `[{"targets": [{"user_id": "00000000-0000-0000-0000-000000000003"}]}]`, 0, "channel_id is required"},
+		{"schedule and user", `[{"targets": [{` + channel + `, "schedule_id": "00000000-0000-0000-0000-000000000002",
+			"user_id": "00000000-0000-0000-0000-000000000003"}]}]`, 0, "cannot both be set"},
+		{"missing delay", `[{"targets": [{` + channel + `}]}, {"targets": [{` + channel + `}]}]`, 0, "tier 1: escalate_after_minutes is required"},
+		{"repeat needs delay", `[{"targets": [{` + channel + `}]}]`, 1, "escalate_after_minutes is required"},
+		{"repeat count", `[{"targets": [{` + channel + `}]}]`, 11, "repeat_count"},
+		{"unknown field", `[{"delay": 5, "targets": [{` + channel + `}]}]`, 0, "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTiers(tt.tiers, tt.repeats)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ParseTiers: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTiers = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tiers := []Tier{{EscalateAfterMinutes: 10}, {EscalateAfterMinutes: 30}}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	step := Next(tiers, 1, 0, 0, now)
+	if step.Done || step.Tier != 1 || step.Repeats != 0 || !step.At.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("after tier 1: %+v", step)
+	}
+	step = Next(tiers, 1, 1, 0, now)
+	if step.Done || step.Tier != 0 || step.Repeats != 1 || !step.At.Equal(now.Add(30*time.Minute)) {
+		t.Errorf("after the last tier: %+v, want a repeat", step)
+	}
+	if step = Next(tiers, 1, 1, 1, now); !step.Done {
+		t.Errorf("after the last repeat: %+v, want done", step)
+	}
+	// A policy edited down to fewer tiers ends escalations past them
+	if step = Next(tiers, 0, 5, 0, now); !step.Done {
+		t.Errorf("past the last tier: %+v, want done", step)
+	}
+}
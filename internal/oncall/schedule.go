@@ -0,0 +1,332 @@
+// Package oncall works out who is on call for a schedule, from its
+// rotation, weekly coverage hours and overrides, and steps alerts through
+// the tiers of escalation policies.
+package oncall
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/maintenance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxShifts bounds the shifts returned for one schedule
+	maxShifts = 1000
+
+	// maxRotationDays bounds the turn of one participant
+	maxRotationDays = 365
+)
+
+// Shift is a period during which one user is on call for a schedule
+type Shift struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Override bool      `json:"override"` // Covered by an override
+}
+
+// Coverage is a weekly period during which a schedule is on call, in the
+// schedule's time zone. An End before Start runs past midnight into the
+// next day.
+type Coverage struct {
+	Days  []string `json:"days"`  // mon to sun; empty for every day
+	Start string   `json:"start"` // HH:MM
+	End   string   `json:"end"`   // HH:MM, 24:00 for midnight
+}
+
+// period is a parsed Coverage, in minutes since midnight
+type period struct {
+	days       [7]bool // By time.Weekday
+	start, end int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Schedule answers who is on call for a schedule with its overrides
+type Schedule struct {
+	participants []uuid.UUID
+	loc          *time.Location
+	start        time.Time // Rotation start in loc
+	days         int
+	coverage     []period
+	overrides    []*models.OnCallOverride // Latest created first
+}
+
+// New prepares a schedule and its overrides for lookups
+func New(s *models.OnCallSchedule, overrides []*models.OnCallOverride) (*Schedule, error) {
+	loc, err := location(s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if s.RotationStart.IsZero() {
+		return nil, fmt.Errorf("rotation_start is required")
+	}
+	if s.RotationDays < 1 || s.RotationDays > maxRotationDays {
+		return nil, fmt.Errorf("rotation_days must be between 1 and %d", maxRotationDays)
+	}
+	coverage, err := parseCoverage(s.Coverage)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]*models.OnCallOverride, len(overrides))
+	copy(sorted, overrides)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	return &Schedule{
+		participants: s.Participants,
+		loc:          loc,
+		start:        s.RotationStart.In(loc),
+		days:         s.RotationDays,
+		coverage:     coverage,
+		overrides:    sorted,
+	}, nil
+}
+
+// Validate checks that a schedule's timezone, rotation and coverage are
+// usable
+func Validate(s *models.OnCallSchedule) error {
+	_, err := New(s, nil)
+	return err
+}
+
+// OnCall returns the user on call at t; false when nobody is
+func (s *Schedule) OnCall(t time.Time) (uuid.UUID, bool) {
+	userID, _, ok := s.at(t)
+	return userID, ok
+}
+
+// Shifts returns who is on call over [from, to), in order, leaving out the
+// periods nobody is
+func (s *Schedule) Shifts(from, to time.Time) []Shift {
+	if !from.Before(to) {
+		return nil
+	}
+
+	// Who is on call only changes at handoffs, coverage edges and
+	// override bounds
+	bounds := []time.Time{from, to}
+	inRange := func(t time.Time) bool { return t.After(from) && t.Before(to) }
+
+	for _, o := range s.overrides {
+		for _, t := range []time.Time{o.StartsAt, o.EndsAt} {
+			if inRange(t) {
+				bounds = append(bounds, t)
+			}
+		}
+	}
+
+	if len(s.participants) > 0 {
+		k := 0
+		if !from.Before(s.start) {
+			k = s.handoffIndex(from) + 1
+		}
+		for t := s.handoff(k); t.Before(to) && len(bounds) < 4*maxShifts; t = s.handoff(k) {
+			if inRange(t) {
+				bounds = append(bounds, t)
+			}
+			k++
+		}
+	}
+
+	if len(s.coverage) > 0 {
+		first := maintenance.DateOf(from.In(s.loc)).AddDate(0, 0, -1)
+		last := maintenance.DateOf(to.In(s.loc))
+		for day := first; !day.After(last) && len(bounds) < 4*maxShifts; day = day.AddDate(0, 0, 1) {
+			for _, p := range s.coverage {
+				if !p.days[day.Weekday()] {
+					continue
+				}
+				start := atMinute(day, p.start)
+				end := atMinute(day, p.end)
+				if p.end <= p.start {
+					end = atMinute(day.AddDate(0, 0, 1), p.end)
+				}
+				for _, t := range []time.Time{start, end} {
+					if inRange(t) {
+						bounds = append(bounds, t)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	var shifts []Shift
+	for i := 0; i+1 < len(bounds); i++ {
+		start, end := bounds[i], bounds[i+1]
+		if !start.Before(end) {
+			continue
+		}
+		userID, override, ok := s.at(start)
+		if !ok {
+			continue
+		}
+		if n := len(shifts); n > 0 {
+			last := &shifts[n-1]
+			if last.End.Equal(start) && last.UserID == userID && last.Override == override {
+				last.End = end
+				continue
+			}
+		}
+		if len(shifts) == maxShifts {
+			break
+		}
+		shifts = append(shifts, Shift{UserID: userID, Start: start, End: end, Override: override})
+	}
+	return shifts
+}
+
+// at returns the user on call at t and whether an override put them there
+func (s *Schedule) at(t time.Time) (uuid.UUID, bool, bool) {
+	for _, o := range s.overrides {
+		if !t.Before(o.StartsAt) && t.Before(o.EndsAt) {
+			return o.UserID, true, true
+		}
+	}
+
+	if len(s.participants) == 0 || t.Before(s.start) || !s.covered(t) {
+		return uuid.Nil, false, false
+	}
+	k := s.handoffIndex(t)
+	return s.participants[k%len(s.participants)], false, true
+}
+
+// handoff returns the start of the kth turn, at the wall clock time of the
+// rotation start so that turns keep their time across daylight saving
+func (s *Schedule) handoff(k int) time.Time {
+	return s.start.AddDate(0, 0, k*s.days)
+}
+
+// handoffIndex returns the turn running at t, which is not before the
+// rotation start
+func (s *Schedule) handoffIndex(t time.Time) int {
+	k := maintenance.DaysBetween(s.start, t.In(s.loc)) / s.days
+	for !s.handoff(k + 1).After(t) {
+		k++
+	}
+	for k > 0 && s.handoff(k).After(t) {
+		k--
+	}
+	return k
+}
+
+// covered reports whether t falls in the coverage hours
+func (s *Schedule) covered(t time.Time) bool {
+	if len(s.coverage) == 0 {
+		return true
+	}
+
+	local := t.In(s.loc)
+	minute := local.Hour()*60 + local.Minute()
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+	for _, p := range s.coverage {
+		if p.start < p.end {
+			if p.days[today] && minute >= p.start && minute < p.end {
+				return true
+			}
+			continue
+		}
+		// Past midnight: from start today, or until end after yesterday's
+		if (p.days[today] && minute >= p.start) || (p.days[yesterday] && minute < p.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCoverage parses a schedule's coverage JSON; empty covers every hour
+func ParseCoverage(raw string) ([]Coverage, error) {
+	var coverage []Coverage
+	if strings.TrimSpace(raw) == "" {
+		return coverage, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&coverage); err != nil {
+		return nil, fmt.Errorf("coverage: %v", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("coverage: trailing data")
+	}
+	return coverage, nil
+}
+
+func parseCoverage(raw string) ([]period, error) {
+	coverage, err := ParseCoverage(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]period, 0, len(coverage))
+	for i, c := range coverage {
+		var p period
+		if len(c.Days) == 0 {
+			for d := range p.days {
+				p.days[d] = true
+			}
+		}
+		for _, day := range c.Days {
+			wd, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return nil, fmt.Errorf("coverage %d: unknown day %q", i+1, day)
+			}
+			p.days[wd] = true
+		}
+		if p.start, err = parseClock(c.Start, false); err != nil {
+			return nil, fmt.Errorf("coverage %d: start: %v", i+1, err)
+		}
+		if p.end, err = parseClock(c.End, true); err != nil {
+			return nil, fmt.Errorf("coverage %d: end: %v", i+1, err)
+		}
+		if p.start == p.end {
+			return nil, fmt.Errorf("coverage %d: start and end must differ", i+1)
+		}
+		periods = append(periods, p)
+	}
+	return periods, nil
+}
+
+// parseClock parses HH:MM into minutes since midnight; 24:00 is allowed
+// as an end
+func parseClock(value string, end bool) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		if end && value == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// location loads a schedule's timezone, UTC when empty
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// atMinute returns the given minute of a day, in the day's location
+func atMinute(day time.Time, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, day.Location())
+}
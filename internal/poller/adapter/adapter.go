@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/profile"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
 	"github.com/google/uuid"
 )
@@ -34,8 +36,8 @@ type PollResult struct {
 	AdapterUsed string    `json:"adapter_used"`
 	Timestamp   time.Time `json:"timestamp"`
 
-	// Generic metrics
-	Metrics map[string]interface{} `json:"metrics"`
+	// Router metrics, checked against metric.Default as they are set
+	Metrics metric.Samples `json:"metrics"`
 
 	// Role-specific data
 	PPPoESessions []models.PPPoESession `json:"pppoe_sessions,omitempty"`
@@ -104,6 +106,9 @@ type AdapterConfig struct {
 	TimeoutSeconds int
 	RetryAttempts  int
 	RetryDelay     time.Duration
+
+	// SNMP vendor profiles; without them only standard MIBs are read
+	Profiles *profile.Set
 }
 
 // DefaultAdapterConfig returns sensible defaults
@@ -123,7 +128,6 @@ func NewPollResult(routerID, tenantID uuid.UUID, adapterName string) *PollResult
 		Success:       false,
 		AdapterUsed:   adapterName,
 		Timestamp:     time.Now(),
-		Metrics:       make(map[string]interface{}),
 		PPPoESessions: []models.PPPoESession{},
 		NATSessions:   []models.NATSession{},
 		DHCPLeases:    []models.DHCPLease{},
@@ -133,40 +137,40 @@ func NewPollResult(routerID, tenantID uuid.UUID, adapterName string) *PollResult
 
 // SetSystemMetrics sets system metrics in the result
 func (pr *PollResult) SetSystemMetrics(sm SystemMetrics) {
-	pr.Metrics["cpu_percent"] = sm.CPUPercent
-	pr.Metrics["memory_percent"] = sm.MemoryPercent
-	pr.Metrics["memory_total_mb"] = sm.MemoryTotalMB
-	pr.Metrics["memory_used_mb"] = sm.MemoryUsedMB
-	pr.Metrics["memory_free_mb"] = sm.MemoryFreeMB
-	pr.Metrics["uptime_seconds"] = sm.UptimeSeconds
+	pr.Metrics.Set("cpu_percent", metric.Float(sm.CPUPercent))
+	pr.Metrics.Set("memory_percent", metric.Float(sm.MemoryPercent))
+	pr.Metrics.Set("memory_total_mb", metric.Int(sm.MemoryTotalMB))
+	pr.Metrics.Set("memory_used_mb", metric.Int(sm.MemoryUsedMB))
+	pr.Metrics.Set("memory_free_mb", metric.Int(sm.MemoryFreeMB))
+	pr.Metrics.Set("uptime_seconds", metric.Int(sm.UptimeSeconds))
 	if sm.TemperatureCelsius > 0 {
-		pr.Metrics["temperature_celsius"] = sm.TemperatureCelsius
+		pr.Metrics.Set("temperature_celsius", metric.Float(sm.TemperatureCelsius))
 	}
 }
 
 // SetPPPoEMetrics sets PPPoE metrics in the result
 func (pr *PollResult) SetPPPoEMetrics(pm PPPoEMetrics) {
-	pr.Metrics["pppoe_total_sessions"] = pm.TotalSessions
-	pr.Metrics["pppoe_active_sessions"] = pm.ActiveSessions
-	pr.Metrics["pppoe_max_sessions"] = pm.MaxSessions
-	pr.Metrics["pppoe_auth_successes"] = pm.AuthSuccesses
-	pr.Metrics["pppoe_auth_failures"] = pm.AuthFailures
-	pr.Metrics["pppoe_throughput_in_mbps"] = pm.ThroughputInMbps
-	pr.Metrics["pppoe_throughput_out_mbps"] = pm.ThroughputOutMbps
+	pr.Metrics.Set("pppoe_total_sessions", metric.Int(int64(pm.TotalSessions)))
+	pr.Metrics.Set("pppoe_active_sessions", metric.Int(int64(pm.ActiveSessions)))
+	pr.Metrics.Set("pppoe_max_sessions", metric.Int(int64(pm.MaxSessions)))
+	pr.Metrics.Set("pppoe_auth_successes", metric.Int(pm.AuthSuccesses))
+	pr.Metrics.Set("pppoe_auth_failures", metric.Int(pm.AuthFailures))
+	pr.Metrics.Set("pppoe_throughput_in_mbps", metric.Float(pm.ThroughputInMbps))
+	pr.Metrics.Set("pppoe_throughput_out_mbps", metric.Float(pm.ThroughputOutMbps))
 }
 
 // SetNATMetrics sets NAT metrics in the result
 func (pr *PollResult) SetNATMetrics(nm NATMetrics) {
-	pr.Metrics["nat_total_sessions"] = nm.TotalSessions
-	pr.Metrics["nat_max_sessions"] = nm.MaxSessions
-	pr.Metrics["nat_pool_utilization"] = nm.PoolUtilization
-	pr.Metrics["nat_port_exhaustion_pct"] = nm.PortExhaustionPct
-	pr.Metrics["nat_new_sessions_per_sec"] = nm.NewSessionsPerSec
+	pr.Metrics.Set("nat_total_sessions", metric.Int(int64(nm.TotalSessions)))
+	pr.Metrics.Set("nat_max_sessions", metric.Int(int64(nm.MaxSessions)))
+	pr.Metrics.Set("nat_pool_utilization", metric.Float(nm.PoolUtilization))
+	pr.Metrics.Set("nat_port_exhaustion_pct", metric.Float(nm.PortExhaustionPct))
+	pr.Metrics.Set("nat_new_sessions_per_sec", metric.Float(nm.NewSessionsPerSec))
 }
 
 // GetMetricsCount returns the total number of metrics collected
 func (pr *PollResult) GetMetricsCount() int {
-	count := len(pr.Metrics)
+	count := pr.Metrics.Len()
 	count += len(pr.PPPoESessions)
 	count += len(pr.NATSessions)
 	count += len(pr.DHCPLeases)
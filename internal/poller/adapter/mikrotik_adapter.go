@@ -6,8 +6,11 @@ import (
 	"log"
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/firmware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
 	"gopkg.in/routeros.v2"
 )
@@ -178,7 +181,7 @@ func (a *MikroTikAdapter) pollSystemResources(client *routeros.Client, result *P
 		// CPU
 		if cpuLoad, ok := res["cpu-load"]; ok {
 			if cpu, err := strconv.ParseFloat(cpuLoad, 64); err == nil {
-				result.Metrics["cpu_percent"] = cpu
+				result.Metrics.Set("cpu_percent", metric.Float(cpu))
 			}
 		}
 
@@ -189,31 +192,36 @@ func (a *MikroTikAdapter) pollSystemResources(client *routeros.Client, result *P
 				free, _ := strconv.ParseInt(freeMem, 10, 64)
 				used := total - free
 
-				result.Metrics["memory_total_mb"] = total / (1024 * 1024)
-				result.Metrics["memory_used_mb"] = used / (1024 * 1024)
-				result.Metrics["memory_free_mb"] = free / (1024 * 1024)
+				result.Metrics.Set("memory_total_mb", metric.Int(total/(1024*1024)))
+				result.Metrics.Set("memory_used_mb", metric.Int(used/(1024*1024)))
+				result.Metrics.Set("memory_free_mb", metric.Int(free/(1024*1024)))
 
 				if total > 0 {
-					result.Metrics["memory_percent"] = float64(used) / float64(total) * 100.0
+					result.Metrics.Set("memory_percent", metric.Float(float64(used)/float64(total)*100.0))
 				}
 			}
 		}
 
 		// Uptime
 		if uptime, ok := res["uptime"]; ok {
-			// Parse uptime string (e.g., "1w2d3h4m5s")
-			// For simplicity, store as string
-			result.Metrics["uptime"] = uptime
+			if seconds, err := parseRouterOSDuration(uptime); err == nil {
+				result.Metrics.Set("uptime_seconds", metric.Int(seconds))
+			} else {
+				log.Printf("Warning: Failed to parse uptime %q: %v", uptime, err)
+			}
 		}
 
 		// Board name
 		if boardName, ok := res["board-name"]; ok {
-			result.Metrics["board_name"] = boardName
+			result.Metrics.Set("board_name", metric.String(boardName))
 		}
 
 		// Version
 		if version, ok := res["version"]; ok {
-			result.Metrics["version"] = version
+			result.Metrics.Set("version", metric.String(version))
+			if osVersion := firmware.ExtractVersion(version); osVersion != "" {
+				result.Metrics.Set("os_version", metric.String(osVersion))
+			}
 		}
 	}
 
@@ -280,7 +288,7 @@ func (a *MikroTikAdapter) pollInterfaces(client *routeros.Client, result *PollRe
 	}
 
 	result.Interfaces = interfaces
-	result.Metrics["interface_count"] = len(interfaces)
+	result.Metrics.Set("interface_count", metric.Int(int64(len(interfaces))))
 
 	return nil
 }
@@ -410,7 +418,50 @@ func (a *MikroTikAdapter) pollDHCPLeases(client *routeros.Client, router *models
 	}
 
 	result.DHCPLeases = leases
-	result.Metrics["dhcp_lease_count"] = len(leases)
+	result.Metrics.Set("dhcp_lease_count", metric.Int(int64(len(leases))))
 
 	return nil
 }
+
+// routerOSDurationUnits are the unit letters of RouterOS durations, in seconds
+var routerOSDurationUnits = map[byte]int64{'w': 7 * 86400, 'd': 86400, 'h': 3600, 'm': 60, 's': 1}
+
+// parseRouterOSDuration parses a RouterOS duration such as "1w2d3h4m5s",
+// or "1w2d03:04:05" as printed by older versions, into seconds
+func parseRouterOSDuration(s string) (int64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	var total int64
+
+	// Older versions print hours, minutes and seconds as a clock
+	if strings.Contains(s, ":") {
+		i := strings.LastIndexAny(s, "wd") + 1
+		var h, m, sec int64
+		if _, err := fmt.Sscanf(s[i:], "%d:%d:%d", &h, &m, &sec); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total, s = h*3600+m*60+sec, s[:i]
+	}
+
+	var n int64
+	digits := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			n = n*10 + int64(c-'0')
+			digits = true
+			continue
+		}
+		unit, ok := routerOSDurationUnits[c]
+		if !ok || !digits {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total += n * unit
+		n, digits = 0, false
+	}
+	if digits {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return total, nil
+}
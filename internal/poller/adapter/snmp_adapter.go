@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/firmware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/profile"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
 	"github.com/gosnmp/gosnmp"
 )
@@ -74,6 +78,11 @@ func (a *SNMPAdapter) Poll(ctx context.Context, router *models.EnhancedRouter) (
 		log.Printf("Warning: Failed to poll system info: %v", err)
 	}
 
+	// Poll vendor MIBs of the router's profile
+	if err := a.pollProfile(client, router, result); err != nil {
+		log.Printf("Warning: Failed to poll vendor metrics of %s: %v", router.Name, err)
+	}
+
 	// Poll interface statistics
 	if err := a.pollInterfaces(client, result); err != nil {
 		log.Printf("Warning: Failed to poll interfaces: %v", err)
@@ -199,6 +208,7 @@ func (a *SNMPAdapter) pollSystemInfo(client *gosnmp.GoSNMP, result *PollResult)
 	// Standard System MIB OIDs
 	oids := []string{
 		"1.3.6.1.2.1.1.1.0",    // sysDescr
+		"1.3.6.1.2.1.1.2.0",    // sysObjectID
 		"1.3.6.1.2.1.1.3.0",    // sysUpTime
 		"1.3.6.1.2.1.1.5.0",    // sysName
 		"1.3.6.1.2.1.25.1.5.0", // hrSystemUptime (if available)
@@ -212,19 +222,43 @@ func (a *SNMPAdapter) pollSystemInfo(client *gosnmp.GoSNMP, result *PollResult)
 	for _, variable := range response.Variables {
 		switch variable.Name {
 		case "1.3.6.1.2.1.1.1.0":
-			result.Metrics["system_description"] = fmt.Sprintf("%s", variable.Value)
+			descr := fmt.Sprintf("%s", variable.Value)
+			result.Metrics.Set("system_description", metric.String(descr))
+			if version := firmware.ExtractVersion(descr); version != "" {
+				result.Metrics.Set("os_version", metric.String(version))
+			}
+		case "1.3.6.1.2.1.1.2.0":
+			if oid, ok := variable.Value.(string); ok {
+				result.Metrics.Set("system_object_id", metric.String(strings.TrimPrefix(oid, ".")))
+			}
 		case "1.3.6.1.2.1.1.3.0":
 			if uptime, ok := variable.Value.(uint32); ok {
-				result.Metrics["uptime_seconds"] = int64(uptime / 100) // Convert timeticks to seconds
+				result.Metrics.Set("uptime_seconds", metric.Int(int64(uptime/100))) // Convert timeticks to seconds
 			}
 		case "1.3.6.1.2.1.1.5.0":
-			result.Metrics["system_name"] = fmt.Sprintf("%s", variable.Value)
+			result.Metrics.Set("system_name", metric.String(fmt.Sprintf("%s", variable.Value)))
 		}
 	}
 
 	return nil
 }
 
+// pollProfile reads CPU, memory and role metrics from the vendor MIBs
+// declared by the profile matching the router's sysObjectID
+func (a *SNMPAdapter) pollProfile(client *gosnmp.GoSNMP, router *models.EnhancedRouter, result *PollResult) error {
+	sysObjectID, ok := result.Metrics.Text("system_object_id")
+	if a.config.Profiles == nil || !ok {
+		return nil
+	}
+	p := a.config.Profiles.Match(sysObjectID)
+	if p == nil {
+		return nil
+	}
+
+	result.Metrics.Set("vendor_profile", metric.String(p.Name))
+	return p.Collect(profile.NewClient(client), &result.Metrics, router.HasRole)
+}
+
 // pollInterfaces polls interface statistics
 func (a *SNMPAdapter) pollInterfaces(client *gosnmp.GoSNMP, result *PollResult) error {
 	// Walk the interface table (IF-MIB)
@@ -246,7 +280,7 @@ func (a *SNMPAdapter) pollInterfaces(client *gosnmp.GoSNMP, result *PollResult)
 	for _, variable := range response.Variables {
 		if variable.Name == "1.3.6.1.2.1.2.1.0" {
 			if ifCount, ok := variable.Value.(int); ok {
-				result.Metrics["interface_count"] = ifCount
+				result.Metrics.Set("interface_count", metric.Int(int64(ifCount)))
 			}
 		}
 	}
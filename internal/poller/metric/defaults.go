@@ -0,0 +1,45 @@
+package metric
+
+import "github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+
+// Default holds the metrics the built-in adapters report. Adapters
+// reporting a new metric add its definition here.
+var Default = MustRegistry(
+	// System
+	Definition{Name: "cpu_percent", Unit: UnitPercent, Kind: KindGauge, Type: TypeFloat, Help: "CPU load"},
+	Definition{Name: "memory_percent", Unit: UnitPercent, Kind: KindGauge, Type: TypeFloat, Help: "Memory in use"},
+	Definition{Name: "memory_total_mb", Unit: UnitMegabytes, Kind: KindGauge, Type: TypeInt, Help: "Total memory"},
+	Definition{Name: "memory_used_mb", Unit: UnitMegabytes, Kind: KindGauge, Type: TypeInt, Help: "Used memory"},
+	Definition{Name: "memory_free_mb", Unit: UnitMegabytes, Kind: KindGauge, Type: TypeInt, Help: "Free memory"},
+	Definition{Name: "uptime_seconds", Unit: UnitSeconds, Kind: KindGauge, Type: TypeInt, Help: "Time since the router started"},
+	Definition{Name: "temperature_celsius", Unit: UnitCelsius, Kind: KindGauge, Type: TypeFloat, Help: "Chassis temperature"},
+	Definition{Name: "interface_count", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Help: "Interfaces on the router"},
+	Definition{Name: "system_name", Kind: KindInfo, Type: TypeString, Help: "sysName of the router"},
+	Definition{Name: "system_description", Kind: KindInfo, Type: TypeString, Help: "sysDescr of the router"},
+	Definition{Name: "board_name", Kind: KindInfo, Type: TypeString, Help: "Hardware model reported by the router"},
+	Definition{Name: "version", Kind: KindInfo, Type: TypeString, Help: "Software version string as reported"},
+	Definition{Name: "os_version", Kind: KindInfo, Type: TypeString, Help: "Normalized OS version"},
+	Definition{Name: "system_object_id", Kind: KindInfo, Type: TypeString, Help: "sysObjectID of the router"},
+	Definition{Name: "vendor_profile", Kind: KindInfo, Type: TypeString, Help: "SNMP vendor profile the router matched"},
+
+	// PPPoE server
+	Definition{Name: "pppoe_total_sessions", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodePPPoEServer, Help: "PPPoE sessions"},
+	Definition{Name: "pppoe_active_sessions", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodePPPoEServer, Help: "Active PPPoE sessions"},
+	Definition{Name: "pppoe_max_sessions", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodePPPoEServer, Help: "PPPoE session limit"},
+	Definition{Name: "pppoe_session_count", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodePPPoEServer, Help: "PPPoE sessions listed by the router"},
+	Definition{Name: "pppoe_auth_successes", Unit: UnitCount, Kind: KindCounter, Type: TypeInt, Role: models.RoleCodePPPoEServer, Help: "Successful PPPoE authentications"},
+	Definition{Name: "pppoe_auth_failures", Unit: UnitCount, Kind: KindCounter, Type: TypeInt, Role: models.RoleCodePPPoEServer, Help: "Failed PPPoE authentications"},
+	Definition{Name: "pppoe_throughput_in_mbps", Unit: UnitMbps, Kind: KindGauge, Type: TypeFloat, Role: models.RoleCodePPPoEServer, Help: "PPPoE subscriber download throughput"},
+	Definition{Name: "pppoe_throughput_out_mbps", Unit: UnitMbps, Kind: KindGauge, Type: TypeFloat, Role: models.RoleCodePPPoEServer, Help: "PPPoE subscriber upload throughput"},
+
+	// NAT gateway
+	Definition{Name: "nat_total_sessions", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodeNATGateway, Help: "NAT translations"},
+	Definition{Name: "nat_max_sessions", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodeNATGateway, Help: "NAT translation limit"},
+	Definition{Name: "nat_session_count", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodeNATGateway, Help: "NAT translations listed by the router"},
+	Definition{Name: "nat_pool_utilization", Unit: UnitPercent, Kind: KindGauge, Type: TypeFloat, Role: models.RoleCodeNATGateway, Help: "NAT pool addresses in use"},
+	Definition{Name: "nat_port_exhaustion_pct", Unit: UnitPercent, Kind: KindGauge, Type: TypeFloat, Role: models.RoleCodeNATGateway, Help: "NAT ports in use"},
+	Definition{Name: "nat_new_sessions_per_sec", Unit: UnitPerSecond, Kind: KindGauge, Type: TypeFloat, Role: models.RoleCodeNATGateway, Help: "New NAT translations per second"},
+
+	// DHCP server
+	Definition{Name: "dhcp_lease_count", Unit: UnitCount, Kind: KindGauge, Type: TypeInt, Role: models.RoleCodeDHCPServer, Help: "DHCP leases"},
+)
@@ -0,0 +1,259 @@
+// Package metric declares the router metrics adapters may report and holds
+// typed metric values. Values are checked against their definition when
+// they are set, so an adapter reporting a metric under an unknown name or
+// with the wrong type fails loudly instead of being stored as zero.
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Kind tells how a metric's values relate over time
+type Kind string
+
+// Metric kinds
+const (
+	KindGauge   Kind = "gauge"   // a value that goes up and down
+	KindCounter Kind = "counter" // a running total that only resets on restart
+	KindInfo    Kind = "info"    // a textual attribute of the router
+)
+
+// Type is the type of a metric's values
+type Type string
+
+// Value types
+const (
+	TypeFloat  Type = "float"
+	TypeInt    Type = "int"
+	TypeString Type = "string"
+)
+
+// Units of the default metrics
+const (
+	UnitPercent   = "percent"
+	UnitMegabytes = "megabytes"
+	UnitSeconds   = "seconds"
+	UnitCelsius   = "celsius"
+	UnitCount     = "count"
+	UnitMbps      = "megabits_per_second"
+	UnitPerSecond = "per_second"
+)
+
+// Limits on definitions and values
+const (
+	maxNameLength   = 100
+	maxStringLength = 1024
+)
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Definition declares a metric
+type Definition struct {
+	Name string `json:"name"`
+	Unit string `json:"unit,omitempty"`
+	Kind Kind   `json:"kind"`
+	Type Type   `json:"type"`
+	Role string `json:"role,omitempty"` // router role code the metric belongs to; empty for any router
+	Help string `json:"help"`
+}
+
+// Value is a typed metric value. The zero Value is invalid.
+type Value struct {
+	typ Type
+	f   float64
+	i   int64
+	s   string
+}
+
+// Float returns a float value
+func Float(v float64) Value {
+	return Value{typ: TypeFloat, f: v}
+}
+
+// Int returns an integer value
+func Int(v int64) Value {
+	return Value{typ: TypeInt, i: v}
+}
+
+// String returns a string value
+func String(v string) Value {
+	return Value{typ: TypeString, s: v}
+}
+
+// Type returns the type of the value, empty for the zero Value
+func (v Value) Type() Type {
+	return v.typ
+}
+
+// Float64 returns a numeric value as a float
+func (v Value) Float64() (float64, bool) {
+	switch v.typ {
+	case TypeFloat:
+		return v.f, true
+	case TypeInt:
+		return float64(v.i), true
+	}
+	return 0, false
+}
+
+// Int64 returns an integer value
+func (v Value) Int64() (int64, bool) {
+	return v.i, v.typ == TypeInt
+}
+
+// Text returns a string value
+func (v Value) Text() (string, bool) {
+	return v.s, v.typ == TypeString
+}
+
+// Interface returns the value as a float64, int64 or string
+func (v Value) Interface() interface{} {
+	switch v.typ {
+	case TypeFloat:
+		return v.f
+	case TypeInt:
+		return v.i
+	case TypeString:
+		return v.s
+	}
+	return nil
+}
+
+// String formats the value
+func (v Value) String() string {
+	switch v.typ {
+	case TypeFloat:
+		return strconv.FormatFloat(v.f, 'g', -1, 64)
+	case TypeInt:
+		return strconv.FormatInt(v.i, 10)
+	case TypeString:
+		return strconv.Quote(v.s)
+	}
+	return "<invalid>"
+}
+
+// MarshalJSON encodes the value as a JSON number or string
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Interface())
+}
+
+// Registry holds metric definitions by name
+type Registry struct {
+	defs map[string]Definition
+}
+
+// NewRegistry creates a registry of definitions
+func NewRegistry(defs ...Definition) (*Registry, error) {
+	r := &Registry{defs: make(map[string]Definition, len(defs))}
+	for _, def := range defs {
+		if err := r.add(def); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) add(def Definition) error {
+	if len(def.Name) > maxNameLength || !namePattern.MatchString(def.Name) {
+		return fmt.Errorf("invalid metric name %q", def.Name)
+	}
+	if _, ok := r.defs[def.Name]; ok {
+		return fmt.Errorf("metric %q defined twice", def.Name)
+	}
+
+	switch def.Type {
+	case TypeFloat, TypeInt:
+		if def.Kind != KindGauge && def.Kind != KindCounter {
+			return fmt.Errorf("numeric metric %q must be a gauge or counter", def.Name)
+		}
+	case TypeString:
+		if def.Kind != KindInfo {
+			return fmt.Errorf("string metric %q must be info", def.Name)
+		}
+	default:
+		return fmt.Errorf("metric %q has unknown type %q", def.Name, def.Type)
+	}
+
+	r.defs[def.Name] = def
+	return nil
+}
+
+// Lookup returns the definition of a metric
+func (r *Registry) Lookup(name string) (Definition, bool) {
+	def, ok := r.defs[name]
+	return def, ok
+}
+
+// Definitions returns every definition, sorted by name
+func (r *Registry) Definitions() []Definition {
+	defs := make([]Definition, 0, len(r.defs))
+	for _, def := range r.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// RoleDefinitions returns the definitions of a router role's metrics, or of
+// the metrics of any router for an empty role, sorted by name
+func (r *Registry) RoleDefinitions(role string) []Definition {
+	defs := make([]Definition, 0)
+	for _, def := range r.Definitions() {
+		if def.Role == role {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// Check validates a value of a metric and returns it converted to the
+// metric's type. Integers are accepted for float metrics; any other type
+// mismatch, non-finite floats, negative counters and oversized strings are
+// errors.
+func (r *Registry) Check(name string, v Value) (Value, error) {
+	def, ok := r.defs[name]
+	if !ok {
+		return Value{}, fmt.Errorf("unknown metric %q", name)
+	}
+
+	switch {
+	case v.typ == "":
+		return Value{}, fmt.Errorf("metric %q has no value", name)
+	case v.typ == def.Type:
+	case v.typ == TypeInt && def.Type == TypeFloat:
+		v = Float(float64(v.i))
+	default:
+		return Value{}, fmt.Errorf("metric %q is %s, got %s value %s", name, def.Type, v.typ, v)
+	}
+
+	switch v.typ {
+	case TypeFloat:
+		if math.IsNaN(v.f) || math.IsInf(v.f, 0) {
+			return Value{}, fmt.Errorf("metric %q is not finite: %s", name, v)
+		}
+	case TypeString:
+		if len(v.s) > maxStringLength {
+			return Value{}, fmt.Errorf("metric %q is longer than %d bytes", name, maxStringLength)
+		}
+	}
+	if def.Kind == KindCounter {
+		if f, _ := v.Float64(); f < 0 {
+			return Value{}, fmt.Errorf("counter %q is negative: %s", name, v)
+		}
+	}
+	return v, nil
+}
+
+// MustRegistry is like NewRegistry but panics on invalid definitions
+func MustRegistry(defs ...Definition) *Registry {
+	r, err := NewRegistry(defs...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
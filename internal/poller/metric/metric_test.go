@@ -0,0 +1,156 @@
+package metric
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		value Value
+		want  Value
+		err   string
+	}{
+		{"cpu_percent", Float(12.5), Float(12.5), ""},
+		{"cpu_percent", Int(12), Float(12), ""},
+		{"uptime_seconds", Int(3600), Int(3600), ""},
+		{"uptime_seconds", Float(3600), Value{}, "is int, got float"},
+		{"uptime_seconds", String("1w2d"), Value{}, "is int, got string"},
+		{"os_version", String("7.14"), String("7.14"), ""},
+		{"os_version", Int(7), Value{}, "is string"},
+		{"cpu_percent", Float(math.NaN()), Value{}, "not finite"},
+		{"cpu_percent", Float(math.Inf(1)), Value{}, "not finite"},
+		{"pppoe_auth_failures", Int(-1), Value{}, "negative"},
+		{"cpu_percent", Value{}, Value{}, "no value"},
+		{"uptime", String("1w2d"), Value{}, "unknown metric"},
+		{"system_name", String(strings.Repeat("x", maxStringLength+1)), Value{}, "longer than"},
+	}
+
+	for _, tt := range tests {
+		got, err := Default.Check(tt.name, tt.value)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Check(%s, %s) error = %v, want %q", tt.name, tt.value, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Check(%s, %s): %v", tt.name, tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Check(%s, %s) = %s (%s), want %s (%s)", tt.name, tt.value, got, got.Type(), tt.want, tt.want.Type())
+		}
+	}
+}
+
+func TestNewRegistryRejectsInvalidDefinitions(t *testing.T) {
+	tests := []struct {
+		defs []Definition
+		err  string
+	}{
+		{[]Definition{{Name: "CPU", Kind: KindGauge, Type: TypeFloat}}, "invalid metric name"},
+		{[]Definition{{Name: "a", Kind: KindGauge, Type: TypeFloat}, {Name: "a", Kind: KindGauge, Type: TypeFloat}}, "defined twice"},
+		{[]Definition{{Name: "a", Kind: KindInfo, Type: TypeInt}}, "gauge or counter"},
+		{[]Definition{{Name: "a", Kind: KindGauge, Type: TypeString}}, "must be info"},
+		{[]Definition{{Name: "a", Kind: KindGauge, Type: "bool"}}, "unknown type"},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewRegistry(tt.defs...); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("NewRegistry(%v) error = %v, want %q", tt.defs, err, tt.err)
+		}
+	}
+}
+
+func TestSamples(t *testing.T) {
+	var s Samples
+	if err := s.Set("cpu_percent", Int(40)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	s.Set("uptime_seconds", Int(3600))
+	s.Set("os_version", String("7.14"))
+	if err := s.Set("uptime_seconds", Float(1.5)); err == nil {
+		t.Error("float accepted for an int metric")
+	}
+	s.Set("made_up", Int(1))
+
+	if cpu, ok := s.Float("cpu_percent"); !ok || cpu != 40 {
+		t.Errorf("cpu_percent = %v, %v", cpu, ok)
+	}
+	if uptime, ok := s.Int("uptime_seconds"); !ok || uptime != 3600 {
+		t.Errorf("uptime_seconds = %v, %v; a rejected value must not replace it", uptime, ok)
+	}
+	if _, ok := s.Int("cpu_percent"); ok {
+		t.Error("float metric read as int")
+	}
+	if v, ok := s.Text("os_version"); !ok || v != "7.14" {
+		t.Errorf("os_version = %q, %v", v, ok)
+	}
+	if s.Has("made_up") || s.Len() != 3 {
+		t.Errorf("names = %v", s.Names())
+	}
+	if len(s.Errors()) != 2 {
+		t.Errorf("errors = %v, want 2", s.Errors())
+	}
+
+	b, err := json.Marshal(struct{ Metrics Samples }{s})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"Metrics":{"cpu_percent":40,"os_version":"7.14","uptime_seconds":3600}}`
+	if string(b) != want {
+		t.Errorf("JSON = %s, want %s", b, want)
+	}
+}
+
+func TestSamplesWithRegistry(t *testing.T) {
+	r := MustRegistry(Definition{Name: "custom_gauge", Kind: KindGauge, Type: TypeFloat})
+	s := NewSamples(r)
+	if err := s.Set("custom_gauge", Float(1)); err != nil {
+		t.Errorf("Set: %v", err)
+	}
+	if err := s.Set("cpu_percent", Float(1)); err == nil {
+		t.Error("metric missing from the registry accepted")
+	}
+}
+
+func TestDefaultDefinitions(t *testing.T) {
+	for _, def := range Default.Definitions() {
+		if def.Help == "" {
+			t.Errorf("metric %s has no help", def.Name)
+		}
+		if def.Type != TypeString && def.Unit == "" {
+			t.Errorf("numeric metric %s has no unit", def.Name)
+		}
+	}
+}
+
+func TestRoleDefinitions(t *testing.T) {
+	defs := Default.RoleDefinitions(models.RoleCodeNATGateway)
+	if len(defs) == 0 {
+		t.Fatal("no NAT gateway metrics")
+	}
+	for i, def := range defs {
+		if def.Role != models.RoleCodeNATGateway {
+			t.Errorf("metric %s of role %q returned for NAT gateways", def.Name, def.Role)
+		}
+		if i > 0 && defs[i-1].Name >= def.Name {
+			t.Errorf("metrics not sorted: %s before %s", defs[i-1].Name, def.Name)
+		}
+	}
+
+	for _, def := range Default.RoleDefinitions("") {
+		if def.Name == "pppoe_active_sessions" {
+			t.Error("PPPoE metric returned for any router")
+		}
+	}
+	if defs := Default.RoleDefinitions("no_such_role"); len(defs) != 0 {
+		t.Errorf("unknown role has metrics %v", defs)
+	}
+}
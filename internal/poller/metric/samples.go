@@ -0,0 +1,102 @@
+package metric
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Samples holds the metric values of one poll by name. Values are checked
+// against a registry, Default unless set with NewSamples, as they are set;
+// rejected values are not stored and are kept as errors. The zero Samples
+// is empty and ready to use.
+type Samples struct {
+	registry *Registry
+	values   map[string]Value
+	errs     []error
+}
+
+// NewSamples creates an empty set checked against registry
+func NewSamples(registry *Registry) Samples {
+	return Samples{registry: registry}
+}
+
+// Set stores a value of a metric, or records and returns why it is invalid
+func (s *Samples) Set(name string, v Value) error {
+	registry := s.registry
+	if registry == nil {
+		registry = Default
+	}
+
+	v, err := registry.Check(name, v)
+	if err != nil {
+		s.errs = append(s.errs, err)
+		return err
+	}
+
+	if s.values == nil {
+		s.values = make(map[string]Value)
+	}
+	s.values[name] = v
+	return nil
+}
+
+// Get returns the value of a metric
+func (s *Samples) Get(name string) (Value, bool) {
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Has reports whether a metric has a value
+func (s *Samples) Has(name string) bool {
+	_, ok := s.values[name]
+	return ok
+}
+
+// Float returns the value of a numeric metric as a float
+func (s *Samples) Float(name string) (float64, bool) {
+	return s.values[name].Float64()
+}
+
+// Int returns the value of an integer metric
+func (s *Samples) Int(name string) (int64, bool) {
+	return s.values[name].Int64()
+}
+
+// Text returns the value of a string metric
+func (s *Samples) Text(name string) (string, bool) {
+	return s.values[name].Text()
+}
+
+// Len returns the number of metrics with a value
+func (s *Samples) Len() int {
+	return len(s.values)
+}
+
+// Names returns the metrics with a value, sorted
+func (s *Samples) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Errors returns why values were rejected, in the order they were set
+func (s *Samples) Errors() []error {
+	return s.errs
+}
+
+// Map returns the values as float64, int64 or string by name
+func (s *Samples) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(s.values))
+	for name, v := range s.values {
+		m[name] = v.Interface()
+	}
+	return m
+}
+
+// MarshalJSON encodes the values as an object by name
+func (s Samples) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Map())
+}
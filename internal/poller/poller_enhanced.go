@@ -2,16 +2,78 @@ package poller
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/alerting"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/batch"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/maintenance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/profile"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/sink"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/sshclient"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrRouterNotFound is returned for on-demand polls of unknown routers
+	ErrRouterNotFound = errors.New("router not found")
+
+	// ErrQueueFull is returned when an on-demand poll could not start
+	// before its deadline
+	ErrQueueFull = errors.New("poll queue full")
+
+	// ErrUnknownAdapter is returned when a health check names an adapter
+	// that is not registered
+	ErrUnknownAdapter = errors.New("unknown adapter")
+
+	// ErrAdapterNotApplicable is returned when a health check names an
+	// adapter the router is not configured for
+	ErrAdapterNotApplicable = errors.New("adapter not configured for router")
+)
+
+// pollJob is a router waiting to be polled. On-demand polls wait for the
+// result on done.
+type pollJob struct {
+	router *models.EnhancedRouter
+	done   chan *adapter.PollResult
+}
+
+// polledRouter is a polling result with the router it belongs to
+type polledRouter struct {
+	router *models.EnhancedRouter
+	result *adapter.PollResult
+}
+
+// HealthResult is the outcome of a health check through one adapter
+type HealthResult struct {
+	RouterID       uuid.UUID `json:"router_id"`
+	Adapter        string    `json:"adapter"`
+	Healthy        bool      `json:"healthy"`
+	ErrorMessage   string    `json:"error_message,omitempty"`
+	ResponseTimeMs int       `json:"response_time_ms"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// Tables written through the batch writer
+const (
+	tablePollingHistory = "polling_history"
+	tableRouterMetrics  = "router_metrics"
+	tableRoleMetrics    = "role_specific_metrics"
 )
 
 // EnhancedService handles router polling using the adapter pattern
@@ -19,37 +81,106 @@ type EnhancedService struct {
 	db       *database.DB
 	config   config.PollerConfig
 	registry *adapter.Registry
-
-	// Channels for work distribution
-	jobs    chan *models.EnhancedRouter
-	results chan *adapter.PollResult
+	writer   *batch.Writer
+	vault    *vault.Vault
+	windows  *maintenance.Checker
+	rules    *alerting.Engine
+	alerts   repository.AlertRepository
+
+	// Destinations of polling results, PostgreSQL by default
+	sinks []sink.ResultSink
+
+	// Channels for work distribution. On-demand polls do not queue behind
+	// scheduled ones; they run as soon as one of onDemandSlots is free.
+	jobs          chan *pollJob
+	onDemand      chan *pollJob
+	onDemandSlots int
+	results       chan *polledRouter
 
 	// Worker management
 	wg sync.WaitGroup
 }
 
-// NewEnhancedService creates a new enhanced poller service
-func NewEnhancedService(db *database.DB, cfg config.PollerConfig) *EnhancedService {
+// NewEnhancedService creates a new enhanced poller service writing results
+// to the sinks listed in sinkCfg. Router credentials are decrypted with
+// secrets; routers in maintenance per windows are not made unreachable and
+// may not be polled. Results are evaluated against the alert rules of rules.
+// Symbolic OIDs of vendor profiles are resolved with mibs.
+func NewEnhancedService(db *database.DB, cfg config.PollerConfig, sinkCfg config.SinkConfig, secrets *vault.Vault, windows *maintenance.Checker, rules *alerting.Engine, mibs *mib.Tree) (*EnhancedService, error) {
+	profiles, err := profile.Load(cfg.ProfileDir, mibs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vendor profiles: %w", err)
+	}
+	log.Printf("Loaded SNMP vendor profiles: %v", profiles.Names())
+
 	// Create adapter registry with configuration
 	adapterConfig := adapter.AdapterConfig{
 		TimeoutSeconds: cfg.TimeoutSeconds,
 		RetryAttempts:  cfg.RetryAttempts,
 		RetryDelay:     2 * time.Second,
+		Profiles:       profiles,
+	}
+
+	writer := batch.NewWriter(db.DB, cfg.WriteBatchSize,
+		time.Duration(max(cfg.WriteFlushSeconds, 1))*time.Second, cfg.WriteQueueSize)
+	writer.Register(tablePollingHistory,
+		"tenant_id", "router_id", "poll_started_at", "poll_completed_at",
+		"adapter_used", "success", "error_message", "metrics_collected", "response_time_ms")
+	writer.Register(tableRouterMetrics,
+		"tenant_id", "router_id", "timestamp",
+		"cpu_percent", "memory_percent", "uptime_seconds", "temperature_celsius")
+	writer.Register(tableRoleMetrics,
+		"tenant_id", "router_id", "role_code", "timestamp", "metrics")
+
+	remote, err := sink.New(sinkCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create result sinks: %w", err)
 	}
 
-	return &EnhancedService{
+	s := &EnhancedService{
 		db:       db,
 		config:   cfg,
 		registry: adapter.NewRegistry(adapterConfig),
-		jobs:     make(chan *models.EnhancedRouter, cfg.ConcurrentPolls),
-		results:  make(chan *adapter.PollResult, cfg.ConcurrentPolls),
+		writer:   writer,
+		vault:    secrets,
+		windows:  windows,
+		rules:    rules,
+		alerts:   postgres.NewAlertRepo(db.DB),
+		jobs:     make(chan *pollJob, cfg.ConcurrentPolls),
+		onDemand: make(chan *pollJob),
+		results:  make(chan *polledRouter, cfg.ConcurrentPolls),
+
+		onDemandSlots: max(cfg.WorkerCount, 1),
+	}
+	for _, name := range sinkCfg.Sinks {
+		if strings.TrimSpace(name) == sink.NamePostgres {
+			s.sinks = append(s.sinks, &postgresSink{writer: writer})
+			break
+		}
 	}
+	s.sinks = append(s.sinks, remote...)
+	return s, nil
 }
 
-// Start begins the enhanced polling service
+// Start begins the enhanced polling service. It returns once ctx is
+// cancelled and the results of the polls still running are stored.
 func (s *EnhancedService) Start(ctx context.Context) error {
 	log.Printf("Starting enhanced poller service with %d workers", s.config.WorkerCount)
 	log.Printf("Registered adapters: %v", s.registry.ListAdapters())
+	log.Printf("Result sinks: %v", s.sinkNames())
+
+	// Start job scheduler
+	s.wg.Add(1)
+	go s.scheduler(ctx)
+
+	s.run(ctx)
+	return nil
+}
+
+// run polls the routers scheduled or polled on demand until ctx is
+// cancelled, then stores what is left and closes the sinks and the writer
+func (s *EnhancedService) run(ctx context.Context) {
+	go s.writer.Run()
 
 	// Start worker goroutines
 	for i := 0; i < s.config.WorkerCount; i++ {
@@ -57,27 +188,38 @@ func (s *EnhancedService) Start(ctx context.Context) error {
 		go s.worker(ctx, i)
 	}
 
-	// Start result processor
+	// Start on-demand poll dispatcher
 	s.wg.Add(1)
-	go s.resultProcessor(ctx)
+	go s.dispatchOnDemand(ctx)
 
-	// Start job scheduler
-	s.wg.Add(1)
-	go s.scheduler(ctx)
+	// Start result processor; it runs until the results are closed
+	processed := make(chan struct{})
+	go func() {
+		defer close(processed)
+		s.resultProcessor(context.WithoutCancel(ctx))
+	}()
 
 	// Wait for context cancellation
 	<-ctx.Done()
 	log.Println("Enhanced poller service shutting down...")
 
-	// Close channels
-	close(s.jobs)
-
-	// Wait for workers to finish
+	// Wait for workers and on-demand polls to finish. The job channels stay
+	// open since PollNow may still be sending; workers stop on the context
+	// instead.
 	s.wg.Wait()
 	close(s.results)
 
+	// Store the results still queued, then flush what the sinks and the
+	// writer buffered
+	<-processed
+	for _, rs := range s.sinks {
+		if err := rs.Close(); err != nil {
+			log.Printf("Error closing %s result sink: %v", rs.Name(), err)
+		}
+	}
+	s.writer.Close()
+
 	log.Println("Enhanced poller service stopped")
-	return nil
 }
 
 // scheduler periodically fetches routers that need polling
@@ -100,25 +242,70 @@ func (s *EnhancedService) scheduler(ctx context.Context) {
 	}
 }
 
-// fetchAndScheduleRouters queries database for routers needing polling
+// routerColumns are the router fields loaded for polling, including the
+// legacy SNMP settings used when a router has no capabilities
+const routerColumns = `
+	r.id, r.tenant_id, r.name, r.management_ip, r.vendor, r.status,
+	r.polling_enabled, r.polling_interval_seconds, r.last_polled_at,
+	r.snmp_version, r.snmp_community, r.snmp_port
+`
+
+// scanRouter scans a row of routerColumns
+func scanRouter(row interface{ Scan(...interface{}) error }) (*models.EnhancedRouter, error) {
+	router := &models.EnhancedRouter{}
+	var snmpVersion *string
+	var snmpPort *int
+
+	err := row.Scan(
+		&router.ID,
+		&router.TenantID,
+		&router.Name,
+		&router.ManagementIP,
+		&router.Vendor,
+		&router.Status,
+		&router.PollingEnabled,
+		&router.PollingIntervalSeconds,
+		&router.LastPolledAt,
+		&snmpVersion,
+		&router.SNMPCommunity,
+		&snmpPort,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	router.SNMPVersion = "v2c"
+	if snmpVersion != nil && *snmpVersion != "" {
+		router.SNMPVersion = *snmpVersion
+	}
+	router.SNMPPort = 161
+	if snmpPort != nil {
+		router.SNMPPort = *snmpPort
+	}
+	return router, nil
+}
+
+// fetchAndScheduleRouters queries database for routers needing polling,
+// leaving out those in a maintenance window that pauses polling
 func (s *EnhancedService) fetchAndScheduleRouters() {
-	// Query routers with their capabilities
 	query := `
-		SELECT 
-			r.id, r.tenant_id, r.name, r.management_ip, r.vendor, r.status,
-			r.polling_enabled, r.polling_interval_seconds, r.last_polled_at,
-			rc.preferred_method, rc.fallback_order
+		SELECT ` + routerColumns + `
 		FROM routers r
-		LEFT JOIN router_capabilities rc ON r.id = rc.router_id
 		WHERE r.polling_enabled = true
 		  AND r.status = 'active'
 		  AND (r.last_polled_at IS NULL 
 		       OR r.last_polled_at < NOW() - (r.polling_interval_seconds || ' seconds')::INTERVAL)
+		  AND NOT (r.id = ANY($2))
 		ORDER BY r.last_polled_at NULLS FIRST
 		LIMIT $1
 	`
 
-	rows, err := s.db.Query(query, s.config.ConcurrentPolls)
+	paused := s.windows.PausedRouters(time.Now())
+	if paused == nil {
+		paused = []uuid.UUID{}
+	}
+
+	rows, err := s.db.Query(query, s.config.ConcurrentPolls, pq.Array(paused))
 	if err != nil {
 		log.Printf("Error querying routers: %v", err)
 		return
@@ -127,37 +314,12 @@ func (s *EnhancedService) fetchAndScheduleRouters() {
 
 	count := 0
 	for rows.Next() {
-		router := &models.EnhancedRouter{}
-		router.Capabilities = &models.RouterCapabilities{}
-
-		var lastPolled *time.Time
-		var preferredMethod, fallbackOrder *string
-
-		err := rows.Scan(
-			&router.ID,
-			&router.TenantID,
-			&router.Name,
-			&router.ManagementIP,
-			&router.Vendor,
-			&router.Status,
-			&router.PollingEnabled,
-			&router.PollingIntervalSeconds,
-			&lastPolled,
-			&preferredMethod,
-			&fallbackOrder,
-		)
-
+		router, err := scanRouter(rows)
 		if err != nil {
 			log.Printf("Error scanning router: %v", err)
 			continue
 		}
 
-		router.LastPolledAt = lastPolled
-
-		if preferredMethod != nil {
-			router.Capabilities.PreferredMethod = *preferredMethod
-		}
-
 		// Load full capabilities for this router
 		s.loadRouterCapabilities(router)
 
@@ -166,7 +328,7 @@ func (s *EnhancedService) fetchAndScheduleRouters() {
 
 		// Send to job channel (non-blocking)
 		select {
-		case s.jobs <- router:
+		case s.jobs <- &pollJob{router: router}:
 			count++
 		default:
 			// Channel full, skip this router
@@ -179,34 +341,77 @@ func (s *EnhancedService) fetchAndScheduleRouters() {
 	}
 }
 
-// loadRouterCapabilities loads full capabilities for a router
+// loadRouter loads a router with its capabilities and roles for an
+// on-demand poll or health check
+func (s *EnhancedService) loadRouter(ctx context.Context, routerID uuid.UUID) (*models.EnhancedRouter, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+routerColumns+` FROM routers r WHERE r.id = $1`, routerID)
+	router, err := scanRouter(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrRouterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load router: %w", err)
+	}
+
+	s.loadRouterCapabilities(router)
+	s.loadRouterRoles(router)
+	return router, nil
+}
+
+// loadRouterCapabilities loads full capabilities for a router and decrypts
+// its credentials. Credentials set on the capabilities take precedence over
+// the referenced credential profiles. Routers without capabilities are
+// polled over SNMP with their legacy settings; SNMP capabilities without a
+// version or community, from either, inherit them too.
 func (s *EnhancedService) loadRouterCapabilities(router *models.EnhancedRouter) {
 	query := `
 		SELECT 
-			snmp_enabled, snmp_version, snmp_community, snmp_port, snmp_timeout_seconds, snmp_retries,
-			api_enabled, api_type, api_endpoint, api_port, api_username, api_password, 
-			api_use_tls, api_verify_cert, api_timeout_seconds,
-			ssh_enabled, ssh_host, ssh_port, ssh_username, ssh_password, ssh_timeout_seconds,
-			preferred_method, fallback_order
-		FROM router_capabilities
-		WHERE router_id = $1
+			COALESCE(rc.snmp_enabled, false), COALESCE(rc.snmp_version, sp.snmp_version, r.snmp_version, 'v2c'),
+			COALESCE(rc.snmp_community, sp.snmp_community, r.snmp_community),
+			COALESCE(rc.snmp_port, r.snmp_port, 161),
+			COALESCE(rc.snmp_timeout_seconds, $2), COALESCE(rc.snmp_retries, $3),
+			COALESCE(rc.snmp_v3_username, sp.username),
+			COALESCE(rc.snmp_v3_auth_protocol, sp.snmp_v3_auth_protocol),
+			COALESCE(rc.snmp_v3_auth_password, sp.snmp_v3_auth_password),
+			COALESCE(rc.snmp_v3_priv_protocol, sp.snmp_v3_priv_protocol),
+			COALESCE(rc.snmp_v3_priv_password, sp.snmp_v3_priv_password),
+			COALESCE(rc.api_enabled, false), COALESCE(rc.api_type, ''), COALESCE(rc.api_endpoint, ''),
+			rc.api_port, COALESCE(rc.api_username, ap.username, ''),
+			COALESCE(rc.api_password, ap.password, ''),
+			COALESCE(rc.api_use_tls, true), COALESCE(rc.api_verify_cert, true),
+			COALESCE(rc.api_timeout_seconds, $2),
+			COALESCE(rc.ssh_enabled, false), COALESCE(rc.ssh_host, ''), COALESCE(rc.ssh_port, 22),
+			COALESCE(rc.ssh_username, hp.username, ''), COALESCE(rc.ssh_password, hp.password),
+			COALESCE(rc.ssh_private_key, hp.private_key), COALESCE(rc.ssh_timeout_seconds, $2),
+			COALESCE(rc.preferred_method, ''), rc.fallback_order
+		FROM router_capabilities rc
+		JOIN routers r ON r.id = rc.router_id
+		LEFT JOIN credential_profiles sp ON sp.id = rc.snmp_profile_id
+		LEFT JOIN credential_profiles ap ON ap.id = rc.api_profile_id
+		LEFT JOIN credential_profiles hp ON hp.id = rc.ssh_profile_id
+		WHERE rc.router_id = $1
 	`
 
 	capabilities := &models.RouterCapabilities{
-		SNMP: &models.SNMPCapability{},
-		API:  &models.APICapability{},
-		SSH:  &models.SSHCapability{},
+		RouterID: router.ID,
+		TenantID: router.TenantID,
+		SNMP:     &models.SNMPCapability{},
+		API:      &models.APICapability{},
+		SSH:      &models.SSHCapability{},
 	}
 
-	var fallbackOrder *string
-
-	err := s.db.QueryRow(query, router.ID).Scan(
+	err := s.db.QueryRow(query, router.ID, s.config.TimeoutSeconds, s.config.RetryAttempts).Scan(
 		&capabilities.SNMP.Enabled,
 		&capabilities.SNMP.Version,
 		&capabilities.SNMP.Community,
 		&capabilities.SNMP.Port,
 		&capabilities.SNMP.TimeoutSeconds,
 		&capabilities.SNMP.Retries,
+		&capabilities.SNMP.V3Username,
+		&capabilities.SNMP.V3AuthProtocol,
+		&capabilities.SNMP.V3AuthPassword,
+		&capabilities.SNMP.V3PrivProtocol,
+		&capabilities.SNMP.V3PrivPassword,
 		&capabilities.API.Enabled,
 		&capabilities.API.Type,
 		&capabilities.API.Endpoint,
@@ -221,20 +426,62 @@ func (s *EnhancedService) loadRouterCapabilities(router *models.EnhancedRouter)
 		&capabilities.SSH.Port,
 		&capabilities.SSH.Username,
 		&capabilities.SSH.Password,
+		&capabilities.SSH.PrivateKey,
 		&capabilities.SSH.TimeoutSeconds,
 		&capabilities.PreferredMethod,
-		&fallbackOrder,
+		pq.Array(&capabilities.FallbackOrder),
 	)
 
+	if err == sql.ErrNoRows {
+		capabilities = s.legacyCapabilities(router)
+		err = nil
+	}
+	if err == nil {
+		err = s.decryptCredentials(capabilities)
+	}
 	if err != nil {
-		// If no capabilities found, router might be using legacy config
-		log.Printf("No capabilities found for router %s, using legacy config", router.Name)
+		log.Printf("Error loading capabilities for router %s: %v", router.Name, err)
+		router.Capabilities = &models.RouterCapabilities{}
 		return
 	}
 
 	router.Capabilities = capabilities
 }
 
+// decryptCredentials decrypts the credentials of loaded capabilities in place
+func (s *EnhancedService) decryptCredentials(capabilities *models.RouterCapabilities) error {
+	snmp, api, ssh := capabilities.SNMP, capabilities.API, capabilities.SSH
+	fields := []*string{snmp.Community, snmp.V3AuthPassword, snmp.V3PrivPassword}
+	if api != nil {
+		fields = append(fields, &api.Password)
+	}
+	if ssh != nil {
+		fields = append(fields, ssh.Password, ssh.PrivateKey)
+	}
+	if err := s.vault.DecryptFields(fields...); err != nil {
+		return fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+	return nil
+}
+
+// legacyCapabilities polls a router without capabilities over SNMP with the
+// version, community and port stored on the router
+func (s *EnhancedService) legacyCapabilities(router *models.EnhancedRouter) *models.RouterCapabilities {
+	return &models.RouterCapabilities{
+		RouterID: router.ID,
+		TenantID: router.TenantID,
+		SNMP: &models.SNMPCapability{
+			Enabled:        true,
+			Version:        router.SNMPVersion,
+			Community:      router.SNMPCommunity,
+			Port:           router.SNMPPort,
+			TimeoutSeconds: s.config.TimeoutSeconds,
+			Retries:        s.config.RetryAttempts,
+		},
+		PreferredMethod: "snmp",
+	}
+}
+
 // loadRouterRoles loads roles for a router
 func (s *EnhancedService) loadRouterRoles(router *models.EnhancedRouter) {
 	query := `
@@ -292,24 +539,56 @@ func (s *EnhancedService) worker(ctx context.Context, id int) {
 		case <-ctx.Done():
 			log.Printf("Enhanced poller worker %d stopping", id)
 			return
-		case router, ok := <-s.jobs:
-			if !ok {
-				log.Printf("Enhanced poller worker %d stopped (channel closed)", id)
-				return
-			}
+		case job := <-s.jobs:
+			s.runJob(ctx, job)
+		}
+	}
+}
+
+// dispatchOnDemand starts on-demand polls, at most onDemandSlots at once,
+// next to the workers so they do not wait for scheduled polls
+func (s *EnhancedService) dispatchOnDemand(ctx context.Context) {
+	defer s.wg.Done()
 
-			result := s.pollRouter(ctx, router)
+	slots := make(chan struct{}, s.onDemandSlots)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case slots <- struct{}{}:
+		}
 
-			// Send result (non-blocking)
-			select {
-			case s.results <- result:
-			case <-ctx.Done():
-				return
-			}
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.onDemand:
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer func() { <-slots }()
+				s.runJob(ctx, job)
+			}()
 		}
 	}
 }
 
+// runJob polls a router and passes the result on. A failed poll cut short
+// by shutdown says nothing about the router; it is not stored, so that it
+// does not count towards the router becoming unreachable. Other results are
+// stored even during shutdown; the result processor reads until run closes
+// the results.
+func (s *EnhancedService) runJob(ctx context.Context, job *pollJob) {
+	result := s.pollRouter(ctx, job.router)
+	if job.done != nil {
+		job.done <- result
+	}
+
+	if !result.Success && errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	s.results <- &polledRouter{router: job.router, result: result}
+}
+
 // pollRouter performs the actual polling using the adapter registry
 func (s *EnhancedService) pollRouter(ctx context.Context, router *models.EnhancedRouter) *adapter.PollResult {
 	log.Printf("Polling router: %s (%s) with roles: %v",
@@ -331,23 +610,21 @@ func (s *EnhancedService) pollRouter(ctx context.Context, router *models.Enhance
 		result.ResponseTimeMs = int(time.Since(pollStartTime).Milliseconds())
 	}
 
+	// Metrics the registry rejected were not stored; the adapter needs fixing
+	for _, err := range result.Metrics.Errors() {
+		log.Printf("Adapter %s reported an invalid metric for router %s: %v", result.AdapterUsed, router.Name, err)
+	}
+
 	// Record polling in history
 	s.recordPollingHistory(result, pollStartTime, time.Now())
 
 	return result
 }
 
-// recordPollingHistory records polling attempt in the database
+// recordPollingHistory queues the polling attempt for the database
 func (s *EnhancedService) recordPollingHistory(result *adapter.PollResult, startTime, endTime time.Time) {
-	query := `
-		INSERT INTO polling_history (
-			tenant_id, router_id, poll_started_at, poll_completed_at,
-			adapter_used, success, error_message, metrics_collected, response_time_ms
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`
-
-	_, err := s.db.Exec(
-		query,
+	err := s.writer.Write(
+		tablePollingHistory,
 		result.TenantID,
 		result.RouterID,
 		startTime,
@@ -364,155 +641,311 @@ func (s *EnhancedService) recordPollingHistory(result *adapter.PollResult, start
 	}
 }
 
-// resultProcessor handles polling results
+// resultProcessor handles polling results until the results channel is
+// closed, so that results sent during shutdown are still stored
 func (s *EnhancedService) resultProcessor(ctx context.Context) {
-	defer s.wg.Done()
-
 	log.Println("Enhanced result processor started")
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Enhanced result processor stopping")
-			return
-		case result, ok := <-s.results:
-			if !ok {
-				log.Println("Enhanced result processor stopped (channel closed)")
-				return
-			}
+	for item := range s.results {
+		s.handleResult(ctx, item)
+	}
+	log.Println("Enhanced result processor stopped (channel closed)")
+}
 
-			if result.Success {
-				s.handleSuccessfulPoll(result)
-			} else {
-				s.handleFailedPoll(result)
-			}
+// handleResult updates the router's polling state, evaluates the alert
+// rules and passes the result to the sinks
+func (s *EnhancedService) handleResult(ctx context.Context, item *polledRouter) {
+	if item.result.Success {
+		s.handleSuccessfulPoll(ctx, item.router, item.result)
+	} else {
+		s.handleFailedPoll(ctx, item.router, item.result)
+	}
+	s.rules.Observe(ctx, item.result)
+
+	for _, rs := range s.sinks {
+		if err := rs.Write(ctx, item.router, item.result); err != nil {
+			log.Printf("Error writing result of router %s to %s sink: %v", item.router.Name, rs.Name(), err)
 		}
 	}
 }
 
+// DeviceExporter returns the prometheus result sink, or nil when it is not
+// configured
+func (s *EnhancedService) DeviceExporter() *sink.Exporter {
+	for _, rs := range s.sinks {
+		if e, ok := rs.(*sink.Exporter); ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// sinkNames lists the configured result sinks
+func (s *EnhancedService) sinkNames() []string {
+	names := make([]string, 0, len(s.sinks))
+	for _, rs := range s.sinks {
+		names = append(names, rs.Name())
+	}
+	return names
+}
+
 // handleSuccessfulPoll processes a successful polling result
-func (s *EnhancedService) handleSuccessfulPoll(result *adapter.PollResult) {
-	// Update last_polled_at timestamp
-	_, err := s.db.Exec(
-		"UPDATE routers SET last_polled_at = $1 WHERE id = $2",
-		result.Timestamp,
-		result.RouterID,
-	)
+func (s *EnhancedService) handleSuccessfulPoll(ctx context.Context, router *models.EnhancedRouter, result *adapter.PollResult) {
+	// Update last_polled_at and clear the failures; an unreachable router
+	// is reachable again
+	var wasUnreachable bool
+	err := s.db.QueryRow(`
+		UPDATE routers r
+		SET last_polled_at = $1, poll_failures = 0, unreachable_since = NULL
+		FROM (SELECT id, unreachable_since FROM routers WHERE id = $2 FOR UPDATE) old
+		WHERE r.id = old.id
+		RETURNING old.unreachable_since IS NOT NULL
+	`, result.Timestamp, result.RouterID).Scan(&wasUnreachable)
 
 	if err != nil {
 		log.Printf("Error updating router poll timestamp: %v", err)
+	} else if wasUnreachable {
+		log.Printf("Router %s is reachable again", router.Name)
+		if _, err := s.alerts.ResolveByDedupKey(ctx, router.TenantID, unreachableDedupKey(router.ID), result.Timestamp); err != nil {
+			log.Printf("Error resolving unreachable alert for router %s: %v", router.Name, err)
+		}
 	}
 
-	// Store router metrics
-	s.storeRouterMetrics(result)
+	// Keep the inventory's OS version current
+	s.refreshOSVersion(result)
 
-	// Store interface metrics
-	s.storeInterfaceMetrics(result)
+	log.Printf("Successfully polled router %s with %d metrics",
+		result.RouterID, result.GetMetricsCount())
+}
 
-	// Store PPPoE sessions
-	if len(result.PPPoESessions) > 0 {
-		s.storePPPoESessions(result)
+// refreshOSVersion updates the router's os_version when the poll reported a different one
+func (s *EnhancedService) refreshOSVersion(result *adapter.PollResult) {
+	version, ok := result.Metrics.Text("os_version")
+	if !ok || version == "" {
+		return
 	}
 
-	// Store NAT sessions
-	if len(result.NATSessions) > 0 {
-		s.storeNATSessions(result)
+	res, err := s.db.Exec(
+		`UPDATE routers SET os_version = $1, updated_at = $2
+		 WHERE id = $3 AND os_version IS DISTINCT FROM $1`,
+		version,
+		result.Timestamp,
+		result.RouterID,
+	)
+	if err != nil {
+		log.Printf("Error updating router OS version: %v", err)
+		return
 	}
 
-	// Store DHCP leases
-	if len(result.DHCPLeases) > 0 {
-		s.storeDHCPLeases(result)
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("Router %s OS version is now %s", result.RouterID, version)
 	}
-
-	log.Printf("Successfully polled router %s with %d metrics",
-		result.RouterID, result.GetMetricsCount())
 }
 
-// storeRouterMetrics stores router-level metrics
-func (s *EnhancedService) storeRouterMetrics(result *adapter.PollResult) {
-	query := `
-		INSERT INTO router_metrics (
-			tenant_id, router_id, timestamp, 
-			cpu_percent, memory_percent, uptime_seconds, temperature_celsius
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
-
-	cpuPercent, _ := result.Metrics["cpu_percent"].(float64)
-	memoryPercent, _ := result.Metrics["memory_percent"].(float64)
-	uptimeSeconds, _ := result.Metrics["uptime_seconds"].(int64)
-	temperatureCelsius, _ := result.Metrics["temperature_celsius"].(float64)
+// handleFailedPoll processes a failed polling result. Once a router fails
+// UnreachableAfter polls in a row it is marked unreachable and an alert is
+// raised; failures during maintenance are not counted.
+func (s *EnhancedService) handleFailedPoll(ctx context.Context, router *models.EnhancedRouter, result *adapter.PollResult) {
+	log.Printf("Failed to poll router %s: %s", result.RouterID, result.ErrorMessage)
 
-	_, err := s.db.Exec(
-		query,
-		result.TenantID,
-		result.RouterID,
-		result.Timestamp,
-		cpuPercent,
-		memoryPercent,
-		uptimeSeconds,
-		temperatureCelsius,
-	)
+	if w := s.windows.Active(router.ID, result.Timestamp); w != nil {
+		log.Printf("Router %s is in maintenance window %q; failure not counted", router.Name, w.Name)
+		return
+	}
 
+	var failures int
+	var becameUnreachable bool
+	err := s.db.QueryRow(`
+		UPDATE routers r
+		SET poll_failures = r.poll_failures + 1,
+			unreachable_since = COALESCE(r.unreachable_since,
+				CASE WHEN r.poll_failures + 1 >= $2 THEN $3::timestamptz END)
+		FROM (SELECT id, unreachable_since FROM routers WHERE id = $1 FOR UPDATE) old
+		WHERE r.id = old.id
+		RETURNING r.poll_failures, old.unreachable_since IS NULL AND r.unreachable_since IS NOT NULL
+	`, router.ID, max(s.config.UnreachableAfter, 1), result.Timestamp).Scan(&failures, &becameUnreachable)
 	if err != nil {
-		log.Printf("Error storing router metrics: %v", err)
+		log.Printf("Error counting failed poll of router %s: %v", router.Name, err)
+		return
+	}
+	if !becameUnreachable {
+		return
 	}
-}
 
-// storeInterfaceMetrics stores interface-level metrics
-func (s *EnhancedService) storeInterfaceMetrics(result *adapter.PollResult) {
-	// TODO: Implement interface metrics storage
-	// This requires matching interfaces by name and storing stats
-	log.Printf("TODO: Store %d interface metrics", len(result.Interfaces))
+	log.Printf("Router %s is unreachable after %d failed polls", router.Name, failures)
+
+	dedupKey := unreachableDedupKey(router.ID)
+	targetType := "router"
+	targetID := router.ID
+	description := fmt.Sprintf("%s failed %d consecutive polls: %s", router.Name, failures, result.ErrorMessage)
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"source":        models.EventSourcePoller,
+		"router_id":     router.ID,
+		"poll_failures": failures,
+		"error":         result.ErrorMessage,
+	})
+	metadataStr := string(metadata)
+
+	alert := &models.Alert{
+		TenantID:    router.TenantID,
+		Name:        "Router unreachable",
+		Description: &description,
+		Severity:    models.SeverityCritical,
+		TargetType:  &targetType,
+		TargetID:    &targetID,
+		TriggeredAt: result.Timestamp,
+		Metadata:    &metadataStr,
+		DedupKey:    &dedupKey,
+	}
+	if _, err := s.alerts.Raise(ctx, alert); err != nil {
+		log.Printf("Error raising unreachable alert for router %s: %v", router.Name, err)
+	}
 }
 
-// storePPPoESessions stores PPPoE session data
-func (s *EnhancedService) storePPPoESessions(result *adapter.PollResult) {
-	// TODO: Implement PPPoE session storage
-	// This should update existing sessions or insert new ones
-	log.Printf("TODO: Store %d PPPoE sessions", len(result.PPPoESessions))
+// unreachableDedupKey identifies the unreachable alert of a router
+func unreachableDedupKey(routerID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s:unreachable", models.EventSourcePoller, routerID)
 }
 
-// storeNATSessions stores NAT session data
-func (s *EnhancedService) storeNATSessions(result *adapter.PollResult) {
-	// TODO: Implement NAT session storage
-	log.Printf("TODO: Store %d NAT sessions", len(result.NATSessions))
+// PollNow polls a router ahead of its schedule and returns the result. The
+// poll does not wait for scheduled polls; ErrQueueFull is returned when no
+// on-demand slot frees up before ctx is done. When ctx ends while the poll
+// runs, ctx's error is returned and the result is still stored once the
+// poll completes.
+func (s *EnhancedService) PollNow(ctx context.Context, routerID uuid.UUID) (*adapter.PollResult, error) {
+	router, err := s.loadRouter(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pollNow(ctx, router)
 }
 
-// storeDHCPLeases stores DHCP lease data
-func (s *EnhancedService) storeDHCPLeases(result *adapter.PollResult) {
-	// TODO: Implement DHCP lease storage
-	log.Printf("TODO: Store %d DHCP leases", len(result.DHCPLeases))
+// pollNow hands a loaded router to the on-demand dispatcher and waits for
+// the result
+func (s *EnhancedService) pollNow(ctx context.Context, router *models.EnhancedRouter) (*adapter.PollResult, error) {
+	job := &pollJob{router: router, done: make(chan *adapter.PollResult, 1)}
+	select {
+	case s.onDemand <- job:
+	case <-ctx.Done():
+		return nil, ErrQueueFull
+	}
+
+	select {
+	case result := <-job.done:
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
-// handleFailedPoll processes a failed polling result
-func (s *EnhancedService) handleFailedPoll(result *adapter.PollResult) {
-	log.Printf("Failed to poll router %s: %s", result.RouterID, result.ErrorMessage)
+// HealthCheck tests connectivity to a router through the named adapter, or
+// through the first adapter in the router's fallback order that succeeds
+// when adapterName is empty
+func (s *EnhancedService) HealthCheck(ctx context.Context, routerID uuid.UUID, adapterName string) (*HealthResult, error) {
+	router, err := s.loadRouter(ctx, routerID)
+	if err != nil {
+		return nil, err
+	}
 
-	// TODO: Create alert for failed polling
-	// TODO: Update router status if consecutive failures exceed threshold
-}
+	var adapters []adapter.PollerAdapter
+	if adapterName != "" {
+		a, err := s.registry.GetAdapterByName(adapterName)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAdapter, adapterName)
+		}
+		if !a.CanHandle(router) {
+			return nil, ErrAdapterNotApplicable
+		}
+		adapters = []adapter.PollerAdapter{a}
+	} else {
+		adapters = s.registry.GetAdapterWithFallback(router)
+		if len(adapters) == 0 {
+			return nil, ErrAdapterNotApplicable
+		}
+	}
 
-// PollNow triggers immediate polling of a specific router
-func (s *EnhancedService) PollNow(routerID uuid.UUID) error {
-	// Query router details with capabilities
-	router := &models.EnhancedRouter{}
-	err := s.db.QueryRow(
-		"SELECT id, tenant_id, name, management_ip FROM routers WHERE id = $1",
-		routerID,
-	).Scan(&router.ID, &router.TenantID, &router.Name, &router.ManagementIP)
+	var result *HealthResult
+	for _, a := range adapters {
+		result = runCheck(router, a.GetAdapterName(), func() error {
+			return checkHealth(ctx, a, router)
+		})
+		if result.Healthy || ctx.Err() != nil {
+			break
+		}
+	}
+	return result, nil
+}
 
+// CheckCredentials tests a router's credentials of one method: SNMP and
+// API credentials through the adapter using them, SSH credentials by
+// logging in
+func (s *EnhancedService) CheckCredentials(ctx context.Context, routerID uuid.UUID, method string) (*HealthResult, error) {
+	router, err := s.loadRouter(ctx, routerID)
 	if err != nil {
-		return fmt.Errorf("router not found: %w", err)
+		return nil, err
 	}
 
-	// Load capabilities and roles
-	s.loadRouterCapabilities(router)
-	s.loadRouterRoles(router)
+	if method == models.CredentialMethodSSH {
+		ssh := router.Capabilities.SSH
+		if ssh == nil || !ssh.Enabled {
+			return nil, ErrAdapterNotApplicable
+		}
+		timeout := time.Duration(s.config.TimeoutSeconds) * time.Second
+		return runCheck(router, models.CredentialMethodSSH, func() error {
+			client, err := sshclient.Dial(ctx, router, timeout)
+			if err != nil {
+				return err
+			}
+			return client.Close()
+		}), nil
+	}
+
+	// The SNMP adapter uses SNMP credentials; every other adapter talks to
+	// a vendor API
+	for _, name := range s.registry.ListAdapters() {
+		a, err := s.registry.GetAdapterByName(name)
+		if err != nil || !a.CanHandle(router) ||
+			(name == models.CredentialMethodSNMP) != (method == models.CredentialMethodSNMP) {
+			continue
+		}
+		return runCheck(router, a.GetAdapterName(), func() error {
+			return checkHealth(ctx, a, router)
+		}), nil
+	}
+	return nil, ErrAdapterNotApplicable
+}
 
-	// Send to job channel
-	s.jobs <- router
+// runCheck times a connectivity check of a router
+func runCheck(router *models.EnhancedRouter, name string, check func() error) *HealthResult {
+	start := time.Now()
+	err := check()
+	result := &HealthResult{
+		RouterID:       router.ID,
+		Adapter:        name,
+		Healthy:        err == nil,
+		ResponseTimeMs: int(time.Since(start).Milliseconds()),
+		CheckedAt:      start,
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+	}
+	return result
+}
 
-	return nil
+// checkHealth runs an adapter's health check, giving up when ctx is done
+// since adapters bound their checks with their own timeouts only
+func checkHealth(ctx context.Context, a adapter.PollerAdapter, router *models.EnhancedRouter) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- a.HealthCheck(ctx, router)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // GetAdapterRegistry returns the adapter registry
@@ -0,0 +1,291 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/batch"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/sink"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// blockingAdapter polls routers named "slow" until released or cancelled,
+// and any other router at once
+type blockingAdapter struct {
+	release chan struct{}
+}
+
+func (a *blockingAdapter) GetAdapterName() string                                    { return "test" }
+func (a *blockingAdapter) CanHandle(*models.EnhancedRouter) bool                     { return true }
+func (a *blockingAdapter) GetSupportedMetrics() []string                             { return nil }
+func (a *blockingAdapter) HealthCheck(context.Context, *models.EnhancedRouter) error { return nil }
+
+func (a *blockingAdapter) Poll(ctx context.Context, router *models.EnhancedRouter) (*adapter.PollResult, error) {
+	if router.Name == "slow" {
+		select {
+		case <-a.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	result := adapter.NewPollResult(router.ID, router.TenantID, a.GetAdapterName())
+	result.Success = true
+	return result, nil
+}
+
+// copiedRows records the rows the batch writer copies
+type copiedRows struct {
+	mu   sync.Mutex
+	rows map[string][][]interface{}
+}
+
+func (c *copiedRows) copy(_ context.Context, table string, _ []string, rows [][]interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rows == nil {
+		c.rows = make(map[string][][]interface{})
+	}
+	for _, r := range rows {
+		c.rows[table] = append(c.rows[table], append([]interface{}(nil), r...))
+	}
+	return nil
+}
+
+// testService returns a service with one worker and one on-demand slot
+// polling through a blockingAdapter, and the rows its writer copies. Nothing
+// is started.
+func testService(t *testing.T) (*EnhancedService, *blockingAdapter, *copiedRows) {
+	t.Helper()
+
+	a := &blockingAdapter{release: make(chan struct{})}
+	registry := adapter.NewRegistry(adapter.DefaultAdapterConfig())
+	registry.Register(a)
+
+	copied := &copiedRows{}
+	writer := batch.NewWriterFunc(copied.copy, 100, time.Hour, 100)
+	writer.Register(tablePollingHistory,
+		"tenant_id", "router_id", "poll_started_at", "poll_completed_at",
+		"adapter_used", "success", "error_message", "metrics_collected", "response_time_ms")
+
+	s := &EnhancedService{
+		config:        config.PollerConfig{WorkerCount: 1},
+		registry:      registry,
+		writer:        writer,
+		jobs:          make(chan *pollJob, 1),
+		onDemand:      make(chan *pollJob),
+		onDemandSlots: 1,
+		results:       make(chan *polledRouter, 10),
+	}
+	return s, a, copied
+}
+
+// newTestService returns a testService with its worker and on-demand
+// dispatcher running; the batch writer is never flushed
+func newTestService(ctx context.Context, t *testing.T) (*EnhancedService, *blockingAdapter) {
+	t.Helper()
+
+	s, a, _ := testService(t)
+	s.wg.Add(2)
+	go s.worker(ctx, 0)
+	go s.dispatchOnDemand(ctx)
+	t.Cleanup(func() {
+		close(a.release)
+		s.wg.Wait()
+	})
+	return s, a
+}
+
+func testRouter(name string) *models.EnhancedRouter {
+	return &models.EnhancedRouter{Router: models.Router{ID: uuid.New(), TenantID: uuid.New(), Name: name}}
+}
+
+func TestPollNowSkipsScheduledPolls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, _ := newTestService(ctx, t)
+
+	// The only worker is busy and another scheduled poll is queued
+	s.jobs <- &pollJob{router: testRouter("slow")}
+	s.jobs <- &pollJob{router: testRouter("slow")}
+
+	pollCtx, pollCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer pollCancel()
+	result, err := s.pollNow(pollCtx, testRouter("fast"))
+	if err != nil {
+		t.Fatalf("pollNow: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("result = %+v, want success", result)
+	}
+}
+
+func TestPollNowQueueFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, _ := newTestService(ctx, t)
+
+	// Take the only on-demand slot
+	go s.pollNow(ctx, testRouter("slow"))
+
+	pollCtx, pollCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer pollCancel()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := s.pollNow(pollCtx, testRouter("fast")); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("err = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestPollNowTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, a := newTestService(ctx, t)
+
+	router := testRouter("slow")
+	pollCtx, pollCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer pollCancel()
+	if _, err := s.pollNow(pollCtx, router); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The poll goes on and its result is still stored
+	a.release <- struct{}{}
+	select {
+	case item := <-s.results:
+		if item.router != router || !item.result.Success {
+			t.Errorf("stored result = %+v for %s", item.result, item.router.Name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("result of the timed out poll was not stored")
+	}
+}
+
+func TestCancelledPollsNotStored(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s, _ := newTestService(ctx, t)
+
+	s.jobs <- &pollJob{router: testRouter("slow")}
+	go s.pollNow(context.Background(), testRouter("slow"))
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	s.wg.Wait()
+	if len(s.results) != 0 {
+		item := <-s.results
+		t.Fatalf("stored result of a poll cut short by shutdown: %+v", item.result)
+	}
+}
+
+func TestCompletedPollsStoredOnShutdown(t *testing.T) {
+	s, _, _ := testService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The poll completed as shutdown began
+	router := testRouter("fast")
+	s.runJob(ctx, &pollJob{router: router})
+	if len(s.results) != 1 {
+		t.Fatal("result of a completed poll dropped on shutdown")
+	}
+	if item := <-s.results; item.router != router || !item.result.Success {
+		t.Errorf("stored result = %+v for %s", item.result, item.router.Name)
+	}
+}
+
+func TestShutdownFlushesWriter(t *testing.T) {
+	s, a, copied := testService(t)
+	defer close(a.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.run(ctx)
+	}()
+
+	// A poll cut short by shutdown is not stored as a result, but its
+	// polling history row is
+	router := testRouter("slow")
+	go s.pollNow(context.Background(), router)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("service did not stop")
+	}
+
+	copied.mu.Lock()
+	defer copied.mu.Unlock()
+	rows := copied.rows[tablePollingHistory]
+	if len(rows) != 1 || rows[0][1] != router.ID {
+		t.Fatalf("copied polling history = %v, want the row of the cancelled poll", rows)
+	}
+}
+
+func TestShutdownFlushesSinks(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	influx, err := sink.NewInfluxSink(config.SinkConfig{
+		InfluxURL:    srv.URL + "/api/v2/write?org=isp&bucket=devices",
+		MetricPrefix: "ispmonitor_device",
+		BatchSize:    1000,
+		FlushSeconds: 3600,
+		QueueSize:    10,
+	}, sink.DefaultLabelMap, srv.Client())
+	if err != nil {
+		t.Fatalf("NewInfluxSink: %v", err)
+	}
+
+	s, a, _ := testService(t)
+	defer close(a.release)
+	s.sinks = []sink.ResultSink{influx}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.run(ctx)
+	}()
+
+	// Samples queued by the result processor are only sent at the next
+	// hourly flush unless shutdown sends them
+	router := testRouter("core-1")
+	result := adapter.NewPollResult(router.ID, router.TenantID, "test")
+	result.Success = true
+	result.Metrics.Set("cpu_percent", metric.Float(12.5))
+	influx.Write(context.Background(), router, result)
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("service did not stop")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 || !strings.Contains(bodies[0], "cpu_percent=12.5") {
+		t.Fatalf("influx writes = %q, want the queued sample", bodies)
+	}
+}
@@ -0,0 +1,184 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/sink"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// rowWriter queues rows for the database, as batch.Writer does
+type rowWriter interface {
+	Write(table string, values ...interface{}) error
+}
+
+// postgresSink stores the metrics of successful polls in the database,
+// through the service's batch writer
+type postgresSink struct {
+	writer rowWriter
+}
+
+// Name returns the sink name
+func (p *postgresSink) Name() string {
+	return sink.NamePostgres
+}
+
+// Write stores the metrics of a successful poll
+func (p *postgresSink) Write(ctx context.Context, router *models.EnhancedRouter, result *adapter.PollResult) error {
+	if !result.Success {
+		return nil
+	}
+
+	// Store interface metrics
+	p.storeInterfaceMetrics(result)
+
+	// Store PPPoE sessions
+	if len(result.PPPoESessions) > 0 {
+		p.storePPPoESessions(result)
+	}
+
+	// Store NAT sessions
+	if len(result.NATSessions) > 0 {
+		p.storeNATSessions(result)
+	}
+
+	// Store DHCP leases
+	if len(result.DHCPLeases) > 0 {
+		p.storeDHCPLeases(result)
+	}
+
+	// Store role-specific metrics
+	if err := p.storeRoleMetrics(result); err != nil {
+		return err
+	}
+
+	// Store router metrics
+	return p.storeRouterMetrics(result)
+}
+
+// Close does nothing; the service closes the batch writer after all sinks
+func (p *postgresSink) Close() error {
+	return nil
+}
+
+// storeRouterMetrics queues router-level metrics for the database. Metrics
+// the router did not report are stored as NULL rather than zero.
+func (p *postgresSink) storeRouterMetrics(result *adapter.PollResult) error {
+	float := func(name string) interface{} {
+		if v, ok := result.Metrics.Float(name); ok {
+			return v
+		}
+		return nil
+	}
+	integer := func(name string) interface{} {
+		if v, ok := result.Metrics.Int(name); ok {
+			return v
+		}
+		return nil
+	}
+
+	return p.writer.Write(
+		tableRouterMetrics,
+		result.TenantID,
+		result.RouterID,
+		result.Timestamp,
+		float("cpu_percent"),
+		float("memory_percent"),
+		integer("uptime_seconds"),
+		float("temperature_celsius"),
+	)
+}
+
+// roleSessionCounts are role metrics derived from the sessions and leases of
+// a poll when the adapter does not report them
+var roleSessionCounts = []struct {
+	name  string
+	count func(*adapter.PollResult) int
+}{
+	{"pppoe_session_count", func(r *adapter.PollResult) int { return len(r.PPPoESessions) }},
+	{"nat_session_count", func(r *adapter.PollResult) int { return len(r.NATSessions) }},
+	{"dhcp_lease_count", func(r *adapter.PollResult) int { return len(r.DHCPLeases) }},
+}
+
+// storeRoleMetrics queues one row per router role with the numeric metrics
+// of that role, as registered in metric.Default
+func (p *postgresSink) storeRoleMetrics(result *adapter.PollResult) error {
+	byRole := make(map[string]map[string]interface{})
+	add := func(name string, v interface{}) {
+		def, ok := metric.Default.Lookup(name)
+		if !ok || def.Role == "" || def.Kind == metric.KindInfo {
+			return
+		}
+		if byRole[def.Role] == nil {
+			byRole[def.Role] = make(map[string]interface{})
+		}
+		byRole[def.Role][name] = v
+	}
+
+	for _, name := range result.Metrics.Names() {
+		if v, ok := result.Metrics.Get(name); ok {
+			add(name, v.Interface())
+		}
+	}
+	for _, c := range roleSessionCounts {
+		if n := c.count(result); n > 0 && !result.Metrics.Has(c.name) {
+			add(c.name, int64(n))
+		}
+	}
+
+	roles := make([]string, 0, len(byRole))
+	for role := range byRole {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	for _, role := range roles {
+		metrics, err := json.Marshal(byRole[role])
+		if err != nil {
+			return fmt.Errorf("failed to encode %s metrics: %w", role, err)
+		}
+		if err := p.writer.Write(
+			tableRoleMetrics,
+			result.TenantID,
+			result.RouterID,
+			role,
+			result.Timestamp,
+			string(metrics),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeInterfaceMetrics stores interface-level metrics
+func (p *postgresSink) storeInterfaceMetrics(result *adapter.PollResult) {
+	// TODO: Implement interface metrics storage
+	// This requires matching interfaces by name and storing stats
+	log.Printf("TODO: Store %d interface metrics", len(result.Interfaces))
+}
+
+// storePPPoESessions stores PPPoE session data
+func (p *postgresSink) storePPPoESessions(result *adapter.PollResult) {
+	// TODO: Implement PPPoE session storage
+	// This should update existing sessions or insert new ones
+	log.Printf("TODO: Store %d PPPoE sessions", len(result.PPPoESessions))
+}
+
+// storeNATSessions stores NAT session data
+func (p *postgresSink) storeNATSessions(result *adapter.PollResult) {
+	// TODO: Implement NAT session storage
+	log.Printf("TODO: Store %d NAT sessions", len(result.NATSessions))
+}
+
+// storeDHCPLeases stores DHCP lease data
+func (p *postgresSink) storeDHCPLeases(result *adapter.PollResult) {
+	// TODO: Implement DHCP lease storage
+	log.Printf("TODO: Store %d DHCP leases", len(result.DHCPLeases))
+}
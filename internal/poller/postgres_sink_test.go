@@ -0,0 +1,68 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/google/uuid"
+)
+
+// recordingWriter keeps the rows queued per table
+type recordingWriter struct {
+	rows map[string][][]interface{}
+}
+
+func (w *recordingWriter) Write(table string, values ...interface{}) error {
+	if w.rows == nil {
+		w.rows = make(map[string][][]interface{})
+	}
+	w.rows[table] = append(w.rows[table], values)
+	return nil
+}
+
+func TestPostgresSinkMissingRouterMetrics(t *testing.T) {
+	w := &recordingWriter{}
+	p := &postgresSink{writer: w}
+
+	router := testRouter("core-1")
+	result := adapter.NewPollResult(router.ID, router.TenantID, "snmp")
+	result.Success = true
+	result.Metrics.Set("cpu_percent", metric.Float(12.5))
+	result.Metrics.Set("uptime_seconds", metric.Int(3600))
+
+	if err := p.Write(context.Background(), router, result); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	rows := w.rows[tableRouterMetrics]
+	if len(rows) != 1 {
+		t.Fatalf("%d router metrics rows, want 1", len(rows))
+	}
+	row := rows[0]
+	if row[0] != router.TenantID || row[1] != router.ID {
+		t.Errorf("row of tenant %v router %v", row[0], row[1])
+	}
+
+	// tenant_id, router_id, timestamp, cpu, memory, uptime, temperature
+	want := []interface{}{12.5, nil, int64(3600), nil}
+	for i, v := range want {
+		if row[3+i] != v {
+			t.Errorf("column %d = %#v, want %#v", 3+i, row[3+i], v)
+		}
+	}
+}
+
+func TestPostgresSinkSkipsFailedPolls(t *testing.T) {
+	w := &recordingWriter{}
+	p := &postgresSink{writer: w}
+
+	result := adapter.NewPollResult(uuid.New(), uuid.New(), "snmp")
+	if err := p.Write(context.Background(), testRouter("core-1"), result); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(w.rows) != 0 {
+		t.Errorf("failed poll stored: %v", w.rows)
+	}
+}
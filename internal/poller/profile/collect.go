@@ -0,0 +1,279 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/gosnmp/gosnmp"
+)
+
+// Client reads OIDs from an SNMP agent
+type Client interface {
+	Get(oids []string) (*gosnmp.SnmpPacket, error)
+	Walk(rootOID string) ([]gosnmp.SnmpPDU, error)
+}
+
+// NewClient adapts a connected gosnmp client, walking with GETBULK except
+// for SNMPv1 agents
+func NewClient(g *gosnmp.GoSNMP) Client {
+	return gosnmpClient{g}
+}
+
+type gosnmpClient struct {
+	*gosnmp.GoSNMP
+}
+
+func (c gosnmpClient) Walk(rootOID string) ([]gosnmp.SnmpPDU, error) {
+	if c.Version == gosnmp.Version1 {
+		return c.WalkAll(rootOID)
+	}
+	return c.BulkWalkAll(rootOID)
+}
+
+var errNoValue = errors.New("no such object")
+
+// Collect reads the profile's metrics into samples. Metrics of a router
+// role are only read when hasRole reports the router holds it. Metrics that
+// cannot be read are skipped and returned joined in the error. Missing
+// memory figures are derived from the ones read.
+func (p *Profile) Collect(client Client, samples *metric.Samples, hasRole func(role string) bool) error {
+	c := &collector{client: client, walks: make(map[string][]gosnmp.SnmpPDU)}
+
+	var errs []error
+	for _, m := range p.Metrics {
+		def, _ := metric.Default.Lookup(m.Metric)
+		if def.Role != "" && (hasRole == nil || !hasRole(def.Role)) {
+			continue
+		}
+
+		v, err := c.read(m)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.Metric, err))
+			continue
+		}
+		if err := samples.Set(m.Metric, typed(def, v)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	deriveMemory(samples)
+	return errors.Join(errs...)
+}
+
+// collector reads mapped values, walking each column once
+type collector struct {
+	client Client
+	walks  map[string][]gosnmp.SnmpPDU
+}
+
+func (c *collector) read(m Mapping) (float64, error) {
+	var v float64
+	var err error
+	switch {
+	case m.Aggregate != "":
+		v, err = c.aggregate(m)
+	case m.Index != nil:
+		v, err = c.indexed(m)
+	default:
+		v, err = c.get(m.OID)
+		if err == nil && m.ScaleOID != "" {
+			var scale float64
+			scale, err = c.get(m.ScaleOID)
+			v *= scale
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return v*m.Scale + m.Offset, nil
+}
+
+func (c *collector) get(oid string) (float64, error) {
+	packet, err := c.client.Get([]string{oid})
+	if err != nil {
+		return 0, err
+	}
+	if len(packet.Variables) == 0 {
+		return 0, fmt.Errorf("%s: %w", oid, errNoValue)
+	}
+	v, err := pduFloat(packet.Variables[0])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", oid, err)
+	}
+	return v, nil
+}
+
+// walk returns the rows of a column by index suffix, in agent order
+func (c *collector) walk(oid string) ([]gosnmp.SnmpPDU, error) {
+	if pdus, ok := c.walks[oid]; ok {
+		return pdus, nil
+	}
+	pdus, err := c.client.Walk(oid)
+	if err != nil {
+		return nil, err
+	}
+	c.walks[oid] = pdus
+	return pdus, nil
+}
+
+func (c *collector) indexed(m Mapping) (float64, error) {
+	pdus, err := c.walk(m.Index.OID)
+	if err != nil {
+		return 0, err
+	}
+	for _, pdu := range pdus {
+		if pduText(pdu) != m.Index.Value {
+			continue
+		}
+		row := rowSuffix(m.Index.OID, pdu.Name)
+		v, err := c.get(m.OID + "." + row)
+		if err == nil && m.ScaleOID != "" {
+			var scale float64
+			scale, err = c.get(m.ScaleOID + "." + row)
+			v *= scale
+		}
+		return v, err
+	}
+	return 0, fmt.Errorf("no row of %s is %s", m.Index.OID, m.Index.Value)
+}
+
+func (c *collector) aggregate(m Mapping) (float64, error) {
+	pdus, err := c.walk(m.OID)
+	if err != nil {
+		return 0, err
+	}
+
+	var scales map[string]float64
+	if m.ScaleOID != "" {
+		scalePDUs, err := c.walk(m.ScaleOID)
+		if err != nil {
+			return 0, err
+		}
+		scales = make(map[string]float64, len(scalePDUs))
+		for _, pdu := range scalePDUs {
+			if v, err := pduFloat(pdu); err == nil {
+				scales[rowSuffix(m.ScaleOID, pdu.Name)] = v
+			}
+		}
+	}
+
+	values := make([]float64, 0, len(pdus))
+	for _, pdu := range pdus {
+		v, err := pduFloat(pdu)
+		if err != nil {
+			continue
+		}
+		if scales != nil {
+			scale, ok := scales[rowSuffix(m.OID, pdu.Name)]
+			if !ok {
+				continue
+			}
+			v *= scale
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return 0, fmt.Errorf("%s: no numeric rows", m.OID)
+	}
+
+	result := values[0]
+	for _, v := range values[1:] {
+		switch m.Aggregate {
+		case AggregateAvg, AggregateSum:
+			result += v
+		case AggregateMin:
+			result = math.Min(result, v)
+		case AggregateMax:
+			result = math.Max(result, v)
+		}
+	}
+	if m.Aggregate == AggregateAvg {
+		result /= float64(len(values))
+	}
+	return result, nil
+}
+
+// deriveMemory fills in total, used, free and percent memory from the
+// others
+func deriveMemory(samples *metric.Samples) {
+	total, hasTotal := samples.Int("memory_total_mb")
+	used, hasUsed := samples.Int("memory_used_mb")
+	free, hasFree := samples.Int("memory_free_mb")
+
+	if !hasTotal && hasUsed && hasFree {
+		total, hasTotal = used+free, true
+		samples.Set("memory_total_mb", metric.Int(total))
+	}
+	if !hasTotal || total <= 0 {
+		return
+	}
+
+	if !hasUsed && hasFree {
+		used, hasUsed = total-free, true
+		samples.Set("memory_used_mb", metric.Int(used))
+	}
+	if !hasUsed {
+		return
+	}
+	if !hasFree {
+		samples.Set("memory_free_mb", metric.Int(total-used))
+	}
+	if !samples.Has("memory_percent") {
+		samples.Set("memory_percent", metric.Float(float64(used)/float64(total)*100))
+	}
+}
+
+// typed converts a read value to the metric's type, rounding integers
+func typed(def metric.Definition, v float64) metric.Value {
+	if def.Type == metric.TypeInt {
+		return metric.Int(int64(math.Round(v)))
+	}
+	return metric.Float(v)
+}
+
+// rowSuffix returns the index of a column row
+func rowSuffix(column, name string) string {
+	return strings.TrimPrefix(normalizeOID(name), column+".")
+}
+
+// pduFloat returns a numeric value. Numbers sent as strings, as some MIBs
+// do for load averages, are parsed.
+func pduFloat(pdu gosnmp.SnmpPDU) (float64, error) {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return 0, errNoValue
+	case gosnmp.OctetString:
+		b, _ := pdu.Value.([]byte)
+		v, err := strconv.ParseFloat(strings.TrimSpace(string(b)), 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", b)
+		}
+		return v, nil
+	case gosnmp.OpaqueFloat:
+		v, _ := pdu.Value.(float32)
+		return float64(v), nil
+	case gosnmp.OpaqueDouble:
+		v, _ := pdu.Value.(float64)
+		return v, nil
+	case gosnmp.ObjectIdentifier, gosnmp.IPAddress:
+		return 0, fmt.Errorf("not a number: %v", pdu.Value)
+	}
+	v, _ := new(big.Float).SetInt(gosnmp.ToBigInt(pdu.Value)).Float64()
+	return v, nil
+}
+
+// pduText formats a value for comparison with an index value
+func pduText(pdu gosnmp.SnmpPDU) string {
+	switch v := pdu.Value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return normalizeOID(v)
+	}
+	return fmt.Sprint(pdu.Value)
+}
@@ -0,0 +1,339 @@
+// Package profile maps vendor SNMP MIBs onto router metrics. A profile is
+// matched to a router by sysObjectID prefix and declares which OIDs hold
+// each metric of metric.Default and how their values are scaled. Profiles
+// are YAML files; the built-in ones are embedded and operators may add or
+// replace profiles from a directory without a new release. OIDs may be
+// written as names of loaded MIB objects, e.g. "UCD-SNMP-MIB::ssCpuIdle.0".
+package profile
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed profiles/*.yaml
+var builtin embed.FS
+
+// Aggregations combining the rows of a walked column
+const (
+	AggregateAvg = "avg"
+	AggregateSum = "sum"
+	AggregateMin = "min"
+	AggregateMax = "max"
+)
+
+var (
+	oidPattern  = regexp.MustCompile(`^[0-9]+(\.[0-9]+)+$`)
+	namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// Profile declares the metrics of the routers whose sysObjectID starts with
+// one of SysObjectIDs
+type Profile struct {
+	Name         string    `yaml:"name"`
+	Description  string    `yaml:"description"`
+	SysObjectIDs []string  `yaml:"sys_object_ids"`
+	Extends      string    `yaml:"extends"` // Profile whose metrics are inherited unless redeclared
+	Metrics      []Mapping `yaml:"metrics"`
+
+	source string // File the profile was read from
+}
+
+// Mapping reads one metric. Without Aggregate or Index, OID is read with a
+// GET. With Aggregate the column at OID is walked and its rows combined;
+// with Index the first row whose Index.OID column equals Index.Value is read.
+// The value read is multiplied by ScaleOID's value in the same row, if set,
+// then by Scale and increased by Offset.
+type Mapping struct {
+	Metric    string  `yaml:"metric"`
+	OID       string  `yaml:"oid"`
+	Aggregate string  `yaml:"aggregate"`
+	Index     *Index  `yaml:"index"`
+	ScaleOID  string  `yaml:"scale_oid"`
+	Scale     float64 `yaml:"scale"` // 1 when zero
+	Offset    float64 `yaml:"offset"`
+}
+
+// Index selects a table row by the value of one of its columns. A value
+// naming a MIB object ("HOST-RESOURCES-TYPES::hrStorageRam") is compared as
+// its OID.
+type Index struct {
+	OID   string `yaml:"oid"`
+	Value string `yaml:"value"`
+}
+
+// Set holds profiles by name, with inherited metrics resolved
+type Set struct {
+	profiles map[string]*Profile
+}
+
+// Load reads the built-in profiles and then the *.yaml and *.yml files of
+// dir, if set. A file declaring the name of a loaded profile replaces it.
+// Symbolic OIDs are resolved with mibs, which may be nil.
+func Load(dir string, mibs *mib.Tree) (*Set, error) {
+	profiles := make(map[string]*Profile)
+
+	if err := loadFS(profiles, builtin, "profiles", mibs); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := loadFS(profiles, os.DirFS(dir), ".", mibs); err != nil {
+			return nil, err
+		}
+	}
+
+	return newSet(profiles)
+}
+
+// Parse reads a single profile, resolving symbolic OIDs with mibs
+func Parse(data []byte, mibs *mib.Tree) (*Profile, error) {
+	p := &Profile{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+	if err := p.validate(mibs); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func loadFS(profiles map[string]*Profile, fsys fs.FS, dir string, mibs *mib.Tree) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read profile %s: %w", entry.Name(), err)
+		}
+		p, err := Parse(data, mibs)
+		if err != nil {
+			return fmt.Errorf("invalid profile %s: %w", entry.Name(), err)
+		}
+		p.source = entry.Name()
+		profiles[p.Name] = p
+	}
+	return nil
+}
+
+// newSet resolves inherited metrics and checks sysObjectID prefixes are not
+// claimed twice
+func newSet(profiles map[string]*Profile) (*Set, error) {
+	prefixes := make(map[string]string)
+	resolved := make(map[string]*Profile, len(profiles))
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := profiles[name]
+		for _, prefix := range p.SysObjectIDs {
+			if other, ok := prefixes[prefix]; ok {
+				return nil, fmt.Errorf("profiles %s and %s both match sysObjectID %s", other, name, prefix)
+			}
+			prefixes[prefix] = name
+		}
+
+		metrics, err := inherit(profiles, p, nil)
+		if err != nil {
+			return nil, err
+		}
+		r := *p
+		r.Metrics = metrics
+		resolved[name] = &r
+	}
+
+	return &Set{profiles: resolved}, nil
+}
+
+// inherit returns a profile's metrics followed by those of its ancestors
+// that it does not redeclare
+func inherit(profiles map[string]*Profile, p *Profile, seen []string) ([]Mapping, error) {
+	for _, name := range seen {
+		if name == p.Name {
+			return nil, fmt.Errorf("profile %s extends itself through %s", p.Name, strings.Join(seen, " -> "))
+		}
+	}
+	if p.Extends == "" {
+		return p.Metrics, nil
+	}
+
+	base, ok := profiles[p.Extends]
+	if !ok {
+		return nil, fmt.Errorf("profile %s extends unknown profile %s", p.Name, p.Extends)
+	}
+	inherited, err := inherit(profiles, base, append(seen, p.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool, len(p.Metrics))
+	for _, m := range p.Metrics {
+		declared[m.Metric] = true
+	}
+	metrics := append([]Mapping{}, p.Metrics...)
+	for _, m := range inherited {
+		if !declared[m.Metric] {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+// Match returns the profile with the longest sysObjectID prefix matching
+// sysObjectID, or nil
+func (s *Set) Match(sysObjectID string) *Profile {
+	sysObjectID = normalizeOID(sysObjectID)
+
+	var best *Profile
+	bestLen := 0
+	for _, p := range s.profiles {
+		for _, prefix := range p.SysObjectIDs {
+			if len(prefix) > bestLen && (sysObjectID == prefix || strings.HasPrefix(sysObjectID, prefix+".")) {
+				best, bestLen = p, len(prefix)
+			}
+		}
+	}
+	return best
+}
+
+// Get returns a profile by name
+func (s *Set) Get(name string) (*Profile, bool) {
+	p, ok := s.profiles[name]
+	return p, ok
+}
+
+// Names returns the names of the profiles, sorted
+func (s *Set) Names() []string {
+	names := make([]string, 0, len(s.profiles))
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Source returns the file the profile was read from
+func (p *Profile) Source() string {
+	return p.source
+}
+
+func (p *Profile) validate(mibs *mib.Tree) error {
+	if !namePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid profile name %q", p.Name)
+	}
+	for i, prefix := range p.SysObjectIDs {
+		oid, err := resolveOID(mibs, prefix)
+		if err != nil {
+			return fmt.Errorf("invalid sysObjectID prefix %q: %w", prefix, err)
+		}
+		p.SysObjectIDs[i] = oid
+	}
+
+	seen := make(map[string]bool, len(p.Metrics))
+	for i := range p.Metrics {
+		m := &p.Metrics[i]
+		if err := m.validate(mibs); err != nil {
+			return fmt.Errorf("metric %d (%s): %w", i+1, m.Metric, err)
+		}
+		if seen[m.Metric] {
+			return fmt.Errorf("metric %s mapped twice", m.Metric)
+		}
+		seen[m.Metric] = true
+	}
+	return nil
+}
+
+func (m *Mapping) validate(mibs *mib.Tree) error {
+	def, ok := metric.Default.Lookup(m.Metric)
+	if !ok {
+		return fmt.Errorf("unknown metric %q", m.Metric)
+	}
+	if def.Type == metric.TypeString {
+		return fmt.Errorf("metric %q is not numeric", m.Metric)
+	}
+
+	oid, err := resolveOID(mibs, m.OID)
+	if err != nil {
+		return fmt.Errorf("invalid oid %q: %w", m.OID, err)
+	}
+	m.OID = oid
+	if m.ScaleOID != "" {
+		oid, err := resolveOID(mibs, m.ScaleOID)
+		if err != nil {
+			return fmt.Errorf("invalid scale_oid %q: %w", m.ScaleOID, err)
+		}
+		m.ScaleOID = oid
+	}
+
+	switch m.Aggregate {
+	case "", AggregateAvg, AggregateSum, AggregateMin, AggregateMax:
+	default:
+		return fmt.Errorf("unknown aggregate %q, expected avg, sum, min or max", m.Aggregate)
+	}
+	if m.Index != nil {
+		if m.Aggregate != "" {
+			return fmt.Errorf("index and aggregate are exclusive")
+		}
+		oid, err := resolveOID(mibs, m.Index.OID)
+		if err != nil {
+			return fmt.Errorf("invalid index oid %q: %w", m.Index.OID, err)
+		}
+		m.Index.OID = oid
+		if m.Index.Value == "" {
+			return fmt.Errorf("index value is required")
+		}
+		if strings.Contains(m.Index.Value, "::") {
+			value, err := mibs.Resolve(m.Index.Value)
+			if err != nil {
+				return fmt.Errorf("invalid index value: %w", err)
+			}
+			m.Index.Value = value
+		}
+		m.Index.Value = strings.TrimPrefix(m.Index.Value, ".")
+	}
+	if m.Scale == 0 {
+		m.Scale = 1
+	}
+	return nil
+}
+
+// resolveOID returns the numeric form of an OID written as numbers or as a
+// MIB object name
+func resolveOID(mibs *mib.Tree, oid string) (string, error) {
+	resolved, err := mibs.Resolve(oid)
+	if err != nil {
+		return "", err
+	}
+	if !oidPattern.MatchString(resolved) {
+		return "", fmt.Errorf("%s is not a full OID", resolved)
+	}
+	return resolved, nil
+}
+
+// normalizeOID strips the leading dot some tools print
+func normalizeOID(oid string) string {
+	return strings.TrimPrefix(strings.TrimSpace(oid), ".")
+}
@@ -0,0 +1,312 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/gosnmp/gosnmp"
+)
+
+// fakeClient serves OIDs from a map; walks return the OIDs below the root
+// in insertion order
+type fakeClient struct {
+	oids  []string
+	pdus  map[string]gosnmp.SnmpPDU
+	walks int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{pdus: make(map[string]gosnmp.SnmpPDU)}
+}
+
+func (c *fakeClient) set(oid string, typ gosnmp.Asn1BER, value interface{}) {
+	c.oids = append(c.oids, oid)
+	c.pdus[oid] = gosnmp.SnmpPDU{Name: "." + oid, Type: typ, Value: value}
+}
+
+func (c *fakeClient) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	packet := &gosnmp.SnmpPacket{}
+	for _, oid := range oids {
+		pdu, ok := c.pdus[oid]
+		if !ok {
+			pdu = gosnmp.SnmpPDU{Name: "." + oid, Type: gosnmp.NoSuchObject}
+		}
+		packet.Variables = append(packet.Variables, pdu)
+	}
+	return packet, nil
+}
+
+func (c *fakeClient) Walk(rootOID string) ([]gosnmp.SnmpPDU, error) {
+	c.walks++
+	var pdus []gosnmp.SnmpPDU
+	for _, oid := range c.oids {
+		if strings.HasPrefix(oid, rootOID+".") {
+			pdus = append(pdus, c.pdus[oid])
+		}
+	}
+	return pdus, nil
+}
+
+func mustLoad(t *testing.T, dir string) *Set {
+	t.Helper()
+	set, err := Load(dir, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return set
+}
+
+func TestBuiltinProfiles(t *testing.T) {
+	set := mustLoad(t, "")
+
+	for _, name := range []string{"cisco", "host-resources", "huawei", "juniper", "mikrotik", "ubnt"} {
+		if _, ok := set.Get(name); !ok {
+			t.Errorf("built-in profile %s missing", name)
+		}
+	}
+
+	mikrotik, _ := set.Get("mikrotik")
+	var names []string
+	for _, m := range mikrotik.Metrics {
+		names = append(names, m.Metric)
+	}
+	if got := strings.Join(names, ","); got != "temperature_celsius,cpu_percent,memory_total_mb,memory_used_mb" {
+		t.Errorf("mikrotik metrics = %s, want its own followed by host-resources'", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	set := mustLoad(t, "")
+
+	tests := []struct {
+		sysObjectID string
+		want        string
+	}{
+		{"1.3.6.1.4.1.9.1.1208", "cisco"},
+		{".1.3.6.1.4.1.14988.1", "mikrotik"},
+		{"1.3.6.1.4.1.2636.1.1.1.2.29", "juniper"},
+		{"1.3.6.1.4.1.8072.3.2.10", "host-resources"},
+		{"1.3.6.1.4.1.99.1", ""},    // prefix 1.3.6.1.4.1.9 must not match
+		{"1.3.6.1.4.1.20110.1", ""}, // nor 1.3.6.1.4.1.2011
+	}
+	for _, tt := range tests {
+		got := ""
+		if p := set.Match(tt.sysObjectID); p != nil {
+			got = p.Name
+		}
+		if got != tt.want {
+			t.Errorf("Match(%s) = %q, want %q", tt.sysObjectID, got, tt.want)
+		}
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cisco.yaml", `
+name: cisco
+sys_object_ids: [1.3.6.1.4.1.9]
+metrics:
+  - metric: cpu_percent
+    oid: 1.3.6.1.4.1.9.2.1.58.0
+`)
+	write("acme.yml", `
+name: acme
+sys_object_ids: [1.3.6.1.4.1.9.1.5000]
+extends: host-resources
+`)
+	write("notes.txt", "not a profile")
+
+	set := mustLoad(t, dir)
+
+	cisco, _ := set.Get("cisco")
+	if len(cisco.Metrics) != 1 || cisco.Metrics[0].OID != "1.3.6.1.4.1.9.2.1.58.0" || cisco.Source() != "cisco.yaml" {
+		t.Errorf("cisco not replaced: %+v", cisco)
+	}
+	if p := set.Match("1.3.6.1.4.1.9.1.5000.1"); p == nil || p.Name != "acme" {
+		t.Errorf("longer override prefix not preferred, got %v", p)
+	}
+	if p := set.Match("1.3.6.1.4.1.9.1.1208"); p == nil || p.Name != "cisco" {
+		t.Errorf("other Cisco routers should keep the cisco profile, got %v", p)
+	}
+}
+
+func TestLoadRejectsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "dup.yaml"), []byte("name: other-cisco\nsys_object_ids: [1.3.6.1.4.1.9]\n"), 0o600)
+	if _, err := Load(dir, nil); err == nil || !strings.Contains(err.Error(), "both match") {
+		t.Errorf("Load error = %v, want a prefix conflict", err)
+	}
+
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.yaml"), []byte("name: a\nextends: b\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "b.yaml"), []byte("name: b\nextends: a\n"), 0o600)
+	if _, err := Load(dir, nil); err == nil || !strings.Contains(err.Error(), "extends itself") {
+		t.Errorf("Load error = %v, want an inheritance cycle", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"bad name", "name: Cisco IOS\n", "invalid profile name"},
+		{"unknown field", "name: x\nvendor: acme\n", "vendor"},
+		{"bad prefix", "name: x\nsys_object_ids: [1.3.6.x]\n", "sysObjectID prefix"},
+		{"unknown name", "name: x\nmetrics: [{metric: cpu_percent, oid: ACME-MIB::acmeCpu.0}]\n", "unknown MIB object"},
+		{"unknown metric", "name: x\nmetrics: [{metric: cpu_load, oid: 1.3.6.1.2.1.1}]\n", "unknown metric"},
+		{"string metric", "name: x\nmetrics: [{metric: system_name, oid: 1.3.6.1.2.1.1.5.0}]\n", "not numeric"},
+		{"bad oid", "name: x\nmetrics: [{metric: cpu_percent, oid: 1.3.6.x}]\n", "invalid oid"},
+		{"bad aggregate", "name: x\nmetrics: [{metric: cpu_percent, oid: 1.3.6.1, aggregate: median}]\n", "unknown aggregate"},
+		{"index and aggregate", "name: x\nmetrics: [{metric: cpu_percent, oid: 1.3.6.1, aggregate: avg, index: {oid: 1.3.6.2, value: '1'}}]\n", "exclusive"},
+		{"mapped twice", "name: x\nmetrics: [{metric: cpu_percent, oid: 1.3.6.1}, {metric: cpu_percent, oid: 1.3.6.2}]\n", "mapped twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Parse error = %v, want one mentioning %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseSymbolicOIDs(t *testing.T) {
+	mibs, err := mib.LoadFS(fstest.MapFS{"ACME-MIB": {Data: []byte(`
+ACME-MIB DEFINITIONS ::= BEGIN
+IMPORTS enterprises FROM SNMPv2-SMI;
+acme        OBJECT IDENTIFIER ::= { enterprises 99999 }
+acmeSystem  OBJECT IDENTIFIER ::= { acme 1 }
+acmeCpu     OBJECT IDENTIFIER ::= { acmeSystem 1 }
+acmeRam     OBJECT IDENTIFIER ::= { acmeSystem 2 }
+acmeStorage OBJECT IDENTIFIER ::= { acmeSystem 3 }
+acmeStorageType OBJECT IDENTIFIER ::= { acmeStorage 1 }
+END
+`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Parse([]byte(`
+name: acme
+sys_object_ids: [acme]
+metrics:
+  - metric: cpu_percent
+    oid: ACME-MIB::acmeCpu.0
+  - metric: memory_used_mb
+    oid: acmeStorage.2
+    index: {oid: acmeStorageType, value: "ACME-MIB::acmeRam"}
+`), mibs)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.SysObjectIDs[0] != "1.3.6.1.4.1.99999" || p.Metrics[0].OID != "1.3.6.1.4.1.99999.1.1.0" {
+		t.Errorf("resolved = %v, %s", p.SysObjectIDs, p.Metrics[0].OID)
+	}
+	if idx := p.Metrics[1].Index; idx.OID != "1.3.6.1.4.1.99999.1.3.1" || idx.Value != "1.3.6.1.4.1.99999.1.2" {
+		t.Errorf("index = %+v", idx)
+	}
+}
+
+func TestCollectHostResources(t *testing.T) {
+	set := mustLoad(t, "")
+	p, _ := set.Get("host-resources")
+
+	c := newFakeClient()
+	c.set("1.3.6.1.2.1.25.3.3.1.2.196608", gosnmp.Integer, 10)
+	c.set("1.3.6.1.2.1.25.3.3.1.2.196609", gosnmp.Integer, 31)
+	c.set("1.3.6.1.2.1.25.2.3.1.2.1", gosnmp.ObjectIdentifier, ".1.3.6.1.2.1.25.2.1.4") // hrStorageFixedDisk
+	c.set("1.3.6.1.2.1.25.2.3.1.2.65536", gosnmp.ObjectIdentifier, ".1.3.6.1.2.1.25.2.1.2")
+	c.set("1.3.6.1.2.1.25.2.3.1.4.1", gosnmp.Integer, 4096)
+	c.set("1.3.6.1.2.1.25.2.3.1.4.65536", gosnmp.Integer, 1024)
+	c.set("1.3.6.1.2.1.25.2.3.1.5.1", gosnmp.Integer, 1000000)
+	c.set("1.3.6.1.2.1.25.2.3.1.5.65536", gosnmp.Integer, 2097152)
+	c.set("1.3.6.1.2.1.25.2.3.1.6.65536", gosnmp.Integer, 524288)
+
+	var samples metric.Samples
+	if err := p.Collect(c, &samples, nil); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if v, _ := samples.Float("cpu_percent"); v != 20.5 {
+		t.Errorf("cpu_percent = %v, want 20.5", v)
+	}
+	if v, _ := samples.Int("memory_total_mb"); v != 2048 {
+		t.Errorf("memory_total_mb = %v, want 2048", v)
+	}
+	if v, _ := samples.Int("memory_used_mb"); v != 512 {
+		t.Errorf("memory_used_mb = %v, want 512", v)
+	}
+	if v, _ := samples.Int("memory_free_mb"); v != 1536 {
+		t.Errorf("memory_free_mb = %v, want 1536", v)
+	}
+	if v, _ := samples.Float("memory_percent"); v != 25 {
+		t.Errorf("memory_percent = %v, want 25", v)
+	}
+	if c.walks != 2 {
+		t.Errorf("walked %d columns, want hrProcessorLoad and hrStorageType once each", c.walks)
+	}
+}
+
+func TestCollectScaleAndOffset(t *testing.T) {
+	set := mustLoad(t, "")
+	p, _ := set.Get("ubnt")
+
+	c := newFakeClient()
+	c.set("1.3.6.1.4.1.2021.11.11.0", gosnmp.Integer, 85)
+	c.set("1.3.6.1.4.1.2021.4.5.0", gosnmp.Integer, 1048576)
+	c.set("1.3.6.1.4.1.2021.4.6.0", gosnmp.Integer, 786432)
+
+	var samples metric.Samples
+	if err := p.Collect(c, &samples, nil); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if v, _ := samples.Float("cpu_percent"); v != 15 {
+		t.Errorf("cpu_percent = %v, want 15", v)
+	}
+	if v, _ := samples.Int("memory_used_mb"); v != 256 {
+		t.Errorf("memory_used_mb = %v, want 256", v)
+	}
+}
+
+func TestCollectRoleMetrics(t *testing.T) {
+	set := mustLoad(t, "")
+	p, _ := set.Get("cisco")
+
+	c := newFakeClient()
+	c.set("1.3.6.1.4.1.9.9.109.1.1.1.1.8.1", gosnmp.Gauge32, uint(7))
+	c.set("1.3.6.1.4.1.9.9.194.1.1.1.0", gosnmp.Gauge32, uint(1843))
+
+	var samples metric.Samples
+	err := p.Collect(c, &samples, func(string) bool { return false })
+	if samples.Has("pppoe_active_sessions") {
+		t.Error("PPPoE metric read for a router without the role")
+	}
+	if err == nil || !strings.Contains(err.Error(), "memory_used_mb") {
+		t.Errorf("Collect error = %v, want the missing memory pools reported", err)
+	}
+	if v, _ := samples.Float("cpu_percent"); v != 7 {
+		t.Errorf("cpu_percent = %v, want 7 despite missing metrics", v)
+	}
+
+	samples = metric.Samples{}
+	p.Collect(c, &samples, func(role string) bool { return role == models.RoleCodePPPoEServer })
+	if v, _ := samples.Int("pppoe_active_sessions"); v != 1843 {
+		t.Errorf("pppoe_active_sessions = %v, want 1843", v)
+	}
+	if samples.Has("pppoe_max_sessions") {
+		t.Error("missing OID stored")
+	}
+}
@@ -0,0 +1,244 @@
+package sink
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// counterFields are interface fields exposed as counters; everything else
+// is a gauge
+var counterFields = map[string]bool{
+	"in_octets":    true,
+	"out_octets":   true,
+	"in_packets":   true,
+	"out_packets":  true,
+	"in_errors":    true,
+	"out_errors":   true,
+	"in_discards":  true,
+	"out_discards": true,
+}
+
+// octetSnapshot is an interface's octet counters at a poll, kept to compute
+// rates at the next one
+type octetSnapshot struct {
+	in, out int64
+	at      time.Time
+}
+
+// exportedRouter is the latest result of a router
+type exportedRouter struct {
+	tenantID uuid.UUID
+	updated  time.Time
+	samples  []Sample
+}
+
+// Exporter keeps the latest polled values of every router and exposes them
+// for Prometheus to scrape. Every series of a metric carries the same label
+// names, with empty values for sources a router lacks. Routers not polled
+// within the stale period are dropped, as are the interface series of a
+// router whose last poll failed.
+type Exporter struct {
+	prefix     string
+	labels     LabelMap
+	staleAfter time.Duration
+
+	// Label names per measurement in exposition order
+	routerLabels    []string
+	interfaceLabels []string
+
+	mu      sync.Mutex
+	routers map[uuid.UUID]*exportedRouter
+	octets  map[uuid.UUID]map[string]octetSnapshot
+
+	now func() time.Time
+}
+
+// NewExporter creates an exporter sink
+func NewExporter(cfg config.SinkConfig, labels LabelMap) *Exporter {
+	e := &Exporter{
+		prefix:     cfg.MetricPrefix,
+		labels:     labels,
+		staleAfter: time.Duration(max(cfg.ExporterStaleSeconds, 1)) * time.Second,
+		routers:    make(map[uuid.UUID]*exportedRouter),
+		octets:     make(map[uuid.UUID]map[string]octetSnapshot),
+		now:        time.Now,
+	}
+	for source, name := range labels {
+		e.interfaceLabels = append(e.interfaceLabels, name)
+		if source != SourceInterface {
+			e.routerLabels = append(e.routerLabels, name)
+		}
+	}
+	sort.Strings(e.routerLabels)
+	sort.Strings(e.interfaceLabels)
+	return e
+}
+
+// Name returns the sink name
+func (e *Exporter) Name() string {
+	return NamePrometheus
+}
+
+// Write replaces the router's exported values with those of result and
+// adds interface rates computed from the previous poll's octet counters
+func (e *Exporter) Write(ctx context.Context, router *models.EnhancedRouter, result *adapter.PollResult) error {
+	samples := Samples(router, result, e.labels)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if result.Success {
+		previous := e.octets[result.RouterID]
+		current := make(map[string]octetSnapshot, len(result.Interfaces))
+		for _, iface := range result.Interfaces {
+			snap := octetSnapshot{in: iface.InOctets, out: iface.OutOctets, at: result.Timestamp}
+			current[iface.Name] = snap
+
+			prev, ok := previous[iface.Name]
+			elapsed := snap.at.Sub(prev.at).Seconds()
+			// Counter resets and wraps make the delta negative; skip the
+			// rate until the next poll
+			if !ok || elapsed <= 0 || snap.in < prev.in || snap.out < prev.out {
+				continue
+			}
+			ifLabels := e.labels.build(router, result, iface.Name)
+			samples = append(samples,
+				Sample{MeasurementInterface, "in_bits_per_second", ifLabels, float64(snap.in-prev.in) * 8 / elapsed, result.Timestamp},
+				Sample{MeasurementInterface, "out_bits_per_second", ifLabels, float64(snap.out-prev.out) * 8 / elapsed, result.Timestamp},
+			)
+		}
+		e.octets[result.RouterID] = current
+	}
+
+	e.routers[result.RouterID] = &exportedRouter{
+		tenantID: result.TenantID,
+		updated:  e.now(),
+		samples:  samples,
+	}
+	return nil
+}
+
+// Close does nothing; exported values are only kept in memory
+func (e *Exporter) Close() error {
+	return nil
+}
+
+// Handler serves the exported values. The tenant query parameter, which
+// may be repeated, limits the output to those tenants.
+func (e *Exporter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tenants map[uuid.UUID]bool
+		for _, v := range r.URL.Query()["tenant"] {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				http.Error(w, "invalid tenant "+v, http.StatusBadRequest)
+				return
+			}
+			if tenants == nil {
+				tenants = make(map[uuid.UUID]bool)
+			}
+			tenants[id] = true
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(&exporterCollector{exporter: e, tenants: tenants})
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, r)
+	})
+}
+
+// exporterCollector collects the exporter's values of some tenants, or of
+// all when tenants is nil
+type exporterCollector struct {
+	exporter *Exporter
+	tenants  map[uuid.UUID]bool
+}
+
+// Describe sends no descriptors; metric names depend on what routers report
+func (c *exporterCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect sends the latest values and drops stale routers
+func (c *exporterCollector) Collect(ch chan<- prometheus.Metric) {
+	e := c.exporter
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cutoff := e.now().Add(-e.staleAfter)
+	descs := make(map[string]*prometheus.Desc)
+	for id, router := range e.routers {
+		if router.updated.Before(cutoff) {
+			delete(e.routers, id)
+			delete(e.octets, id)
+			continue
+		}
+		if c.tenants != nil && !c.tenants[router.tenantID] {
+			continue
+		}
+
+		for _, s := range router.samples {
+			names := e.routerLabels
+			if s.Measurement == MeasurementInterface {
+				names = e.interfaceLabels
+			}
+
+			name := s.MetricName(e.prefix)
+			desc, ok := descs[name]
+			if !ok {
+				help := "Polled " + s.Measurement + " " + strings.ReplaceAll(s.Field, "_", " ")
+				if def, ok := routerMetric(s); ok {
+					help = def.Help
+				}
+				desc = prometheus.NewDesc(name, help, names, nil)
+				descs[name] = desc
+			}
+
+			valueType := prometheus.GaugeValue
+			if s.Measurement == MeasurementInterface && counterFields[s.Field] {
+				valueType = prometheus.CounterValue
+			} else if def, ok := routerMetric(s); ok && def.Kind == metric.KindCounter {
+				valueType = prometheus.CounterValue
+			}
+			// No timestamps, so that Prometheus marks series stale once
+			// they are dropped here
+			m, err := prometheus.NewConstMetric(desc, valueType, s.Value, labelValues(names, s.Labels)...)
+			if err != nil {
+				continue
+			}
+			ch <- m
+		}
+	}
+}
+
+// routerMetric returns the definition of a router sample's metric
+func routerMetric(s Sample) (metric.Definition, bool) {
+	if s.Measurement != MeasurementRouter {
+		return metric.Definition{}, false
+	}
+	return metric.Default.Lookup(s.Field)
+}
+
+// labelValues orders the values of labels by names, with empty values for
+// names the labels lack
+func labelValues(names []string, labels []Label) []string {
+	values := make([]string, len(names))
+	for i, name := range names {
+		for _, l := range labels {
+			if l.Name == name {
+				values[i] = l.Value
+				break
+			}
+		}
+	}
+	return values
+}
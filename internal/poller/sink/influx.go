@@ -0,0 +1,168 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// maxDatagramSize keeps line protocol datagrams within a typical MTU
+const maxDatagramSize = 1400
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+// InfluxSink writes samples as InfluxDB line protocol, either to an HTTP
+// write endpoint (InfluxDB 1.x /write or 2.x /api/v2/write) or as UDP
+// datagrams. Each line holds the fields of one measurement and label set.
+type InfluxSink struct {
+	prefix string
+	labels LabelMap
+
+	client  *http.Client
+	url     string
+	header  http.Header
+	udpAddr string
+
+	batcher *batcher
+}
+
+// NewInfluxSink creates a sink for cfg.InfluxURL, an http(s) write URL
+// including its query parameters, or udp://host:port
+func NewInfluxSink(cfg config.SinkConfig, labels LabelMap, client *http.Client) (*InfluxSink, error) {
+	u, err := url.Parse(cfg.InfluxURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Influx sink URL %q", cfg.InfluxURL)
+	}
+
+	s := &InfluxSink{prefix: cfg.MetricPrefix, labels: labels, client: client}
+	switch u.Scheme {
+	case "http", "https":
+		s.url = cfg.InfluxURL
+		s.header = http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+		if cfg.InfluxToken != "" {
+			s.header.Set("Authorization", "Token "+cfg.InfluxToken)
+		}
+	case "udp":
+		s.udpAddr = u.Host
+	default:
+		return nil, fmt.Errorf("unsupported Influx sink scheme %q", u.Scheme)
+	}
+
+	s.batcher = newBatcher(NameInflux, cfg, s.send)
+	return s, nil
+}
+
+// Name returns the sink name
+func (s *InfluxSink) Name() string {
+	return NameInflux
+}
+
+// Write queues the samples of a result
+func (s *InfluxSink) Write(ctx context.Context, router *models.EnhancedRouter, result *adapter.PollResult) error {
+	s.batcher.add(Samples(router, result, s.labels))
+	return nil
+}
+
+// Close sends queued samples
+func (s *InfluxSink) Close() error {
+	s.batcher.close()
+	return nil
+}
+
+func (s *InfluxSink) send(ctx context.Context, samples []Sample) error {
+	lines := LineProtocol(s.prefix, samples)
+	if s.udpAddr != "" {
+		return s.sendUDP(ctx, lines)
+	}
+	return post(ctx, s.client, s.url, lines, s.header)
+}
+
+// sendUDP splits lines into datagrams of whole lines
+func (s *InfluxSink) sendUDP(ctx context.Context, lines []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", s.udpAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for len(lines) > 0 {
+		end := len(lines)
+		if end > maxDatagramSize {
+			// A line longer than a datagram is sent on its own
+			end = bytes.LastIndexByte(lines[:maxDatagramSize], '\n') + 1
+			if end == 0 {
+				end = bytes.IndexByte(lines, '\n') + 1
+			}
+			if end == 0 {
+				end = len(lines)
+			}
+		}
+		if _, err := conn.Write(lines[:end]); err != nil {
+			return err
+		}
+		lines = lines[end:]
+	}
+	return nil
+}
+
+// LineProtocol encodes samples with nanosecond timestamps. Consecutive
+// samples of the same measurement, labels and time share a line.
+func LineProtocol(prefix string, samples []Sample) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(samples); {
+		first := samples[i]
+		buf.WriteString(measurementEscaper.Replace(metricName(prefix, first.Measurement)))
+		for _, l := range first.Labels {
+			if l.Value == "" {
+				continue
+			}
+			buf.WriteByte(',')
+			buf.WriteString(tagEscaper.Replace(l.Name))
+			buf.WriteByte('=')
+			buf.WriteString(tagEscaper.Replace(l.Value))
+		}
+
+		buf.WriteByte(' ')
+		j := i
+		for ; j < len(samples) && sameSeries(first, samples[j]); j++ {
+			if j > i {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(tagEscaper.Replace(samples[j].Field))
+			buf.WriteByte('=')
+			buf.WriteString(strconv.FormatFloat(samples[j].Value, 'f', -1, 64))
+		}
+
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatInt(first.Time.UnixNano(), 10))
+		buf.WriteByte('\n')
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// sameSeries reports whether b can be written on a's line
+func sameSeries(a, b Sample) bool {
+	if a.Measurement != b.Measurement || !a.Time.Equal(b.Time) || len(a.Labels) != len(b.Labels) {
+		return false
+	}
+	for i := range a.Labels {
+		if a.Labels[i] != b.Labels[i] {
+			return false
+		}
+	}
+	return true
+}
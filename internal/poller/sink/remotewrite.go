@@ -0,0 +1,137 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the remote write 1.0 protobuf messages
+// (prometheus/prompb types.proto and remote.proto)
+const (
+	fieldWriteRequestTimeseries = 1
+	fieldTimeSeriesLabels       = 1
+	fieldTimeSeriesSamples      = 2
+	fieldLabelName              = 1
+	fieldLabelValue             = 2
+	fieldSampleValue            = 1
+	fieldSampleTimestamp        = 2
+)
+
+// RemoteWriteSink sends samples with the Prometheus remote write protocol,
+// accepted by Prometheus, Mimir, Thanos, VictoriaMetrics and others
+type RemoteWriteSink struct {
+	prefix string
+	labels LabelMap
+
+	client *http.Client
+	url    string
+	header http.Header
+
+	batcher *batcher
+}
+
+// NewRemoteWriteSink creates a sink for cfg.RemoteWriteURL
+func NewRemoteWriteSink(cfg config.SinkConfig, labels LabelMap, client *http.Client) (*RemoteWriteSink, error) {
+	u, err := url.Parse(cfg.RemoteWriteURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid remote write URL %q", cfg.RemoteWriteURL)
+	}
+
+	s := &RemoteWriteSink{
+		prefix: cfg.MetricPrefix,
+		labels: labels,
+		client: client,
+		url:    cfg.RemoteWriteURL,
+		header: http.Header{
+			"Content-Type":                      {"application/x-protobuf"},
+			"Content-Encoding":                  {"snappy"},
+			"X-Prometheus-Remote-Write-Version": {"0.1.0"},
+		},
+	}
+	switch {
+	case cfg.RemoteWriteBearerToken != "":
+		s.header.Set("Authorization", "Bearer "+cfg.RemoteWriteBearerToken)
+	case cfg.RemoteWriteUsername != "":
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(cfg.RemoteWriteUsername, cfg.RemoteWritePassword)
+		s.header.Set("Authorization", req.Header.Get("Authorization"))
+	}
+
+	s.batcher = newBatcher(NameRemoteWrite, cfg, s.send)
+	return s, nil
+}
+
+// Name returns the sink name
+func (s *RemoteWriteSink) Name() string {
+	return NameRemoteWrite
+}
+
+// Write queues the samples of a result
+func (s *RemoteWriteSink) Write(ctx context.Context, router *models.EnhancedRouter, result *adapter.PollResult) error {
+	s.batcher.add(Samples(router, result, s.labels))
+	return nil
+}
+
+// Close sends queued samples
+func (s *RemoteWriteSink) Close() error {
+	s.batcher.close()
+	return nil
+}
+
+func (s *RemoteWriteSink) send(ctx context.Context, samples []Sample) error {
+	body := snappy.Encode(nil, WriteRequest(s.prefix, samples))
+	return post(ctx, s.client, s.url, body, s.header)
+}
+
+// WriteRequest encodes samples as a remote write WriteRequest with one time
+// series per sample. Labels are sorted by name, as receivers require.
+func WriteRequest(prefix string, samples []Sample) []byte {
+	var buf, series, msg []byte
+	var labels []Label
+	for _, sample := range samples {
+		series = series[:0]
+
+		labels = append(labels[:0], Label{Name: "__name__", Value: sample.MetricName(prefix)})
+		for _, l := range sample.Labels {
+			if l.Value != "" {
+				labels = append(labels, l)
+			}
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+		for _, l := range labels {
+			msg = appendLabel(msg[:0], l.Name, l.Value)
+			series = protowire.AppendTag(series, fieldTimeSeriesLabels, protowire.BytesType)
+			series = protowire.AppendBytes(series, msg)
+		}
+
+		msg = msg[:0]
+		msg = protowire.AppendTag(msg, fieldSampleValue, protowire.Fixed64Type)
+		msg = protowire.AppendFixed64(msg, math.Float64bits(sample.Value))
+		msg = protowire.AppendTag(msg, fieldSampleTimestamp, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, uint64(sample.Time.UnixMilli()))
+		series = protowire.AppendTag(series, fieldTimeSeriesSamples, protowire.BytesType)
+		series = protowire.AppendBytes(series, msg)
+
+		buf = protowire.AppendTag(buf, fieldWriteRequestTimeseries, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, series)
+	}
+	return buf
+}
+
+func appendLabel(b []byte, name, value string) []byte {
+	b = protowire.AppendTag(b, fieldLabelName, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	b = protowire.AppendTag(b, fieldLabelValue, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
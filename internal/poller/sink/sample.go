@@ -0,0 +1,204 @@
+package sink
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// Label sources that can be mapped to label names
+const (
+	SourceTenant    = "tenant"
+	SourceRouter    = "router"
+	SourceRouterID  = "router_id"
+	SourceInterface = "interface"
+	SourceRole      = "role"
+)
+
+// Measurements samples belong to
+const (
+	MeasurementRouter    = "router"
+	MeasurementInterface = "interface"
+)
+
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Label is a name/value pair identifying a series
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one polled value. Samples of a measurement with the same labels
+// are consecutive in the slices returned by Samples.
+type Sample struct {
+	Measurement string
+	Field       string
+	Labels      []Label // sorted by name
+	Value       float64
+	Time        time.Time
+}
+
+// MetricName returns the Prometheus metric name of the sample
+func (s Sample) MetricName(prefix string) string {
+	return metricName(prefix, s.Measurement) + "_" + sanitizeName(s.Field)
+}
+
+// LabelMap maps label sources to the label names they are written as.
+// Sources missing from the map are not written.
+type LabelMap map[string]string
+
+// DefaultLabelMap writes every source under its own name except the router
+// ID, since the router name is more readable and usually unique per tenant
+var DefaultLabelMap = LabelMap{
+	SourceTenant:    "tenant",
+	SourceRouter:    "router",
+	SourceInterface: "interface",
+	SourceRole:      "role",
+}
+
+// ParseLabelMap parses a comma separated list of source=label pairs, e.g.
+// "tenant=customer,router=device". An empty string yields DefaultLabelMap.
+func ParseLabelMap(spec string) (LabelMap, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultLabelMap, nil
+	}
+
+	m := make(LabelMap)
+	names := make(map[string]bool)
+	for _, pair := range strings.Split(spec, ",") {
+		source, name, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label mapping %q, want source=label", pair)
+		}
+		source, name = strings.TrimSpace(source), strings.TrimSpace(name)
+		switch source {
+		case SourceTenant, SourceRouter, SourceRouterID, SourceInterface, SourceRole:
+		default:
+			return nil, fmt.Errorf("unknown label source %q", source)
+		}
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("label name %q mapped twice", name)
+		}
+		names[name] = true
+		m[source] = name
+	}
+	return m, nil
+}
+
+// Samples converts a polling result into samples. Every result yields the
+// router's up sample; successful ones add the numeric router metrics,
+// session counts and interface counters.
+func Samples(router *models.EnhancedRouter, result *adapter.PollResult, labels LabelMap) []Sample {
+	at := result.Timestamp
+	routerLabels := labels.build(router, result, "")
+
+	up := 0.0
+	if result.Success {
+		up = 1
+	}
+	samples := []Sample{{MeasurementRouter, "up", routerLabels, up, at}}
+	if !result.Success {
+		return samples
+	}
+
+	for _, field := range result.Metrics.Names() {
+		if v, ok := result.Metrics.Float(field); ok {
+			samples = append(samples, Sample{MeasurementRouter, field, routerLabels, v, at})
+		}
+	}
+
+	// Session counts reported by the adapter take precedence
+	counts := []struct {
+		field string
+		count int
+	}{
+		{"pppoe_session_count", len(result.PPPoESessions)},
+		{"nat_session_count", len(result.NATSessions)},
+		{"dhcp_lease_count", len(result.DHCPLeases)},
+	}
+	for _, c := range counts {
+		if !result.Metrics.Has(c.field) && c.count > 0 {
+			samples = append(samples, Sample{MeasurementRouter, c.field, routerLabels, float64(c.count), at})
+		}
+	}
+
+	for _, iface := range result.Interfaces {
+		ifLabels := labels.build(router, result, iface.Name)
+		add := func(field string, v float64) {
+			samples = append(samples, Sample{MeasurementInterface, field, ifLabels, v, at})
+		}
+		add("up", boolValue(iface.Status == "up"))
+		add("admin_up", boolValue(iface.AdminStatus == "up"))
+		add("speed_mbps", float64(iface.Speed))
+		add("in_octets", float64(iface.InOctets))
+		add("out_octets", float64(iface.OutOctets))
+		add("in_packets", float64(iface.InPackets))
+		add("out_packets", float64(iface.OutPackets))
+		add("in_errors", float64(iface.InErrors))
+		add("out_errors", float64(iface.OutErrors))
+		add("in_discards", float64(iface.InDiscards))
+		add("out_discards", float64(iface.OutDiscards))
+		add("utilization_percent", iface.UtilizationPercent)
+	}
+	return samples
+}
+
+// build returns the mapped labels of a router, or of one of its interfaces
+// when ifName is set, sorted by name
+func (m LabelMap) build(router *models.EnhancedRouter, result *adapter.PollResult, ifName string) []Label {
+	values := map[string]string{
+		SourceTenant:   result.TenantID.String(),
+		SourceRouterID: result.RouterID.String(),
+	}
+	if router != nil {
+		values[SourceRouter] = router.Name
+		if role := router.GetPrimaryRole(); role != nil && role.Role != nil {
+			values[SourceRole] = role.Role.Code
+		}
+	}
+	if ifName != "" {
+		values[SourceInterface] = ifName
+	}
+
+	labels := make([]Label, 0, len(m))
+	for source, name := range m {
+		if v := values[source]; v != "" {
+			labels = append(labels, Label{Name: name, Value: v})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func metricName(prefix, measurement string) string {
+	if prefix == "" {
+		return sanitizeName(measurement)
+	}
+	return sanitizeName(prefix + "_" + measurement)
+}
+
+// sanitizeName replaces characters not allowed in metric names
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
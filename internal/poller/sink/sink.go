@@ -0,0 +1,203 @@
+// Package sink writes polling results to time series databases besides
+// PostgreSQL.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/metrics"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// Sink names used in configuration
+const (
+	NamePostgres    = "postgres"
+	NameInflux      = "influx"
+	NameRemoteWrite = "remote_write"
+	NamePrometheus  = "prometheus"
+)
+
+// sendTimeout bounds one request to a sink
+const sendTimeout = 10 * time.Second
+
+// ResultSink receives every polling result
+type ResultSink interface {
+	// Name identifies the sink in logs and metrics
+	Name() string
+
+	// Write stores or queues a result. Sinks that write to remote systems
+	// queue and must not block the result processor.
+	Write(ctx context.Context, router *models.EnhancedRouter, result *adapter.PollResult) error
+
+	// Close flushes queued results. Write must not be called afterwards.
+	Close() error
+}
+
+// New creates the sinks listed in cfg. The postgres sink belongs to the
+// poller and is skipped here.
+func New(cfg config.SinkConfig) ([]ResultSink, error) {
+	labels, err := ParseLabelMap(cfg.LabelMap)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: sendTimeout}
+	var sinks []ResultSink
+	for _, name := range cfg.Sinks {
+		switch strings.TrimSpace(name) {
+		case NamePostgres, "":
+		case NameInflux:
+			s, err := NewInfluxSink(cfg, labels, client)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, s)
+		case NamePrometheus:
+			sinks = append(sinks, NewExporter(cfg, labels))
+		case NameRemoteWrite:
+			s, err := NewRemoteWriteSink(cfg, labels, client)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, s)
+		default:
+			return nil, fmt.Errorf("unknown result sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// batcher queues samples of a remote sink and sends them in batches of
+// batchSize samples, or every interval. Results arriving while the queue is
+// full are dropped so that a slow remote does not hold up polling.
+type batcher struct {
+	name      string
+	batchSize int
+	interval  time.Duration
+	send      func(ctx context.Context, samples []Sample) error
+
+	queue chan []Sample
+	done  chan struct{}
+}
+
+func newBatcher(name string, cfg config.SinkConfig, send func(context.Context, []Sample) error) *batcher {
+	b := &batcher{
+		name:      name,
+		batchSize: max(cfg.BatchSize, 1),
+		interval:  time.Duration(max(cfg.FlushSeconds, 1)) * time.Second,
+		send:      send,
+		queue:     make(chan []Sample, cfg.QueueSize),
+		done:      make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add queues the samples of one result
+func (b *batcher) add(samples []Sample) {
+	select {
+	case b.queue <- samples:
+	default:
+		metrics.SinkSamplesDropped.WithLabelValues(b.name).Add(float64(len(samples)))
+	}
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	var pending []Sample
+	for {
+		select {
+		case samples, ok := <-b.queue:
+			if !ok {
+				b.flush(pending)
+				return
+			}
+			pending = append(pending, samples...)
+			if len(pending) >= b.batchSize {
+				b.flush(pending)
+				pending = nil
+			}
+		case <-ticker.C:
+			b.flush(pending)
+			pending = nil
+		}
+	}
+}
+
+func (b *batcher) flush(samples []Sample) {
+	if len(samples) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	if err := b.send(ctx, samples); err != nil {
+		metrics.SinkSamplesDropped.WithLabelValues(b.name).Add(float64(len(samples)))
+		log.Printf("Error writing %d samples to %s sink: %v", len(samples), b.name, err)
+		return
+	}
+	metrics.SinkSamplesWritten.WithLabelValues(b.name).Add(float64(len(samples)))
+}
+
+// close sends what is queued and stops the batcher
+func (b *batcher) close() {
+	close(b.queue)
+	<-b.done
+}
+
+// sendAttempts is how often a request failing with a server or network
+// error is tried before the batch is dropped
+const sendAttempts = 3
+
+// post sends body to url, retrying server errors and rate limiting. Other
+// client errors mean the remote rejected the data and are not retried.
+func post(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	var lastErr error
+	for attempt := 0; attempt < sendAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return lastErr
+			case <-time.After(time.Duration(attempt) * 500 * time.Millisecond):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header = header.Clone()
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			lastErr = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		default:
+			return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		}
+	}
+	return lastErr
+}
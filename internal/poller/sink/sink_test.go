@@ -0,0 +1,408 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/golang/snappy"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	testTenant = uuid.MustParse("8a0f3c51-6d7e-4b2a-9c1d-2e3f4a5b6c7d")
+	testRouter = uuid.MustParse("1b2c3d4e-5f60-4718-8293-a4b5c6d7e8f9")
+	testTime   = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func testResult() (*models.EnhancedRouter, *adapter.PollResult) {
+	router := &models.EnhancedRouter{
+		Router: models.Router{ID: testRouter, TenantID: testTenant, Name: "bras 1"},
+		Roles: []models.RouterRoleAssignment{
+			{IsPrimary: true, Role: &models.RouterRole{Code: "pppoe_server"}},
+		},
+	}
+	result := adapter.NewPollResult(testRouter, testTenant, "snmp")
+	result.Success = true
+	result.Timestamp = testTime
+	result.Metrics.Set("cpu_percent", metric.Float(12.5))
+	result.Metrics.Set("uptime_seconds", metric.Int(3600))
+	result.Metrics.Set("os_version", metric.String("7.14"))
+	result.PPPoESessions = make([]models.PPPoESession, 3)
+	result.Interfaces = []adapter.InterfaceStatus{
+		{Name: "ether1", Status: "up", AdminStatus: "up", InOctets: 1000, OutOctets: 2000},
+	}
+	return router, result
+}
+
+func testConfig() config.SinkConfig {
+	return config.SinkConfig{
+		MetricPrefix: "ispmonitor_device",
+		BatchSize:    1000,
+		FlushSeconds: 3600,
+		QueueSize:    10,
+	}
+}
+
+// receiver is an httptest server recording request bodies and headers
+type receiver struct {
+	*httptest.Server
+	mu      sync.Mutex
+	bodies  [][]byte
+	headers []http.Header
+	status  []int // responses to send, in order; then 204
+}
+
+func newReceiver(t *testing.T, status ...int) *receiver {
+	r := &receiver{status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.bodies = append(r.bodies, body)
+		r.headers = append(r.headers, req.Header.Clone())
+		code := http.StatusNoContent
+		if len(r.status) > 0 {
+			code, r.status = r.status[0], r.status[1:]
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestSamples(t *testing.T) {
+	router, result := testResult()
+	samples := Samples(router, result, DefaultLabelMap)
+
+	byName := make(map[string]Sample)
+	for _, s := range samples {
+		byName[s.MetricName("ispmonitor_device")] = s
+	}
+
+	want := map[string]float64{
+		"ispmonitor_device_router_up":                  1,
+		"ispmonitor_device_router_cpu_percent":         12.5,
+		"ispmonitor_device_router_uptime_seconds":      3600,
+		"ispmonitor_device_router_pppoe_session_count": 3,
+		"ispmonitor_device_interface_up":               1,
+		"ispmonitor_device_interface_in_octets":        1000,
+	}
+	for name, v := range want {
+		if s, ok := byName[name]; !ok || s.Value != v {
+			t.Errorf("%s = %+v, want %v", name, s, v)
+		}
+	}
+	if _, ok := byName["ispmonitor_device_router_os_version"]; ok {
+		t.Error("string metric converted to a sample")
+	}
+
+	cpu := byName["ispmonitor_device_router_cpu_percent"]
+	wantLabels := []Label{{"role", "pppoe_server"}, {"router", "bras 1"}, {"tenant", testTenant.String()}}
+	if len(cpu.Labels) != len(wantLabels) {
+		t.Fatalf("router labels = %v, want %v", cpu.Labels, wantLabels)
+	}
+	for i := range wantLabels {
+		if cpu.Labels[i] != wantLabels[i] {
+			t.Fatalf("router labels = %v, want %v", cpu.Labels, wantLabels)
+		}
+	}
+	if ifUp := byName["ispmonitor_device_interface_up"]; len(ifUp.Labels) != 4 || ifUp.Labels[0] != (Label{"interface", "ether1"}) {
+		t.Errorf("interface labels = %v", ifUp.Labels)
+	}
+
+	// A failed poll only reports the router down
+	result.Success = false
+	if got := Samples(router, result, DefaultLabelMap); len(got) != 1 || got[0].Field != "up" || got[0].Value != 0 {
+		t.Errorf("failed poll samples = %+v", got)
+	}
+}
+
+func TestParseLabelMap(t *testing.T) {
+	m, err := ParseLabelMap("tenant=customer, router_id=device_id")
+	if err != nil {
+		t.Fatalf("ParseLabelMap: %v", err)
+	}
+	if len(m) != 2 || m[SourceTenant] != "customer" || m[SourceRouterID] != "device_id" {
+		t.Errorf("label map = %v", m)
+	}
+
+	for _, spec := range []string{"tenant", "site=site", "tenant=__name", "tenant=a-b", "tenant=x,router=x"} {
+		if _, err := ParseLabelMap(spec); err == nil {
+			t.Errorf("ParseLabelMap(%q) accepted", spec)
+		}
+	}
+}
+
+func TestLineProtocol(t *testing.T) {
+	router, result := testResult()
+	result.Metrics = metric.Samples{}
+	result.Metrics.Set("cpu_percent", metric.Float(12.5))
+	result.PPPoESessions = nil
+	result.Interfaces = nil
+
+	got := string(LineProtocol("ispmonitor_device", Samples(router, result, DefaultLabelMap)))
+	want := "ispmonitor_device_router,role=pppoe_server,router=bras\\ 1,tenant=" + testTenant.String() +
+		" up=1,cpu_percent=12.5 1772366400000000000\n"
+	if got != want {
+		t.Errorf("line protocol:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestInfluxSinkHTTP(t *testing.T) {
+	r := newReceiver(t, http.StatusServiceUnavailable)
+	cfg := testConfig()
+	cfg.InfluxURL = r.URL + "/api/v2/write?org=isp&bucket=devices"
+	cfg.InfluxToken = "t0ken"
+
+	s, err := NewInfluxSink(cfg, DefaultLabelMap, r.Client())
+	if err != nil {
+		t.Fatalf("NewInfluxSink: %v", err)
+	}
+	router, result := testResult()
+	s.Write(context.Background(), router, result)
+	s.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// The 503 is retried
+	if len(r.bodies) != 2 {
+		t.Fatalf("requests = %d, want 2", len(r.bodies))
+	}
+	if auth := r.headers[1].Get("Authorization"); auth != "Token t0ken" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	lines := strings.Split(strings.TrimSpace(string(r.bodies[1])), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ispmonitor_device_router,") ||
+		!strings.HasPrefix(lines[1], "ispmonitor_device_interface,interface=ether1,") {
+		t.Errorf("body = %q", r.bodies[1])
+	}
+}
+
+func TestInfluxSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on udp: %v", err)
+	}
+	defer conn.Close()
+
+	cfg := testConfig()
+	cfg.InfluxURL = "udp://" + conn.LocalAddr().String()
+	s, err := NewInfluxSink(cfg, DefaultLabelMap, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("NewInfluxSink: %v", err)
+	}
+	router, result := testResult()
+	s.Write(context.Background(), router, result)
+	s.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 65535)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no datagram: %v", err)
+	}
+	if !strings.HasPrefix(string(buf[:n]), "ispmonitor_device_router,") || !strings.HasSuffix(string(buf[:n]), "\n") {
+		t.Errorf("datagram = %q", buf[:n])
+	}
+}
+
+// series is a decoded remote write time series
+type series struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+func decodeWriteRequest(t *testing.T, b []byte) []series {
+	t.Helper()
+	var out []series
+	for len(b) > 0 {
+		_, _, n := protowire.ConsumeTag(b)
+		ts, m := protowire.ConsumeBytes(b[n:])
+		if m < 0 {
+			t.Fatal("malformed WriteRequest")
+		}
+		b = b[n+m:]
+
+		s := series{labels: map[string]string{}}
+		var names []string
+		for len(ts) > 0 {
+			num, _, n := protowire.ConsumeTag(ts)
+			msg, m := protowire.ConsumeBytes(ts[n:])
+			ts = ts[n+m:]
+			fields := map[protowire.Number][]byte{}
+			var value uint64
+			var timestamp uint64
+			for len(msg) > 0 {
+				fnum, typ, n := protowire.ConsumeTag(msg)
+				msg = msg[n:]
+				switch typ {
+				case protowire.BytesType:
+					v, m := protowire.ConsumeBytes(msg)
+					fields[fnum] = v
+					msg = msg[m:]
+				case protowire.Fixed64Type:
+					v, m := protowire.ConsumeFixed64(msg)
+					value = v
+					msg = msg[m:]
+				case protowire.VarintType:
+					v, m := protowire.ConsumeVarint(msg)
+					timestamp = v
+					msg = msg[m:]
+				}
+			}
+			if num == fieldTimeSeriesLabels {
+				name := string(fields[fieldLabelName])
+				names = append(names, name)
+				s.labels[name] = string(fields[fieldLabelValue])
+			} else {
+				s.value = math.Float64frombits(value)
+				s.timestamp = int64(timestamp)
+			}
+		}
+		for i := 1; i < len(names); i++ {
+			if names[i-1] >= names[i] {
+				t.Fatalf("labels not sorted: %v", names)
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestRemoteWriteSink(t *testing.T) {
+	r := newReceiver(t)
+	cfg := testConfig()
+	cfg.RemoteWriteURL = r.URL + "/api/v1/write"
+	cfg.RemoteWriteUsername = "isp"
+	cfg.RemoteWritePassword = "pw"
+
+	labels, _ := ParseLabelMap("tenant=tenant,router=instance,interface=ifName")
+	s, err := NewRemoteWriteSink(cfg, labels, r.Client())
+	if err != nil {
+		t.Fatalf("NewRemoteWriteSink: %v", err)
+	}
+	router, result := testResult()
+	s.Write(context.Background(), router, result)
+	s.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.bodies) != 1 {
+		t.Fatalf("requests = %d, want 1", len(r.bodies))
+	}
+	h := r.headers[0]
+	if h.Get("Content-Encoding") != "snappy" || h.Get("Content-Type") != "application/x-protobuf" ||
+		h.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" || !strings.HasPrefix(h.Get("Authorization"), "Basic ") {
+		t.Errorf("headers = %v", h)
+	}
+
+	body, err := snappy.Decode(nil, r.bodies[0])
+	if err != nil {
+		t.Fatalf("snappy: %v", err)
+	}
+	found := false
+	for _, s := range decodeWriteRequest(t, body) {
+		if s.labels["__name__"] != "ispmonitor_device_interface_out_octets" {
+			continue
+		}
+		found = true
+		if s.labels["ifName"] != "ether1" || s.labels["instance"] != "bras 1" || s.labels["role"] != "" {
+			t.Errorf("labels = %v", s.labels)
+		}
+		if s.value != 2000 || s.timestamp != testTime.UnixMilli() {
+			t.Errorf("sample = %v @ %d", s.value, s.timestamp)
+		}
+	}
+	if !found {
+		t.Error("interface out_octets series not written")
+	}
+}
+
+func TestNewRejectsUnknownSink(t *testing.T) {
+	cfg := testConfig()
+	cfg.Sinks = []string{"postgres", "graphite"}
+	if _, err := New(cfg); err == nil {
+		t.Error("unknown sink accepted")
+	}
+
+	cfg.Sinks = []string{"postgres"}
+	if sinks, err := New(cfg); err != nil || len(sinks) != 0 {
+		t.Errorf("New(postgres) = %v, %v; want no remote sinks", sinks, err)
+	}
+}
+
+func scrape(t *testing.T, e *Exporter, query string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	e.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/devices"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape%s: status %d: %s", query, rec.Code, rec.Body)
+	}
+	return rec.Body.String()
+}
+
+func TestExporter(t *testing.T) {
+	cfg := testConfig()
+	cfg.ExporterStaleSeconds = 600
+	e := NewExporter(cfg, DefaultLabelMap)
+	now := testTime
+	e.now = func() time.Time { return now }
+
+	router, result := testResult()
+	e.Write(context.Background(), router, result)
+
+	// The next poll a minute later yields interface rates
+	_, next := testResult()
+	next.Timestamp = testTime.Add(time.Minute)
+	next.Interfaces[0].InOctets += 60 * 1000
+	next.Interfaces[0].OutOctets += 60 * 500
+	e.Write(context.Background(), router, next)
+
+	// A router without a role in another tenant
+	other := &models.EnhancedRouter{Router: models.Router{ID: uuid.New(), TenantID: uuid.New(), Name: "core"}}
+	failed := adapter.NewPollResult(other.ID, other.TenantID, "snmp")
+	e.Write(context.Background(), other, failed)
+
+	body := scrape(t, e, "")
+	tenant := testTenant.String()
+	for _, want := range []string{
+		`ispmonitor_device_router_cpu_percent{role="pppoe_server",router="bras 1",tenant="` + tenant + `"} 12.5`,
+		`ispmonitor_device_interface_in_bits_per_second{interface="ether1",role="pppoe_server",router="bras 1",tenant="` + tenant + `"} 8000`,
+		`ispmonitor_device_interface_out_bits_per_second{interface="ether1",role="pppoe_server",router="bras 1",tenant="` + tenant + `"} 4000`,
+		"# TYPE ispmonitor_device_interface_in_octets counter",
+		`ispmonitor_device_router_pppoe_session_count{role="pppoe_server",router="bras 1",tenant="` + tenant + `"} 3`,
+		// Same label names for every router; the failed poll only reports down
+		`ispmonitor_device_router_up{role="",router="core",tenant="` + other.TenantID.String() + `"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape missing %s", want)
+		}
+	}
+
+	if body := scrape(t, e, "?tenant="+tenant); strings.Contains(body, `router="core"`) || !strings.Contains(body, `router="bras 1"`) {
+		t.Errorf("tenant filter:\n%s", body)
+	}
+
+	// Routers not polled within the stale period are dropped
+	now = now.Add(11 * time.Minute)
+	if body := scrape(t, e, ""); strings.Contains(body, "ispmonitor_device_router_up") {
+		t.Errorf("stale routers exported:\n%s", body)
+	}
+	if len(e.routers) != 0 || len(e.octets) != 0 {
+		t.Error("stale routers kept in memory")
+	}
+}
@@ -0,0 +1,193 @@
+package radius
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Acct-Status-Type values
+const (
+	StatusStart         = 1
+	StatusStop          = 2
+	StatusInterimUpdate = 3
+	StatusAccountingOn  = 7
+	StatusAccountingOff = 8
+)
+
+// maxTimestampSkew bounds how far Event-Timestamp may be from the receive
+// time before the NAS clock is distrusted and Acct-Delay-Time used instead
+const maxTimestampSkew = time.Hour
+
+// serviceTypes names Service-Type values (RFC 2865 5.6)
+var serviceTypes = map[uint32]string{
+	1:  "Login",
+	2:  "Framed",
+	3:  "Callback-Login",
+	4:  "Callback-Framed",
+	5:  "Outbound",
+	6:  "Administrative",
+	7:  "NAS-Prompt",
+	8:  "Authenticate-Only",
+	9:  "Callback-NAS-Prompt",
+	10: "Call-Check",
+	11: "Callback-Administrative",
+}
+
+// terminateCauses names Acct-Terminate-Cause values (RFC 2866 5.10 and
+// RFC 3580 3.32)
+var terminateCauses = map[uint32]string{
+	1:  "User-Request",
+	2:  "Lost-Carrier",
+	3:  "Lost-Service",
+	4:  "Idle-Timeout",
+	5:  "Session-Timeout",
+	6:  "Admin-Reset",
+	7:  "Admin-Reboot",
+	8:  "Port-Error",
+	9:  "NAS-Error",
+	10: "NAS-Request",
+	11: "NAS-Reboot",
+	12: "Port-Unneeded",
+	13: "Port-Preempted",
+	14: "Port-Suspended",
+	15: "Service-Unavailable",
+	16: "Callback",
+	17: "User-Error",
+	18: "Host-Request",
+	19: "Supplicant-Restart",
+	20: "Reauthentication-Failure",
+	21: "Port-Reinitialized",
+	22: "Port-Administratively-Disabled",
+}
+
+// CauseNASReboot is recorded for sessions closed by Accounting-On or
+// Accounting-Off, which tell that the NAS dropped all of its sessions
+const CauseNASReboot = "NAS-Reboot"
+
+// AccountingRecord is an Accounting-Request in terms of a PPPoE session.
+// Octet and packet counters are from the NAS's perspective: input is
+// received from the subscriber (upload), output sent to it (download).
+type AccountingRecord struct {
+	StatusType     uint32
+	SessionID      string
+	Username       string
+	CallingStation string
+	FramedIP       net.IP
+	NASIdentifier  string
+	NASIP          net.IP
+	NASPort        string // NAS-Port-Id, or NAS-Port when absent
+	ServiceType    string
+	SessionTime    *int64 // seconds
+	InputOctets    int64
+	OutputOctets   int64
+	InputPackets   int64
+	OutputPackets  int64
+	TerminateCause string
+
+	// EventTime is when the NAS generated the record
+	EventTime time.Time
+}
+
+// ParseAccounting reads the accounting attributes of an Accounting-Request
+// received at received
+func ParseAccounting(p *Packet, received time.Time) (*AccountingRecord, error) {
+	if p.Code != CodeAccountingRequest {
+		return nil, fmt.Errorf("unexpected RADIUS code %d", p.Code)
+	}
+	status, ok := p.Uint32(AttrAcctStatusType)
+	if !ok {
+		return nil, fmt.Errorf("missing Acct-Status-Type")
+	}
+
+	rec := &AccountingRecord{
+		StatusType:     status,
+		SessionID:      p.String(AttrAcctSessionID),
+		Username:       p.String(AttrUserName),
+		CallingStation: p.String(AttrCallingStationID),
+		NASIdentifier:  p.String(AttrNASIdentifier),
+		NASPort:        p.String(AttrNASPortID),
+		EventTime:      eventTime(p, received),
+	}
+
+	if v, ok := p.Attribute(AttrFramedIPAddress); ok && len(v) == 4 {
+		rec.FramedIP = net.IP(append([]byte(nil), v...))
+	}
+	if v, ok := p.Attribute(AttrNASIPAddress); ok && len(v) == 4 {
+		rec.NASIP = net.IP(append([]byte(nil), v...))
+	} else if v, ok := p.Attribute(AttrNASIPv6Address); ok && len(v) == 16 {
+		rec.NASIP = net.IP(append([]byte(nil), v...))
+	}
+	if rec.NASPort == "" {
+		if port, ok := p.Uint32(AttrNASPort); ok {
+			rec.NASPort = strconv.FormatUint(uint64(port), 10)
+		}
+	}
+	if v, ok := p.Uint32(AttrServiceType); ok {
+		rec.ServiceType = enumName(serviceTypes, v)
+	}
+	if v, ok := p.Uint32(AttrAcctTerminateCause); ok {
+		rec.TerminateCause = enumName(terminateCauses, v)
+	}
+	if v, ok := p.Uint32(AttrAcctSessionTime); ok {
+		seconds := int64(v)
+		rec.SessionTime = &seconds
+	}
+
+	rec.InputOctets = counter(p, AttrAcctInputOctets, AttrAcctInputGigawords)
+	rec.OutputOctets = counter(p, AttrAcctOutputOctets, AttrAcctOutputGigawords)
+	rec.InputPackets = counter(p, AttrAcctInputPackets, 0)
+	rec.OutputPackets = counter(p, AttrAcctOutputPackets, 0)
+
+	return rec, nil
+}
+
+// ConnectTime returns when the session started: the event time of a Start,
+// otherwise the event time less the session time, or nil when unknown
+func (r *AccountingRecord) ConnectTime() *time.Time {
+	switch {
+	case r.StatusType == StatusStart:
+		t := r.EventTime
+		return &t
+	case r.SessionTime != nil:
+		t := r.EventTime.Add(-time.Duration(*r.SessionTime) * time.Second)
+		return &t
+	}
+	return nil
+}
+
+// eventTime trusts Event-Timestamp when it is close to the receive time and
+// otherwise subtracts Acct-Delay-Time, the seconds the NAS spent sending
+func eventTime(p *Packet, received time.Time) time.Time {
+	if ts, ok := p.Uint32(AttrEventTimestamp); ok {
+		t := time.Unix(int64(ts), 0)
+		if d := received.Sub(t); d < maxTimestampSkew && d > -maxTimestampSkew {
+			return t
+		}
+	}
+	if delay, ok := p.Uint32(AttrAcctDelayTime); ok {
+		return received.Add(-time.Duration(delay) * time.Second)
+	}
+	return received
+}
+
+// counter combines a 32 bit counter with its gigawords attribute, which
+// counts how many times it wrapped
+func counter(p *Packet, low, gigawords uint8) int64 {
+	v, _ := p.Uint32(low)
+	total := int64(v)
+	if gigawords != 0 {
+		if g, ok := p.Uint32(gigawords); ok {
+			total += int64(g) << 32
+		}
+	}
+	return total
+}
+
+func enumName(names map[uint32]string, v uint32) string {
+	if name, ok := names[v]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
@@ -0,0 +1,142 @@
+package radius
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/google/uuid"
+)
+
+// nas is a router sending accounting requests
+type nas struct {
+	TenantID uuid.UUID
+	RouterID uuid.UUID
+	Name     string
+	Secret   string // Shared secret; empty uses the server-wide secret
+}
+
+// NASMap attributes accounting requests to routers with RADIUS accounting
+// enabled, by NAS-Identifier or address (see ingest.Sources). An identifier
+// claimed by several routers is ambiguous and not attributed.
+type NASMap struct {
+	db    *sql.DB
+	vault *vault.Vault
+	byIP  *ingest.Sources[*nas]
+
+	mu           sync.RWMutex
+	byIdentifier map[string][]*nas
+}
+
+// NewNASMap creates an empty NAS map; call Refresh to load it. Shared
+// secrets are decrypted with secrets.
+func NewNASMap(db *sql.DB, secrets *vault.Vault) *NASMap {
+	return &NASMap{
+		db:           db,
+		vault:        secrets,
+		byIP:         ingest.NewSources[*nas](),
+		byIdentifier: make(map[string][]*nas),
+	}
+}
+
+// Refresh reloads the NAS mapping and returns the number of routers.
+// Routers whose secret cannot be decrypted are left out rather than
+// authenticated with the server-wide secret.
+func (m *NASMap) Refresh(ctx context.Context) (int, error) {
+	query := ingest.SourceQuery(`
+		SELECT r.id, r.tenant_id, r.name, rc.radius_secret, rc.radius_nas_identifier
+		FROM routers r
+		JOIN router_capabilities rc ON r.id = rc.router_id
+		WHERE rc.radius_acct_enabled = true
+	`)
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	byIdentifier := make(map[string][]*nas)
+	byIP := ingest.NewSourceSet[*nas]()
+	seen := make(map[uuid.UUID]*nas)
+	count := 0
+	for rows.Next() {
+		var ip string
+		var priority int
+		var secret, identifier *string
+		n := &nas{}
+		if err := rows.Scan(&ip, &priority, &n.RouterID, &n.TenantID, &n.Name, &secret, &identifier); err != nil {
+			return 0, err
+		}
+
+		if existing, ok := seen[n.RouterID]; ok {
+			if existing == nil {
+				continue
+			}
+			n = existing
+		} else {
+			if secret != nil {
+				plaintext, err := m.vault.Decrypt(*secret)
+				if err != nil {
+					log.Printf("Ignoring RADIUS accounting from router %s: %v", n.Name, err)
+					seen[n.RouterID] = nil
+					continue
+				}
+				n.Secret = plaintext
+			}
+			if identifier != nil && *identifier != "" {
+				byIdentifier[*identifier] = append(byIdentifier[*identifier], n)
+			}
+			seen[n.RouterID] = n
+			count++
+		}
+
+		byIP.Add(ip, priority, n)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	m.byIdentifier = byIdentifier
+	m.mu.Unlock()
+	m.byIP.Set(byIP)
+
+	return count, nil
+}
+
+// Lookup returns the router sending a request with the given NAS-Identifier
+// and NAS-IP-Address from source. The identifier is tried first, then the
+// NAS address (the source differs when requests are relayed or NATed), then
+// the source. ambiguous is true when several routers claim the first match.
+func (m *NASMap) Lookup(identifier string, nasIP, source net.IP) (n *nas, ambiguous bool) {
+	if identifier != "" {
+		m.mu.RLock()
+		n, ambiguous := single(m.byIdentifier[identifier])
+		m.mu.RUnlock()
+		if n != nil || ambiguous {
+			return n, ambiguous
+		}
+	}
+	for _, ip := range []net.IP{nasIP, source} {
+		if n, ambiguous := m.byIP.LookupIP(ip); n != nil || ambiguous {
+			return n, ambiguous
+		}
+	}
+	return nil, false
+}
+
+func single(candidates []*nas) (*nas, bool) {
+	switch len(candidates) {
+	case 0:
+		return nil, false
+	case 1:
+		return candidates[0], false
+	default:
+		return nil, true
+	}
+}
@@ -0,0 +1,200 @@
+package radius
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// RADIUS (RFC 2865) packet codes used for accounting (RFC 2866)
+const (
+	CodeAccountingRequest  = 4
+	CodeAccountingResponse = 5
+)
+
+// Attribute types read from accounting requests
+const (
+	AttrUserName            = 1
+	AttrNASIPAddress        = 4
+	AttrNASPort             = 5
+	AttrServiceType         = 6
+	AttrFramedIPAddress     = 8
+	AttrCalledStationID     = 30
+	AttrCallingStationID    = 31
+	AttrNASIdentifier       = 32
+	AttrAcctStatusType      = 40
+	AttrAcctDelayTime       = 41
+	AttrAcctInputOctets     = 42
+	AttrAcctOutputOctets    = 43
+	AttrAcctSessionID       = 44
+	AttrAcctSessionTime     = 46
+	AttrAcctInputPackets    = 47
+	AttrAcctOutputPackets   = 48
+	AttrAcctTerminateCause  = 49
+	AttrAcctInputGigawords  = 52
+	AttrAcctOutputGigawords = 53
+	AttrEventTimestamp      = 55
+	AttrNASPortID           = 87
+	AttrNASIPv6Address      = 95
+)
+
+const (
+	headerLen        = 20
+	authenticatorLen = 16
+	maxPacketLen     = 4096
+)
+
+// ErrMalformed is returned for packets that are not valid RADIUS
+var ErrMalformed = errors.New("malformed RADIUS packet")
+
+// Attribute is one type-length-value attribute
+type Attribute struct {
+	Type  uint8
+	Value []byte
+}
+
+// Packet is a decoded RADIUS packet
+type Packet struct {
+	Code          uint8
+	Identifier    uint8
+	Authenticator [authenticatorLen]byte
+	Attributes    []Attribute
+}
+
+// Decode parses a RADIUS packet. Bytes past the length field are padding
+// and ignored, as RFC 2865 requires.
+func Decode(data []byte) (*Packet, error) {
+	if len(data) < headerLen {
+		return nil, ErrMalformed
+	}
+	length := int(binary.BigEndian.Uint16(data[2:]))
+	if length < headerLen || length > maxPacketLen || length > len(data) {
+		return nil, ErrMalformed
+	}
+
+	p := &Packet{Code: data[0], Identifier: data[1]}
+	copy(p.Authenticator[:], data[4:headerLen])
+
+	attrs := data[headerLen:length]
+	for len(attrs) > 0 {
+		if len(attrs) < 2 || attrs[1] < 2 || int(attrs[1]) > len(attrs) {
+			return nil, ErrMalformed
+		}
+		p.Attributes = append(p.Attributes, Attribute{Type: attrs[0], Value: attrs[2:attrs[1]]})
+		attrs = attrs[attrs[1]:]
+	}
+	return p, nil
+}
+
+// Encode serializes the packet as is; use SignRequest to set the authenticator
+func (p *Packet) Encode() ([]byte, error) {
+	buf := make([]byte, headerLen, maxPacketLen)
+	buf[0] = p.Code
+	buf[1] = p.Identifier
+	copy(buf[4:], p.Authenticator[:])
+	for _, a := range p.Attributes {
+		if len(a.Value) > 253 {
+			return nil, fmt.Errorf("RADIUS attribute %d too long", a.Type)
+		}
+		buf = append(buf, a.Type, uint8(len(a.Value)+2))
+		buf = append(buf, a.Value...)
+	}
+	if len(buf) > maxPacketLen {
+		return nil, fmt.Errorf("RADIUS packet too long")
+	}
+	binary.BigEndian.PutUint16(buf[2:], uint16(len(buf)))
+	return buf, nil
+}
+
+// Attribute returns the value of the first attribute of type t
+func (p *Packet) Attribute(t uint8) ([]byte, bool) {
+	for _, a := range p.Attributes {
+		if a.Type == t {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+// String returns the first attribute of type t as a string, or ""
+func (p *Packet) String(t uint8) string {
+	v, _ := p.Attribute(t)
+	return string(v)
+}
+
+// Uint32 returns the first attribute of type t as an integer
+func (p *Packet) Uint32(t uint8) (uint32, bool) {
+	v, ok := p.Attribute(t)
+	if !ok || len(v) != 4 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(v), true
+}
+
+// accountingAuthenticator is MD5(Code+Identifier+Length+auth+Attributes+Secret)
+// over the encoded packet data with the authenticator field replaced by auth
+func accountingAuthenticator(data []byte, auth []byte, secret string) [authenticatorLen]byte {
+	h := md5.New()
+	h.Write(data[:4])
+	h.Write(auth)
+	h.Write(data[headerLen:])
+	h.Write([]byte(secret))
+	var sum [authenticatorLen]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// VerifyRequest checks an encoded Accounting-Request's authenticator, which
+// is computed over the packet with a zero authenticator (RFC 2866 3)
+func VerifyRequest(data []byte, secret string) bool {
+	if len(data) < headerLen {
+		return false
+	}
+	length := int(binary.BigEndian.Uint16(data[2:]))
+	if length < headerLen || length > len(data) {
+		return false
+	}
+	data = data[:length]
+	expected := accountingAuthenticator(data, make([]byte, authenticatorLen), secret)
+	return subtle.ConstantTimeCompare(expected[:], data[4:headerLen]) == 1
+}
+
+// SignRequest encodes p as an Accounting-Request authenticated with secret
+func SignRequest(p *Packet, secret string) ([]byte, error) {
+	p.Authenticator = [authenticatorLen]byte{}
+	data, err := p.Encode()
+	if err != nil {
+		return nil, err
+	}
+	p.Authenticator = accountingAuthenticator(data, data[4:headerLen], secret)
+	copy(data[4:], p.Authenticator[:])
+	return data, nil
+}
+
+// Response encodes the Accounting-Response to request, whose authenticator
+// is MD5 over the response with the request's authenticator (RFC 2866 3)
+func Response(request *Packet, secret string) []byte {
+	data := make([]byte, headerLen)
+	data[0] = CodeAccountingResponse
+	data[1] = request.Identifier
+	binary.BigEndian.PutUint16(data[2:], headerLen)
+	auth := accountingAuthenticator(data, request.Authenticator[:], secret)
+	copy(data[4:], auth[:])
+	return data
+}
+
+// VerifyResponse checks an encoded Accounting-Response to request
+func VerifyResponse(data []byte, request *Packet, secret string) bool {
+	if len(data) < headerLen || data[0] != CodeAccountingResponse || data[1] != request.Identifier {
+		return false
+	}
+	length := int(binary.BigEndian.Uint16(data[2:]))
+	if length < headerLen || length > len(data) {
+		return false
+	}
+	data = data[:length]
+	expected := accountingAuthenticator(data, request.Authenticator[:], secret)
+	return subtle.ConstantTimeCompare(expected[:], data[4:headerLen]) == 1
+}
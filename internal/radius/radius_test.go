@@ -0,0 +1,278 @@
+package radius
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// fakeSessions records the repository calls made by the server
+type fakeSessions struct {
+	mu          sync.Mutex
+	calls       []string
+	sessions    []*models.PPPoESession
+	disconnects []string
+}
+
+func (f *fakeSessions) record(call string, s *models.PPPoESession) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	f.sessions = append(f.sessions, s)
+	return nil
+}
+
+func (f *fakeSessions) Start(ctx context.Context, s *models.PPPoESession) error {
+	return f.record("start", s)
+}
+
+func (f *fakeSessions) Update(ctx context.Context, s *models.PPPoESession) error {
+	return f.record("update", s)
+}
+
+func (f *fakeSessions) Stop(ctx context.Context, s *models.PPPoESession) error {
+	return f.record("stop", s)
+}
+
+func (f *fakeSessions) DisconnectRouter(ctx context.Context, routerID uuid.UUID, at time.Time, cause string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "disconnect")
+	f.disconnects = append(f.disconnects, cause)
+	return 1, nil
+}
+
+// client is a minimal RADIUS accounting client standing in for a NAS
+type client struct {
+	secret string
+	nextID uint8
+}
+
+func uint32Attr(t uint8, v uint32) Attribute {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return Attribute{Type: t, Value: b}
+}
+
+func (c *client) request(t *testing.T, status uint32, attrs ...Attribute) (*Packet, []byte) {
+	t.Helper()
+	c.nextID++
+	p := &Packet{
+		Code:       CodeAccountingRequest,
+		Identifier: c.nextID,
+		Attributes: append([]Attribute{
+			uint32Attr(AttrAcctStatusType, status),
+			{Type: AttrNASIdentifier, Value: []byte("bras-1")},
+		}, attrs...),
+	}
+	data, err := SignRequest(p, c.secret)
+	if err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	return p, data
+}
+
+func newTestServer(repo *fakeSessions, secret string) (*Server, *nas) {
+	n := &nas{TenantID: uuid.New(), RouterID: uuid.New(), Name: "bras-1", Secret: secret}
+	m := NewNASMap(nil, nil)
+	m.byIdentifier["bras-1"] = []*nas{n}
+	set := ingest.NewSourceSet[*nas]()
+	set.Add("192.0.2.1", ingest.ManagementAddress, n)
+	m.byIP.Set(set)
+	return &Server{
+		config:   config.RADIUSConfig{Secret: "fallback"},
+		nas:      m,
+		sessions: repo,
+		queue:    make(chan request, 16),
+	}, n
+}
+
+func TestPacketAuthenticators(t *testing.T) {
+	c := &client{secret: "s3cret"}
+	req, data := c.request(t, StatusStart, Attribute{Type: AttrAcctSessionID, Value: []byte("81000001")})
+
+	if !VerifyRequest(data, "s3cret") {
+		t.Fatal("request authenticator not verified with the shared secret")
+	}
+	if VerifyRequest(data, "wrong") {
+		t.Fatal("request authenticator verified with a wrong secret")
+	}
+
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decoded.String(AttrAcctSessionID) != "81000001" || decoded.Identifier != req.Identifier {
+		t.Fatalf("decoded packet = %+v", decoded)
+	}
+
+	resp := Response(decoded, "s3cret")
+	if !VerifyResponse(resp, req, "s3cret") {
+		t.Fatal("response authenticator not verified")
+	}
+	if VerifyResponse(resp, req, "wrong") {
+		t.Fatal("response authenticator verified with a wrong secret")
+	}
+
+	if _, err := Decode(append(data[:headerLen:headerLen], 1, 10)); err != ErrMalformed {
+		t.Fatalf("truncated attribute: err = %v, want ErrMalformed", err)
+	}
+}
+
+func TestParseAccounting(t *testing.T) {
+	received := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &client{secret: "s"}
+	_, data := c.request(t, StatusStop,
+		Attribute{Type: AttrAcctSessionID, Value: []byte("81000001")},
+		Attribute{Type: AttrUserName, Value: []byte("alice")},
+		Attribute{Type: AttrFramedIPAddress, Value: []byte{100, 64, 0, 7}},
+		uint32Attr(AttrNASPort, 15728654),
+		uint32Attr(AttrServiceType, 2),
+		uint32Attr(AttrAcctSessionTime, 3600),
+		uint32Attr(AttrAcctInputOctets, 10),
+		uint32Attr(AttrAcctInputGigawords, 1),
+		uint32Attr(AttrAcctOutputOctets, 20),
+		uint32Attr(AttrAcctTerminateCause, 1),
+		uint32Attr(AttrAcctDelayTime, 5),
+	)
+	p, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	rec, err := ParseAccounting(p, received)
+	if err != nil {
+		t.Fatalf("ParseAccounting: %v", err)
+	}
+	if rec.InputOctets != 1<<32+10 || rec.OutputOctets != 20 {
+		t.Errorf("octets = %d/%d, want gigawords applied", rec.InputOctets, rec.OutputOctets)
+	}
+	if rec.TerminateCause != "User-Request" || rec.ServiceType != "Framed" || rec.NASPort != "15728654" {
+		t.Errorf("record = %+v", rec)
+	}
+	if want := received.Add(-5 * time.Second); !rec.EventTime.Equal(want) {
+		t.Errorf("event time = %v, want %v (received less Acct-Delay-Time)", rec.EventTime, want)
+	}
+	if want := received.Add(-3605 * time.Second); !rec.ConnectTime().Equal(want) {
+		t.Errorf("connect time = %v, want %v", rec.ConnectTime(), want)
+	}
+
+	// A plausible Event-Timestamp is used as is; one far off is not
+	stamp := received.Add(-2 * time.Second)
+	p.Attributes = append(p.Attributes, uint32Attr(AttrEventTimestamp, uint32(stamp.Unix())))
+	if rec, _ := ParseAccounting(p, received); !rec.EventTime.Equal(stamp) {
+		t.Errorf("event time = %v, want Event-Timestamp %v", rec.EventTime, stamp)
+	}
+	p.Attributes[len(p.Attributes)-1] = uint32Attr(AttrEventTimestamp, uint32(stamp.Add(-48*time.Hour).Unix()))
+	if rec, _ := ParseAccounting(p, received); !rec.EventTime.Equal(received.Add(-5 * time.Second)) {
+		t.Errorf("event time = %v, want skewed Event-Timestamp ignored", rec.EventTime)
+	}
+}
+
+func TestServerHandle(t *testing.T) {
+	repo := &fakeSessions{}
+	s, n := newTestServer(repo, "per-nas")
+	c := &client{secret: "per-nas"}
+	source := &net.UDPAddr{IP: net.ParseIP("198.51.100.9"), Port: 3799}
+	received := time.Now()
+
+	id := Attribute{Type: AttrAcctSessionID, Value: []byte("81000001")}
+	user := Attribute{Type: AttrUserName, Value: []byte("alice")}
+
+	for _, status := range []uint32{StatusStart, StatusInterimUpdate, StatusStop} {
+		req, data := c.request(t, status, id, user, uint32Attr(AttrAcctSessionTime, 60), uint32Attr(AttrAcctTerminateCause, 4))
+		resp := s.handle(context.Background(), request{data: data, source: source, received: received})
+		if resp == nil || !VerifyResponse(resp, req, "per-nas") {
+			t.Fatalf("status %d: no valid response", status)
+		}
+	}
+
+	if got := repo.calls; len(got) != 3 || got[0] != "start" || got[1] != "update" || got[2] != "stop" {
+		t.Fatalf("calls = %v", got)
+	}
+	start, stop := repo.sessions[0], repo.sessions[2]
+	if start.RouterID != n.RouterID || *start.SessionID != "81000001" || start.Status != models.SessionStatusActive {
+		t.Errorf("start session = %+v", start)
+	}
+	if !start.ConnectTime.Equal(received) {
+		t.Errorf("start connect time = %v, want event time", start.ConnectTime)
+	}
+	if stop.Status != models.SessionStatusDisconnected || *stop.DisconnectCause != "Idle-Timeout" || !stop.DisconnectTime.Equal(received) {
+		t.Errorf("stop session = %+v", stop)
+	}
+
+	// Requests signed with another secret are not acknowledged
+	_, data := (&client{secret: "fallback"}).request(t, StatusStart, id, user)
+	if resp := s.handle(context.Background(), request{data: data, source: source, received: received}); resp != nil {
+		t.Error("request with wrong secret acknowledged")
+	}
+	if s.badAuth.Load() != 1 {
+		t.Errorf("bad authenticator count = %d, want 1", s.badAuth.Load())
+	}
+
+	// Accounting-On closes all of the router's sessions
+	_, data = c.request(t, StatusAccountingOn)
+	if resp := s.handle(context.Background(), request{data: data, source: source, received: received}); resp == nil {
+		t.Error("Accounting-On not acknowledged")
+	}
+	if len(repo.disconnects) != 1 || repo.disconnects[0] != CauseNASReboot {
+		t.Errorf("disconnects = %v", repo.disconnects)
+	}
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	repo := &fakeSessions{}
+	s, n := newTestServer(repo, "")
+	s.nas.byIdentifier = map[string][]*nas{}
+	set := ingest.NewSourceSet[*nas]()
+	set.Add("127.0.0.1", ingest.ManagementAddress, n)
+	s.nas.byIP.Set(set)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on udp: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.read(conn)
+	go s.process(ctx, conn)
+	defer conn.Close()
+
+	nasConn, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer nasConn.Close()
+
+	// The router has no secret of its own, so the server-wide one applies;
+	// the NAS is found by its source address
+	c := &client{secret: "fallback"}
+	req, data := c.request(t, StatusStart, Attribute{Type: AttrAcctSessionID, Value: []byte("a1")})
+	if _, err := nasConn.Write(data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	nasConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, maxPacketLen)
+	size, err := nasConn.Read(buf)
+	if err != nil {
+		t.Fatalf("no response: %v", err)
+	}
+	if !VerifyResponse(buf[:size], req, "fallback") {
+		t.Fatal("invalid Accounting-Response")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.calls) != 1 || repo.calls[0] != "start" {
+		t.Fatalf("calls = %v", repo.calls)
+	}
+}
@@ -0,0 +1,286 @@
+package radius
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// request is a datagram waiting to be processed
+type request struct {
+	data     []byte
+	source   net.Addr
+	received time.Time
+}
+
+// Server receives RADIUS accounting from routers acting as NAS and keeps
+// their PPPoE sessions. A request is acknowledged only once it is stored, so
+// the NAS retransmits requests that could not be written.
+type Server struct {
+	config   config.RADIUSConfig
+	nas      *NASMap
+	sessions repository.PPPoESessionRepository
+	queue    chan request
+
+	// Counters reported with each NAS refresh
+	receivedCount atomic.Uint64
+	malformed     atomic.Uint64
+	unknown       atomic.Uint64
+	ambiguous     atomic.Uint64
+	noSecret      atomic.Uint64
+	badAuth       atomic.Uint64
+	storeErrors   atomic.Uint64
+	dropped       atomic.Uint64
+}
+
+// NewServer creates a new RADIUS accounting server; shared secrets of
+// routers are decrypted with secrets
+func NewServer(db *database.DB, cfg config.RADIUSConfig, secrets *vault.Vault) *Server {
+	return &Server{
+		config:   cfg,
+		nas:      NewNASMap(db.DB, secrets),
+		sessions: postgres.NewPPPoESessionRepo(db.DB),
+		queue:    make(chan request, cfg.QueueSize),
+	}
+}
+
+// Start serves accounting requests until ctx is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	count, err := s.nas.Refresh(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load RADIUS NAS routers: %w", err)
+	}
+	log.Printf("RADIUS accounting: %d NAS routers", count)
+
+	conn, err := net.ListenPacket("udp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on udp %s: %w", s.config.Addr, err)
+	}
+	log.Printf("RADIUS accounting server listening on udp %s", s.config.Addr)
+
+	var workers sync.WaitGroup
+	for i := 0; i < max(s.config.Workers, 1); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.process(ctx, conn)
+		}()
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- s.read(conn)
+	}()
+
+	refresh := time.NewTicker(time.Duration(s.config.RefreshSeconds) * time.Second)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			workers.Wait()
+			log.Println("RADIUS accounting server stopped")
+			return nil
+		case err := <-readErr:
+			conn.Close()
+			workers.Wait()
+			return fmt.Errorf("RADIUS accounting listener stopped: %w", err)
+		case <-refresh.C:
+			if _, err := s.nas.Refresh(ctx); err != nil {
+				log.Printf("Error refreshing RADIUS NAS routers: %v", err)
+			}
+			s.logStats()
+		}
+	}
+}
+
+// read queues received datagrams until conn is closed
+func (s *Server) read(conn net.PacketConn) error {
+	buf := make([]byte, maxPacketLen)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.receivedCount.Add(1)
+
+		item := request{data: append([]byte(nil), buf[:n]...), source: addr, received: time.Now()}
+		select {
+		case s.queue <- item:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// process handles queued requests and sends their responses until ctx is
+// cancelled
+func (s *Server) process(ctx context.Context, conn net.PacketConn) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-s.queue:
+			if response := s.handle(ctx, item); response != nil {
+				if _, err := conn.WriteTo(response, item.source); err != nil && !errors.Is(err, net.ErrClosed) {
+					log.Printf("Error sending RADIUS accounting response to %s: %v", item.source, err)
+				}
+			}
+		}
+	}
+}
+
+// handle authenticates one request, applies it to the router's sessions and
+// returns the response, or nil when the request is not acknowledged
+func (s *Server) handle(ctx context.Context, item request) []byte {
+	packet, err := Decode(item.data)
+	if err != nil || packet.Code != CodeAccountingRequest {
+		s.malformed.Add(1)
+		return nil
+	}
+
+	rec, err := ParseAccounting(packet, item.received)
+	if err != nil {
+		s.malformed.Add(1)
+		return nil
+	}
+
+	var source net.IP
+	if addr, ok := item.source.(*net.UDPAddr); ok {
+		source = addr.IP
+	}
+	n, ambiguous := s.nas.Lookup(rec.NASIdentifier, rec.NASIP, source)
+	if n == nil {
+		if ambiguous {
+			s.ambiguous.Add(1)
+		} else {
+			s.unknown.Add(1)
+		}
+		return nil
+	}
+
+	secret := n.Secret
+	if secret == "" {
+		secret = s.config.Secret
+	}
+	if secret == "" {
+		s.noSecret.Add(1)
+		return nil
+	}
+	if !VerifyRequest(item.data, secret) {
+		s.badAuth.Add(1)
+		return nil
+	}
+
+	if err := s.apply(ctx, n, rec); err != nil {
+		s.storeErrors.Add(1)
+		log.Printf("Error storing RADIUS accounting from router %s: %v", n.Name, err)
+		return nil
+	}
+
+	return Response(packet, secret)
+}
+
+// apply stores an accounting record. Status types other than session
+// records and Accounting-On/Off are acknowledged without effect.
+func (s *Server) apply(ctx context.Context, n *nas, rec *AccountingRecord) error {
+	switch rec.StatusType {
+	case StatusStart, StatusInterimUpdate, StatusStop:
+	case StatusAccountingOn, StatusAccountingOff:
+		_, err := s.sessions.DisconnectRouter(ctx, n.RouterID, rec.EventTime, CauseNASReboot)
+		return err
+	default:
+		return nil
+	}
+
+	if rec.SessionID == "" {
+		// Nothing to key the session on; acknowledge so the NAS stops
+		// retransmitting
+		s.malformed.Add(1)
+		return nil
+	}
+
+	session := toSession(n, rec)
+	switch rec.StatusType {
+	case StatusStart:
+		return s.sessions.Start(ctx, session)
+	case StatusInterimUpdate:
+		return s.sessions.Update(ctx, session)
+	default:
+		return s.sessions.Stop(ctx, session)
+	}
+}
+
+// toSession maps an accounting record of n to its PPPoE session
+func toSession(n *nas, rec *AccountingRecord) *models.PPPoESession {
+	sessionID := rec.SessionID
+	session := &models.PPPoESession{
+		TenantID:           n.TenantID,
+		RouterID:           n.RouterID,
+		SessionID:          &sessionID,
+		Username:           rec.Username,
+		CallingStationID:   optionalString(rec.CallingStation),
+		NASPort:            optionalString(rec.NASPort),
+		ServiceType:        optionalString(rec.ServiceType),
+		SessionTimeSeconds: rec.SessionTime,
+		BytesIn:            rec.InputOctets,
+		BytesOut:           rec.OutputOctets,
+		PacketsIn:          rec.InputPackets,
+		PacketsOut:         rec.OutputPackets,
+		Status:             models.SessionStatusActive,
+		ConnectTime:        rec.ConnectTime(),
+	}
+	if rec.FramedIP != nil {
+		ip := rec.FramedIP
+		session.FramedIPAddress = &ip
+	}
+	if rec.NASIP != nil {
+		ip := rec.NASIP
+		session.NASIPAddress = &ip
+	}
+
+	if rec.StatusType == StatusStop {
+		at := rec.EventTime
+		session.Status = models.SessionStatusDisconnected
+		session.DisconnectTime = &at
+		session.DisconnectCause = optionalString(rec.TerminateCause)
+	}
+	return session
+}
+
+// logStats reports and resets the request counters
+func (s *Server) logStats() {
+	ingest.LogStats("RADIUS accounting", &s.receivedCount, "received", []ingest.Count{
+		{Name: "malformed", Value: &s.malformed},
+		{Name: "unknown NAS", Value: &s.unknown},
+		{Name: "ambiguous NAS", Value: &s.ambiguous},
+		{Name: "no secret", Value: &s.noSecret},
+		{Name: "bad authenticator", Value: &s.badAuth},
+		{Name: "store errors", Value: &s.storeErrors},
+		{Name: "dropped", Value: &s.dropped},
+	})
+}
+
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
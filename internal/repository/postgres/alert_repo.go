@@ -26,7 +26,7 @@ func (r *AlertRepo) GetByID(ctx context.Context, tenantID, alertID uuid.UUID) (*
 	query := `
 		SELECT id, tenant_id, rule_id, name, description, severity, status,
 			target_type, target_id, triggered_at, acknowledged_at, acknowledged_by,
-			resolved_at, metadata
+			resolved_at, metadata, suppressed, maintenance_window_id
 		FROM alerts
 		WHERE id = $1 AND tenant_id = $2
 	`
@@ -36,7 +36,7 @@ func (r *AlertRepo) GetByID(ctx context.Context, tenantID, alertID uuid.UUID) (*
 		&alert.ID, &alert.TenantID, &alert.RuleID, &alert.Name, &alert.Description,
 		&alert.Severity, &alert.Status, &alert.TargetType, &alert.TargetID,
 		&alert.TriggeredAt, &alert.AcknowledgedAt, &alert.AcknowledgedBy,
-		&alert.ResolvedAt, &alert.Metadata,
+		&alert.ResolvedAt, &alert.Metadata, &alert.Suppressed, &alert.MaintenanceWindowID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -59,7 +59,7 @@ func (r *AlertRepo) List(ctx context.Context, tenantID uuid.UUID, opts repositor
 	query := `
 		SELECT id, tenant_id, rule_id, name, description, severity, status,
 			target_type, target_id, triggered_at, acknowledged_at, acknowledged_by,
-			resolved_at, metadata
+			resolved_at, metadata, suppressed, maintenance_window_id
 		FROM alerts
 		WHERE tenant_id = $1
 		ORDER BY triggered_at DESC
@@ -79,7 +79,7 @@ func (r *AlertRepo) List(ctx context.Context, tenantID uuid.UUID, opts repositor
 			&alert.ID, &alert.TenantID, &alert.RuleID, &alert.Name, &alert.Description,
 			&alert.Severity, &alert.Status, &alert.TargetType, &alert.TargetID,
 			&alert.TriggeredAt, &alert.AcknowledgedAt, &alert.AcknowledgedBy,
-			&alert.ResolvedAt, &alert.Metadata,
+			&alert.ResolvedAt, &alert.Metadata, &alert.Suppressed, &alert.MaintenanceWindowID,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -115,3 +115,83 @@ func (r *AlertRepo) Acknowledge(ctx context.Context, tenantID, alertID, userID u
 
 	return nil
 }
+
+// Raise creates an alert for alert.DedupKey unless an unresolved alert with
+// the same key already exists. It reports whether a new alert was created.
+func (r *AlertRepo) Raise(ctx context.Context, alert *models.Alert) (bool, error) {
+	query := `
+		INSERT INTO alerts (id, tenant_id, rule_id, name, description, severity, status,
+			target_type, target_id, triggered_at, metadata, dedup_key, suppressed, maintenance_window_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (tenant_id, dedup_key) WHERE dedup_key IS NOT NULL AND status IN ('active', 'acknowledged')
+		DO NOTHING
+	`
+
+	if alert.ID == uuid.Nil {
+		alert.ID = uuid.New()
+	}
+	if alert.Status == "" {
+		alert.Status = "active"
+	}
+	if alert.TriggeredAt.IsZero() {
+		alert.TriggeredAt = time.Now()
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		alert.ID, alert.TenantID, alert.RuleID, alert.Name, alert.Description, alert.Severity,
+		alert.Status, alert.TargetType, alert.TargetID, alert.TriggeredAt, alert.Metadata, alert.DedupKey,
+		alert.Suppressed, alert.MaintenanceWindowID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// ResolveByDedupKey resolves the unresolved alert raised for dedupKey, if any
+func (r *AlertRepo) ResolveByDedupKey(ctx context.Context, tenantID uuid.UUID, dedupKey string, resolvedAt time.Time) (int64, error) {
+	query := `
+		UPDATE alerts
+		SET status = 'resolved', resolved_at = $1
+		WHERE tenant_id = $2 AND dedup_key = $3 AND status IN ('active', 'acknowledged')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, resolvedAt, tenantID, dedupKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListUnresolvedBySource returns the unresolved alerts of every tenant whose
+// dedup key starts with source
+func (r *AlertRepo) ListUnresolvedBySource(ctx context.Context, source string) ([]*models.Alert, error) {
+	query := `
+		SELECT id, tenant_id, rule_id, name, severity, status, target_type, target_id,
+			triggered_at, dedup_key, suppressed
+		FROM alerts
+		WHERE dedup_key LIKE $1 AND status IN ('active', 'acknowledged')
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, source+":%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]*models.Alert, 0)
+	for rows.Next() {
+		alert := &models.Alert{}
+		err := rows.Scan(
+			&alert.ID, &alert.TenantID, &alert.RuleID, &alert.Name, &alert.Severity, &alert.Status,
+			&alert.TargetType, &alert.TargetID, &alert.TriggeredAt, &alert.DedupKey, &alert.Suppressed,
+		)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
@@ -0,0 +1,323 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const alertRuleColumns = `
+	ar.id, ar.tenant_id, ar.name, ar.description, ar.rule_type, ar.target_type, ar.target_id,
+	ar.condition, ar.threshold_value, ar.severity, ar.enabled, ar.created_by,
+	ar.created_at, ar.updated_at
+`
+
+// AlertRuleRepo implements repository.AlertRuleRepository
+type AlertRuleRepo struct {
+	db *sql.DB
+}
+
+// NewAlertRuleRepo creates a new alert rule repository
+func NewAlertRuleRepo(db *sql.DB) repository.AlertRuleRepository {
+	return &AlertRuleRepo{db: db}
+}
+
+// Create creates a new alert rule
+func (r *AlertRuleRepo) Create(ctx context.Context, rule *models.AlertRule) error {
+	query := `
+		INSERT INTO alert_rules (id, tenant_id, name, description, rule_type, target_type, target_id,
+			condition, threshold_value, severity, enabled, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	if rule.ID == uuid.Nil {
+		rule.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		rule.ID, rule.TenantID, rule.Name, rule.Description, rule.RuleType, rule.TargetType, rule.TargetID,
+		rule.Condition, rule.ThresholdValue, rule.Severity, rule.Enabled, rule.CreatedBy,
+		rule.CreatedAt, rule.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves an alert rule by ID with tenant isolation
+func (r *AlertRuleRepo) GetByID(ctx context.Context, tenantID, ruleID uuid.UUID) (*models.AlertRule, error) {
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM alert_rules ar
+		WHERE ar.id = $1 AND ar.tenant_id = $2
+	`
+
+	rule, err := scanAlertRule(r.db.QueryRowContext(ctx, query, ruleID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("alert rule not found")
+	}
+
+	return rule, err
+}
+
+// List retrieves a paginated list of alert rules by name
+func (r *AlertRuleRepo) List(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]*models.AlertRule, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM alert_rules WHERE tenant_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + alertRuleColumns + `
+		FROM alert_rules ar
+		WHERE ar.tenant_id = $1
+		ORDER BY ar.name, ar.id
+		LIMIT $2 OFFSET $3
+	`
+
+	rules, err := r.query(ctx, query, tenantID, opts.PageSize, offset)
+	return rules, total, err
+}
+
+// Update updates an alert rule
+func (r *AlertRuleRepo) Update(ctx context.Context, rule *models.AlertRule) error {
+	query := `
+		UPDATE alert_rules
+		SET name = $1, description = $2, rule_type = $3, target_type = $4, target_id = $5,
+			condition = $6, threshold_value = $7, severity = $8, enabled = $9, updated_at = $10
+		WHERE id = $11 AND tenant_id = $12
+	`
+
+	rule.UpdatedAt = time.Now()
+
+	res, err := r.db.ExecContext(ctx, query,
+		rule.Name, rule.Description, rule.RuleType, rule.TargetType, rule.TargetID,
+		rule.Condition, rule.ThresholdValue, rule.Severity, rule.Enabled, rule.UpdatedAt,
+		rule.ID, rule.TenantID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+	return nil
+}
+
+// Delete deletes an alert rule; its alerts keep their dedup key and are
+// resolved by the evaluator
+func (r *AlertRuleRepo) Delete(ctx context.Context, tenantID, ruleID uuid.UUID) error {
+	query := `DELETE FROM alert_rules WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, ruleID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+	return nil
+}
+
+// TargetExists reports whether a rule's target exists; router roles are
+// shared by every tenant
+func (r *AlertRuleRepo) TargetExists(ctx context.Context, tenantID uuid.UUID, targetType string, targetID uuid.UUID) (bool, error) {
+	query := `
+		SELECT CASE $2
+			WHEN 'router' THEN EXISTS (SELECT 1 FROM routers WHERE id = $3 AND tenant_id = $1)
+			WHEN 'interface' THEN EXISTS (SELECT 1 FROM interfaces WHERE id = $3 AND tenant_id = $1)
+			WHEN 'pop' THEN EXISTS (SELECT 1 FROM pops WHERE id = $3 AND tenant_id = $1)
+			WHEN 'role' THEN EXISTS (SELECT 1 FROM router_roles WHERE id = $3)
+			ELSE false
+			END
+	`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, tenantID, targetType, targetID).Scan(&exists)
+	return exists, err
+}
+
+// ListEnabled returns the enabled rules of every tenant
+func (r *AlertRuleRepo) ListEnabled(ctx context.Context) ([]*models.AlertRule, error) {
+	return r.query(ctx, `
+		SELECT `+alertRuleColumns+`
+		FROM alert_rules ar
+		WHERE ar.enabled = true
+		ORDER BY ar.tenant_id, ar.name
+	`)
+}
+
+// ListTargets returns the routers a rule applies to: its router, the router
+// of its interface, the routers holding its role or those of its POP, or
+// every router of the tenant
+func (r *AlertRuleRepo) ListTargets(ctx context.Context, rule *models.AlertRule, withInterfaces bool) ([]*repository.AlertRuleTarget, error) {
+	query := `
+		SELECT r.id, r.name, r.last_polled_at
+		FROM routers r
+		WHERE r.tenant_id = $1
+		  AND CASE $2
+		      WHEN 'router' THEN r.id = $3
+		      WHEN 'pop' THEN r.pop_id = $3
+		      WHEN 'role' THEN EXISTS (
+		          SELECT 1 FROM router_role_assignments rra
+		          WHERE rra.router_id = r.id AND rra.role_id = $3)
+		      WHEN 'interface' THEN EXISTS (
+		          SELECT 1 FROM interfaces i
+		          WHERE i.id = $3 AND i.router_id = r.id AND i.tenant_id = r.tenant_id)
+		      ELSE true
+		      END
+		ORDER BY r.name
+	`
+
+	targetType := ""
+	if rule.TargetType != nil {
+		targetType = *rule.TargetType
+	}
+	rows, err := r.db.QueryContext(ctx, query, rule.TenantID, targetType, rule.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	targets := make([]*repository.AlertRuleTarget, 0)
+	byRouter := make(map[uuid.UUID]*repository.AlertRuleTarget)
+	for rows.Next() {
+		t := &repository.AlertRuleTarget{}
+		if err := rows.Scan(&t.RouterID, &t.RouterName, &t.LastPolledAt); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+		byRouter[t.RouterID] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !withInterfaces || len(targets) == 0 {
+		return targets, nil
+	}
+
+	routerIDs := make([]uuid.UUID, 0, len(targets))
+	for _, t := range targets {
+		routerIDs = append(routerIDs, t.RouterID)
+		t.Interfaces = make(map[string]uuid.UUID)
+	}
+	var interfaceID *uuid.UUID
+	if targetType == models.AlertTargetInterface {
+		interfaceID = rule.TargetID
+	}
+
+	ifRows, err := r.db.QueryContext(ctx, `
+		SELECT i.router_id, i.id, i.name
+		FROM interfaces i
+		WHERE i.tenant_id = $1 AND i.router_id = ANY($2) AND ($3::uuid IS NULL OR i.id = $3)
+	`, rule.TenantID, pq.Array(routerIDs), interfaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer ifRows.Close()
+
+	for ifRows.Next() {
+		var routerID, id uuid.UUID
+		var name string
+		if err := ifRows.Scan(&routerID, &id, &name); err != nil {
+			return nil, err
+		}
+		byRouter[routerID].Interfaces[name] = id
+	}
+	return targets, ifRows.Err()
+}
+
+// routerMetricColumns are the metrics stored in router_metrics columns; the
+// others are read from role_specific_metrics
+var routerMetricColumns = map[string]bool{
+	"cpu_percent":         true,
+	"memory_percent":      true,
+	"uptime_seconds":      true,
+	"temperature_celsius": true,
+}
+
+// ListPoints returns the stored values of a router metric, or the times of
+// successful polls
+func (r *AlertRuleRepo) ListPoints(ctx context.Context, metric string, routerIDs []uuid.UUID, from, to time.Time) ([]*models.MetricPoint, error) {
+	query := `
+		SELECT m.router_id, m.timestamp, (m.metrics ->> $1)::double precision
+		FROM role_specific_metrics m
+		WHERE m.router_id = ANY($2) AND m.timestamp >= $3 AND m.timestamp < $4
+		  AND jsonb_typeof(m.metrics -> $1) = 'number'
+		ORDER BY m.router_id, m.timestamp
+	`
+	args := []interface{}{metric, pq.Array(routerIDs), from.UTC(), to.UTC()}
+	switch {
+	case metric == "":
+		query = `
+			SELECT h.router_id, h.poll_completed_at, 1
+			FROM polling_history h
+			WHERE h.router_id = ANY($1) AND h.poll_completed_at >= $2 AND h.poll_completed_at < $3
+			  AND h.success = true
+			ORDER BY h.router_id, h.poll_completed_at
+		`
+		args = args[1:]
+	case routerMetricColumns[metric]:
+		query = fmt.Sprintf(`
+			SELECT m.router_id, m.timestamp, m.%s::double precision
+			FROM router_metrics m
+			WHERE m.router_id = ANY($1) AND m.timestamp >= $2 AND m.timestamp < $3
+			  AND m.%[1]s IS NOT NULL
+			ORDER BY m.router_id, m.timestamp
+		`, metric)
+		args = args[1:]
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]*models.MetricPoint, 0)
+	for rows.Next() {
+		p := &models.MetricPoint{}
+		if err := rows.Scan(&p.RouterID, &p.Timestamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (r *AlertRuleRepo) query(ctx context.Context, query string, args ...interface{}) ([]*models.AlertRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]*models.AlertRule, 0)
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanAlertRule(row interface{ Scan(...interface{}) error }) (*models.AlertRule, error) {
+	rule := &models.AlertRule{}
+	err := row.Scan(
+		&rule.ID, &rule.TenantID, &rule.Name, &rule.Description, &rule.RuleType,
+		&rule.TargetType, &rule.TargetID, &rule.Condition, &rule.ThresholdValue,
+		&rule.Severity, &rule.Enabled, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	return rule, err
+}
@@ -0,0 +1,321 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ComplianceRepo implements repository.ComplianceRepository
+type ComplianceRepo struct {
+	db *sql.DB
+}
+
+// NewComplianceRepo creates a new compliance repository
+func NewComplianceRepo(db *sql.DB) repository.ComplianceRepository {
+	return &ComplianceRepo{db: db}
+}
+
+const compliancePolicyColumns = `id, tenant_id, name, description, rules, role_sections,
+	is_active, created_at, updated_at`
+
+// CreatePolicy creates a new compliance policy
+func (r *ComplianceRepo) CreatePolicy(ctx context.Context, policy *models.CompliancePolicy) error {
+	query := `
+		INSERT INTO compliance_policies (` + compliancePolicyColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	rules, sections, err := marshalPolicyBody(policy)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	if policy.ID == uuid.Nil {
+		policy.ID = uuid.New()
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		policy.ID, policy.TenantID, policy.Name, policy.Description, rules, sections,
+		policy.IsActive, policy.CreatedAt, policy.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("compliance policy already exists")
+	}
+
+	return err
+}
+
+// GetPolicy retrieves a compliance policy by ID with tenant isolation
+func (r *ComplianceRepo) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*models.CompliancePolicy, error) {
+	query := `
+		SELECT ` + compliancePolicyColumns + `
+		FROM compliance_policies
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	policy, err := scanPolicy(r.db.QueryRowContext(ctx, query, policyID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("compliance policy not found")
+	}
+
+	return policy, err
+}
+
+// ListPolicies retrieves a paginated list of compliance policies
+func (r *ComplianceRepo) ListPolicies(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]*models.CompliancePolicy, int64, error) {
+	countQuery := `SELECT COUNT(*) FROM compliance_policies WHERE tenant_id = $1`
+	var total int64
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + compliancePolicyColumns + `
+		FROM compliance_policies
+		WHERE tenant_id = $1
+		ORDER BY name ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	policies, err := r.queryPolicies(ctx, query, tenantID, opts.PageSize, offset)
+	return policies, total, err
+}
+
+// ListActivePolicies retrieves all active compliance policies of a tenant
+func (r *ComplianceRepo) ListActivePolicies(ctx context.Context, tenantID uuid.UUID) ([]*models.CompliancePolicy, error) {
+	query := `
+		SELECT ` + compliancePolicyColumns + `
+		FROM compliance_policies
+		WHERE tenant_id = $1 AND is_active = true
+		ORDER BY name ASC
+	`
+
+	return r.queryPolicies(ctx, query, tenantID)
+}
+
+// UpdatePolicy updates an existing compliance policy
+func (r *ComplianceRepo) UpdatePolicy(ctx context.Context, policy *models.CompliancePolicy) error {
+	query := `
+		UPDATE compliance_policies
+		SET name = $1, description = $2, rules = $3, role_sections = $4,
+			is_active = $5, updated_at = $6
+		WHERE id = $7 AND tenant_id = $8
+	`
+
+	rules, sections, err := marshalPolicyBody(policy)
+	if err != nil {
+		return err
+	}
+
+	policy.UpdatedAt = time.Now()
+
+	_, err = r.db.ExecContext(ctx, query,
+		policy.Name, policy.Description, rules, sections,
+		policy.IsActive, policy.UpdatedAt, policy.ID, policy.TenantID,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("compliance policy already exists")
+	}
+
+	return err
+}
+
+// DeletePolicy deletes a compliance policy and its results with tenant isolation
+func (r *ComplianceRepo) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	query := `DELETE FROM compliance_policies WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, policyID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("compliance policy not found")
+	}
+	return nil
+}
+
+// SaveResult stores the latest evaluation of a policy against a router,
+// replacing the previous one
+func (r *ComplianceRepo) SaveResult(ctx context.Context, result *models.ComplianceResult) error {
+	query := `
+		INSERT INTO compliance_results (id, tenant_id, router_id, policy_id, config_version,
+			score, passed_checks, total_checks, violations, evaluated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (router_id, policy_id) DO UPDATE SET
+			config_version = EXCLUDED.config_version,
+			score = EXCLUDED.score,
+			passed_checks = EXCLUDED.passed_checks,
+			total_checks = EXCLUDED.total_checks,
+			violations = EXCLUDED.violations,
+			evaluated_at = EXCLUDED.evaluated_at
+	`
+
+	if result.ID == uuid.Nil {
+		result.ID = uuid.New()
+	}
+	if result.Violations == nil {
+		result.Violations = []models.ComplianceViolation{}
+	}
+
+	violations, err := json.Marshal(result.Violations)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		result.ID, result.TenantID, result.RouterID, result.PolicyID, result.ConfigVersion,
+		result.Score, result.PassedChecks, result.TotalChecks, string(violations), result.EvaluatedAt,
+	)
+
+	return err
+}
+
+// ListResults retrieves compliance results of active policies, worst score first
+func (r *ComplianceRepo) ListResults(ctx context.Context, tenantID uuid.UUID, filter repository.ComplianceResultFilter) ([]*models.ComplianceResult, error) {
+	conditions := []string{"cr.tenant_id = $1", "cp.is_active = true"}
+	args := []interface{}{tenantID}
+
+	if filter.RouterID != nil {
+		args = append(args, *filter.RouterID)
+		conditions = append(conditions, fmt.Sprintf("cr.router_id = $%d", len(args)))
+	}
+	if filter.PolicyID != nil {
+		args = append(args, *filter.PolicyID)
+		conditions = append(conditions, fmt.Sprintf("cr.policy_id = $%d", len(args)))
+	}
+	if filter.RoleCode != "" {
+		args = append(args, filter.RoleCode)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM router_role_assignments rra
+			JOIN router_roles rr ON rra.role_id = rr.id
+			WHERE rra.router_id = cr.router_id AND rr.code = $%d)`, len(args)))
+	}
+
+	query := `
+		SELECT cr.id, cr.tenant_id, cr.router_id, cr.policy_id, cr.config_version,
+			cr.score, cr.passed_checks, cr.total_checks, cr.violations, cr.evaluated_at,
+			r.name, cp.name,
+			COALESCE((
+				SELECT array_agg(rr.code ORDER BY rra.priority)
+				FROM router_role_assignments rra
+				JOIN router_roles rr ON rra.role_id = rr.id
+				WHERE rra.router_id = cr.router_id
+			), '{}')
+		FROM compliance_results cr
+		JOIN routers r ON cr.router_id = r.id
+		JOIN compliance_policies cp ON cr.policy_id = cp.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY cr.score ASC, r.name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*models.ComplianceResult, 0)
+	for rows.Next() {
+		result := &models.ComplianceResult{}
+		var violations []byte
+		err := rows.Scan(
+			&result.ID, &result.TenantID, &result.RouterID, &result.PolicyID, &result.ConfigVersion,
+			&result.Score, &result.PassedChecks, &result.TotalChecks, &violations, &result.EvaluatedAt,
+			&result.RouterName, &result.PolicyName, pq.Array(&result.RoleCodes),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(violations, &result.Violations); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func (r *ComplianceRepo) queryPolicies(ctx context.Context, query string, args ...interface{}) ([]*models.CompliancePolicy, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]*models.CompliancePolicy, 0)
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPolicy(row rowScanner) (*models.CompliancePolicy, error) {
+	policy := &models.CompliancePolicy{}
+	var rules, sections []byte
+
+	err := row.Scan(
+		&policy.ID, &policy.TenantID, &policy.Name, &policy.Description, &rules, &sections,
+		&policy.IsActive, &policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rules, &policy.Rules); err != nil {
+		return nil, fmt.Errorf("invalid rules in compliance policy %s: %w", policy.ID, err)
+	}
+	if err := json.Unmarshal(sections, &policy.RoleSections); err != nil {
+		return nil, fmt.Errorf("invalid role sections in compliance policy %s: %w", policy.ID, err)
+	}
+
+	return policy, nil
+}
+
+func marshalPolicyBody(policy *models.CompliancePolicy) (string, string, error) {
+	if policy.Rules == nil {
+		policy.Rules = []models.ComplianceRule{}
+	}
+	if policy.RoleSections == nil {
+		policy.RoleSections = map[string][]models.ComplianceSection{}
+	}
+
+	rules, err := json.Marshal(policy.Rules)
+	if err != nil {
+		return "", "", err
+	}
+	sections, err := json.Marshal(policy.RoleSections)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(rules), string(sections), nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
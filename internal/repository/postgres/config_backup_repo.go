@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ConfigBackupRepo implements repository.ConfigBackupRepository
+type ConfigBackupRepo struct {
+	db *sql.DB
+}
+
+// NewConfigBackupRepo creates a new configuration backup repository
+func NewConfigBackupRepo(db *sql.DB) repository.ConfigBackupRepository {
+	return &ConfigBackupRepo{db: db}
+}
+
+// Create stores a new configuration version, assigning the next version number for the router
+func (r *ConfigBackupRepo) Create(ctx context.Context, v *models.ConfigVersion) error {
+	query := `
+		INSERT INTO config_versions (id, tenant_id, router_id, version, content, content_hash,
+			size_bytes, collection_method, lines_added, lines_removed, collected_at,
+			last_verified_at, created_at)
+		VALUES ($1, $2, $3,
+			(SELECT COALESCE(MAX(version), 0) + 1 FROM config_versions WHERE router_id = $3),
+			$4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING version
+	`
+
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	v.CreatedAt = time.Now()
+	v.SizeBytes = len(v.Content)
+
+	return r.db.QueryRowContext(ctx, query,
+		v.ID, v.TenantID, v.RouterID, v.Content, v.ContentHash,
+		v.SizeBytes, v.CollectionMethod, v.LinesAdded, v.LinesRemoved,
+		v.CollectedAt, v.LastVerifiedAt, v.CreatedAt,
+	).Scan(&v.Version)
+}
+
+// GetByVersion retrieves a specific version of a router's configuration with tenant isolation
+func (r *ConfigBackupRepo) GetByVersion(ctx context.Context, tenantID, routerID uuid.UUID, version int) (*models.ConfigVersion, error) {
+	query := `
+		SELECT id, tenant_id, router_id, version, content, content_hash, size_bytes,
+			collection_method, lines_added, lines_removed, collected_at,
+			last_verified_at, created_at
+		FROM config_versions
+		WHERE tenant_id = $1 AND router_id = $2 AND version = $3
+	`
+
+	v := &models.ConfigVersion{}
+	err := r.db.QueryRowContext(ctx, query, tenantID, routerID, version).Scan(
+		&v.ID, &v.TenantID, &v.RouterID, &v.Version, &v.Content, &v.ContentHash,
+		&v.SizeBytes, &v.CollectionMethod, &v.LinesAdded, &v.LinesRemoved,
+		&v.CollectedAt, &v.LastVerifiedAt, &v.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrConfigVersionNotFound
+	}
+
+	return v, err
+}
+
+// GetLatest retrieves the most recent configuration version of a router
+func (r *ConfigBackupRepo) GetLatest(ctx context.Context, tenantID, routerID uuid.UUID) (*models.ConfigVersion, error) {
+	query := `
+		SELECT id, tenant_id, router_id, version, content, content_hash, size_bytes,
+			collection_method, lines_added, lines_removed, collected_at,
+			last_verified_at, created_at
+		FROM config_versions
+		WHERE tenant_id = $1 AND router_id = $2
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	v := &models.ConfigVersion{}
+	err := r.db.QueryRowContext(ctx, query, tenantID, routerID).Scan(
+		&v.ID, &v.TenantID, &v.RouterID, &v.Version, &v.Content, &v.ContentHash,
+		&v.SizeBytes, &v.CollectionMethod, &v.LinesAdded, &v.LinesRemoved,
+		&v.CollectedAt, &v.LastVerifiedAt, &v.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrConfigVersionNotFound
+	}
+
+	return v, err
+}
+
+// List retrieves a paginated list of versions for a router, newest first.
+// Content is omitted; fetch a single version to read it.
+func (r *ConfigBackupRepo) List(ctx context.Context, tenantID, routerID uuid.UUID, opts repository.ListOptions) ([]*models.ConfigVersion, int64, error) {
+	countQuery := `SELECT COUNT(*) FROM config_versions WHERE tenant_id = $1 AND router_id = $2`
+	var total int64
+	err := r.db.QueryRowContext(ctx, countQuery, tenantID, routerID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT id, tenant_id, router_id, version, content_hash, size_bytes,
+			collection_method, lines_added, lines_removed, collected_at,
+			last_verified_at, created_at
+		FROM config_versions
+		WHERE tenant_id = $1 AND router_id = $2
+		ORDER BY version DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, routerID, opts.PageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	versions := make([]*models.ConfigVersion, 0)
+	for rows.Next() {
+		v := &models.ConfigVersion{}
+		err := rows.Scan(
+			&v.ID, &v.TenantID, &v.RouterID, &v.Version, &v.ContentHash,
+			&v.SizeBytes, &v.CollectionMethod, &v.LinesAdded, &v.LinesRemoved,
+			&v.CollectedAt, &v.LastVerifiedAt, &v.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, total, rows.Err()
+}
+
+// MarkVerified records that a backup run saw the stored content unchanged
+func (r *ConfigBackupRepo) MarkVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE config_versions SET last_verified_at = $1 WHERE id = $2",
+		verifiedAt, id,
+	)
+	return err
+}
@@ -0,0 +1,308 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// profileReferenceColumns are the router_capabilities columns referencing
+// a profile of each method
+var profileReferenceColumns = map[string]string{
+	models.CredentialMethodSNMP: "snmp_profile_id",
+	models.CredentialMethodAPI:  "api_profile_id",
+	models.CredentialMethodSSH:  "ssh_profile_id",
+}
+
+// profileOverrideColumns are the router_capabilities credentials that
+// override a profile of each method
+var profileOverrideColumns = map[string][]string{
+	models.CredentialMethodSNMP: {
+		"snmp_version", "snmp_community", "snmp_v3_username", "snmp_v3_auth_protocol",
+		"snmp_v3_auth_password", "snmp_v3_priv_protocol", "snmp_v3_priv_password",
+	},
+	models.CredentialMethodAPI: {"api_username", "api_password"},
+	models.CredentialMethodSSH: {"ssh_username", "ssh_password", "ssh_private_key"},
+}
+
+const credentialProfileColumns = `
+	p.id, p.tenant_id, p.name, p.method, p.description,
+	p.snmp_version, p.snmp_community, p.snmp_v3_auth_protocol, p.snmp_v3_auth_password,
+	p.snmp_v3_priv_protocol, p.snmp_v3_priv_password,
+	p.username, p.password, p.private_key,
+	p.rotated_at, p.created_at, p.updated_at,
+	(SELECT COUNT(*) FROM router_capabilities rc
+	 WHERE rc.snmp_profile_id = p.id OR rc.api_profile_id = p.id OR rc.ssh_profile_id = p.id)
+`
+
+// CredentialProfileRepo implements repository.CredentialProfileRepository
+type CredentialProfileRepo struct {
+	db *sql.DB
+}
+
+// NewCredentialProfileRepo creates a new credential profile repository
+func NewCredentialProfileRepo(db *sql.DB) repository.CredentialProfileRepository {
+	return &CredentialProfileRepo{db: db}
+}
+
+// Create creates a new credential profile
+func (r *CredentialProfileRepo) Create(ctx context.Context, profile *models.CredentialProfile) error {
+	query := `
+		INSERT INTO credential_profiles (id, tenant_id, name, method, description,
+			snmp_version, snmp_community, snmp_v3_auth_protocol, snmp_v3_auth_password,
+			snmp_v3_priv_protocol, snmp_v3_priv_password,
+			username, password, private_key, rotated_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`
+
+	now := time.Now()
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+
+	if profile.ID == uuid.Nil {
+		profile.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		profile.ID, profile.TenantID, profile.Name, profile.Method, profile.Description,
+		profile.SNMPVersion, profile.SNMPCommunity, profile.SNMPV3AuthProtocol, profile.SNMPV3AuthPassword,
+		profile.SNMPV3PrivProtocol, profile.SNMPV3PrivPassword,
+		profile.Username, profile.Password, profile.PrivateKey,
+		profile.RotatedAt, profile.CreatedAt, profile.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("credential profile already exists")
+	}
+
+	return err
+}
+
+// GetByID retrieves a credential profile by ID with tenant isolation
+func (r *CredentialProfileRepo) GetByID(ctx context.Context, tenantID, profileID uuid.UUID) (*models.CredentialProfile, error) {
+	query := `
+		SELECT ` + credentialProfileColumns + `
+		FROM credential_profiles p
+		WHERE p.id = $1 AND p.tenant_id = $2
+	`
+
+	profile, err := scanCredentialProfile(r.db.QueryRowContext(ctx, query, profileID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("credential profile not found")
+	}
+
+	return profile, err
+}
+
+// List retrieves a paginated list of credential profiles, optionally of one method
+func (r *CredentialProfileRepo) List(ctx context.Context, tenantID uuid.UUID, method string, opts repository.ListOptions) ([]*models.CredentialProfile, int64, error) {
+	where := `WHERE p.tenant_id = $1 AND ($2 = '' OR p.method = $2)`
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM credential_profiles p ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID, method).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + credentialProfileColumns + `
+		FROM credential_profiles p
+		` + where + `
+		ORDER BY p.method, p.name
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, method, opts.PageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	profiles := make([]*models.CredentialProfile, 0)
+	for rows.Next() {
+		profile, err := scanCredentialProfile(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, total, rows.Err()
+}
+
+// Update updates a credential profile; its method cannot change
+func (r *CredentialProfileRepo) Update(ctx context.Context, profile *models.CredentialProfile) error {
+	query := `
+		UPDATE credential_profiles
+		SET name = $1, description = $2,
+			snmp_version = $3, snmp_community = $4, snmp_v3_auth_protocol = $5,
+			snmp_v3_auth_password = $6, snmp_v3_priv_protocol = $7, snmp_v3_priv_password = $8,
+			username = $9, password = $10, private_key = $11, rotated_at = $12, updated_at = $13
+		WHERE id = $14 AND tenant_id = $15
+	`
+
+	profile.UpdatedAt = time.Now()
+
+	res, err := r.db.ExecContext(ctx, query,
+		profile.Name, profile.Description,
+		profile.SNMPVersion, profile.SNMPCommunity, profile.SNMPV3AuthProtocol,
+		profile.SNMPV3AuthPassword, profile.SNMPV3PrivProtocol, profile.SNMPV3PrivPassword,
+		profile.Username, profile.Password, profile.PrivateKey, profile.RotatedAt, profile.UpdatedAt,
+		profile.ID, profile.TenantID,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("credential profile already exists")
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("credential profile not found")
+	}
+	return nil
+}
+
+// Delete deletes a credential profile that no router references
+func (r *CredentialProfileRepo) Delete(ctx context.Context, tenantID, profileID uuid.UUID) error {
+	query := `DELETE FROM credential_profiles WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, profileID, tenantID)
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("credential profile is in use")
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("credential profile not found")
+	}
+	return nil
+}
+
+// ListRouterIDs returns the routers referencing a profile
+func (r *CredentialProfileRepo) ListRouterIDs(ctx context.Context, profile *models.CredentialProfile) ([]uuid.UUID, error) {
+	column, ok := profileReferenceColumns[profile.Method]
+	if !ok {
+		return nil, fmt.Errorf("invalid credential method %q", profile.Method)
+	}
+
+	query := `
+		SELECT rc.router_id
+		FROM router_capabilities rc
+		JOIN routers r ON r.id = rc.router_id
+		WHERE rc.` + column + ` = $1 AND rc.tenant_id = $2
+		ORDER BY r.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, profile.ID, profile.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routerIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		routerIDs = append(routerIDs, id)
+	}
+	return routerIDs, rows.Err()
+}
+
+// AssignRouters makes routers of the profile's tenant reference it. Routers
+// without capabilities get them with their legacy SNMP settings, as
+// migrated routers did, so that their polling is unchanged.
+func (r *CredentialProfileRepo) AssignRouters(ctx context.Context, profile *models.CredentialProfile, routerIDs []uuid.UUID, keepOverrides bool) (int, error) {
+	column, ok := profileReferenceColumns[profile.Method]
+	if !ok {
+		return 0, fmt.Errorf("invalid credential method %q", profile.Method)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO router_capabilities (router_id, tenant_id, snmp_enabled, snmp_version,
+			snmp_port, preferred_method, fallback_order)
+		SELECT r.id, r.tenant_id, r.polling_enabled, r.snmp_version, r.snmp_port, 'snmp', ARRAY['snmp']
+		FROM routers r
+		WHERE r.id = ANY($1) AND r.tenant_id = $2
+		ON CONFLICT (router_id) DO NOTHING
+	`, pq.Array(routerIDs), profile.TenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	assignments := []string{column + " = $1"}
+	if !keepOverrides {
+		for _, override := range profileOverrideColumns[profile.Method] {
+			assignments = append(assignments, override+" = NULL")
+		}
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE router_capabilities
+		SET `+strings.Join(assignments, ", ")+`
+		WHERE router_id = ANY($2) AND tenant_id = $3
+	`, profile.ID, pq.Array(routerIDs), profile.TenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n), tx.Commit()
+}
+
+// UnassignRouter removes a router's reference to a profile. The router's
+// own credentials, if any, are used afterwards.
+func (r *CredentialProfileRepo) UnassignRouter(ctx context.Context, profile *models.CredentialProfile, routerID uuid.UUID) error {
+	column, ok := profileReferenceColumns[profile.Method]
+	if !ok {
+		return fmt.Errorf("invalid credential method %q", profile.Method)
+	}
+
+	query := `
+		UPDATE router_capabilities SET ` + column + ` = NULL
+		WHERE router_id = $1 AND tenant_id = $2 AND ` + column + ` = $3
+	`
+	res, err := r.db.ExecContext(ctx, query, routerID, profile.TenantID, profile.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("router not found for credential profile")
+	}
+	return nil
+}
+
+func scanCredentialProfile(row interface{ Scan(...interface{}) error }) (*models.CredentialProfile, error) {
+	profile := &models.CredentialProfile{}
+	err := row.Scan(
+		&profile.ID, &profile.TenantID, &profile.Name, &profile.Method, &profile.Description,
+		&profile.SNMPVersion, &profile.SNMPCommunity, &profile.SNMPV3AuthProtocol, &profile.SNMPV3AuthPassword,
+		&profile.SNMPV3PrivProtocol, &profile.SNMPV3PrivPassword,
+		&profile.Username, &profile.Password, &profile.PrivateKey,
+		&profile.RotatedAt, &profile.CreatedAt, &profile.UpdatedAt,
+		&profile.RouterCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key violation
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
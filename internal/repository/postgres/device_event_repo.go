@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// DeviceEventRepo implements repository.DeviceEventRepository
+type DeviceEventRepo struct {
+	db *sql.DB
+}
+
+// NewDeviceEventRepo creates a new device event repository
+func NewDeviceEventRepo(db *sql.DB) repository.DeviceEventRepository {
+	return &DeviceEventRepo{db: db}
+}
+
+// Create stores a device event
+func (r *DeviceEventRepo) Create(ctx context.Context, event *models.DeviceEvent) error {
+	query := `
+		INSERT INTO device_events (id, tenant_id, router_id, event_type, severity, source,
+			message, metadata, occurred_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	event.CreatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.TenantID, event.RouterID, event.EventType, event.Severity,
+		event.Source, event.Message, event.Metadata, event.OccurredAt, event.CreatedAt,
+	)
+
+	return err
+}
@@ -0,0 +1,345 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const escalationPolicyColumns = `
+	ep.id, ep.tenant_id, ep.name, ep.description, ep.severities, ep.role_codes, ep.pop_ids,
+	ep.tiers, ep.repeat_count, ep.enabled, ep.created_by, ep.created_at, ep.updated_at
+`
+
+const alertEscalationColumns = `
+	e.alert_id, e.policy_id, e.tenant_id, e.tier, e.repeats, e.next_escalation_at,
+	e.stopped_at, e.stop_reason, e.created_at, e.updated_at
+`
+
+// EscalationRepo implements repository.EscalationRepository
+type EscalationRepo struct {
+	db *sql.DB
+}
+
+// NewEscalationRepo creates a new escalation repository
+func NewEscalationRepo(db *sql.DB) repository.EscalationRepository {
+	return &EscalationRepo{db: db}
+}
+
+// CreatePolicy creates a new escalation policy
+func (r *EscalationRepo) CreatePolicy(ctx context.Context, policy *models.EscalationPolicy) error {
+	query := `
+		INSERT INTO escalation_policies (id, tenant_id, name, description, severities, role_codes, pop_ids,
+			tiers, repeat_count, enabled, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	if policy.ID == uuid.Nil {
+		policy.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		policy.ID, policy.TenantID, policy.Name, policy.Description,
+		pq.Array(nonNilStrings(policy.Severities)), pq.Array(nonNilStrings(policy.RoleCodes)),
+		pq.Array(nonNilUUIDs(policy.POPIDs)),
+		policy.Tiers, policy.RepeatCount, policy.Enabled, policy.CreatedBy, policy.CreatedAt, policy.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("escalation policy already exists")
+	}
+
+	return err
+}
+
+// GetPolicy retrieves an escalation policy by ID with tenant isolation
+func (r *EscalationRepo) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*models.EscalationPolicy, error) {
+	query := `
+		SELECT ` + escalationPolicyColumns + `
+		FROM escalation_policies ep
+		WHERE ep.id = $1 AND ep.tenant_id = $2
+	`
+
+	policy, err := scanEscalationPolicy(r.db.QueryRowContext(ctx, query, policyID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("escalation policy not found")
+	}
+
+	return policy, err
+}
+
+// ListPolicies retrieves a paginated list of escalation policies by name
+func (r *EscalationRepo) ListPolicies(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]*models.EscalationPolicy, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM escalation_policies WHERE tenant_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + escalationPolicyColumns + `
+		FROM escalation_policies ep
+		WHERE ep.tenant_id = $1
+		ORDER BY ep.name
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, opts.PageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	policies := make([]*models.EscalationPolicy, 0)
+	for rows.Next() {
+		policy, err := scanEscalationPolicy(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, total, rows.Err()
+}
+
+// UpdatePolicy updates an escalation policy; running escalations continue
+// with its new tiers
+func (r *EscalationRepo) UpdatePolicy(ctx context.Context, policy *models.EscalationPolicy) error {
+	query := `
+		UPDATE escalation_policies
+		SET name = $1, description = $2, severities = $3, role_codes = $4, pop_ids = $5,
+			tiers = $6, repeat_count = $7, enabled = $8, updated_at = $9
+		WHERE id = $10 AND tenant_id = $11
+	`
+
+	policy.UpdatedAt = time.Now()
+
+	res, err := r.db.ExecContext(ctx, query,
+		policy.Name, policy.Description,
+		pq.Array(nonNilStrings(policy.Severities)), pq.Array(nonNilStrings(policy.RoleCodes)),
+		pq.Array(nonNilUUIDs(policy.POPIDs)),
+		policy.Tiers, policy.RepeatCount, policy.Enabled, policy.UpdatedAt,
+		policy.ID, policy.TenantID,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("escalation policy already exists")
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("escalation policy not found")
+	}
+	return nil
+}
+
+// DeletePolicy deletes an escalation policy, ending its escalations;
+// deliveries already queued are still sent
+func (r *EscalationRepo) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	query := `DELETE FROM escalation_policies WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, policyID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("escalation policy not found")
+	}
+	return nil
+}
+
+// ListAlertEscalations retrieves the escalations of an alert, oldest first
+func (r *EscalationRepo) ListAlertEscalations(ctx context.Context, tenantID, alertID uuid.UUID) ([]*models.AlertEscalation, error) {
+	query := `
+		SELECT ` + alertEscalationColumns + `
+		FROM alert_escalations e
+		WHERE e.alert_id = $1 AND e.tenant_id = $2
+		ORDER BY e.created_at, e.policy_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, alertID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	escalations := make([]*models.AlertEscalation, 0)
+	for rows.Next() {
+		escalation, err := scanAlertEscalation(rows)
+		if err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, escalation)
+	}
+	return escalations, rows.Err()
+}
+
+// ClaimEscalations claims the running escalations whose next tier is due,
+// oldest first
+func (r *EscalationRepo) ClaimEscalations(ctx context.Context, limit int, lease time.Duration) ([]*repository.EscalationJob, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE alert_escalations
+			SET next_escalation_at = CURRENT_TIMESTAMP + $2::double precision * INTERVAL '1 second'
+			WHERE (alert_id, policy_id) IN (
+				SELECT alert_id, policy_id FROM alert_escalations
+				WHERE stopped_at IS NULL AND next_escalation_at <= CURRENT_TIMESTAMP
+				ORDER BY next_escalation_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + alertEscalationColumns + `, ` + escalationPolicyColumns + `,
+			a.id, a.tenant_id, a.rule_id, a.name, a.description, a.severity, a.status,
+			a.target_type, a.target_id, a.triggered_at, a.acknowledged_at, a.resolved_at,
+			a.metadata, a.suppressed
+		FROM claimed e
+		JOIN escalation_policies ep ON ep.id = e.policy_id
+		JOIN alerts a ON a.id = e.alert_id
+		ORDER BY e.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]*repository.EscalationJob, 0)
+	for rows.Next() {
+		e := &models.AlertEscalation{}
+		ep := &models.EscalationPolicy{}
+		a := &models.Alert{}
+		err := rows.Scan(
+			&e.AlertID, &e.PolicyID, &e.TenantID, &e.Tier, &e.Repeats, &e.NextEscalationAt,
+			&e.StoppedAt, &e.StopReason, &e.CreatedAt, &e.UpdatedAt,
+			&ep.ID, &ep.TenantID, &ep.Name, &ep.Description,
+			pq.Array(&ep.Severities), pq.Array(&ep.RoleCodes), pq.Array(&ep.POPIDs),
+			&ep.Tiers, &ep.RepeatCount, &ep.Enabled, &ep.CreatedBy, &ep.CreatedAt, &ep.UpdatedAt,
+			&a.ID, &a.TenantID, &a.RuleID, &a.Name, &a.Description, &a.Severity, &a.Status,
+			&a.TargetType, &a.TargetID, &a.TriggeredAt, &a.AcknowledgedAt, &a.ResolvedAt,
+			&a.Metadata, &a.Suppressed,
+		)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &repository.EscalationJob{Escalation: e, Policy: ep, Alert: a})
+	}
+	return jobs, rows.Err()
+}
+
+// AdvanceEscalation queues a tier's deliveries and stores the escalation's
+// next step in one transaction. The update only matches while the
+// escalation is still running at tier and repeats, so that an
+// acknowledgement or another dispatcher in between pages nothing.
+func (r *EscalationRepo) AdvanceEscalation(ctx context.Context, escalation *models.AlertEscalation, tier, repeats int, deliveries []*models.NotificationDelivery) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE alert_escalations
+		SET tier = $1, repeats = $2, next_escalation_at = $3, stopped_at = $4, stop_reason = $5
+		WHERE alert_id = $6 AND policy_id = $7 AND tier = $8 AND repeats = $9 AND stopped_at IS NULL
+	`,
+		escalation.Tier, escalation.Repeats, escalation.NextEscalationAt, escalation.StoppedAt,
+		escalation.StopReason, escalation.AlertID, escalation.PolicyID, tier, repeats,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	for _, delivery := range deliveries {
+		if err := insertDelivery(ctx, tx, delivery); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// StopEscalation stops one escalation of an alert
+func (r *EscalationRepo) StopEscalation(ctx context.Context, alertID, policyID uuid.UUID, reason string) error {
+	query := `
+		UPDATE alert_escalations
+		SET stopped_at = CURRENT_TIMESTAMP, stop_reason = $1, next_escalation_at = NULL
+		WHERE alert_id = $2 AND policy_id = $3 AND stopped_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, reason, alertID, policyID)
+	return err
+}
+
+// StopAlertEscalations stops the running escalations of an alert and
+// cancels the escalation deliveries not sent yet
+func (r *EscalationRepo) StopAlertEscalations(ctx context.Context, tenantID, alertID uuid.UUID, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE alert_escalations
+		SET stopped_at = CURRENT_TIMESTAMP, stop_reason = $1, next_escalation_at = NULL
+		WHERE alert_id = $2 AND tenant_id = $3 AND stopped_at IS NULL
+	`, reason, alertID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE notification_deliveries
+		SET status = 'cancelled', next_attempt_at = NULL, last_error = 'alert ' || $1::text
+		WHERE alert_id = $2 AND tenant_id = $3 AND event = 'escalation' AND status = 'pending'
+	`, reason, alertID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanEscalationPolicy(row interface{ Scan(...interface{}) error }) (*models.EscalationPolicy, error) {
+	policy := &models.EscalationPolicy{}
+	err := row.Scan(
+		&policy.ID, &policy.TenantID, &policy.Name, &policy.Description,
+		pq.Array(&policy.Severities), pq.Array(&policy.RoleCodes), pq.Array(&policy.POPIDs),
+		&policy.Tiers, &policy.RepeatCount, &policy.Enabled, &policy.CreatedBy,
+		&policy.CreatedAt, &policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func scanAlertEscalation(row interface{ Scan(...interface{}) error }) (*models.AlertEscalation, error) {
+	escalation := &models.AlertEscalation{}
+	err := row.Scan(
+		&escalation.AlertID, &escalation.PolicyID, &escalation.TenantID, &escalation.Tier,
+		&escalation.Repeats, &escalation.NextEscalationAt, &escalation.StoppedAt,
+		&escalation.StopReason, &escalation.CreatedAt, &escalation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return escalation, nil
+}
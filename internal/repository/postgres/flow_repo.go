@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// FlowRepo implements repository.FlowRepository
+type FlowRepo struct {
+	db *sql.DB
+}
+
+// NewFlowRepo creates a new flow rollup repository
+func NewFlowRepo(db *sql.DB) repository.FlowRepository {
+	return &FlowRepo{db: db}
+}
+
+// flowUpsertBatch keeps each statement well below PostgreSQL's limit of
+// 65535 bind parameters
+const flowUpsertBatch = 1000
+
+// flowGroupExpressions maps FlowRepository.Top dimensions to SQL
+var flowGroupExpressions = map[string]string{
+	repository.FlowGroupInterface: `r.name || ' ' || COALESCE(i.name, 'ifIndex ' || f.input_if_index)`,
+	repository.FlowGroupProtocol:  `f.protocol::text`,
+	repository.FlowGroupSrcPrefix: `f.src_prefix::text`,
+	repository.FlowGroupDstPrefix: `f.dst_prefix::text`,
+	repository.FlowGroupSrcAS:     `f.src_as::text`,
+	repository.FlowGroupDstAS:     `f.dst_as::text`,
+}
+
+// UpsertRollups adds rollups to the stored totals. A minute flushed more than
+// once, for example because of late exports, accumulates rather than being
+// overwritten.
+func (r *FlowRepo) UpsertRollups(ctx context.Context, rollups []*models.FlowRollup) error {
+	for start := 0; start < len(rollups); start += flowUpsertBatch {
+		end := start + flowUpsertBatch
+		if end > len(rollups) {
+			end = len(rollups)
+		}
+		if err := r.upsertBatch(ctx, rollups[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *FlowRepo) upsertBatch(ctx context.Context, rollups []*models.FlowRollup) error {
+	const columnCount = 13
+	placeholders := make([]string, 0, len(rollups))
+	args := make([]interface{}, 0, len(rollups)*columnCount)
+	for i, f := range rollups {
+		base := i * columnCount
+		params := make([]string, columnCount)
+		for j := range params {
+			params[j] = fmt.Sprintf("$%d", base+j+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(params, ", ")+")")
+		args = append(args,
+			f.TenantID, f.RouterID, f.Bucket, f.InputIfIndex, f.OutputIfIndex, f.Protocol,
+			f.SrcPrefix, f.DstPrefix, f.SrcAS, f.DstAS, f.Bytes, f.Packets, f.Flows,
+		)
+	}
+
+	query := `
+		INSERT INTO flow_rollups (tenant_id, router_id, bucket, input_if_index, output_if_index, protocol,
+			src_prefix, dst_prefix, src_as, dst_as, bytes, packets, flows)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+		ON CONFLICT ON CONSTRAINT unique_flow_rollup DO UPDATE SET
+			bytes = flow_rollups.bytes + EXCLUDED.bytes,
+			packets = flow_rollups.packets + EXCLUDED.packets,
+			flows = flow_rollups.flows + EXCLUDED.flows
+	`
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Top returns the largest keys of one dimension by bytes
+func (r *FlowRepo) Top(ctx context.Context, tenantID uuid.UUID, filter repository.FlowFilter, groupBy string, limit int) ([]*models.FlowTotal, error) {
+	expr, ok := flowGroupExpressions[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported flow grouping %q", groupBy)
+	}
+
+	where, args := flowConditions(tenantID, filter)
+	args = append(args, limit)
+
+	joins := ""
+	if groupBy == repository.FlowGroupInterface {
+		joins = `
+			JOIN routers r ON r.id = f.router_id
+			LEFT JOIN interfaces i ON i.router_id = f.router_id AND i.if_index = f.input_if_index`
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s AS key, SUM(f.bytes), SUM(f.packets), SUM(f.flows)
+		FROM flow_rollups f %s
+		WHERE %s
+		GROUP BY 1
+		ORDER BY 2 DESC
+		LIMIT $%d
+	`, expr, joins, where, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]*models.FlowTotal, 0)
+	for rows.Next() {
+		t := &models.FlowTotal{}
+		if err := rows.Scan(&t.Key, &t.Bytes, &t.Packets, &t.Flows); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+// Matrix returns the largest source/destination pairs by bytes, keyed by
+// prefix or, when byAS is set, by AS number
+func (r *FlowRepo) Matrix(ctx context.Context, tenantID uuid.UUID, filter repository.FlowFilter, byAS bool, limit int) ([]*models.FlowMatrixCell, error) {
+	src, dst := "f.src_prefix::text", "f.dst_prefix::text"
+	if byAS {
+		src, dst = "f.src_as::text", "f.dst_as::text"
+	}
+
+	where, args := flowConditions(tenantID, filter)
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT %s, %s, SUM(f.bytes), SUM(f.packets), SUM(f.flows)
+		FROM flow_rollups f
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY 3 DESC
+		LIMIT $%d
+	`, src, dst, where, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cells := make([]*models.FlowMatrixCell, 0)
+	for rows.Next() {
+		c := &models.FlowMatrixCell{}
+		if err := rows.Scan(&c.Source, &c.Destination, &c.Bytes, &c.Packets, &c.Flows); err != nil {
+			return nil, err
+		}
+		cells = append(cells, c)
+	}
+
+	return cells, rows.Err()
+}
+
+// DeleteBefore removes up to limit rollups for minutes before cutoff
+func (r *FlowRepo) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM flow_rollups
+		WHERE id IN (
+			SELECT id FROM flow_rollups WHERE bucket < $1 LIMIT $2
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// flowConditions builds the WHERE clause shared by the flow queries
+func flowConditions(tenantID uuid.UUID, filter repository.FlowFilter) (string, []interface{}) {
+	conditions := []string{"f.tenant_id = $1", "f.bucket >= $2", "f.bucket < $3"}
+	args := []interface{}{tenantID, filter.From, filter.To}
+
+	if filter.RouterID != nil {
+		args = append(args, *filter.RouterID)
+		conditions = append(conditions, fmt.Sprintf("f.router_id = $%d", len(args)))
+	}
+	if filter.Protocol != nil {
+		args = append(args, *filter.Protocol)
+		conditions = append(conditions, fmt.Sprintf("f.protocol = $%d", len(args)))
+	}
+	if filter.IfIndex != nil {
+		args = append(args, *filter.IfIndex)
+		conditions = append(conditions, fmt.Sprintf("(f.input_if_index = $%d OR f.output_if_index = $%d)", len(args), len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// InterfaceMetricRepo implements repository.InterfaceMetricRepository
+type InterfaceMetricRepo struct {
+	db *sql.DB
+}
+
+// NewInterfaceMetricRepo creates a new interface metric repository
+func NewInterfaceMetricRepo(db *sql.DB) repository.InterfaceMetricRepository {
+	return &InterfaceMetricRepo{db: db}
+}
+
+// CreateBatch stores samples with a single multi-row insert
+func (r *InterfaceMetricRepo) CreateBatch(ctx context.Context, metrics []*models.InterfaceMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	const columnCount = 12
+	placeholders := make([]string, 0, len(metrics))
+	args := make([]interface{}, 0, len(metrics)*columnCount)
+	for i, m := range metrics {
+		base := i * columnCount
+		params := make([]string, columnCount)
+		for j := range params {
+			params[j] = fmt.Sprintf("$%d", base+j+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(params, ", ")+")")
+		args = append(args,
+			m.TenantID, m.InterfaceID, m.Timestamp, m.InOctets, m.OutOctets,
+			m.InPackets, m.OutPackets, m.InErrors, m.OutErrors,
+			m.InDiscards, m.OutDiscards, m.UtilizationPercent,
+		)
+	}
+
+	query := `
+		INSERT INTO interface_metrics
+			(tenant_id, interface_id, timestamp, in_octets, out_octets,
+			 in_packets, out_packets, in_errors, out_errors,
+			 in_discards, out_discards, utilization_percent)
+		VALUES ` + strings.Join(placeholders, ", ")
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
@@ -132,3 +133,30 @@ func (r *InterfaceRepo) ListByRouter(ctx context.Context, tenantID, routerID uui
 
 	return interfaces, total, rows.Err()
 }
+
+// UpdateStatusByIndex sets the operational and administrative status of the
+// router interface with the given ifIndex and returns the updated interface
+func (r *InterfaceRepo) UpdateStatusByIndex(ctx context.Context, tenantID, routerID uuid.UUID, ifIndex int, status, adminStatus string) (*models.Interface, error) {
+	query := `
+		UPDATE interfaces
+		SET status = $1, admin_status = $2, updated_at = $3
+		WHERE tenant_id = $4 AND router_id = $5 AND if_index = $6
+		RETURNING id, tenant_id, router_id, name, description, if_index, if_type,
+			speed_mbps, mtu, mac_address, ip_address, subnet_mask, status,
+			admin_status, created_at, updated_at
+	`
+
+	iface := &models.Interface{}
+	err := r.db.QueryRowContext(ctx, query, status, adminStatus, time.Now(), tenantID, routerID, ifIndex).Scan(
+		&iface.ID, &iface.TenantID, &iface.RouterID, &iface.Name, &iface.Description,
+		&iface.IfIndex, &iface.IfType, &iface.SpeedMbps, &iface.MTU, &iface.MACAddress,
+		&iface.IPAddress, &iface.SubnetMask, &iface.Status, &iface.AdminStatus,
+		&iface.CreatedAt, &iface.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("interface not found")
+	}
+
+	return iface, err
+}
@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const maintenanceWindowColumns = `
+	w.id, w.tenant_id, w.name, w.description, w.starts_at, w.ends_at,
+	w.recurrence, w.duration_minutes, w.timezone,
+	w.router_ids, w.pop_ids, w.region_ids, w.role_codes,
+	w.pause_polling, w.enabled, w.created_by, w.created_at, w.updated_at
+`
+
+// MaintenanceWindowRepo implements repository.MaintenanceWindowRepository
+type MaintenanceWindowRepo struct {
+	db *sql.DB
+}
+
+// NewMaintenanceWindowRepo creates a new maintenance window repository
+func NewMaintenanceWindowRepo(db *sql.DB) repository.MaintenanceWindowRepository {
+	return &MaintenanceWindowRepo{db: db}
+}
+
+// Create creates a new maintenance window
+func (r *MaintenanceWindowRepo) Create(ctx context.Context, window *models.MaintenanceWindow) error {
+	query := `
+		INSERT INTO maintenance_windows (id, tenant_id, name, description, starts_at, ends_at,
+			recurrence, duration_minutes, timezone,
+			router_ids, pop_ids, region_ids, role_codes,
+			pause_polling, enabled, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`
+
+	now := time.Now()
+	window.CreatedAt = now
+	window.UpdatedAt = now
+
+	if window.ID == uuid.Nil {
+		window.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		window.ID, window.TenantID, window.Name, window.Description, window.StartsAt, window.EndsAt,
+		window.Recurrence, window.DurationMinutes, window.Timezone,
+		pq.Array(nonNilUUIDs(window.RouterIDs)), pq.Array(nonNilUUIDs(window.POPIDs)),
+		pq.Array(nonNilUUIDs(window.RegionIDs)), pq.Array(nonNilStrings(window.RoleCodes)),
+		window.PausePolling, window.Enabled, window.CreatedBy, window.CreatedAt, window.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a maintenance window by ID with tenant isolation
+func (r *MaintenanceWindowRepo) GetByID(ctx context.Context, tenantID, windowID uuid.UUID) (*models.MaintenanceWindow, error) {
+	query := `
+		SELECT ` + maintenanceWindowColumns + `
+		FROM maintenance_windows w
+		WHERE w.id = $1 AND w.tenant_id = $2
+	`
+
+	window, err := scanMaintenanceWindow(r.db.QueryRowContext(ctx, query, windowID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("maintenance window not found")
+	}
+
+	return window, err
+}
+
+// List retrieves a paginated list of maintenance windows, latest first
+func (r *MaintenanceWindowRepo) List(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]*models.MaintenanceWindow, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM maintenance_windows WHERE tenant_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + maintenanceWindowColumns + `
+		FROM maintenance_windows w
+		WHERE w.tenant_id = $1
+		ORDER BY w.starts_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	windows, err := r.query(ctx, query, tenantID, opts.PageSize, offset)
+	return windows, total, err
+}
+
+// Update updates a maintenance window
+func (r *MaintenanceWindowRepo) Update(ctx context.Context, window *models.MaintenanceWindow) error {
+	query := `
+		UPDATE maintenance_windows
+		SET name = $1, description = $2, starts_at = $3, ends_at = $4,
+			recurrence = $5, duration_minutes = $6, timezone = $7,
+			router_ids = $8, pop_ids = $9, region_ids = $10, role_codes = $11,
+			pause_polling = $12, enabled = $13, updated_at = $14
+		WHERE id = $15 AND tenant_id = $16
+	`
+
+	window.UpdatedAt = time.Now()
+
+	res, err := r.db.ExecContext(ctx, query,
+		window.Name, window.Description, window.StartsAt, window.EndsAt,
+		window.Recurrence, window.DurationMinutes, window.Timezone,
+		pq.Array(nonNilUUIDs(window.RouterIDs)), pq.Array(nonNilUUIDs(window.POPIDs)),
+		pq.Array(nonNilUUIDs(window.RegionIDs)), pq.Array(nonNilStrings(window.RoleCodes)),
+		window.PausePolling, window.Enabled, window.UpdatedAt,
+		window.ID, window.TenantID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+	return nil
+}
+
+// Delete deletes a maintenance window; alerts it suppressed stay suppressed
+func (r *MaintenanceWindowRepo) Delete(ctx context.Context, tenantID, windowID uuid.UUID) error {
+	query := `DELETE FROM maintenance_windows WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, windowID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("maintenance window not found")
+	}
+	return nil
+}
+
+// ListEnabled returns the enabled windows of a tenant, or of every tenant,
+// that may be active at or after filter.Since, optionally only those
+// covering a router
+func (r *MaintenanceWindowRepo) ListEnabled(ctx context.Context, filter repository.MaintenanceWindowFilter) ([]*models.MaintenanceWindow, error) {
+	query := `
+		WITH RECURSIVE router_regions AS (
+			SELECT p.region_id AS id
+			FROM routers r
+			JOIN pops p ON p.id = r.pop_id
+			WHERE r.id = $2 AND p.region_id IS NOT NULL
+			UNION
+			SELECT rg.parent_region_id
+			FROM regions rg
+			JOIN router_regions rr ON rg.id = rr.id
+			WHERE rg.parent_region_id IS NOT NULL
+		)
+		SELECT ` + maintenanceWindowColumns + `
+		FROM maintenance_windows w
+		WHERE w.enabled = true
+		  AND ($1::uuid IS NULL OR w.tenant_id = $1)
+		  AND (w.ends_at IS NULL
+		       OR w.ends_at + COALESCE(w.duration_minutes, 0) * INTERVAL '1 minute' > $3)
+		  AND ($2::uuid IS NULL
+		       OR cardinality(w.router_ids) + cardinality(w.pop_ids)
+		          + cardinality(w.region_ids) + cardinality(w.role_codes) = 0
+		       OR $2 = ANY(w.router_ids)
+		       OR EXISTS (SELECT 1 FROM routers r WHERE r.id = $2 AND r.pop_id = ANY(w.pop_ids))
+		       OR w.region_ids && ARRAY(SELECT id FROM router_regions)
+		       OR w.role_codes && ARRAY(
+		              SELECT rr.code::text
+		              FROM router_role_assignments rra
+		              JOIN router_roles rr ON rr.id = rra.role_id
+		              WHERE rra.router_id = $2))
+		ORDER BY w.starts_at
+	`
+
+	return r.query(ctx, query, filter.TenantID, filter.RouterID, filter.Since)
+}
+
+// ListRouterIDs returns the routers a window's scope covers: the listed
+// routers, those in the listed POPs or regions and their sub-regions, and
+// those holding a listed role, or every router of the tenant when the
+// window has no scope
+func (r *MaintenanceWindowRepo) ListRouterIDs(ctx context.Context, window *models.MaintenanceWindow) ([]uuid.UUID, error) {
+	query := `
+		WITH RECURSIVE scope_regions AS (
+			SELECT id FROM regions WHERE tenant_id = $1 AND id = ANY($4)
+			UNION
+			SELECT rg.id
+			FROM regions rg
+			JOIN scope_regions s ON rg.parent_region_id = s.id
+		)
+		SELECT r.id
+		FROM routers r
+		LEFT JOIN pops p ON p.id = r.pop_id
+		WHERE r.tenant_id = $1
+		  AND (NOT $6
+		       OR r.id = ANY($2)
+		       OR r.pop_id = ANY($3)
+		       OR p.region_id IN (SELECT id FROM scope_regions)
+		       OR EXISTS (
+		              SELECT 1
+		              FROM router_role_assignments rra
+		              JOIN router_roles rr ON rr.id = rra.role_id
+		              WHERE rra.router_id = r.id AND rr.code = ANY($5)))
+		ORDER BY r.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, window.TenantID,
+		pq.Array(nonNilUUIDs(window.RouterIDs)), pq.Array(nonNilUUIDs(window.POPIDs)),
+		pq.Array(nonNilUUIDs(window.RegionIDs)), pq.Array(nonNilStrings(window.RoleCodes)),
+		window.HasScope(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routerIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		routerIDs = append(routerIDs, id)
+	}
+	return routerIDs, rows.Err()
+}
+
+func (r *MaintenanceWindowRepo) query(ctx context.Context, query string, args ...interface{}) ([]*models.MaintenanceWindow, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := make([]*models.MaintenanceWindow, 0)
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+func scanMaintenanceWindow(row interface{ Scan(...interface{}) error }) (*models.MaintenanceWindow, error) {
+	window := &models.MaintenanceWindow{}
+	err := row.Scan(
+		&window.ID, &window.TenantID, &window.Name, &window.Description, &window.StartsAt, &window.EndsAt,
+		&window.Recurrence, &window.DurationMinutes, &window.Timezone,
+		pq.Array(&window.RouterIDs), pq.Array(&window.POPIDs), pq.Array(&window.RegionIDs), pq.Array(&window.RoleCodes),
+		&window.PausePolling, &window.Enabled, &window.CreatedBy, &window.CreatedAt, &window.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// nonNilUUIDs returns ids, or an empty slice for nil so that it is stored
+// as an empty array rather than NULL
+func nonNilUUIDs(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
+
+// nonNilStrings returns values, or an empty slice for nil
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
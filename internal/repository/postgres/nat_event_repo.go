@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// NATEventRepo implements repository.NATEventRepository
+type NATEventRepo struct {
+	db *sql.DB
+}
+
+// NewNATEventRepo creates a new NAT event repository
+func NewNATEventRepo(db *sql.DB) repository.NATEventRepository {
+	return &NATEventRepo{db: db}
+}
+
+const natEventInsertColumns = `tenant_id, router_id, event_time, nat_event, protocol,
+	src_address, src_port, dst_address, dst_port, post_nat_src_address, post_nat_src_port,
+	post_nat_dst_address, post_nat_dst_port, received_at`
+
+// CreateBatch stores events with a single multi-row insert
+func (r *NATEventRepo) CreateBatch(ctx context.Context, events []*models.NATEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	const columnCount = 14
+	placeholders := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*columnCount)
+	for i, e := range events {
+		base := i * columnCount
+		params := make([]string, columnCount)
+		for j := range params {
+			params[j] = fmt.Sprintf("$%d", base+j+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(params, ", ")+")")
+		args = append(args,
+			e.TenantID, e.RouterID, e.EventTime, e.NATEvent, e.Protocol,
+			e.SrcAddress, e.SrcPort, e.DstAddress, e.DstPort, e.PostNATSrcAddress, e.PostNATSrcPort,
+			e.PostNATDstAddress, e.PostNATDstPort, e.ReceivedAt,
+		)
+	}
+
+	query := `INSERT INTO nat_events (` + natEventInsertColumns + `) VALUES ` + strings.Join(placeholders, ", ")
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Search retrieves events matching the filter, newest first
+func (r *NATEventRepo) Search(ctx context.Context, tenantID uuid.UUID, filter repository.NATEventFilter, opts repository.ListOptions) ([]*models.NATEvent, int64, error) {
+	conditions := []string{"n.tenant_id = $1"}
+	args := []interface{}{tenantID}
+
+	if filter.RouterID != nil {
+		args = append(args, *filter.RouterID)
+		conditions = append(conditions, fmt.Sprintf("n.router_id = $%d", len(args)))
+	}
+	if filter.Address != "" {
+		args = append(args, filter.Address)
+		conditions = append(conditions, fmt.Sprintf("n.src_address = $%d::inet", len(args)))
+	}
+	if filter.PostNATAddress != "" {
+		args = append(args, filter.PostNATAddress)
+		conditions = append(conditions, fmt.Sprintf("n.post_nat_src_address = $%d::inet", len(args)))
+	}
+	if filter.PostNATPort != nil {
+		args = append(args, *filter.PostNATPort)
+		conditions = append(conditions, fmt.Sprintf("n.post_nat_src_port = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("n.event_time >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("n.event_time < $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM nat_events n WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	args = append(args, opts.PageSize, offset)
+	query := fmt.Sprintf(`
+		SELECT n.id, n.tenant_id, n.router_id, n.event_time, n.nat_event, n.protocol,
+			host(n.src_address), n.src_port, host(n.dst_address), n.dst_port,
+			host(n.post_nat_src_address), n.post_nat_src_port,
+			host(n.post_nat_dst_address), n.post_nat_dst_port, n.received_at, r.name
+		FROM nat_events n
+		JOIN routers r ON n.router_id = r.id
+		WHERE %s
+		ORDER BY n.event_time DESC, n.id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := make([]*models.NATEvent, 0)
+	for rows.Next() {
+		e := &models.NATEvent{}
+		err := rows.Scan(
+			&e.ID, &e.TenantID, &e.RouterID, &e.EventTime, &e.NATEvent, &e.Protocol,
+			&e.SrcAddress, &e.SrcPort, &e.DstAddress, &e.DstPort,
+			&e.PostNATSrcAddress, &e.PostNATSrcPort,
+			&e.PostNATDstAddress, &e.PostNATDstPort, &e.ReceivedAt, &e.RouterName,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+
+	return events, total, rows.Err()
+}
+
+// DeleteBefore removes up to limit events that occurred before cutoff
+func (r *NATEventRepo) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM nat_events
+		WHERE id IN (
+			SELECT id FROM nat_events WHERE event_time < $1 LIMIT $2
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
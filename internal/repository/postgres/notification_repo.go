@@ -0,0 +1,633 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const notificationChannelColumns = `
+	nc.id, nc.tenant_id, nc.name, nc.channel_type, nc.config, nc.secret,
+	nc.title_template, nc.body_template, nc.enabled, nc.created_by, nc.created_at, nc.updated_at
+`
+
+const notificationRouteColumns = `
+	nr.id, nr.tenant_id, nr.channel_id, nr.name, nr.severities, nr.role_codes, nr.pop_ids,
+	nr.notify_resolved, nr.enabled, nr.created_at, nr.updated_at
+`
+
+const notificationDeliveryColumns = `
+	d.id, d.tenant_id, d.channel_id, d.alert_id, d.event, d.status, d.attempts,
+	d.next_attempt_at, d.last_error, d.sent_at, d.tier, d.recipient_id, d.created_at, d.updated_at
+`
+
+// alertRouterJoin resolves the router an alert targets, directly or through
+// one of its interfaces, as r
+const alertRouterJoin = `
+	LEFT JOIN interfaces i ON a.target_type = 'interface' AND i.id = a.target_id
+	LEFT JOIN routers r ON r.id = CASE WHEN a.target_type = 'router' THEN a.target_id ELSE i.router_id END
+`
+
+// alertMatches is the condition that an alert a, with its router r, matches
+// the severity, POP and role filters of the route or policy aliased f
+func alertMatches(f string) string {
+	return strings.NewReplacer("f.", f+".").Replace(`
+		(cardinality(f.severities) = 0 OR a.severity = ANY(f.severities))
+		AND (cardinality(f.pop_ids) = 0 OR r.pop_id = ANY(f.pop_ids))
+		AND (cardinality(f.role_codes) = 0 OR EXISTS (
+		        SELECT 1
+		        FROM router_role_assignments rra
+		        JOIN router_roles rr ON rr.id = rra.role_id
+		        WHERE rra.router_id = r.id AND rr.code::text = ANY(f.role_codes)))
+	`)
+}
+
+// NotificationRepo implements repository.NotificationRepository
+type NotificationRepo struct {
+	db *sql.DB
+}
+
+// NewNotificationRepo creates a new notification repository
+func NewNotificationRepo(db *sql.DB) repository.NotificationRepository {
+	return &NotificationRepo{db: db}
+}
+
+// CreateChannel creates a new notification channel
+func (r *NotificationRepo) CreateChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	query := `
+		INSERT INTO notification_channels (id, tenant_id, name, channel_type, config, secret,
+			title_template, body_template, enabled, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	now := time.Now()
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+
+	if channel.ID == uuid.Nil {
+		channel.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		channel.ID, channel.TenantID, channel.Name, channel.ChannelType, channel.Config, channel.Secret,
+		channel.TitleTemplate, channel.BodyTemplate, channel.Enabled, channel.CreatedBy,
+		channel.CreatedAt, channel.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("notification channel already exists")
+	}
+
+	return err
+}
+
+// GetChannel retrieves a notification channel by ID with tenant isolation
+func (r *NotificationRepo) GetChannel(ctx context.Context, tenantID, channelID uuid.UUID) (*models.NotificationChannel, error) {
+	query := `
+		SELECT ` + notificationChannelColumns + `
+		FROM notification_channels nc
+		WHERE nc.id = $1 AND nc.tenant_id = $2
+	`
+
+	channel, err := scanNotificationChannel(r.db.QueryRowContext(ctx, query, channelID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("notification channel not found")
+	}
+
+	return channel, err
+}
+
+// ListChannels retrieves a paginated list of notification channels by name
+func (r *NotificationRepo) ListChannels(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]*models.NotificationChannel, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM notification_channels WHERE tenant_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + notificationChannelColumns + `
+		FROM notification_channels nc
+		WHERE nc.tenant_id = $1
+		ORDER BY nc.name
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, opts.PageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	channels := make([]*models.NotificationChannel, 0)
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, total, rows.Err()
+}
+
+// UpdateChannel updates a notification channel
+func (r *NotificationRepo) UpdateChannel(ctx context.Context, channel *models.NotificationChannel) error {
+	query := `
+		UPDATE notification_channels
+		SET name = $1, channel_type = $2, config = $3, secret = $4,
+			title_template = $5, body_template = $6, enabled = $7, updated_at = $8
+		WHERE id = $9 AND tenant_id = $10
+	`
+
+	channel.UpdatedAt = time.Now()
+
+	res, err := r.db.ExecContext(ctx, query,
+		channel.Name, channel.ChannelType, channel.Config, channel.Secret,
+		channel.TitleTemplate, channel.BodyTemplate, channel.Enabled, channel.UpdatedAt,
+		channel.ID, channel.TenantID,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("notification channel already exists")
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification channel not found")
+	}
+	return nil
+}
+
+// DeleteChannel deletes a notification channel with its routes and
+// delivery log
+func (r *NotificationRepo) DeleteChannel(ctx context.Context, tenantID, channelID uuid.UUID) error {
+	query := `DELETE FROM notification_channels WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, channelID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification channel not found")
+	}
+	return nil
+}
+
+// CreateRoute creates a new notification route
+func (r *NotificationRepo) CreateRoute(ctx context.Context, route *models.NotificationRoute) error {
+	query := `
+		INSERT INTO notification_routes (id, tenant_id, channel_id, name, severities, role_codes, pop_ids,
+			notify_resolved, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	now := time.Now()
+	route.CreatedAt = now
+	route.UpdatedAt = now
+
+	if route.ID == uuid.Nil {
+		route.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		route.ID, route.TenantID, route.ChannelID, route.Name,
+		pq.Array(nonNilStrings(route.Severities)), pq.Array(nonNilStrings(route.RoleCodes)),
+		pq.Array(nonNilUUIDs(route.POPIDs)),
+		route.NotifyResolved, route.Enabled, route.CreatedAt, route.UpdatedAt,
+	)
+	return err
+}
+
+// GetRoute retrieves a notification route by ID with tenant isolation
+func (r *NotificationRepo) GetRoute(ctx context.Context, tenantID, routeID uuid.UUID) (*models.NotificationRoute, error) {
+	query := `
+		SELECT ` + notificationRouteColumns + `
+		FROM notification_routes nr
+		WHERE nr.id = $1 AND nr.tenant_id = $2
+	`
+
+	route, err := scanNotificationRoute(r.db.QueryRowContext(ctx, query, routeID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("notification route not found")
+	}
+
+	return route, err
+}
+
+// ListRoutes retrieves a paginated list of notification routes by name
+func (r *NotificationRepo) ListRoutes(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]*models.NotificationRoute, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM notification_routes WHERE tenant_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + notificationRouteColumns + `
+		FROM notification_routes nr
+		WHERE nr.tenant_id = $1
+		ORDER BY nr.name
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, opts.PageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	routes := make([]*models.NotificationRoute, 0)
+	for rows.Next() {
+		route, err := scanNotificationRoute(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		routes = append(routes, route)
+	}
+	return routes, total, rows.Err()
+}
+
+// UpdateRoute updates a notification route
+func (r *NotificationRepo) UpdateRoute(ctx context.Context, route *models.NotificationRoute) error {
+	query := `
+		UPDATE notification_routes
+		SET channel_id = $1, name = $2, severities = $3, role_codes = $4, pop_ids = $5,
+			notify_resolved = $6, enabled = $7, updated_at = $8
+		WHERE id = $9 AND tenant_id = $10
+	`
+
+	route.UpdatedAt = time.Now()
+
+	res, err := r.db.ExecContext(ctx, query,
+		route.ChannelID, route.Name,
+		pq.Array(nonNilStrings(route.Severities)), pq.Array(nonNilStrings(route.RoleCodes)),
+		pq.Array(nonNilUUIDs(route.POPIDs)),
+		route.NotifyResolved, route.Enabled, route.UpdatedAt,
+		route.ID, route.TenantID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification route not found")
+	}
+	return nil
+}
+
+// DeleteRoute deletes a notification route; queued deliveries are still sent
+func (r *NotificationRepo) DeleteRoute(ctx context.Context, tenantID, routeID uuid.UUID) error {
+	query := `DELETE FROM notification_routes WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, routeID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("notification route not found")
+	}
+	return nil
+}
+
+// ListDeliveries retrieves a paginated delivery log, latest first
+func (r *NotificationRepo) ListDeliveries(ctx context.Context, tenantID uuid.UUID, filter repository.NotificationDeliveryFilter, opts repository.ListOptions) ([]*models.NotificationDelivery, int64, error) {
+	conditions := []string{"d.tenant_id = $1"}
+	args := []interface{}{tenantID}
+
+	if filter.ChannelID != nil {
+		args = append(args, *filter.ChannelID)
+		conditions = append(conditions, fmt.Sprintf("d.channel_id = $%d", len(args)))
+	}
+	if filter.AlertID != nil {
+		args = append(args, *filter.AlertID)
+		conditions = append(conditions, fmt.Sprintf("d.alert_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("d.status = $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM notification_deliveries d WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	args = append(args, opts.PageSize, offset)
+	query := fmt.Sprintf(`
+		SELECT `+notificationDeliveryColumns+`
+		FROM notification_deliveries d
+		WHERE %s
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.NotificationDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, total, rows.Err()
+}
+
+// CreateDelivery records a delivery
+func (r *NotificationRepo) CreateDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return insertDelivery(ctx, r.db, delivery)
+}
+
+// QueueDeliveries routes the alerts that fired or resolved since they were
+// last routed, and starts the escalations of those that fired. Alerts are
+// locked while routed so that dispatchers running side by side route each
+// alert once; suppressed alerts are marked routed without queueing
+// anything.
+func (r *NotificationRepo) QueueDeliveries(ctx context.Context, limit int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	firing, err := lockAlertIDs(ctx, tx, `
+		SELECT id FROM alerts
+		WHERE notified_at IS NULL
+		ORDER BY triggered_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	if len(firing) > 0 {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_deliveries (tenant_id, channel_id, alert_id, event, status, next_attempt_at)
+			SELECT DISTINCT a.tenant_id, nr.channel_id, a.id, 'firing', 'pending', CURRENT_TIMESTAMP
+			FROM alerts a
+			`+alertRouterJoin+`
+			JOIN notification_routes nr ON nr.tenant_id = a.tenant_id AND nr.enabled = true
+			JOIN notification_channels nc ON nc.id = nr.channel_id AND nc.enabled = true
+			WHERE a.id = ANY($1) AND a.suppressed = false AND `+alertMatches("nr")+`
+			ON CONFLICT (channel_id, alert_id, event) WHERE event IN ('firing', 'resolved') DO NOTHING
+		`, pq.Array(firing))
+		if err != nil {
+			return 0, err
+		}
+		n, _ := res.RowsAffected()
+		queued += int(n)
+
+		// Escalations page their first tier on the dispatcher's next pass
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO alert_escalations (alert_id, policy_id, tenant_id, next_escalation_at)
+			SELECT a.id, ep.id, a.tenant_id, CURRENT_TIMESTAMP
+			FROM alerts a
+			`+alertRouterJoin+`
+			JOIN escalation_policies ep ON ep.tenant_id = a.tenant_id AND ep.enabled = true
+			WHERE a.id = ANY($1) AND a.suppressed = false AND a.acknowledged_at IS NULL
+			  AND a.status <> 'resolved' AND `+alertMatches("ep")+`
+			ON CONFLICT (alert_id, policy_id) DO NOTHING
+		`, pq.Array(firing))
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE alerts SET notified_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`, pq.Array(firing))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	resolved, err := lockAlertIDs(ctx, tx, `
+		SELECT id FROM alerts
+		WHERE status = 'resolved' AND notified_at IS NOT NULL AND resolved_notified_at IS NULL
+		ORDER BY resolved_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resolved) > 0 {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_deliveries (tenant_id, channel_id, alert_id, event, status, next_attempt_at)
+			SELECT d.tenant_id, d.channel_id, d.alert_id, 'resolved', 'pending', CURRENT_TIMESTAMP
+			FROM notification_deliveries d
+			JOIN notification_channels nc ON nc.id = d.channel_id AND nc.enabled = true
+			WHERE d.alert_id = ANY($1) AND d.event = 'firing'
+			  AND EXISTS (
+			          SELECT 1 FROM notification_routes nr
+			          WHERE nr.channel_id = d.channel_id AND nr.enabled = true AND nr.notify_resolved = true)
+			ON CONFLICT (channel_id, alert_id, event) WHERE event IN ('firing', 'resolved') DO NOTHING
+		`, pq.Array(resolved))
+		if err != nil {
+			return 0, err
+		}
+		n, _ := res.RowsAffected()
+		queued += int(n)
+
+		_, err = tx.ExecContext(ctx, `UPDATE alerts SET resolved_notified_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`, pq.Array(resolved))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return queued, nil
+}
+
+// ClaimDeliveries claims the due pending deliveries, oldest first
+func (r *NotificationRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*repository.NotificationJob, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE notification_deliveries
+			SET next_attempt_at = CURRENT_TIMESTAMP + $2::double precision * INTERVAL '1 second'
+			WHERE id IN (
+				SELECT id FROM notification_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + notificationDeliveryColumns + `, ` + notificationChannelColumns + `,
+			a.id, a.tenant_id, a.rule_id, a.name, a.description, a.severity, a.status,
+			a.target_type, a.target_id, a.triggered_at, a.acknowledged_at, a.resolved_at,
+			a.metadata, a.suppressed, r.id, COALESCE(r.name, ''),
+			u.id, u.email, u.first_name, u.last_name
+		FROM claimed d
+		JOIN notification_channels nc ON nc.id = d.channel_id
+		JOIN alerts a ON a.id = d.alert_id
+		` + alertRouterJoin + `
+		LEFT JOIN users u ON u.id = d.recipient_id
+		ORDER BY d.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]*repository.NotificationJob, 0)
+	for rows.Next() {
+		d := &models.NotificationDelivery{}
+		nc := &models.NotificationChannel{}
+		a := &models.Alert{}
+		job := &repository.NotificationJob{Delivery: d, Channel: nc, Alert: a}
+		var userID *uuid.UUID
+		var email *string
+		u := &models.User{}
+		err := rows.Scan(
+			&d.ID, &d.TenantID, &d.ChannelID, &d.AlertID, &d.Event, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.SentAt, &d.Tier, &d.RecipientID, &d.CreatedAt, &d.UpdatedAt,
+			&nc.ID, &nc.TenantID, &nc.Name, &nc.ChannelType, &nc.Config, &nc.Secret,
+			&nc.TitleTemplate, &nc.BodyTemplate, &nc.Enabled, &nc.CreatedBy, &nc.CreatedAt, &nc.UpdatedAt,
+			&a.ID, &a.TenantID, &a.RuleID, &a.Name, &a.Description, &a.Severity, &a.Status,
+			&a.TargetType, &a.TargetID, &a.TriggeredAt, &a.AcknowledgedAt, &a.ResolvedAt,
+			&a.Metadata, &a.Suppressed, &job.RouterID, &job.RouterName,
+			&userID, &email, &u.FirstName, &u.LastName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if userID != nil {
+			u.ID, u.TenantID, u.Email = *userID, d.TenantID, *email
+			job.Recipient = u
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// UpdateDelivery stores the outcome of a send attempt
+func (r *NotificationRepo) UpdateDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	query := `
+		UPDATE notification_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, sent_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.SentAt,
+		delivery.ID,
+	)
+	return err
+}
+
+// DeleteDeliveriesBefore removes up to limit sent or failed deliveries
+// created before cutoff, so that retention runs do not hold long locks
+func (r *NotificationRepo) DeleteDeliveriesBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM notification_deliveries
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE created_at < $1 AND status <> 'pending'
+			LIMIT $2
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// rowQueryer is a *sql.DB or *sql.Tx
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertDelivery records a delivery within db or a transaction
+func insertDelivery(ctx context.Context, db rowQueryer, delivery *models.NotificationDelivery) error {
+	query := `
+		INSERT INTO notification_deliveries (tenant_id, channel_id, alert_id, event, status, attempts,
+			next_attempt_at, last_error, sent_at, tier, recipient_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+
+	return db.QueryRowContext(ctx, query,
+		delivery.TenantID, delivery.ChannelID, delivery.AlertID, delivery.Event, delivery.Status,
+		delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.SentAt,
+		delivery.Tier, delivery.RecipientID,
+	).Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+}
+
+// lockAlertIDs runs a locking query for alert IDs within tx
+func lockAlertIDs(ctx context.Context, tx *sql.Tx, query string, limit int) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanNotificationChannel(row interface{ Scan(...interface{}) error }) (*models.NotificationChannel, error) {
+	channel := &models.NotificationChannel{}
+	err := row.Scan(
+		&channel.ID, &channel.TenantID, &channel.Name, &channel.ChannelType, &channel.Config, &channel.Secret,
+		&channel.TitleTemplate, &channel.BodyTemplate, &channel.Enabled, &channel.CreatedBy,
+		&channel.CreatedAt, &channel.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+func scanNotificationRoute(row interface{ Scan(...interface{}) error }) (*models.NotificationRoute, error) {
+	route := &models.NotificationRoute{}
+	err := row.Scan(
+		&route.ID, &route.TenantID, &route.ChannelID, &route.Name,
+		pq.Array(&route.Severities), pq.Array(&route.RoleCodes), pq.Array(&route.POPIDs),
+		&route.NotifyResolved, &route.Enabled, &route.CreatedAt, &route.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+func scanNotificationDelivery(row interface{ Scan(...interface{}) error }) (*models.NotificationDelivery, error) {
+	delivery := &models.NotificationDelivery{}
+	err := row.Scan(
+		&delivery.ID, &delivery.TenantID, &delivery.ChannelID, &delivery.AlertID, &delivery.Event,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError,
+		&delivery.SentAt, &delivery.Tier, &delivery.RecipientID, &delivery.CreatedAt, &delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
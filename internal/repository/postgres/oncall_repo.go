@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const oncallScheduleColumns = `
+	id, tenant_id, name, description, timezone, participants, rotation_start, rotation_days,
+	coverage, created_by, created_at, updated_at
+`
+
+const oncallOverrideColumns = `
+	id, tenant_id, schedule_id, user_id, starts_at, ends_at, note, created_by, created_at
+`
+
+// OnCallRepo implements repository.OnCallRepository
+type OnCallRepo struct {
+	db *sql.DB
+}
+
+// NewOnCallRepo creates a new on-call schedule repository
+func NewOnCallRepo(db *sql.DB) repository.OnCallRepository {
+	return &OnCallRepo{db: db}
+}
+
+// CreateSchedule creates a new on-call schedule
+func (r *OnCallRepo) CreateSchedule(ctx context.Context, schedule *models.OnCallSchedule) error {
+	query := `
+		INSERT INTO oncall_schedules (id, tenant_id, name, description, timezone, participants,
+			rotation_start, rotation_days, coverage, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	if schedule.ID == uuid.Nil {
+		schedule.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		schedule.ID, schedule.TenantID, schedule.Name, schedule.Description, schedule.Timezone,
+		pq.Array(nonNilUUIDs(schedule.Participants)), schedule.RotationStart, schedule.RotationDays,
+		schedule.Coverage, schedule.CreatedBy, schedule.CreatedAt, schedule.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("on-call schedule already exists")
+	}
+
+	return err
+}
+
+// GetSchedule retrieves an on-call schedule by ID with tenant isolation
+func (r *OnCallRepo) GetSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) (*models.OnCallSchedule, error) {
+	query := `
+		SELECT ` + oncallScheduleColumns + `
+		FROM oncall_schedules
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	schedule, err := scanOnCallSchedule(r.db.QueryRowContext(ctx, query, scheduleID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("on-call schedule not found")
+	}
+
+	return schedule, err
+}
+
+// ListSchedules retrieves a paginated list of on-call schedules by name
+func (r *OnCallRepo) ListSchedules(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]*models.OnCallSchedule, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM oncall_schedules WHERE tenant_id = $1`
+	if err := r.db.QueryRowContext(ctx, countQuery, tenantID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	query := `
+		SELECT ` + oncallScheduleColumns + `
+		FROM oncall_schedules
+		WHERE tenant_id = $1
+		ORDER BY name
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, opts.PageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	schedules := make([]*models.OnCallSchedule, 0)
+	for rows.Next() {
+		schedule, err := scanOnCallSchedule(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, total, rows.Err()
+}
+
+// UpdateSchedule updates an on-call schedule
+func (r *OnCallRepo) UpdateSchedule(ctx context.Context, schedule *models.OnCallSchedule) error {
+	query := `
+		UPDATE oncall_schedules
+		SET name = $1, description = $2, timezone = $3, participants = $4, rotation_start = $5,
+			rotation_days = $6, coverage = $7, updated_at = $8
+		WHERE id = $9 AND tenant_id = $10
+	`
+
+	schedule.UpdatedAt = time.Now()
+
+	res, err := r.db.ExecContext(ctx, query,
+		schedule.Name, schedule.Description, schedule.Timezone,
+		pq.Array(nonNilUUIDs(schedule.Participants)), schedule.RotationStart,
+		schedule.RotationDays, schedule.Coverage, schedule.UpdatedAt,
+		schedule.ID, schedule.TenantID,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("on-call schedule already exists")
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("on-call schedule not found")
+	}
+	return nil
+}
+
+// DeleteSchedule deletes an on-call schedule with its overrides
+func (r *OnCallRepo) DeleteSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) error {
+	query := `DELETE FROM oncall_schedules WHERE id = $1 AND tenant_id = $2`
+	res, err := r.db.ExecContext(ctx, query, scheduleID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("on-call schedule not found")
+	}
+	return nil
+}
+
+// CreateOverride creates a new override of an on-call schedule
+func (r *OnCallRepo) CreateOverride(ctx context.Context, override *models.OnCallOverride) error {
+	query := `
+		INSERT INTO oncall_overrides (id, tenant_id, schedule_id, user_id, starts_at, ends_at, note,
+			created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	override.CreatedAt = time.Now()
+	if override.ID == uuid.Nil {
+		override.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		override.ID, override.TenantID, override.ScheduleID, override.UserID,
+		override.StartsAt, override.EndsAt, override.Note, override.CreatedBy, override.CreatedAt,
+	)
+	return err
+}
+
+// ListOverrides retrieves the overrides of a schedule overlapping
+// [from, to), by start
+func (r *OnCallRepo) ListOverrides(ctx context.Context, tenantID, scheduleID uuid.UUID, from, to time.Time) ([]*models.OnCallOverride, error) {
+	query := `
+		SELECT ` + oncallOverrideColumns + `
+		FROM oncall_overrides
+		WHERE schedule_id = $1 AND tenant_id = $2 AND ends_at > $3 AND starts_at < $4
+		ORDER BY starts_at, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scheduleID, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make([]*models.OnCallOverride, 0)
+	for rows.Next() {
+		o := &models.OnCallOverride{}
+		err := rows.Scan(
+			&o.ID, &o.TenantID, &o.ScheduleID, &o.UserID, &o.StartsAt, &o.EndsAt, &o.Note,
+			&o.CreatedBy, &o.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// DeleteOverride deletes an override of an on-call schedule
+func (r *OnCallRepo) DeleteOverride(ctx context.Context, tenantID, scheduleID, overrideID uuid.UUID) error {
+	query := `DELETE FROM oncall_overrides WHERE id = $1 AND schedule_id = $2 AND tenant_id = $3`
+	res, err := r.db.ExecContext(ctx, query, overrideID, scheduleID, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("on-call override not found")
+	}
+	return nil
+}
+
+func scanOnCallSchedule(row interface{ Scan(...interface{}) error }) (*models.OnCallSchedule, error) {
+	schedule := &models.OnCallSchedule{}
+	err := row.Scan(
+		&schedule.ID, &schedule.TenantID, &schedule.Name, &schedule.Description, &schedule.Timezone,
+		pq.Array(&schedule.Participants), &schedule.RotationStart, &schedule.RotationDays,
+		&schedule.Coverage, &schedule.CreatedBy, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
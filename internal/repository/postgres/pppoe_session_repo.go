@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// PPPoESessionRepo implements repository.PPPoESessionRepository
+type PPPoESessionRepo struct {
+	db *sql.DB
+}
+
+// NewPPPoESessionRepo creates a new PPPoE session repository
+func NewPPPoESessionRepo(db *sql.DB) repository.PPPoESessionRepository {
+	return &PPPoESessionRepo{db: db}
+}
+
+const pppoeSessionInsert = `
+	INSERT INTO pppoe_sessions (tenant_id, router_id, session_id, username, calling_station_id,
+		framed_ip_address, nas_ip_address, nas_port, service_type, session_time_seconds,
+		bytes_in, bytes_out, packets_in, packets_out, status, connect_time, disconnect_time, disconnect_cause)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	ON CONFLICT (router_id, session_id) WHERE session_id IS NOT NULL DO UPDATE SET
+		username = EXCLUDED.username,
+		calling_station_id = COALESCE(EXCLUDED.calling_station_id, pppoe_sessions.calling_station_id),
+		framed_ip_address = COALESCE(EXCLUDED.framed_ip_address, pppoe_sessions.framed_ip_address),
+		nas_ip_address = COALESCE(EXCLUDED.nas_ip_address, pppoe_sessions.nas_ip_address),
+		nas_port = COALESCE(EXCLUDED.nas_port, pppoe_sessions.nas_port),
+		service_type = COALESCE(EXCLUDED.service_type, pppoe_sessions.service_type),
+		updated_at = CURRENT_TIMESTAMP,
+`
+
+// Start records a session start. A stored session with the same ID that
+// ended before this start is an earlier session and is replaced; one that is
+// active or ended later (the start arrived late) keeps its counters and end.
+func (r *PPPoESessionRepo) Start(ctx context.Context, s *models.PPPoESession) error {
+	query := pppoeSessionInsert + `
+		session_time_seconds = CASE WHEN pppoe_sessions.status = 'disconnected'
+			THEN NULL ELSE pppoe_sessions.session_time_seconds END,
+		bytes_in = CASE WHEN pppoe_sessions.status = 'disconnected' THEN 0 ELSE pppoe_sessions.bytes_in END,
+		bytes_out = CASE WHEN pppoe_sessions.status = 'disconnected' THEN 0 ELSE pppoe_sessions.bytes_out END,
+		packets_in = CASE WHEN pppoe_sessions.status = 'disconnected' THEN 0 ELSE pppoe_sessions.packets_in END,
+		packets_out = CASE WHEN pppoe_sessions.status = 'disconnected' THEN 0 ELSE pppoe_sessions.packets_out END,
+		status = EXCLUDED.status,
+		connect_time = EXCLUDED.connect_time,
+		disconnect_time = NULL,
+		disconnect_cause = NULL
+		WHERE pppoe_sessions.status <> 'disconnected'
+			OR pppoe_sessions.disconnect_time IS NULL
+			OR pppoe_sessions.disconnect_time <= EXCLUDED.connect_time
+	`
+	return r.upsert(ctx, query, s)
+}
+
+// Update records cumulative counters of an active session. Updates for a
+// session already stopped arrived late and are ignored.
+func (r *PPPoESessionRepo) Update(ctx context.Context, s *models.PPPoESession) error {
+	query := pppoeSessionInsert + `
+		session_time_seconds = GREATEST(pppoe_sessions.session_time_seconds, EXCLUDED.session_time_seconds),
+		bytes_in = GREATEST(pppoe_sessions.bytes_in, EXCLUDED.bytes_in),
+		bytes_out = GREATEST(pppoe_sessions.bytes_out, EXCLUDED.bytes_out),
+		packets_in = GREATEST(pppoe_sessions.packets_in, EXCLUDED.packets_in),
+		packets_out = GREATEST(pppoe_sessions.packets_out, EXCLUDED.packets_out),
+		connect_time = COALESCE(pppoe_sessions.connect_time, EXCLUDED.connect_time)
+		WHERE pppoe_sessions.status <> 'disconnected'
+	`
+	return r.upsert(ctx, query, s)
+}
+
+// Stop records a session's end and final counters. A stop older than the
+// stored session's start belongs to an earlier session and is ignored.
+func (r *PPPoESessionRepo) Stop(ctx context.Context, s *models.PPPoESession) error {
+	query := pppoeSessionInsert + `
+		session_time_seconds = GREATEST(pppoe_sessions.session_time_seconds, EXCLUDED.session_time_seconds),
+		bytes_in = GREATEST(pppoe_sessions.bytes_in, EXCLUDED.bytes_in),
+		bytes_out = GREATEST(pppoe_sessions.bytes_out, EXCLUDED.bytes_out),
+		packets_in = GREATEST(pppoe_sessions.packets_in, EXCLUDED.packets_in),
+		packets_out = GREATEST(pppoe_sessions.packets_out, EXCLUDED.packets_out),
+		status = EXCLUDED.status,
+		connect_time = COALESCE(pppoe_sessions.connect_time, EXCLUDED.connect_time),
+		disconnect_time = EXCLUDED.disconnect_time,
+		disconnect_cause = EXCLUDED.disconnect_cause
+		WHERE pppoe_sessions.connect_time IS NULL
+			OR pppoe_sessions.connect_time <= EXCLUDED.disconnect_time
+	`
+	return r.upsert(ctx, query, s)
+}
+
+func (r *PPPoESessionRepo) upsert(ctx context.Context, query string, s *models.PPPoESession) error {
+	_, err := r.db.ExecContext(ctx, query,
+		s.TenantID, s.RouterID, s.SessionID, s.Username, s.CallingStationID,
+		ipString(s.FramedIPAddress), ipString(s.NASIPAddress), s.NASPort, s.ServiceType, s.SessionTimeSeconds,
+		s.BytesIn, s.BytesOut, s.PacketsIn, s.PacketsOut, s.Status, s.ConnectTime, s.DisconnectTime, s.DisconnectCause,
+	)
+	return err
+}
+
+// DisconnectRouter ends all of a router's sessions that were active at at
+func (r *PPPoESessionRepo) DisconnectRouter(ctx context.Context, routerID uuid.UUID, at time.Time, cause string) (int64, error) {
+	query := `
+		UPDATE pppoe_sessions
+		SET status = $3, disconnect_time = $2, disconnect_cause = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE router_id = $1 AND status <> $3
+			AND (connect_time IS NULL OR connect_time <= $2)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, routerID, at, models.SessionStatusDisconnected, cause)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ipString passes an address to an INET column, or NULL
+func ipString(ip *net.IP) interface{} {
+	if ip == nil || *ip == nil {
+		return nil
+	}
+	return ip.String()
+}
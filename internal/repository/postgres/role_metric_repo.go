@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// RoleMetricRepo implements repository.RoleMetricRepository
+type RoleMetricRepo struct {
+	db *sql.DB
+}
+
+// NewRoleMetricRepo creates a new role metric repository
+func NewRoleMetricRepo(db *sql.DB) repository.RoleMetricRepository {
+	return &RoleMetricRepo{db: db}
+}
+
+// roleMetricAggregations maps RoleMetricRepository.Series aggregations to
+// SQL over the value v of the row at m.timestamp
+var roleMetricAggregations = map[string]string{
+	repository.RoleMetricAggAvg:  `AVG(v.value)`,
+	repository.RoleMetricAggMin:  `MIN(v.value)`,
+	repository.RoleMetricAggMax:  `MAX(v.value)`,
+	repository.RoleMetricAggSum:  `SUM(v.value)`,
+	repository.RoleMetricAggLast: `(array_agg(v.value ORDER BY m.timestamp DESC))[1]`,
+}
+
+// Series aggregates the numeric metrics of a role per router and interval.
+// Intervals are aligned to the Unix epoch.
+func (r *RoleMetricRepo) Series(ctx context.Context, tenantID uuid.UUID, filter repository.RoleMetricFilter, interval time.Duration, aggregation string) ([]*models.RoleMetricPoint, error) {
+	aggregate, ok := roleMetricAggregations[aggregation]
+	if !ok {
+		return nil, fmt.Errorf("unsupported role metric aggregation %q", aggregation)
+	}
+	seconds := int64(interval / time.Second)
+	if seconds <= 0 {
+		return nil, fmt.Errorf("role metric interval must be at least one second")
+	}
+
+	conditions := []string{"m.tenant_id = $1", "m.role_code = $2", "m.timestamp >= $3", "m.timestamp < $4"}
+	args := []interface{}{tenantID, filter.RoleCode, filter.From.UTC(), filter.To.UTC()}
+
+	if filter.RouterID != nil {
+		args = append(args, *filter.RouterID)
+		conditions = append(conditions, fmt.Sprintf("m.router_id = $%d", len(args)))
+	}
+	if len(filter.Metrics) > 0 {
+		args = append(args, pq.Array(filter.Metrics))
+		conditions = append(conditions, fmt.Sprintf("kv.key = ANY($%d)", len(args)))
+	}
+	args = append(args, seconds)
+
+	query := fmt.Sprintf(`
+		SELECT m.router_id, rt.name, kv.key,
+			to_timestamp(floor(extract(epoch FROM m.timestamp) / $%[1]d) * $%[1]d) AS bucket,
+			%[2]s
+		FROM role_specific_metrics m
+		JOIN routers rt ON rt.id = m.router_id
+		CROSS JOIN LATERAL jsonb_each(m.metrics) kv
+		CROSS JOIN LATERAL (SELECT (kv.value #>> '{}')::double precision AS value) v
+		WHERE %[3]s AND jsonb_typeof(kv.value) = 'number'
+		GROUP BY m.router_id, rt.name, kv.key, bucket
+		ORDER BY rt.name, m.router_id, kv.key, bucket
+	`, len(args), aggregate, strings.Join(conditions, " AND "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]*models.RoleMetricPoint, 0)
+	for rows.Next() {
+		p := &models.RoleMetricPoint{}
+		if err := rows.Scan(&p.RouterID, &p.RouterName, &p.Metric, &p.Bucket, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
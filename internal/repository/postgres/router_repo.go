@@ -158,3 +158,32 @@ func (r *RouterRepo) Delete(ctx context.Context, tenantID, routerID uuid.UUID) e
 	_, err := r.db.ExecContext(ctx, query, routerID, tenantID)
 	return err
 }
+
+// GetRoleCodes retrieves the codes of the roles assigned to a router, primary role first
+func (r *RouterRepo) GetRoleCodes(ctx context.Context, tenantID, routerID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT rr.code
+		FROM router_role_assignments rra
+		JOIN router_roles rr ON rra.role_id = rr.id
+		JOIN routers r ON rra.router_id = r.id
+		WHERE rra.router_id = $1 AND r.tenant_id = $2
+		ORDER BY rra.is_primary DESC, rra.priority ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, routerID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := make([]string, 0)
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, rows.Err()
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// SubscriberUsageRepo implements repository.SubscriberUsageRepository
+type SubscriberUsageRepo struct {
+	db *sql.DB
+}
+
+// NewSubscriberUsageRepo creates a new subscriber usage repository
+func NewSubscriberUsageRepo(db *sql.DB) repository.SubscriberUsageRepository {
+	return &SubscriberUsageRepo{db: db}
+}
+
+// subscriberOrderExpressions maps SubscriberUsageRepository.Top orderings to SQL
+var subscriberOrderExpressions = map[string]string{
+	repository.SubscriberOrderTotal: `SUM(s.bytes_in + s.bytes_out)`,
+	repository.SubscriberOrderIn:    `SUM(s.bytes_in)`,
+	repository.SubscriberOrderOut:   `SUM(s.bytes_out)`,
+}
+
+// UpsertUsage adds per-minute usage to the stored totals
+func (r *SubscriberUsageRepo) UpsertUsage(ctx context.Context, usage []*models.SubscriberUsage) error {
+	const columnCount = 10
+	for start := 0; start < len(usage); start += flowUpsertBatch {
+		batch := usage[start:min(start+flowUpsertBatch, len(usage))]
+
+		placeholders := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*columnCount)
+		for i, u := range batch {
+			placeholders = append(placeholders, rowPlaceholders(i*columnCount, columnCount))
+			args = append(args,
+				u.TenantID, u.RouterID, u.Bucket, u.Subscriber, u.SubscriberType,
+				u.BytesIn, u.BytesOut, u.PacketsIn, u.PacketsOut, u.Flows,
+			)
+		}
+
+		query := `
+			INSERT INTO subscriber_usage (tenant_id, router_id, bucket, subscriber, subscriber_type,
+				bytes_in, bytes_out, packets_in, packets_out, flows)
+			VALUES ` + strings.Join(placeholders, ", ") + `
+			ON CONFLICT ON CONSTRAINT unique_subscriber_usage DO UPDATE SET
+				bytes_in = subscriber_usage.bytes_in + EXCLUDED.bytes_in,
+				bytes_out = subscriber_usage.bytes_out + EXCLUDED.bytes_out,
+				packets_in = subscriber_usage.packets_in + EXCLUDED.packets_in,
+				packets_out = subscriber_usage.packets_out + EXCLUDED.packets_out,
+				flows = subscriber_usage.flows + EXCLUDED.flows
+		`
+		if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertAppUsage adds hourly application usage to the stored totals
+func (r *SubscriberUsageRepo) UpsertAppUsage(ctx context.Context, usage []*models.SubscriberAppUsage) error {
+	const columnCount = 10
+	for start := 0; start < len(usage); start += flowUpsertBatch {
+		batch := usage[start:min(start+flowUpsertBatch, len(usage))]
+
+		placeholders := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*columnCount)
+		for i, u := range batch {
+			placeholders = append(placeholders, rowPlaceholders(i*columnCount, columnCount))
+			args = append(args,
+				u.TenantID, u.RouterID, u.Bucket, u.Subscriber, u.SubscriberType,
+				u.Protocol, u.Port, u.BytesIn, u.BytesOut, u.Flows,
+			)
+		}
+
+		query := `
+			INSERT INTO subscriber_app_usage (tenant_id, router_id, bucket, subscriber, subscriber_type,
+				protocol, port, bytes_in, bytes_out, flows)
+			VALUES ` + strings.Join(placeholders, ", ") + `
+			ON CONFLICT ON CONSTRAINT unique_subscriber_app_usage DO UPDATE SET
+				bytes_in = subscriber_app_usage.bytes_in + EXCLUDED.bytes_in,
+				bytes_out = subscriber_app_usage.bytes_out + EXCLUDED.bytes_out,
+				flows = subscriber_app_usage.flows + EXCLUDED.flows
+		`
+		if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Top returns the subscribers with the most traffic
+func (r *SubscriberUsageRepo) Top(ctx context.Context, tenantID uuid.UUID, filter repository.SubscriberUsageFilter, orderBy string, limit int) ([]*models.SubscriberTotal, error) {
+	order, ok := subscriberOrderExpressions[orderBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported subscriber ordering %q", orderBy)
+	}
+
+	where, args := subscriberUsageConditions(tenantID, filter, false)
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT s.subscriber, s.subscriber_type, SUM(s.bytes_in), SUM(s.bytes_out),
+			SUM(s.packets_in), SUM(s.packets_out), SUM(s.flows)
+		FROM subscriber_usage s
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY %s DESC
+		LIMIT $%d
+	`, where, order, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]*models.SubscriberTotal, 0)
+	for rows.Next() {
+		t := &models.SubscriberTotal{}
+		err := rows.Scan(&t.Subscriber, &t.SubscriberType, &t.BytesIn, &t.BytesOut,
+			&t.PacketsIn, &t.PacketsOut, &t.Flows)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+// History returns a subscriber's traffic per step over the filter's range
+func (r *SubscriberUsageRepo) History(ctx context.Context, tenantID uuid.UUID, filter repository.SubscriberUsageFilter, step string) ([]*models.SubscriberUsagePoint, error) {
+	switch step {
+	case repository.SubscriberStepMinute, repository.SubscriberStepHour, repository.SubscriberStepDay:
+	default:
+		return nil, fmt.Errorf("unsupported subscriber usage step %q", step)
+	}
+
+	where, args := subscriberUsageConditions(tenantID, filter, false)
+	args = append(args, step)
+
+	query := fmt.Sprintf(`
+		SELECT date_trunc($%d, s.bucket), SUM(s.bytes_in), SUM(s.bytes_out)
+		FROM subscriber_usage s
+		WHERE %s
+		GROUP BY 1
+		ORDER BY 1
+	`, len(args), where)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]*models.SubscriberUsagePoint, 0)
+	for rows.Next() {
+		p := &models.SubscriberUsagePoint{}
+		if err := rows.Scan(&p.Bucket, &p.BytesIn, &p.BytesOut); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// Applications returns the protocols and ports with the most subscriber
+// traffic. Application usage is hourly, so the range is widened to whole
+// hours.
+func (r *SubscriberUsageRepo) Applications(ctx context.Context, tenantID uuid.UUID, filter repository.SubscriberUsageFilter, limit int) ([]*models.ApplicationTotal, error) {
+	where, args := subscriberUsageConditions(tenantID, filter, true)
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT s.protocol, s.port, SUM(s.bytes_in), SUM(s.bytes_out), SUM(s.flows)
+		FROM subscriber_app_usage s
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY SUM(s.bytes_in + s.bytes_out) DESC
+		LIMIT $%d
+	`, where, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]*models.ApplicationTotal, 0)
+	for rows.Next() {
+		t := &models.ApplicationTotal{}
+		if err := rows.Scan(&t.Protocol, &t.Port, &t.BytesIn, &t.BytesOut, &t.Flows); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+// DeleteUsageBefore removes up to limit usage rows for minutes before cutoff
+func (r *SubscriberUsageRepo) DeleteUsageBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return r.deleteBefore(ctx, "subscriber_usage", cutoff, limit)
+}
+
+// DeleteAppUsageBefore removes up to limit application usage rows for hours
+// before cutoff
+func (r *SubscriberUsageRepo) DeleteAppUsageBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return r.deleteBefore(ctx, "subscriber_app_usage", cutoff, limit)
+}
+
+func (r *SubscriberUsageRepo) deleteBefore(ctx context.Context, table string, cutoff time.Time, limit int) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE id IN (
+			SELECT id FROM %s WHERE bucket < $1 LIMIT $2
+		)
+	`, table, table)
+
+	result, err := r.db.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// subscriberUsageConditions builds the WHERE clause shared by the subscriber
+// usage queries. hourly widens the start of the range to the hour.
+func subscriberUsageConditions(tenantID uuid.UUID, filter repository.SubscriberUsageFilter, hourly bool) (string, []interface{}) {
+	from := "s.bucket >= $2"
+	if hourly {
+		from = "s.bucket >= date_trunc('hour', $2::timestamp)"
+	}
+	conditions := []string{"s.tenant_id = $1", from, "s.bucket < $3"}
+	args := []interface{}{tenantID, filter.From, filter.To}
+
+	if filter.RouterID != nil {
+		args = append(args, *filter.RouterID)
+		conditions = append(conditions, fmt.Sprintf("s.router_id = $%d", len(args)))
+	}
+	if filter.POPID != nil {
+		args = append(args, *filter.POPID)
+		conditions = append(conditions, fmt.Sprintf("s.router_id IN (SELECT id FROM routers WHERE pop_id = $%d)", len(args)))
+	}
+	if filter.Subscriber != "" {
+		args = append(args, filter.Subscriber)
+		conditions = append(conditions, fmt.Sprintf("s.subscriber = $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// rowPlaceholders returns "($base+1, ..., $base+n)" for a multi-row insert
+func rowPlaceholders(base, n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = fmt.Sprintf("$%d", base+i+1)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
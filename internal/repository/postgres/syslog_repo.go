@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+)
+
+// SyslogRepo implements repository.SyslogRepository
+type SyslogRepo struct {
+	db *sql.DB
+}
+
+// NewSyslogRepo creates a new syslog repository
+func NewSyslogRepo(db *sql.DB) repository.SyslogRepository {
+	return &SyslogRepo{db: db}
+}
+
+const syslogInsertColumns = `tenant_id, router_id, source_ip, facility, severity, hostname,
+	app_name, proc_id, msg_id, structured_data, message, device_time, received_at`
+
+// CreateBatch stores messages with a single multi-row insert
+func (r *SyslogRepo) CreateBatch(ctx context.Context, messages []*models.SyslogMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	const columnCount = 13
+	placeholders := make([]string, 0, len(messages))
+	args := make([]interface{}, 0, len(messages)*columnCount)
+	for i, m := range messages {
+		base := i * columnCount
+		params := make([]string, columnCount)
+		for j := range params {
+			params[j] = fmt.Sprintf("$%d", base+j+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(params, ", ")+")")
+		args = append(args,
+			m.TenantID, m.RouterID, m.SourceIP, m.Facility, m.Severity, m.Hostname,
+			m.AppName, m.ProcID, m.MsgID, m.StructuredData, m.Message, m.DeviceTime, m.ReceivedAt,
+		)
+	}
+
+	query := `INSERT INTO syslog_messages (` + syslogInsertColumns + `) VALUES ` + strings.Join(placeholders, ", ")
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Search retrieves messages matching the filter, newest first
+func (r *SyslogRepo) Search(ctx context.Context, tenantID uuid.UUID, filter repository.SyslogFilter, opts repository.ListOptions) ([]*models.SyslogMessage, int64, error) {
+	conditions := []string{"s.tenant_id = $1"}
+	args := []interface{}{tenantID}
+
+	if filter.RouterID != nil {
+		args = append(args, *filter.RouterID)
+		conditions = append(conditions, fmt.Sprintf("s.router_id = $%d", len(args)))
+	}
+	if filter.MaxSeverity != nil {
+		args = append(args, *filter.MaxSeverity)
+		conditions = append(conditions, fmt.Sprintf("s.severity <= $%d", len(args)))
+	}
+	if filter.Facility != nil {
+		args = append(args, *filter.Facility)
+		conditions = append(conditions, fmt.Sprintf("s.facility = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("s.received_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("s.received_at < $%d", len(args)))
+	}
+	if filter.Text != "" {
+		args = append(args, "%"+escapeLike(filter.Text)+"%")
+		conditions = append(conditions, fmt.Sprintf("s.message ILIKE $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM syslog_messages s WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (opts.Page - 1) * opts.PageSize
+	args = append(args, opts.PageSize, offset)
+	query := fmt.Sprintf(`
+		SELECT s.id, s.tenant_id, s.router_id, host(s.source_ip), s.facility, s.severity,
+			s.hostname, s.app_name, s.proc_id, s.msg_id, s.structured_data, s.message,
+			s.device_time, s.received_at, r.name
+		FROM syslog_messages s
+		JOIN routers r ON s.router_id = r.id
+		WHERE %s
+		ORDER BY s.received_at DESC, s.id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	messages := make([]*models.SyslogMessage, 0)
+	for rows.Next() {
+		m := &models.SyslogMessage{}
+		err := rows.Scan(
+			&m.ID, &m.TenantID, &m.RouterID, &m.SourceIP, &m.Facility, &m.Severity,
+			&m.Hostname, &m.AppName, &m.ProcID, &m.MsgID, &m.StructuredData, &m.Message,
+			&m.DeviceTime, &m.ReceivedAt, &m.RouterName,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, total, rows.Err()
+}
+
+// DeleteBefore removes up to limit messages received before cutoff, so that
+// retention runs do not hold long locks on a large table
+func (r *SyslogRepo) DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM syslog_messages
+		WHERE id IN (
+			SELECT id FROM syslog_messages WHERE received_at < $1 LIMIT $2
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// escapeLike escapes the LIKE wildcards in user-supplied search text
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
 	"github.com/google/uuid"
@@ -43,6 +45,7 @@ type RouterRepository interface {
 	List(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.Router, int64, error)
 	Update(ctx context.Context, router *models.Router) error
 	Delete(ctx context.Context, tenantID, routerID uuid.UUID) error
+	GetRoleCodes(ctx context.Context, tenantID, routerID uuid.UUID) ([]string, error)
 }
 
 // InterfaceRepository defines the interface for interface data access
@@ -50,6 +53,12 @@ type InterfaceRepository interface {
 	GetByID(ctx context.Context, tenantID, interfaceID uuid.UUID) (*models.Interface, error)
 	List(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.Interface, int64, error)
 	ListByRouter(ctx context.Context, tenantID, routerID uuid.UUID, opts ListOptions) ([]*models.Interface, int64, error)
+	UpdateStatusByIndex(ctx context.Context, tenantID, routerID uuid.UUID, ifIndex int, status, adminStatus string) (*models.Interface, error)
+}
+
+// InterfaceMetricRepository defines the interface for interface counter samples
+type InterfaceMetricRepository interface {
+	CreateBatch(ctx context.Context, metrics []*models.InterfaceMetric) error
 }
 
 // LinkRepository defines the interface for link data access
@@ -63,4 +72,371 @@ type AlertRepository interface {
 	GetByID(ctx context.Context, tenantID, alertID uuid.UUID) (*models.Alert, error)
 	List(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.Alert, int64, error)
 	Acknowledge(ctx context.Context, tenantID, alertID, userID uuid.UUID) error
+	Raise(ctx context.Context, alert *models.Alert) (bool, error)
+	ResolveByDedupKey(ctx context.Context, tenantID uuid.UUID, dedupKey string, resolvedAt time.Time) (int64, error)
+
+	// ListUnresolvedBySource returns the unresolved alerts of every tenant
+	// whose dedup key was issued by source, e.g. models.EventSourceAlertRule
+	ListUnresolvedBySource(ctx context.Context, source string) ([]*models.Alert, error)
+}
+
+// ErrConfigVersionNotFound is returned when a router has no such
+// configuration version, or none at all
+var ErrConfigVersionNotFound = errors.New("config version not found")
+
+// ConfigBackupRepository defines the interface for configuration version data access
+type ConfigBackupRepository interface {
+	Create(ctx context.Context, version *models.ConfigVersion) error
+	GetByVersion(ctx context.Context, tenantID, routerID uuid.UUID, version int) (*models.ConfigVersion, error)
+	// GetLatest returns ErrConfigVersionNotFound when the router was never
+	// backed up
+	GetLatest(ctx context.Context, tenantID, routerID uuid.UUID) (*models.ConfigVersion, error)
+	List(ctx context.Context, tenantID, routerID uuid.UUID, opts ListOptions) ([]*models.ConfigVersion, int64, error)
+	MarkVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error
+}
+
+// DeviceEventRepository defines the interface for device event data access
+type DeviceEventRepository interface {
+	Create(ctx context.Context, event *models.DeviceEvent) error
+}
+
+// ComplianceResultFilter narrows a compliance report
+type ComplianceResultFilter struct {
+	RouterID *uuid.UUID
+	PolicyID *uuid.UUID
+	RoleCode string
+}
+
+// ComplianceRepository defines the interface for compliance policy and result data access
+type ComplianceRepository interface {
+	CreatePolicy(ctx context.Context, policy *models.CompliancePolicy) error
+	GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*models.CompliancePolicy, error)
+	ListPolicies(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.CompliancePolicy, int64, error)
+	ListActivePolicies(ctx context.Context, tenantID uuid.UUID) ([]*models.CompliancePolicy, error)
+	UpdatePolicy(ctx context.Context, policy *models.CompliancePolicy) error
+	DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error
+	SaveResult(ctx context.Context, result *models.ComplianceResult) error
+	ListResults(ctx context.Context, tenantID uuid.UUID, filter ComplianceResultFilter) ([]*models.ComplianceResult, error)
+}
+
+// SyslogFilter narrows a syslog search
+type SyslogFilter struct {
+	RouterID    *uuid.UUID
+	MaxSeverity *int // Messages at this severity or more severe
+	Facility    *int
+	From        *time.Time
+	To          *time.Time
+	Text        string // Case-insensitive substring of the message
+}
+
+// SyslogRepository defines the interface for syslog message data access
+type SyslogRepository interface {
+	CreateBatch(ctx context.Context, messages []*models.SyslogMessage) error
+	Search(ctx context.Context, tenantID uuid.UUID, filter SyslogFilter, opts ListOptions) ([]*models.SyslogMessage, int64, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// FlowFilter narrows a flow rollup query
+type FlowFilter struct {
+	RouterID *uuid.UUID
+	From     time.Time
+	To       time.Time
+	Protocol *int
+	IfIndex  *int // Matches either the input or the output interface
+}
+
+// Flow rollup dimensions accepted by FlowRepository.Top
+const (
+	FlowGroupInterface = "interface"
+	FlowGroupProtocol  = "protocol"
+	FlowGroupSrcPrefix = "src_prefix"
+	FlowGroupDstPrefix = "dst_prefix"
+	FlowGroupSrcAS     = "src_as"
+	FlowGroupDstAS     = "dst_as"
+)
+
+// FlowRepository defines the interface for flow rollup data access
+type FlowRepository interface {
+	UpsertRollups(ctx context.Context, rollups []*models.FlowRollup) error
+	Top(ctx context.Context, tenantID uuid.UUID, filter FlowFilter, groupBy string, limit int) ([]*models.FlowTotal, error)
+	Matrix(ctx context.Context, tenantID uuid.UUID, filter FlowFilter, byAS bool, limit int) ([]*models.FlowMatrixCell, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// SubscriberUsageFilter narrows a subscriber usage query
+type SubscriberUsageFilter struct {
+	RouterID   *uuid.UUID
+	POPID      *uuid.UUID // Routers of this POP
+	Subscriber string
+	From       time.Time
+	To         time.Time
+}
+
+// Orderings accepted by SubscriberUsageRepository.Top
+const (
+	SubscriberOrderTotal = "total"
+	SubscriberOrderIn    = "in"
+	SubscriberOrderOut   = "out"
+)
+
+// Series steps accepted by SubscriberUsageRepository.History
+const (
+	SubscriberStepMinute = "minute"
+	SubscriberStepHour   = "hour"
+	SubscriberStepDay    = "day"
+)
+
+// SubscriberUsageRepository defines the interface for subscriber traffic data
+// access
+type SubscriberUsageRepository interface {
+	UpsertUsage(ctx context.Context, usage []*models.SubscriberUsage) error
+	UpsertAppUsage(ctx context.Context, usage []*models.SubscriberAppUsage) error
+	Top(ctx context.Context, tenantID uuid.UUID, filter SubscriberUsageFilter, orderBy string, limit int) ([]*models.SubscriberTotal, error)
+	History(ctx context.Context, tenantID uuid.UUID, filter SubscriberUsageFilter, step string) ([]*models.SubscriberUsagePoint, error)
+	Applications(ctx context.Context, tenantID uuid.UUID, filter SubscriberUsageFilter, limit int) ([]*models.ApplicationTotal, error)
+	DeleteUsageBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	DeleteAppUsageBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// RoleMetricFilter narrows a role-specific metric series query
+type RoleMetricFilter struct {
+	RouterID *uuid.UUID
+	RoleCode string
+	Metrics  []string // All numeric metrics of the role when empty
+	From     time.Time
+	To       time.Time
+}
+
+// Aggregations accepted by RoleMetricRepository.Series
+const (
+	RoleMetricAggAvg  = "avg"
+	RoleMetricAggMin  = "min"
+	RoleMetricAggMax  = "max"
+	RoleMetricAggSum  = "sum"
+	RoleMetricAggLast = "last"
+)
+
+// RoleMetricRepository defines the interface for role-specific metric data
+// access. Rows are written by the poller's batch writer.
+type RoleMetricRepository interface {
+	// Series aggregates each metric per router over intervals of the given
+	// length, ordered by router name, metric and time
+	Series(ctx context.Context, tenantID uuid.UUID, filter RoleMetricFilter, interval time.Duration, aggregation string) ([]*models.RoleMetricPoint, error)
+}
+
+// NATEventFilter narrows a NAT event search
+type NATEventFilter struct {
+	RouterID       *uuid.UUID
+	Address        string // Inside (pre-NAT) source address
+	PostNATAddress string // Translated (public) source address
+	PostNATPort    *int
+	From           *time.Time
+	To             *time.Time
+}
+
+// NATEventRepository defines the interface for NAT event data access
+type NATEventRepository interface {
+	CreateBatch(ctx context.Context, events []*models.NATEvent) error
+	Search(ctx context.Context, tenantID uuid.UUID, filter NATEventFilter, opts ListOptions) ([]*models.NATEvent, int64, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// PPPoESessionRepository defines the interface for PPPoE session data access.
+// Sessions are keyed by router and session ID; Update and Stop arriving for
+// a session that is not stored yet create it.
+type PPPoESessionRepository interface {
+	Start(ctx context.Context, session *models.PPPoESession) error
+	Update(ctx context.Context, session *models.PPPoESession) error
+	Stop(ctx context.Context, session *models.PPPoESession) error
+	DisconnectRouter(ctx context.Context, routerID uuid.UUID, at time.Time, cause string) (int64, error)
+}
+
+// CredentialProfileRepository defines the interface for credential profile
+// data access. Secrets are stored as given; callers encrypt them.
+type CredentialProfileRepository interface {
+	Create(ctx context.Context, profile *models.CredentialProfile) error
+	GetByID(ctx context.Context, tenantID, profileID uuid.UUID) (*models.CredentialProfile, error)
+	List(ctx context.Context, tenantID uuid.UUID, method string, opts ListOptions) ([]*models.CredentialProfile, int64, error)
+	Update(ctx context.Context, profile *models.CredentialProfile) error
+	Delete(ctx context.Context, tenantID, profileID uuid.UUID) error
+
+	// ListRouterIDs returns the routers referencing a profile
+	ListRouterIDs(ctx context.Context, profile *models.CredentialProfile) ([]uuid.UUID, error)
+
+	// AssignRouters makes routers of the profile's tenant reference it and
+	// returns how many were assigned. Unless keepOverrides is set, the
+	// routers' own credentials of the profile's method are cleared.
+	AssignRouters(ctx context.Context, profile *models.CredentialProfile, routerIDs []uuid.UUID, keepOverrides bool) (int, error)
+
+	// UnassignRouter removes a router's reference to a profile
+	UnassignRouter(ctx context.Context, profile *models.CredentialProfile, routerID uuid.UUID) error
+}
+
+// MaintenanceWindowFilter narrows a query for enabled maintenance windows
+type MaintenanceWindowFilter struct {
+	TenantID *uuid.UUID
+	RouterID *uuid.UUID // Windows whose scope covers the router
+	Since    time.Time  // Windows that may still be active at or after this time
+}
+
+// MaintenanceWindowRepository defines the interface for maintenance window data access
+type MaintenanceWindowRepository interface {
+	Create(ctx context.Context, window *models.MaintenanceWindow) error
+	GetByID(ctx context.Context, tenantID, windowID uuid.UUID) (*models.MaintenanceWindow, error)
+	List(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.MaintenanceWindow, int64, error)
+	Update(ctx context.Context, window *models.MaintenanceWindow) error
+	Delete(ctx context.Context, tenantID, windowID uuid.UUID) error
+
+	// ListEnabled returns the enabled windows matching filter
+	ListEnabled(ctx context.Context, filter MaintenanceWindowFilter) ([]*models.MaintenanceWindow, error)
+
+	// ListRouterIDs returns the routers a window's scope covers
+	ListRouterIDs(ctx context.Context, window *models.MaintenanceWindow) ([]uuid.UUID, error)
+}
+
+// AlertRuleTarget is a router an alert rule applies to
+type AlertRuleTarget struct {
+	RouterID     uuid.UUID
+	RouterName   string
+	LastPolledAt *time.Time
+
+	// IDs of the router's interfaces by name, when requested; for rules
+	// targeting an interface, that interface only
+	Interfaces map[string]uuid.UUID
+}
+
+// AlertRuleRepository defines the interface for alert rule data access
+type AlertRuleRepository interface {
+	Create(ctx context.Context, rule *models.AlertRule) error
+	GetByID(ctx context.Context, tenantID, ruleID uuid.UUID) (*models.AlertRule, error)
+	List(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.AlertRule, int64, error)
+	Update(ctx context.Context, rule *models.AlertRule) error
+	Delete(ctx context.Context, tenantID, ruleID uuid.UUID) error
+
+	// TargetExists reports whether the router, interface or POP of a tenant,
+	// or the router role, a rule targets exists
+	TargetExists(ctx context.Context, tenantID uuid.UUID, targetType string, targetID uuid.UUID) (bool, error)
+
+	// ListEnabled returns the enabled rules of every tenant
+	ListEnabled(ctx context.Context) ([]*models.AlertRule, error)
+
+	// ListTargets returns the routers a rule applies to, with their
+	// interfaces when withInterfaces is set
+	ListTargets(ctx context.Context, rule *models.AlertRule, withInterfaces bool) ([]*AlertRuleTarget, error)
+
+	// ListPoints returns the stored values of a router metric of the given
+	// routers in [from, to), ordered by router and time; for an empty metric,
+	// the successful polls with value 1. Interface metrics are not stored.
+	ListPoints(ctx context.Context, metric string, routerIDs []uuid.UUID, from, to time.Time) ([]*models.MetricPoint, error)
+}
+
+// NotificationDeliveryFilter narrows a delivery log listing
+type NotificationDeliveryFilter struct {
+	ChannelID *uuid.UUID
+	AlertID   *uuid.UUID
+	Status    string
+}
+
+// NotificationJob is a claimed delivery with what is needed to send it
+type NotificationJob struct {
+	Delivery   *models.NotificationDelivery
+	Channel    *models.NotificationChannel
+	Alert      *models.Alert
+	RouterID   *uuid.UUID // Router the alert targets, directly or through an interface
+	RouterName string
+	Recipient  *models.User // User paged by an escalation delivery, if any
+}
+
+// NotificationRepository defines the interface for notification channel,
+// route and delivery data access
+type NotificationRepository interface {
+	CreateChannel(ctx context.Context, channel *models.NotificationChannel) error
+	GetChannel(ctx context.Context, tenantID, channelID uuid.UUID) (*models.NotificationChannel, error)
+	ListChannels(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.NotificationChannel, int64, error)
+	UpdateChannel(ctx context.Context, channel *models.NotificationChannel) error
+	DeleteChannel(ctx context.Context, tenantID, channelID uuid.UUID) error
+
+	CreateRoute(ctx context.Context, route *models.NotificationRoute) error
+	GetRoute(ctx context.Context, tenantID, routeID uuid.UUID) (*models.NotificationRoute, error)
+	ListRoutes(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.NotificationRoute, int64, error)
+	UpdateRoute(ctx context.Context, route *models.NotificationRoute) error
+	DeleteRoute(ctx context.Context, tenantID, routeID uuid.UUID) error
+
+	// ListDeliveries returns the delivery log of a tenant, latest first
+	ListDeliveries(ctx context.Context, tenantID uuid.UUID, filter NotificationDeliveryFilter, opts ListOptions) ([]*models.NotificationDelivery, int64, error)
+
+	// CreateDelivery records a delivery, e.g. a test send made at once
+	CreateDelivery(ctx context.Context, delivery *models.NotificationDelivery) error
+
+	// QueueDeliveries routes up to limit alerts of every tenant that fired
+	// or resolved since they were last routed, queueing a pending delivery
+	// per matching channel and starting an escalation per matching policy.
+	// Resolutions go only to the channels the firing was queued for.
+	// Returns the number of deliveries queued.
+	QueueDeliveries(ctx context.Context, limit int) (int, error)
+
+	// ClaimDeliveries returns up to limit pending deliveries that are due,
+	// moving their next attempt lease into the future so that no other
+	// dispatcher claims them meanwhile
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*NotificationJob, error)
+
+	// UpdateDelivery stores the outcome of a send attempt
+	UpdateDelivery(ctx context.Context, delivery *models.NotificationDelivery) error
+
+	// DeleteDeliveriesBefore removes up to limit finished deliveries created
+	// before cutoff
+	DeleteDeliveriesBefore(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+}
+
+// OnCallRepository defines the interface for on-call schedule and override
+// data access
+type OnCallRepository interface {
+	CreateSchedule(ctx context.Context, schedule *models.OnCallSchedule) error
+	GetSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) (*models.OnCallSchedule, error)
+	ListSchedules(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.OnCallSchedule, int64, error)
+	UpdateSchedule(ctx context.Context, schedule *models.OnCallSchedule) error
+	DeleteSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) error
+
+	CreateOverride(ctx context.Context, override *models.OnCallOverride) error
+	// ListOverrides returns the overrides of a schedule overlapping
+	// [from, to), by start
+	ListOverrides(ctx context.Context, tenantID, scheduleID uuid.UUID, from, to time.Time) ([]*models.OnCallOverride, error)
+	DeleteOverride(ctx context.Context, tenantID, scheduleID, overrideID uuid.UUID) error
+}
+
+// EscalationJob is a claimed alert escalation with its policy and alert
+type EscalationJob struct {
+	Escalation *models.AlertEscalation
+	Policy     *models.EscalationPolicy
+	Alert      *models.Alert
+}
+
+// EscalationRepository defines the interface for escalation policy and
+// alert escalation data access
+type EscalationRepository interface {
+	CreatePolicy(ctx context.Context, policy *models.EscalationPolicy) error
+	GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (*models.EscalationPolicy, error)
+	ListPolicies(ctx context.Context, tenantID uuid.UUID, opts ListOptions) ([]*models.EscalationPolicy, int64, error)
+	UpdatePolicy(ctx context.Context, policy *models.EscalationPolicy) error
+	DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error
+
+	// ListAlertEscalations returns the escalations of an alert, oldest first
+	ListAlertEscalations(ctx context.Context, tenantID, alertID uuid.UUID) ([]*models.AlertEscalation, error)
+
+	// ClaimEscalations returns up to limit running escalations whose next
+	// tier is due, moving their next escalation into the future by lease so
+	// that no other dispatcher claims them meanwhile
+	ClaimEscalations(ctx context.Context, limit int, lease time.Duration) ([]*EscalationJob, error)
+
+	// AdvanceEscalation queues the deliveries paging a tier and stores the
+	// escalation's next step, unless it was stopped or moved on from tier
+	// and repeats meanwhile. Reports whether it advanced.
+	AdvanceEscalation(ctx context.Context, escalation *models.AlertEscalation, tier, repeats int, deliveries []*models.NotificationDelivery) (bool, error)
+
+	// StopEscalation stops one escalation of an alert
+	StopEscalation(ctx context.Context, alertID, policyID uuid.UUID, reason string) error
+
+	// StopAlertEscalations stops the running escalations of an alert and
+	// cancels their pending deliveries
+	StopAlertEscalations(ctx context.Context, tenantID, alertID uuid.UUID, reason string) error
 }
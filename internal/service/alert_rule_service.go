@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/alerting"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MaxAlertRulePreview is the longest range a rule preview may replay
+const MaxAlertRulePreview = 7 * 24 * time.Hour
+
+// AlertRuleService handles alert rule business logic
+type AlertRuleService struct {
+	ruleRepo repository.AlertRuleRepository
+	engine   *alerting.Engine
+	logger   *zap.Logger
+}
+
+// NewAlertRuleService creates a new alert rule service. Changes are applied
+// to engine at once so that evaluation follows them without waiting for
+// its next refresh.
+func NewAlertRuleService(
+	ruleRepo repository.AlertRuleRepository,
+	engine *alerting.Engine,
+	logger *zap.Logger,
+) *AlertRuleService {
+	return &AlertRuleService{
+		ruleRepo: ruleRepo,
+		engine:   engine,
+		logger:   logger,
+	}
+}
+
+// CreateRule creates a new alert rule
+func (s *AlertRuleService) CreateRule(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, req *dto.CreateAlertRuleRequest) (dto.AlertRuleDTO, error) {
+	rule := newAlertRule(tenantID, req)
+	rule.CreatedBy = userID
+
+	if _, err := s.validateRule(ctx, rule); err != nil {
+		return dto.AlertRuleDTO{}, err
+	}
+
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		s.logger.Error("Failed to create alert rule", zap.Error(err))
+		return dto.AlertRuleDTO{}, fmt.Errorf("failed to create alert rule")
+	}
+
+	s.logger.Info("Alert rule created successfully", zap.String("rule_id", rule.ID.String()))
+	s.refresh(ctx)
+
+	return toAlertRuleDTO(rule), nil
+}
+
+// GetRule retrieves an alert rule by ID
+func (s *AlertRuleService) GetRule(ctx context.Context, tenantID, ruleID uuid.UUID) (dto.AlertRuleDTO, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, tenantID, ruleID)
+	if err != nil {
+		s.logger.Error("Failed to get alert rule", zap.Error(err))
+		return dto.AlertRuleDTO{}, fmt.Errorf("alert rule not found")
+	}
+
+	return toAlertRuleDTO(rule), nil
+}
+
+// ListRules retrieves a list of alert rules
+func (s *AlertRuleService) ListRules(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]dto.AlertRuleDTO, int64, error) {
+	rules, total, err := s.ruleRepo.List(ctx, tenantID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list alert rules", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list alert rules")
+	}
+
+	ruleDTOs := make([]dto.AlertRuleDTO, len(rules))
+	for i, rule := range rules {
+		ruleDTOs[i] = toAlertRuleDTO(rule)
+	}
+
+	return ruleDTOs, total, nil
+}
+
+// UpdateRule updates an existing alert rule. Alerts it raised stay active
+// while its series keep firing under the new condition.
+func (s *AlertRuleService) UpdateRule(ctx context.Context, tenantID, ruleID uuid.UUID, req *dto.UpdateAlertRuleRequest) (dto.AlertRuleDTO, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, tenantID, ruleID)
+	if err != nil {
+		return dto.AlertRuleDTO{}, fmt.Errorf("alert rule not found")
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Description != nil {
+		rule.Description = req.Description
+	}
+	if req.RuleType != nil {
+		rule.RuleType = *req.RuleType
+	}
+	if req.TargetType != nil {
+		// An empty target type applies the rule to every router
+		rule.TargetType = req.TargetType
+		if *req.TargetType == "" {
+			rule.TargetType = nil
+			rule.TargetID = nil
+		}
+	}
+	if req.TargetID != nil {
+		rule.TargetID = req.TargetID
+	}
+	if req.Condition != nil {
+		rule.Condition = string(req.Condition)
+	}
+	if req.ThresholdValue != nil {
+		rule.ThresholdValue = req.ThresholdValue
+	}
+	if req.Severity != nil {
+		rule.Severity = *req.Severity
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+
+	if _, err := s.validateRule(ctx, rule); err != nil {
+		return dto.AlertRuleDTO{}, err
+	}
+
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		s.logger.Error("Failed to update alert rule", zap.Error(err))
+		if err.Error() == "alert rule not found" {
+			return dto.AlertRuleDTO{}, err
+		}
+		return dto.AlertRuleDTO{}, fmt.Errorf("failed to update alert rule")
+	}
+
+	s.logger.Info("Alert rule updated successfully", zap.String("rule_id", rule.ID.String()))
+	s.refresh(ctx)
+
+	return toAlertRuleDTO(rule), nil
+}
+
+// DeleteRule deletes an alert rule; the alerts it raised are resolved
+func (s *AlertRuleService) DeleteRule(ctx context.Context, tenantID, ruleID uuid.UUID) error {
+	if err := s.ruleRepo.Delete(ctx, tenantID, ruleID); err != nil {
+		s.logger.Error("Failed to delete alert rule", zap.Error(err))
+		if err.Error() == "alert rule not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete alert rule")
+	}
+
+	s.logger.Info("Alert rule deleted successfully", zap.String("rule_id", ruleID.String()))
+	s.refresh(ctx)
+
+	return nil
+}
+
+// PreviewRule replays the stored series of the last period through a draft
+// rule and returns when it would have fired, per router and in order of
+// firing. Interface metrics are not stored and cannot be previewed.
+func (s *AlertRuleService) PreviewRule(ctx context.Context, tenantID uuid.UUID, req *dto.CreateAlertRuleRequest, period time.Duration) (dto.AlertRulePreviewDTO, error) {
+	if period <= 0 || period > MaxAlertRulePreview {
+		return dto.AlertRulePreviewDTO{}, fmt.Errorf("invalid preview period: must be between 1 and %d hours", int(MaxAlertRulePreview.Hours()))
+	}
+
+	rule := newAlertRule(tenantID, req)
+	compiled, err := s.validateRule(ctx, rule)
+	if err != nil {
+		return dto.AlertRulePreviewDTO{}, err
+	}
+	if compiled.Interface {
+		return dto.AlertRulePreviewDTO{}, fmt.Errorf("invalid alert rule: interface metrics are not stored, so interface rules cannot be previewed")
+	}
+
+	to := time.Now()
+	from := to.Add(-period)
+	preview := dto.AlertRulePreviewDTO{From: from, To: to, Firings: make([]dto.AlertRuleFiringDTO, 0)}
+
+	targets, err := s.ruleRepo.ListTargets(ctx, rule, false)
+	if err != nil {
+		s.logger.Error("Failed to list alert rule targets", zap.Error(err))
+		return dto.AlertRulePreviewDTO{}, fmt.Errorf("failed to preview alert rule")
+	}
+	preview.Routers = len(targets)
+	if len(targets) == 0 {
+		return preview, nil
+	}
+
+	routerIDs := make([]uuid.UUID, len(targets))
+	for i, t := range targets {
+		routerIDs[i] = t.RouterID
+	}
+	stored, err := s.ruleRepo.ListPoints(ctx, compiled.Metric, routerIDs, from, to)
+	if err != nil {
+		s.logger.Error("Failed to list stored metric points", zap.Error(err))
+		return dto.AlertRulePreviewDTO{}, fmt.Errorf("failed to preview alert rule")
+	}
+	preview.Points = len(stored)
+
+	points := make(map[uuid.UUID][]alerting.Point)
+	for _, p := range stored {
+		points[p.RouterID] = append(points[p.RouterID], alerting.Point{Time: p.Timestamp, Value: p.Value})
+	}
+	for _, t := range targets {
+		for _, f := range alerting.Preview(compiled, points[t.RouterID], from, to) {
+			preview.Firings = append(preview.Firings, dto.AlertRuleFiringDTO{
+				RouterID:   t.RouterID,
+				RouterName: t.RouterName,
+				FiredAt:    f.Start,
+				ResolvedAt: f.End,
+				Value:      f.Value,
+			})
+		}
+	}
+	sort.SliceStable(preview.Firings, func(i, j int) bool {
+		return preview.Firings[i].FiredAt.Before(preview.Firings[j].FiredAt)
+	})
+
+	return preview, nil
+}
+
+// validateRule defaults the severity, checks the rule's condition against
+// its type and that its target exists
+func (s *AlertRuleService) validateRule(ctx context.Context, rule *models.AlertRule) (*alerting.Rule, error) {
+	if rule.Severity == "" {
+		rule.Severity = models.SeverityWarning
+	}
+
+	compiled, err := alerting.Compile(rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert rule: %v", err)
+	}
+
+	if rule.TargetType != nil {
+		exists, err := s.ruleRepo.TargetExists(ctx, rule.TenantID, *rule.TargetType, *rule.TargetID)
+		if err != nil {
+			s.logger.Error("Failed to check alert rule target", zap.Error(err))
+			return nil, fmt.Errorf("failed to check alert rule target")
+		}
+		if !exists {
+			return nil, fmt.Errorf("%s %s not found", *rule.TargetType, *rule.TargetID)
+		}
+	}
+	return compiled, nil
+}
+
+// refresh applies rule changes to the engine; on failure they are picked
+// up by its next periodic refresh
+func (s *AlertRuleService) refresh(ctx context.Context) {
+	if err := s.engine.Refresh(ctx); err != nil {
+		s.logger.Warn("Failed to refresh alert rules", zap.Error(err))
+	}
+}
+
+func newAlertRule(tenantID uuid.UUID, req *dto.CreateAlertRuleRequest) *models.AlertRule {
+	rule := &models.AlertRule{
+		ID:             uuid.New(),
+		TenantID:       tenantID,
+		Name:           req.Name,
+		Description:    req.Description,
+		RuleType:       req.RuleType,
+		TargetType:     req.TargetType,
+		TargetID:       req.TargetID,
+		Condition:      string(req.Condition),
+		ThresholdValue: req.ThresholdValue,
+		Severity:       req.Severity,
+		Enabled:        true,
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return rule
+}
+
+func toAlertRuleDTO(rule *models.AlertRule) dto.AlertRuleDTO {
+	return dto.AlertRuleDTO{
+		ID:             rule.ID,
+		TenantID:       rule.TenantID,
+		Name:           rule.Name,
+		Description:    rule.Description,
+		RuleType:       rule.RuleType,
+		TargetType:     rule.TargetType,
+		TargetID:       rule.TargetID,
+		Condition:      json.RawMessage(rule.Condition),
+		ThresholdValue: rule.ThresholdValue,
+		Severity:       rule.Severity,
+		Enabled:        rule.Enabled,
+		CreatedBy:      rule.CreatedBy,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+	}
+}
@@ -13,18 +13,21 @@ import (
 
 // AlertService handles alert business logic
 type AlertService struct {
-	alertRepo repository.AlertRepository
-	logger    *zap.Logger
+	alertRepo      repository.AlertRepository
+	escalationRepo repository.EscalationRepository
+	logger         *zap.Logger
 }
 
 // NewAlertService creates a new alert service
 func NewAlertService(
 	alertRepo repository.AlertRepository,
+	escalationRepo repository.EscalationRepository,
 	logger *zap.Logger,
 ) *AlertService {
 	return &AlertService{
-		alertRepo: alertRepo,
-		logger:    logger,
+		alertRepo:      alertRepo,
+		escalationRepo: escalationRepo,
+		logger:         logger,
 	}
 }
 
@@ -55,13 +58,20 @@ func (s *AlertService) ListAlerts(ctx context.Context, tenantID uuid.UUID, opts
 	return alertDTOs, total, nil
 }
 
-// AcknowledgeAlert acknowledges an alert
+// AcknowledgeAlert acknowledges an alert and stops its escalations,
+// cancelling the pages not sent yet
 func (s *AlertService) AcknowledgeAlert(ctx context.Context, tenantID, alertID, userID uuid.UUID) error {
 	if err := s.alertRepo.Acknowledge(ctx, tenantID, alertID, userID); err != nil {
 		s.logger.Error("Failed to acknowledge alert", zap.Error(err))
 		return fmt.Errorf("failed to acknowledge alert")
 	}
 
+	// The dispatcher also stops escalations of acknowledged alerts, so the
+	// acknowledgement stands if this fails
+	if err := s.escalationRepo.StopAlertEscalations(ctx, tenantID, alertID, models.EscalationAcknowledged); err != nil {
+		s.logger.Error("Failed to stop alert escalations", zap.Error(err), zap.String("alert_id", alertID.String()))
+	}
+
 	s.logger.Info("Alert acknowledged successfully", zap.String("alert_id", alertID.String()))
 
 	return nil
@@ -72,6 +82,7 @@ func toAlertDTO(alert *models.Alert) dto.AlertDTO {
 	return dto.AlertDTO{
 		ID:             alert.ID,
 		TenantID:       alert.TenantID,
+		RuleID:         alert.RuleID,
 		Name:           alert.Name,
 		Description:    alert.Description,
 		Severity:       alert.Severity,
@@ -82,5 +93,8 @@ func toAlertDTO(alert *models.Alert) dto.AlertDTO {
 		AcknowledgedAt: alert.AcknowledgedAt,
 		AcknowledgedBy: alert.AcknowledgedBy,
 		ResolvedAt:     alert.ResolvedAt,
+
+		Suppressed:          alert.Suppressed,
+		MaintenanceWindowID: alert.MaintenanceWindowID,
 	}
 }
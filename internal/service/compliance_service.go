@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/compliance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ComplianceService handles compliance policy and report business logic
+type ComplianceService struct {
+	complianceRepo repository.ComplianceRepository
+	engine         *compliance.Engine
+	logger         *zap.Logger
+}
+
+// NewComplianceService creates a new compliance service
+func NewComplianceService(
+	complianceRepo repository.ComplianceRepository,
+	engine *compliance.Engine,
+	logger *zap.Logger,
+) *ComplianceService {
+	return &ComplianceService{
+		complianceRepo: complianceRepo,
+		engine:         engine,
+		logger:         logger,
+	}
+}
+
+// CreatePolicy creates a new compliance policy
+func (s *ComplianceService) CreatePolicy(ctx context.Context, tenantID uuid.UUID, req *dto.CreateCompliancePolicyRequest) (dto.CompliancePolicyDTO, error) {
+	policy := &models.CompliancePolicy{
+		ID:           uuid.New(),
+		TenantID:     tenantID,
+		Name:         req.Name,
+		Description:  req.Description,
+		Rules:        fromComplianceRuleDTOs(req.Rules),
+		RoleSections: fromComplianceSectionDTOs(req.RoleSections),
+		IsActive:     true,
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+
+	if err := compliance.ValidatePolicy(policy); err != nil {
+		return dto.CompliancePolicyDTO{}, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if err := s.complianceRepo.CreatePolicy(ctx, policy); err != nil {
+		s.logger.Error("Failed to create compliance policy", zap.Error(err))
+		if err.Error() == "compliance policy already exists" {
+			return dto.CompliancePolicyDTO{}, err
+		}
+		return dto.CompliancePolicyDTO{}, fmt.Errorf("failed to create compliance policy")
+	}
+
+	s.logger.Info("Compliance policy created successfully", zap.String("policy_id", policy.ID.String()))
+
+	return toCompliancePolicyDTO(policy), nil
+}
+
+// GetPolicy retrieves a compliance policy by ID
+func (s *ComplianceService) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (dto.CompliancePolicyDTO, error) {
+	policy, err := s.complianceRepo.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		s.logger.Error("Failed to get compliance policy", zap.Error(err))
+		return dto.CompliancePolicyDTO{}, fmt.Errorf("compliance policy not found")
+	}
+
+	return toCompliancePolicyDTO(policy), nil
+}
+
+// ListPolicies retrieves a list of compliance policies
+func (s *ComplianceService) ListPolicies(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]dto.CompliancePolicyDTO, int64, error) {
+	policies, total, err := s.complianceRepo.ListPolicies(ctx, tenantID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list compliance policies", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list compliance policies")
+	}
+
+	policyDTOs := make([]dto.CompliancePolicyDTO, len(policies))
+	for i, policy := range policies {
+		policyDTOs[i] = toCompliancePolicyDTO(policy)
+	}
+
+	return policyDTOs, total, nil
+}
+
+// UpdatePolicy updates an existing compliance policy
+func (s *ComplianceService) UpdatePolicy(ctx context.Context, tenantID, policyID uuid.UUID, req *dto.UpdateCompliancePolicyRequest) (dto.CompliancePolicyDTO, error) {
+	policy, err := s.complianceRepo.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		s.logger.Error("Failed to get compliance policy", zap.Error(err))
+		return dto.CompliancePolicyDTO{}, fmt.Errorf("compliance policy not found")
+	}
+
+	if req.Name != nil {
+		policy.Name = *req.Name
+	}
+	if req.Description != nil {
+		policy.Description = req.Description
+	}
+	if req.Rules != nil {
+		policy.Rules = fromComplianceRuleDTOs(req.Rules)
+	}
+	if req.RoleSections != nil {
+		policy.RoleSections = fromComplianceSectionDTOs(req.RoleSections)
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+
+	if err := compliance.ValidatePolicy(policy); err != nil {
+		return dto.CompliancePolicyDTO{}, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	if err := s.complianceRepo.UpdatePolicy(ctx, policy); err != nil {
+		s.logger.Error("Failed to update compliance policy", zap.Error(err))
+		if err.Error() == "compliance policy already exists" {
+			return dto.CompliancePolicyDTO{}, err
+		}
+		return dto.CompliancePolicyDTO{}, fmt.Errorf("failed to update compliance policy")
+	}
+
+	s.logger.Info("Compliance policy updated successfully", zap.String("policy_id", policy.ID.String()))
+
+	return toCompliancePolicyDTO(policy), nil
+}
+
+// DeletePolicy deletes a compliance policy
+func (s *ComplianceService) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	if err := s.complianceRepo.DeletePolicy(ctx, tenantID, policyID); err != nil {
+		s.logger.Error("Failed to delete compliance policy", zap.Error(err))
+		if err.Error() == "compliance policy not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete compliance policy")
+	}
+
+	s.logger.Info("Compliance policy deleted successfully", zap.String("policy_id", policyID.String()))
+
+	return nil
+}
+
+// EvaluateTenant re-checks all active policies against every router's latest configuration
+func (s *ComplianceService) EvaluateTenant(ctx context.Context, tenantID uuid.UUID) (dto.ComplianceEvaluationDTO, error) {
+	evaluated, err := s.engine.EvaluateTenant(ctx, tenantID)
+	if err != nil {
+		s.logger.Error("Failed to evaluate compliance", zap.Error(err))
+		return dto.ComplianceEvaluationDTO{}, fmt.Errorf("failed to evaluate compliance")
+	}
+
+	return dto.ComplianceEvaluationDTO{RoutersEvaluated: evaluated}, nil
+}
+
+// GetRouterCompliance re-checks one router and returns its score and violations
+func (s *ComplianceService) GetRouterCompliance(ctx context.Context, tenantID, routerID uuid.UUID) (dto.RouterComplianceDTO, error) {
+	results, err := s.engine.EvaluateRouter(ctx, tenantID, routerID)
+	if err != nil {
+		s.logger.Error("Failed to evaluate router compliance", zap.Error(err))
+		if err.Error() == "router not found" {
+			return dto.RouterComplianceDTO{}, err
+		}
+		return dto.RouterComplianceDTO{}, fmt.Errorf("failed to evaluate router compliance")
+	}
+	if len(results) == 0 {
+		return dto.RouterComplianceDTO{}, fmt.Errorf("no compliance results found: router has no stored configuration or tenant has no active policies")
+	}
+
+	return summarizeRouterCompliance(results)[0], nil
+}
+
+// GetReport builds a fleet compliance report from the stored results,
+// optionally limited to one role, one policy, or non-compliant routers
+func (s *ComplianceService) GetReport(ctx context.Context, tenantID uuid.UUID, filter repository.ComplianceResultFilter, nonCompliantOnly bool) (dto.ComplianceReportDTO, error) {
+	results, err := s.complianceRepo.ListResults(ctx, tenantID, filter)
+	if err != nil {
+		s.logger.Error("Failed to list compliance results", zap.Error(err))
+		return dto.ComplianceReportDTO{}, fmt.Errorf("failed to build compliance report")
+	}
+
+	routers := summarizeRouterCompliance(results)
+	report := dto.ComplianceReportDTO{
+		GeneratedAt:      time.Now(),
+		RoutersEvaluated: len(routers),
+		Routers:          make([]dto.RouterComplianceDTO, 0, len(routers)),
+	}
+
+	var scoreSum float64
+	for _, router := range routers {
+		scoreSum += router.Score
+		if router.Compliant {
+			report.CompliantRouters++
+		} else {
+			report.NonCompliantRouters++
+		}
+		if nonCompliantOnly && router.Compliant {
+			continue
+		}
+		report.Routers = append(report.Routers, router)
+	}
+	if len(routers) > 0 {
+		report.AverageScore = math.Round(scoreSum/float64(len(routers))*10) / 10
+	}
+
+	return report, nil
+}
+
+// summarizeRouterCompliance merges per-policy results into one entry per
+// router, worst score first. The router score averages the policy scores
+// weighted by their number of checks.
+func summarizeRouterCompliance(results []*models.ComplianceResult) []dto.RouterComplianceDTO {
+	byRouter := make(map[uuid.UUID]*dto.RouterComplianceDTO)
+	weighted := make(map[uuid.UUID]float64)
+	order := make([]uuid.UUID, 0)
+
+	for _, result := range results {
+		summary, ok := byRouter[result.RouterID]
+		if !ok {
+			summary = &dto.RouterComplianceDTO{
+				RouterID:      result.RouterID,
+				RouterName:    result.RouterName,
+				RoleCodes:     result.RoleCodes,
+				ConfigVersion: result.ConfigVersion,
+				Violations:    make([]dto.ComplianceViolationDTO, 0),
+				EvaluatedAt:   result.EvaluatedAt,
+			}
+			byRouter[result.RouterID] = summary
+			order = append(order, result.RouterID)
+		}
+
+		summary.PassedChecks += result.PassedChecks
+		summary.TotalChecks += result.TotalChecks
+		weighted[result.RouterID] += result.Score * float64(result.TotalChecks)
+		if result.EvaluatedAt.After(summary.EvaluatedAt) {
+			summary.EvaluatedAt = result.EvaluatedAt
+			summary.ConfigVersion = result.ConfigVersion
+		}
+		for _, v := range result.Violations {
+			summary.Violations = append(summary.Violations, toComplianceViolationDTO(v))
+		}
+	}
+
+	summaries := make([]dto.RouterComplianceDTO, 0, len(order))
+	for _, routerID := range order {
+		summary := byRouter[routerID]
+		summary.Score = 100
+		if summary.TotalChecks > 0 {
+			summary.Score = math.Round(weighted[routerID]/float64(summary.TotalChecks)*10) / 10
+		}
+		summary.Compliant = len(summary.Violations) == 0
+		summaries = append(summaries, *summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].Score < summaries[j].Score
+	})
+
+	return summaries
+}
+
+// toCompliancePolicyDTO converts a CompliancePolicy model to CompliancePolicyDTO
+func toCompliancePolicyDTO(policy *models.CompliancePolicy) dto.CompliancePolicyDTO {
+	policyDTO := dto.CompliancePolicyDTO{
+		ID:           policy.ID,
+		TenantID:     policy.TenantID,
+		Name:         policy.Name,
+		Description:  policy.Description,
+		Rules:        make([]dto.ComplianceRuleDTO, len(policy.Rules)),
+		RoleSections: make(map[string][]dto.ComplianceSectionDTO, len(policy.RoleSections)),
+		IsActive:     policy.IsActive,
+		CreatedAt:    policy.CreatedAt,
+		UpdatedAt:    policy.UpdatedAt,
+	}
+
+	for i, rule := range policy.Rules {
+		policyDTO.Rules[i] = dto.ComplianceRuleDTO{
+			Type:        rule.Type,
+			Pattern:     rule.Pattern,
+			Regex:       rule.Regex,
+			Description: rule.Description,
+			Severity:    rule.Severity,
+		}
+	}
+	for roleCode, sections := range policy.RoleSections {
+		sectionDTOs := make([]dto.ComplianceSectionDTO, len(sections))
+		for i, section := range sections {
+			sectionDTOs[i] = dto.ComplianceSectionDTO{
+				Name:     section.Name,
+				Template: section.Template,
+				Severity: section.Severity,
+			}
+		}
+		policyDTO.RoleSections[roleCode] = sectionDTOs
+	}
+
+	return policyDTO
+}
+
+func fromComplianceRuleDTOs(ruleDTOs []dto.ComplianceRuleDTO) []models.ComplianceRule {
+	rules := make([]models.ComplianceRule, len(ruleDTOs))
+	for i, r := range ruleDTOs {
+		rules[i] = models.ComplianceRule{
+			Type:        r.Type,
+			Pattern:     r.Pattern,
+			Regex:       r.Regex,
+			Description: r.Description,
+			Severity:    r.Severity,
+		}
+	}
+	return rules
+}
+
+func fromComplianceSectionDTOs(sectionDTOs map[string][]dto.ComplianceSectionDTO) map[string][]models.ComplianceSection {
+	roleSections := make(map[string][]models.ComplianceSection, len(sectionDTOs))
+	for roleCode, sections := range sectionDTOs {
+		converted := make([]models.ComplianceSection, len(sections))
+		for i, section := range sections {
+			converted[i] = models.ComplianceSection{
+				Name:     section.Name,
+				Template: section.Template,
+				Severity: section.Severity,
+			}
+		}
+		roleSections[roleCode] = converted
+	}
+	return roleSections
+}
+
+func toComplianceViolationDTO(v models.ComplianceViolation) dto.ComplianceViolationDTO {
+	return dto.ComplianceViolationDTO{
+		PolicyID:     v.PolicyID,
+		PolicyName:   v.PolicyName,
+		Type:         v.Type,
+		Check:        v.Check,
+		Severity:     v.Severity,
+		Message:      v.Message,
+		Line:         v.Line,
+		Content:      v.Content,
+		MissingLines: v.MissingLines,
+	}
+}
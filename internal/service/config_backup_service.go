@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/configbackup"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ConfigBackupService handles configuration version business logic
+type ConfigBackupService struct {
+	configRepo repository.ConfigBackupRepository
+	routerRepo repository.RouterRepository
+	logger     *zap.Logger
+}
+
+// NewConfigBackupService creates a new configuration backup service
+func NewConfigBackupService(
+	configRepo repository.ConfigBackupRepository,
+	routerRepo repository.RouterRepository,
+	logger *zap.Logger,
+) *ConfigBackupService {
+	return &ConfigBackupService{
+		configRepo: configRepo,
+		routerRepo: routerRepo,
+		logger:     logger,
+	}
+}
+
+// ListVersions retrieves the stored configuration versions of a router, newest first
+func (s *ConfigBackupService) ListVersions(ctx context.Context, tenantID, routerID uuid.UUID, opts repository.ListOptions) ([]dto.ConfigVersionDTO, int64, error) {
+	if _, err := s.routerRepo.GetByID(ctx, tenantID, routerID); err != nil {
+		s.logger.Error("Failed to get router", zap.Error(err))
+		return nil, 0, fmt.Errorf("router not found")
+	}
+
+	versions, total, err := s.configRepo.List(ctx, tenantID, routerID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list config versions", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list config versions")
+	}
+
+	versionDTOs := make([]dto.ConfigVersionDTO, len(versions))
+	for i, v := range versions {
+		versionDTOs[i] = toConfigVersionDTO(v)
+	}
+
+	return versionDTOs, total, nil
+}
+
+// GetVersion retrieves one configuration version including its content
+func (s *ConfigBackupService) GetVersion(ctx context.Context, tenantID, routerID uuid.UUID, version int) (dto.ConfigVersionDTO, error) {
+	v, err := s.configRepo.GetByVersion(ctx, tenantID, routerID, version)
+	if err != nil {
+		s.logger.Error("Failed to get config version", zap.Error(err))
+		return dto.ConfigVersionDTO{}, fmt.Errorf("config version not found")
+	}
+
+	versionDTO := toConfigVersionDTO(v)
+	versionDTO.Content = v.Content
+
+	return versionDTO, nil
+}
+
+// DiffVersions returns a unified diff between two configuration versions of a router
+func (s *ConfigBackupService) DiffVersions(ctx context.Context, tenantID, routerID uuid.UUID, fromVersion, toVersion, contextLines int) (dto.ConfigDiffDTO, error) {
+	from, err := s.configRepo.GetByVersion(ctx, tenantID, routerID, fromVersion)
+	if err != nil {
+		s.logger.Error("Failed to get config version", zap.Error(err))
+		return dto.ConfigDiffDTO{}, fmt.Errorf("config version %d not found", fromVersion)
+	}
+
+	to, err := s.configRepo.GetByVersion(ctx, tenantID, routerID, toVersion)
+	if err != nil {
+		s.logger.Error("Failed to get config version", zap.Error(err))
+		return dto.ConfigDiffDTO{}, fmt.Errorf("config version %d not found", toVersion)
+	}
+
+	stats := configbackup.Stats(from.Content, to.Content)
+	diff := configbackup.UnifiedDiff(
+		fmt.Sprintf("version %d (%s)", from.Version, from.CollectedAt.Format("2006-01-02 15:04:05")),
+		fmt.Sprintf("version %d (%s)", to.Version, to.CollectedAt.Format("2006-01-02 15:04:05")),
+		from.Content, to.Content, contextLines,
+	)
+
+	return dto.ConfigDiffDTO{
+		RouterID:     routerID,
+		FromVersion:  from.Version,
+		ToVersion:    to.Version,
+		LinesAdded:   stats.Added,
+		LinesRemoved: stats.Removed,
+		Identical:    diff == "",
+		Diff:         diff,
+	}, nil
+}
+
+// toConfigVersionDTO converts a ConfigVersion model to ConfigVersionDTO without content
+func toConfigVersionDTO(v *models.ConfigVersion) dto.ConfigVersionDTO {
+	return dto.ConfigVersionDTO{
+		ID:               v.ID,
+		RouterID:         v.RouterID,
+		Version:          v.Version,
+		ContentHash:      v.ContentHash,
+		SizeBytes:        v.SizeBytes,
+		CollectionMethod: v.CollectionMethod,
+		LinesAdded:       v.LinesAdded,
+		LinesRemoved:     v.LinesRemoved,
+		CollectedAt:      v.CollectedAt,
+		LastVerifiedAt:   v.LastVerifiedAt,
+	}
+}
@@ -0,0 +1,576 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// credentialCheckConcurrency bounds the routers checked at once after a
+// rotation
+const credentialCheckConcurrency = 32
+
+// Outcomes of a credential check on one router
+const (
+	credentialCheckPassed  = "passed"
+	credentialCheckFailed  = "failed"
+	credentialCheckSkipped = "skipped"
+)
+
+// CredentialProfileService handles credential profile business logic
+type CredentialProfileService struct {
+	profileRepo repository.CredentialProfileRepository
+	routerRepo  repository.RouterRepository
+	poller      routerPoller
+	vault       *vault.Vault
+	timeout     time.Duration
+	logger      *zap.Logger
+}
+
+// NewCredentialProfileService creates a new credential profile service.
+// Secrets are encrypted with secrets before they are stored; checks of a
+// profile against its routers give up after timeout.
+func NewCredentialProfileService(
+	profileRepo repository.CredentialProfileRepository,
+	routerRepo repository.RouterRepository,
+	pollerService *poller.EnhancedService,
+	secrets *vault.Vault,
+	timeout time.Duration,
+	logger *zap.Logger,
+) *CredentialProfileService {
+	return &CredentialProfileService{
+		profileRepo: profileRepo,
+		routerRepo:  routerRepo,
+		poller:      pollerService,
+		vault:       secrets,
+		timeout:     timeout,
+		logger:      logger,
+	}
+}
+
+// CreateProfile creates a new credential profile
+func (s *CredentialProfileService) CreateProfile(ctx context.Context, tenantID uuid.UUID, req *dto.CreateCredentialProfileRequest) (dto.CredentialProfileDTO, error) {
+	profile := &models.CredentialProfile{
+		ID:                 uuid.New(),
+		TenantID:           tenantID,
+		Name:               req.Name,
+		Method:             req.Method,
+		Description:        req.Description,
+		SNMPVersion:        req.SNMPVersion,
+		SNMPV3AuthProtocol: req.SNMPV3AuthProtocol,
+		SNMPV3PrivProtocol: req.SNMPV3PrivProtocol,
+		Username:           req.Username,
+	}
+	if profile.Method == models.CredentialMethodSNMP && profile.SNMPVersion == nil {
+		version := "v2c"
+		profile.SNMPVersion = &version
+	}
+	if applyCredentialSecrets(profile, &req.CredentialSecrets) {
+		now := time.Now()
+		profile.RotatedAt = &now
+	}
+
+	if err := validateCredentialProfile(profile); err != nil {
+		return dto.CredentialProfileDTO{}, err
+	}
+	if err := s.encryptSecrets(profile); err != nil {
+		return dto.CredentialProfileDTO{}, fmt.Errorf("failed to create credential profile")
+	}
+
+	if err := s.profileRepo.Create(ctx, profile); err != nil {
+		s.logger.Error("Failed to create credential profile", zap.Error(err))
+		if err.Error() == "credential profile already exists" {
+			return dto.CredentialProfileDTO{}, err
+		}
+		return dto.CredentialProfileDTO{}, fmt.Errorf("failed to create credential profile")
+	}
+
+	s.logger.Info("Credential profile created successfully", zap.String("profile_id", profile.ID.String()))
+
+	return toCredentialProfileDTO(profile), nil
+}
+
+// GetProfile retrieves a credential profile by ID
+func (s *CredentialProfileService) GetProfile(ctx context.Context, tenantID, profileID uuid.UUID) (dto.CredentialProfileDTO, error) {
+	profile, err := s.profileRepo.GetByID(ctx, tenantID, profileID)
+	if err != nil {
+		s.logger.Error("Failed to get credential profile", zap.Error(err))
+		return dto.CredentialProfileDTO{}, fmt.Errorf("credential profile not found")
+	}
+
+	return toCredentialProfileDTO(profile), nil
+}
+
+// ListProfiles retrieves a list of credential profiles, of one method when
+// method is not empty
+func (s *CredentialProfileService) ListProfiles(ctx context.Context, tenantID uuid.UUID, method string, opts repository.ListOptions) ([]dto.CredentialProfileDTO, int64, error) {
+	profiles, total, err := s.profileRepo.List(ctx, tenantID, method, opts)
+	if err != nil {
+		s.logger.Error("Failed to list credential profiles", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list credential profiles")
+	}
+
+	profileDTOs := make([]dto.CredentialProfileDTO, len(profiles))
+	for i, profile := range profiles {
+		profileDTOs[i] = toCredentialProfileDTO(profile)
+	}
+
+	return profileDTOs, total, nil
+}
+
+// UpdateProfile updates an existing credential profile
+func (s *CredentialProfileService) UpdateProfile(ctx context.Context, tenantID, profileID uuid.UUID, req *dto.UpdateCredentialProfileRequest) (dto.CredentialProfileDTO, error) {
+	profile, err := s.getDecrypted(ctx, tenantID, profileID)
+	if err != nil {
+		return dto.CredentialProfileDTO{}, err
+	}
+
+	if req.Name != nil {
+		profile.Name = *req.Name
+	}
+	if req.Description != nil {
+		profile.Description = req.Description
+	}
+	if req.SNMPVersion != nil {
+		profile.SNMPVersion = req.SNMPVersion
+	}
+	if req.SNMPV3AuthProtocol != nil {
+		profile.SNMPV3AuthProtocol = req.SNMPV3AuthProtocol
+	}
+	if req.SNMPV3PrivProtocol != nil {
+		profile.SNMPV3PrivProtocol = req.SNMPV3PrivProtocol
+	}
+	if req.Username != nil {
+		profile.Username = req.Username
+	}
+	if applyCredentialSecrets(profile, &req.CredentialSecrets) {
+		now := time.Now()
+		profile.RotatedAt = &now
+	}
+
+	if err := s.save(ctx, profile); err != nil {
+		return dto.CredentialProfileDTO{}, err
+	}
+
+	s.logger.Info("Credential profile updated successfully", zap.String("profile_id", profile.ID.String()))
+
+	return toCredentialProfileDTO(profile), nil
+}
+
+// DeleteProfile deletes a credential profile no router references
+func (s *CredentialProfileService) DeleteProfile(ctx context.Context, tenantID, profileID uuid.UUID) error {
+	if err := s.profileRepo.Delete(ctx, tenantID, profileID); err != nil {
+		s.logger.Error("Failed to delete credential profile", zap.Error(err))
+		switch err.Error() {
+		case "credential profile not found", "credential profile is in use":
+			return err
+		}
+		return fmt.Errorf("failed to delete credential profile")
+	}
+
+	s.logger.Info("Credential profile deleted successfully", zap.String("profile_id", profileID.String()))
+
+	return nil
+}
+
+// ListRouters retrieves the routers referencing a credential profile
+func (s *CredentialProfileService) ListRouters(ctx context.Context, tenantID, profileID uuid.UUID) (dto.CredentialProfileRoutersDTO, error) {
+	profile, err := s.profileRepo.GetByID(ctx, tenantID, profileID)
+	if err != nil {
+		return dto.CredentialProfileRoutersDTO{}, fmt.Errorf("credential profile not found")
+	}
+
+	routerIDs, err := s.profileRepo.ListRouterIDs(ctx, profile)
+	if err != nil {
+		s.logger.Error("Failed to list credential profile routers", zap.Error(err))
+		return dto.CredentialProfileRoutersDTO{}, fmt.Errorf("failed to list credential profile routers")
+	}
+
+	return dto.CredentialProfileRoutersDTO{ProfileID: profile.ID, RouterIDs: routerIDs}, nil
+}
+
+// AssignRouters makes routers of the tenant reference a credential profile
+func (s *CredentialProfileService) AssignRouters(ctx context.Context, tenantID, profileID uuid.UUID, req *dto.AssignCredentialProfileRequest) (dto.CredentialProfileRoutersDTO, error) {
+	profile, err := s.profileRepo.GetByID(ctx, tenantID, profileID)
+	if err != nil {
+		return dto.CredentialProfileRoutersDTO{}, fmt.Errorf("credential profile not found")
+	}
+
+	for _, routerID := range req.RouterIDs {
+		if _, err := s.routerRepo.GetByID(ctx, tenantID, routerID); err != nil {
+			return dto.CredentialProfileRoutersDTO{}, fmt.Errorf("router %s not found", routerID)
+		}
+	}
+
+	n, err := s.profileRepo.AssignRouters(ctx, profile, req.RouterIDs, req.KeepOverrides)
+	if err != nil {
+		s.logger.Error("Failed to assign credential profile", zap.Error(err))
+		return dto.CredentialProfileRoutersDTO{}, fmt.Errorf("failed to assign credential profile")
+	}
+
+	s.logger.Info("Credential profile assigned",
+		zap.String("profile_id", profile.ID.String()),
+		zap.Int("routers", n),
+		zap.Bool("keep_overrides", req.KeepOverrides))
+
+	return s.ListRouters(ctx, tenantID, profileID)
+}
+
+// UnassignRouter removes a router's reference to a credential profile
+func (s *CredentialProfileService) UnassignRouter(ctx context.Context, tenantID, profileID, routerID uuid.UUID) error {
+	profile, err := s.profileRepo.GetByID(ctx, tenantID, profileID)
+	if err != nil {
+		return fmt.Errorf("credential profile not found")
+	}
+
+	if err := s.profileRepo.UnassignRouter(ctx, profile, routerID); err != nil {
+		s.logger.Error("Failed to unassign credential profile", zap.Error(err))
+		if err.Error() == "router not found for credential profile" {
+			return err
+		}
+		return fmt.Errorf("failed to unassign credential profile")
+	}
+
+	return nil
+}
+
+// TestProfile checks the credentials of a profile against every router
+// referencing it
+func (s *CredentialProfileService) TestProfile(ctx context.Context, tenantID, profileID uuid.UUID) (*dto.CredentialCheckReportDTO, error) {
+	profile, err := s.profileRepo.GetByID(ctx, tenantID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("credential profile not found")
+	}
+
+	return s.checkRouters(ctx, profile)
+}
+
+// RotateProfile replaces the credentials of a profile and checks them
+// against every router referencing it. With RollbackOnFailure the previous
+// credentials are restored when any router fails.
+func (s *CredentialProfileService) RotateProfile(ctx context.Context, tenantID, profileID uuid.UUID, req *dto.RotateCredentialProfileRequest) (*dto.CredentialCheckReportDTO, error) {
+	previous, err := s.profileRepo.GetByID(ctx, tenantID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("credential profile not found")
+	}
+
+	profile, err := s.getDecrypted(ctx, tenantID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Username != nil {
+		profile.Username = req.Username
+	}
+	if !applyCredentialSecrets(profile, &req.CredentialSecrets) {
+		return nil, fmt.Errorf("invalid credential profile: no new secret given")
+	}
+	now := time.Now()
+	profile.RotatedAt = &now
+
+	if err := s.save(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	report, err := s.checkRouters(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RollbackOnFailure && report.Failed > 0 {
+		if err := s.profileRepo.Update(ctx, previous); err != nil {
+			s.logger.Error("Failed to roll back credential profile",
+				zap.String("profile_id", profile.ID.String()), zap.Error(err))
+			return nil, fmt.Errorf("failed to roll back credential profile")
+		}
+		report.Profile = toCredentialProfileDTO(previous)
+		report.RolledBack = true
+	}
+
+	s.logger.Info("Credential profile rotated",
+		zap.String("profile_id", profile.ID.String()),
+		zap.Int("passed", report.Passed),
+		zap.Int("failed", report.Failed),
+		zap.Int("skipped", report.Skipped),
+		zap.Bool("rolled_back", report.RolledBack))
+
+	return report, nil
+}
+
+// checkRouters checks a profile's credentials against its routers, a
+// bounded number at once, until the service timeout
+func (s *CredentialProfileService) checkRouters(ctx context.Context, profile *models.CredentialProfile) (*dto.CredentialCheckReportDTO, error) {
+	routerIDs, err := s.profileRepo.ListRouterIDs(ctx, profile)
+	if err != nil {
+		s.logger.Error("Failed to list credential profile routers", zap.Error(err))
+		return nil, fmt.Errorf("failed to check credential profile")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	results := make([]dto.CredentialCheckDTO, len(routerIDs))
+	sem := make(chan struct{}, credentialCheckConcurrency)
+	var wg sync.WaitGroup
+	for i, routerID := range routerIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				results[i] = s.checkRouter(ctx, routerID, profile.Method)
+			case <-ctx.Done():
+				results[i] = dto.CredentialCheckDTO{
+					RouterID:     routerID,
+					Status:       credentialCheckFailed,
+					ErrorMessage: fmt.Sprintf("check timed out after %s", s.timeout),
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &dto.CredentialCheckReportDTO{
+		Profile: toCredentialProfileDTO(profile),
+		Routers: len(results),
+		Results: results,
+	}
+	for _, result := range results {
+		switch result.Status {
+		case credentialCheckPassed:
+			report.Passed++
+		case credentialCheckFailed:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+	}
+	return report, nil
+}
+
+// checkRouter checks a router's credentials of one method
+func (s *CredentialProfileService) checkRouter(ctx context.Context, routerID uuid.UUID, method string) dto.CredentialCheckDTO {
+	check := dto.CredentialCheckDTO{RouterID: routerID, Status: credentialCheckFailed}
+
+	result, err := s.poller.CheckCredentials(ctx, routerID, method)
+	switch {
+	case errors.Is(err, poller.ErrAdapterNotApplicable):
+		check.Status = credentialCheckSkipped
+		check.ErrorMessage = fmt.Sprintf("no %s connection enabled", method)
+		return check
+	case errors.Is(err, context.DeadlineExceeded):
+		check.ErrorMessage = fmt.Sprintf("check timed out after %s", s.timeout)
+		return check
+	case err != nil:
+		check.ErrorMessage = err.Error()
+		return check
+	}
+
+	check.Adapter = result.Adapter
+	check.ErrorMessage = result.ErrorMessage
+	check.ResponseTimeMs = result.ResponseTimeMs
+	if result.Healthy {
+		check.Status = credentialCheckPassed
+	}
+	return check
+}
+
+// getDecrypted retrieves a credential profile with its secrets in plaintext
+func (s *CredentialProfileService) getDecrypted(ctx context.Context, tenantID, profileID uuid.UUID) (*models.CredentialProfile, error) {
+	profile, err := s.profileRepo.GetByID(ctx, tenantID, profileID)
+	if err != nil {
+		s.logger.Error("Failed to get credential profile", zap.Error(err))
+		return nil, fmt.Errorf("credential profile not found")
+	}
+
+	err = s.vault.DecryptFields(profile.SNMPCommunity, profile.SNMPV3AuthPassword,
+		profile.SNMPV3PrivPassword, profile.Password, profile.PrivateKey)
+	if err != nil {
+		s.logger.Error("Failed to decrypt credential profile",
+			zap.String("profile_id", profile.ID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to decrypt credential profile")
+	}
+	return profile, nil
+}
+
+// save validates a profile with plaintext secrets, encrypts them and
+// stores it
+func (s *CredentialProfileService) save(ctx context.Context, profile *models.CredentialProfile) error {
+	if err := validateCredentialProfile(profile); err != nil {
+		return err
+	}
+	if err := s.encryptSecrets(profile); err != nil {
+		return fmt.Errorf("failed to update credential profile")
+	}
+
+	if err := s.profileRepo.Update(ctx, profile); err != nil {
+		s.logger.Error("Failed to update credential profile", zap.Error(err))
+		switch err.Error() {
+		case "credential profile not found", "credential profile already exists":
+			return err
+		}
+		return fmt.Errorf("failed to update credential profile")
+	}
+	return nil
+}
+
+// encryptSecrets encrypts the secrets of a profile in place
+func (s *CredentialProfileService) encryptSecrets(profile *models.CredentialProfile) error {
+	for _, field := range []**string{
+		&profile.SNMPCommunity, &profile.SNMPV3AuthPassword, &profile.SNMPV3PrivPassword,
+		&profile.Password, &profile.PrivateKey,
+	} {
+		if *field == nil {
+			continue
+		}
+		encrypted, err := s.vault.Encrypt(**field)
+		if err != nil {
+			s.logger.Error("Failed to encrypt credential profile secret", zap.Error(err))
+			return err
+		}
+		*field = &encrypted
+	}
+	return nil
+}
+
+// applyCredentialSecrets sets the secrets given in a request on a profile,
+// clearing those given empty, and reports whether any was given
+func applyCredentialSecrets(profile *models.CredentialProfile, secrets *dto.CredentialSecrets) bool {
+	changed := false
+	for _, s := range []struct {
+		field **string
+		value *string
+	}{
+		{&profile.SNMPCommunity, secrets.SNMPCommunity},
+		{&profile.SNMPV3AuthPassword, secrets.SNMPV3AuthPassword},
+		{&profile.SNMPV3PrivPassword, secrets.SNMPV3PrivPassword},
+		{&profile.Password, secrets.Password},
+		{&profile.PrivateKey, secrets.PrivateKey},
+	} {
+		if s.value == nil {
+			continue
+		}
+		changed = true
+		if *s.value == "" {
+			*s.field = nil
+			continue
+		}
+		value := *s.value
+		*s.field = &value
+	}
+	return changed
+}
+
+// validateCredentialProfile checks that a profile with plaintext secrets
+// holds what its method needs and nothing of other methods
+func validateCredentialProfile(p *models.CredentialProfile) error {
+	set := func(s *string) bool { return s != nil && *s != "" }
+
+	switch p.Method {
+	case models.CredentialMethodSNMP:
+		if set(p.Password) || set(p.PrivateKey) {
+			return fmt.Errorf("invalid credential profile: password and private_key do not apply to snmp profiles")
+		}
+		version := "v2c"
+		if p.SNMPVersion != nil {
+			version = *p.SNMPVersion
+		}
+		switch version {
+		case "v1", "v2c":
+			if !set(p.SNMPCommunity) {
+				return fmt.Errorf("invalid credential profile: snmp_community is required for SNMP %s", version)
+			}
+		case "v3":
+			if !set(p.Username) {
+				return fmt.Errorf("invalid credential profile: username is required for SNMP v3")
+			}
+			if set(p.SNMPV3AuthPassword) != set(p.SNMPV3AuthProtocol) {
+				return fmt.Errorf("invalid credential profile: snmp_v3_auth_protocol and snmp_v3_auth_password go together")
+			}
+			if set(p.SNMPV3PrivPassword) != set(p.SNMPV3PrivProtocol) {
+				return fmt.Errorf("invalid credential profile: snmp_v3_priv_protocol and snmp_v3_priv_password go together")
+			}
+			if set(p.SNMPV3PrivPassword) && !set(p.SNMPV3AuthPassword) {
+				return fmt.Errorf("invalid credential profile: SNMP v3 privacy requires authentication")
+			}
+		default:
+			return fmt.Errorf("invalid credential profile: unknown SNMP version %q", version)
+		}
+
+	case models.CredentialMethodAPI, models.CredentialMethodSSH:
+		if p.SNMPVersion != nil || set(p.SNMPCommunity) || set(p.SNMPV3AuthPassword) || set(p.SNMPV3PrivPassword) ||
+			p.SNMPV3AuthProtocol != nil || p.SNMPV3PrivProtocol != nil {
+			return fmt.Errorf("invalid credential profile: SNMP settings do not apply to %s profiles", p.Method)
+		}
+		if !set(p.Username) {
+			return fmt.Errorf("invalid credential profile: username is required")
+		}
+		if p.Method == models.CredentialMethodAPI {
+			if set(p.PrivateKey) {
+				return fmt.Errorf("invalid credential profile: private_key applies to ssh profiles only")
+			}
+			if !set(p.Password) {
+				return fmt.Errorf("invalid credential profile: password is required")
+			}
+			break
+		}
+		if !set(p.Password) && !set(p.PrivateKey) {
+			return fmt.Errorf("invalid credential profile: password or private_key is required")
+		}
+		if set(p.PrivateKey) {
+			if _, err := ssh.ParsePrivateKey([]byte(*p.PrivateKey)); err != nil {
+				return fmt.Errorf("invalid credential profile: private_key: %w", err)
+			}
+		}
+
+	default:
+		return fmt.Errorf("invalid credential profile: unknown method %q", p.Method)
+	}
+	return nil
+}
+
+func toCredentialProfileDTO(profile *models.CredentialProfile) dto.CredentialProfileDTO {
+	secretsSet := make([]string, 0)
+	for _, s := range []struct {
+		name  string
+		value *string
+	}{
+		{"snmp_community", profile.SNMPCommunity},
+		{"snmp_v3_auth_password", profile.SNMPV3AuthPassword},
+		{"snmp_v3_priv_password", profile.SNMPV3PrivPassword},
+		{"password", profile.Password},
+		{"private_key", profile.PrivateKey},
+	} {
+		if s.value != nil && *s.value != "" {
+			secretsSet = append(secretsSet, s.name)
+		}
+	}
+
+	return dto.CredentialProfileDTO{
+		ID:                 profile.ID,
+		TenantID:           profile.TenantID,
+		Name:               profile.Name,
+		Method:             profile.Method,
+		Description:        profile.Description,
+		SNMPVersion:        profile.SNMPVersion,
+		SNMPV3AuthProtocol: profile.SNMPV3AuthProtocol,
+		SNMPV3PrivProtocol: profile.SNMPV3PrivProtocol,
+		Username:           profile.Username,
+		SecretsSet:         secretsSet,
+		RouterCount:        profile.RouterCount,
+		RotatedAt:          profile.RotatedAt,
+		CreatedAt:          profile.CreatedAt,
+		UpdatedAt:          profile.UpdatedAt,
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeProfiles is a credential profile repository holding profiles and
+// their routers in memory. Routers in overrides have credentials of their
+// own, which assignments clear unless told to keep them.
+type fakeProfiles struct {
+	mu        sync.Mutex
+	profiles  map[uuid.UUID]*models.CredentialProfile
+	routers   map[uuid.UUID][]uuid.UUID
+	overrides map[uuid.UUID]bool
+	updates   int
+	assigned  bool
+}
+
+func newFakeProfiles() *fakeProfiles {
+	return &fakeProfiles{
+		profiles:  make(map[uuid.UUID]*models.CredentialProfile),
+		routers:   make(map[uuid.UUID][]uuid.UUID),
+		overrides: make(map[uuid.UUID]bool),
+	}
+}
+
+// cloneProfile copies a profile with its secrets, as a repository returns
+// a fresh one per read
+func cloneProfile(p *models.CredentialProfile) *models.CredentialProfile {
+	c := *p
+	for _, field := range []**string{
+		&c.SNMPVersion, &c.SNMPCommunity, &c.SNMPV3AuthPassword, &c.SNMPV3PrivPassword,
+		&c.Username, &c.Password, &c.PrivateKey,
+	} {
+		if *field != nil {
+			v := **field
+			*field = &v
+		}
+	}
+	return &c
+}
+
+func (r *fakeProfiles) Create(_ context.Context, profile *models.CredentialProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profiles[profile.ID] = cloneProfile(profile)
+	return nil
+}
+
+func (r *fakeProfiles) GetByID(_ context.Context, tenantID, profileID uuid.UUID) (*models.CredentialProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	profile, ok := r.profiles[profileID]
+	if !ok || profile.TenantID != tenantID {
+		return nil, fmt.Errorf("credential profile not found")
+	}
+	return cloneProfile(profile), nil
+}
+
+func (r *fakeProfiles) List(context.Context, uuid.UUID, string, repository.ListOptions) ([]*models.CredentialProfile, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeProfiles) Update(_ context.Context, profile *models.CredentialProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.profiles[profile.ID]; !ok {
+		return fmt.Errorf("credential profile not found")
+	}
+	r.profiles[profile.ID] = cloneProfile(profile)
+	r.updates++
+	return nil
+}
+
+func (r *fakeProfiles) Delete(context.Context, uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (r *fakeProfiles) ListRouterIDs(_ context.Context, profile *models.CredentialProfile) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uuid.UUID(nil), r.routers[profile.ID]...), nil
+}
+
+func (r *fakeProfiles) AssignRouters(_ context.Context, profile *models.CredentialProfile, routerIDs []uuid.UUID, keepOverrides bool) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.assigned = true
+	for _, id := range routerIDs {
+		r.routers[profile.ID] = append(r.routers[profile.ID], id)
+		if !keepOverrides {
+			delete(r.overrides, id)
+		}
+	}
+	return len(routerIDs), nil
+}
+
+func (r *fakeProfiles) UnassignRouter(context.Context, *models.CredentialProfile, uuid.UUID) error {
+	return nil
+}
+
+// newCredentialTest returns a service with a vault, a stored SNMP profile
+// with community "old" and the given routers assigned to it
+func newCredentialTest(t *testing.T, p *fakePoller, routerIDs ...uuid.UUID) (*CredentialProfileService, *fakeProfiles, uuid.UUID, uuid.UUID) {
+	t.Helper()
+
+	key, err := vault.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := vault.New(config.VaultConfig{MasterKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profiles := newFakeProfiles()
+	s := NewCredentialProfileService(profiles, newFakeRouters(), nil, secrets, time.Second, zap.NewNop())
+	s.poller = p
+
+	tenantID := uuid.New()
+	community := "old"
+	profile, err := s.CreateProfile(context.Background(), tenantID, &dto.CreateCredentialProfileRequest{
+		Name:              "core snmp",
+		Method:            models.CredentialMethodSNMP,
+		CredentialSecrets: dto.CredentialSecrets{SNMPCommunity: &community},
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	profiles.routers[profile.ID] = routerIDs
+	return s, profiles, tenantID, profile.ID
+}
+
+// storedCommunity decrypts the community of the stored profile
+func storedCommunity(t *testing.T, s *CredentialProfileService, profiles *fakeProfiles, profileID uuid.UUID) string {
+	t.Helper()
+	stored := profiles.profiles[profileID]
+	if stored.SNMPCommunity == nil {
+		t.Fatal("stored profile has no community")
+	}
+	if *stored.SNMPCommunity == "old" || *stored.SNMPCommunity == "new" {
+		t.Fatalf("community stored in plaintext")
+	}
+	community, err := s.vault.Decrypt(*stored.SNMPCommunity)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	return community
+}
+
+func TestRotateProfileRollsBackAfterPartialFailure(t *testing.T) {
+	passed, failed, skipped := uuid.New(), uuid.New(), uuid.New()
+	p := &fakePoller{checks: map[uuid.UUID]error{
+		failed:  fmt.Errorf("authentication failed"),
+		skipped: poller.ErrAdapterNotApplicable,
+	}}
+	s, profiles, tenantID, profileID := newCredentialTest(t, p, passed, failed, skipped)
+	previous := cloneProfile(profiles.profiles[profileID])
+
+	community := "new"
+	report, err := s.RotateProfile(context.Background(), tenantID, profileID, &dto.RotateCredentialProfileRequest{
+		CredentialSecrets: dto.CredentialSecrets{SNMPCommunity: &community},
+		RollbackOnFailure: true,
+	})
+	if err != nil {
+		t.Fatalf("RotateProfile: %v", err)
+	}
+
+	if report.Routers != 3 || report.Passed != 1 || report.Failed != 1 || report.Skipped != 1 {
+		t.Errorf("report = %d routers, %d passed, %d failed, %d skipped; want 3, 1, 1, 1",
+			report.Routers, report.Passed, report.Failed, report.Skipped)
+	}
+	if !report.RolledBack {
+		t.Fatal("rotation with a failed router not rolled back")
+	}
+	if !report.Profile.RotatedAt.Equal(*previous.RotatedAt) {
+		t.Errorf("report shows the rotated profile")
+	}
+
+	// The new credentials were stored, checked, then replaced by the
+	// previous ones as they were
+	if profiles.updates != 2 {
+		t.Errorf("%d profile updates, want 2", profiles.updates)
+	}
+	stored := profiles.profiles[profileID]
+	if *stored.SNMPCommunity != *previous.SNMPCommunity || !stored.RotatedAt.Equal(*previous.RotatedAt) {
+		t.Errorf("stored profile not restored")
+	}
+	if got := storedCommunity(t, s, profiles, profileID); got != "old" {
+		t.Errorf("stored community = %q, want old", got)
+	}
+
+	for i, want := range []string{credentialCheckPassed, credentialCheckFailed, credentialCheckSkipped} {
+		if report.Results[i].Status != want {
+			t.Errorf("result %d = %s, want %s", i, report.Results[i].Status, want)
+		}
+	}
+	if report.Results[1].ErrorMessage != "authentication failed" {
+		t.Errorf("failed check message = %q", report.Results[1].ErrorMessage)
+	}
+}
+
+func TestRotateProfileKeepsCredentials(t *testing.T) {
+	passed, failed := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		checks   map[uuid.UUID]error
+		rollback bool
+	}{
+		{"all passed", nil, true},
+		{"failure without rollback", map[uuid.UUID]error{failed: fmt.Errorf("timeout")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, profiles, tenantID, profileID := newCredentialTest(t, &fakePoller{checks: tt.checks}, passed, failed)
+
+			community := "new"
+			report, err := s.RotateProfile(context.Background(), tenantID, profileID, &dto.RotateCredentialProfileRequest{
+				CredentialSecrets: dto.CredentialSecrets{SNMPCommunity: &community},
+				RollbackOnFailure: tt.rollback,
+			})
+			if err != nil {
+				t.Fatalf("RotateProfile: %v", err)
+			}
+			if report.RolledBack {
+				t.Error("rotation rolled back")
+			}
+			if got := storedCommunity(t, s, profiles, profileID); got != "new" {
+				t.Errorf("stored community = %q, want new", got)
+			}
+			if report.Profile.RotatedAt == nil {
+				t.Error("rotation time not set")
+			}
+		})
+	}
+}
+
+func TestRotateProfileRequiresSecret(t *testing.T) {
+	p := &fakePoller{}
+	s, profiles, tenantID, profileID := newCredentialTest(t, p, uuid.New())
+
+	username := "monitor"
+	_, err := s.RotateProfile(context.Background(), tenantID, profileID, &dto.RotateCredentialProfileRequest{
+		Username:          &username,
+		RollbackOnFailure: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "no new secret given") {
+		t.Fatalf("err = %v, want no new secret given", err)
+	}
+	if profiles.updates != 0 || len(p.polled) != 0 {
+		t.Errorf("profile updated or routers checked without a new secret")
+	}
+
+	// Profiles of other tenants are not found
+	community := "new"
+	_, err = s.RotateProfile(context.Background(), uuid.New(), profileID, &dto.RotateCredentialProfileRequest{
+		CredentialSecrets: dto.CredentialSecrets{SNMPCommunity: &community},
+	})
+	if err == nil || err.Error() != "credential profile not found" {
+		t.Fatalf("rotation of another tenant's profile: err = %v", err)
+	}
+}
+
+func TestAssignRoutersOverrides(t *testing.T) {
+	s, profiles, tenantID, profileID := newCredentialTest(t, &fakePoller{})
+	kept, cleared := &models.Router{ID: uuid.New(), TenantID: tenantID}, &models.Router{ID: uuid.New(), TenantID: tenantID}
+	other := &models.Router{ID: uuid.New(), TenantID: uuid.New()}
+	s.routerRepo = newFakeRouters(kept, cleared, other)
+	profiles.overrides[kept.ID] = true
+	profiles.overrides[cleared.ID] = true
+
+	// A router of another tenant fails the whole assignment
+	_, err := s.AssignRouters(context.Background(), tenantID, profileID, &dto.AssignCredentialProfileRequest{
+		RouterIDs: []uuid.UUID{kept.ID, other.ID},
+	})
+	if err == nil || err.Error() != fmt.Sprintf("router %s not found", other.ID) {
+		t.Fatalf("err = %v, want router %s not found", err, other.ID)
+	}
+	if profiles.assigned {
+		t.Fatal("routers assigned although one belongs to another tenant")
+	}
+
+	// The router's own credentials keep precedence over the profile only
+	// when asked
+	_, err = s.AssignRouters(context.Background(), tenantID, profileID, &dto.AssignCredentialProfileRequest{
+		RouterIDs:     []uuid.UUID{kept.ID},
+		KeepOverrides: true,
+	})
+	if err != nil {
+		t.Fatalf("AssignRouters: %v", err)
+	}
+	routers, err := s.AssignRouters(context.Background(), tenantID, profileID, &dto.AssignCredentialProfileRequest{
+		RouterIDs: []uuid.UUID{cleared.ID},
+	})
+	if err != nil {
+		t.Fatalf("AssignRouters: %v", err)
+	}
+
+	if !profiles.overrides[kept.ID] {
+		t.Error("override cleared with KeepOverrides")
+	}
+	if profiles.overrides[cleared.ID] {
+		t.Error("override kept without KeepOverrides")
+	}
+	if len(routers.RouterIDs) != 2 || routers.ProfileID != profileID {
+		t.Errorf("assigned routers = %+v", routers)
+	}
+}
+
+func TestCheckRoutersFanOut(t *testing.T) {
+	routerIDs := make([]uuid.UUID, 3*credentialCheckConcurrency)
+	for i := range routerIDs {
+		routerIDs[i] = uuid.New()
+	}
+	timedOut := routerIDs[len(routerIDs)-1]
+	p := &fakePoller{
+		checks: map[uuid.UUID]error{timedOut: context.DeadlineExceeded},
+		delay:  5 * time.Millisecond,
+	}
+	s, _, tenantID, profileID := newCredentialTest(t, p, routerIDs...)
+
+	report, err := s.TestProfile(context.Background(), tenantID, profileID)
+	if err != nil {
+		t.Fatalf("TestProfile: %v", err)
+	}
+
+	if len(p.polled) != len(routerIDs) {
+		t.Errorf("%d routers checked, want %d", len(p.polled), len(routerIDs))
+	}
+	if p.maxInFlight > credentialCheckConcurrency {
+		t.Errorf("%d checks at once, want at most %d", p.maxInFlight, credentialCheckConcurrency)
+	}
+	if report.Passed != len(routerIDs)-1 || report.Failed != 1 {
+		t.Errorf("report = %d passed, %d failed", report.Passed, report.Failed)
+	}
+
+	// Results are in router order
+	for i, result := range report.Results {
+		if result.RouterID != routerIDs[i] {
+			t.Fatalf("result %d is of router %s, want %s", i, result.RouterID, routerIDs[i])
+		}
+	}
+	last := report.Results[len(report.Results)-1]
+	if last.Status != credentialCheckFailed || last.ErrorMessage != "check timed out after 1s" {
+		t.Errorf("timed out check = %+v", last)
+	}
+}
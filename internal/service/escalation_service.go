@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/oncall"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// EscalationService handles escalation policy business logic
+type EscalationService struct {
+	escalationRepo   repository.EscalationRepository
+	alertRepo        repository.AlertRepository
+	notificationRepo repository.NotificationRepository
+	oncallRepo       repository.OnCallRepository
+	userRepo         repository.UserRepository
+	logger           *zap.Logger
+}
+
+// NewEscalationService creates a new escalation service. The other
+// repositories check the alerts, channels, schedules and users policies
+// refer to.
+func NewEscalationService(
+	escalationRepo repository.EscalationRepository,
+	alertRepo repository.AlertRepository,
+	notificationRepo repository.NotificationRepository,
+	oncallRepo repository.OnCallRepository,
+	userRepo repository.UserRepository,
+	logger *zap.Logger,
+) *EscalationService {
+	return &EscalationService{
+		escalationRepo:   escalationRepo,
+		alertRepo:        alertRepo,
+		notificationRepo: notificationRepo,
+		oncallRepo:       oncallRepo,
+		userRepo:         userRepo,
+		logger:           logger,
+	}
+}
+
+// CreatePolicy creates a new escalation policy; alerts firing from then on
+// are escalated under it
+func (s *EscalationService) CreatePolicy(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, req *dto.CreateEscalationPolicyRequest) (dto.EscalationPolicyDTO, error) {
+	policy := &models.EscalationPolicy{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		Name:        req.Name,
+		Description: nonEmpty(req.Description),
+		Severities:  req.Severities,
+		RoleCodes:   req.RoleCodes,
+		POPIDs:      req.POPIDs,
+		Tiers:       string(req.Tiers),
+		RepeatCount: req.RepeatCount,
+		Enabled:     true,
+		CreatedBy:   userID,
+	}
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return dto.EscalationPolicyDTO{}, err
+	}
+
+	if err := s.escalationRepo.CreatePolicy(ctx, policy); err != nil {
+		s.logger.Error("Failed to create escalation policy", zap.Error(err))
+		if err.Error() == "escalation policy already exists" {
+			return dto.EscalationPolicyDTO{}, err
+		}
+		return dto.EscalationPolicyDTO{}, fmt.Errorf("failed to create escalation policy")
+	}
+
+	s.logger.Info("Escalation policy created successfully", zap.String("policy_id", policy.ID.String()))
+
+	return toEscalationPolicyDTO(policy), nil
+}
+
+// GetPolicy retrieves an escalation policy by ID
+func (s *EscalationService) GetPolicy(ctx context.Context, tenantID, policyID uuid.UUID) (dto.EscalationPolicyDTO, error) {
+	policy, err := s.escalationRepo.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		s.logger.Error("Failed to get escalation policy", zap.Error(err))
+		return dto.EscalationPolicyDTO{}, fmt.Errorf("escalation policy not found")
+	}
+
+	return toEscalationPolicyDTO(policy), nil
+}
+
+// ListPolicies retrieves a list of escalation policies
+func (s *EscalationService) ListPolicies(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]dto.EscalationPolicyDTO, int64, error) {
+	policies, total, err := s.escalationRepo.ListPolicies(ctx, tenantID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list escalation policies", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list escalation policies")
+	}
+
+	policyDTOs := make([]dto.EscalationPolicyDTO, len(policies))
+	for i, policy := range policies {
+		policyDTOs[i] = toEscalationPolicyDTO(policy)
+	}
+
+	return policyDTOs, total, nil
+}
+
+// UpdatePolicy updates an existing escalation policy; running escalations
+// page the new tiers from their current position
+func (s *EscalationService) UpdatePolicy(ctx context.Context, tenantID, policyID uuid.UUID, req *dto.UpdateEscalationPolicyRequest) (dto.EscalationPolicyDTO, error) {
+	policy, err := s.escalationRepo.GetPolicy(ctx, tenantID, policyID)
+	if err != nil {
+		return dto.EscalationPolicyDTO{}, fmt.Errorf("escalation policy not found")
+	}
+
+	if req.Name != nil {
+		policy.Name = *req.Name
+	}
+	if req.Description != nil {
+		policy.Description = nonEmpty(req.Description)
+	}
+	if req.Severities != nil {
+		policy.Severities = req.Severities
+	}
+	if req.RoleCodes != nil {
+		policy.RoleCodes = req.RoleCodes
+	}
+	if req.POPIDs != nil {
+		policy.POPIDs = req.POPIDs
+	}
+	if req.Tiers != nil {
+		policy.Tiers = string(req.Tiers)
+	}
+	if req.RepeatCount != nil {
+		policy.RepeatCount = *req.RepeatCount
+	}
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return dto.EscalationPolicyDTO{}, err
+	}
+
+	if err := s.escalationRepo.UpdatePolicy(ctx, policy); err != nil {
+		s.logger.Error("Failed to update escalation policy", zap.Error(err))
+		switch err.Error() {
+		case "escalation policy not found", "escalation policy already exists":
+			return dto.EscalationPolicyDTO{}, err
+		}
+		return dto.EscalationPolicyDTO{}, fmt.Errorf("failed to update escalation policy")
+	}
+
+	s.logger.Info("Escalation policy updated successfully", zap.String("policy_id", policy.ID.String()))
+
+	return toEscalationPolicyDTO(policy), nil
+}
+
+// DeletePolicy deletes an escalation policy, ending its escalations;
+// deliveries already queued are still sent
+func (s *EscalationService) DeletePolicy(ctx context.Context, tenantID, policyID uuid.UUID) error {
+	if err := s.escalationRepo.DeletePolicy(ctx, tenantID, policyID); err != nil {
+		s.logger.Error("Failed to delete escalation policy", zap.Error(err))
+		if err.Error() == "escalation policy not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete escalation policy")
+	}
+
+	s.logger.Info("Escalation policy deleted successfully", zap.String("policy_id", policyID.String()))
+
+	return nil
+}
+
+// ListAlertEscalations retrieves the escalations of an alert, oldest first
+func (s *EscalationService) ListAlertEscalations(ctx context.Context, tenantID, alertID uuid.UUID) ([]dto.AlertEscalationDTO, error) {
+	if _, err := s.alertRepo.GetByID(ctx, tenantID, alertID); err != nil {
+		return nil, fmt.Errorf("alert not found")
+	}
+
+	escalations, err := s.escalationRepo.ListAlertEscalations(ctx, tenantID, alertID)
+	if err != nil {
+		s.logger.Error("Failed to list alert escalations", zap.Error(err))
+		return nil, fmt.Errorf("failed to list alert escalations")
+	}
+
+	names := make(map[uuid.UUID]string)
+	escalationDTOs := make([]dto.AlertEscalationDTO, len(escalations))
+	for i, e := range escalations {
+		name, ok := names[e.PolicyID]
+		if !ok {
+			if policy, err := s.escalationRepo.GetPolicy(ctx, tenantID, e.PolicyID); err == nil {
+				name = policy.Name
+			}
+			names[e.PolicyID] = name
+		}
+		escalationDTOs[i] = dto.AlertEscalationDTO{
+			AlertID:          e.AlertID,
+			PolicyID:         e.PolicyID,
+			PolicyName:       name,
+			NextTier:         e.Tier + 1,
+			Repeats:          e.Repeats,
+			NextEscalationAt: e.NextEscalationAt,
+			StoppedAt:        e.StoppedAt,
+			StopReason:       e.StopReason,
+			CreatedAt:        e.CreatedAt,
+			UpdatedAt:        e.UpdatedAt,
+		}
+	}
+
+	return escalationDTOs, nil
+}
+
+// validatePolicy checks a policy's tiers and that the channels, schedules
+// and users they page exist in the tenant
+func (s *EscalationService) validatePolicy(ctx context.Context, policy *models.EscalationPolicy) error {
+	tiers, err := oncall.ParseTiers(policy.Tiers, policy.RepeatCount)
+	if err != nil {
+		return fmt.Errorf("invalid escalation policy: %v", err)
+	}
+
+	checked := make(map[uuid.UUID]bool)
+	for _, tier := range tiers {
+		for _, target := range tier.Targets {
+			if err := s.checkTarget(ctx, policy.TenantID, target, checked); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkTarget checks the channel, schedule and user of a tier target,
+// skipping the IDs already checked
+func (s *EscalationService) checkTarget(ctx context.Context, tenantID uuid.UUID, target oncall.Target, checked map[uuid.UUID]bool) error {
+	if !checked[target.ChannelID] {
+		if _, err := s.notificationRepo.GetChannel(ctx, tenantID, target.ChannelID); err != nil {
+			if err.Error() == "notification channel not found" {
+				return fmt.Errorf("notification channel %s not found", target.ChannelID)
+			}
+			s.logger.Error("Failed to check escalation channel", zap.Error(err))
+			return fmt.Errorf("failed to check escalation policy targets")
+		}
+		checked[target.ChannelID] = true
+	}
+
+	if target.ScheduleID != nil && !checked[*target.ScheduleID] {
+		if _, err := s.oncallRepo.GetSchedule(ctx, tenantID, *target.ScheduleID); err != nil {
+			if err.Error() == "on-call schedule not found" {
+				return fmt.Errorf("on-call schedule %s not found", *target.ScheduleID)
+			}
+			s.logger.Error("Failed to check escalation schedule", zap.Error(err))
+			return fmt.Errorf("failed to check escalation policy targets")
+		}
+		checked[*target.ScheduleID] = true
+	}
+
+	if target.UserID != nil && !checked[*target.UserID] {
+		user, err := s.userRepo.GetByID(ctx, *target.UserID)
+		if err != nil || user.TenantID != tenantID {
+			return fmt.Errorf("user %s not found", *target.UserID)
+		}
+		checked[*target.UserID] = true
+	}
+	return nil
+}
+
+func toEscalationPolicyDTO(policy *models.EscalationPolicy) dto.EscalationPolicyDTO {
+	policyDTO := dto.EscalationPolicyDTO{
+		ID:          policy.ID,
+		TenantID:    policy.TenantID,
+		Name:        policy.Name,
+		Description: policy.Description,
+		Severities:  policy.Severities,
+		RoleCodes:   policy.RoleCodes,
+		POPIDs:      nonNilIDs(policy.POPIDs),
+		Tiers:       json.RawMessage(policy.Tiers),
+		RepeatCount: policy.RepeatCount,
+		Enabled:     policy.Enabled,
+		CreatedBy:   policy.CreatedBy,
+		CreatedAt:   policy.CreatedAt,
+		UpdatedAt:   policy.UpdatedAt,
+	}
+	if policyDTO.Severities == nil {
+		policyDTO.Severities = []string{}
+	}
+	if policyDTO.RoleCodes == nil {
+		policyDTO.RoleCodes = []string{}
+	}
+	return policyDTO
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/firmware"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Firmware group statuses
+const (
+	FirmwareStatusOK          = "ok"
+	FirmwareStatusVulnerable  = "vulnerable"
+	FirmwareStatusUnsupported = "unsupported"
+	FirmwareStatusUnknown     = "unknown"
+)
+
+// inventoryPageSize is the page size used when loading the fleet
+const inventoryPageSize = 100
+
+// FirmwareService builds firmware inventory reports
+type FirmwareService struct {
+	routerRepo repository.RouterRepository
+	store      *firmware.Store
+	logger     *zap.Logger
+}
+
+// NewFirmwareService creates a new firmware service
+func NewFirmwareService(
+	routerRepo repository.RouterRepository,
+	store *firmware.Store,
+	logger *zap.Logger,
+) *FirmwareService {
+	return &FirmwareService{
+		routerRepo: routerRepo,
+		store:      store,
+		logger:     logger,
+	}
+}
+
+// GetInventory groups the tenant's routers by vendor, model and OS version and
+// flags groups running affected or unsupported releases
+func (s *FirmwareService) GetInventory(ctx context.Context, tenantID uuid.UUID, flaggedOnly bool) (dto.FirmwareInventoryDTO, error) {
+	routers, err := s.listAllRouters(ctx, tenantID)
+	if err != nil {
+		s.logger.Error("Failed to list routers", zap.Error(err))
+		return dto.FirmwareInventoryDTO{}, fmt.Errorf("failed to build firmware inventory")
+	}
+
+	// A missing or broken dataset still yields the grouping
+	dataset, err := s.store.Dataset()
+	if err != nil {
+		s.logger.Warn("Firmware dataset not loaded", zap.Error(err))
+	}
+
+	now := time.Now()
+	inventory := dto.FirmwareInventoryDTO{
+		GeneratedAt:   now,
+		DatasetLoaded: dataset != nil,
+		TotalRouters:  len(routers),
+		Groups:        make([]dto.FirmwareGroupDTO, 0),
+	}
+	if dataset != nil {
+		inventory.DatasetUpdated = dataset.Updated
+	}
+
+	groups := make(map[string]*dto.FirmwareGroupDTO)
+	for _, router := range routers {
+		vendor, model, version := derefString(router.Vendor), derefString(router.Model), derefString(router.OSVersion)
+		key := strings.ToLower(vendor) + "\x00" + strings.ToLower(model) + "\x00" + version
+
+		group, ok := groups[key]
+		if !ok {
+			group = &dto.FirmwareGroupDTO{
+				Vendor:    vendor,
+				Model:     model,
+				OSVersion: version,
+				Routers:   make([]dto.FirmwareRouterDTO, 0),
+			}
+			assessFirmwareGroup(group, dataset.Assess(vendor, model, version, now))
+			groups[key] = group
+		}
+
+		group.RouterCount++
+		group.Routers = append(group.Routers, dto.FirmwareRouterDTO{ID: router.ID, Name: router.Name})
+	}
+
+	for _, group := range groups {
+		if group.Status == FirmwareStatusUnknown {
+			inventory.UnknownVersionRouters += group.RouterCount
+		}
+		if group.Vulnerable {
+			inventory.VulnerableRouters += group.RouterCount
+		}
+		if group.Unsupported {
+			inventory.UnsupportedRouters += group.RouterCount
+		}
+		if flaggedOnly && !group.Vulnerable && !group.Unsupported {
+			continue
+		}
+		inventory.Groups = append(inventory.Groups, *group)
+	}
+
+	sort.Slice(inventory.Groups, func(i, j int) bool {
+		a, b := inventory.Groups[i], inventory.Groups[j]
+		if !strings.EqualFold(a.Vendor, b.Vendor) {
+			return strings.ToLower(a.Vendor) < strings.ToLower(b.Vendor)
+		}
+		if !strings.EqualFold(a.Model, b.Model) {
+			return strings.ToLower(a.Model) < strings.ToLower(b.Model)
+		}
+		return firmware.CompareVersions(a.OSVersion, b.OSVersion) < 0
+	})
+
+	return inventory, nil
+}
+
+// listAllRouters pages through every router of the tenant
+func (s *FirmwareService) listAllRouters(ctx context.Context, tenantID uuid.UUID) ([]*models.Router, error) {
+	all := make([]*models.Router, 0)
+	for page := 1; ; page++ {
+		routers, total, err := s.routerRepo.List(ctx, tenantID, repository.ListOptions{Page: page, PageSize: inventoryPageSize})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, routers...)
+		if int64(len(all)) >= total || len(routers) == 0 {
+			return all, nil
+		}
+	}
+}
+
+// assessFirmwareGroup fills the group's status from a dataset assessment
+func assessFirmwareGroup(group *dto.FirmwareGroupDTO, assessment firmware.Assessment) {
+	for _, advisory := range assessment.Advisories {
+		group.Advisories = append(group.Advisories, dto.FirmwareAdvisoryDTO{
+			ID:       advisory.ID,
+			Title:    advisory.Title,
+			Severity: advisory.Severity,
+			URL:      advisory.URL,
+		})
+	}
+	if assessment.Lifecycle != nil {
+		group.Lifecycle = &dto.FirmwareLifecycleDTO{
+			Release:      assessment.Lifecycle.Release,
+			EndOfSale:    assessment.Lifecycle.EndOfSale,
+			EndOfSupport: assessment.Lifecycle.EndOfSupport,
+			Note:         assessment.Lifecycle.Note,
+		}
+	}
+
+	group.Vulnerable = len(assessment.Advisories) > 0
+	group.Unsupported = assessment.Unsupported
+
+	switch {
+	case group.OSVersion == "":
+		group.Status = FirmwareStatusUnknown
+	case group.Vulnerable:
+		group.Status = FirmwareStatusVulnerable
+	case group.Unsupported:
+		group.Status = FirmwareStatusUnsupported
+	default:
+		group.Status = FirmwareStatusOK
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/flow"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// FlowService handles traffic queries over flow rollups and subscriber usage,
+// and NAT event search
+type FlowService struct {
+	flowRepo            repository.FlowRepository
+	natEventRepo        repository.NATEventRepository
+	subscriberUsageRepo repository.SubscriberUsageRepository
+	logger              *zap.Logger
+}
+
+// NewFlowService creates a new flow service
+func NewFlowService(flowRepo repository.FlowRepository, natEventRepo repository.NATEventRepository, subscriberUsageRepo repository.SubscriberUsageRepository, logger *zap.Logger) *FlowService {
+	return &FlowService{
+		flowRepo:            flowRepo,
+		natEventRepo:        natEventRepo,
+		subscriberUsageRepo: subscriberUsageRepo,
+		logger:              logger,
+	}
+}
+
+// Top returns the largest keys of one dimension over the filter's time range
+func (s *FlowService) Top(ctx context.Context, tenantID uuid.UUID, filter repository.FlowFilter, groupBy string, limit int) (*dto.FlowTopResponse, error) {
+	totals, err := s.flowRepo.Top(ctx, tenantID, filter, groupBy, limit)
+	if err != nil {
+		s.logger.Error("Failed to query top flows", zap.String("group_by", groupBy), zap.Error(err))
+		return nil, fmt.Errorf("failed to query flows")
+	}
+
+	seconds := filter.To.Sub(filter.From).Seconds()
+	items := make([]dto.FlowTotalDTO, len(totals))
+	for i, t := range totals {
+		items[i] = dto.FlowTotalDTO{
+			Key:           t.Key,
+			Bytes:         t.Bytes,
+			Packets:       t.Packets,
+			Flows:         t.Flows,
+			BitsPerSecond: bitsPerSecond(t.Bytes, seconds),
+		}
+		if groupBy == repository.FlowGroupProtocol {
+			if protocol, err := strconv.Atoi(t.Key); err == nil {
+				items[i].Label = flow.ProtocolName(protocol)
+			}
+		}
+	}
+
+	return &dto.FlowTopResponse{
+		GroupBy: groupBy,
+		From:    filter.From,
+		To:      filter.To,
+		Items:   items,
+	}, nil
+}
+
+// Matrix returns the largest source/destination pairs over the filter's time
+// range, by prefix or by AS
+func (s *FlowService) Matrix(ctx context.Context, tenantID uuid.UUID, filter repository.FlowFilter, byAS bool, limit int) (*dto.FlowMatrixResponse, error) {
+	cells, err := s.flowRepo.Matrix(ctx, tenantID, filter, byAS, limit)
+	if err != nil {
+		s.logger.Error("Failed to query traffic matrix", zap.Bool("by_as", byAS), zap.Error(err))
+		return nil, fmt.Errorf("failed to query flows")
+	}
+
+	seconds := filter.To.Sub(filter.From).Seconds()
+	cellDTOs := make([]dto.FlowMatrixCellDTO, len(cells))
+	for i, c := range cells {
+		cellDTOs[i] = dto.FlowMatrixCellDTO{
+			Source:        c.Source,
+			Destination:   c.Destination,
+			Bytes:         c.Bytes,
+			Packets:       c.Packets,
+			Flows:         c.Flows,
+			BitsPerSecond: bitsPerSecond(c.Bytes, seconds),
+		}
+	}
+
+	by := "prefix"
+	if byAS {
+		by = "as"
+	}
+	return &dto.FlowMatrixResponse{
+		By:    by,
+		From:  filter.From,
+		To:    filter.To,
+		Cells: cellDTOs,
+	}, nil
+}
+
+// SearchNATEvents retrieves the tenant's NAT events matching the filter
+func (s *FlowService) SearchNATEvents(ctx context.Context, tenantID uuid.UUID, filter repository.NATEventFilter, opts repository.ListOptions) ([]dto.NATEventDTO, int64, error) {
+	events, total, err := s.natEventRepo.Search(ctx, tenantID, filter, opts)
+	if err != nil {
+		s.logger.Error("Failed to search NAT events", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to search NAT events")
+	}
+
+	eventDTOs := make([]dto.NATEventDTO, len(events))
+	for i, e := range events {
+		eventDTOs[i] = dto.NATEventDTO{
+			ID:                e.ID,
+			RouterID:          e.RouterID,
+			RouterName:        e.RouterName,
+			EventTime:         e.EventTime,
+			Event:             flow.NATEventName(e.NATEvent),
+			Protocol:          e.Protocol,
+			SrcAddress:        e.SrcAddress,
+			SrcPort:           e.SrcPort,
+			DstAddress:        e.DstAddress,
+			DstPort:           e.DstPort,
+			PostNATSrcAddress: e.PostNATSrcAddress,
+			PostNATSrcPort:    e.PostNATSrcPort,
+			PostNATDstAddress: e.PostNATDstAddress,
+			PostNATDstPort:    e.PostNATDstPort,
+		}
+	}
+
+	return eventDTOs, total, nil
+}
+
+// TopSubscribers returns the subscribers with the most traffic over the
+// filter's time range
+func (s *FlowService) TopSubscribers(ctx context.Context, tenantID uuid.UUID, filter repository.SubscriberUsageFilter, orderBy string, limit int) (*dto.SubscriberTopResponse, error) {
+	totals, err := s.subscriberUsageRepo.Top(ctx, tenantID, filter, orderBy, limit)
+	if err != nil {
+		s.logger.Error("Failed to query top subscribers", zap.String("order_by", orderBy), zap.Error(err))
+		return nil, fmt.Errorf("failed to query subscriber usage")
+	}
+
+	seconds := filter.To.Sub(filter.From).Seconds()
+	items := make([]dto.SubscriberTotalDTO, len(totals))
+	for i, t := range totals {
+		items[i] = dto.SubscriberTotalDTO{
+			Subscriber:       t.Subscriber,
+			SubscriberType:   t.SubscriberType,
+			BytesIn:          t.BytesIn,
+			BytesOut:         t.BytesOut,
+			PacketsIn:        t.PacketsIn,
+			PacketsOut:       t.PacketsOut,
+			Flows:            t.Flows,
+			BitsPerSecondIn:  bitsPerSecond(t.BytesIn, seconds),
+			BitsPerSecondOut: bitsPerSecond(t.BytesOut, seconds),
+		}
+	}
+
+	return &dto.SubscriberTopResponse{
+		OrderBy: orderBy,
+		From:    filter.From,
+		To:      filter.To,
+		Items:   items,
+	}, nil
+}
+
+// SubscriberHistory returns one subscriber's traffic per step over the
+// filter's time range
+func (s *FlowService) SubscriberHistory(ctx context.Context, tenantID uuid.UUID, filter repository.SubscriberUsageFilter, step string) (*dto.SubscriberHistoryResponse, error) {
+	points, err := s.subscriberUsageRepo.History(ctx, tenantID, filter, step)
+	if err != nil {
+		s.logger.Error("Failed to query subscriber usage history", zap.String("step", step), zap.Error(err))
+		return nil, fmt.Errorf("failed to query subscriber usage")
+	}
+
+	pointDTOs := make([]dto.SubscriberUsagePointDTO, len(points))
+	for i, p := range points {
+		pointDTOs[i] = dto.SubscriberUsagePointDTO{
+			Bucket:   p.Bucket,
+			BytesIn:  p.BytesIn,
+			BytesOut: p.BytesOut,
+		}
+	}
+
+	return &dto.SubscriberHistoryResponse{
+		Subscriber: filter.Subscriber,
+		Step:       step,
+		From:       filter.From,
+		To:         filter.To,
+		Points:     pointDTOs,
+	}, nil
+}
+
+// SubscriberApplications returns subscriber traffic by application over the
+// filter's time range, for all subscribers or the filter's subscriber
+func (s *FlowService) SubscriberApplications(ctx context.Context, tenantID uuid.UUID, filter repository.SubscriberUsageFilter, limit int) (*dto.SubscriberApplicationsResponse, error) {
+	totals, err := s.subscriberUsageRepo.Applications(ctx, tenantID, filter, limit)
+	if err != nil {
+		s.logger.Error("Failed to query subscriber applications", zap.Error(err))
+		return nil, fmt.Errorf("failed to query subscriber usage")
+	}
+
+	items := make([]dto.ApplicationTotalDTO, len(totals))
+	for i, t := range totals {
+		items[i] = dto.ApplicationTotalDTO{
+			Application: flow.ApplicationName(t.Protocol, t.Port),
+			Protocol:    t.Protocol,
+			Port:        t.Port,
+			BytesIn:     t.BytesIn,
+			BytesOut:    t.BytesOut,
+			Flows:       t.Flows,
+		}
+	}
+
+	return &dto.SubscriberApplicationsResponse{
+		Subscriber: filter.Subscriber,
+		From:       filter.From,
+		To:         filter.To,
+		Items:      items,
+	}, nil
+}
+
+// bitsPerSecond averages a byte count over a duration in seconds
+func bitsPerSecond(bytes int64, seconds float64) int64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return int64(float64(bytes) * 8 / seconds)
+}
@@ -35,3 +35,11 @@ func postGISToGeoPoint(location string) *dto.GeoPoint {
 func geoPointToPostGIS(point *dto.GeoPoint) string {
 	return fmt.Sprintf("POINT(%f %f)", point.Longitude, point.Latitude)
 }
+
+// derefString returns the string a pointer refers to, or "" for nil
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/maintenance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MaintenanceService handles maintenance window business logic
+type MaintenanceService struct {
+	windowRepo repository.MaintenanceWindowRepository
+	routerRepo repository.RouterRepository
+	windows    *maintenance.Checker
+	logger     *zap.Logger
+}
+
+// NewMaintenanceService creates a new maintenance service. Changes are
+// applied to windows at once so that polling and alerting follow them
+// without waiting for its next refresh.
+func NewMaintenanceService(
+	windowRepo repository.MaintenanceWindowRepository,
+	routerRepo repository.RouterRepository,
+	windows *maintenance.Checker,
+	logger *zap.Logger,
+) *MaintenanceService {
+	return &MaintenanceService{
+		windowRepo: windowRepo,
+		routerRepo: routerRepo,
+		windows:    windows,
+		logger:     logger,
+	}
+}
+
+// CreateWindow creates a new maintenance window
+func (s *MaintenanceService) CreateWindow(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, req *dto.CreateMaintenanceWindowRequest) (dto.MaintenanceWindowDTO, error) {
+	window := &models.MaintenanceWindow{
+		ID:              uuid.New(),
+		TenantID:        tenantID,
+		Name:            req.Name,
+		Description:     req.Description,
+		StartsAt:        req.StartsAt,
+		EndsAt:          req.EndsAt,
+		Recurrence:      req.Recurrence,
+		DurationMinutes: req.DurationMinutes,
+		Timezone:        req.Timezone,
+		RouterIDs:       req.RouterIDs,
+		POPIDs:          req.POPIDs,
+		RegionIDs:       req.RegionIDs,
+		RoleCodes:       req.RoleCodes,
+		PausePolling:    req.PausePolling,
+		Enabled:         true,
+		CreatedBy:       userID,
+	}
+	if req.Enabled != nil {
+		window.Enabled = *req.Enabled
+	}
+
+	if err := s.validateWindow(ctx, window); err != nil {
+		return dto.MaintenanceWindowDTO{}, err
+	}
+
+	if err := s.windowRepo.Create(ctx, window); err != nil {
+		s.logger.Error("Failed to create maintenance window", zap.Error(err))
+		return dto.MaintenanceWindowDTO{}, fmt.Errorf("failed to create maintenance window")
+	}
+
+	s.logger.Info("Maintenance window created successfully", zap.String("window_id", window.ID.String()))
+	s.refresh(ctx)
+
+	return toMaintenanceWindowDTO(window, time.Now()), nil
+}
+
+// GetWindow retrieves a maintenance window by ID
+func (s *MaintenanceService) GetWindow(ctx context.Context, tenantID, windowID uuid.UUID) (dto.MaintenanceWindowDTO, error) {
+	window, err := s.windowRepo.GetByID(ctx, tenantID, windowID)
+	if err != nil {
+		s.logger.Error("Failed to get maintenance window", zap.Error(err))
+		return dto.MaintenanceWindowDTO{}, fmt.Errorf("maintenance window not found")
+	}
+
+	return toMaintenanceWindowDTO(window, time.Now()), nil
+}
+
+// ListWindows retrieves a list of maintenance windows
+func (s *MaintenanceService) ListWindows(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]dto.MaintenanceWindowDTO, int64, error) {
+	windows, total, err := s.windowRepo.List(ctx, tenantID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list maintenance windows", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list maintenance windows")
+	}
+
+	now := time.Now()
+	windowDTOs := make([]dto.MaintenanceWindowDTO, len(windows))
+	for i, window := range windows {
+		windowDTOs[i] = toMaintenanceWindowDTO(window, now)
+	}
+
+	return windowDTOs, total, nil
+}
+
+// UpdateWindow updates an existing maintenance window
+func (s *MaintenanceService) UpdateWindow(ctx context.Context, tenantID, windowID uuid.UUID, req *dto.UpdateMaintenanceWindowRequest) (dto.MaintenanceWindowDTO, error) {
+	window, err := s.windowRepo.GetByID(ctx, tenantID, windowID)
+	if err != nil {
+		return dto.MaintenanceWindowDTO{}, fmt.Errorf("maintenance window not found")
+	}
+
+	if req.Name != nil {
+		window.Name = *req.Name
+	}
+	if req.Description != nil {
+		window.Description = req.Description
+	}
+	if req.StartsAt != nil {
+		window.StartsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		window.EndsAt = req.EndsAt
+	}
+	if req.Recurrence != nil {
+		// An empty recurrence turns the window into a one-off one
+		window.Recurrence = req.Recurrence
+		if *req.Recurrence == "" {
+			window.Recurrence = nil
+			window.DurationMinutes = nil
+		}
+	}
+	if req.DurationMinutes != nil {
+		window.DurationMinutes = req.DurationMinutes
+	}
+	if req.Timezone != nil {
+		window.Timezone = *req.Timezone
+	}
+	if req.RouterIDs != nil {
+		window.RouterIDs = req.RouterIDs
+	}
+	if req.POPIDs != nil {
+		window.POPIDs = req.POPIDs
+	}
+	if req.RegionIDs != nil {
+		window.RegionIDs = req.RegionIDs
+	}
+	if req.RoleCodes != nil {
+		window.RoleCodes = req.RoleCodes
+	}
+	if req.PausePolling != nil {
+		window.PausePolling = *req.PausePolling
+	}
+	if req.Enabled != nil {
+		window.Enabled = *req.Enabled
+	}
+
+	if err := s.validateWindow(ctx, window); err != nil {
+		return dto.MaintenanceWindowDTO{}, err
+	}
+
+	if err := s.windowRepo.Update(ctx, window); err != nil {
+		s.logger.Error("Failed to update maintenance window", zap.Error(err))
+		if err.Error() == "maintenance window not found" {
+			return dto.MaintenanceWindowDTO{}, err
+		}
+		return dto.MaintenanceWindowDTO{}, fmt.Errorf("failed to update maintenance window")
+	}
+
+	s.logger.Info("Maintenance window updated successfully", zap.String("window_id", window.ID.String()))
+	s.refresh(ctx)
+
+	return toMaintenanceWindowDTO(window, time.Now()), nil
+}
+
+// DeleteWindow deletes a maintenance window
+func (s *MaintenanceService) DeleteWindow(ctx context.Context, tenantID, windowID uuid.UUID) error {
+	if err := s.windowRepo.Delete(ctx, tenantID, windowID); err != nil {
+		s.logger.Error("Failed to delete maintenance window", zap.Error(err))
+		if err.Error() == "maintenance window not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete maintenance window")
+	}
+
+	s.logger.Info("Maintenance window deleted successfully", zap.String("window_id", windowID.String()))
+	s.refresh(ctx)
+
+	return nil
+}
+
+// ListRouters retrieves the routers a maintenance window covers
+func (s *MaintenanceService) ListRouters(ctx context.Context, tenantID, windowID uuid.UUID) (dto.MaintenanceWindowRoutersDTO, error) {
+	window, err := s.windowRepo.GetByID(ctx, tenantID, windowID)
+	if err != nil {
+		return dto.MaintenanceWindowRoutersDTO{}, fmt.Errorf("maintenance window not found")
+	}
+
+	routerIDs, err := s.windowRepo.ListRouterIDs(ctx, window)
+	if err != nil {
+		s.logger.Error("Failed to list maintenance window routers", zap.Error(err))
+		return dto.MaintenanceWindowRoutersDTO{}, fmt.Errorf("failed to list maintenance window routers")
+	}
+
+	return dto.MaintenanceWindowRoutersDTO{WindowID: window.ID, RouterIDs: routerIDs}, nil
+}
+
+// Annotations returns the occurrences of enabled windows overlapping
+// [from, to) as graph annotations, only those covering a router when
+// routerID is set
+func (s *MaintenanceService) Annotations(ctx context.Context, tenantID uuid.UUID, routerID *uuid.UUID, from, to time.Time) ([]dto.AnnotationDTO, error) {
+	return maintenanceAnnotations(ctx, s.windowRepo, tenantID, routerID, from, to)
+}
+
+// validateWindow defaults the timezone and checks the schedule and that
+// listed routers belong to the tenant
+func (s *MaintenanceService) validateWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	if err := maintenance.Validate(window); err != nil {
+		return fmt.Errorf("invalid maintenance window: %v", err)
+	}
+
+	for _, routerID := range window.RouterIDs {
+		if _, err := s.routerRepo.GetByID(ctx, window.TenantID, routerID); err != nil {
+			return fmt.Errorf("router %s not found", routerID)
+		}
+	}
+	return nil
+}
+
+// refresh applies window changes to the checker; on failure they are
+// picked up by its next periodic refresh
+func (s *MaintenanceService) refresh(ctx context.Context) {
+	if err := s.windows.Refresh(ctx); err != nil {
+		s.logger.Warn("Failed to refresh maintenance windows", zap.Error(err))
+	}
+}
+
+// maintenanceAnnotations lists the occurrences of a tenant's enabled
+// windows overlapping [from, to) as annotations, in order of start
+func maintenanceAnnotations(ctx context.Context, windowRepo repository.MaintenanceWindowRepository, tenantID uuid.UUID, routerID *uuid.UUID, from, to time.Time) ([]dto.AnnotationDTO, error) {
+	windows, err := windowRepo.ListEnabled(ctx, repository.MaintenanceWindowFilter{
+		TenantID: &tenantID,
+		RouterID: routerID,
+		Since:    from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows")
+	}
+
+	annotations := make([]dto.AnnotationDTO, 0)
+	for _, window := range windows {
+		occurrences, err := maintenance.Occurrences(window, from, to)
+		if err != nil {
+			continue
+		}
+
+		tags := []string{"maintenance"}
+		if window.PausePolling {
+			tags = append(tags, "polling-paused")
+		}
+		for _, o := range occurrences {
+			annotations = append(annotations, dto.AnnotationDTO{
+				WindowID: window.ID,
+				Title:    window.Name,
+				Text:     window.Description,
+				Start:    o.Start,
+				End:      o.End,
+				Tags:     tags,
+			})
+		}
+	}
+
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return annotations[i].Start.Before(annotations[j].Start)
+	})
+	return annotations, nil
+}
+
+func toMaintenanceWindowDTO(window *models.MaintenanceWindow, now time.Time) dto.MaintenanceWindowDTO {
+	windowDTO := dto.MaintenanceWindowDTO{
+		ID:              window.ID,
+		TenantID:        window.TenantID,
+		Name:            window.Name,
+		Description:     window.Description,
+		StartsAt:        window.StartsAt,
+		EndsAt:          window.EndsAt,
+		Recurrence:      window.Recurrence,
+		DurationMinutes: window.DurationMinutes,
+		Timezone:        window.Timezone,
+		RouterIDs:       nonNilIDs(window.RouterIDs),
+		POPIDs:          nonNilIDs(window.POPIDs),
+		RegionIDs:       nonNilIDs(window.RegionIDs),
+		RoleCodes:       window.RoleCodes,
+		PausePolling:    window.PausePolling,
+		Enabled:         window.Enabled,
+		CreatedBy:       window.CreatedBy,
+		CreatedAt:       window.CreatedAt,
+		UpdatedAt:       window.UpdatedAt,
+	}
+	if windowDTO.RoleCodes == nil {
+		windowDTO.RoleCodes = []string{}
+	}
+
+	if window.Enabled {
+		if next, err := maintenance.Next(window, now); err == nil && next != nil {
+			windowDTO.Active = next.Contains(now)
+			windowDTO.NextOccurrence = &dto.MaintenanceOccurrenceDTO{Start: next.Start, End: next.End}
+		}
+	}
+
+	return windowDTO
+}
+
+// nonNilIDs returns ids, or an empty slice for nil so that it is encoded
+// as an empty list
+func nonNilIDs(ids []uuid.UUID) []uuid.UUID {
+	if ids == nil {
+		return []uuid.UUID{}
+	}
+	return ids
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/metric"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -13,21 +14,27 @@ import (
 
 // MetricsService handles metrics business logic
 type MetricsService struct {
-	interfaceRepo repository.InterfaceRepository
-	routerRepo    repository.RouterRepository
-	logger        *zap.Logger
+	interfaceRepo  repository.InterfaceRepository
+	routerRepo     repository.RouterRepository
+	windowRepo     repository.MaintenanceWindowRepository
+	roleMetricRepo repository.RoleMetricRepository
+	logger         *zap.Logger
 }
 
 // NewMetricsService creates a new metrics service
 func NewMetricsService(
 	interfaceRepo repository.InterfaceRepository,
 	routerRepo repository.RouterRepository,
+	windowRepo repository.MaintenanceWindowRepository,
+	roleMetricRepo repository.RoleMetricRepository,
 	logger *zap.Logger,
 ) *MetricsService {
 	return &MetricsService{
-		interfaceRepo: interfaceRepo,
-		routerRepo:    routerRepo,
-		logger:        logger,
+		interfaceRepo:  interfaceRepo,
+		routerRepo:     routerRepo,
+		windowRepo:     windowRepo,
+		roleMetricRepo: roleMetricRepo,
+		logger:         logger,
 	}
 }
 
@@ -40,6 +47,12 @@ func (s *MetricsService) GetInterfaceMetrics(ctx context.Context, tenantID, inte
 		return nil, fmt.Errorf("interface not found")
 	}
 
+	annotations, err := maintenanceAnnotations(ctx, s.windowRepo, tenantID, &iface.RouterID, from, to)
+	if err != nil {
+		s.logger.Error("Failed to get maintenance annotations", zap.Error(err))
+		return nil, err
+	}
+
 	// Stub implementation - return empty metrics for now
 	// Real implementation will query TimescaleDB for actual metrics data
 	metrics := &dto.InterfaceMetrics{
@@ -52,6 +65,7 @@ func (s *MetricsService) GetInterfaceMetrics(ctx context.Context, tenantID, inte
 		InErrors:      []dto.MetricDataPoint{},
 		OutErrors:     []dto.MetricDataPoint{},
 		Utilization:   []dto.MetricDataPoint{},
+		Annotations:   annotations,
 	}
 
 	s.logger.Info("Retrieved interface metrics (stub)",
@@ -71,6 +85,12 @@ func (s *MetricsService) GetRouterMetrics(ctx context.Context, tenantID, routerI
 		return nil, fmt.Errorf("router not found")
 	}
 
+	annotations, err := maintenanceAnnotations(ctx, s.windowRepo, tenantID, &routerID, from, to)
+	if err != nil {
+		s.logger.Error("Failed to get maintenance annotations", zap.Error(err))
+		return nil, err
+	}
+
 	// Stub implementation - return empty metrics for now
 	// Real implementation will query TimescaleDB for actual metrics data
 	metrics := &dto.RouterMetrics{
@@ -81,6 +101,7 @@ func (s *MetricsService) GetRouterMetrics(ctx context.Context, tenantID, routerI
 		TotalInBps:       []dto.MetricDataPoint{},
 		TotalOutBps:      []dto.MetricDataPoint{},
 		ActiveInterfaces: []dto.MetricDataPoint{},
+		Annotations:      annotations,
 	}
 
 	s.logger.Info("Retrieved router metrics (stub)",
@@ -90,3 +111,131 @@ func (s *MetricsService) GetRouterMetrics(ctx context.Context, tenantID, routerI
 
 	return metrics, nil
 }
+
+// Limits on role metric series
+const (
+	minRoleMetricInterval   = time.Minute
+	maxRoleMetricPoints     = 1000
+	defaultRoleMetricPoints = 300 // Points per series when no interval is given
+)
+
+// GetRoleMetrics returns the series of a role's numeric metrics per router,
+// for one router when the filter names one. Without an interval one is
+// chosen to give about defaultRoleMetricPoints points.
+func (s *MetricsService) GetRoleMetrics(ctx context.Context, tenantID uuid.UUID, filter repository.RoleMetricFilter, interval time.Duration, aggregation string) (*dto.RoleMetrics, error) {
+	defs, err := roleMetricDefinitions(filter)
+	if err != nil {
+		return nil, err
+	}
+	if aggregation == "" {
+		aggregation = repository.RoleMetricAggAvg
+	}
+	interval, err = roleMetricInterval(filter.From, filter.To, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.RoleMetrics{
+		Role:            filter.RoleCode,
+		Aggregation:     aggregation,
+		IntervalSeconds: int64(interval / time.Second),
+		From:            filter.From,
+		To:              filter.To,
+		Routers:         []dto.RouterRoleMetrics{},
+	}
+
+	// Verify router exists; it is listed even without data
+	if filter.RouterID != nil {
+		router, err := s.routerRepo.GetByID(ctx, tenantID, *filter.RouterID)
+		if err != nil {
+			s.logger.Error("Failed to get router", zap.Error(err))
+			return nil, fmt.Errorf("router not found")
+		}
+		result.Routers = append(result.Routers, dto.RouterRoleMetrics{
+			RouterID:   router.ID.String(),
+			RouterName: router.Name,
+			Series:     []dto.RoleMetricSeries{},
+		})
+	}
+
+	points, err := s.roleMetricRepo.Series(ctx, tenantID, filter, interval, aggregation)
+	if err != nil {
+		s.logger.Error("Failed to query role metrics",
+			zap.String("role", filter.RoleCode),
+			zap.String("aggregation", aggregation),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to query role metrics")
+	}
+
+	// Points are ordered by router, metric and time
+	for _, p := range points {
+		routerID := p.RouterID.String()
+		if n := len(result.Routers); n == 0 || result.Routers[n-1].RouterID != routerID {
+			result.Routers = append(result.Routers, dto.RouterRoleMetrics{
+				RouterID:   routerID,
+				RouterName: p.RouterName,
+				Series:     []dto.RoleMetricSeries{},
+			})
+		}
+		router := &result.Routers[len(result.Routers)-1]
+
+		if n := len(router.Series); n == 0 || router.Series[n-1].Metric != p.Metric {
+			def := defs[p.Metric]
+			router.Series = append(router.Series, dto.RoleMetricSeries{
+				Metric: p.Metric,
+				Unit:   def.Unit,
+				Kind:   string(def.Kind),
+				Points: []dto.MetricDataPoint{},
+			})
+		}
+		series := &router.Series[len(router.Series)-1]
+		series.Points = append(series.Points, dto.MetricDataPoint{Timestamp: p.Bucket, Value: p.Value})
+	}
+
+	result.Annotations, err = maintenanceAnnotations(ctx, s.windowRepo, tenantID, filter.RouterID, filter.From, filter.To)
+	if err != nil {
+		s.logger.Error("Failed to get maintenance annotations", zap.Error(err))
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// roleMetricDefinitions returns the numeric metrics of the filter's role by
+// name, checking the role has metrics and the filter's metrics belong to it
+func roleMetricDefinitions(filter repository.RoleMetricFilter) (map[string]metric.Definition, error) {
+	defs := make(map[string]metric.Definition)
+	for _, def := range metric.Default.RoleDefinitions(filter.RoleCode) {
+		if def.Kind != metric.KindInfo {
+			defs[def.Name] = def
+		}
+	}
+	if filter.RoleCode == "" || len(defs) == 0 {
+		return nil, fmt.Errorf("invalid role metrics query: role %q has no metrics", filter.RoleCode)
+	}
+	for _, name := range filter.Metrics {
+		if _, ok := defs[name]; !ok {
+			return nil, fmt.Errorf("invalid role metrics query: %q is not a numeric metric of role %s", name, filter.RoleCode)
+		}
+	}
+	return defs, nil
+}
+
+// roleMetricInterval checks or chooses the interval of a series over a time
+// range. Chosen intervals are whole minutes.
+func roleMetricInterval(from, to time.Time, interval time.Duration) (time.Duration, error) {
+	if !from.Before(to) {
+		return 0, fmt.Errorf("invalid role metrics query: from must be before to")
+	}
+	if interval == 0 {
+		interval = (to.Sub(from) / defaultRoleMetricPoints).Round(time.Minute)
+		interval = max(interval, minRoleMetricInterval)
+	}
+	if interval < minRoleMetricInterval || interval%time.Second != 0 {
+		return 0, fmt.Errorf("invalid role metrics query: interval must be whole seconds and at least %s", minRoleMetricInterval)
+	}
+	if to.Sub(from)/interval > maxRoleMetricPoints {
+		return 0, fmt.Errorf("invalid role metrics query: interval %s gives more than %d points", interval, maxRoleMetricPoints)
+	}
+	return interval, nil
+}
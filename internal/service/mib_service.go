@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
+	"go.uber.org/zap"
+)
+
+// MIBService looks up loaded MIB objects by name or OID
+type MIBService struct {
+	mibs   *mib.Tree
+	logger *zap.Logger
+}
+
+// NewMIBService creates a new MIB service
+func NewMIBService(mibs *mib.Tree, logger *zap.Logger) *MIBService {
+	return &MIBService{
+		mibs:   mibs,
+		logger: logger,
+	}
+}
+
+// Lookup resolves a query that is either a numeric OID or an object name,
+// optionally qualified by module and followed by an instance suffix
+func (s *MIBService) Lookup(query string) (*dto.MIBNodeDTO, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("invalid MIB query: q is required")
+	}
+
+	oid, err := s.mibs.Resolve(query)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "unknown MIB object") {
+			return nil, fmt.Errorf("MIB object not found: %s", query)
+		}
+		return nil, fmt.Errorf("invalid MIB query: %w", err)
+	}
+
+	node, instance := s.mibs.Lookup(oid)
+	if node == nil {
+		return nil, fmt.Errorf("MIB object not found: %s", query)
+	}
+
+	result := &dto.MIBNodeDTO{
+		Name:        node.Module + "::" + node.Name,
+		Object:      node.Name,
+		Module:      node.Module,
+		OID:         node.OID,
+		Instance:    instance,
+		Kind:        node.Kind,
+		Syntax:      node.Syntax,
+		Access:      node.Access,
+		Units:       node.Units,
+		Status:      node.Status,
+		Description: node.Description,
+		Index:       node.Index,
+		Augments:    node.Augments,
+	}
+	for _, e := range node.Enums {
+		result.Enums = append(result.Enums, dto.MIBEnumDTO{Value: e.Value, Name: e.Name})
+	}
+	return result, nil
+}
+
+// ListModules returns the loaded modules and the problems found loading them
+func (s *MIBService) ListModules() dto.MIBModuleListResponse {
+	response := dto.MIBModuleListResponse{
+		Modules:  []dto.MIBModuleDTO{},
+		Warnings: []string{},
+	}
+	for _, m := range s.mibs.Modules() {
+		response.Modules = append(response.Modules, dto.MIBModuleDTO{Name: m.Name, File: m.File, Objects: m.Objects})
+	}
+	response.Warnings = append(response.Warnings, s.mibs.Warnings()...)
+	return response
+}
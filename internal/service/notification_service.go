@@ -0,0 +1,461 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/notify"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// NotificationService handles notification channel, route and delivery
+// log business logic
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	dispatcher       *notify.Dispatcher
+	vault            *vault.Vault
+	logger           *zap.Logger
+}
+
+// NewNotificationService creates a new notification service. Channel
+// secrets are encrypted with secrets before they are stored; test sends
+// go through dispatcher.
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	dispatcher *notify.Dispatcher,
+	secrets *vault.Vault,
+	logger *zap.Logger,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		dispatcher:       dispatcher,
+		vault:            secrets,
+		logger:           logger,
+	}
+}
+
+// CreateChannel creates a new notification channel
+func (s *NotificationService) CreateChannel(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, req *dto.CreateNotificationChannelRequest) (dto.NotificationChannelDTO, error) {
+	channel := &models.NotificationChannel{
+		ID:            uuid.New(),
+		TenantID:      tenantID,
+		Name:          req.Name,
+		ChannelType:   req.ChannelType,
+		Config:        string(req.Config),
+		TitleTemplate: nonEmpty(req.TitleTemplate),
+		BodyTemplate:  nonEmpty(req.BodyTemplate),
+		Enabled:       true,
+		CreatedBy:     userID,
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+
+	secret := ""
+	if req.Secret != nil {
+		secret = *req.Secret
+	}
+	if err := s.validateChannel(channel, secret); err != nil {
+		return dto.NotificationChannelDTO{}, err
+	}
+	if err := s.setSecret(channel, secret); err != nil {
+		return dto.NotificationChannelDTO{}, fmt.Errorf("failed to create notification channel")
+	}
+
+	if err := s.notificationRepo.CreateChannel(ctx, channel); err != nil {
+		s.logger.Error("Failed to create notification channel", zap.Error(err))
+		if err.Error() == "notification channel already exists" {
+			return dto.NotificationChannelDTO{}, err
+		}
+		return dto.NotificationChannelDTO{}, fmt.Errorf("failed to create notification channel")
+	}
+
+	s.logger.Info("Notification channel created successfully", zap.String("channel_id", channel.ID.String()))
+
+	return toNotificationChannelDTO(channel), nil
+}
+
+// GetChannel retrieves a notification channel by ID
+func (s *NotificationService) GetChannel(ctx context.Context, tenantID, channelID uuid.UUID) (dto.NotificationChannelDTO, error) {
+	channel, err := s.notificationRepo.GetChannel(ctx, tenantID, channelID)
+	if err != nil {
+		s.logger.Error("Failed to get notification channel", zap.Error(err))
+		return dto.NotificationChannelDTO{}, fmt.Errorf("notification channel not found")
+	}
+
+	return toNotificationChannelDTO(channel), nil
+}
+
+// ListChannels retrieves a list of notification channels
+func (s *NotificationService) ListChannels(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]dto.NotificationChannelDTO, int64, error) {
+	channels, total, err := s.notificationRepo.ListChannels(ctx, tenantID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list notification channels", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list notification channels")
+	}
+
+	channelDTOs := make([]dto.NotificationChannelDTO, len(channels))
+	for i, channel := range channels {
+		channelDTOs[i] = toNotificationChannelDTO(channel)
+	}
+
+	return channelDTOs, total, nil
+}
+
+// UpdateChannel updates an existing notification channel. The stored
+// secret is kept unless one is given.
+func (s *NotificationService) UpdateChannel(ctx context.Context, tenantID, channelID uuid.UUID, req *dto.UpdateNotificationChannelRequest) (dto.NotificationChannelDTO, error) {
+	channel, err := s.notificationRepo.GetChannel(ctx, tenantID, channelID)
+	if err != nil {
+		return dto.NotificationChannelDTO{}, fmt.Errorf("notification channel not found")
+	}
+
+	if req.Name != nil {
+		channel.Name = *req.Name
+	}
+	if req.ChannelType != nil {
+		channel.ChannelType = *req.ChannelType
+	}
+	if req.Config != nil {
+		channel.Config = string(req.Config)
+	}
+	if req.TitleTemplate != nil {
+		channel.TitleTemplate = nonEmpty(req.TitleTemplate)
+	}
+	if req.BodyTemplate != nil {
+		channel.BodyTemplate = nonEmpty(req.BodyTemplate)
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+
+	secret := ""
+	if req.Secret != nil {
+		secret = *req.Secret
+	} else if channel.Secret != nil {
+		if secret, err = s.vault.Decrypt(*channel.Secret); err != nil {
+			s.logger.Error("Failed to decrypt notification channel secret", zap.Error(err))
+			return dto.NotificationChannelDTO{}, fmt.Errorf("failed to update notification channel")
+		}
+	}
+	if err := s.validateChannel(channel, secret); err != nil {
+		return dto.NotificationChannelDTO{}, err
+	}
+	if req.Secret != nil {
+		if err := s.setSecret(channel, secret); err != nil {
+			return dto.NotificationChannelDTO{}, fmt.Errorf("failed to update notification channel")
+		}
+	}
+
+	if err := s.notificationRepo.UpdateChannel(ctx, channel); err != nil {
+		s.logger.Error("Failed to update notification channel", zap.Error(err))
+		switch err.Error() {
+		case "notification channel not found", "notification channel already exists":
+			return dto.NotificationChannelDTO{}, err
+		}
+		return dto.NotificationChannelDTO{}, fmt.Errorf("failed to update notification channel")
+	}
+
+	s.logger.Info("Notification channel updated successfully", zap.String("channel_id", channel.ID.String()))
+
+	return toNotificationChannelDTO(channel), nil
+}
+
+// DeleteChannel deletes a notification channel with its routes and
+// delivery log
+func (s *NotificationService) DeleteChannel(ctx context.Context, tenantID, channelID uuid.UUID) error {
+	if err := s.notificationRepo.DeleteChannel(ctx, tenantID, channelID); err != nil {
+		s.logger.Error("Failed to delete notification channel", zap.Error(err))
+		if err.Error() == "notification channel not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete notification channel")
+	}
+
+	s.logger.Info("Notification channel deleted successfully", zap.String("channel_id", channelID.String()))
+
+	return nil
+}
+
+// TestChannel sends a test notification to a channel at once, without
+// retries, and returns its delivery log entry. A failed send is reported
+// in the entry rather than as an error.
+func (s *NotificationService) TestChannel(ctx context.Context, tenantID, channelID uuid.UUID) (dto.NotificationDeliveryDTO, error) {
+	channel, err := s.notificationRepo.GetChannel(ctx, tenantID, channelID)
+	if err != nil {
+		return dto.NotificationDeliveryDTO{}, fmt.Errorf("notification channel not found")
+	}
+
+	now := time.Now()
+	delivery := &models.NotificationDelivery{
+		TenantID:  tenantID,
+		ChannelID: channelID,
+		Event:     models.NotificationTest,
+		Status:    models.DeliverySent,
+		Attempts:  1,
+		SentAt:    &now,
+	}
+	if err := s.dispatcher.Send(ctx, channel, notify.TestEvent(channel, now), 0); err != nil {
+		msg := err.Error()
+		delivery.Status = models.DeliveryFailed
+		delivery.SentAt = nil
+		delivery.LastError = &msg
+	}
+
+	if err := s.notificationRepo.CreateDelivery(ctx, delivery); err != nil {
+		s.logger.Warn("Failed to record test notification", zap.Error(err))
+		delivery.CreatedAt = now
+	}
+
+	return toNotificationDeliveryDTO(delivery), nil
+}
+
+// CreateRoute creates a new notification route
+func (s *NotificationService) CreateRoute(ctx context.Context, tenantID uuid.UUID, req *dto.CreateNotificationRouteRequest) (dto.NotificationRouteDTO, error) {
+	route := &models.NotificationRoute{
+		ID:             uuid.New(),
+		TenantID:       tenantID,
+		ChannelID:      req.ChannelID,
+		Name:           req.Name,
+		Severities:     req.Severities,
+		RoleCodes:      req.RoleCodes,
+		POPIDs:         req.POPIDs,
+		NotifyResolved: true,
+		Enabled:        true,
+	}
+	if req.NotifyResolved != nil {
+		route.NotifyResolved = *req.NotifyResolved
+	}
+	if req.Enabled != nil {
+		route.Enabled = *req.Enabled
+	}
+
+	if err := s.validateRoute(ctx, route); err != nil {
+		return dto.NotificationRouteDTO{}, err
+	}
+
+	if err := s.notificationRepo.CreateRoute(ctx, route); err != nil {
+		s.logger.Error("Failed to create notification route", zap.Error(err))
+		return dto.NotificationRouteDTO{}, fmt.Errorf("failed to create notification route")
+	}
+
+	s.logger.Info("Notification route created successfully", zap.String("route_id", route.ID.String()))
+
+	return toNotificationRouteDTO(route), nil
+}
+
+// GetRoute retrieves a notification route by ID
+func (s *NotificationService) GetRoute(ctx context.Context, tenantID, routeID uuid.UUID) (dto.NotificationRouteDTO, error) {
+	route, err := s.notificationRepo.GetRoute(ctx, tenantID, routeID)
+	if err != nil {
+		s.logger.Error("Failed to get notification route", zap.Error(err))
+		return dto.NotificationRouteDTO{}, fmt.Errorf("notification route not found")
+	}
+
+	return toNotificationRouteDTO(route), nil
+}
+
+// ListRoutes retrieves a list of notification routes
+func (s *NotificationService) ListRoutes(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]dto.NotificationRouteDTO, int64, error) {
+	routes, total, err := s.notificationRepo.ListRoutes(ctx, tenantID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list notification routes", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list notification routes")
+	}
+
+	routeDTOs := make([]dto.NotificationRouteDTO, len(routes))
+	for i, route := range routes {
+		routeDTOs[i] = toNotificationRouteDTO(route)
+	}
+
+	return routeDTOs, total, nil
+}
+
+// UpdateRoute updates an existing notification route; deliveries already
+// queued are unaffected
+func (s *NotificationService) UpdateRoute(ctx context.Context, tenantID, routeID uuid.UUID, req *dto.UpdateNotificationRouteRequest) (dto.NotificationRouteDTO, error) {
+	route, err := s.notificationRepo.GetRoute(ctx, tenantID, routeID)
+	if err != nil {
+		return dto.NotificationRouteDTO{}, fmt.Errorf("notification route not found")
+	}
+
+	if req.ChannelID != nil {
+		route.ChannelID = *req.ChannelID
+	}
+	if req.Name != nil {
+		route.Name = *req.Name
+	}
+	if req.Severities != nil {
+		route.Severities = req.Severities
+	}
+	if req.RoleCodes != nil {
+		route.RoleCodes = req.RoleCodes
+	}
+	if req.POPIDs != nil {
+		route.POPIDs = req.POPIDs
+	}
+	if req.NotifyResolved != nil {
+		route.NotifyResolved = *req.NotifyResolved
+	}
+	if req.Enabled != nil {
+		route.Enabled = *req.Enabled
+	}
+
+	if err := s.validateRoute(ctx, route); err != nil {
+		return dto.NotificationRouteDTO{}, err
+	}
+
+	if err := s.notificationRepo.UpdateRoute(ctx, route); err != nil {
+		s.logger.Error("Failed to update notification route", zap.Error(err))
+		if err.Error() == "notification route not found" {
+			return dto.NotificationRouteDTO{}, err
+		}
+		return dto.NotificationRouteDTO{}, fmt.Errorf("failed to update notification route")
+	}
+
+	s.logger.Info("Notification route updated successfully", zap.String("route_id", route.ID.String()))
+
+	return toNotificationRouteDTO(route), nil
+}
+
+// DeleteRoute deletes a notification route
+func (s *NotificationService) DeleteRoute(ctx context.Context, tenantID, routeID uuid.UUID) error {
+	if err := s.notificationRepo.DeleteRoute(ctx, tenantID, routeID); err != nil {
+		s.logger.Error("Failed to delete notification route", zap.Error(err))
+		if err.Error() == "notification route not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete notification route")
+	}
+
+	s.logger.Info("Notification route deleted successfully", zap.String("route_id", routeID.String()))
+
+	return nil
+}
+
+// ListDeliveries retrieves the delivery log, latest first
+func (s *NotificationService) ListDeliveries(ctx context.Context, tenantID uuid.UUID, filter repository.NotificationDeliveryFilter, opts repository.ListOptions) ([]dto.NotificationDeliveryDTO, int64, error) {
+	deliveries, total, err := s.notificationRepo.ListDeliveries(ctx, tenantID, filter, opts)
+	if err != nil {
+		s.logger.Error("Failed to list notification deliveries", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list notification deliveries")
+	}
+
+	deliveryDTOs := make([]dto.NotificationDeliveryDTO, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryDTOs[i] = toNotificationDeliveryDTO(delivery)
+	}
+
+	return deliveryDTOs, total, nil
+}
+
+// validateChannel defaults an empty config and checks the channel's config,
+// secret and templates
+func (s *NotificationService) validateChannel(channel *models.NotificationChannel, secret string) error {
+	if channel.Config == "" || channel.Config == "null" {
+		channel.Config = "{}"
+	}
+	if err := notify.Validate(channel, secret); err != nil {
+		return fmt.Errorf("invalid notification channel: %v", err)
+	}
+	return nil
+}
+
+// setSecret encrypts a channel's secret, clearing it when empty
+func (s *NotificationService) setSecret(channel *models.NotificationChannel, secret string) error {
+	if secret == "" {
+		channel.Secret = nil
+		return nil
+	}
+	encrypted, err := s.vault.Encrypt(secret)
+	if err != nil {
+		s.logger.Error("Failed to encrypt notification channel secret", zap.Error(err))
+		return err
+	}
+	channel.Secret = &encrypted
+	return nil
+}
+
+// validateRoute checks that a route's channel exists
+func (s *NotificationService) validateRoute(ctx context.Context, route *models.NotificationRoute) error {
+	if _, err := s.notificationRepo.GetChannel(ctx, route.TenantID, route.ChannelID); err != nil {
+		if err.Error() == "notification channel not found" {
+			return fmt.Errorf("notification channel %s not found", route.ChannelID)
+		}
+		s.logger.Error("Failed to check notification route channel", zap.Error(err))
+		return fmt.Errorf("failed to check notification route channel")
+	}
+	return nil
+}
+
+// nonEmpty returns value, or nil when it is nil or empty
+func nonEmpty(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
+
+func toNotificationChannelDTO(channel *models.NotificationChannel) dto.NotificationChannelDTO {
+	return dto.NotificationChannelDTO{
+		ID:            channel.ID,
+		TenantID:      channel.TenantID,
+		Name:          channel.Name,
+		ChannelType:   channel.ChannelType,
+		Config:        json.RawMessage(channel.Config),
+		SecretSet:     channel.Secret != nil && *channel.Secret != "",
+		TitleTemplate: channel.TitleTemplate,
+		BodyTemplate:  channel.BodyTemplate,
+		Enabled:       channel.Enabled,
+		CreatedBy:     channel.CreatedBy,
+		CreatedAt:     channel.CreatedAt,
+		UpdatedAt:     channel.UpdatedAt,
+	}
+}
+
+func toNotificationRouteDTO(route *models.NotificationRoute) dto.NotificationRouteDTO {
+	routeDTO := dto.NotificationRouteDTO{
+		ID:             route.ID,
+		TenantID:       route.TenantID,
+		ChannelID:      route.ChannelID,
+		Name:           route.Name,
+		Severities:     route.Severities,
+		RoleCodes:      route.RoleCodes,
+		POPIDs:         nonNilIDs(route.POPIDs),
+		NotifyResolved: route.NotifyResolved,
+		Enabled:        route.Enabled,
+		CreatedAt:      route.CreatedAt,
+		UpdatedAt:      route.UpdatedAt,
+	}
+	if routeDTO.Severities == nil {
+		routeDTO.Severities = []string{}
+	}
+	if routeDTO.RoleCodes == nil {
+		routeDTO.RoleCodes = []string{}
+	}
+	return routeDTO
+}
+
+func toNotificationDeliveryDTO(delivery *models.NotificationDelivery) dto.NotificationDeliveryDTO {
+	return dto.NotificationDeliveryDTO{
+		ID:            delivery.ID,
+		ChannelID:     delivery.ChannelID,
+		AlertID:       delivery.AlertID,
+		Event:         delivery.Event,
+		Tier:          delivery.Tier,
+		RecipientID:   delivery.RecipientID,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		LastError:     delivery.LastError,
+		SentAt:        delivery.SentAt,
+		CreatedAt:     delivery.CreatedAt,
+	}
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/notify"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/oncall"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultShiftRange is how far ahead shifts are listed by default
+	defaultShiftRange = 14 * 24 * time.Hour
+
+	// maxShiftRange is the longest range shifts are listed over
+	maxShiftRange = 92 * 24 * time.Hour
+)
+
+// OnCallService handles on-call schedule and override business logic
+type OnCallService struct {
+	oncallRepo repository.OnCallRepository
+	userRepo   repository.UserRepository
+	logger     *zap.Logger
+}
+
+// NewOnCallService creates a new on-call service
+func NewOnCallService(
+	oncallRepo repository.OnCallRepository,
+	userRepo repository.UserRepository,
+	logger *zap.Logger,
+) *OnCallService {
+	return &OnCallService{
+		oncallRepo: oncallRepo,
+		userRepo:   userRepo,
+		logger:     logger,
+	}
+}
+
+// CreateSchedule creates a new on-call schedule
+func (s *OnCallService) CreateSchedule(ctx context.Context, tenantID uuid.UUID, userID *uuid.UUID, req *dto.CreateOnCallScheduleRequest) (dto.OnCallScheduleDTO, error) {
+	schedule := &models.OnCallSchedule{
+		ID:            uuid.New(),
+		TenantID:      tenantID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Timezone:      req.Timezone,
+		Participants:  req.Participants,
+		RotationStart: req.RotationStart,
+		RotationDays:  req.RotationDays,
+		Coverage:      string(req.Coverage),
+		CreatedBy:     userID,
+	}
+	if schedule.RotationDays == 0 {
+		schedule.RotationDays = 7
+	}
+
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return dto.OnCallScheduleDTO{}, err
+	}
+
+	if err := s.oncallRepo.CreateSchedule(ctx, schedule); err != nil {
+		s.logger.Error("Failed to create on-call schedule", zap.Error(err))
+		if err.Error() == "on-call schedule already exists" {
+			return dto.OnCallScheduleDTO{}, err
+		}
+		return dto.OnCallScheduleDTO{}, fmt.Errorf("failed to create on-call schedule")
+	}
+
+	s.logger.Info("On-call schedule created successfully", zap.String("schedule_id", schedule.ID.String()))
+
+	return toOnCallScheduleDTO(schedule), nil
+}
+
+// GetSchedule retrieves an on-call schedule by ID with the user on call now
+func (s *OnCallService) GetSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) (dto.OnCallScheduleDTO, error) {
+	schedule, err := s.oncallRepo.GetSchedule(ctx, tenantID, scheduleID)
+	if err != nil {
+		s.logger.Error("Failed to get on-call schedule", zap.Error(err))
+		return dto.OnCallScheduleDTO{}, fmt.Errorf("on-call schedule not found")
+	}
+
+	scheduleDTO := toOnCallScheduleDTO(schedule)
+	onCall, err := notify.OnCall(ctx, s.oncallRepo, tenantID, scheduleID, time.Now())
+	if err != nil {
+		s.logger.Warn("Failed to resolve on-call user", zap.Error(err))
+	}
+	scheduleDTO.OnCall = onCall
+
+	return scheduleDTO, nil
+}
+
+// ListSchedules retrieves a list of on-call schedules
+func (s *OnCallService) ListSchedules(ctx context.Context, tenantID uuid.UUID, opts repository.ListOptions) ([]dto.OnCallScheduleDTO, int64, error) {
+	schedules, total, err := s.oncallRepo.ListSchedules(ctx, tenantID, opts)
+	if err != nil {
+		s.logger.Error("Failed to list on-call schedules", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list on-call schedules")
+	}
+
+	scheduleDTOs := make([]dto.OnCallScheduleDTO, len(schedules))
+	for i, schedule := range schedules {
+		scheduleDTOs[i] = toOnCallScheduleDTO(schedule)
+	}
+
+	return scheduleDTOs, total, nil
+}
+
+// UpdateSchedule updates an existing on-call schedule; the rotation is
+// recomputed from the new settings, overrides are kept
+func (s *OnCallService) UpdateSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID, req *dto.UpdateOnCallScheduleRequest) (dto.OnCallScheduleDTO, error) {
+	schedule, err := s.oncallRepo.GetSchedule(ctx, tenantID, scheduleID)
+	if err != nil {
+		return dto.OnCallScheduleDTO{}, fmt.Errorf("on-call schedule not found")
+	}
+
+	if req.Name != nil {
+		schedule.Name = *req.Name
+	}
+	if req.Description != nil {
+		schedule.Description = nonEmpty(req.Description)
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = *req.Timezone
+	}
+	if req.Participants != nil {
+		schedule.Participants = req.Participants
+	}
+	if req.RotationStart != nil {
+		schedule.RotationStart = *req.RotationStart
+	}
+	if req.RotationDays != nil {
+		schedule.RotationDays = *req.RotationDays
+	}
+	if req.Coverage != nil {
+		schedule.Coverage = string(req.Coverage)
+	}
+
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return dto.OnCallScheduleDTO{}, err
+	}
+
+	if err := s.oncallRepo.UpdateSchedule(ctx, schedule); err != nil {
+		s.logger.Error("Failed to update on-call schedule", zap.Error(err))
+		switch err.Error() {
+		case "on-call schedule not found", "on-call schedule already exists":
+			return dto.OnCallScheduleDTO{}, err
+		}
+		return dto.OnCallScheduleDTO{}, fmt.Errorf("failed to update on-call schedule")
+	}
+
+	s.logger.Info("On-call schedule updated successfully", zap.String("schedule_id", schedule.ID.String()))
+
+	return toOnCallScheduleDTO(schedule), nil
+}
+
+// DeleteSchedule deletes an on-call schedule with its overrides.
+// Escalation targets paging it page nobody from then on.
+func (s *OnCallService) DeleteSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) error {
+	if err := s.oncallRepo.DeleteSchedule(ctx, tenantID, scheduleID); err != nil {
+		s.logger.Error("Failed to delete on-call schedule", zap.Error(err))
+		if err.Error() == "on-call schedule not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete on-call schedule")
+	}
+
+	s.logger.Info("On-call schedule deleted successfully", zap.String("schedule_id", scheduleID.String()))
+
+	return nil
+}
+
+// CreateOverride puts a user on call for a schedule over a period
+func (s *OnCallService) CreateOverride(ctx context.Context, tenantID, scheduleID uuid.UUID, userID *uuid.UUID, req *dto.CreateOnCallOverrideRequest) (dto.OnCallOverrideDTO, error) {
+	if _, err := s.oncallRepo.GetSchedule(ctx, tenantID, scheduleID); err != nil {
+		return dto.OnCallOverrideDTO{}, fmt.Errorf("on-call schedule not found")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return dto.OnCallOverrideDTO{}, fmt.Errorf("invalid on-call override: ends_at must be after starts_at")
+	}
+	if err := s.checkUser(ctx, tenantID, req.UserID); err != nil {
+		return dto.OnCallOverrideDTO{}, err
+	}
+
+	override := &models.OnCallOverride{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		ScheduleID: scheduleID,
+		UserID:     req.UserID,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		Note:       nonEmpty(req.Note),
+		CreatedBy:  userID,
+	}
+	if err := s.oncallRepo.CreateOverride(ctx, override); err != nil {
+		s.logger.Error("Failed to create on-call override", zap.Error(err))
+		return dto.OnCallOverrideDTO{}, fmt.Errorf("failed to create on-call override")
+	}
+
+	s.logger.Info("On-call override created successfully",
+		zap.String("schedule_id", scheduleID.String()),
+		zap.String("override_id", override.ID.String()))
+
+	return toOnCallOverrideDTO(override), nil
+}
+
+// ListOverrides retrieves the overrides of a schedule overlapping a range,
+// by start; the range defaults to the next two weeks
+func (s *OnCallService) ListOverrides(ctx context.Context, tenantID, scheduleID uuid.UUID, from, to *time.Time) ([]dto.OnCallOverrideDTO, error) {
+	if _, err := s.oncallRepo.GetSchedule(ctx, tenantID, scheduleID); err != nil {
+		return nil, fmt.Errorf("on-call schedule not found")
+	}
+	start, end, err := shiftRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.oncallRepo.ListOverrides(ctx, tenantID, scheduleID, start, end)
+	if err != nil {
+		s.logger.Error("Failed to list on-call overrides", zap.Error(err))
+		return nil, fmt.Errorf("failed to list on-call overrides")
+	}
+
+	overrideDTOs := make([]dto.OnCallOverrideDTO, len(overrides))
+	for i, override := range overrides {
+		overrideDTOs[i] = toOnCallOverrideDTO(override)
+	}
+
+	return overrideDTOs, nil
+}
+
+// DeleteOverride deletes an override of an on-call schedule
+func (s *OnCallService) DeleteOverride(ctx context.Context, tenantID, scheduleID, overrideID uuid.UUID) error {
+	if err := s.oncallRepo.DeleteOverride(ctx, tenantID, scheduleID, overrideID); err != nil {
+		s.logger.Error("Failed to delete on-call override", zap.Error(err))
+		if err.Error() == "on-call override not found" {
+			return err
+		}
+		return fmt.Errorf("failed to delete on-call override")
+	}
+
+	s.logger.Info("On-call override deleted successfully", zap.String("override_id", overrideID.String()))
+
+	return nil
+}
+
+// ListShifts returns who is on call for a schedule over a range, overrides
+// applied; the range defaults to the next two weeks
+func (s *OnCallService) ListShifts(ctx context.Context, tenantID, scheduleID uuid.UUID, from, to *time.Time) ([]dto.OnCallShiftDTO, error) {
+	schedule, err := s.oncallRepo.GetSchedule(ctx, tenantID, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("on-call schedule not found")
+	}
+	start, end, err := shiftRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.oncallRepo.ListOverrides(ctx, tenantID, scheduleID, start, end)
+	if err != nil {
+		s.logger.Error("Failed to list on-call overrides", zap.Error(err))
+		return nil, fmt.Errorf("failed to list on-call shifts")
+	}
+	sched, err := oncall.New(schedule, overrides)
+	if err != nil {
+		s.logger.Error("Failed to load on-call schedule", zap.Error(err))
+		return nil, fmt.Errorf("failed to list on-call shifts")
+	}
+
+	names := make(map[uuid.UUID]string)
+	shifts := sched.Shifts(start, end)
+	shiftDTOs := make([]dto.OnCallShiftDTO, len(shifts))
+	for i, shift := range shifts {
+		name, ok := names[shift.UserID]
+		if !ok {
+			if user, err := s.userRepo.GetByID(ctx, shift.UserID); err == nil {
+				name = notify.UserName(user)
+			}
+			names[shift.UserID] = name
+		}
+		shiftDTOs[i] = dto.OnCallShiftDTO{
+			UserID:   shift.UserID,
+			UserName: name,
+			Start:    shift.Start,
+			End:      shift.End,
+			Override: shift.Override,
+		}
+	}
+
+	return shiftDTOs, nil
+}
+
+// validateSchedule defaults an empty timezone and coverage and checks the
+// schedule's rotation, coverage and participants
+func (s *OnCallService) validateSchedule(ctx context.Context, schedule *models.OnCallSchedule) error {
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if schedule.Coverage == "" || schedule.Coverage == "null" {
+		schedule.Coverage = "[]"
+	}
+	if len(schedule.Participants) == 0 {
+		return fmt.Errorf("invalid on-call schedule: participants are required")
+	}
+	if err := oncall.Validate(schedule); err != nil {
+		return fmt.Errorf("invalid on-call schedule: %v", err)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(schedule.Participants))
+	for _, userID := range schedule.Participants {
+		if seen[userID] {
+			return fmt.Errorf("invalid on-call schedule: participant %s is listed twice", userID)
+		}
+		seen[userID] = true
+		if err := s.checkUser(ctx, schedule.TenantID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUser checks that a user exists in the tenant
+func (s *OnCallService) checkUser(ctx context.Context, tenantID, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return fmt.Errorf("user %s not found", userID)
+	}
+	return nil
+}
+
+// shiftRange applies the defaults and limits of a schedule lookup range
+func shiftRange(from, to *time.Time) (time.Time, time.Time, error) {
+	start := time.Now()
+	if from != nil {
+		start = *from
+	}
+	end := start.Add(defaultShiftRange)
+	if to != nil {
+		end = *to
+	}
+
+	if !end.After(start) {
+		return start, end, fmt.Errorf("invalid time range: to must be after from")
+	}
+	if end.Sub(start) > maxShiftRange {
+		return start, end, fmt.Errorf("invalid time range: at most %d days", int(maxShiftRange.Hours()/24))
+	}
+	return start, end, nil
+}
+
+func toOnCallScheduleDTO(schedule *models.OnCallSchedule) dto.OnCallScheduleDTO {
+	return dto.OnCallScheduleDTO{
+		ID:            schedule.ID,
+		TenantID:      schedule.TenantID,
+		Name:          schedule.Name,
+		Description:   schedule.Description,
+		Timezone:      schedule.Timezone,
+		Participants:  nonNilIDs(schedule.Participants),
+		RotationStart: schedule.RotationStart,
+		RotationDays:  schedule.RotationDays,
+		Coverage:      json.RawMessage(schedule.Coverage),
+		CreatedBy:     schedule.CreatedBy,
+		CreatedAt:     schedule.CreatedAt,
+		UpdatedAt:     schedule.UpdatedAt,
+	}
+}
+
+func toOnCallOverrideDTO(override *models.OnCallOverride) dto.OnCallOverrideDTO {
+	return dto.OnCallOverrideDTO{
+		ID:         override.ID,
+		ScheduleID: override.ScheduleID,
+		UserID:     override.UserID,
+		StartsAt:   override.StartsAt,
+		EndsAt:     override.EndsAt,
+		Note:       override.Note,
+		CreatedBy:  override.CreatedBy,
+		CreatedAt:  override.CreatedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// routerPoller is what the services need of the poller for on-demand polls
+// and checks of routers
+type routerPoller interface {
+	PollNow(ctx context.Context, routerID uuid.UUID) (*adapter.PollResult, error)
+	HealthCheck(ctx context.Context, routerID uuid.UUID, adapterName string) (*poller.HealthResult, error)
+	CheckCredentials(ctx context.Context, routerID uuid.UUID, method string) (*poller.HealthResult, error)
+}
+
+// PollerService handles on-demand polls and health checks of routers
+type PollerService struct {
+	routerRepo repository.RouterRepository
+	poller     routerPoller
+	timeout    time.Duration
+	logger     *zap.Logger
+}
+
+// NewPollerService creates a new poller service; requests give up after
+// timeout
+func NewPollerService(routerRepo repository.RouterRepository, pollerService *poller.EnhancedService, timeout time.Duration, logger *zap.Logger) *PollerService {
+	return &PollerService{
+		routerRepo: routerRepo,
+		poller:     pollerService,
+		timeout:    timeout,
+		logger:     logger,
+	}
+}
+
+// PollNow polls a router of the tenant and waits for the result
+func (s *PollerService) PollNow(ctx context.Context, tenantID, routerID uuid.UUID) (*dto.PollResultDTO, error) {
+	if _, err := s.routerRepo.GetByID(ctx, tenantID, routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result, err := s.poller.PollNow(ctx, routerID)
+	if err != nil {
+		return nil, s.pollerError("Failed to poll router", routerID, err)
+	}
+
+	return toPollResultDTO(result), nil
+}
+
+// HealthCheck checks connectivity to a router of the tenant through the
+// named adapter, or the router's adapters in fallback order
+func (s *PollerService) HealthCheck(ctx context.Context, tenantID, routerID uuid.UUID, adapterName string) (*dto.RouterHealthDTO, error) {
+	if _, err := s.routerRepo.GetByID(ctx, tenantID, routerID); err != nil {
+		return nil, fmt.Errorf("router not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result, err := s.poller.HealthCheck(ctx, routerID, adapterName)
+	if err != nil {
+		return nil, s.pollerError("Failed to health check router", routerID, err)
+	}
+
+	return &dto.RouterHealthDTO{
+		RouterID:       result.RouterID,
+		Adapter:        result.Adapter,
+		Healthy:        result.Healthy,
+		ErrorMessage:   result.ErrorMessage,
+		ResponseTimeMs: result.ResponseTimeMs,
+		CheckedAt:      result.CheckedAt,
+	}, nil
+}
+
+// pollerError maps poller errors to the messages handlers respond with
+func (s *PollerService) pollerError(msg string, routerID uuid.UUID, err error) error {
+	switch {
+	case errors.Is(err, poller.ErrRouterNotFound):
+		return fmt.Errorf("router not found")
+	case errors.Is(err, poller.ErrQueueFull):
+		return fmt.Errorf("poll queue full")
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("poll timed out after %s", s.timeout)
+	case errors.Is(err, poller.ErrUnknownAdapter), errors.Is(err, poller.ErrAdapterNotApplicable):
+		return fmt.Errorf("invalid adapter: %w", err)
+	}
+
+	s.logger.Error(msg, zap.String("router_id", routerID.String()), zap.Error(err))
+	return fmt.Errorf("failed to poll router")
+}
+
+func toPollResultDTO(result *adapter.PollResult) *dto.PollResultDTO {
+	interfaces := make([]dto.PolledInterfaceDTO, len(result.Interfaces))
+	for i, iface := range result.Interfaces {
+		interfaces[i] = dto.PolledInterfaceDTO{
+			Name:        iface.Name,
+			IfIndex:     iface.IfIndex,
+			Status:      iface.Status,
+			AdminStatus: iface.AdminStatus,
+			SpeedMbps:   iface.Speed,
+			InOctets:    iface.InOctets,
+			OutOctets:   iface.OutOctets,
+			InErrors:    iface.InErrors,
+			OutErrors:   iface.OutErrors,
+		}
+	}
+
+	metricErrors := make([]string, len(result.Metrics.Errors()))
+	for i, err := range result.Metrics.Errors() {
+		metricErrors[i] = err.Error()
+	}
+
+	return &dto.PollResultDTO{
+		RouterID:       result.RouterID,
+		Success:        result.Success,
+		AdapterUsed:    result.AdapterUsed,
+		Timestamp:      result.Timestamp,
+		ResponseTimeMs: result.ResponseTimeMs,
+		ErrorMessage:   result.ErrorMessage,
+		Metrics:        result.Metrics.Map(),
+		MetricErrors:   metricErrors,
+		Interfaces:     interfaces,
+		PPPoESessions:  len(result.PPPoESessions),
+		NATSessions:    len(result.NATSessions),
+		DHCPLeases:     len(result.DHCPLeases),
+	}
+}
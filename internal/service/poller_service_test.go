@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/poller/adapter"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeRouters is a router repository holding routers in memory
+type fakeRouters struct {
+	routers map[uuid.UUID]*models.Router
+}
+
+func newFakeRouters(routers ...*models.Router) *fakeRouters {
+	r := &fakeRouters{routers: make(map[uuid.UUID]*models.Router)}
+	for _, router := range routers {
+		r.routers[router.ID] = router
+	}
+	return r
+}
+
+func (r *fakeRouters) GetByID(_ context.Context, tenantID, routerID uuid.UUID) (*models.Router, error) {
+	router, ok := r.routers[routerID]
+	if !ok || router.TenantID != tenantID {
+		return nil, fmt.Errorf("router not found")
+	}
+	return router, nil
+}
+
+func (r *fakeRouters) Create(context.Context, *models.Router) error {
+	return nil
+}
+
+func (r *fakeRouters) Update(context.Context, *models.Router) error {
+	return nil
+}
+
+func (r *fakeRouters) Delete(context.Context, uuid.UUID, uuid.UUID) error {
+	return nil
+}
+
+func (r *fakeRouters) List(context.Context, uuid.UUID, repository.ListOptions) ([]*models.Router, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeRouters) GetRoleCodes(context.Context, uuid.UUID, uuid.UUID) ([]string, error) {
+	return nil, nil
+}
+
+// fakePoller answers polls with err, and credential checks per router with
+// the outcome in checks after delay: nil passes, poller.ErrAdapterNotApplicable
+// and context.DeadlineExceeded are returned, other errors fail the check
+type fakePoller struct {
+	err    error
+	checks map[uuid.UUID]error
+	delay  time.Duration
+
+	mu          sync.Mutex
+	polled      []uuid.UUID
+	inFlight    int
+	maxInFlight int
+}
+
+func (p *fakePoller) PollNow(_ context.Context, routerID uuid.UUID) (*adapter.PollResult, error) {
+	p.mu.Lock()
+	p.polled = append(p.polled, routerID)
+	p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	result := adapter.NewPollResult(routerID, uuid.Nil, "snmp")
+	result.Success = true
+	return result, nil
+}
+
+func (p *fakePoller) HealthCheck(ctx context.Context, routerID uuid.UUID, adapterName string) (*poller.HealthResult, error) {
+	return p.CheckCredentials(ctx, routerID, adapterName)
+}
+
+func (p *fakePoller) CheckCredentials(_ context.Context, routerID uuid.UUID, method string) (*poller.HealthResult, error) {
+	p.mu.Lock()
+	p.polled = append(p.polled, routerID)
+	p.inFlight++
+	p.maxInFlight = max(p.maxInFlight, p.inFlight)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.inFlight--
+		p.mu.Unlock()
+	}()
+	time.Sleep(p.delay)
+
+	result := &poller.HealthResult{RouterID: routerID, Adapter: method, CheckedAt: time.Now()}
+	switch err := p.checks[routerID]; {
+	case err == nil:
+		result.Healthy = true
+	case errors.Is(err, poller.ErrAdapterNotApplicable), errors.Is(err, context.DeadlineExceeded):
+		return nil, err
+	default:
+		result.ErrorMessage = err.Error()
+	}
+	return result, nil
+}
+
+func TestPollNowChecksTenant(t *testing.T) {
+	router := &models.Router{ID: uuid.New(), TenantID: uuid.New(), Name: "core-1"}
+	p := &fakePoller{}
+	s := NewPollerService(newFakeRouters(router), nil, time.Second, zap.NewNop())
+	s.poller = p
+
+	if _, err := s.PollNow(context.Background(), uuid.New(), router.ID); err == nil || err.Error() != "router not found" {
+		t.Fatalf("poll of another tenant's router: err = %v, want router not found", err)
+	}
+	if len(p.polled) != 0 {
+		t.Fatalf("router of another tenant was polled")
+	}
+
+	result, err := s.PollNow(context.Background(), router.TenantID, router.ID)
+	if err != nil {
+		t.Fatalf("PollNow: %v", err)
+	}
+	if !result.Success || result.RouterID != router.ID {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestPollNowErrors(t *testing.T) {
+	router := &models.Router{ID: uuid.New(), TenantID: uuid.New(), Name: "core-1"}
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{poller.ErrQueueFull, "poll queue full"},
+		{context.DeadlineExceeded, "poll timed out after 1s"},
+		{poller.ErrRouterNotFound, "router not found"},
+		{fmt.Errorf("connection refused"), "failed to poll router"},
+	}
+	for _, tt := range tests {
+		s := NewPollerService(newFakeRouters(router), nil, time.Second, zap.NewNop())
+		s.poller = &fakePoller{err: tt.err}
+
+		_, err := s.PollNow(context.Background(), router.TenantID, router.ID)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("poller error %v: err = %v, want %q", tt.err, err, tt.want)
+		}
+	}
+}
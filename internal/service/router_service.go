@@ -6,6 +6,7 @@ import (
 
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
 	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -14,16 +15,20 @@ import (
 // RouterService handles router business logic
 type RouterService struct {
 	routerRepo repository.RouterRepository
+	vault      *vault.Vault
 	logger     *zap.Logger
 }
 
-// NewRouterService creates a new router service
+// NewRouterService creates a new router service; SNMP communities are
+// encrypted with secrets before they are stored
 func NewRouterService(
 	routerRepo repository.RouterRepository,
+	secrets *vault.Vault,
 	logger *zap.Logger,
 ) *RouterService {
 	return &RouterService{
 		routerRepo: routerRepo,
+		vault:      secrets,
 		logger:     logger,
 	}
 }
@@ -65,7 +70,12 @@ func (s *RouterService) CreateRouter(ctx context.Context, tenantID uuid.UUID, re
 		router.SNMPVersion = "v2c"
 	}
 	if req.SNMPCommunity != nil {
-		router.SNMPCommunity = req.SNMPCommunity
+		community, err := s.vault.Encrypt(*req.SNMPCommunity)
+		if err != nil {
+			s.logger.Error("Failed to encrypt SNMP community", zap.Error(err))
+			return dto.RouterDTO{}, fmt.Errorf("failed to create router")
+		}
+		router.SNMPCommunity = &community
 	}
 
 	if err := s.routerRepo.Create(ctx, router); err != nil {
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/api/dto"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/syslog"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SyslogService handles syslog search
+type SyslogService struct {
+	syslogRepo repository.SyslogRepository
+	logger     *zap.Logger
+}
+
+// NewSyslogService creates a new syslog service
+func NewSyslogService(syslogRepo repository.SyslogRepository, logger *zap.Logger) *SyslogService {
+	return &SyslogService{
+		syslogRepo: syslogRepo,
+		logger:     logger,
+	}
+}
+
+// Search retrieves the tenant's syslog messages matching the filter
+func (s *SyslogService) Search(ctx context.Context, tenantID uuid.UUID, filter repository.SyslogFilter, opts repository.ListOptions) ([]dto.SyslogMessageDTO, int64, error) {
+	messages, total, err := s.syslogRepo.Search(ctx, tenantID, filter, opts)
+	if err != nil {
+		s.logger.Error("Failed to search syslog messages", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to search syslog messages")
+	}
+
+	messageDTOs := make([]dto.SyslogMessageDTO, len(messages))
+	for i, m := range messages {
+		messageDTOs[i] = dto.SyslogMessageDTO{
+			ID:             m.ID,
+			RouterID:       m.RouterID,
+			RouterName:     m.RouterName,
+			SourceIP:       m.SourceIP,
+			Facility:       syslog.FacilityName(m.Facility),
+			Severity:       syslog.SeverityName(m.Severity),
+			SeverityLevel:  m.Severity,
+			Hostname:       derefString(m.Hostname),
+			AppName:        derefString(m.AppName),
+			ProcID:         derefString(m.ProcID),
+			MsgID:          derefString(m.MsgID),
+			StructuredData: derefString(m.StructuredData),
+			Message:        m.Message,
+			DeviceTime:     m.DeviceTime,
+			ReceivedAt:     m.ReceivedAt,
+		}
+	}
+
+	return messageDTOs, total, nil
+}
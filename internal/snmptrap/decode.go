@@ -0,0 +1,385 @@
+// Package snmptrap receives SNMP traps and informs from routers. Standard
+// traps (coldStart, warmStart, linkDown, linkUp, authenticationFailure), BGP
+// state changes and known vendor traps are decoded into device events;
+// link traps update interface status immediately, and failure/recovery pairs
+// raise and clear alerts. Other traps and varbinds are named with the loaded
+// MIBs.
+package snmptrap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/gosnmp/gosnmp"
+)
+
+// Well-known OIDs
+const (
+	oidSysUpTime    = "1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID  = "1.3.6.1.6.3.1.1.4.1.0"
+	oidSnmpTraps    = "1.3.6.1.6.3.1.1.5" // coldStart(1) .. authenticationFailure(5)
+	oidIfIndex      = "1.3.6.1.2.1.2.2.1.1."
+	oidIfDescr      = "1.3.6.1.2.1.2.2.1.2."
+	oidIfAdmin      = "1.3.6.1.2.1.2.2.1.7."
+	oidIfOper       = "1.3.6.1.2.1.2.2.1.8."
+	oidIfName       = "1.3.6.1.2.1.31.1.1.1.1."
+	oidBGPPeerEntry = "1.3.6.1.2.1.15.3.1."
+)
+
+// Trap is a received trap or inform, copied out of the SNMP packet
+type Trap struct {
+	Version   string // v1, v2c, v3
+	Community string
+	User      string // v3 user name
+	Auth      bool   // v3 message authenticated
+	Priv      bool   // v3 message encrypted
+	Inform    bool
+	TrapOID   string // snmpTrapOID, or the RFC 3584 translation of a v1 trap
+	TrapName  string // MIB name of TrapOID, set by Decorate
+	Agent     string // v1 agent-addr, empty for v2c/v3
+	Uptime    uint32 // sysUpTime in hundredths of a second
+	Varbinds  []Varbind
+	Received  time.Time
+}
+
+// Varbind is a trap variable with its value rendered as text
+type Varbind struct {
+	OID       string `json:"oid"`
+	Name      string `json:"name,omitempty"` // MODULE::object.instance, set by Decorate
+	Value     string `json:"value"`
+	ValueName string `json:"value_name,omitempty"` // Enumeration label or MIB name of the value
+}
+
+// FromPacket copies a decoded packet into a Trap
+func FromPacket(packet *gosnmp.SnmpPacket, received time.Time) *Trap {
+	trap := &Trap{
+		Community: packet.Community,
+		Inform:    packet.PDUType == gosnmp.InformRequest,
+		Received:  received,
+	}
+
+	switch packet.Version {
+	case gosnmp.Version1:
+		trap.Version = "v1"
+	case gosnmp.Version2c:
+		trap.Version = "v2c"
+	default:
+		// USM verifies what the message flags claim; they are kept to
+		// check against the level the user is configured for
+		trap.Version = "v3"
+		if usm, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok {
+			trap.User = usm.UserName
+		}
+		trap.Auth = packet.MsgFlags&gosnmp.AuthNoPriv != 0
+		trap.Priv = packet.MsgFlags&gosnmp.AuthPriv == gosnmp.AuthPriv
+	}
+
+	if packet.PDUType == gosnmp.Trap {
+		// RFC 3584 section 3.1: generic traps map to snmpTraps.N+1,
+		// enterprise-specific traps to enterprise.0.specific
+		trap.Agent = packet.AgentAddress
+		trap.Uptime = uint32(packet.Timestamp)
+		if packet.GenericTrap == 6 {
+			trap.TrapOID = normalizeOID(packet.Enterprise) + ".0." + strconv.Itoa(packet.SpecificTrap)
+		} else {
+			trap.TrapOID = oidSnmpTraps + "." + strconv.Itoa(packet.GenericTrap+1)
+		}
+	}
+
+	for _, pdu := range packet.Variables {
+		oid := normalizeOID(pdu.Name)
+		switch oid {
+		case oidSysUpTime:
+			if ticks, ok := pdu.Value.(uint32); ok {
+				trap.Uptime = ticks
+			}
+			continue
+		case oidSnmpTrapOID:
+			if value, ok := pdu.Value.(string); ok {
+				trap.TrapOID = normalizeOID(value)
+			}
+			continue
+		}
+		trap.Varbinds = append(trap.Varbinds, Varbind{OID: oid, Value: formatValue(pdu)})
+	}
+
+	return trap
+}
+
+// Decorate names the trap OID and varbinds from mibs: varbind OIDs by the
+// object they are an instance of, enumerated values by label and OID values
+// by the node they name
+func Decorate(trap *Trap, mibs *mib.Tree) {
+	if mibs == nil {
+		return
+	}
+	if n, suffix := mibs.Lookup(trap.TrapOID); n != nil && suffix == "" {
+		trap.TrapName = mibs.Format(trap.TrapOID)
+	}
+
+	for i := range trap.Varbinds {
+		vb := &trap.Varbinds[i]
+		n, _ := mibs.Lookup(vb.OID)
+		if n == nil {
+			continue
+		}
+		vb.Name = mibs.Format(vb.OID)
+
+		if value, err := strconv.ParseInt(vb.Value, 10, 64); err == nil {
+			vb.ValueName, _ = n.EnumName(value)
+		} else if strings.Contains(vb.Value, ".") {
+			// Only exact matches: dotted values may be addresses
+			if named, suffix := mibs.Lookup(vb.Value); named != nil && suffix == "" {
+				vb.ValueName = mibs.Format(vb.Value)
+			}
+		}
+	}
+}
+
+// Event kinds produced by Classify
+const (
+	KindColdStart   = "cold_start"
+	KindWarmStart   = "warm_start"
+	KindLinkDown    = "link_down"
+	KindLinkUp      = "link_up"
+	KindAuthFailure = "auth_failure"
+	KindBGPDown     = "bgp_down"
+	KindBGPUp       = "bgp_up"
+	KindVendor      = "vendor"
+)
+
+// trapInfo describes a known trap
+type trapInfo struct {
+	Name     string
+	Kind     string
+	Severity string
+	// Condition groups a failure trap with its recovery trap; Raises is true
+	// for the failure and false for the recovery. Empty = event only.
+	Condition string
+	Raises    bool
+}
+
+// knownTraps lists standard and vendor traps that are decoded by name
+var knownTraps = map[string]trapInfo{
+	oidSnmpTraps + ".1": {Name: "coldStart", Kind: KindColdStart, Severity: models.SeverityWarning},
+	oidSnmpTraps + ".2": {Name: "warmStart", Kind: KindWarmStart, Severity: models.SeverityInfo},
+	oidSnmpTraps + ".3": {Name: "linkDown", Kind: KindLinkDown, Severity: models.SeverityCritical, Condition: "link", Raises: true},
+	oidSnmpTraps + ".4": {Name: "linkUp", Kind: KindLinkUp, Severity: models.SeverityInfo, Condition: "link"},
+	oidSnmpTraps + ".5": {Name: "authenticationFailure", Kind: KindAuthFailure, Severity: models.SeverityWarning, Condition: "auth_failure", Raises: true},
+
+	// BGP4-MIB (RFC 4273) and its RFC 1657 predecessor
+	"1.3.6.1.2.1.15.0.1": {Name: "bgpEstablishedNotification", Kind: KindBGPUp, Severity: models.SeverityInfo, Condition: "bgp"},
+	"1.3.6.1.2.1.15.0.2": {Name: "bgpBackwardTransNotification", Kind: KindBGPDown, Severity: models.SeverityCritical, Condition: "bgp", Raises: true},
+	"1.3.6.1.2.1.15.7.1": {Name: "bgpEstablished", Kind: KindBGPUp, Severity: models.SeverityInfo, Condition: "bgp"},
+	"1.3.6.1.2.1.15.7.2": {Name: "bgpBackwardTransition", Kind: KindBGPDown, Severity: models.SeverityCritical, Condition: "bgp", Raises: true},
+
+	// Cisco
+	"1.3.6.1.4.1.9.9.43.2.0.1": {Name: "ciscoConfigManEvent", Kind: KindVendor, Severity: models.SeverityInfo},
+	"1.3.6.1.4.1.9.9.41.2.0.1": {Name: "clogMessageGenerated", Kind: KindVendor, Severity: models.SeverityInfo},
+	"1.3.6.1.4.1.9.9.13.3.0.3": {Name: "ciscoEnvMonTemperatureNotification", Kind: KindVendor, Severity: models.SeverityCritical},
+
+	// Juniper chassis alarms and their recoveries
+	"1.3.6.1.4.1.2636.4.1.1": {Name: "jnxPowerSupplyFailure", Kind: KindVendor, Severity: models.SeverityCritical, Condition: "power_supply", Raises: true},
+	"1.3.6.1.4.1.2636.4.1.2": {Name: "jnxFanFailure", Kind: KindVendor, Severity: models.SeverityCritical, Condition: "fan", Raises: true},
+	"1.3.6.1.4.1.2636.4.1.3": {Name: "jnxOverTemperature", Kind: KindVendor, Severity: models.SeverityCritical, Condition: "temperature", Raises: true},
+	"1.3.6.1.4.1.2636.4.2.1": {Name: "jnxPowerSupplyOK", Kind: KindVendor, Severity: models.SeverityInfo, Condition: "power_supply"},
+	"1.3.6.1.4.1.2636.4.2.2": {Name: "jnxFanOK", Kind: KindVendor, Severity: models.SeverityInfo, Condition: "fan"},
+	"1.3.6.1.4.1.2636.4.2.3": {Name: "jnxTemperatureOK", Kind: KindVendor, Severity: models.SeverityInfo, Condition: "temperature"},
+}
+
+// enterpriseNames maps private enterprise numbers to vendor names
+var enterpriseNames = map[string]string{
+	"9":     "cisco",
+	"2011":  "huawei",
+	"2636":  "juniper",
+	"6527":  "nokia",
+	"14988": "mikrotik",
+	"30065": "arista",
+}
+
+// Event is the interpretation of a trap
+type Event struct {
+	Kind      string
+	Name      string // Trap name, or the MIB name or OID of unknown traps
+	Severity  string
+	Vendor    string
+	IfIndex   int // 0 when the trap does not refer to an interface
+	IfName    string
+	AdminDown bool   // linkDown caused by an administrative shutdown
+	Peer      string // BGP peer address
+	Message   string
+
+	// Condition identifies the alert this trap raises or clears, relative to
+	// the router, e.g. "link:3" or "bgp:192.0.2.1". Empty = event only.
+	Condition string
+	Raises    bool
+}
+
+// Classify interprets a trap
+func Classify(trap *Trap) Event {
+	info, known := lookupTrap(trap.TrapOID)
+	if !known {
+		name := trap.TrapName
+		if name == "" {
+			name = trap.TrapOID
+		}
+		info = trapInfo{Name: name, Kind: KindVendor, Severity: models.SeverityInfo}
+	}
+
+	event := Event{
+		Kind:     info.Kind,
+		Name:     info.Name,
+		Severity: info.Severity,
+		Vendor:   vendorOf(trap.TrapOID),
+		Raises:   info.Raises,
+	}
+
+	for _, vb := range trap.Varbinds {
+		switch {
+		case strings.HasPrefix(vb.OID, oidIfIndex):
+			event.IfIndex, _ = strconv.Atoi(vb.Value)
+		case strings.HasPrefix(vb.OID, oidIfName):
+			event.IfName = vb.Value
+		case strings.HasPrefix(vb.OID, oidIfDescr):
+			if event.IfName == "" {
+				event.IfName = vb.Value
+			}
+		case strings.HasPrefix(vb.OID, oidIfAdmin):
+			event.AdminDown = vb.Value == "2"
+		case strings.HasPrefix(vb.OID, oidBGPPeerEntry):
+			event.Peer = oidSuffixAddress(vb.OID)
+		}
+		// Interface traps without an ifIndex varbind still carry it as the
+		// index of ifAdminStatus/ifOperStatus
+		if event.IfIndex == 0 && (strings.HasPrefix(vb.OID, oidIfAdmin) || strings.HasPrefix(vb.OID, oidIfOper)) {
+			event.IfIndex, _ = strconv.Atoi(vb.OID[strings.LastIndexByte(vb.OID, '.')+1:])
+		}
+	}
+
+	switch event.Kind {
+	case KindLinkDown, KindLinkUp:
+		if event.IfIndex > 0 {
+			event.Condition = fmt.Sprintf("%s:%d", info.Condition, event.IfIndex)
+		}
+		if event.AdminDown {
+			// A shutdown is intentional: record it without alerting
+			event.Severity = models.SeverityInfo
+			event.Raises = false
+		}
+	case KindBGPDown, KindBGPUp:
+		if event.Peer != "" {
+			event.Condition = info.Condition + ":" + event.Peer
+		} else {
+			event.Condition = info.Condition
+		}
+	default:
+		event.Condition = info.Condition
+	}
+
+	event.Message = describe(event)
+	return event
+}
+
+// lookupTrap finds a known trap. A v1 enterprise-specific trap translated
+// as enterprise.0.specific may correspond to a notification defined directly
+// under the enterprise (enterprise.specific), as Juniper's chassis traps are.
+func lookupTrap(oid string) (trapInfo, bool) {
+	if info, ok := knownTraps[oid]; ok {
+		return info, true
+	}
+	if i := strings.LastIndex(oid, ".0."); i >= 0 && !strings.Contains(oid[i+3:], ".") {
+		info, ok := knownTraps[oid[:i]+oid[i+2:]]
+		return info, ok
+	}
+	return trapInfo{}, false
+}
+
+// describe builds the human-readable event message
+func describe(event Event) string {
+	iface := event.IfName
+	if iface == "" && event.IfIndex > 0 {
+		iface = fmt.Sprintf("ifIndex %d", event.IfIndex)
+	}
+
+	switch event.Kind {
+	case KindColdStart:
+		return "Device restarted (cold start)"
+	case KindWarmStart:
+		return "Device restarted (warm start)"
+	case KindLinkDown:
+		if event.AdminDown {
+			return fmt.Sprintf("Interface %s administratively down", iface)
+		}
+		return fmt.Sprintf("Interface %s down", iface)
+	case KindLinkUp:
+		return fmt.Sprintf("Interface %s up", iface)
+	case KindAuthFailure:
+		return "SNMP authentication failure"
+	case KindBGPDown:
+		return strings.TrimSpace("BGP session down " + event.Peer)
+	case KindBGPUp:
+		return strings.TrimSpace("BGP session established " + event.Peer)
+	}
+
+	if event.Vendor != "" {
+		return fmt.Sprintf("%s trap %s", event.Vendor, event.Name)
+	}
+	return "Trap " + event.Name
+}
+
+// vendorOf returns the vendor owning an enterprise OID
+func vendorOf(oid string) string {
+	const enterprises = "1.3.6.1.4.1."
+	if !strings.HasPrefix(oid, enterprises) {
+		return ""
+	}
+	number, _, _ := strings.Cut(oid[len(enterprises):], ".")
+	return enterpriseNames[number]
+}
+
+// oidSuffixAddress extracts the IPv4 address that indexes a table entry
+func oidSuffixAddress(oid string) string {
+	parts := strings.Split(oid, ".")
+	if len(parts) < 4 {
+		return ""
+	}
+	return strings.Join(parts[len(parts)-4:], ".")
+}
+
+func normalizeOID(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
+
+// formatValue renders a varbind value as text
+func formatValue(pdu gosnmp.SnmpPDU) string {
+	switch value := pdu.Value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		if utf8.Valid(value) && isPrintable(value) {
+			return string(value)
+		}
+		return fmt.Sprintf("%x", value)
+	case string:
+		if pdu.Type == gosnmp.ObjectIdentifier {
+			return normalizeOID(value)
+		}
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func isPrintable(b []byte) bool {
+	for _, c := range b {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,489 @@
+package snmptrap
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/maintenance"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/gosnmp/gosnmp"
+)
+
+// received is a trap waiting to be processed
+type received struct {
+	trap   *Trap
+	source net.IP
+}
+
+// Receiver listens for traps and informs and turns them into events and alerts
+type Receiver struct {
+	config     config.SNMPTrapConfig
+	agents     *AgentMap
+	events     repository.DeviceEventRepository
+	alerts     repository.AlertRepository
+	interfaces repository.InterfaceRepository
+	windows    *maintenance.Checker
+	mibs       *mib.Tree
+	queue      chan received
+
+	// v3 users known to the listener; only touched by Start's goroutine
+	usm      *gosnmp.SnmpV3SecurityParametersTable
+	usmUsers map[string]bool
+	engineID string
+
+	// Last time each trap was processed, for deduplication; only touched by
+	// the processing goroutine
+	seen map[string]time.Time
+
+	// Counters reported with each agent refresh
+	receivedCount atomic.Uint64
+	unknown       atomic.Uint64
+	ambiguous     atomic.Uint64
+	badCommunity  atomic.Uint64
+	duplicates    atomic.Uint64
+	dropped       atomic.Uint64
+}
+
+// NewReceiver creates a new trap receiver; router SNMP credentials are
+// decrypted with secrets, alerts of routers in maintenance per windows are
+// raised suppressed and traps are named with mibs
+func NewReceiver(db *database.DB, cfg config.SNMPTrapConfig, secrets *vault.Vault, windows *maintenance.Checker, mibs *mib.Tree) *Receiver {
+	return &Receiver{
+		config:     cfg,
+		agents:     NewAgentMap(db.DB, secrets),
+		events:     postgres.NewDeviceEventRepo(db.DB),
+		alerts:     postgres.NewAlertRepo(db.DB),
+		interfaces: postgres.NewInterfaceRepo(db.DB),
+		windows:    windows,
+		mibs:       mibs,
+		queue:      make(chan received, cfg.QueueSize),
+		usmUsers:   make(map[string]bool),
+		seen:       make(map[string]time.Time),
+	}
+}
+
+// Start listens for traps until ctx is cancelled
+func (r *Receiver) Start(ctx context.Context) error {
+	params, err := r.listenerParams()
+	if err != nil {
+		return err
+	}
+
+	agents, err := r.agents.Refresh(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load trap agents: %w", err)
+	}
+	r.addUSMUsers(agents)
+
+	listener := gosnmp.NewTrapListener()
+	listener.Params = params
+	listener.OnNewTrap = r.onTrap
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- listener.Listen(r.config.Addr)
+	}()
+
+	select {
+	case <-listener.Listening():
+		log.Printf("SNMP trap receiver listening on udp %s", r.config.Addr)
+	case err := <-listenErr:
+		return fmt.Errorf("failed to listen on udp %s: %w", r.config.Addr, err)
+	}
+
+	processDone := make(chan struct{})
+	go func() {
+		defer close(processDone)
+		r.process(ctx)
+	}()
+
+	refresh := time.NewTicker(time.Duration(r.config.RefreshSeconds) * time.Second)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			listener.Close()
+			<-processDone
+			log.Println("SNMP trap receiver stopped")
+			return nil
+		case err := <-listenErr:
+			return fmt.Errorf("SNMP trap listener stopped: %w", err)
+		case <-refresh.C:
+			agents, err := r.agents.Refresh(ctx)
+			if err != nil {
+				log.Printf("Error refreshing trap agents: %v", err)
+			} else {
+				r.addUSMUsers(agents)
+			}
+			r.logStats()
+		}
+	}
+}
+
+// listenerParams returns the listener settings. With an engine ID, v3
+// traps are accepted from the USM users added by addUSMUsers; v1 and v2c
+// traps are decoded either way.
+func (r *Receiver) listenerParams() (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{
+		Version: gosnmp.Version2c,
+		Timeout: 2 * time.Second,
+	}
+	if r.config.EngineID == "" {
+		return params, nil
+	}
+
+	engineID, err := hex.DecodeString(strings.TrimPrefix(r.config.EngineID, "0x"))
+	if err != nil || len(engineID) < 5 || len(engineID) > 32 {
+		return nil, fmt.Errorf("invalid SNMP trap engine ID %q: expected 5-32 bytes in hex", r.config.EngineID)
+	}
+	r.engineID = string(engineID)
+	r.usm = gosnmp.NewSnmpV3SecurityParametersTable(gosnmp.Logger{})
+	// gosnmp only verifies v3 authentication for a v3 listener
+	params.Version = gosnmp.Version3
+	params.SecurityModel = gosnmp.UserSecurityModel
+	params.SecurityParameters = &gosnmp.UsmSecurityParameters{AuthoritativeEngineID: r.engineID}
+	params.TrapSecurityParametersTable = r.usm
+	return params, nil
+}
+
+// addUSMUsers registers v3 users that are not known to the listener yet.
+// Users are localized to the receiver's engine ID, which is what senders
+// use for informs. Removed or changed users take effect after a restart.
+func (r *Receiver) addUSMUsers(agents []*agent) {
+	if r.usm == nil {
+		return
+	}
+
+	for _, a := range agents {
+		if a.V3Username == "" {
+			continue
+		}
+		key := a.V3Username + "\x00" + a.V3AuthProtocol + "\x00" + a.V3AuthPassword + "\x00" + a.V3PrivProtocol + "\x00" + a.V3PrivPassword
+		if r.usmUsers[key] {
+			continue
+		}
+
+		params := &gosnmp.UsmSecurityParameters{
+			UserName:                 a.V3Username,
+			AuthoritativeEngineID:    r.engineID,
+			AuthenticationProtocol:   authProtocol(a.V3AuthProtocol),
+			AuthenticationPassphrase: a.V3AuthPassword,
+			PrivacyProtocol:          privProtocol(a.V3PrivProtocol),
+			PrivacyPassphrase:        a.V3PrivPassword,
+		}
+		if err := r.usm.Add(a.V3Username, params); err != nil {
+			log.Printf("Error adding SNMPv3 trap user for router %s: %v", a.Name, err)
+			continue
+		}
+		r.usmUsers[key] = true
+	}
+}
+
+// onTrap is called by the listener for every decoded trap and inform; the
+// listener answers informs itself after this returns
+func (r *Receiver) onTrap(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+	r.receivedCount.Add(1)
+
+	item := received{trap: FromPacket(packet, time.Now())}
+	if addr != nil {
+		item.source = append(net.IP(nil), addr.IP...)
+	}
+
+	select {
+	case r.queue <- item:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// process handles queued traps until ctx is cancelled
+func (r *Receiver) process(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-r.queue:
+			r.handle(ctx, item)
+		}
+	}
+}
+
+// handle attributes one trap to a router and records its effects
+func (r *Receiver) handle(ctx context.Context, item received) {
+	trap := item.trap
+
+	// The UDP source is authoritative; the v1 agent-addr helps when traps
+	// are relayed or NATed
+	a, ambiguous := r.agents.LookupIP(item.source)
+	if a == nil && !ambiguous && trap.Agent != "" {
+		a, ambiguous = r.agents.LookupIP(net.ParseIP(trap.Agent))
+	}
+	if a == nil {
+		if ambiguous {
+			r.ambiguous.Add(1)
+		} else {
+			r.unknown.Add(1)
+		}
+		return
+	}
+
+	if !r.communityAccepted(a, trap) {
+		r.badCommunity.Add(1)
+		return
+	}
+
+	Decorate(trap, r.mibs)
+	event := Classify(trap)
+
+	if r.isDuplicate(a, trap, event) {
+		r.duplicates.Add(1)
+		return
+	}
+
+	var iface *models.Interface
+	if event.IfIndex > 0 && (event.Kind == KindLinkDown || event.Kind == KindLinkUp) {
+		iface = r.updateInterface(ctx, a, event)
+	}
+	if iface != nil && event.IfName == "" {
+		event.IfName = iface.Name
+		event.Message = describe(event)
+	}
+
+	r.recordEvent(ctx, a, trap, event)
+
+	if event.Condition != "" {
+		r.applyAlert(ctx, a, event, iface, trap.Received)
+	}
+}
+
+// communityAccepted checks the v1/v2c community against the router's, or
+// the receiver-wide community when the router has none. v3 traps must be
+// from the router's user, at least at the security level it is configured
+// for.
+func (r *Receiver) communityAccepted(a *agent, trap *Trap) bool {
+	if trap.Version == "v3" {
+		if a.V3Username == "" || trap.User != a.V3Username {
+			return false
+		}
+		if authProtocol(a.V3AuthProtocol) != gosnmp.NoAuth && !trap.Auth {
+			return false
+		}
+		return privProtocol(a.V3PrivProtocol) == gosnmp.NoPriv || trap.Priv
+	}
+	expected := a.Community
+	if expected == "" {
+		expected = r.config.Community
+	}
+	return expected == "" || trap.Community == expected
+}
+
+// isDuplicate reports whether the same trap from the same router was seen
+// within the deduplication window. Senders retransmit informs and some
+// devices emit the same trap several times for one state change.
+func (r *Receiver) isDuplicate(a *agent, trap *Trap, event Event) bool {
+	window := time.Duration(r.config.DedupSeconds) * time.Second
+	if window <= 0 {
+		return false
+	}
+
+	var key strings.Builder
+	key.WriteString(a.RouterID.String())
+	key.WriteString("|" + trap.TrapOID)
+	for _, vb := range trap.Varbinds {
+		key.WriteString("|" + vb.OID + "=" + vb.Value)
+	}
+
+	now := trap.Received
+	if last, ok := r.seen[key.String()]; ok && now.Sub(last) < window {
+		return true
+	}
+	r.seen[key.String()] = now
+
+	// Keep the map bounded by pruning expired entries as it grows
+	if len(r.seen) > 10000 {
+		for k, t := range r.seen {
+			if now.Sub(t) >= window {
+				delete(r.seen, k)
+			}
+		}
+	}
+
+	return false
+}
+
+// updateInterface applies a link trap to the interface's stored status
+func (r *Receiver) updateInterface(ctx context.Context, a *agent, event Event) *models.Interface {
+	status, adminStatus := "up", "up"
+	if event.Kind == KindLinkDown {
+		status = "down"
+		if event.AdminDown {
+			status, adminStatus = "admin-down", "down"
+		}
+	}
+
+	iface, err := r.interfaces.UpdateStatusByIndex(ctx, a.TenantID, a.RouterID, event.IfIndex, status, adminStatus)
+	if err != nil {
+		// Interfaces not discovered by polling yet are expected
+		if !strings.Contains(err.Error(), "not found") {
+			log.Printf("Error updating interface %d of router %s from trap: %v", event.IfIndex, a.Name, err)
+		}
+		return nil
+	}
+	return iface
+}
+
+// eventTypes maps event kinds to device event types
+var eventTypes = map[string]string{
+	KindColdStart:   models.EventTypeColdStart,
+	KindWarmStart:   models.EventTypeWarmStart,
+	KindLinkDown:    models.EventTypeLinkDown,
+	KindLinkUp:      models.EventTypeLinkUp,
+	KindAuthFailure: models.EventTypeAuthFailure,
+	KindBGPDown:     models.EventTypeBGPDown,
+	KindBGPUp:       models.EventTypeBGPUp,
+	KindVendor:      models.EventTypeSNMPTrap,
+}
+
+// recordEvent stores the trap as a device event
+func (r *Receiver) recordEvent(ctx context.Context, a *agent, trap *Trap, event Event) {
+	metadata := map[string]interface{}{
+		"trap_oid":  trap.TrapOID,
+		"trap_name": event.Name,
+		"version":   trap.Version,
+		"inform":    trap.Inform,
+		"uptime":    trap.Uptime,
+		"varbinds":  trap.Varbinds,
+	}
+	if event.Vendor != "" {
+		metadata["vendor"] = event.Vendor
+	}
+	if event.IfIndex > 0 {
+		metadata["if_index"] = event.IfIndex
+	}
+	if event.Peer != "" {
+		metadata["peer"] = event.Peer
+	}
+	encoded, _ := json.Marshal(metadata)
+	metadataStr := string(encoded)
+
+	routerID := a.RouterID
+	deviceEvent := &models.DeviceEvent{
+		TenantID:   a.TenantID,
+		RouterID:   &routerID,
+		EventType:  eventTypes[event.Kind],
+		Severity:   event.Severity,
+		Source:     models.EventSourceSNMPTrap,
+		Message:    event.Message,
+		Metadata:   &metadataStr,
+		OccurredAt: trap.Received,
+	}
+
+	if err := r.events.Create(ctx, deviceEvent); err != nil {
+		log.Printf("Error recording trap event for router %s: %v", a.Name, err)
+	}
+}
+
+// applyAlert raises the alert for a failure trap or resolves it on recovery
+func (r *Receiver) applyAlert(ctx context.Context, a *agent, event Event, iface *models.Interface, at time.Time) {
+	dedupKey := fmt.Sprintf("%s:%s:%s", models.EventSourceSNMPTrap, a.RouterID, event.Condition)
+
+	if !event.Raises {
+		if _, err := r.alerts.ResolveByDedupKey(ctx, a.TenantID, dedupKey, at); err != nil {
+			log.Printf("Error resolving trap alert for router %s: %v", a.Name, err)
+		}
+		return
+	}
+
+	targetType, targetID := "router", a.RouterID
+	if iface != nil {
+		targetType, targetID = "interface", iface.ID
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"source":    models.EventSourceSNMPTrap,
+		"router_id": a.RouterID,
+		"trap_name": event.Name,
+	})
+	metadataStr := string(metadata)
+	description := fmt.Sprintf("%s on %s", event.Message, a.Name)
+
+	alert := &models.Alert{
+		TenantID:    a.TenantID,
+		Name:        event.Message,
+		Description: &description,
+		Severity:    event.Severity,
+		TargetType:  &targetType,
+		TargetID:    uuidPtr(targetID),
+		TriggeredAt: at,
+		Metadata:    &metadataStr,
+		DedupKey:    &dedupKey,
+	}
+	r.windows.Suppress(alert, a.RouterID)
+
+	if _, err := r.alerts.Raise(ctx, alert); err != nil {
+		log.Printf("Error raising trap alert for router %s: %v", a.Name, err)
+	}
+}
+
+// logStats reports and resets the receive counters
+func (r *Receiver) logStats() {
+	ingest.LogStats("SNMP traps", &r.receivedCount, "received", []ingest.Count{
+		{Name: "unknown agent", Value: &r.unknown},
+		{Name: "ambiguous agent", Value: &r.ambiguous},
+		{Name: "bad community", Value: &r.badCommunity},
+		{Name: "duplicate", Value: &r.duplicates},
+		{Name: "dropped", Value: &r.dropped},
+	})
+}
+
+func authProtocol(name string) gosnmp.SnmpV3AuthProtocol {
+	switch strings.ToUpper(name) {
+	case "MD5":
+		return gosnmp.MD5
+	case "SHA":
+		return gosnmp.SHA
+	case "SHA-224":
+		return gosnmp.SHA224
+	case "SHA-256":
+		return gosnmp.SHA256
+	case "SHA-384":
+		return gosnmp.SHA384
+	case "SHA-512":
+		return gosnmp.SHA512
+	}
+	return gosnmp.NoAuth
+}
+
+func privProtocol(name string) gosnmp.SnmpV3PrivProtocol {
+	switch strings.ToUpper(name) {
+	case "DES":
+		return gosnmp.DES
+	case "AES":
+		return gosnmp.AES
+	case "AES-192":
+		return gosnmp.AES192
+	case "AES-256":
+		return gosnmp.AES256
+	}
+	return gosnmp.NoPriv
+}
+
+func uuidPtr(id uuid.UUID) *uuid.UUID {
+	return &id
+}
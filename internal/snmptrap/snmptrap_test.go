@@ -0,0 +1,270 @@
+package snmptrap
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/mib"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"github.com/google/uuid"
+	"github.com/gosnmp/gosnmp"
+)
+
+func TestFromPacketV2c(t *testing.T) {
+	packet := &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		PDUType:   gosnmp.InformRequest,
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(123456)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+			{Name: ".1.3.6.1.2.1.2.2.1.1.3", Type: gosnmp.Integer, Value: 3},
+			{Name: ".1.3.6.1.2.1.2.2.1.7.3", Type: gosnmp.Integer, Value: 1},
+			{Name: ".1.3.6.1.2.1.2.2.1.8.3", Type: gosnmp.Integer, Value: 2},
+			{Name: ".1.3.6.1.2.1.31.1.1.1.1.3", Type: gosnmp.OctetString, Value: []byte("ether3")},
+		},
+	}
+
+	trap := FromPacket(packet, time.Now())
+	if trap.Version != "v2c" || !trap.Inform || trap.Community != "public" {
+		t.Errorf("header = %s inform=%v community=%q", trap.Version, trap.Inform, trap.Community)
+	}
+	if trap.TrapOID != "1.3.6.1.6.3.1.1.5.3" || trap.Uptime != 123456 {
+		t.Errorf("trap OID = %q, uptime = %d", trap.TrapOID, trap.Uptime)
+	}
+	if len(trap.Varbinds) != 4 {
+		t.Fatalf("expected sysUpTime and snmpTrapOID to be stripped, got %d varbinds", len(trap.Varbinds))
+	}
+
+	event := Classify(trap)
+	if event.Kind != KindLinkDown || event.IfIndex != 3 || event.IfName != "ether3" {
+		t.Errorf("event = %+v", event)
+	}
+	if !event.Raises || event.Condition != "link:3" || event.Severity != models.SeverityCritical {
+		t.Errorf("expected link:3 critical alert, got %+v", event)
+	}
+	if event.Message != "Interface ether3 down" {
+		t.Errorf("message = %q", event.Message)
+	}
+}
+
+func TestFromPacketV1(t *testing.T) {
+	generic := &gosnmp.SnmpPacket{
+		Version: gosnmp.Version1,
+		PDUType: gosnmp.Trap,
+		SnmpTrap: gosnmp.SnmpTrap{
+			Enterprise:   ".1.3.6.1.4.1.9.1.1",
+			AgentAddress: "192.0.2.10",
+			GenericTrap:  0,
+			Timestamp:    42,
+		},
+	}
+	trap := FromPacket(generic, time.Now())
+	if trap.TrapOID != "1.3.6.1.6.3.1.1.5.1" || trap.Agent != "192.0.2.10" || trap.Uptime != 42 {
+		t.Errorf("trap = %+v", trap)
+	}
+	if event := Classify(trap); event.Kind != KindColdStart || event.Condition != "" {
+		t.Errorf("expected event-only coldStart, got %+v", event)
+	}
+
+	specific := &gosnmp.SnmpPacket{
+		Version: gosnmp.Version1,
+		PDUType: gosnmp.Trap,
+		SnmpTrap: gosnmp.SnmpTrap{
+			Enterprise:   ".1.3.6.1.4.1.2636.4.1",
+			GenericTrap:  6,
+			SpecificTrap: 2,
+		},
+	}
+	event := Classify(FromPacket(specific, time.Now()))
+	if event.Name != "jnxFanFailure" || event.Vendor != "juniper" || !event.Raises || event.Condition != "fan" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	adminDown := Classify(&Trap{
+		TrapOID: "1.3.6.1.6.3.1.1.5.3",
+		Varbinds: []Varbind{
+			{OID: "1.3.6.1.2.1.2.2.1.7.5", Value: "2"},
+			{OID: "1.3.6.1.2.1.2.2.1.8.5", Value: "2"},
+		},
+	})
+	if adminDown.IfIndex != 5 || !adminDown.AdminDown || adminDown.Raises || adminDown.Condition != "link:5" {
+		t.Errorf("expected admin shutdown to clear rather than raise, got %+v", adminDown)
+	}
+
+	linkUp := Classify(&Trap{
+		TrapOID:  "1.3.6.1.6.3.1.1.5.4",
+		Varbinds: []Varbind{{OID: "1.3.6.1.2.1.2.2.1.1.5", Value: "5"}},
+	})
+	if linkUp.Raises || linkUp.Condition != "link:5" {
+		t.Errorf("expected linkUp to clear link:5, got %+v", linkUp)
+	}
+
+	bgp := Classify(&Trap{
+		TrapOID: "1.3.6.1.2.1.15.0.2",
+		Varbinds: []Varbind{
+			{OID: "1.3.6.1.2.1.15.3.1.14.198.51.100.7", Value: "0000"},
+			{OID: "1.3.6.1.2.1.15.3.1.2.198.51.100.7", Value: "1"},
+		},
+	})
+	if bgp.Kind != KindBGPDown || bgp.Peer != "198.51.100.7" || bgp.Condition != "bgp:198.51.100.7" {
+		t.Errorf("event = %+v", bgp)
+	}
+
+	vendor := Classify(&Trap{TrapOID: "1.3.6.1.4.1.14988.1.0.1"})
+	if vendor.Kind != KindVendor || vendor.Vendor != "mikrotik" || vendor.Condition != "" {
+		t.Errorf("event = %+v", vendor)
+	}
+	if vendor.Message != "mikrotik trap 1.3.6.1.4.1.14988.1.0.1" {
+		t.Errorf("message = %q", vendor.Message)
+	}
+}
+
+func TestDecorate(t *testing.T) {
+	mibs, err := mib.LoadFS(fstest.MapFS{"MIKROTIK-MIB": {Data: []byte(`
+MIKROTIK-MIB DEFINITIONS ::= BEGIN
+IMPORTS enterprises, OBJECT-TYPE, NOTIFICATION-TYPE FROM SNMPv2-SMI;
+mikrotik       OBJECT IDENTIFIER ::= { enterprises 14988 }
+mikrotikTraps  OBJECT IDENTIFIER ::= { mikrotik 1 0 }
+mtxrTemperatureAlarm NOTIFICATION-TYPE
+    OBJECTS     { mtxrAlarmState }
+    STATUS      current
+    DESCRIPTION "Temperature alarm."
+    ::= { mikrotikTraps 1 }
+mtxrAlarmState OBJECT-TYPE
+    SYNTAX      INTEGER { normal(1), alarm(2) }
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Alarm state."
+    ::= { mikrotik 2 1 }
+mtxrAlarmSource OBJECT-TYPE
+    SYNTAX      OBJECT IDENTIFIER
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "Sensor raising the alarm."
+    ::= { mikrotik 2 2 }
+END
+`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trap := &Trap{
+		TrapOID: "1.3.6.1.4.1.14988.1.0.1",
+		Varbinds: []Varbind{
+			{OID: "1.3.6.1.4.1.14988.2.1.3", Value: "2"},
+			{OID: "1.3.6.1.4.1.14988.2.2.3", Value: "1.3.6.1.4.1.14988.2.1"},
+			{OID: "1.3.6.1.2.1.4.20.1.1.192.0.2.1", Value: "192.0.2.1"},
+		},
+	}
+	Decorate(trap, mibs)
+
+	if trap.TrapName != "MIKROTIK-MIB::mtxrTemperatureAlarm" {
+		t.Errorf("trap name = %q", trap.TrapName)
+	}
+	if vb := trap.Varbinds[0]; vb.Name != "MIKROTIK-MIB::mtxrAlarmState.3" || vb.ValueName != "alarm" {
+		t.Errorf("varbind = %+v", vb)
+	}
+	if vb := trap.Varbinds[1]; vb.ValueName != "MIKROTIK-MIB::mtxrAlarmState" {
+		t.Errorf("varbind = %+v", vb)
+	}
+	if vb := trap.Varbinds[2]; vb.Name != "SNMPv2-SMI::mib-2.4.20.1.1.192.0.2.1" || vb.ValueName != "" {
+		t.Errorf("varbind = %+v", vb)
+	}
+
+	event := Classify(trap)
+	if event.Name != "MIKROTIK-MIB::mtxrTemperatureAlarm" || event.Message != "mikrotik trap MIKROTIK-MIB::mtxrTemperatureAlarm" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestV3SecurityLevel(t *testing.T) {
+	engineID := "\x80\x00\x1f\x88\x04trap"
+	a := &agent{
+		RouterID:       uuid.New(),
+		Name:           "core-1",
+		V3Username:     "monitor",
+		V3AuthProtocol: "SHA",
+		V3AuthPassword: "authpass123",
+	}
+	set := ingest.NewSourceSet[*agent]()
+	set.Add("192.0.2.1", 0, a)
+	agents := &AgentMap{Sources: ingest.NewSources[*agent]()}
+	agents.Set(set)
+
+	r := &Receiver{
+		config:   config.SNMPTrapConfig{EngineID: hex.EncodeToString([]byte(engineID))},
+		agents:   agents,
+		usmUsers: make(map[string]bool),
+		seen:     make(map[string]time.Time),
+	}
+	listener, err := r.listenerParams()
+	if err != nil {
+		t.Fatalf("listenerParams: %v", err)
+	}
+	r.addUSMUsers([]*agent{a})
+
+	// send encodes a linkDown trap of the router's user at the given level
+	send := func(flags gosnmp.SnmpV3MsgFlags, auth gosnmp.SnmpV3AuthProtocol) *Trap {
+		t.Helper()
+		sender := &gosnmp.GoSNMP{
+			Version:       gosnmp.Version3,
+			SecurityModel: gosnmp.UserSecurityModel,
+			MsgFlags:      flags,
+			SecurityParameters: &gosnmp.UsmSecurityParameters{
+				UserName:                 "monitor",
+				AuthoritativeEngineID:    engineID,
+				AuthenticationProtocol:   auth,
+				AuthenticationPassphrase: "authpass123",
+			},
+		}
+		// As Connect does before sending
+		if err := sender.SecurityParameters.InitSecurityKeys(); err != nil {
+			t.Fatalf("InitSecurityKeys: %v", err)
+		}
+		data, err := sender.SnmpEncodePacket(gosnmp.SNMPv2Trap, []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+			{Name: ".1.3.6.1.2.1.2.2.1.1.3", Type: gosnmp.Integer, Value: 3},
+		}, 0, 0)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		packet, err := listener.UnmarshalTrap(data, false)
+		if err != nil {
+			t.Fatalf("UnmarshalTrap: %v", err)
+		}
+		return FromPacket(packet, time.Now())
+	}
+
+	// USM accepts a trap claiming no authentication for a user that has it
+	trap := send(gosnmp.NoAuthNoPriv, gosnmp.NoAuth)
+	r.handle(context.Background(), received{trap: trap, source: net.ParseIP("192.0.2.1")})
+	if got := r.badCommunity.Load(); got != 1 {
+		t.Errorf("unauthenticated v3 trap: bad community = %d, want 1", got)
+	}
+
+	if trap := send(gosnmp.AuthNoPriv, gosnmp.SHA); !r.communityAccepted(a, trap) {
+		t.Errorf("authenticated v3 trap rejected: %+v", trap)
+	}
+
+	// v2c traps are still decoded
+	v2c := &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public"}
+	data, err := v2c.SnmpEncodePacket(gosnmp.SNMPv2Trap, []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+	}, 0, 0)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if packet, err := listener.UnmarshalTrap(data, false); err != nil || packet.Community != "public" {
+		t.Errorf("v2c trap: %v", err)
+	}
+}
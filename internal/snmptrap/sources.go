@@ -0,0 +1,113 @@
+package snmptrap
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/vault"
+	"github.com/google/uuid"
+)
+
+// agent is a router that may send traps
+type agent struct {
+	TenantID  uuid.UUID
+	RouterID  uuid.UUID
+	Name      string
+	Community string // Expected v1/v2c community; empty accepts any
+
+	// SNMPv3 user, used to authenticate v3 traps and informs
+	V3Username     string
+	V3AuthProtocol string
+	V3AuthPassword string
+	V3PrivProtocol string
+	V3PrivPassword string
+}
+
+// AgentMap attributes trap source addresses to routers; see ingest.Sources
+type AgentMap struct {
+	db    *sql.DB
+	vault *vault.Vault
+	*ingest.Sources[*agent]
+}
+
+// NewAgentMap creates an empty agent map; call Refresh to load it. SNMP
+// credentials are decrypted with secrets.
+func NewAgentMap(db *sql.DB, secrets *vault.Vault) *AgentMap {
+	return &AgentMap{db: db, vault: secrets, Sources: ingest.NewSources[*agent]()}
+}
+
+// Refresh reloads the address to router mapping and returns the loaded
+// agents. Routers whose credentials cannot be decrypted are left out, so
+// their traps are not accepted with an empty community.
+func (m *AgentMap) Refresh(ctx context.Context) ([]*agent, error) {
+	query := ingest.SourceQuery(`
+		SELECT r.id, r.tenant_id, r.name,
+			COALESCE(rc.snmp_community, sp.snmp_community, r.snmp_community) AS community,
+			COALESCE(rc.snmp_v3_username, sp.username),
+			COALESCE(rc.snmp_v3_auth_protocol, sp.snmp_v3_auth_protocol),
+			COALESCE(rc.snmp_v3_auth_password, sp.snmp_v3_auth_password),
+			COALESCE(rc.snmp_v3_priv_protocol, sp.snmp_v3_priv_protocol),
+			COALESCE(rc.snmp_v3_priv_password, sp.snmp_v3_priv_password)
+		FROM routers r
+		LEFT JOIN router_capabilities rc ON r.id = rc.router_id
+		LEFT JOIN credential_profiles sp ON sp.id = rc.snmp_profile_id
+	`)
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := ingest.NewSourceSet[*agent]()
+	seen := make(map[uuid.UUID]*agent)
+	agents := make([]*agent, 0)
+	for rows.Next() {
+		var ip string
+		var priority int
+		var community, v3User, v3AuthProto, v3AuthPass, v3PrivProto, v3PrivPass *string
+		a := &agent{}
+		err := rows.Scan(&ip, &priority, &a.RouterID, &a.TenantID, &a.Name, &community,
+			&v3User, &v3AuthProto, &v3AuthPass, &v3PrivProto, &v3PrivPass)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing, ok := seen[a.RouterID]; ok {
+			if existing == nil {
+				continue
+			}
+			a = existing
+		} else {
+			a.Community = derefString(community)
+			a.V3Username = derefString(v3User)
+			a.V3AuthProtocol = derefString(v3AuthProto)
+			a.V3AuthPassword = derefString(v3AuthPass)
+			a.V3PrivProtocol = derefString(v3PrivProto)
+			a.V3PrivPassword = derefString(v3PrivPass)
+			if err := m.vault.DecryptFields(&a.Community, &a.V3AuthPassword, &a.V3PrivPassword); err != nil {
+				log.Printf("Ignoring traps from router %s: %v", a.Name, err)
+				seen[a.RouterID] = nil
+				continue
+			}
+			seen[a.RouterID] = a
+			agents = append(agents, a)
+		}
+		set.Add(ip, priority, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Set(set)
+	return agents, nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,72 @@
+// Package sshclient connects to routers over SSH with the credentials of
+// their SSH capability.
+package sshclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// Dial connects and authenticates to a router. The capability's timeout,
+// or timeout when it has none, bounds the connection and handshake; ctx's
+// deadline, if any, bounds the whole connection.
+func Dial(ctx context.Context, router *models.EnhancedRouter, timeout time.Duration) (*ssh.Client, error) {
+	sshCfg := router.Capabilities.SSH
+
+	auth := []ssh.AuthMethod{}
+	if sshCfg.PrivateKey != nil && *sshCfg.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(*sshCfg.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if sshCfg.Password != nil && *sshCfg.Password != "" {
+		auth = append(auth, ssh.Password(*sshCfg.Password))
+	}
+
+	if sshCfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(sshCfg.TimeoutSeconds) * time.Second
+	}
+
+	clientConfig := &ssh.ClientConfig{
+		User: sshCfg.Username,
+		Auth: auth,
+		// Devices are addressed by management IP and host keys are not
+		// provisioned in the inventory, so they cannot be pinned yet
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         timeout,
+	}
+
+	host := sshCfg.Host
+	if host == "" {
+		host = router.ManagementIP
+	}
+	port := sshCfg.Port
+	if port == 0 {
+		port = 22
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SSH handshake failed: %w", err)
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
@@ -0,0 +1,270 @@
+// Package syslog receives syslog messages from routers over UDP, TCP and TLS.
+// Messages in RFC 5424 and RFC 3164 (BSD) format are parsed, attributed to a
+// router by source address, filtered by the router's configured facility and
+// severity, and written to the syslog_messages table in batches.
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a parsed syslog message
+type Message struct {
+	Facility       int
+	Severity       int
+	Timestamp      *time.Time // Device timestamp, nil when absent or unparseable
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData string
+	Text           string
+}
+
+// Default priority for messages without a PRI part (RFC 3164 section 4.3.3)
+const (
+	defaultFacility = 1 // user
+	defaultSeverity = 5 // notice
+)
+
+// nilValue marks an absent RFC 5424 header field
+const nilValue = "-"
+
+// Parse decodes a single syslog message in RFC 5424 or RFC 3164 format. BSD
+// messages are parsed leniently since routers rarely follow RFC 3164 exactly;
+// whatever cannot be recognised as a header stays in the message text.
+func Parse(data []byte, received time.Time) (*Message, error) {
+	data = bytes.TrimRight(data, "\r\n\x00")
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty message")
+	}
+
+	msg := &Message{Facility: defaultFacility, Severity: defaultSeverity}
+
+	rest := string(data)
+	if rest[0] == '<' {
+		end := strings.IndexByte(rest, '>')
+		if end < 2 || end > 4 {
+			return nil, fmt.Errorf("invalid PRI")
+		}
+		pri, err := strconv.Atoi(rest[1:end])
+		if err != nil || pri > 191 {
+			return nil, fmt.Errorf("invalid PRI %q", rest[1:end])
+		}
+		msg.Facility = pri / 8
+		msg.Severity = pri % 8
+		rest = rest[end+1:]
+	}
+
+	if strings.HasPrefix(rest, "1 ") {
+		if err := parseRFC5424(msg, rest[2:]); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+
+	parseRFC3164(msg, rest, received)
+	return msg, nil
+}
+
+// parseRFC5424 parses the part after "<PRI>1 "
+func parseRFC5424(msg *Message, rest string) error {
+	fields := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		field, remainder, ok := strings.Cut(rest, " ")
+		if !ok {
+			return fmt.Errorf("truncated RFC 5424 header")
+		}
+		fields = append(fields, field)
+		rest = remainder
+	}
+
+	if fields[0] != nilValue {
+		if ts, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+			msg.Timestamp = &ts
+		}
+	}
+	msg.Hostname = nilToEmpty(fields[1])
+	msg.AppName = nilToEmpty(fields[2])
+	msg.ProcID = nilToEmpty(fields[3])
+	msg.MsgID = nilToEmpty(fields[4])
+
+	sd, text, err := splitStructuredData(rest)
+	if err != nil {
+		return err
+	}
+	msg.StructuredData = nilToEmpty(sd)
+	msg.Text = strings.TrimPrefix(text, "\ufeff") // Optional UTF-8 BOM
+
+	return nil
+}
+
+// splitStructuredData separates STRUCTURED-DATA from MSG, honouring the
+// escaped "\]" inside parameter values
+func splitStructuredData(rest string) (string, string, error) {
+	if rest == nilValue {
+		return nilValue, "", nil
+	}
+	if text, ok := strings.CutPrefix(rest, nilValue+" "); ok {
+		return nilValue, text, nil
+	}
+	if !strings.HasPrefix(rest, "[") {
+		return "", "", fmt.Errorf("invalid RFC 5424 structured data")
+	}
+
+	inQuotes := false
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuotes = !inQuotes
+		case ']':
+			if inQuotes {
+				continue
+			}
+			// Another element may follow immediately
+			if i+1 < len(rest) && rest[i+1] == '[' {
+				continue
+			}
+			return rest[:i+1], strings.TrimPrefix(rest[i+1:], " "), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated RFC 5424 structured data")
+}
+
+// bsdTimestamp matches "Mmm dd hh:mm:ss" with optional Cisco decorations:
+// a leading '*' or '.' (clock not synchronised), milliseconds, a year and a
+// trailing colon
+var bsdTimestamp = regexp.MustCompile(`^[*.]?([A-Z][a-z]{2} {1,2}\d{1,2} (?:\d{4} )?\d{2}:\d{2}:\d{2})(\.\d+)?(?: [A-Z]{3,4})?:? `)
+
+// bsdTag matches a TAG or TAG[PID] followed by a colon
+var bsdTag = regexp.MustCompile(`^([^\s\[\]:]{1,48})(?:\[([^\]]*)\])?: ?`)
+
+// ciscoSequence matches the optional sequence number Cisco prepends
+var ciscoSequence = regexp.MustCompile(`^\d+: `)
+
+// parseRFC3164 parses a BSD-style message after the PRI part
+func parseRFC3164(msg *Message, rest string, received time.Time) {
+	rest = ciscoSequence.ReplaceAllString(rest, "")
+
+	hasTimestamp := false
+	if m := bsdTimestamp.FindStringSubmatch(rest); m != nil {
+		if ts, ok := parseBSDTime(m[1], received); ok {
+			msg.Timestamp = &ts
+		}
+		hasTimestamp = true
+		rest = rest[len(m[0]):]
+	}
+
+	// A hostname is only expected after a timestamp, and never looks like a tag
+	if hasTimestamp && !bsdTag.MatchString(rest) && !strings.HasPrefix(rest, "%") {
+		if host, remainder, ok := strings.Cut(rest, " "); ok && host != "" {
+			msg.Hostname = host
+			rest = remainder
+		}
+	}
+
+	if m := bsdTag.FindStringSubmatch(rest); m != nil {
+		msg.AppName = m[1]
+		msg.ProcID = m[2]
+		rest = rest[len(m[0]):]
+	}
+
+	msg.Text = rest
+}
+
+// parseBSDTime resolves the year-less BSD timestamp against the receive time;
+// a timestamp more than a day in the future belongs to the previous year
+func parseBSDTime(value string, received time.Time) (time.Time, bool) {
+	value = strings.Replace(value, "  ", " ", 1)
+
+	if ts, err := time.ParseInLocation("Jan 2 2006 15:04:05", value, received.Location()); err == nil {
+		return ts, true
+	}
+
+	ts, err := time.ParseInLocation("Jan 2 15:04:05", value, received.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	ts = ts.AddDate(received.Year(), 0, 0)
+	if ts.After(received.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	return ts, true
+}
+
+func nilToEmpty(s string) string {
+	if s == nilValue {
+		return ""
+	}
+	return s
+}
+
+// severityNames lists severity keywords by numeric value
+var severityNames = []string{"emergency", "alert", "critical", "error", "warning", "notice", "info", "debug"}
+
+// severityAliases maps the short forms used by syslog.conf and vendors
+var severityAliases = map[string]int{
+	"emerg": 0, "panic": 0, "crit": 2, "err": 3, "warn": 4, "informational": 6,
+}
+
+// facilityNames lists facility keywords by numeric value
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "clock",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// SeverityName returns the keyword for a numeric severity
+func SeverityName(severity int) string {
+	if severity < 0 || severity >= len(severityNames) {
+		return strconv.Itoa(severity)
+	}
+	return severityNames[severity]
+}
+
+// FacilityName returns the keyword for a numeric facility
+func FacilityName(facility int) string {
+	if facility < 0 || facility >= len(facilityNames) {
+		return strconv.Itoa(facility)
+	}
+	return facilityNames[facility]
+}
+
+// ParseSeverity accepts a severity keyword, alias or number
+func ParseSeverity(value string) (int, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for i, name := range severityNames {
+		if value == name {
+			return i, true
+		}
+	}
+	if severity, ok := severityAliases[value]; ok {
+		return severity, true
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n < len(severityNames) {
+		return n, true
+	}
+	return 0, false
+}
+
+// ParseFacility accepts a facility keyword or number
+func ParseFacility(value string) (int, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for i, name := range facilityNames {
+		if value == name {
+			return i, true
+		}
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n < len(facilityNames) {
+		return n, true
+	}
+	return 0, false
+}
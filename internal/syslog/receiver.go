@@ -0,0 +1,417 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/database"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/repository/postgres"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/models"
+)
+
+// maxMessageSize bounds a single message; RFC 5425 requires at least 2048
+// octets and most senders stay well below this
+const maxMessageSize = 64 * 1024
+
+// flushInterval is the longest a queued message waits before being written
+const flushInterval = time.Second
+
+// retentionBatch is the number of rows removed per retention delete
+const retentionBatch = 10000
+
+// Receiver listens for syslog messages and stores them
+type Receiver struct {
+	config  config.SyslogConfig
+	repo    repository.SyslogRepository
+	sources *SourceMap
+	queue   chan *models.SyslogMessage
+
+	// Counters reported with each source refresh
+	received  atomic.Uint64
+	unknown   atomic.Uint64
+	ambiguous atomic.Uint64
+	malformed atomic.Uint64
+	filtered  atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewReceiver creates a new syslog receiver
+func NewReceiver(db *database.DB, cfg config.SyslogConfig) *Receiver {
+	return &Receiver{
+		config:  cfg,
+		repo:    postgres.NewSyslogRepo(db.DB),
+		sources: NewSourceMap(db.DB),
+		queue:   make(chan *models.SyslogMessage, cfg.QueueSize),
+	}
+}
+
+// Start opens the configured listeners and runs until ctx is cancelled
+func (r *Receiver) Start(ctx context.Context) error {
+	if err := r.sources.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to load syslog sources: %w", err)
+	}
+
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	if r.config.UDPAddr != "" {
+		conn, err := net.ListenPacket("udp", r.config.UDPAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on udp %s: %w", r.config.UDPAddr, err)
+		}
+		closers = append(closers, conn)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.serveUDP(conn)
+		}()
+		log.Printf("Syslog receiver listening on udp %s", r.config.UDPAddr)
+	}
+
+	if r.config.TCPAddr != "" {
+		listener, err := net.Listen("tcp", r.config.TCPAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on tcp %s: %w", r.config.TCPAddr, err)
+		}
+		closers = append(closers, listener)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.serveStream(ctx, listener)
+		}()
+		log.Printf("Syslog receiver listening on tcp %s", r.config.TCPAddr)
+	}
+
+	if r.config.TLSAddr != "" {
+		cert, err := tls.LoadX509KeyPair(r.config.TLSCertFile, r.config.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load syslog TLS certificate: %w", err)
+		}
+		listener, err := tls.Listen("tcp", r.config.TLSAddr, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to listen on tls %s: %w", r.config.TLSAddr, err)
+		}
+		closers = append(closers, listener)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.serveStream(ctx, listener)
+		}()
+		log.Printf("Syslog receiver listening on tls %s", r.config.TLSAddr)
+	}
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		r.runWriter(ctx)
+	}()
+
+	refresh := time.NewTicker(time.Duration(r.config.RefreshSeconds) * time.Second)
+	defer refresh.Stop()
+	retention := time.NewTicker(time.Hour)
+	defer retention.Stop()
+
+	r.applyRetention(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, c := range closers {
+				c.Close()
+			}
+			closers = nil
+			wg.Wait()
+			<-writerDone
+			log.Println("Syslog receiver stopped")
+			return nil
+		case <-refresh.C:
+			if err := r.sources.Refresh(ctx); err != nil {
+				log.Printf("Error refreshing syslog sources: %v", err)
+			}
+			r.logStats()
+		case <-retention.C:
+			r.applyRetention(ctx)
+		}
+	}
+}
+
+// serveUDP reads one message per datagram until the connection is closed
+func (r *Receiver) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("Syslog UDP read error: %v", err)
+			continue
+		}
+		if udpAddr, ok := addr.(*net.UDPAddr); ok {
+			r.handle(buf[:n], udpAddr.IP, time.Now())
+		}
+	}
+}
+
+// serveStream accepts TCP or TLS connections until the listener is closed
+func (r *Receiver) serveStream(ctx context.Context, listener net.Listener) {
+	var conns sync.WaitGroup
+	defer conns.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("Syslog accept error: %v", err)
+			continue
+		}
+
+		conns.Add(1)
+		go func() {
+			defer conns.Done()
+			r.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn reads framed messages from one stream connection. Both RFC 6587
+// framings are accepted: octet counting ("LEN SP MSG") and newline-delimited.
+func (r *Receiver) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	ip := net.IP(nil)
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		ip = tcpAddr.IP
+	}
+
+	reader := bufio.NewReaderSize(conn, maxMessageSize)
+	for {
+		frame, err := readFrame(reader)
+		if len(frame) > 0 && ip != nil {
+			r.handle(frame, ip, time.Now())
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("Syslog stream from %s closed: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+	}
+}
+
+// readFrame returns the next message from a stream
+func readFrame(reader *bufio.Reader) ([]byte, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] >= '1' && first[0] <= '9' {
+		// MSG-LEN has at most as many digits as maxMessageSize
+		header, err := reader.Peek(8)
+		if err != nil && len(header) == 0 {
+			return nil, err
+		}
+		space := bytes.IndexByte(header, ' ')
+		if space < 0 {
+			return nil, fmt.Errorf("invalid octet count %q", header)
+		}
+		length, err := strconv.Atoi(string(header[:space]))
+		if err != nil || length > maxMessageSize {
+			return nil, fmt.Errorf("invalid octet count %q", header[:space])
+		}
+		if _, err := reader.Discard(space + 1); err != nil {
+			return nil, err
+		}
+		frame := make([]byte, length)
+		_, err = io.ReadFull(reader, frame)
+		return frame, err
+	}
+
+	line, err := reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		// Keep the first maxMessageSize bytes and skip the rest of the line
+		frame := append([]byte(nil), line...)
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = reader.ReadSlice('\n')
+		}
+		return frame, err
+	}
+	return append([]byte(nil), line...), err
+}
+
+// handle attributes, parses, filters and queues one raw message
+func (r *Receiver) handle(raw []byte, ip net.IP, received time.Time) {
+	r.received.Add(1)
+
+	src, ambiguous := r.sources.LookupIP(ip)
+	if src == nil {
+		if ambiguous {
+			r.ambiguous.Add(1)
+		} else {
+			r.unknown.Add(1)
+		}
+		return
+	}
+
+	msg, err := Parse(raw, received)
+	if err != nil {
+		r.malformed.Add(1)
+		return
+	}
+
+	if !src.Filter.Allows(msg.Facility, msg.Severity) {
+		r.filtered.Add(1)
+		return
+	}
+
+	record := &models.SyslogMessage{
+		TenantID:       src.TenantID,
+		RouterID:       src.RouterID,
+		SourceIP:       ip.String(),
+		Facility:       msg.Facility,
+		Severity:       msg.Severity,
+		Hostname:       optional(msg.Hostname, 255),
+		AppName:        optional(msg.AppName, 255),
+		ProcID:         optional(msg.ProcID, 128),
+		MsgID:          optional(msg.MsgID, 64),
+		StructuredData: optional(msg.StructuredData, maxMessageSize),
+		Message:        clean(msg.Text, maxMessageSize),
+		DeviceTime:     msg.Timestamp,
+		ReceivedAt:     received,
+	}
+
+	select {
+	case r.queue <- record:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// runWriter drains the queue in batches until ctx is cancelled, then writes
+// whatever is still queued
+func (r *Receiver) runWriter(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.SyslogMessage, 0, r.config.BatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.repo.CreateBatch(ctx, batch); err != nil {
+			log.Printf("Error storing %d syslog messages: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case msg := <-r.queue:
+			batch = append(batch, msg)
+			if len(batch) >= r.config.BatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			for {
+				select {
+				case msg := <-r.queue:
+					batch = append(batch, msg)
+					if len(batch) >= r.config.BatchSize {
+						flush(shutdownCtx)
+					}
+				default:
+					flush(shutdownCtx)
+					return
+				}
+			}
+		}
+	}
+}
+
+// applyRetention deletes messages older than the retention period
+func (r *Receiver) applyRetention(ctx context.Context) {
+	if r.config.RetentionDays <= 0 {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -r.config.RetentionDays)
+	var total int64
+	for {
+		deleted, err := r.repo.DeleteBefore(ctx, cutoff, retentionBatch)
+		if err != nil {
+			log.Printf("Error applying syslog retention: %v", err)
+			return
+		}
+		total += deleted
+		if deleted < retentionBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Syslog retention removed %d messages older than %d days", total, r.config.RetentionDays)
+	}
+}
+
+// logStats reports and resets the receive counters
+func (r *Receiver) logStats() {
+	ingest.LogStats("Syslog", &r.received, "received", []ingest.Count{
+		{Name: "unknown source", Value: &r.unknown},
+		{Name: "ambiguous source", Value: &r.ambiguous},
+		{Name: "malformed", Value: &r.malformed},
+		{Name: "filtered", Value: &r.filtered},
+		{Name: "dropped", Value: &r.dropped},
+	}, fmt.Sprintf("%d known sources", r.sources.Len()))
+}
+
+// clean makes device-supplied text storable: PostgreSQL rejects NUL bytes
+// and invalid UTF-8, and one bad row would fail the whole batch
+func clean(s string, maxLen int) string {
+	s = strings.ToValidUTF8(strings.ReplaceAll(s, "\x00", ""), "\uFFFD")
+	if len(s) > maxLen {
+		s = strings.ToValidUTF8(s[:maxLen], "")
+	}
+	return s
+}
+
+func optional(s string, maxLen int) *string {
+	s = clean(s, maxLen)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
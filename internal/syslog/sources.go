@@ -0,0 +1,118 @@
+package syslog
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/internal/ingest"
+	"github.com/google/uuid"
+)
+
+// Filter is a router's syslog facility/severity selection
+type Filter struct {
+	facilities  map[int]bool // nil accepts every facility
+	maxSeverity int          // Least severe level accepted (7 = debug)
+}
+
+// NewFilter builds a filter from the router's syslog capability settings.
+// facility is a comma-separated list of facility keywords ("*" or empty for
+// all); severity is the least severe level to keep, e.g. "warning" keeps
+// emergency through warning. Unknown keywords do not filter.
+func NewFilter(facility, severity *string) Filter {
+	filter := Filter{maxSeverity: len(severityNames) - 1}
+
+	if severity != nil && *severity != "" {
+		if level, ok := ParseSeverity(*severity); ok {
+			filter.maxSeverity = level
+		}
+	}
+
+	if facility != nil {
+		for _, name := range strings.Split(*facility, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || name == "*" || strings.EqualFold(name, "any") {
+				filter.facilities = nil
+				break
+			}
+			if code, ok := ParseFacility(name); ok {
+				if filter.facilities == nil {
+					filter.facilities = make(map[int]bool)
+				}
+				filter.facilities[code] = true
+			}
+		}
+	}
+
+	return filter
+}
+
+// Allows reports whether a message passes the filter
+func (f Filter) Allows(facility, severity int) bool {
+	if severity > f.maxSeverity {
+		return false
+	}
+	return f.facilities == nil || f.facilities[facility]
+}
+
+// source is a router that is allowed to send syslog
+type source struct {
+	TenantID uuid.UUID
+	RouterID uuid.UUID
+	Name     string
+	Filter   Filter
+}
+
+// SourceMap attributes source addresses to routers with syslog enabled; see
+// ingest.Sources
+type SourceMap struct {
+	db *sql.DB
+	*ingest.Sources[*source]
+}
+
+// NewSourceMap creates an empty source map; call Refresh to load it
+func NewSourceMap(db *sql.DB) *SourceMap {
+	return &SourceMap{db: db, Sources: ingest.NewSources[*source]()}
+}
+
+// Refresh reloads the address to router mapping
+func (m *SourceMap) Refresh(ctx context.Context) error {
+	query := ingest.SourceQuery(`
+		SELECT r.id, r.tenant_id, r.name, rc.syslog_facility, rc.syslog_severity
+		FROM routers r
+		JOIN router_capabilities rc ON r.id = rc.router_id
+		WHERE rc.syslog_enabled = true
+	`)
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	set := ingest.NewSourceSet[*source]()
+	seen := make(map[uuid.UUID]*source)
+	for rows.Next() {
+		var ip string
+		var priority int
+		var facility, severity *string
+		src := &source{}
+		if err := rows.Scan(&ip, &priority, &src.RouterID, &src.TenantID, &src.Name, &facility, &severity); err != nil {
+			return err
+		}
+
+		if existing, ok := seen[src.RouterID]; ok {
+			src = existing
+		} else {
+			src.Filter = NewFilter(facility, severity)
+			seen[src.RouterID] = src
+		}
+		set.Add(ip, priority, src)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	m.Set(set)
+	return nil
+}
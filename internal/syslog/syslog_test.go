@@ -0,0 +1,144 @@
+package syslog
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	raw := `<165>1 2024-03-01T10:15:30.123Z core-01 sshd 4711 ID47 [exampleSDID@32473 iut="3" eventSource="App\]lication"][meta x="1"] ` + "\ufeff" + `Accepted password for admin`
+
+	msg, err := Parse([]byte(raw), time.Now())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if msg.Facility != 20 || msg.Severity != 5 {
+		t.Errorf("priority = %d/%d, want 20/5", msg.Facility, msg.Severity)
+	}
+	if msg.Timestamp == nil || !msg.Timestamp.Equal(time.Date(2024, 3, 1, 10, 15, 30, 123000000, time.UTC)) {
+		t.Errorf("timestamp = %v", msg.Timestamp)
+	}
+	if msg.Hostname != "core-01" || msg.AppName != "sshd" || msg.ProcID != "4711" || msg.MsgID != "ID47" {
+		t.Errorf("header = %q %q %q %q", msg.Hostname, msg.AppName, msg.ProcID, msg.MsgID)
+	}
+	if msg.StructuredData != `[exampleSDID@32473 iut="3" eventSource="App\]lication"][meta x="1"]` {
+		t.Errorf("structured data = %q", msg.StructuredData)
+	}
+	if msg.Text != "Accepted password for admin" {
+		t.Errorf("text = %q", msg.Text)
+	}
+
+	msg, err = Parse([]byte("<14>1 - - - - - -"), time.Now())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if msg.Timestamp != nil || msg.Hostname != "" || msg.StructuredData != "" || msg.Text != "" {
+		t.Errorf("expected all-nil header, got %+v", msg)
+	}
+}
+
+func TestParseRFC3164(t *testing.T) {
+	received := time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		raw      string
+		hostname string
+		appName  string
+		procID   string
+		text     string
+		year     int
+	}{
+		{
+			name:     "mikrotik",
+			raw:      "<30>Dec 31 23:59:58 MikroTik system,info,account user admin logged in via ssh",
+			hostname: "MikroTik",
+			text:     "system,info,account user admin logged in via ssh",
+			year:     2023,
+		},
+		{
+			name:    "cisco",
+			raw:     "<189>123: *Jan  1 00:29:11.123: %SYS-5-CONFIG_I: Configured from console by vty0",
+			appName: "%SYS-5-CONFIG_I",
+			text:    "Configured from console by vty0",
+			year:    2024,
+		},
+		{
+			name:     "tag with pid",
+			raw:      "<38>Jan  1 00:10:00 edge-02 sshd[812]: Connection closed\n",
+			hostname: "edge-02",
+			appName:  "sshd",
+			procID:   "812",
+			text:     "Connection closed",
+			year:     2024,
+		},
+		{
+			name: "no header",
+			raw:  "<13>interface ether1 link down",
+			text: "interface ether1 link down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Parse([]byte(tt.raw), received)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if msg.Hostname != tt.hostname || msg.AppName != tt.appName || msg.ProcID != tt.procID || msg.Text != tt.text {
+				t.Errorf("got host=%q app=%q pid=%q text=%q", msg.Hostname, msg.AppName, msg.ProcID, msg.Text)
+			}
+			if tt.year == 0 {
+				if msg.Timestamp != nil {
+					t.Errorf("expected no timestamp, got %v", msg.Timestamp)
+				}
+			} else if msg.Timestamp == nil || msg.Timestamp.Year() != tt.year {
+				t.Errorf("timestamp = %v, want year %d", msg.Timestamp, tt.year)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, raw := range []string{"", "<999>x", "<abc>x", "<13>1 2024-01-01T00:00:00Z host app"} {
+		if _, err := Parse([]byte(raw), time.Now()); err == nil {
+			t.Errorf("Parse(%q) expected error", raw)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+
+	filter := NewFilter(strPtr("local0, local7"), strPtr("warning"))
+	if !filter.Allows(16, 4) || !filter.Allows(23, 0) {
+		t.Error("expected local0/local7 at warning or above to pass")
+	}
+	if filter.Allows(16, 5) {
+		t.Error("expected notice to be filtered")
+	}
+	if filter.Allows(3, 0) {
+		t.Error("expected daemon facility to be filtered")
+	}
+
+	all := NewFilter(nil, strPtr("*"))
+	if !all.Allows(3, 7) {
+		t.Error("expected empty filter to accept everything")
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	stream := "17 <13>1 - - - - - -<14>legacy line\n11 <13>counted"
+	reader := bufio.NewReader(strings.NewReader(stream))
+
+	expected := []string{"<13>1 - - - - - -", "<14>legacy line\n", "<13>counted"}
+	for _, want := range expected {
+		frame, err := readFrame(reader)
+		if string(frame) != want {
+			t.Fatalf("readFrame() = %q (err %v), want %q", frame, err, want)
+		}
+	}
+}
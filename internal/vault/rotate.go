@@ -0,0 +1,184 @@
+package vault
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Columns lists the encrypted columns of each table. Every table has an id
+// primary key.
+var Columns = map[string][]string{
+	"credential_profiles": {
+		"snmp_community", "snmp_v3_auth_password", "snmp_v3_priv_password",
+		"password", "private_key",
+	},
+	"router_capabilities": {
+		"snmp_community", "snmp_v3_auth_password", "snmp_v3_priv_password",
+		"api_password", "ssh_password", "ssh_private_key", "netconf_password",
+		"radius_secret",
+	},
+	"notification_channels": {"secret"},
+	"routers":               {"snmp_community"},
+}
+
+// TableStatus counts the values of a table by the key they are encrypted
+// with; plaintext values are counted under an empty key ID
+type TableStatus struct {
+	Table  string
+	Values map[string]int
+}
+
+// RotateResult reports the re-encrypted rows and values of a table
+type RotateResult struct {
+	Table  string
+	Rows   int
+	Values int
+}
+
+// Status counts the stored values of every table in Columns
+func Status(ctx context.Context, db *sql.DB) ([]TableStatus, error) {
+	var statuses []TableStatus
+	for _, table := range tables() {
+		status := TableStatus{Table: table, Values: make(map[string]int)}
+		err := scanTable(ctx, db, table, func(id string, values []sql.NullString) {
+			for _, value := range values {
+				if value.Valid && value.String != "" {
+					status.Values[KeyID(value.String)]++
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Rotate encrypts every stored value that is in plaintext or encrypted
+// with an older key with the active key. Each table is rotated in one
+// transaction; with dryRun the transactions are rolled back.
+func Rotate(ctx context.Context, db *sql.DB, v *Vault, dryRun bool) ([]RotateResult, error) {
+	if !v.Enabled() {
+		return nil, ErrNoMasterKey
+	}
+
+	var results []RotateResult
+	for _, table := range tables() {
+		result, err := rotateTable(ctx, db, v, table, dryRun)
+		if err != nil {
+			return results, fmt.Errorf("failed to rotate %s: %w", table, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func rotateTable(ctx context.Context, db *sql.DB, v *Vault, table string, dryRun bool) (RotateResult, error) {
+	result := RotateResult{Table: table}
+	columns := Columns[table]
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	type row struct {
+		id     string
+		values []sql.NullString
+	}
+	var rows []row
+	err = scanTable(ctx, tx, table, func(id string, values []sql.NullString) {
+		rows = append(rows, row{id: id, values: values})
+	})
+	if err != nil {
+		return result, err
+	}
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", column, i+2)
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s WHERE id = $1", table, strings.Join(assignments, ", "))
+
+	for _, r := range rows {
+		changed := 0
+		args := make([]any, len(columns)+1)
+		args[0] = r.id
+		for i, value := range r.values {
+			args[i+1] = value
+			if !value.Valid || !v.NeedsRotation(value.String) {
+				continue
+			}
+			rotated, err := v.Rotate(value.String)
+			if err != nil {
+				return result, fmt.Errorf("row %s column %s: %w", r.id, columns[i], err)
+			}
+			args[i+1] = rotated
+			changed++
+		}
+		if changed == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, update, args...); err != nil {
+			return result, err
+		}
+		result.Rows++
+		result.Values += changed
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	return result, tx.Commit()
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// scanTable calls fn with the id and encrypted column values of every row,
+// locking the rows when q is a transaction
+func scanTable(ctx context.Context, q queryer, table string, fn func(id string, values []sql.NullString)) error {
+	columns := Columns[table]
+	query := fmt.Sprintf("SELECT id, %s FROM %s", strings.Join(columns, ", "), table)
+	if _, ok := q.(*sql.Tx); ok {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns)+1)
+		dest[0] = &id
+		for i := range values {
+			dest[i+1] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		fn(id, values)
+	}
+	return rows.Err()
+}
+
+// tables returns the tables of Columns in a stable order
+func tables() []string {
+	names := make([]string, 0, len(Columns))
+	for table := range Columns {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,276 @@
+// Package vault encrypts router credentials at rest. Every value is sealed
+// with its own AES-256-GCM data key, which is in turn sealed with a master
+// key (envelope encryption). Encrypted values name the master key by ID so
+// that keys can be rotated while older values stay readable.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+)
+
+// prefix marks encrypted values; the format is
+// vault:v1:<key id>:<base64 sealed data key>:<base64 sealed value>
+const prefix = "vault:v1:"
+
+// keySize is the size of master and data keys (AES-256)
+const keySize = 32
+
+var (
+	// ErrNoMasterKey is returned when decrypting without any master key
+	ErrNoMasterKey = errors.New("vault: no master key configured")
+
+	// ErrUnknownKey is returned for values encrypted with a key that is not
+	// configured
+	ErrUnknownKey = errors.New("vault: value encrypted with an unknown key")
+
+	// ErrMalformed is returned for values with the vault prefix that cannot
+	// be parsed or authenticated
+	ErrMalformed = errors.New("vault: malformed encrypted value")
+)
+
+// Vault encrypts with the active master key and decrypts with any
+// configured key. A vault without keys stores new values in plaintext and
+// fails to decrypt encrypted ones.
+type Vault struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// New creates a vault from the configured master key and the previous keys
+// still needed to read values not yet rotated
+func New(cfg config.VaultConfig) (*Vault, error) {
+	master := cfg.MasterKey
+	if master == "" && cfg.MasterKeyFile != "" {
+		data, err := os.ReadFile(cfg.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault master key file: %w", err)
+		}
+		master = strings.TrimSpace(string(data))
+	}
+
+	v := &Vault{keys: make(map[string]cipher.AEAD)}
+	if master == "" {
+		if strings.TrimSpace(cfg.PreviousKeys) != "" {
+			return nil, errors.New("vault: previous keys configured without a master key")
+		}
+		return v, nil
+	}
+
+	id, err := v.addKey(master)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault master key: %w", err)
+	}
+	v.activeID = id
+
+	for _, key := range strings.Split(cfg.PreviousKeys, ",") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if _, err := v.addKey(key); err != nil {
+			return nil, fmt.Errorf("invalid previous vault key: %w", err)
+		}
+	}
+	return v, nil
+}
+
+// GenerateKey returns a new random master key, base64 encoded
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// addKey adds a base64 encoded master key and returns its ID
+func (v *Vault) addKey(encoded string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("not valid base64")
+	}
+	if len(key) != keySize {
+		return "", fmt.Errorf("must be %d bytes, got %d", keySize, len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	id := keyID(key)
+	v.keys[id] = aead
+	return id, nil
+}
+
+// Enabled reports whether a master key is configured
+func (v *Vault) Enabled() bool {
+	return v.activeID != ""
+}
+
+// ActiveKeyID returns the ID of the key new values are encrypted with, or
+// an empty string without a master key
+func (v *Vault) ActiveKeyID() string {
+	return v.activeID
+}
+
+// Encrypt seals a value with a new data key. Empty values and values
+// without a master key are returned unchanged.
+func (v *Vault) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || !v.Enabled() {
+		return plaintext, nil
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+
+	sealedValue, err := seal(dekAEAD, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	// The key ID is authenticated with the data key so that a value cannot
+	// be relabelled with another key's ID
+	sealedKey, err := seal(v.keys[v.activeID], dek, []byte(v.activeID))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + v.activeID + ":" +
+		base64.RawStdEncoding.EncodeToString(sealedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealedValue), nil
+}
+
+// Decrypt opens an encrypted value. Values without the vault prefix were
+// stored before encryption was enabled and are returned unchanged.
+func (v *Vault) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if len(v.keys) == 0 {
+		return "", ErrNoMasterKey
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformed
+	}
+	kek, ok := v.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownKey, parts[0])
+	}
+	sealedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrMalformed
+	}
+	sealedValue, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	dek, err := open(kek, sealedKey, []byte(parts[0]))
+	if err != nil {
+		return "", ErrMalformed
+	}
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := open(dekAEAD, sealedValue, nil)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
+
+// DecryptFields decrypts each non-nil field in place, stopping at the
+// first error
+func (v *Vault) DecryptFields(fields ...*string) error {
+	for _, field := range fields {
+		if field == nil {
+			continue
+		}
+		plaintext, err := v.Decrypt(*field)
+		if err != nil {
+			return err
+		}
+		*field = plaintext
+	}
+	return nil
+}
+
+// NeedsRotation reports whether a value is not yet encrypted with the
+// active key. Empty values never need rotation.
+func (v *Vault) NeedsRotation(value string) bool {
+	return value != "" && v.Enabled() && KeyID(value) != v.activeID
+}
+
+// Rotate re-encrypts a value with the active key
+func (v *Vault) Rotate(value string) (string, error) {
+	plaintext, err := v.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return v.Encrypt(plaintext)
+}
+
+// IsEncrypted reports whether a value carries the vault prefix
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the ID of the key a value is encrypted with, or an empty
+// string for plaintext
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id
+}
+
+// keyID derives a short, stable ID from a master key
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data and prepends the random nonce
+func seal(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, additional), nil
+}
+
+// open decrypts data sealed by seal
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
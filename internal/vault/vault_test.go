@@ -0,0 +1,195 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MohamadKhaledAbbas/ISPVisualMonitor/pkg/config"
+)
+
+func newTestVault(t *testing.T, cfg config.VaultConfig) *Vault {
+	t.Helper()
+	v, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return v
+}
+
+func generateKey(t *testing.T) string {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	v := newTestVault(t, config.VaultConfig{MasterKey: generateKey(t)})
+
+	encrypted, err := v.Encrypt("s3cret community")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEncrypted(encrypted) || strings.Contains(encrypted, "s3cret") {
+		t.Fatalf("value not encrypted: %q", encrypted)
+	}
+	if KeyID(encrypted) != v.ActiveKeyID() {
+		t.Errorf("key ID = %q, want %q", KeyID(encrypted), v.ActiveKeyID())
+	}
+
+	again, _ := v.Encrypt("s3cret community")
+	if again == encrypted {
+		t.Error("encrypting twice produced the same value")
+	}
+
+	plaintext, err := v.Decrypt(encrypted)
+	if err != nil || plaintext != "s3cret community" {
+		t.Fatalf("Decrypt = %q, %v", plaintext, err)
+	}
+
+	empty, _ := v.Encrypt("")
+	if empty != "" {
+		t.Errorf("empty value encrypted to %q", empty)
+	}
+}
+
+func TestPlaintextPassesThrough(t *testing.T) {
+	v := newTestVault(t, config.VaultConfig{MasterKey: generateKey(t)})
+	plaintext, err := v.Decrypt("public")
+	if err != nil || plaintext != "public" {
+		t.Fatalf("Decrypt = %q, %v", plaintext, err)
+	}
+	if !v.NeedsRotation("public") {
+		t.Error("plaintext value does not need rotation")
+	}
+	if v.NeedsRotation("") {
+		t.Error("empty value needs rotation")
+	}
+}
+
+func TestWithoutMasterKey(t *testing.T) {
+	v := newTestVault(t, config.VaultConfig{})
+	if v.Enabled() {
+		t.Fatal("vault without keys is enabled")
+	}
+
+	stored, err := v.Encrypt("public")
+	if err != nil || stored != "public" {
+		t.Fatalf("Encrypt = %q, %v; want plaintext", stored, err)
+	}
+
+	encrypted, _ := newTestVault(t, config.VaultConfig{MasterKey: generateKey(t)}).Encrypt("public")
+	if _, err := v.Decrypt(encrypted); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("Decrypt error = %v, want ErrNoMasterKey", err)
+	}
+
+	if _, err := New(config.VaultConfig{PreviousKeys: generateKey(t)}); err == nil {
+		t.Error("previous keys without a master key accepted")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey := generateKey(t), generateKey(t)
+	old := newTestVault(t, config.VaultConfig{MasterKey: oldKey})
+	encrypted, _ := old.Encrypt("api-password")
+
+	// The new key alone cannot read values of the old one
+	fresh := newTestVault(t, config.VaultConfig{MasterKey: newKey})
+	if _, err := fresh.Decrypt(encrypted); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Decrypt error = %v, want ErrUnknownKey", err)
+	}
+
+	v := newTestVault(t, config.VaultConfig{MasterKey: newKey, PreviousKeys: " " + oldKey + ", "})
+	if !v.NeedsRotation(encrypted) {
+		t.Fatal("value of the previous key does not need rotation")
+	}
+	rotated, err := v.Rotate(encrypted)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if KeyID(rotated) != fresh.ActiveKeyID() || v.NeedsRotation(rotated) {
+		t.Fatalf("rotated value has key %q, want %q", KeyID(rotated), fresh.ActiveKeyID())
+	}
+	if plaintext, err := fresh.Decrypt(rotated); err != nil || plaintext != "api-password" {
+		t.Fatalf("Decrypt rotated = %q, %v", plaintext, err)
+	}
+}
+
+func TestTamperedValues(t *testing.T) {
+	v := newTestVault(t, config.VaultConfig{MasterKey: generateKey(t)})
+	other := newTestVault(t, config.VaultConfig{MasterKey: generateKey(t)})
+	encrypted, _ := v.Encrypt("ssh-password")
+	parts := strings.Split(encrypted, ":")
+
+	tampered := []string{
+		"vault:v1:garbage",
+		strings.Join([]string{"vault", "v1", parts[2], parts[3], flipFirst(parts[4])}, ":"),
+		strings.Join([]string{"vault", "v1", parts[2], parts[3], "!!"}, ":"),
+		// A data key relabelled with another configured key's ID
+		strings.Join([]string{"vault", "v1", other.ActiveKeyID(), parts[3], parts[4]}, ":"),
+	}
+	both := newTestVault(t, config.VaultConfig{MasterKey: generateKey(t)})
+	both.keys[v.ActiveKeyID()] = v.keys[v.ActiveKeyID()]
+	both.keys[other.ActiveKeyID()] = other.keys[other.ActiveKeyID()]
+
+	for _, value := range tampered {
+		if _, err := both.Decrypt(value); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Decrypt(%q) error = %v, want ErrMalformed", value, err)
+		}
+	}
+}
+
+// flipFirst changes the first character of a base64 string
+func flipFirst(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
+
+func TestDecryptFields(t *testing.T) {
+	v := newTestVault(t, config.VaultConfig{MasterKey: generateKey(t)})
+	encrypted, _ := v.Encrypt("community")
+	plain := "legacy"
+	var unset *string
+
+	if err := v.DecryptFields(&encrypted, &plain, unset); err != nil {
+		t.Fatalf("DecryptFields: %v", err)
+	}
+	if encrypted != "community" || plain != "legacy" {
+		t.Errorf("fields = %q, %q", encrypted, plain)
+	}
+}
+
+func TestMasterKeyFile(t *testing.T) {
+	key := generateKey(t)
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fromFile := newTestVault(t, config.VaultConfig{MasterKeyFile: path})
+	fromEnv := newTestVault(t, config.VaultConfig{MasterKey: key})
+	if !fromFile.Enabled() || fromFile.ActiveKeyID() != fromEnv.ActiveKeyID() {
+		t.Errorf("key ID from file = %q, want %q", fromFile.ActiveKeyID(), fromEnv.ActiveKeyID())
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, key := range []string{"not base64!", "c2hvcnQ="} {
+		if _, err := New(config.VaultConfig{MasterKey: key}); err == nil {
+			t.Errorf("key %q accepted", key)
+		}
+	}
+	if _, err := New(config.VaultConfig{MasterKey: generateKey(t), PreviousKeys: "c2hvcnQ="}); err == nil {
+		t.Error("short previous key accepted")
+	}
+	if _, err := New(config.VaultConfig{MasterKeyFile: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("missing key file accepted")
+	}
+}
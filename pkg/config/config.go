@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,17 @@ type Config struct {
 	Database DatabaseConfig
 	Poller   PollerConfig
 	Auth     AuthConfig
+	Backup   ConfigBackupConfig
+	Firmware FirmwareConfig
+	Syslog   SyslogConfig
+	SNMPTrap SNMPTrapConfig
+	Flow     FlowConfig
+	RADIUS   RADIUSConfig
+	Sink     SinkConfig
+	Metrics  MetricsConfig
+	Vault    VaultConfig
+	MIB      MIBConfig
+	Notify   NotifyConfig
 }
 
 // APIConfig holds API server configuration
@@ -42,6 +54,163 @@ type PollerConfig struct {
 	TimeoutSeconds  int
 	RetryAttempts   int
 	ConcurrentPolls int
+
+	// How long an on-demand poll or health check from the API may take;
+	// TimeoutSeconds times RetryAttempts by default, as long as a poll of an
+	// unresponsive router. The API server's write timeout is raised to fit.
+	PollNowTimeoutSeconds int
+
+	// Consecutive failed polls after which a router is unreachable;
+	// failures during maintenance windows are not counted
+	UnreachableAfter int
+
+	// Poll results are written with COPY in batches of WriteBatchSize rows
+	// per table, or every WriteFlushSeconds. Once WriteQueueSize rows are
+	// waiting, result processing and in turn polling slow down.
+	WriteBatchSize    int
+	WriteFlushSeconds int
+	WriteQueueSize    int
+
+	// Directory of SNMP vendor profiles adding to or replacing the built-in
+	// ones; empty uses the built-in profiles only
+	ProfileDir string
+}
+
+// ConfigBackupConfig holds device configuration backup settings
+type ConfigBackupConfig struct {
+	Enabled         bool
+	IntervalMinutes int
+	Workers         int
+	TimeoutSeconds  int
+}
+
+// FirmwareConfig holds firmware inventory settings
+type FirmwareConfig struct {
+	DatasetPath string // Local advisory/EOL JSON file; empty disables matching
+}
+
+// SyslogConfig holds syslog receiver settings
+type SyslogConfig struct {
+	Enabled        bool
+	UDPAddr        string // Empty disables the listener
+	TCPAddr        string
+	TLSAddr        string // RFC 5425; requires TLSCertFile and TLSKeyFile
+	TLSCertFile    string
+	TLSKeyFile     string
+	RetentionDays  int
+	QueueSize      int // Messages buffered before new ones are dropped
+	BatchSize      int
+	RefreshSeconds int // How often the source address map is reloaded
+}
+
+// SNMPTrapConfig holds SNMP trap receiver settings
+type SNMPTrapConfig struct {
+	Enabled        bool
+	Addr           string
+	Community      string // Accepted v1/v2c community for routers without one
+	EngineID       string // Hex engine ID for SNMPv3 informs; empty disables v3
+	DedupSeconds   int    // Identical traps within this window are dropped
+	QueueSize      int
+	RefreshSeconds int // How often the agent address map is reloaded
+}
+
+// FlowConfig holds flow collector settings
+type FlowConfig struct {
+	Enabled        bool
+	NetFlowAddr    string // Default NetFlow listener; ports set on routers are added
+	IPFIXAddr      string // Default IPFIX UDP and TCP listener; empty disables IPFIX
+	IPv4PrefixLen  int    // Addresses are truncated to these prefix lengths
+	IPv6PrefixLen  int
+	MaxRollups     int // Bound on in-memory rollup keys
+	RetentionDays  int
+	RefreshSeconds int // How often the exporter address map is reloaded
+
+	// IPFIX enterprise elements decoded as IANA elements, "PEN:ID=IANA,..."
+	EnterpriseElements string
+
+	// NAT translation events from IPFIX exporters
+	NATRetentionDays int
+	NATQueueSize     int
+	NATBatchSize     int
+
+	// sFlow listener; empty disables sFlow. Counter samples are stored as
+	// interface metrics per SFlowCounters: "fallback" for routers without
+	// SNMP polling, "always" or "off".
+	SFlowAddr            string
+	SFlowCounters        string
+	SFlowCounterInterval int // seconds between stored samples per interface
+
+	// Attribute flows to PPPoE and DHCP subscribers by address
+	SubscriberAccounting bool
+}
+
+// RADIUSConfig holds RADIUS accounting server settings
+type RADIUSConfig struct {
+	Enabled        bool
+	Addr           string
+	Secret         string // Shared secret for routers without one
+	Workers        int
+	QueueSize      int
+	RefreshSeconds int // How often the NAS map is reloaded
+}
+
+// MetricsConfig holds the Prometheus metrics listener settings
+type MetricsConfig struct {
+	Enabled bool
+	Port    int
+}
+
+// VaultConfig holds the master keys router credentials are encrypted with.
+// Without a master key credentials are stored in plaintext.
+type VaultConfig struct {
+	MasterKey     string // Base64 AES-256 key new values are encrypted with
+	MasterKeyFile string // File holding the master key, used when MasterKey is empty
+	PreviousKeys  string // Comma separated base64 keys still read until rotated away
+}
+
+// MIBConfig holds the MIB modules used to name OIDs
+type MIBConfig struct {
+	Dir string // Directory of MIB files; empty knows the SNMPv2-SMI root nodes only
+}
+
+// NotifyConfig holds alert notification delivery settings
+type NotifyConfig struct {
+	Enabled         bool
+	IntervalSeconds int // How often new alerts and due deliveries are picked up
+	TimeoutSeconds  int // Per send attempt
+
+	// A failed delivery is retried after RetrySeconds, doubling per attempt
+	// up to MaxRetrySeconds, and given up after MaxAttempts attempts
+	MaxAttempts     int
+	RetrySeconds    int
+	MaxRetrySeconds int
+
+	RetentionDays int // Delivery log retention
+}
+
+// SinkConfig holds the destinations polling results are written to
+type SinkConfig struct {
+	Sinks []string // postgres, influx, remote_write
+
+	MetricPrefix string // Prefix of metric and measurement names
+	LabelMap     string // source=label pairs; empty uses the default mapping
+
+	// Remote sinks send every FlushSeconds or once BatchSize samples are
+	// queued; results beyond QueueSize waiting to be sent are dropped
+	BatchSize    int
+	FlushSeconds int
+	QueueSize    int
+
+	// The prometheus sink drops routers not polled for this long
+	ExporterStaleSeconds int
+
+	InfluxURL   string // http(s) write URL with query parameters, or udp://host:port
+	InfluxToken string
+
+	RemoteWriteURL         string
+	RemoteWriteBearerToken string
+	RemoteWriteUsername    string
+	RemoteWritePassword    string
 }
 
 // AuthConfig holds authentication configuration
@@ -86,11 +255,16 @@ func Load() (*Config, error) {
 			MinConns: getEnvInt("DB_MIN_CONNS", 5),
 		},
 		Poller: PollerConfig{
-			WorkerCount:     getEnvInt("POLLER_WORKERS", 10),
-			DefaultInterval: getEnvInt("POLLER_INTERVAL", 300),
-			TimeoutSeconds:  getEnvInt("POLLER_TIMEOUT", 30),
-			RetryAttempts:   getEnvInt("POLLER_RETRY", 3),
-			ConcurrentPolls: getEnvInt("POLLER_CONCURRENT", 50),
+			WorkerCount:       getEnvInt("POLLER_WORKERS", 10),
+			DefaultInterval:   getEnvInt("POLLER_INTERVAL", 300),
+			TimeoutSeconds:    getEnvInt("POLLER_TIMEOUT", 30),
+			RetryAttempts:     getEnvInt("POLLER_RETRY", 3),
+			ConcurrentPolls:   getEnvInt("POLLER_CONCURRENT", 50),
+			UnreachableAfter:  getEnvInt("POLLER_UNREACHABLE_AFTER", 3),
+			WriteBatchSize:    getEnvInt("POLLER_WRITE_BATCH_SIZE", 1000),
+			WriteFlushSeconds: getEnvInt("POLLER_WRITE_FLUSH_INTERVAL", 1),
+			WriteQueueSize:    getEnvInt("POLLER_WRITE_QUEUE_SIZE", 20000),
+			ProfileDir:        getEnv("POLLER_PROFILE_DIR", ""),
 		},
 		Auth: AuthConfig{
 			Provider:         getEnv("AUTH_PROVIDER", "local"),
@@ -112,8 +286,103 @@ func Load() (*Config, error) {
 			TokenExpiry:        getEnvInt("TOKEN_EXPIRY_MIN", 60),
 			RefreshTokenExpiry: getEnvInt("REFRESH_TOKEN_EXPIRY_DAYS", 7),
 		},
+		Backup: ConfigBackupConfig{
+			Enabled:         getEnvBool("CONFIG_BACKUP_ENABLED", true),
+			IntervalMinutes: getEnvInt("CONFIG_BACKUP_INTERVAL_MIN", 360),
+			Workers:         getEnvInt("CONFIG_BACKUP_WORKERS", 5),
+			TimeoutSeconds:  getEnvInt("CONFIG_BACKUP_TIMEOUT", 60),
+		},
+		Firmware: FirmwareConfig{
+			DatasetPath: getEnv("FIRMWARE_DATASET_PATH", ""),
+		},
+		Syslog: SyslogConfig{
+			Enabled:        getEnvBool("SYSLOG_ENABLED", false),
+			UDPAddr:        getEnv("SYSLOG_UDP_ADDR", ":514"),
+			TCPAddr:        getEnv("SYSLOG_TCP_ADDR", ":514"),
+			TLSAddr:        getEnv("SYSLOG_TLS_ADDR", ""),
+			TLSCertFile:    getEnv("SYSLOG_TLS_CERT_FILE", ""),
+			TLSKeyFile:     getEnv("SYSLOG_TLS_KEY_FILE", ""),
+			RetentionDays:  getEnvInt("SYSLOG_RETENTION_DAYS", 30),
+			QueueSize:      getEnvInt("SYSLOG_QUEUE_SIZE", 10000),
+			BatchSize:      getEnvInt("SYSLOG_BATCH_SIZE", 500),
+			RefreshSeconds: getEnvInt("SYSLOG_SOURCE_REFRESH", 60),
+		},
+		SNMPTrap: SNMPTrapConfig{
+			Enabled:        getEnvBool("SNMP_TRAP_ENABLED", false),
+			Addr:           getEnv("SNMP_TRAP_ADDR", ":162"),
+			Community:      getEnv("SNMP_TRAP_COMMUNITY", ""),
+			EngineID:       getEnv("SNMP_TRAP_ENGINE_ID", ""),
+			DedupSeconds:   getEnvInt("SNMP_TRAP_DEDUP_SECONDS", 60),
+			QueueSize:      getEnvInt("SNMP_TRAP_QUEUE_SIZE", 5000),
+			RefreshSeconds: getEnvInt("SNMP_TRAP_AGENT_REFRESH", 60),
+		},
+		Flow: FlowConfig{
+			Enabled:              getEnvBool("FLOW_ENABLED", false),
+			NetFlowAddr:          getEnv("FLOW_NETFLOW_ADDR", ":2055"),
+			IPFIXAddr:            getEnv("FLOW_IPFIX_ADDR", ":4739"),
+			IPv4PrefixLen:        getEnvInt("FLOW_IPV4_PREFIX", 24),
+			IPv6PrefixLen:        getEnvInt("FLOW_IPV6_PREFIX", 48),
+			MaxRollups:           getEnvInt("FLOW_MAX_ROLLUPS", 200000),
+			RetentionDays:        getEnvInt("FLOW_RETENTION_DAYS", 30),
+			RefreshSeconds:       getEnvInt("FLOW_EXPORTER_REFRESH", 60),
+			EnterpriseElements:   getEnv("FLOW_IPFIX_ENTERPRISE_ELEMENTS", ""),
+			NATRetentionDays:     getEnvInt("FLOW_NAT_RETENTION_DAYS", 365),
+			NATQueueSize:         getEnvInt("FLOW_NAT_QUEUE_SIZE", 50000),
+			NATBatchSize:         getEnvInt("FLOW_NAT_BATCH_SIZE", 1000),
+			SFlowAddr:            getEnv("FLOW_SFLOW_ADDR", ":6343"),
+			SFlowCounters:        getEnv("FLOW_SFLOW_COUNTERS", "fallback"),
+			SFlowCounterInterval: getEnvInt("FLOW_SFLOW_COUNTER_INTERVAL", 60),
+			SubscriberAccounting: getEnvBool("FLOW_SUBSCRIBER_ACCOUNTING", true),
+		},
+		RADIUS: RADIUSConfig{
+			Enabled:        getEnvBool("RADIUS_ACCT_ENABLED", false),
+			Addr:           getEnv("RADIUS_ACCT_ADDR", ":1813"),
+			Secret:         getEnv("RADIUS_ACCT_SECRET", ""),
+			Workers:        getEnvInt("RADIUS_ACCT_WORKERS", 4),
+			QueueSize:      getEnvInt("RADIUS_ACCT_QUEUE_SIZE", 10000),
+			RefreshSeconds: getEnvInt("RADIUS_ACCT_NAS_REFRESH", 60),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvBool("ENABLE_METRICS", true),
+			Port:    getEnvInt("METRICS_PORT", 9090),
+		},
+		Sink: SinkConfig{
+			Sinks:                  strings.Split(getEnv("RESULT_SINKS", "postgres"), ","),
+			MetricPrefix:           getEnv("SINK_METRIC_PREFIX", "ispmonitor_device"),
+			LabelMap:               getEnv("SINK_LABEL_MAP", ""),
+			BatchSize:              getEnvInt("SINK_BATCH_SIZE", 5000),
+			FlushSeconds:           getEnvInt("SINK_FLUSH_INTERVAL", 10),
+			QueueSize:              getEnvInt("SINK_QUEUE_SIZE", 1000),
+			ExporterStaleSeconds:   getEnvInt("SINK_EXPORTER_STALE_AFTER", 900),
+			InfluxURL:              getEnv("SINK_INFLUX_URL", ""),
+			InfluxToken:            getEnv("SINK_INFLUX_TOKEN", ""),
+			RemoteWriteURL:         getEnv("SINK_REMOTE_WRITE_URL", ""),
+			RemoteWriteBearerToken: getEnv("SINK_REMOTE_WRITE_BEARER_TOKEN", ""),
+			RemoteWriteUsername:    getEnv("SINK_REMOTE_WRITE_USERNAME", ""),
+			RemoteWritePassword:    getEnv("SINK_REMOTE_WRITE_PASSWORD", ""),
+		},
+		Vault: VaultConfig{
+			MasterKey:     getEnv("VAULT_MASTER_KEY", ""),
+			MasterKeyFile: getEnv("VAULT_MASTER_KEY_FILE", ""),
+			PreviousKeys:  getEnv("VAULT_PREVIOUS_KEYS", ""),
+		},
+		MIB: MIBConfig{
+			Dir: getEnv("MIB_DIR", ""),
+		},
+		Notify: NotifyConfig{
+			Enabled:         getEnvBool("NOTIFY_ENABLED", true),
+			IntervalSeconds: getEnvInt("NOTIFY_INTERVAL", 10),
+			TimeoutSeconds:  getEnvInt("NOTIFY_TIMEOUT", 10),
+			MaxAttempts:     getEnvInt("NOTIFY_MAX_ATTEMPTS", 6),
+			RetrySeconds:    getEnvInt("NOTIFY_RETRY_SECONDS", 30),
+			MaxRetrySeconds: getEnvInt("NOTIFY_MAX_RETRY_SECONDS", 3600),
+			RetentionDays:   getEnvInt("NOTIFY_RETENTION_DAYS", 90),
+		},
 	}
 
+	cfg.Poller.PollNowTimeoutSeconds = getEnvInt("POLLER_POLL_NOW_TIMEOUT",
+		cfg.Poller.TimeoutSeconds*max(cfg.Poller.RetryAttempts, 1))
+
 	// Validate required fields
 	if cfg.API.JWTSecret == "change-me-in-production" {
 		return nil, fmt.Errorf("JWT_SECRET must be set in production")
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alert rule types
+const (
+	AlertRuleThreshold    = "threshold"      // Value beyond threshold_value for condition.for
+	AlertRuleRateOfChange = "rate_of_change" // Change over condition.window beyond threshold_value
+	AlertRuleAbsence      = "absence"        // No data for condition.window
+)
+
+// Alert rule and alert target types
+const (
+	AlertTargetRouter    = "router"
+	AlertTargetInterface = "interface"
+	AlertTargetRole      = "role"
+	AlertTargetPOP       = "pop"
+)
+
+// AlertRule raises alerts from polled metrics. It applies to the router or
+// interface TargetID, the routers holding role TargetID or those of POP
+// TargetID; without a target it applies to every router of the tenant.
+// Condition is the JSON condition evaluated for RuleType, e.g.
+// {"metric": "cpu_percent", "operator": ">", "for": "5m"}.
+type AlertRule struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	TenantID       uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	Name           string     `json:"name" db:"name"`
+	Description    *string    `json:"description,omitempty" db:"description"`
+	RuleType       string     `json:"rule_type" db:"rule_type"`
+	TargetType     *string    `json:"target_type,omitempty" db:"target_type"`
+	TargetID       *uuid.UUID `json:"target_id,omitempty" db:"target_id"`
+	Condition      string     `json:"condition" db:"condition"` // JSONB as string
+	ThresholdValue *float64   `json:"threshold_value,omitempty" db:"threshold_value"`
+	Severity       string     `json:"severity" db:"severity"`
+	Enabled        bool       `json:"enabled" db:"enabled"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// MetricPoint is a stored value of a router metric
+type MetricPoint struct {
+	RouterID  uuid.UUID `json:"router_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
@@ -6,7 +6,8 @@ import (
 	"github.com/google/uuid"
 )
 
-// RouterCapabilities represents all connection methods and credentials for a router
+// RouterCapabilities represents all connection methods and credentials for a router.
+// Credentials are never serialized; they are stored encrypted by the vault.
 type RouterCapabilities struct {
 	ID       uuid.UUID `json:"id" db:"id"`
 	RouterID uuid.UUID `json:"router_id" db:"router_id"`
@@ -33,6 +34,9 @@ type RouterCapabilities struct {
 	// IPFIX Capability (passive)
 	IPFIX *IPFIXCapability `json:"ipfix,omitempty"`
 
+	// RADIUS Accounting Capability (passive)
+	RADIUS *RADIUSCapability `json:"radius,omitempty"`
+
 	// Connection preferences
 	PreferredMethod string   `json:"preferred_method" db:"preferred_method"` // api, snmp, ssh, netconf
 	FallbackOrder   []string `json:"fallback_order" db:"fallback_order"`     // Order of methods to try
@@ -49,8 +53,8 @@ type RouterCapabilities struct {
 // SNMPCapability represents SNMP connection details
 type SNMPCapability struct {
 	Enabled        bool    `json:"enabled" db:"snmp_enabled"`
-	Version        string  `json:"version" db:"snmp_version"`               // v1, v2c, v3
-	Community      *string `json:"community,omitempty" db:"snmp_community"` // For v1/v2c (sensitive)
+	Version        string  `json:"version" db:"snmp_version"` // v1, v2c, v3
+	Community      *string `json:"-" db:"snmp_community"`     // For v1/v2c (sensitive)
 	Port           int     `json:"port" db:"snmp_port"`
 	TimeoutSeconds int     `json:"timeout_seconds" db:"snmp_timeout_seconds"`
 	Retries        int     `json:"retries" db:"snmp_retries"`
@@ -58,9 +62,9 @@ type SNMPCapability struct {
 	// SNMPv3 specific
 	V3Username     *string `json:"v3_username,omitempty" db:"snmp_v3_username"`
 	V3AuthProtocol *string `json:"v3_auth_protocol,omitempty" db:"snmp_v3_auth_protocol"` // MD5, SHA, SHA-256, etc.
-	V3AuthPassword *string `json:"v3_auth_password,omitempty" db:"snmp_v3_auth_password"` // (sensitive)
+	V3AuthPassword *string `json:"-" db:"snmp_v3_auth_password"`                          // (sensitive)
 	V3PrivProtocol *string `json:"v3_priv_protocol,omitempty" db:"snmp_v3_priv_protocol"` // DES, AES, AES-256
-	V3PrivPassword *string `json:"v3_priv_password,omitempty" db:"snmp_v3_priv_password"` // (sensitive)
+	V3PrivPassword *string `json:"-" db:"snmp_v3_priv_password"`                          // (sensitive)
 }
 
 // APICapability represents vendor-specific API connection details
@@ -70,7 +74,7 @@ type APICapability struct {
 	Endpoint       string `json:"endpoint" db:"api_endpoint"`
 	Port           *int   `json:"port,omitempty" db:"api_port"`
 	Username       string `json:"username" db:"api_username"`
-	Password       string `json:"password" db:"api_password"` // (sensitive)
+	Password       string `json:"-" db:"api_password"` // (sensitive)
 	UseTLS         bool   `json:"use_tls" db:"api_use_tls"`
 	VerifyCert     bool   `json:"verify_cert" db:"api_verify_cert"`
 	TimeoutSeconds int    `json:"timeout_seconds" db:"api_timeout_seconds"`
@@ -82,8 +86,8 @@ type SSHCapability struct {
 	Host           string  `json:"host" db:"ssh_host"`
 	Port           int     `json:"port" db:"ssh_port"`
 	Username       string  `json:"username" db:"ssh_username"`
-	Password       *string `json:"password,omitempty" db:"ssh_password"`       // (sensitive)
-	PrivateKey     *string `json:"private_key,omitempty" db:"ssh_private_key"` // (sensitive)
+	Password       *string `json:"-" db:"ssh_password"`    // (sensitive)
+	PrivateKey     *string `json:"-" db:"ssh_private_key"` // (sensitive)
 	TimeoutSeconds int     `json:"timeout_seconds" db:"ssh_timeout_seconds"`
 }
 
@@ -92,7 +96,7 @@ type NETCONFCapability struct {
 	Enabled  bool   `json:"enabled" db:"netconf_enabled"`
 	Port     int    `json:"port" db:"netconf_port"`
 	Username string `json:"username" db:"netconf_username"`
-	Password string `json:"password" db:"netconf_password"` // (sensitive)
+	Password string `json:"-" db:"netconf_password"` // (sensitive)
 }
 
 // SyslogCapability represents syslog receiver configuration
@@ -115,6 +119,13 @@ type IPFIXCapability struct {
 	Port    int  `json:"port" db:"ipfix_port"`
 }
 
+// RADIUSCapability represents RADIUS accounting sent by the router as a NAS
+type RADIUSCapability struct {
+	Enabled       bool    `json:"enabled" db:"radius_acct_enabled"`
+	Secret        *string `json:"-" db:"radius_secret"` // (sensitive)
+	NASIdentifier *string `json:"nas_identifier,omitempty" db:"radius_nas_identifier"`
+}
+
 // GetEnabledMethods returns a list of enabled connection methods
 func (rc *RouterCapabilities) GetEnabledMethods() []string {
 	methods := []string{}
@@ -140,6 +151,9 @@ func (rc *RouterCapabilities) GetEnabledMethods() []string {
 	if rc.IPFIX != nil && rc.IPFIX.Enabled {
 		methods = append(methods, "ipfix")
 	}
+	if rc.RADIUS != nil && rc.RADIUS.Enabled {
+		methods = append(methods, "radius")
+	}
 
 	return methods
 }
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CompliancePolicy is a tenant-defined golden template that stored
+// configurations are checked against
+type CompliancePolicy struct {
+	ID           uuid.UUID                      `json:"id" db:"id"`
+	TenantID     uuid.UUID                      `json:"tenant_id" db:"tenant_id"`
+	Name         string                         `json:"name" db:"name"`
+	Description  *string                        `json:"description,omitempty" db:"description"`
+	Rules        []ComplianceRule               `json:"rules" db:"rules"`                 // JSONB
+	RoleSections map[string][]ComplianceSection `json:"role_sections" db:"role_sections"` // JSONB, keyed by RouterRole.Code
+	IsActive     bool                           `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time                      `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time                      `json:"updated_at" db:"updated_at"`
+}
+
+// ComplianceRule is a single line-level check applied to every router
+type ComplianceRule struct {
+	Type        string `json:"type"`    // required_line, forbidden_pattern
+	Pattern     string `json:"pattern"` // Literal line, or regular expression
+	Regex       bool   `json:"regex,omitempty"`
+	Description string `json:"description,omitempty"`
+	Severity    string `json:"severity,omitempty"` // critical, warning, info
+}
+
+// ComplianceSection is a templated block of lines that must appear, in order,
+// in the configuration of routers holding the role it is keyed by
+type ComplianceSection struct {
+	Name     string `json:"name"`
+	Template string `json:"template"` // text/template; see compliance.TemplateData
+	Severity string `json:"severity,omitempty"`
+}
+
+// ComplianceViolation describes one failed check
+type ComplianceViolation struct {
+	PolicyID     uuid.UUID `json:"policy_id"`
+	PolicyName   string    `json:"policy_name"`
+	Type         string    `json:"type"`
+	Check        string    `json:"check"` // Rule pattern or section name
+	Severity     string    `json:"severity"`
+	Message      string    `json:"message"`
+	Line         int       `json:"line,omitempty"`          // Offending line number for forbidden patterns
+	Content      string    `json:"content,omitempty"`       // Offending line for forbidden patterns
+	MissingLines []string  `json:"missing_lines,omitempty"` // Missing lines for required sections
+}
+
+// ComplianceResult is the latest evaluation of one policy against one router
+type ComplianceResult struct {
+	ID            uuid.UUID             `json:"id" db:"id"`
+	TenantID      uuid.UUID             `json:"tenant_id" db:"tenant_id"`
+	RouterID      uuid.UUID             `json:"router_id" db:"router_id"`
+	PolicyID      uuid.UUID             `json:"policy_id" db:"policy_id"`
+	ConfigVersion int                   `json:"config_version" db:"config_version"`
+	Score         float64               `json:"score" db:"score"`
+	PassedChecks  int                   `json:"passed_checks" db:"passed_checks"`
+	TotalChecks   int                   `json:"total_checks" db:"total_checks"`
+	Violations    []ComplianceViolation `json:"violations" db:"violations"` // JSONB
+	EvaluatedAt   time.Time             `json:"evaluated_at" db:"evaluated_at"`
+
+	// Expanded fields (from joins)
+	RouterName string   `json:"router_name,omitempty" db:"-"`
+	PolicyName string   `json:"policy_name,omitempty" db:"-"`
+	RoleCodes  []string `json:"role_codes,omitempty" db:"-"`
+}
+
+// Compliance check types
+const (
+	ComplianceRequiredLine     = "required_line"
+	ComplianceForbiddenPattern = "forbidden_pattern"
+	ComplianceRequiredSection  = "required_section"
+)
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConfigVersion represents one stored version of a router's configuration
+type ConfigVersion struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	TenantID         uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	RouterID         uuid.UUID `json:"router_id" db:"router_id"`
+	Version          int       `json:"version" db:"version"`
+	Content          string    `json:"content,omitempty" db:"content"` // Secrets are masked before storage
+	ContentHash      string    `json:"content_hash" db:"content_hash"`
+	SizeBytes        int       `json:"size_bytes" db:"size_bytes"`
+	CollectionMethod string    `json:"collection_method" db:"collection_method"` // ssh, mikrotik_api
+	LinesAdded       int       `json:"lines_added" db:"lines_added"`
+	LinesRemoved     int       `json:"lines_removed" db:"lines_removed"`
+	CollectedAt      time.Time `json:"collected_at" db:"collected_at"`
+	LastVerifiedAt   time.Time `json:"last_verified_at" db:"last_verified_at"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// Config collection methods
+const (
+	ConfigCollectionSSH         = "ssh"
+	ConfigCollectionMikroTikAPI = "mikrotik_api"
+)
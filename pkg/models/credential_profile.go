@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Connection methods a credential profile can hold credentials for
+const (
+	CredentialMethodSNMP = "snmp"
+	CredentialMethodAPI  = "api"
+	CredentialMethodSSH  = "ssh"
+)
+
+// CredentialProfile represents named credentials of one method shared by
+// routers. Routers reference a profile per method; credentials set on a
+// router's capabilities override the profile value by value. Secrets are
+// stored encrypted and never serialized.
+type CredentialProfile struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	TenantID    uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	Name        string    `json:"name" db:"name"`
+	Method      string    `json:"method" db:"method"` // snmp, api, ssh
+	Description *string   `json:"description,omitempty" db:"description"`
+
+	// SNMP
+	SNMPVersion        *string `json:"snmp_version,omitempty" db:"snmp_version"`
+	SNMPCommunity      *string `json:"-" db:"snmp_community"` // (sensitive)
+	SNMPV3AuthProtocol *string `json:"snmp_v3_auth_protocol,omitempty" db:"snmp_v3_auth_protocol"`
+	SNMPV3AuthPassword *string `json:"-" db:"snmp_v3_auth_password"` // (sensitive)
+	SNMPV3PrivProtocol *string `json:"snmp_v3_priv_protocol,omitempty" db:"snmp_v3_priv_protocol"`
+	SNMPV3PrivPassword *string `json:"-" db:"snmp_v3_priv_password"` // (sensitive)
+
+	// API, SSH and SNMPv3 user
+	Username   *string `json:"username,omitempty" db:"username"`
+	Password   *string `json:"-" db:"password"`    // (sensitive)
+	PrivateKey *string `json:"-" db:"private_key"` // SSH only (sensitive)
+
+	RouterCount int        `json:"router_count" db:"-"` // Routers referencing the profile
+	RotatedAt   *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
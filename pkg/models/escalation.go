@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reasons an alert escalation stopped
+const (
+	EscalationAcknowledged = "acknowledged"
+	EscalationResolved     = "resolved"
+	EscalationExhausted    = "exhausted" // Every tier and repeat paged
+	EscalationDisabled     = "disabled"  // Policy disabled meanwhile
+)
+
+// OnCallSchedule represents a rotation of users on call. Participants take
+// turns in order, each for RotationDays, handing over at the wall clock
+// time of RotationStart in Timezone; nobody is on call before
+// RotationStart. Coverage is the JSON list of weekly hours the schedule is
+// on call, e.g. [{"days": ["mon"], "start": "09:00", "end": "17:00"}]; an
+// empty list covers every hour.
+type OnCallSchedule struct {
+	ID            uuid.UUID   `json:"id" db:"id"`
+	TenantID      uuid.UUID   `json:"tenant_id" db:"tenant_id"`
+	Name          string      `json:"name" db:"name"`
+	Description   *string     `json:"description,omitempty" db:"description"`
+	Timezone      string      `json:"timezone" db:"timezone"`
+	Participants  []uuid.UUID `json:"participants" db:"participants"`
+	RotationStart time.Time   `json:"rotation_start" db:"rotation_start"`
+	RotationDays  int         `json:"rotation_days" db:"rotation_days"`
+	Coverage      string      `json:"coverage" db:"coverage"` // JSONB as string
+	CreatedBy     *uuid.UUID  `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// OnCallOverride puts a user on call for a schedule from StartsAt to
+// EndsAt, in place of the rotation and regardless of coverage hours. The
+// latest created wins where overrides overlap.
+type OnCallOverride struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	TenantID   uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	ScheduleID uuid.UUID  `json:"schedule_id" db:"schedule_id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	StartsAt   time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time  `json:"ends_at" db:"ends_at"`
+	Note       *string    `json:"note,omitempty" db:"note"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// EscalationPolicy pages the alerts matching all of its filters tier after
+// tier until they are acknowledged or resolved; an empty filter matches
+// every alert. Tiers is the JSON list of tiers, each paging its targets and
+// escalating to the next after escalate_after_minutes. After the last tier
+// the policy starts over RepeatCount times.
+type EscalationPolicy struct {
+	ID          uuid.UUID   `json:"id" db:"id"`
+	TenantID    uuid.UUID   `json:"tenant_id" db:"tenant_id"`
+	Name        string      `json:"name" db:"name"`
+	Description *string     `json:"description,omitempty" db:"description"`
+	Severities  []string    `json:"severities" db:"severities"`
+	RoleCodes   []string    `json:"role_codes" db:"role_codes"`
+	POPIDs      []uuid.UUID `json:"pop_ids" db:"pop_ids"`
+	Tiers       string      `json:"tiers" db:"tiers"` // JSONB as string
+	RepeatCount int         `json:"repeat_count" db:"repeat_count"`
+	Enabled     bool        `json:"enabled" db:"enabled"`
+	CreatedBy   *uuid.UUID  `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// AlertEscalation is the progress of an alert through a policy. Tier is
+// the index of the next tier to page at NextEscalationAt; Repeats counts
+// the passes started over.
+type AlertEscalation struct {
+	AlertID          uuid.UUID  `json:"alert_id" db:"alert_id"`
+	PolicyID         uuid.UUID  `json:"policy_id" db:"policy_id"`
+	TenantID         uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	Tier             int        `json:"tier" db:"tier"`
+	Repeats          int        `json:"repeats" db:"repeats"`
+	NextEscalationAt *time.Time `json:"next_escalation_at,omitempty" db:"next_escalation_at"`
+	StoppedAt        *time.Time `json:"stopped_at,omitempty" db:"stopped_at"`
+	StopReason       *string    `json:"stop_reason,omitempty" db:"stop_reason"` // acknowledged, resolved, exhausted, disabled
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceEvent represents a discrete event that happened on a device
+type DeviceEvent struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	TenantID   uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	RouterID   *uuid.UUID `json:"router_id,omitempty" db:"router_id"`
+	EventType  string     `json:"event_type" db:"event_type"`
+	Severity   string     `json:"severity" db:"severity"`
+	Source     string     `json:"source" db:"source"`
+	Message    string     `json:"message" db:"message"`
+	Metadata   *string    `json:"metadata,omitempty" db:"metadata"` // JSONB as string
+	OccurredAt time.Time  `json:"occurred_at" db:"occurred_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Device event types
+const (
+	EventTypeConfigChange = "config_change"
+	EventTypeColdStart    = "cold_start"
+	EventTypeWarmStart    = "warm_start"
+	EventTypeLinkDown     = "link_down"
+	EventTypeLinkUp       = "link_up"
+	EventTypeAuthFailure  = "auth_failure"
+	EventTypeBGPDown      = "bgp_down"
+	EventTypeBGPUp        = "bgp_up"
+	EventTypeSNMPTrap     = "snmp_trap" // Other vendor or enterprise traps
+)
+
+// Device event sources
+const (
+	EventSourceConfigBackup = "config_backup"
+	EventSourceSNMPTrap     = "snmp_trap"
+	EventSourcePoller       = "poller"
+	EventSourceAlertRule    = "alert_rule"
+)
+
+// Event severity constants
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
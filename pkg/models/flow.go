@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FlowRollup represents traffic aggregated over one minute for a router
+type FlowRollup struct {
+	TenantID      uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	RouterID      uuid.UUID `json:"router_id" db:"router_id"`
+	Bucket        time.Time `json:"bucket" db:"bucket"`
+	InputIfIndex  int       `json:"input_if_index" db:"input_if_index"`
+	OutputIfIndex int       `json:"output_if_index" db:"output_if_index"`
+	Protocol      int       `json:"protocol" db:"protocol"`
+	SrcPrefix     string    `json:"src_prefix" db:"src_prefix"`
+	DstPrefix     string    `json:"dst_prefix" db:"dst_prefix"`
+	SrcAS         int64     `json:"src_as" db:"src_as"`
+	DstAS         int64     `json:"dst_as" db:"dst_as"`
+	Bytes         int64     `json:"bytes" db:"bytes"`
+	Packets       int64     `json:"packets" db:"packets"`
+	Flows         int64     `json:"flows" db:"flows"`
+}
+
+// FlowTotal is traffic summed over one key of a top-N query
+type FlowTotal struct {
+	Key     string `json:"key"`
+	Bytes   int64  `json:"bytes"`
+	Packets int64  `json:"packets"`
+	Flows   int64  `json:"flows"`
+}
+
+// FlowMatrixCell is traffic summed between one source and one destination
+type FlowMatrixCell struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Bytes       int64  `json:"bytes"`
+	Packets     int64  `json:"packets"`
+	Flows       int64  `json:"flows"`
+}
+
+// NATEvent represents a NAT translation reported by a router
+type NATEvent struct {
+	ID                int64     `json:"id" db:"id"`
+	TenantID          uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	RouterID          uuid.UUID `json:"router_id" db:"router_id"`
+	EventTime         time.Time `json:"event_time" db:"event_time"`
+	NATEvent          int       `json:"nat_event" db:"nat_event"`
+	Protocol          int       `json:"protocol" db:"protocol"`
+	SrcAddress        *string   `json:"src_address,omitempty" db:"src_address"`
+	SrcPort           *int      `json:"src_port,omitempty" db:"src_port"`
+	DstAddress        *string   `json:"dst_address,omitempty" db:"dst_address"`
+	DstPort           *int      `json:"dst_port,omitempty" db:"dst_port"`
+	PostNATSrcAddress *string   `json:"post_nat_src_address,omitempty" db:"post_nat_src_address"`
+	PostNATSrcPort    *int      `json:"post_nat_src_port,omitempty" db:"post_nat_src_port"`
+	PostNATDstAddress *string   `json:"post_nat_dst_address,omitempty" db:"post_nat_dst_address"`
+	PostNATDstPort    *int      `json:"post_nat_dst_port,omitempty" db:"post_nat_dst_port"`
+	ReceivedAt        time.Time `json:"received_at" db:"received_at"`
+
+	// Joined field
+	RouterName string `json:"router_name,omitempty" db:"-"`
+}
+
+// Subscriber types of subscriber usage
+const (
+	SubscriberTypePPPoE = "pppoe"
+	SubscriberTypeDHCP  = "dhcp"
+)
+
+// SubscriberUsage represents one subscriber's traffic over one minute seen by
+// a router. Bytes in are downloaded by the subscriber, bytes out uploaded.
+type SubscriberUsage struct {
+	TenantID       uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	RouterID       uuid.UUID `json:"router_id" db:"router_id"`
+	Bucket         time.Time `json:"bucket" db:"bucket"`
+	Subscriber     string    `json:"subscriber" db:"subscriber"` // PPPoE username or DHCP MAC address
+	SubscriberType string    `json:"subscriber_type" db:"subscriber_type"`
+	BytesIn        int64     `json:"bytes_in" db:"bytes_in"`
+	BytesOut       int64     `json:"bytes_out" db:"bytes_out"`
+	PacketsIn      int64     `json:"packets_in" db:"packets_in"`
+	PacketsOut     int64     `json:"packets_out" db:"packets_out"`
+	Flows          int64     `json:"flows" db:"flows"`
+}
+
+// SubscriberAppUsage represents one subscriber's traffic to one application
+// port over one hour
+type SubscriberAppUsage struct {
+	TenantID       uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	RouterID       uuid.UUID `json:"router_id" db:"router_id"`
+	Bucket         time.Time `json:"bucket" db:"bucket"`
+	Subscriber     string    `json:"subscriber" db:"subscriber"`
+	SubscriberType string    `json:"subscriber_type" db:"subscriber_type"`
+	Protocol       int       `json:"protocol" db:"protocol"`
+	Port           int       `json:"port" db:"port"` // Remote port; 0 for ephemeral ports
+	BytesIn        int64     `json:"bytes_in" db:"bytes_in"`
+	BytesOut       int64     `json:"bytes_out" db:"bytes_out"`
+	Flows          int64     `json:"flows" db:"flows"`
+}
+
+// SubscriberTotal is one subscriber's traffic summed over a time range
+type SubscriberTotal struct {
+	Subscriber     string `json:"subscriber"`
+	SubscriberType string `json:"subscriber_type"`
+	BytesIn        int64  `json:"bytes_in"`
+	BytesOut       int64  `json:"bytes_out"`
+	PacketsIn      int64  `json:"packets_in"`
+	PacketsOut     int64  `json:"packets_out"`
+	Flows          int64  `json:"flows"`
+}
+
+// SubscriberUsagePoint is a subscriber's traffic in one interval of a series
+type SubscriberUsagePoint struct {
+	Bucket   time.Time `json:"bucket"`
+	BytesIn  int64     `json:"bytes_in"`
+	BytesOut int64     `json:"bytes_out"`
+}
+
+// ApplicationTotal is traffic to one protocol and port summed over a time
+// range
+type ApplicationTotal struct {
+	Protocol int   `json:"protocol"`
+	Port     int   `json:"port"`
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+	Flows    int64 `json:"flows"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaintenanceWindow represents planned maintenance on a set of routers.
+// A one-off window runs from StartsAt to EndsAt. A recurring window runs
+// for DurationMinutes from each start of Recurrence, a cron expression or
+// RRULE evaluated in Timezone from StartsAt until EndsAt, if set.
+//
+// The window covers the routers listed and those in the listed POPs,
+// regions (with their sub-regions) or holding the listed roles; a window
+// with no scope covers every router of the tenant. While it is active,
+// failed polls do not make covered routers unreachable and their alerts
+// are raised suppressed; with PausePolling they are not polled at all.
+type MaintenanceWindow struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	TenantID        uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	Name            string     `json:"name" db:"name"`
+	Description     *string    `json:"description,omitempty" db:"description"`
+	StartsAt        time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	Recurrence      *string    `json:"recurrence,omitempty" db:"recurrence"` // cron or RRULE
+	DurationMinutes *int       `json:"duration_minutes,omitempty" db:"duration_minutes"`
+	Timezone        string     `json:"timezone" db:"timezone"`
+
+	// Scope
+	RouterIDs []uuid.UUID `json:"router_ids" db:"router_ids"`
+	POPIDs    []uuid.UUID `json:"pop_ids" db:"pop_ids"`
+	RegionIDs []uuid.UUID `json:"region_ids" db:"region_ids"`
+	RoleCodes []string    `json:"role_codes" db:"role_codes"`
+
+	PausePolling bool       `json:"pause_polling" db:"pause_polling"`
+	Enabled      bool       `json:"enabled" db:"enabled"`
+	CreatedBy    *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsRecurring reports whether the window repeats
+func (w *MaintenanceWindow) IsRecurring() bool {
+	return w.Recurrence != nil && *w.Recurrence != ""
+}
+
+// HasScope reports whether the window is limited to some routers rather
+// than covering the whole tenant
+func (w *MaintenanceWindow) HasScope() bool {
+	return len(w.RouterIDs) > 0 || len(w.POPIDs) > 0 || len(w.RegionIDs) > 0 || len(w.RoleCodes) > 0
+}
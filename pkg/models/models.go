@@ -104,6 +104,24 @@ type Interface struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// InterfaceMetric is one interface counter sample. Counts are the deltas
+// over the interval ending at Timestamp.
+type InterfaceMetric struct {
+	ID                 int64     `json:"id" db:"id"`
+	TenantID           uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	InterfaceID        uuid.UUID `json:"interface_id" db:"interface_id"`
+	Timestamp          time.Time `json:"timestamp" db:"timestamp"`
+	InOctets           int64     `json:"in_octets" db:"in_octets"`
+	OutOctets          int64     `json:"out_octets" db:"out_octets"`
+	InPackets          int64     `json:"in_packets" db:"in_packets"`
+	OutPackets         int64     `json:"out_packets" db:"out_packets"`
+	InErrors           int64     `json:"in_errors" db:"in_errors"`
+	OutErrors          int64     `json:"out_errors" db:"out_errors"`
+	InDiscards         int64     `json:"in_discards" db:"in_discards"`
+	OutDiscards        int64     `json:"out_discards" db:"out_discards"`
+	UtilizationPercent *float64  `json:"utilization_percent,omitempty" db:"utilization_percent"`
+}
+
 // Link represents a connection between two interfaces
 type Link struct {
 	ID                uuid.UUID `json:"id" db:"id"`
@@ -152,5 +170,10 @@ type Alert struct {
 	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
 	AcknowledgedBy *uuid.UUID `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
 	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
-	Metadata       *string    `json:"metadata,omitempty" db:"metadata"` // JSONB as string
+	Metadata       *string    `json:"metadata,omitempty" db:"metadata"`   // JSONB as string
+	DedupKey       *string    `json:"dedup_key,omitempty" db:"dedup_key"` // Condition key of event-driven alerts
+
+	// Raised while its target was in maintenance
+	Suppressed          bool       `json:"suppressed" db:"suppressed"`
+	MaintenanceWindowID *uuid.UUID `json:"maintenance_window_id,omitempty" db:"maintenance_window_id"`
 }
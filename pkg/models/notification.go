@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification channel types
+const (
+	ChannelEmail    = "email"    // SMTP
+	ChannelWebhook  = "webhook"  // JSON POST signed with HMAC-SHA256
+	ChannelSlack    = "slack"    // Slack or Mattermost incoming webhook
+	ChannelTelegram = "telegram" // Telegram bot
+)
+
+// Notification events, one firing and resolved delivery per alert and
+// channel and one escalation delivery per tier target paged
+const (
+	NotificationFiring     = "firing"
+	NotificationResolved   = "resolved"
+	NotificationTest       = "test"
+	NotificationEscalation = "escalation"
+)
+
+// Notification delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySent      = "sent"
+	DeliveryFailed    = "failed"    // Given up after the last attempt
+	DeliveryCancelled = "cancelled" // Escalation of an alert acknowledged before the send
+)
+
+// NotificationChannel represents a destination for alert notifications.
+// Config holds the settings of the channel type as JSON; Secret holds the
+// SMTP password, webhook HMAC key, incoming webhook URL or bot token and is
+// stored encrypted and never serialized. Nil templates use the defaults.
+type NotificationChannel struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	TenantID      uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	Name          string     `json:"name" db:"name"`
+	ChannelType   string     `json:"channel_type" db:"channel_type"` // email, webhook, slack, telegram
+	Config        string     `json:"config" db:"config"`             // JSONB as string
+	Secret        *string    `json:"-" db:"secret"`                  // (sensitive)
+	TitleTemplate *string    `json:"title_template,omitempty" db:"title_template"`
+	BodyTemplate  *string    `json:"body_template,omitempty" db:"body_template"`
+	Enabled       bool       `json:"enabled" db:"enabled"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationRoute sends the alerts matching all of its filters to a
+// channel; an empty filter matches every alert. Role and POP filters match
+// the router an alert targets, directly or through one of its interfaces.
+type NotificationRoute struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	TenantID       uuid.UUID   `json:"tenant_id" db:"tenant_id"`
+	ChannelID      uuid.UUID   `json:"channel_id" db:"channel_id"`
+	Name           string      `json:"name" db:"name"`
+	Severities     []string    `json:"severities" db:"severities"`
+	RoleCodes      []string    `json:"role_codes" db:"role_codes"`
+	POPIDs         []uuid.UUID `json:"pop_ids" db:"pop_ids"`
+	NotifyResolved bool        `json:"notify_resolved" db:"notify_resolved"`
+	Enabled        bool        `json:"enabled" db:"enabled"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationDelivery is the log entry of one alert event sent, or being
+// retried, to one channel. Test sends have no alert; escalation deliveries
+// record the tier paged, from 1, and the user paged through the channel.
+type NotificationDelivery struct {
+	ID            int64      `json:"id" db:"id"`
+	TenantID      uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	ChannelID     uuid.UUID  `json:"channel_id" db:"channel_id"`
+	AlertID       *uuid.UUID `json:"alert_id,omitempty" db:"alert_id"`
+	Event         string     `json:"event" db:"event"`   // firing, resolved, test, escalation
+	Status        string     `json:"status" db:"status"` // pending, sent, failed, cancelled
+	Tier          *int       `json:"tier,omitempty" db:"tier"`
+	RecipientID   *uuid.UUID `json:"recipient_id,omitempty" db:"recipient_id"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastError     *string    `json:"last_error,omitempty" db:"last_error"`
+	SentAt        *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -84,6 +84,16 @@ type RoleSpecificMetric struct {
 	CreatedAt time.Time              `json:"created_at" db:"created_at"`
 }
 
+// RoleMetricPoint is one role-specific metric of a router aggregated over
+// one interval of a series
+type RoleMetricPoint struct {
+	RouterID   uuid.UUID `json:"router_id"`
+	RouterName string    `json:"router_name"`
+	Metric     string    `json:"metric"`
+	Bucket     time.Time `json:"bucket"`
+	Value      float64   `json:"value"`
+}
+
 // PollingHistory tracks polling attempts and their results
 type PollingHistory struct {
 	ID               int64      `json:"id" db:"id"`
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyslogMessage represents a syslog message received from a router
+type SyslogMessage struct {
+	ID             int64      `json:"id" db:"id"`
+	TenantID       uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	RouterID       uuid.UUID  `json:"router_id" db:"router_id"`
+	SourceIP       string     `json:"source_ip" db:"source_ip"`
+	Facility       int        `json:"facility" db:"facility"`
+	Severity       int        `json:"severity" db:"severity"`
+	Hostname       *string    `json:"hostname,omitempty" db:"hostname"`
+	AppName        *string    `json:"app_name,omitempty" db:"app_name"`
+	ProcID         *string    `json:"proc_id,omitempty" db:"proc_id"`
+	MsgID          *string    `json:"msg_id,omitempty" db:"msg_id"`
+	StructuredData *string    `json:"structured_data,omitempty" db:"structured_data"`
+	Message        string     `json:"message" db:"message"`
+	DeviceTime     *time.Time `json:"device_time,omitempty" db:"device_time"`
+	ReceivedAt     time.Time  `json:"received_at" db:"received_at"`
+
+	// Joined field
+	RouterName string `json:"router_name,omitempty" db:"-"`
+}